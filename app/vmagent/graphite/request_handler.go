@@ -26,6 +26,15 @@ func InsertHandler(r io.Reader) error {
 	})
 }
 
+// PickleInsertHandler processes remote write for graphite pickle protocol.
+//
+// See https://graphite.readthedocs.io/en/latest/feeding-carbon.html#the-pickle-protocol
+func PickleInsertHandler(r io.Reader) error {
+	return stream.ParsePickle(r, func(rows []parser.Row) error {
+		return insertRows(nil, rows)
+	})
+}
+
 func insertRows(at *auth.Token, rows []parser.Row) error {
 	ctx := common.GetPushCtx()
 	defer common.PutPushCtx(ctx)
@@ -26,6 +26,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/prometheusimport"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/promremotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/remotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/statsd"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/vmimport"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/buildinfo"
@@ -34,11 +35,14 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/envflag"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/influxutil"
 	graphiteserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/graphite"
 	influxserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/influx"
+	opentelemetryserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentelemetry"
 	opentsdbserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentsdb"
 	opentsdbhttpserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentsdbhttp"
+	statsdserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/statsd"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape"
@@ -65,6 +69,10 @@ var (
 		"See also -graphiteListenAddr.useProxyProtocol")
 	graphiteUseProxyProtocol = flag.Bool("graphiteListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted at -graphiteListenAddr . "+
 		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
+	graphitePickleListenAddr = flag.String("graphitePickleListenAddr", "", "TCP address to listen for Graphite pickle protocol data. Usually :2004 must be set. Doesn't work if empty. "+
+		"See https://docs.victoriametrics.com/victoriametrics/integrations/graphite/#pickle-protocol . See also -graphitePickleListenAddr.useProxyProtocol")
+	graphitePickleUseProxyProtocol = flag.Bool("graphitePickleListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted at -graphitePickleListenAddr . "+
+		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
 	opentsdbListenAddr = flag.String("opentsdbListenAddr", "", "TCP and UDP address to listen for OpenTSDB metrics. "+
 		"Telnet put messages and HTTP /api/put messages are simultaneously served on TCP port. "+
 		"Usually :4242 must be set. Doesn't work if empty. See also -opentsdbListenAddr.useProxyProtocol")
@@ -74,21 +82,35 @@ var (
 		"See also -opentsdbHTTPListenAddr.useProxyProtocol")
 	opentsdbHTTPUseProxyProtocol = flag.Bool("opentsdbHTTPListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted "+
 		"at -opentsdbHTTPListenAddr . See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
+	opentelemetryGRPCListenAddr = flag.String("opentelemetry.grpcListenAddr", "", "TCP address to listen for OpenTelemetry metrics via OTLP/gRPC protocol. Usually :4317 must be set. Doesn't work if empty. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#opentelemetry-grpc-listener . See also -opentelemetry.grpcListenAddr.useProxyProtocol")
+	opentelemetryGRPCUseProxyProtocol = flag.Bool("opentelemetry.grpcListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted "+
+		"at -opentelemetry.grpcListenAddr . See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
+	statsdListenAddr = flag.String("statsdListenAddr", "", "TCP and UDP address to listen for StatsD plaintext data. Usually :8125 must be set. Doesn't work if empty. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#statsd-listener . See also -statsdListenAddr.useProxyProtocol")
+	statsdUseProxyProtocol = flag.Bool("statsdListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted at -statsdListenAddr . "+
+		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
 	configAuthKey = flagutil.NewPassword("configAuthKey", "Authorization key for accessing /config page. It must be passed via authKey query arg. It overrides -httpAuth.*")
 	reloadAuthKey = flagutil.NewPassword("reloadAuthKey", "Auth key for /-/reload http endpoint. It must be passed via authKey query arg. It overrides -httpAuth.*")
 	dryRun        = flag.Bool("dryRun", false, "Whether to check config files without running vmagent. The following files are checked: "+
 		"-promscrape.config, -remoteWrite.relabelConfig, -remoteWrite.urlRelabelConfig, -remoteWrite.streamAggr.config . "+
 		"Unknown config entries aren't allowed in -promscrape.config by default. This can be changed by passing -promscrape.config.strictParse=false command-line flag")
+	testScrapeAuthKey = flagutil.NewPassword("testScrapeAuthKey", "Auth key for scraping arbitrary urls via /test-scrape http endpoint. It must be passed via authKey query arg. "+
+		"It overrides -httpAuth.* . Scraping arbitrary urls via /test-scrape is disabled if this flag isn't set. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#on-demand-scrapes")
 	maxLabelsPerTimeseries = flag.Int("maxLabelsPerTimeseries", 0, "The maximum number of labels per time series to be accepted. Series with superfluous labels are ignored. In this case the vm_rows_ignored_total{reason=\"too_many_labels\"} metric at /metrics page is incremented")
 	maxLabelNameLen        = flag.Int("maxLabelNameLen", 0, "The maximum length of label names in the accepted time series. Series with longer label name are ignored. In this case the vm_rows_ignored_total{reason=\"too_long_label_name\"} metric at /metrics page is incremented")
 	maxLabelValueLen       = flag.Int("maxLabelValueLen", 0, "The maximum length of label values in the accepted time series. Series with longer label value are ignored. In this case the vm_rows_ignored_total{reason=\"too_long_label_value\"} metric at /metrics page is incremented")
 )
 
 var (
-	influxServer       *influxserver.Server
-	graphiteServer     *graphiteserver.Server
-	opentsdbServer     *opentsdbserver.Server
-	opentsdbhttpServer *opentsdbhttpserver.Server
+	influxServer         *influxserver.Server
+	graphiteServer       *graphiteserver.Server
+	graphitePickleServer *graphiteserver.PickleServer
+	opentsdbServer       *opentsdbserver.Server
+	opentsdbhttpServer   *opentsdbhttpserver.Server
+	opentelemetryServer  *opentelemetryserver.Server
+	statsdServer         *statsdserver.Server
 )
 
 var (
@@ -154,6 +176,9 @@ func main() {
 	if len(*graphiteListenAddr) > 0 {
 		graphiteServer = graphiteserver.MustStart(*graphiteListenAddr, *graphiteUseProxyProtocol, graphite.InsertHandler)
 	}
+	if len(*graphitePickleListenAddr) > 0 {
+		graphitePickleServer = graphiteserver.MustStartPickle(*graphitePickleListenAddr, *graphitePickleUseProxyProtocol, graphite.PickleInsertHandler)
+	}
 	if len(*opentsdbListenAddr) > 0 {
 		httpInsertHandler := getOpenTSDBHTTPInsertHandler()
 		opentsdbServer = opentsdbserver.MustStart(*opentsdbListenAddr, *opentsdbUseProxyProtocol, opentsdb.InsertHandler, httpInsertHandler)
@@ -162,7 +187,18 @@ func main() {
 		httpInsertHandler := getOpenTSDBHTTPInsertHandler()
 		opentsdbhttpServer = opentsdbhttpserver.MustStart(*opentsdbHTTPListenAddr, *opentsdbHTTPUseProxyProtocol, httpInsertHandler)
 	}
+	if len(*opentelemetryGRPCListenAddr) > 0 {
+		opentelemetryServer = opentelemetryserver.MustStart(*opentelemetryGRPCListenAddr, *opentelemetryGRPCUseProxyProtocol, opentelemetry.GRPCInsertHandler)
+	}
+	if len(*statsdListenAddr) > 0 {
+		statsd.Init()
+		statsdServer = statsdserver.MustStart(*statsdListenAddr, *statsdUseProxyProtocol, statsd.InsertHandler)
+	}
 
+	if remotewrite.IsOTLPEnabled() {
+		// Metric types and units from scrape responses are needed for sending data via OpenTelemetry protocol.
+		promscrape.EnableMetadata()
+	}
 	promscrape.Init(remotewrite.PushDropSamplesOnFailure)
 
 	go httpserver.Serve(listenAddrs, requestHandler, httpserver.ServeOptions{
@@ -191,12 +227,22 @@ func main() {
 	if len(*graphiteListenAddr) > 0 {
 		graphiteServer.MustStop()
 	}
+	if len(*graphitePickleListenAddr) > 0 {
+		graphitePickleServer.MustStop()
+	}
 	if len(*opentsdbListenAddr) > 0 {
 		opentsdbServer.MustStop()
 	}
 	if len(*opentsdbHTTPListenAddr) > 0 {
 		opentsdbhttpServer.MustStop()
 	}
+	if len(*opentelemetryGRPCListenAddr) > 0 {
+		opentelemetryServer.MustStop()
+	}
+	if len(*statsdListenAddr) > 0 {
+		statsdServer.MustStop()
+		statsd.MustStop()
+	}
 	protoparserutil.StopUnmarshalWorkers()
 	remotewrite.Stop()
 
@@ -251,6 +297,7 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 			{"service-discovery", "labels before and after relabeling for discovered targets"},
 			{"metric-relabel-debug", "debug metric relabeling"},
 			{"api/v1/targets", "advanced information about discovered targets in JSON format"},
+			{"test-scrape", "on-demand scrape of the given target or url"},
 			{"config", "-promscrape.config contents"},
 			{"metrics", "available service metrics"},
 			{"flags", "command-line flags"},
@@ -448,7 +495,26 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 		// https://prometheus.io/docs/prometheus/latest/querying/api/#targets
 		state := r.FormValue("state")
 		scrapePool := r.FormValue("scrapePool")
-		promscrape.WriteAPIV1Targets(w, state, scrapePool)
+		withHistory := httputil.GetBool(r, "history")
+		promscrape.WriteAPIV1Targets(w, state, scrapePool, withHistory)
+		return true
+	case "/prometheus/test-scrape", "/test-scrape":
+		promscrapeTestScrapeRequests.Inc()
+		if promscrape.IsAdHocTestScrape(r) {
+			if testScrapeAuthKey.Get() == "" {
+				promscrapeTestScrapeErrors.Inc()
+				httpserver.Errorf(w, r, "scraping arbitrary urls via /test-scrape is disabled; set -testScrapeAuthKey command-line flag for enabling it; "+
+					"see https://docs.victoriametrics.com/victoriametrics/vmagent/#on-demand-scrapes")
+				return true
+			}
+			if !httpserver.CheckAuthFlag(w, r, testScrapeAuthKey) {
+				return true
+			}
+		}
+		if err := promscrape.WriteTestScrape(w, r); err != nil {
+			promscrapeTestScrapeErrors.Inc()
+			httpserver.Errorf(w, r, "%s", err)
+		}
 		return true
 	case "/prometheus/target_response", "/target_response":
 		promscrapeTargetResponseRequests.Inc()
@@ -744,6 +810,9 @@ var (
 	promscrapeTargetResponseRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/target_response"}`)
 	promscrapeTargetResponseErrors   = metrics.NewCounter(`vmagent_http_request_errors_total{path="/target_response"}`)
 
+	promscrapeTestScrapeRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/test-scrape"}`)
+	promscrapeTestScrapeErrors   = metrics.NewCounter(`vmagent_http_request_errors_total{path="/test-scrape"}`)
+
 	promscrapeConfigRequests       = metrics.NewCounter(`vmagent_http_requests_total{path="/config"}`)
 	promscrapeStatusConfigRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/api/v1/status/config"}`)
 
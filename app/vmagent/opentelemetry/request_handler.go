@@ -41,6 +41,16 @@ func InsertHandler(at *auth.Token, req *http.Request) error {
 	})
 }
 
+// GRPCInsertHandler processes OpenTelemetry metrics received via OTLP/gRPC.
+//
+// The tenant from at is used only if -enableMultitenantHandlers is set.
+func GRPCInsertHandler(at *auth.Token, extraLabels []prompbmarshal.Label, tss []prompbmarshal.TimeSeries) error {
+	if !remotewrite.MultitenancyEnabled() {
+		at = nil
+	}
+	return insertRows(at, tss, extraLabels)
+}
+
 func insertRows(at *auth.Token, tss []prompbmarshal.TimeSeries, extraLabels []prompbmarshal.Label) error {
 	ctx := common.GetPushCtx()
 	defer common.PutPushCtx(ctx)
@@ -0,0 +1,166 @@
+package remotewrite
+
+import (
+	"flag"
+	"math"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+var (
+	adaptiveQueues = flag.Bool("remoteWrite.adaptiveQueues", false, "Whether to automatically adjust the number of concurrent queues to each -remoteWrite.url "+
+		"between -remoteWrite.adaptiveQueues.min and -remoteWrite.adaptiveQueues.max depending on the send latency, the ingestion rate and the amount of pending data. "+
+		"-remoteWrite.queues is used as the initial number of queues in this case. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#adaptive-remote-write-queues")
+	adaptiveQueuesMin = flag.Int("remoteWrite.adaptiveQueues.min", 1, "The minimum number of concurrent queues to each -remoteWrite.url when -remoteWrite.adaptiveQueues is set")
+	adaptiveQueuesMax = flag.Int("remoteWrite.adaptiveQueues.max", maxQueues, "The maximum number of concurrent queues to each -remoteWrite.url when -remoteWrite.adaptiveQueues is set. "+
+		"Default value depends on the number of available CPU cores")
+	adaptiveQueuesUpdateInterval = flag.Duration("remoteWrite.adaptiveQueues.updateInterval", 10*time.Second, "How often to re-calculate the number of concurrent queues "+
+		"to each -remoteWrite.url when -remoteWrite.adaptiveQueues is set")
+)
+
+// initAdaptiveQueues validates -remoteWrite.adaptiveQueues.* flags.
+//
+// It must be called after -remoteWrite.queues is validated.
+func initAdaptiveQueues() {
+	if !*adaptiveQueues {
+		return
+	}
+	if *adaptiveQueuesMax > maxQueues {
+		*adaptiveQueuesMax = maxQueues
+	}
+	if *adaptiveQueuesMin <= 0 {
+		*adaptiveQueuesMin = 1
+	}
+	if *adaptiveQueuesMin > *adaptiveQueuesMax {
+		logger.Fatalf("-remoteWrite.adaptiveQueues.min=%d cannot exceed -remoteWrite.adaptiveQueues.max=%d", *adaptiveQueuesMin, *adaptiveQueuesMax)
+	}
+	if *adaptiveQueuesUpdateInterval < time.Second {
+		logger.Fatalf("-remoteWrite.adaptiveQueues.updateInterval=%s cannot be smaller than 1s", *adaptiveQueuesUpdateInterval)
+	}
+	if *queues < *adaptiveQueuesMin {
+		*queues = *adaptiveQueuesMin
+	}
+	if *queues > *adaptiveQueuesMax {
+		*queues = *adaptiveQueuesMax
+	}
+}
+
+// getMaxConcurrency returns the maximum number of concurrent queues to every -remoteWrite.url.
+func getMaxConcurrency() int {
+	if *adaptiveQueues {
+		return *adaptiveQueuesMax
+	}
+	return *queues
+}
+
+// queuesStats contains the client stats used for calculating the desired number of queues.
+type queuesStats struct {
+	// bytesSent is the number of bytes sent to remote storage.
+	bytesSent uint64
+
+	// sendDuration is the total duration in seconds spent on sending the data to remote storage by all the queues.
+	sendDuration float64
+
+	// errors is the number of failed requests to remote storage.
+	errors uint64
+
+	// rateLimitReached is the number of times -remoteWrite.rateLimit has been reached.
+	rateLimitReached uint64
+
+	// pendingBytes is the number of pending bytes in the queue.
+	pendingBytes uint64
+}
+
+func (c *client) getQueuesStats() queuesStats {
+	return queuesStats{
+		bytesSent:        c.bytesSent.Get(),
+		sendDuration:     c.sendDuration.Get(),
+		errors:           c.errorsCount.Get(),
+		rateLimitReached: c.rateLimitReached.Get(),
+		pendingBytes:     c.fq.GetPendingBytes(),
+	}
+}
+
+func (c *client) runQueuesScaler(minQueues, maxQueues int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := c.getQueuesStats()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+		}
+		cur := c.getQueuesStats()
+		current := c.getWorkersCount()
+		desired := calculateDesiredQueues(current, minQueues, maxQueues, &prev, &cur, interval)
+		prev = cur
+		if desired == current {
+			continue
+		}
+		logger.Infof("changing the number of queues for -remoteWrite.url=%q from %d to %d; pending data: %d bytes", c.sanitizedURL, current, desired, cur.pendingBytes)
+		c.setWorkersCount(desired)
+	}
+}
+
+// The share of the pending data, which must be sent per each adaptive queues update interval in addition to the ingested data.
+//
+// This is the same integral gain as Prometheus uses for shards autoscaling.
+const pendingDataGain = 0.1
+
+// The number of queues isn't changed if the desired number of queues differs from the current number by less than this ratio.
+//
+// This prevents from frequent changes of the number of queues.
+const queuesTolerance = 0.3
+
+// calculateDesiredQueues returns the desired number of queues in the range [minQueues ... maxQueues]
+// based on the stats collected at the beginning (prev) and at the end (cur) of the given interval.
+//
+// The desired number of queues is the number of concurrent senders, which can send the ingested data
+// plus a share of the pending data during the interval with the measured per-byte send latency.
+func calculateDesiredQueues(current, minQueues, maxQueues int, prev, cur *queuesStats, interval time.Duration) int {
+	desired := current
+	bytesSent := float64(cur.bytesSent - prev.bytesSent)
+	sendDuration := cur.sendDuration - prev.sendDuration
+	pendingBytes := float64(cur.pendingBytes)
+	secs := interval.Seconds()
+
+	// The ingested data is either sent or added to the pending data.
+	bytesIngested := bytesSent + pendingBytes - float64(prev.pendingBytes)
+	if bytesIngested < 0 {
+		bytesIngested = 0
+	}
+	switch {
+	case bytesSent > 0 && sendDuration > 0:
+		durationPerByte := sendDuration / bytesSent
+		bytesToSendPerSecond := bytesIngested/secs + pendingDataGain*pendingBytes/secs
+		d := durationPerByte * bytesToSendPerSecond
+		lowerBound := float64(current) * (1 - queuesTolerance)
+		upperBound := float64(current) * (1 + queuesTolerance)
+		if d < lowerBound || d > upperBound {
+			desired = int(math.Ceil(d))
+		}
+	case bytesIngested == 0 && pendingBytes == 0:
+		// There is no data to send.
+		desired = minQueues
+	default:
+		// The data is pending, but nothing has been sent during the interval, so the send latency is unknown.
+		// Keep the current number of queues, since the remote storage may be temporarily unavailable.
+	}
+
+	if desired > current && (cur.errors > prev.errors || cur.rateLimitReached > prev.rateLimitReached) {
+		// Do not increase the number of queues if the remote storage returns errors or if the rate limit is reached,
+		// since additional queues cannot help in this case, while they may increase the load on the remote storage.
+		desired = current
+	}
+	if desired < minQueues {
+		desired = minQueues
+	}
+	if desired > maxQueues {
+		desired = maxQueues
+	}
+	return desired
+}
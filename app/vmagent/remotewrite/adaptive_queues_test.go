@@ -0,0 +1,103 @@
+package remotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
+)
+
+func TestCalculateDesiredQueues(t *testing.T) {
+	f := func(current, minQueues, maxQueues int, prev, cur queuesStats, resultExpected int) {
+		t.Helper()
+		result := calculateDesiredQueues(current, minQueues, maxQueues, &prev, &cur, 10*time.Second)
+		if result != resultExpected {
+			t.Fatalf("unexpected number of queues; got %d; want %d", result, resultExpected)
+		}
+	}
+
+	// 1MB is sent during 10 seconds with 40 seconds of total send duration, so 4 queues are needed.
+	prev := queuesStats{
+		bytesSent:    1000,
+		sendDuration: 100,
+	}
+	cur := queuesStats{
+		bytesSent:    1000 + 1e6,
+		sendDuration: 140,
+	}
+	f(4, 1, 100, prev, cur, 4)
+	f(2, 1, 100, prev, cur, 4)
+	f(16, 1, 100, prev, cur, 4)
+
+	// The number of queues isn't changed if the desired number is close to the current number.
+	f(5, 1, 100, prev, cur, 5)
+
+	// The number of queues is limited by minQueues and maxQueues.
+	f(16, 8, 100, prev, cur, 8)
+	f(1, 1, 3, prev, cur, 3)
+
+	// Additional queues are needed for sending the pending data.
+	prevPending := prev
+	prevPending.pendingBytes = 1e7
+	curPending := cur
+	curPending.pendingBytes = 1e7
+	f(4, 1, 100, prevPending, curPending, 8)
+	f(4, 1, 6, prevPending, curPending, 6)
+
+	// The growing pending data increases the ingestion rate.
+	curPending.pendingBytes = 1.2e7
+	f(4, 1, 100, prevPending, curPending, 17)
+
+	// The number of queues isn't increased on errors.
+	curErrors := curPending
+	curErrors.errors = 1
+	f(4, 1, 100, prevPending, curErrors, 4)
+
+	// The number of queues isn't increased when the rate limit is reached.
+	curRateLimited := curPending
+	curRateLimited.rateLimitReached = 1
+	f(4, 1, 100, prevPending, curRateLimited, 4)
+
+	// The number of queues may be decreased on errors.
+	curErrors = cur
+	curErrors.sendDuration = 110
+	curErrors.errors = 1
+	f(4, 1, 100, prev, curErrors, 1)
+
+	// Nothing is sent while there is pending data.
+	curStalled := prevPending
+	curStalled.pendingBytes = 2e7
+	f(4, 1, 100, prevPending, curStalled, 4)
+
+	// There is no data to send.
+	f(4, 2, 100, prev, prev, 2)
+}
+
+func TestClientSetWorkersCount(t *testing.T) {
+	path := "adaptive-queues-test"
+	fs.MustRemoveAll(path)
+	defer fs.MustRemoveAll(path)
+
+	fq := persistentqueue.MustOpenFastQueue(path, "foobar", 10, 0, false)
+	c := &client{
+		fq:     fq,
+		stopCh: make(chan struct{}),
+	}
+	f := func(n int) {
+		t.Helper()
+		c.setWorkersCount(n)
+		if workersCount := c.getWorkersCount(); workersCount != n {
+			t.Fatalf("unexpected number of workers; got %d; want %d", workersCount, n)
+		}
+	}
+	f(4)
+	f(8)
+	f(1)
+	f(3)
+
+	fq.UnblockAllReaders()
+	close(c.stopCh)
+	c.wg.Wait()
+	fq.MustClose()
+}
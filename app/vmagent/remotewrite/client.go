@@ -93,6 +93,13 @@ type client struct {
 	useVMProto          atomic.Bool
 	canDowngradeVMProto atomic.Bool
 
+	// Whether to use OpenTelemetry protocol for sending the data to remoteWriteURL.
+	// See https://docs.victoriametrics.com/victoriametrics/vmagent/#sending-data-via-opentelemetry-protocol
+	useOTLP bool
+
+	// otlpResourceLabels contains labels, which are sent as resource attributes via OpenTelemetry protocol.
+	otlpResourceLabels []string
+
 	fq *persistentqueue.FastQueue
 	hc *http.Client
 
@@ -105,6 +112,12 @@ type client struct {
 
 	rl *ratelimiter.RateLimiter
 
+	// workersMu protects workerStopChs.
+	workersMu sync.Mutex
+
+	// workerStopChs contains stop channels for the running workers.
+	workerStopChs []chan struct{}
+
 	bytesSent       *metrics.Counter
 	blocksSent      *metrics.Counter
 	requestDuration *metrics.Histogram
@@ -115,6 +128,11 @@ type client struct {
 	retriesCount    *metrics.Counter
 	sendDuration    *metrics.FloatCounter
 
+	rateLimitReached *metrics.Counter
+
+	// health tracks the health of remote storage for failover groups.
+	health clientHealth
+
 	wg     sync.WaitGroup
 	stopCh chan struct{}
 }
@@ -164,15 +182,23 @@ func newHTTPClient(argIdx int, remoteWriteURL, sanitizedURL string, fq *persiste
 	}
 	c.sendBlock = c.sendBlockHTTP
 
+	if otlpEnabled.GetOptionalArg(argIdx) {
+		c.useOTLP = true
+		c.otlpResourceLabels = getOTLPResourceLabels(argIdx)
+	}
+
 	useVMProto := forceVMProto.GetOptionalArg(argIdx)
 	usePromProto := forcePromProto.GetOptionalArg(argIdx)
 	if useVMProto && usePromProto {
 		logger.Fatalf("-remoteWrite.useVMProto and -remoteWrite.usePromProto cannot be set simultaneously for -remoteWrite.url=%s", sanitizedURL)
 	}
 	if !useVMProto && !usePromProto {
-		// The VM protocol could be downgraded later at runtime if unsupported media type response status is received.
 		useVMProto = true
-		c.canDowngradeVMProto.Store(true)
+		if !c.useOTLP {
+			// The VM protocol could be downgraded later at runtime if unsupported media type response status is received.
+			// This isn't needed for OpenTelemetry protocol, since blocks are converted before sending them to remote storage.
+			c.canDowngradeVMProto.Store(true)
+		}
 	}
 	c.useVMProto.Store(useVMProto)
 
@@ -180,10 +206,10 @@ func newHTTPClient(argIdx int, remoteWriteURL, sanitizedURL string, fq *persiste
 }
 
 func (c *client) init(argIdx, concurrency int, sanitizedURL string) {
-	limitReached := metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_rate_limit_reached_total{url=%q}`, c.sanitizedURL))
+	c.rateLimitReached = metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_rate_limit_reached_total{url=%q}`, c.sanitizedURL))
 	if bytesPerSec := rateLimit.GetOptionalArg(argIdx); bytesPerSec > 0 {
 		logger.Infof("applying %d bytes per second rate limit for -remoteWrite.url=%q", bytesPerSec, sanitizedURL)
-		c.rl = ratelimiter.New(int64(bytesPerSec), limitReached, c.stopCh)
+		c.rl = ratelimiter.New(int64(bytesPerSec), c.rateLimitReached, c.stopCh)
 	}
 	c.bytesSent = metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_bytes_sent_total{url=%q}`, c.sanitizedURL))
 	c.blocksSent = metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_blocks_sent_total{url=%q}`, c.sanitizedURL))
@@ -197,18 +223,51 @@ func (c *client) init(argIdx, concurrency int, sanitizedURL string) {
 	c.retriesCount = metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_retries_count_total{url=%q}`, c.sanitizedURL))
 	c.sendDuration = metrics.GetOrCreateFloatCounter(fmt.Sprintf(`vmagent_remotewrite_send_duration_seconds_total{url=%q}`, c.sanitizedURL))
 	metrics.GetOrCreateGauge(fmt.Sprintf(`vmagent_remotewrite_queues{url=%q}`, c.sanitizedURL), func() float64 {
-		return float64(*queues)
+		return float64(c.getWorkersCount())
 	})
-	for i := 0; i < concurrency; i++ {
+	c.health.init()
+	c.setWorkersCount(concurrency)
+	if *adaptiveQueues {
 		c.wg.Add(1)
 		go func() {
 			defer c.wg.Done()
-			c.runWorker()
+			c.runQueuesScaler(*adaptiveQueuesMin, *adaptiveQueuesMax, *adaptiveQueuesUpdateInterval)
 		}()
 	}
 	logger.Infof("initialized client for -remoteWrite.url=%q", c.sanitizedURL)
 }
 
+// getWorkersCount returns the number of running workers at c.
+func (c *client) getWorkersCount() int {
+	c.workersMu.Lock()
+	n := len(c.workerStopChs)
+	c.workersMu.Unlock()
+	return n
+}
+
+// setWorkersCount starts or stops workers at c, so the number of running workers becomes equal to n.
+//
+// The stopped worker finishes sending the current block before exiting.
+func (c *client) setWorkersCount(n int) {
+	c.workersMu.Lock()
+	defer c.workersMu.Unlock()
+
+	for len(c.workerStopChs) < n {
+		workerStopCh := make(chan struct{})
+		c.workerStopChs = append(c.workerStopChs, workerStopCh)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.runWorker(workerStopCh)
+		}()
+	}
+	for len(c.workerStopChs) > n {
+		lastIdx := len(c.workerStopChs) - 1
+		close(c.workerStopChs[lastIdx])
+		c.workerStopChs = c.workerStopChs[:lastIdx]
+	}
+}
+
 func (c *client) MustStop() {
 	close(c.stopCh)
 	c.wg.Wait()
@@ -296,11 +355,17 @@ func getAWSAPIConfig(argIdx int) (*awsapi.Config, error) {
 	return cfg, nil
 }
 
-func (c *client) runWorker() {
+func (c *client) runWorker(workerStopCh <-chan struct{}) {
 	var ok bool
 	var block []byte
 	ch := make(chan bool, 1)
 	for {
+		select {
+		case <-workerStopCh:
+			// The number of workers has been decreased.
+			return
+		default:
+		}
 		block, ok = c.fq.MustReadBlock(block[:0])
 		if !ok {
 			return
@@ -382,7 +447,9 @@ func (c *client) newRequest(url string, body []byte) (*http.Request, error) {
 	h := req.Header
 	h.Set("User-Agent", "vmagent")
 	h.Set("Content-Type", "application/x-protobuf")
-	if encoding.IsZstd(body) {
+	if c.useOTLP {
+		h.Set("Content-Encoding", "gzip")
+	} else if encoding.IsZstd(body) {
 		h.Set("Content-Encoding", "zstd")
 		h.Set("X-VictoriaMetrics-Remote-Write-Version", "1")
 	} else {
@@ -403,6 +470,15 @@ func (c *client) newRequest(url string, body []byte) (*http.Request, error) {
 // The function returns false only if c.stopCh is closed.
 // Otherwise, it tries sending the block to remote storage indefinitely.
 func (c *client) sendBlockHTTP(block []byte) bool {
+	if c.useOTLP {
+		otlpBlock, err := appendOTLPRequest(nil, block, c.otlpResourceLabels)
+		if err != nil {
+			logger.Errorf("cannot convert a block with size %d bytes to OpenTelemetry format for %q; skipping the block: %s", len(block), c.sanitizedURL, err)
+			c.packetsDropped.Inc()
+			return true
+		}
+		block = otlpBlock
+	}
 	c.rl.Register(len(block))
 	maxRetryDuration := timeutil.AddJitterToDuration(c.retryMaxTime)
 	retryDuration := timeutil.AddJitterToDuration(c.retryMinInterval)
@@ -414,6 +490,7 @@ again:
 	c.requestDuration.UpdateDuration(startTime)
 	if err != nil {
 		c.errorsCount.Inc()
+		c.health.registerFailure()
 		retryDuration *= 2
 		if retryDuration > maxRetryDuration {
 			retryDuration = maxRetryDuration
@@ -438,6 +515,7 @@ again:
 		c.requestsOKCount.Inc()
 		c.bytesSent.Add(len(block))
 		c.blocksSent.Inc()
+		c.health.registerSuccess()
 		return true
 	}
 
@@ -450,6 +528,7 @@ again:
 		// and https://github.com/VictoriaMetrics/VictoriaMetrics/issues/1149
 		_ = resp.Body.Close()
 		c.packetsDropped.Inc()
+		c.health.registerSuccess()
 		return true
 		// - Remote Write v1 specification implicitly expects a `400 Bad Request` when the encoding is not supported.
 		// - Remote Write v2 specification explicitly specifies a `415 Unsupported Media Type` for unsupported encodings.
@@ -462,7 +541,7 @@ again:
 			c.useVMProto.Store(false)
 		}
 
-		if encoding.IsZstd(block) {
+		if !c.useOTLP && encoding.IsZstd(block) {
 			logger.Infof("received unsupported media type or bad request from remote storage at %q. Re-packing the block to Prometheus remote write and retrying."+
 				"See https://docs.victoriametrics.com/victoriametrics/vmagent/#victoriametrics-remote-write-protocol", c.sanitizedURL)
 
@@ -479,10 +558,12 @@ again:
 		logBlockRejected(block, c.sanitizedURL, resp)
 		_ = resp.Body.Close()
 		c.packetsDropped.Inc()
+		c.health.registerSuccess()
 		return true
 	}
 
 	// Unexpected status code returned
+	c.health.registerFailure()
 	retriesCount++
 	retryAfterHeader := parseRetryAfterHeader(resp.Header.Get("Retry-After"))
 	retryDuration = getRetryDuration(retryAfterHeader, retryDuration, maxRetryDuration)
@@ -0,0 +1,274 @@
+package remotewrite
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+var (
+	failoverGroupNames = flagutil.NewArrayString("remoteWrite.failoverGroup", "Optional failover group name for the corresponding -remoteWrite.url. "+
+		"-remoteWrite.url systems with the same failover group receive a single copy of data, which is sent to the first available -remoteWrite.url "+
+		"in the group in the order of their appearance in command line. Empty group name means the -remoteWrite.url doesn't belong to any failover group. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#failover-groups")
+	failoverFailureDuration = flag.Duration("remoteWrite.failover.failureDuration", 30*time.Second, "The duration for continuously failing requests "+
+		"to -remoteWrite.url in the failover group before switching to the next -remoteWrite.url in the group. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#failover-groups")
+	failoverMaxPendingBytes = flagutil.NewBytes("remoteWrite.failover.maxPendingBytes", 0, "The maximum size of pending data for -remoteWrite.url "+
+		"in the failover group before switching to the next -remoteWrite.url in the group. Zero value disables switching by the size of pending data. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#failover-groups")
+	failoverRecoveryDelay = flag.Duration("remoteWrite.failover.recoveryDelay", time.Minute, "The duration for successful requests to the recovered -remoteWrite.url "+
+		"in the failover group before switching back to it. See https://docs.victoriametrics.com/victoriametrics/vmagent/#failover-groups")
+)
+
+// failoverGroup is a group of remote storage systems, which receive a single copy of data.
+//
+// The data is sent to the active remote storage in the group, while the remaining remote storage systems are used as standby.
+type failoverGroup struct {
+	name string
+
+	// rwctxs contains remote storage systems in the group ordered by their priority.
+	rwctxs []*remoteWriteCtx
+
+	// activeIdx is the index of the active remote storage at rwctxs.
+	activeIdx atomic.Int64
+
+	switchesTotal *metrics.Counter
+}
+
+var (
+	// failoverGroupsGlobal contains failover groups configured via -remoteWrite.failoverGroup.
+	failoverGroupsGlobal []*failoverGroup
+
+	// activeRwctxsGlobal contains remote storage systems, which must receive data.
+	//
+	// It contains remote storage systems outside failover groups and the active remote storage systems from failover groups.
+	// It is nil if failover groups aren't configured.
+	activeRwctxsGlobal atomic.Pointer[[]*remoteWriteCtx]
+
+	failoverStopCh = make(chan struct{})
+	failoverWG     sync.WaitGroup
+)
+
+func initFailoverGroups() {
+	if len(*failoverGroupNames) == 0 {
+		return
+	}
+	if len(*failoverGroupNames) > len(rwctxsGlobal) {
+		logger.Fatalf("too many -remoteWrite.failoverGroup args: %d; it mustn't exceed the number of -remoteWrite.url args: %d", len(*failoverGroupNames), len(rwctxsGlobal))
+	}
+	if *shardByURL {
+		logger.Fatalf("-remoteWrite.failoverGroup cannot be used together with -remoteWrite.shardByURL")
+	}
+	if *failoverFailureDuration <= 0 {
+		logger.Fatalf("-remoteWrite.failover.failureDuration must be positive; got %s", *failoverFailureDuration)
+	}
+
+	groups := make(map[string]*failoverGroup)
+	var fgs []*failoverGroup
+	for i, rwctx := range rwctxsGlobal {
+		name := failoverGroupNames.GetOptionalArg(i)
+		if name == "" {
+			continue
+		}
+		fg := groups[name]
+		if fg == nil {
+			fg = &failoverGroup{
+				name:          name,
+				switchesTotal: metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_failover_switches_total{group=%q}`, name)),
+			}
+			groups[name] = fg
+			fgs = append(fgs, fg)
+		}
+		fg.rwctxs = append(fg.rwctxs, rwctx)
+	}
+	for _, fg := range fgs {
+		if len(fg.rwctxs) < 2 {
+			logger.Fatalf("failover group %q set via -remoteWrite.failoverGroup must contain at least 2 -remoteWrite.url systems; got %d", fg.name, len(fg.rwctxs))
+		}
+		for i, rwctx := range fg.rwctxs {
+			_ = metrics.GetOrCreateGauge(fmt.Sprintf(`vmagent_remotewrite_failover_active{group=%q,url=%q}`, fg.name, rwctx.c.sanitizedURL), func() float64 {
+				if fg.activeIdx.Load() == int64(i) {
+					return 1
+				}
+				return 0
+			})
+		}
+	}
+	failoverGroupsGlobal = fgs
+	updateActiveRemoteWriteCtxs()
+
+	failoverWG.Add(1)
+	go func() {
+		defer failoverWG.Done()
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-failoverStopCh:
+				return
+			case <-t.C:
+			}
+			for _, fg := range failoverGroupsGlobal {
+				fg.update()
+			}
+		}
+	}()
+}
+
+func stopFailoverGroups() {
+	close(failoverStopCh)
+	failoverWG.Wait()
+	failoverGroupsGlobal = nil
+	activeRwctxsGlobal.Store(nil)
+}
+
+// getActiveRemoteWriteCtxs returns remote storage systems, which must receive the data.
+//
+// The returned remote storage systems are ordered in the same way as rwctxsGlobal.
+func getActiveRemoteWriteCtxs() []*remoteWriteCtx {
+	if p := activeRwctxsGlobal.Load(); p != nil {
+		return *p
+	}
+	return rwctxsGlobal
+}
+
+func updateActiveRemoteWriteCtxs() {
+	standby := make(map[*remoteWriteCtx]struct{})
+	for _, fg := range failoverGroupsGlobal {
+		activeIdx := int(fg.activeIdx.Load())
+		for i, rwctx := range fg.rwctxs {
+			if i != activeIdx {
+				standby[rwctx] = struct{}{}
+			}
+		}
+	}
+	rwctxs := make([]*remoteWriteCtx, 0, len(rwctxsGlobal))
+	for _, rwctx := range rwctxsGlobal {
+		if _, ok := standby[rwctx]; !ok {
+			rwctxs = append(rwctxs, rwctx)
+		}
+	}
+	activeRwctxsGlobal.Store(&rwctxs)
+}
+
+// update switches fg to another remote storage if the active remote storage becomes unavailable
+// or if remote storage with higher priority recovers.
+func (fg *failoverGroup) update() {
+	ct := fasttime.UnixTimestamp()
+	states := make([]failoverMemberState, len(fg.rwctxs))
+	for i, rwctx := range fg.rwctxs {
+		states[i] = rwctx.c.health.getState(ct)
+		states[i].pendingBytes = rwctx.fq.GetPendingBytes()
+	}
+	activeIdx := int(fg.activeIdx.Load())
+	newActiveIdx := selectFailoverMember(activeIdx, states, *failoverFailureDuration, *failoverRecoveryDelay, uint64(failoverMaxPendingBytes.N))
+	if newActiveIdx == activeIdx {
+		return
+	}
+	prev := fg.rwctxs[activeIdx]
+	next := fg.rwctxs[newActiveIdx]
+	st := &states[activeIdx]
+	logger.Warnf("failover group %q: switching from -remoteWrite.url=%q (failing for %s, pending data %d bytes) to -remoteWrite.url=%q; "+
+		"the pending data for %q remains in its queue and is sent when it becomes available",
+		fg.name, prev.c.sanitizedURL, st.failingDuration, st.pendingBytes, next.c.sanitizedURL, prev.c.sanitizedURL)
+	fg.activeIdx.Store(int64(newActiveIdx))
+	fg.switchesTotal.Inc()
+	updateActiveRemoteWriteCtxs()
+}
+
+// failoverMemberState is the state of remote storage in failover group.
+type failoverMemberState struct {
+	// failingDuration is the duration for continuously failing requests to remote storage.
+	failingDuration time.Duration
+
+	// healthyDuration is the duration for continuously successful requests to remote storage.
+	healthyDuration time.Duration
+
+	// pendingBytes is the size of pending data for remote storage.
+	pendingBytes uint64
+}
+
+// selectFailoverMember returns the index of remote storage, which must receive data in failover group with the given states.
+//
+// activeIdx is the index of the currently active remote storage.
+func selectFailoverMember(activeIdx int, states []failoverMemberState, failureDuration, recoveryDelay time.Duration, maxPendingBytes uint64) int {
+	for i := range states {
+		st := &states[i]
+		if st.failingDuration >= failureDuration {
+			continue
+		}
+		if maxPendingBytes > 0 && st.pendingBytes > maxPendingBytes {
+			continue
+		}
+		if i < activeIdx {
+			// Switch back to remote storage with higher priority only after it recovers
+			// in order to prevent from frequent switches.
+			if st.healthyDuration < recoveryDelay {
+				continue
+			}
+			if maxPendingBytes > 0 && st.pendingBytes > maxPendingBytes/2 {
+				continue
+			}
+		}
+		return i
+	}
+	// All the remote storage systems are unavailable. Leave the active remote storage as is,
+	// since there is no sense in switching between unavailable remote storage systems.
+	return activeIdx
+}
+
+// clientHealth tracks the health of remote storage based on the results of requests to it.
+type clientHealth struct {
+	// failingSince is the unix timestamp in seconds for the first failed request after the last successful request.
+	//
+	// It is zero if the last request was successful.
+	failingSince atomic.Uint64
+
+	// healthySince is the unix timestamp in seconds for the first successful request after the last failed request.
+	healthySince atomic.Uint64
+}
+
+func (ch *clientHealth) init() {
+	ch.healthySince.Store(fasttime.UnixTimestamp())
+}
+
+// registerSuccess must be called after the request to remote storage succeeds.
+func (ch *clientHealth) registerSuccess() {
+	if ch.failingSince.Load() == 0 {
+		return
+	}
+	ch.healthySince.Store(fasttime.UnixTimestamp())
+	ch.failingSince.Store(0)
+}
+
+// registerFailure must be called after the request to remote storage fails.
+func (ch *clientHealth) registerFailure() {
+	ch.failingSince.CompareAndSwap(0, fasttime.UnixTimestamp())
+}
+
+// getState returns the state of remote storage at the given unix timestamp in seconds.
+func (ch *clientHealth) getState(currentTimestamp uint64) failoverMemberState {
+	var st failoverMemberState
+	if failingSince := ch.failingSince.Load(); failingSince > 0 {
+		st.failingDuration = timestampDiff(currentTimestamp, failingSince)
+		return st
+	}
+	st.healthyDuration = timestampDiff(currentTimestamp, ch.healthySince.Load())
+	return st
+}
+
+func timestampDiff(currentTimestamp, timestamp uint64) time.Duration {
+	if currentTimestamp <= timestamp {
+		return 0
+	}
+	return time.Duration(currentTimestamp-timestamp) * time.Second
+}
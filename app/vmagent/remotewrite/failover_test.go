@@ -0,0 +1,105 @@
+package remotewrite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectFailoverMember(t *testing.T) {
+	f := func(activeIdx int, states []failoverMemberState, maxPendingBytes uint64, resultExpected int) {
+		t.Helper()
+		result := selectFailoverMember(activeIdx, states, 30*time.Second, time.Minute, maxPendingBytes)
+		if result != resultExpected {
+			t.Fatalf("unexpected active member; got %d; want %d", result, resultExpected)
+		}
+	}
+
+	healthy := failoverMemberState{
+		healthyDuration: time.Hour,
+	}
+	recovering := failoverMemberState{
+		healthyDuration: 10 * time.Second,
+	}
+	failingShortly := failoverMemberState{
+		failingDuration: 10 * time.Second,
+	}
+	failing := failoverMemberState{
+		failingDuration: time.Minute,
+	}
+
+	// All the members are healthy
+	f(0, []failoverMemberState{healthy, healthy}, 0, 0)
+
+	// The active member fails for a short time
+	f(0, []failoverMemberState{failingShortly, healthy}, 0, 0)
+
+	// The active member fails for too long
+	f(0, []failoverMemberState{failing, healthy}, 0, 1)
+	f(0, []failoverMemberState{failing, failing, healthy}, 0, 2)
+
+	// All the members fail
+	f(0, []failoverMemberState{failing, failing}, 0, 0)
+	f(1, []failoverMemberState{failing, failing}, 0, 1)
+
+	// The member with higher priority recovers
+	f(1, []failoverMemberState{recovering, healthy}, 0, 1)
+	f(1, []failoverMemberState{healthy, healthy}, 0, 0)
+	f(2, []failoverMemberState{failing, healthy, healthy}, 0, 1)
+
+	// The member with lower priority is used if the member with higher priority fails shortly
+	f(1, []failoverMemberState{failingShortly, healthy}, 0, 1)
+
+	// The active member has too much pending data
+	f(0, []failoverMemberState{
+		{healthyDuration: time.Hour, pendingBytes: 2000},
+		healthy,
+	}, 1000, 1)
+
+	// The member with higher priority recovers, but it has too much pending data
+	f(1, []failoverMemberState{
+		{healthyDuration: time.Hour, pendingBytes: 800},
+		healthy,
+	}, 1000, 1)
+	f(1, []failoverMemberState{
+		{healthyDuration: time.Hour, pendingBytes: 400},
+		healthy,
+	}, 1000, 0)
+}
+
+func TestClientHealth(t *testing.T) {
+	f := func(ch *clientHealth, currentTimestamp uint64, failingDurationExpected, healthyDurationExpected time.Duration) {
+		t.Helper()
+		st := ch.getState(currentTimestamp)
+		if st.failingDuration != failingDurationExpected {
+			t.Fatalf("unexpected failingDuration; got %s; want %s", st.failingDuration, failingDurationExpected)
+		}
+		if st.healthyDuration != healthyDurationExpected {
+			t.Fatalf("unexpected healthyDuration; got %s; want %s", st.healthyDuration, healthyDurationExpected)
+		}
+	}
+
+	var ch clientHealth
+	ch.healthySince.Store(100)
+	f(&ch, 110, 0, 10*time.Second)
+
+	// The first failure starts the failing period
+	ch.failingSince.Store(120)
+	ch.registerFailure()
+	f(&ch, 150, 30*time.Second, 0)
+
+	// Subsequent failures don't change the failing period
+	ch.registerFailure()
+	f(&ch, 160, 40*time.Second, 0)
+
+	// The success resets the failing period
+	ch.registerSuccess()
+	if n := ch.failingSince.Load(); n != 0 {
+		t.Fatalf("unexpected failingSince after success; got %d; want 0", n)
+	}
+	if n := ch.healthySince.Load(); n <= 120 {
+		t.Fatalf("healthySince must be updated after success; got %d", n)
+	}
+
+	// Timestamps in the future result in zero durations
+	f(&ch, 1, 0, 0)
+}
@@ -0,0 +1,581 @@
+package remotewrite
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding/zstd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/pb"
+)
+
+var (
+	otlpEnabled = flagutil.NewArrayBool("remoteWrite.otlp", "Whether to send data to the corresponding -remoteWrite.url via OpenTelemetry protocol (OTLP/HTTP protobuf) "+
+		"instead of Prometheus remote write protocol. -remoteWrite.url must point to OTLP metrics endpoint in this case, for example, http://otel-collector:4318/v1/metrics . "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#sending-data-via-opentelemetry-protocol")
+	otlpResourceLabels = flagutil.NewArrayString("remoteWrite.otlp.resourceLabels", "Optional list of labels delimited by ';', which must be sent as resource attributes "+
+		"to the corresponding -remoteWrite.url with enabled -remoteWrite.otlp . For example, -remoteWrite.otlp.resourceLabels='job;instance'. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#sending-data-via-opentelemetry-protocol")
+)
+
+// otlpEnabledAny is set to true if at least a single -remoteWrite.url has enabled -remoteWrite.otlp.
+var otlpEnabledAny bool
+
+// IsOTLPEnabled returns true if at least a single -remoteWrite.url has enabled -remoteWrite.otlp.
+//
+// It must be called after Init.
+func IsOTLPEnabled() bool {
+	return otlpEnabledAny
+}
+
+func initOTLP() {
+	otlpEnabledAny = false
+	for i := range *remoteWriteURLs {
+		if otlpEnabled.GetOptionalArg(i) {
+			otlpEnabledAny = true
+		}
+	}
+}
+
+func getOTLPResourceLabels(argIdx int) []string {
+	s := otlpResourceLabels.GetOptionalArg(argIdx)
+	var labels []string
+	for _, label := range strings.Split(s, ";") {
+		label = strings.TrimSpace(label)
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// appendOTLPRequest converts the block stored in persistent queue to gzip-compressed OpenTelemetry ExportMetricsServiceRequest,
+// appends it to dst and returns the result.
+//
+// The block must contain snappy- or zstd-compressed Prometheus remote write request.
+func appendOTLPRequest(dst, block []byte, resourceLabels []string) ([]byte, error) {
+	var data []byte
+	var err error
+	if encoding.IsZstd(block) {
+		data, err = zstd.Decompress(nil, block)
+	} else {
+		data, err = snappy.Decode(nil, block)
+	}
+	if err != nil {
+		return dst, fmt.Errorf("cannot decompress block: %w", err)
+	}
+	var wr prompb.WriteRequest
+	if err := wr.UnmarshalProtobuf(data); err != nil {
+		return dst, fmt.Errorf("cannot unmarshal block: %w", err)
+	}
+	req := convertToOTLP(wr.Timeseries, resourceLabels)
+	data = req.MarshalProtobuf(data[:0])
+
+	bb := bytes.NewBuffer(dst)
+	zw := getGzipWriter(bb)
+	if _, err := zw.Write(data); err != nil {
+		putGzipWriter(zw)
+		return dst, fmt.Errorf("cannot compress OpenTelemetry request: %w", err)
+	}
+	err = zw.Close()
+	putGzipWriter(zw)
+	if err != nil {
+		return dst, fmt.Errorf("cannot compress OpenTelemetry request: %w", err)
+	}
+	return bb.Bytes(), nil
+}
+
+func getGzipWriter(w *bytes.Buffer) *gzip.Writer {
+	v := gzipWriterPool.Get()
+	if v == nil {
+		zw, err := gzip.NewWriterLevel(w, gzip.BestSpeed)
+		if err != nil {
+			panic(fmt.Errorf("BUG: cannot create gzip writer: %w", err))
+		}
+		return zw
+	}
+	zw := v.(*gzip.Writer)
+	zw.Reset(w)
+	return zw
+}
+
+func putGzipWriter(zw *gzip.Writer) {
+	zw.Reset(nil)
+	gzipWriterPool.Put(zw)
+}
+
+var gzipWriterPool sync.Pool
+
+// convertToOTLP converts tss to OpenTelemetry ExportMetricsServiceRequest.
+//
+// Labels from resourceLabels are converted to resource attributes, while the remaining labels are converted to data point attributes.
+//
+// The metric type is obtained from the metadata registered via RegisterMetricMetadata.
+// If the metadata is unknown, then the type is detected from metric names according to Prometheus naming conventions:
+//
+//   - series with `_bucket` suffix and `le` label are converted to histograms together with the corresponding `_sum` and `_count` series;
+//   - series with `quantile` label are converted to summaries together with the corresponding `_sum` and `_count` series;
+//   - series with `_total` suffix are converted to monotonic cumulative sums;
+//   - the remaining series are converted to gauges.
+func convertToOTLP(tss []prompb.TimeSeries, resourceLabels []string) *pb.ExportMetricsServiceRequest {
+	var c otlpConverter
+	c.init(tss, resourceLabels)
+	for i := range tss {
+		c.addTimeSeries(&tss[i])
+	}
+	return c.finish()
+}
+
+type otlpConverter struct {
+	resourceLabels []string
+
+	// histogramFamilies and summaryFamilies contain metric family names, which must be converted to histograms and summaries.
+	histogramFamilies map[string]struct{}
+	summaryFamilies   map[string]struct{}
+
+	resources     []*otlpResource
+	resourcesByID map[string]*otlpResource
+
+	keyBuf []byte
+}
+
+type otlpResource struct {
+	rm *pb.ResourceMetrics
+	sm *pb.ScopeMetrics
+
+	// metrics contains metrics keyed by metric kind plus metric name.
+	metrics map[string]*pb.Metric
+
+	histogramPoints      []*otlpHistogramPoint
+	histogramPointsByKey map[string]int
+
+	summaryPoints      []*otlpSummaryPoint
+	summaryPointsByKey map[string]int
+}
+
+type otlpHistogramPoint struct {
+	dp      *pb.HistogramDataPoint
+	buckets []otlpBucket
+
+	count    float64
+	hasCount bool
+}
+
+type otlpBucket struct {
+	upperBound float64
+	count      float64
+}
+
+type otlpSummaryPoint struct {
+	dp *pb.SummaryDataPoint
+}
+
+func (c *otlpConverter) init(tss []prompb.TimeSeries, resourceLabels []string) {
+	c.resourceLabels = resourceLabels
+	c.histogramFamilies = make(map[string]struct{})
+	c.summaryFamilies = make(map[string]struct{})
+	c.resourcesByID = make(map[string]*otlpResource)
+
+	// Detect histograms and summaries, since they consist of multiple series.
+	countFamilies := make(map[string]struct{})
+	for i := range tss {
+		ts := &tss[i]
+		name := getLabelValue(ts.Labels, "__name__")
+		if base, ok := strings.CutSuffix(name, "_count"); ok {
+			countFamilies[base] = struct{}{}
+		}
+		if md := getMetricMetadata(name); md != nil {
+			if md.typ == metricTypeSummary {
+				c.summaryFamilies[name] = struct{}{}
+			}
+			continue
+		}
+		for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+			base, ok := strings.CutSuffix(name, suffix)
+			if !ok {
+				continue
+			}
+			if md := getMetricMetadata(base); md != nil {
+				switch {
+				case md.typ == metricTypeHistogram:
+					c.histogramFamilies[base] = struct{}{}
+				case md.typ == metricTypeSummary && suffix != "_bucket":
+					c.summaryFamilies[base] = struct{}{}
+				}
+			} else if suffix == "_bucket" && hasLabel(ts.Labels, "le") {
+				c.histogramFamilies[base] = struct{}{}
+			}
+			break
+		}
+	}
+	for i := range tss {
+		ts := &tss[i]
+		name := getLabelValue(ts.Labels, "__name__")
+		if !hasLabel(ts.Labels, "quantile") || getMetricMetadata(name) != nil {
+			continue
+		}
+		if _, ok := countFamilies[name]; ok {
+			c.summaryFamilies[name] = struct{}{}
+		}
+	}
+}
+
+func (c *otlpConverter) addTimeSeries(ts *prompb.TimeSeries) {
+	name := getLabelValue(ts.Labels, "__name__")
+	if name == "" || len(ts.Samples) == 0 {
+		return
+	}
+	r := c.getResource(ts.Labels)
+
+	if _, ok := c.summaryFamilies[name]; ok && hasLabel(ts.Labels, "quantile") {
+		c.addSummarySeries(r, name, "quantile", ts)
+		return
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		base, ok := strings.CutSuffix(name, suffix)
+		if !ok {
+			continue
+		}
+		if _, ok := c.histogramFamilies[base]; ok {
+			if suffix != "_bucket" || hasLabel(ts.Labels, "le") {
+				c.addHistogramSeries(r, base, suffix, ts)
+				return
+			}
+		}
+		if _, ok := c.summaryFamilies[base]; ok && suffix != "_bucket" {
+			c.addSummarySeries(r, base, suffix, ts)
+			return
+		}
+	}
+	c.addNumberSeries(r, name, ts)
+}
+
+func (c *otlpConverter) getResource(labels []prompb.Label) *otlpResource {
+	c.keyBuf = c.keyBuf[:0]
+	for _, name := range c.resourceLabels {
+		c.keyBuf = strconv.AppendQuote(c.keyBuf, getLabelValue(labels, name))
+	}
+	if r := c.resourcesByID[string(c.keyBuf)]; r != nil {
+		return r
+	}
+	var attrs []*pb.KeyValue
+	for _, name := range c.resourceLabels {
+		if value := getLabelValue(labels, name); value != "" {
+			attrs = append(attrs, newOTLPKeyValue(name, value))
+		}
+	}
+	sm := &pb.ScopeMetrics{}
+	r := &otlpResource{
+		rm: &pb.ResourceMetrics{
+			Resource: &pb.Resource{
+				Attributes: attrs,
+			},
+			ScopeMetrics: []*pb.ScopeMetrics{sm},
+		},
+		sm:                   sm,
+		metrics:              make(map[string]*pb.Metric),
+		histogramPointsByKey: make(map[string]int),
+		summaryPointsByKey:   make(map[string]int),
+	}
+	c.resources = append(c.resources, r)
+	c.resourcesByID[string(c.keyBuf)] = r
+	return r
+}
+
+func (c *otlpConverter) addNumberSeries(r *otlpResource, name string, ts *prompb.TimeSeries) {
+	m := r.metrics["n"+name]
+	if m == nil {
+		m = newOTLPMetric(name)
+		md := getMetricMetadata(name)
+		if (md != nil && md.typ == metricTypeCounter) || (md == nil && strings.HasSuffix(name, "_total")) {
+			m.Sum = &pb.Sum{
+				AggregationTemporality: pb.AggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+		} else {
+			m.Gauge = &pb.Gauge{}
+		}
+		r.addMetric("n"+name, m)
+	}
+	attrs := c.getAttributes(ts.Labels, "")
+	for _, s := range ts.Samples {
+		v := s.Value
+		dp := &pb.NumberDataPoint{
+			Attributes:   attrs,
+			TimeUnixNano: timestampToUnixNano(s.Timestamp),
+			DoubleValue:  &v,
+		}
+		if decimal.IsStaleNaN(v) {
+			dp.Flags = otlpFlagNoRecordedValue
+		}
+		if m.Sum != nil {
+			m.Sum.DataPoints = append(m.Sum.DataPoints, dp)
+		} else {
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, dp)
+		}
+	}
+}
+
+func (c *otlpConverter) addHistogramSeries(r *otlpResource, name, suffix string, ts *prompb.TimeSeries) {
+	m := r.metrics["h"+name]
+	if m == nil {
+		m = newOTLPMetric(name)
+		m.Histogram = &pb.Histogram{
+			AggregationTemporality: pb.AggregationTemporalityCumulative,
+		}
+		r.addMetric("h"+name, m)
+	}
+	var upperBound float64
+	if suffix == "_bucket" {
+		v, err := strconv.ParseFloat(getLabelValue(ts.Labels, "le"), 64)
+		if err != nil {
+			// Invalid bucket. Skip it.
+			return
+		}
+		upperBound = v
+	}
+	attrs := c.getAttributes(ts.Labels, "le")
+	for _, s := range ts.Samples {
+		key := c.getPointKey(name, s.Timestamp)
+		idx, ok := r.histogramPointsByKey[key]
+		if !ok {
+			idx = len(r.histogramPoints)
+			r.histogramPointsByKey[key] = idx
+			dp := &pb.HistogramDataPoint{
+				Attributes:   attrs,
+				TimeUnixNano: timestampToUnixNano(s.Timestamp),
+			}
+			m.Histogram.DataPoints = append(m.Histogram.DataPoints, dp)
+			r.histogramPoints = append(r.histogramPoints, &otlpHistogramPoint{
+				dp: dp,
+			})
+		}
+		hp := r.histogramPoints[idx]
+		if decimal.IsStaleNaN(s.Value) {
+			hp.dp.Flags = otlpFlagNoRecordedValue
+			continue
+		}
+		switch suffix {
+		case "_bucket":
+			hp.buckets = append(hp.buckets, otlpBucket{
+				upperBound: upperBound,
+				count:      s.Value,
+			})
+		case "_sum":
+			v := s.Value
+			hp.dp.Sum = &v
+		case "_count":
+			hp.count = s.Value
+			hp.hasCount = true
+		}
+	}
+}
+
+func (c *otlpConverter) addSummarySeries(r *otlpResource, name, suffix string, ts *prompb.TimeSeries) {
+	m := r.metrics["s"+name]
+	if m == nil {
+		m = newOTLPMetric(name)
+		m.Summary = &pb.Summary{}
+		r.addMetric("s"+name, m)
+	}
+	var quantile float64
+	if suffix == "quantile" {
+		v, err := strconv.ParseFloat(getLabelValue(ts.Labels, "quantile"), 64)
+		if err != nil {
+			// Invalid quantile. Skip it.
+			return
+		}
+		quantile = v
+	}
+	attrs := c.getAttributes(ts.Labels, "quantile")
+	for _, s := range ts.Samples {
+		key := c.getPointKey(name, s.Timestamp)
+		idx, ok := r.summaryPointsByKey[key]
+		if !ok {
+			idx = len(r.summaryPoints)
+			r.summaryPointsByKey[key] = idx
+			dp := &pb.SummaryDataPoint{
+				Attributes:   attrs,
+				TimeUnixNano: timestampToUnixNano(s.Timestamp),
+			}
+			m.Summary.DataPoints = append(m.Summary.DataPoints, dp)
+			r.summaryPoints = append(r.summaryPoints, &otlpSummaryPoint{
+				dp: dp,
+			})
+		}
+		sp := r.summaryPoints[idx]
+		if decimal.IsStaleNaN(s.Value) {
+			sp.dp.Flags = otlpFlagNoRecordedValue
+			continue
+		}
+		switch suffix {
+		case "quantile":
+			sp.dp.QuantileValues = append(sp.dp.QuantileValues, &pb.ValueAtQuantile{
+				Quantile: quantile,
+				Value:    s.Value,
+			})
+		case "_sum":
+			sp.dp.Sum = s.Value
+		case "_count":
+			sp.dp.Count = uint64(s.Value)
+		}
+	}
+}
+
+// getAttributes returns data point attributes for the given labels.
+//
+// The returned attributes are sorted by name. __name__, resource labels and ignoreLabel are skipped.
+// c.keyBuf contains the key for the returned attributes after the call.
+func (c *otlpConverter) getAttributes(labels []prompb.Label, ignoreLabel string) []*pb.KeyValue {
+	var attrs []*pb.KeyValue
+	for i := range labels {
+		label := &labels[i]
+		if label.Name == "__name__" || label.Name == ignoreLabel || c.isResourceLabel(label.Name) {
+			continue
+		}
+		attrs = append(attrs, newOTLPKeyValue(label.Name, label.Value))
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Key < attrs[j].Key
+	})
+	c.keyBuf = c.keyBuf[:0]
+	for _, a := range attrs {
+		c.keyBuf = strconv.AppendQuote(c.keyBuf, a.Key)
+		c.keyBuf = strconv.AppendQuote(c.keyBuf, *a.Value.StringValue)
+	}
+	return attrs
+}
+
+// getPointKey returns the key for the data point of the given metric family at the given timestamp
+// with the attributes obtained by the previous getAttributes call.
+func (c *otlpConverter) getPointKey(name string, timestamp int64) string {
+	n := len(c.keyBuf)
+	c.keyBuf = strconv.AppendQuote(c.keyBuf, name)
+	c.keyBuf = strconv.AppendInt(c.keyBuf, timestamp, 10)
+	key := string(c.keyBuf)
+	c.keyBuf = c.keyBuf[:n]
+	return key
+}
+
+func (c *otlpConverter) isResourceLabel(name string) bool {
+	for _, label := range c.resourceLabels {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *otlpConverter) finish() *pb.ExportMetricsServiceRequest {
+	req := &pb.ExportMetricsServiceRequest{}
+	for _, r := range c.resources {
+		for _, hp := range r.histogramPoints {
+			hp.finish()
+		}
+		for _, sp := range r.summaryPoints {
+			sort.Slice(sp.dp.QuantileValues, func(i, j int) bool {
+				return sp.dp.QuantileValues[i].Quantile < sp.dp.QuantileValues[j].Quantile
+			})
+		}
+		req.ResourceMetrics = append(req.ResourceMetrics, r.rm)
+	}
+	return req
+}
+
+// finish converts cumulative Prometheus buckets at hp to OpenTelemetry explicit buckets.
+func (hp *otlpHistogramPoint) finish() {
+	buckets := hp.buckets
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].upperBound < buckets[j].upperBound
+	})
+	dp := hp.dp
+	prevCount := float64(0)
+	for _, b := range buckets {
+		if math.IsInf(b.upperBound, 1) {
+			break
+		}
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.upperBound)
+		dp.BucketCounts = append(dp.BucketCounts, getBucketCount(b.count, prevCount))
+		prevCount = b.count
+	}
+	totalCount := prevCount
+	if hp.hasCount {
+		totalCount = hp.count
+	} else if len(buckets) > 0 && math.IsInf(buckets[len(buckets)-1].upperBound, 1) {
+		totalCount = buckets[len(buckets)-1].count
+	}
+	dp.BucketCounts = append(dp.BucketCounts, getBucketCount(totalCount, prevCount))
+	dp.Count = uint64(totalCount)
+}
+
+func getBucketCount(cumulativeCount, prevCumulativeCount float64) uint64 {
+	if cumulativeCount <= prevCumulativeCount {
+		return 0
+	}
+	return uint64(cumulativeCount - prevCumulativeCount)
+}
+
+func (r *otlpResource) addMetric(key string, m *pb.Metric) {
+	r.metrics[key] = m
+	r.sm.Metrics = append(r.sm.Metrics, m)
+}
+
+func newOTLPMetric(name string) *pb.Metric {
+	m := &pb.Metric{
+		Name: name,
+	}
+	if md := getMetricMetadata(name); md != nil {
+		m.Unit = md.unit
+	}
+	return m
+}
+
+func newOTLPKeyValue(key, value string) *pb.KeyValue {
+	return &pb.KeyValue{
+		Key: key,
+		Value: &pb.AnyValue{
+			StringValue: &value,
+		},
+	}
+}
+
+// otlpFlagNoRecordedValue is set for data points, which correspond to Prometheus staleness markers.
+//
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+const otlpFlagNoRecordedValue = 1
+
+func timestampToUnixNano(timestamp int64) uint64 {
+	if timestamp <= 0 {
+		return 0
+	}
+	return uint64(timestamp) * 1e6
+}
+
+func getLabelValue(labels []prompb.Label, name string) string {
+	for i := range labels {
+		if labels[i].Name == name {
+			return labels[i].Value
+		}
+	}
+	return ""
+}
+
+func hasLabel(labels []prompb.Label, name string) bool {
+	for i := range labels {
+		if labels[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
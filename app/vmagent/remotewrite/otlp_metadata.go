@@ -0,0 +1,95 @@
+package remotewrite
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+// Metric types from Prometheus metadata.
+//
+// See https://github.com/prometheus/prometheus/blob/c5282933765ec322a0664d0a0268f8276e83b156/prompb/types.proto#L21
+const (
+	metricTypeUnknown   = 0
+	metricTypeCounter   = 1
+	metricTypeGauge     = 2
+	metricTypeHistogram = 3
+	metricTypeSummary   = 5
+)
+
+// maxMetricMetadataEntries is the maximum number of metric families, which metadata is stored in metricMetadataCache.
+const maxMetricMetadataEntries = 100_000
+
+// metricMetadata contains the metadata for metric family, which is used when sending data via OpenTelemetry protocol.
+type metricMetadata struct {
+	typ  uint32
+	unit string
+}
+
+var (
+	// metricMetadataCache contains metricMetadata entries keyed by metric family name.
+	metricMetadataCache        sync.Map
+	metricMetadataCacheEntries atomic.Int64
+)
+
+// RegisterMetricMetadata registers mms, so they could be used when sending data via OpenTelemetry protocol.
+//
+// See https://docs.victoriametrics.com/victoriametrics/vmagent/#sending-data-via-opentelemetry-protocol
+func RegisterMetricMetadata(mms []prompb.MetricMetadata) {
+	if !otlpEnabledAny {
+		return
+	}
+	for i := range mms {
+		mm := &mms[i]
+		registerMetricMetadata(mm.MetricFamilyName, mm.Type, mm.Unit)
+	}
+}
+
+// registerScrapedMetricMetadata registers mms passed to TryPush, such as metadata obtained from scrape responses.
+func registerScrapedMetricMetadata(mms []prompbmarshal.MetricMetadata) {
+	if !otlpEnabledAny {
+		return
+	}
+	for i := range mms {
+		mm := &mms[i]
+		registerMetricMetadata(mm.MetricFamilyName, mm.Type, mm.Unit)
+	}
+}
+
+func registerMetricMetadata(metricFamilyName string, typ uint32, unit string) {
+	if metricFamilyName == "" || typ == metricTypeUnknown {
+		return
+	}
+	if v, ok := metricMetadataCache.Load(metricFamilyName); ok {
+		md := v.(*metricMetadata)
+		if md.typ == typ && md.unit == unit {
+			return
+		}
+	} else if metricMetadataCacheEntries.Add(1) > maxMetricMetadataEntries {
+		// Reset the cache in order to limit its memory usage.
+		// Actual metadata is registered again on subsequent requests.
+		metricMetadataCache.Clear()
+		metricMetadataCacheEntries.Store(1)
+	}
+	md := &metricMetadata{
+		typ: typ,
+		// Copy the unit, since it may refer to the request buffer, which is re-used after return.
+		unit: strings.Clone(unit),
+	}
+	// Copy the metric family name, since it may refer to the request buffer, which is re-used after return.
+	metricMetadataCache.Store(strings.Clone(metricFamilyName), md)
+}
+
+// getMetricMetadata returns the metadata for the given metricFamilyName.
+//
+// nil is returned if the metadata is unknown.
+func getMetricMetadata(metricFamilyName string) *metricMetadata {
+	v, ok := metricMetadataCache.Load(metricFamilyName)
+	if !ok {
+		return nil
+	}
+	return v.(*metricMetadata)
+}
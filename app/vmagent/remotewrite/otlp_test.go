@@ -0,0 +1,319 @@
+package remotewrite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/pb"
+)
+
+func TestConvertToOTLP(t *testing.T) {
+	f := func(tss []prompb.TimeSeries, resourceLabels []string, resultExpected string) {
+		t.Helper()
+		req := convertToOTLP(tss, resourceLabels)
+		result := formatOTLPRequest(req)
+		if result != resultExpected {
+			t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	// empty series
+	f(nil, nil, "")
+
+	// gauge and counter
+	f([]prompb.TimeSeries{
+		newTestSeries(`foo{job="a",instance="x"}`, 1, 10),
+		newTestSeries(`bar_total{job="a",instance="y"}`, 2, 20),
+	}, []string{"job"}, `resource{job="a"}
+  foo gauge
+    {instance="x"} 1000000 10 flags=0
+  bar_total sum monotonic=true temporality=2
+    {instance="y"} 2000000 20 flags=0
+`)
+
+	// multiple resources
+	f([]prompb.TimeSeries{
+		newTestSeries(`foo{job="a",instance="x"}`, 1, 10),
+		newTestSeries(`foo{job="b",instance="x"}`, 1, 20),
+		newTestSeries(`foo{instance="y"}`, 1, 30),
+	}, []string{"job", "instance"}, `resource{job="a",instance="x"}
+  foo gauge
+    {} 1000000 10 flags=0
+resource{job="b",instance="x"}
+  foo gauge
+    {} 1000000 20 flags=0
+resource{instance="y"}
+  foo gauge
+    {} 1000000 30 flags=0
+`)
+
+	// staleness marker
+	f([]prompb.TimeSeries{
+		newTestSeries(`foo{job="a"}`, 1, decimal.StaleNaN),
+	}, nil, `resource{}
+  foo gauge
+    {job="a"} 1000000 NaN flags=1
+`)
+
+	// histogram
+	f([]prompb.TimeSeries{
+		newTestSeries(`req_duration_bucket{le="0.1",path="/"}`, 1, 2),
+		newTestSeries(`req_duration_bucket{le="1",path="/"}`, 1, 5),
+		newTestSeries(`req_duration_bucket{path="/",le="+Inf"}`, 1, 7),
+		newTestSeries(`req_duration_sum{path="/"}`, 1, 3.5),
+		newTestSeries(`req_duration_count{path="/"}`, 1, 7),
+	}, nil, `resource{}
+  req_duration histogram temporality=2
+    {path="/"} 1000000 count=7 sum=3.5 bounds=[0.1 1] counts=[2 3 2] flags=0
+`)
+
+	// histogram without +Inf bucket and _count
+	f([]prompb.TimeSeries{
+		newTestSeries(`req_duration_bucket{le="1"}`, 1, 2),
+		newTestSeries(`req_duration_bucket{le="0.5"}`, 1, 1),
+	}, nil, `resource{}
+  req_duration histogram temporality=2
+    {} 1000000 count=2 sum=<nil> bounds=[0.5 1] counts=[1 1 0] flags=0
+`)
+
+	// summary
+	f([]prompb.TimeSeries{
+		newTestSeries(`rpc_duration{quantile="0.99"}`, 1, 10),
+		newTestSeries(`rpc_duration{quantile="0.5"}`, 1, 3),
+		newTestSeries(`rpc_duration_sum`, 1, 100),
+		newTestSeries(`rpc_duration_count`, 1, 20),
+	}, nil, `resource{}
+  rpc_duration summary
+    {} 1000000 count=20 sum=100 quantiles=[0.5:3 0.99:10] flags=0
+`)
+
+	// series with quantile label without _count series is a gauge
+	f([]prompb.TimeSeries{
+		newTestSeries(`foo{quantile="0.5"}`, 1, 3),
+	}, nil, `resource{}
+  foo gauge
+    {quantile="0.5"} 1000000 3 flags=0
+`)
+}
+
+func TestConvertToOTLPWithMetadata(t *testing.T) {
+	defer metricMetadataCache.Clear()
+
+	otlpEnabledAny = true
+	defer func() {
+		otlpEnabledAny = false
+	}()
+	RegisterMetricMetadata([]prompb.MetricMetadata{
+		{
+			MetricFamilyName: "requests",
+			Type:             metricTypeCounter,
+			Unit:             "1",
+		},
+		{
+			MetricFamilyName: "temperature_total",
+			Type:             metricTypeGauge,
+			Unit:             "Cel",
+		},
+		{
+			MetricFamilyName: "latency",
+			Type:             metricTypeHistogram,
+			Unit:             "s",
+		},
+	})
+	tss := []prompb.TimeSeries{
+		newTestSeries(`requests`, 1, 10),
+		newTestSeries(`temperature_total`, 1, 20),
+		newTestSeries(`latency_sum`, 1, 3),
+		newTestSeries(`latency_count`, 1, 2),
+	}
+	req := convertToOTLP(tss, nil)
+	result := formatOTLPRequest(req)
+	resultExpected := `resource{}
+  requests unit=1 sum monotonic=true temporality=2
+    {} 1000000 10 flags=0
+  temperature_total unit=Cel gauge
+    {} 1000000 20 flags=0
+  latency unit=s histogram temporality=2
+    {} 1000000 count=2 sum=3 bounds=[] counts=[2] flags=0
+`
+	if result != resultExpected {
+		t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
+
+func TestConvertToOTLPWithScrapedMetadata(t *testing.T) {
+	defer metricMetadataCache.Clear()
+
+	otlpEnabledAny = true
+	defer func() {
+		otlpEnabledAny = false
+	}()
+	registerScrapedMetricMetadata([]prompbmarshal.MetricMetadata{
+		{
+			MetricFamilyName: "requests",
+			Type:             metricTypeCounter,
+		},
+		{
+			MetricFamilyName: "latency",
+			Type:             metricTypeSummary,
+			Unit:             "seconds",
+		},
+	})
+	tss := []prompb.TimeSeries{
+		newTestSeries(`requests`, 1, 10),
+		newTestSeries(`latency_sum`, 1, 3),
+		newTestSeries(`latency_count`, 1, 2),
+	}
+	req := convertToOTLP(tss, nil)
+	result := formatOTLPRequest(req)
+	resultExpected := `resource{}
+  requests sum monotonic=true temporality=2
+    {} 1000000 10 flags=0
+  latency unit=seconds summary
+    {} 1000000 count=2 sum=3 quantiles=[] flags=0
+`
+	if result != resultExpected {
+		t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
+
+func TestAppendOTLPRequest(t *testing.T) {
+	wr := &prompbmarshal.WriteRequest{
+		Timeseries: []prompbmarshal.TimeSeries{
+			{
+				Labels: []prompbmarshal.Label{
+					{Name: "__name__", Value: "foo"},
+					{Name: "job", Value: "bar"},
+				},
+				Samples: []prompbmarshal.Sample{
+					{Value: 1, Timestamp: 2},
+				},
+			},
+		},
+	}
+	block := snappy.Encode(nil, wr.MarshalProtobuf(nil))
+	data, err := appendOTLPRequest(nil, block, []string{"job"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("cannot create gzip reader: %s", err)
+	}
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("cannot decompress request: %s", err)
+	}
+	var req pb.ExportMetricsServiceRequest
+	if err := req.UnmarshalProtobuf(data); err != nil {
+		t.Fatalf("cannot unmarshal request: %s", err)
+	}
+	result := formatOTLPRequest(&req)
+	resultExpected := `resource{job="bar"}
+  foo gauge
+    {} 2000000 1 flags=0
+`
+	if result != resultExpected {
+		t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+
+	// invalid block
+	if _, err := appendOTLPRequest(nil, []byte("foobar"), nil); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
+
+func newTestSeries(s string, timestamp int64, value float64) prompb.TimeSeries {
+	name, tail, _ := strings.Cut(s, "{")
+	labels := []prompb.Label{
+		{Name: "__name__", Value: name},
+	}
+	tail = strings.TrimSuffix(tail, "}")
+	if tail != "" {
+		for _, kv := range strings.Split(tail, ",") {
+			k, v, _ := strings.Cut(kv, "=")
+			labels = append(labels, prompb.Label{
+				Name:  k,
+				Value: strings.Trim(v, `"`),
+			})
+		}
+	}
+	return prompb.TimeSeries{
+		Labels: labels,
+		Samples: []prompb.Sample{
+			{Value: value, Timestamp: timestamp},
+		},
+	}
+}
+
+func formatOTLPRequest(req *pb.ExportMetricsServiceRequest) string {
+	var sb strings.Builder
+	for _, rm := range req.ResourceMetrics {
+		fmt.Fprintf(&sb, "resource%s\n", formatOTLPAttributes(rm.Resource.Attributes))
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				fmt.Fprintf(&sb, "  %s", m.Name)
+				if m.Unit != "" {
+					fmt.Fprintf(&sb, " unit=%s", m.Unit)
+				}
+				switch {
+				case m.Gauge != nil:
+					fmt.Fprintf(&sb, " gauge\n")
+					formatOTLPNumberDataPoints(&sb, m.Gauge.DataPoints)
+				case m.Sum != nil:
+					fmt.Fprintf(&sb, " sum monotonic=%v temporality=%d\n", m.Sum.IsMonotonic, m.Sum.AggregationTemporality)
+					formatOTLPNumberDataPoints(&sb, m.Sum.DataPoints)
+				case m.Histogram != nil:
+					fmt.Fprintf(&sb, " histogram temporality=%d\n", m.Histogram.AggregationTemporality)
+					for _, dp := range m.Histogram.DataPoints {
+						sum := "<nil>"
+						if dp.Sum != nil {
+							sum = fmt.Sprintf("%g", *dp.Sum)
+						}
+						fmt.Fprintf(&sb, "    %s %d count=%d sum=%s bounds=%v counts=%v flags=%d\n",
+							formatOTLPAttributes(dp.Attributes), dp.TimeUnixNano, dp.Count, sum, dp.ExplicitBounds, dp.BucketCounts, dp.Flags)
+					}
+				case m.Summary != nil:
+					fmt.Fprintf(&sb, " summary\n")
+					for _, dp := range m.Summary.DataPoints {
+						var quantiles []string
+						for _, q := range dp.QuantileValues {
+							quantiles = append(quantiles, fmt.Sprintf("%g:%g", q.Quantile, q.Value))
+						}
+						fmt.Fprintf(&sb, "    %s %d count=%d sum=%g quantiles=%v flags=%d\n",
+							formatOTLPAttributes(dp.Attributes), dp.TimeUnixNano, dp.Count, dp.Sum, quantiles, dp.Flags)
+					}
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func formatOTLPNumberDataPoints(sb *strings.Builder, dps []*pb.NumberDataPoint) {
+	for _, dp := range dps {
+		v := math.NaN()
+		if dp.DoubleValue != nil {
+			v = *dp.DoubleValue
+		}
+		fmt.Fprintf(sb, "    %s %d %g flags=%d\n", formatOTLPAttributes(dp.Attributes), dp.TimeUnixNano, v, dp.Flags)
+	}
+}
+
+func formatOTLPAttributes(attrs []*pb.KeyValue) string {
+	a := make([]string, 0, len(attrs))
+	for _, kv := range attrs {
+		a = append(a, fmt.Sprintf("%s=%q", kv.Key, *kv.Value.StringValue))
+	}
+	return "{" + strings.Join(a, ",") + "}"
+}
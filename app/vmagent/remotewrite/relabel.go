@@ -50,8 +50,12 @@ func initRelabelMetrics() {
 
 // CheckRelabelConfigs checks -remoteWrite.relabelConfig and -remoteWrite.urlRelabelConfig.
 func CheckRelabelConfigs() error {
-	_, err := loadRelabelConfigs()
-	return err
+	rcs, err := loadRelabelConfigs()
+	if err != nil {
+		return err
+	}
+	rcs.mustStop()
+	return nil
 }
 
 func initRelabelConfigs() {
@@ -68,8 +72,8 @@ func initRelabelConfigs() {
 }
 
 func reloadRelabelConfigs() {
-	rcs := allRelabelConfigs.Load()
-	if !rcs.isSet() {
+	rcsOld := allRelabelConfigs.Load()
+	if !rcsOld.isSet() {
 		return
 	}
 	relabelConfigReloads.Inc()
@@ -82,6 +86,7 @@ func reloadRelabelConfigs() {
 		return
 	}
 	allRelabelConfigs.Store(rcs)
+	rcsOld.mustStop()
 	relabelConfigSuccess.Set(1)
 	relabelConfigTimestamp.Set(fasttime.UnixTimestamp())
 	logger.Infof("successfully reloaded relabel configs")
@@ -97,6 +102,7 @@ func loadRelabelConfigs() (*relabelConfigs, error) {
 		rcs.global = global
 	}
 	if len(*relabelConfigPaths) > len(*remoteWriteURLs) {
+		rcs.mustStop()
 		return nil, fmt.Errorf("too many -remoteWrite.urlRelabelConfig args: %d; it mustn't exceed the number of -remoteWrite.url args: %d",
 			len(*relabelConfigPaths), (len(*remoteWriteURLs)))
 	}
@@ -108,6 +114,7 @@ func loadRelabelConfigs() (*relabelConfigs, error) {
 		}
 		prc, err := promrelabel.LoadRelabelConfigs(path)
 		if err != nil {
+			rcs.mustStop()
 			return nil, fmt.Errorf("cannot load relabel configs from -remoteWrite.urlRelabelConfig=%q: %w", path, err)
 		}
 		rcs.perURL[i] = prc
@@ -120,6 +127,14 @@ type relabelConfigs struct {
 	perURL []*promrelabel.ParsedConfigs
 }
 
+// mustStop releases resources held by rcs.
+func (rcs *relabelConfigs) mustStop() {
+	rcs.global.MustStop()
+	for _, pcs := range rcs.perURL {
+		pcs.MustStop()
+	}
+}
+
 func (rcs *relabelConfigs) isSet() bool {
 	if rcs == nil {
 		return false
@@ -125,10 +125,15 @@ var allRelabelConfigs atomic.Pointer[relabelConfigs]
 // Contains the current global stream aggregators.
 var sasGlobal atomic.Pointer[streamaggr.Aggregators]
 
+// sasGlobalReloadLock prevents from stopping sasGlobal by the reload with the state persistence while samples are pushed to it.
+//
+// It is used only if -streamAggr.stateSaveInterval is set.
+var sasGlobalReloadLock sync.RWMutex
+
 // Contains the current global deduplicator.
 var deduplicatorGlobal *streamaggr.Deduplicator
 
-// maxQueues limits the maximum value for `-remoteWrite.queues`. There is no sense in setting too high value,
+// maxQueues limits the maximum value for `-remoteWrite.queues` and `-remoteWrite.adaptiveQueues.max`. There is no sense in setting too high value,
 // since it may lead to high memory usage due to big number of buffers.
 var maxQueues = cgroup.AvailableCPUs() * 16
 
@@ -181,6 +186,7 @@ func Init() {
 	if *queues <= 0 {
 		*queues = 1
 	}
+	initAdaptiveQueues()
 
 	if len(*shardByURLLabels) > 0 && len(*shardByURLIgnoreLabels) > 0 {
 		logger.Fatalf("-remoteWrite.shardByURL.labels and -remoteWrite.shardByURL.ignoreLabels cannot be set simultaneously; " +
@@ -200,7 +206,10 @@ func Init() {
 
 	initStreamAggrConfigGlobal()
 
+	initTmpDataEncryption()
+	initOTLP()
 	initRemoteWriteCtxs(*remoteWriteURLs)
+	initFailoverGroups()
 
 	disableOnDiskQueues := []bool(*disableOnDiskQueue)
 	disableOnDiskQueueAny = slices.Contains(disableOnDiskQueues, true)
@@ -225,6 +234,7 @@ func Init() {
 			}
 			reloadRelabelConfigs()
 			reloadStreamAggrConfigs()
+			reloadTmpDataEncryption()
 		}
 	}()
 }
@@ -345,6 +355,8 @@ func Stop() {
 	close(configReloaderStopCh)
 	configReloaderWG.Wait()
 
+	stopFailoverGroups()
+
 	sasGlobal.Load().MustStop()
 	if deduplicatorGlobal != nil {
 		deduplicatorGlobal.MustStop()
@@ -384,6 +396,9 @@ func TryPush(at *auth.Token, wr *prompbmarshal.WriteRequest) bool {
 }
 
 func tryPush(at *auth.Token, wr *prompbmarshal.WriteRequest, forceDropSamplesOnFailure bool) bool {
+	if len(wr.Metadata) > 0 {
+		registerScrapedMetricMetadata(wr.Metadata)
+	}
 	tss := wr.Timeseries
 
 	var tenantRctx *relabelCtx
@@ -421,8 +436,6 @@ func tryPush(at *auth.Token, wr *prompbmarshal.WriteRequest, forceDropSamplesOnF
 	// Allow up to 10x of labels per each block on average.
 	maxLabelsPerBlock := 10 * maxSamplesPerBlock
 
-	sas := sasGlobal.Load()
-
 	for len(tss) > 0 {
 		// Process big tss in smaller blocks in order to reduce the maximum memory usage
 		samplesCount := 0
@@ -466,6 +479,10 @@ func tryPush(at *auth.Token, wr *prompbmarshal.WriteRequest, forceDropSamplesOnF
 		}
 		sortLabelsIfNeeded(tssBlock)
 		tssBlock = limitSeriesCardinality(tssBlock)
+		if *streamAggrStateSaveInterval > 0 {
+			sasGlobalReloadLock.RLock()
+		}
+		sas := sasGlobal.Load()
 		if sas.IsEnabled() {
 			matchIdxs := matchIdxsPool.Get()
 			matchIdxs.B = sas.Push(tssBlock, matchIdxs.B)
@@ -474,6 +491,9 @@ func tryPush(at *auth.Token, wr *prompbmarshal.WriteRequest, forceDropSamplesOnF
 			}
 			matchIdxsPool.Put(matchIdxs)
 		}
+		if *streamAggrStateSaveInterval > 0 {
+			sasGlobalReloadLock.RUnlock()
+		}
 		if deduplicatorGlobal != nil {
 			deduplicatorGlobal.Push(tssBlock)
 			tssBlock = tssBlock[:0]
@@ -488,15 +508,18 @@ func tryPush(at *auth.Token, wr *prompbmarshal.WriteRequest, forceDropSamplesOnF
 // getEligibleRemoteWriteCtxs checks whether writes to configured remote storage systems are blocked and
 // returns only the unblocked rwctx.
 //
+// Standby remote storage systems from failover groups are skipped. See https://docs.victoriametrics.com/victoriametrics/vmagent/#failover-groups
+//
 // calculateHealthyRwctxIdx will rely on the order of rwctx to be in ascending order.
 func getEligibleRemoteWriteCtxs(tss []prompbmarshal.TimeSeries, forceDropSamplesOnFailure bool) ([]*remoteWriteCtx, bool) {
+	rwctxsActive := getActiveRemoteWriteCtxs()
 	if !disableOnDiskQueueAny {
-		return rwctxsGlobal, true
+		return rwctxsActive, true
 	}
 
 	// This code is applicable if at least a single remote storage has -disableOnDiskQueue
-	rwctxs := make([]*remoteWriteCtx, 0, len(rwctxsGlobal))
-	for _, rwctx := range rwctxsGlobal {
+	rwctxs := make([]*remoteWriteCtx, 0, len(rwctxsActive))
+	for _, rwctx := range rwctxsActive {
 		if !rwctx.fq.IsWriteBlocked() {
 			rwctxs = append(rwctxs, rwctx)
 		} else {
@@ -785,6 +808,11 @@ type remoteWriteCtx struct {
 	sas          atomic.Pointer[streamaggr.Aggregators]
 	deduplicator *streamaggr.Deduplicator
 
+	// sasReloadLock prevents from stopping sas by the reload with the state persistence while samples are pushed to it.
+	//
+	// It is used only if -streamAggr.stateSaveInterval is set.
+	sasReloadLock sync.RWMutex
+
 	streamAggrKeepInput bool
 	streamAggrDropInput bool
 
@@ -798,12 +826,17 @@ type remoteWriteCtx struct {
 	rowsDroppedOnPushFailure *metrics.Counter
 }
 
-func newRemoteWriteCtx(argIdx int, remoteWriteURL *url.URL, maxInmemoryBlocks int, sanitizedURL string) *remoteWriteCtx {
+// getRemoteWriteURLHash returns the hash for remoteWriteURL, which doesn't depend on query args.
+func getRemoteWriteURLHash(remoteWriteURL *url.URL) uint64 {
 	// strip query params, otherwise changing params resets pq
 	pqURL := *remoteWriteURL
 	pqURL.RawQuery = ""
 	pqURL.Fragment = ""
-	h := xxhash.Sum64([]byte(pqURL.String()))
+	return xxhash.Sum64([]byte(pqURL.String()))
+}
+
+func newRemoteWriteCtx(argIdx int, remoteWriteURL *url.URL, maxInmemoryBlocks int, sanitizedURL string) *remoteWriteCtx {
+	h := getRemoteWriteURLHash(remoteWriteURL)
 	queuePath := filepath.Join(*tmpDataPath, persistentQueueDirname, fmt.Sprintf("%d_%016X", argIdx+1, h))
 	maxPendingBytes := maxPendingBytesPerURL.GetOptionalArg(argIdx)
 	if maxPendingBytes != 0 && maxPendingBytes < persistentqueue.DefaultChunkFileSize {
@@ -830,7 +863,7 @@ func newRemoteWriteCtx(argIdx int, remoteWriteURL *url.URL, maxInmemoryBlocks in
 	var c *client
 	switch remoteWriteURL.Scheme {
 	case "http", "https":
-		c = newHTTPClient(argIdx, remoteWriteURL.String(), sanitizedURL, fq, *queues)
+		c = newHTTPClient(argIdx, remoteWriteURL.String(), sanitizedURL, fq, getMaxConcurrency())
 	default:
 		logger.Fatalf("unsupported scheme: %s for remoteWriteURL: %s, want `http`, `https`", remoteWriteURL.Scheme, sanitizedURL)
 	}
@@ -839,7 +872,7 @@ func newRemoteWriteCtx(argIdx int, remoteWriteURL *url.URL, maxInmemoryBlocks in
 	// Initialize pss
 	sf := significantFigures.GetOptionalArg(argIdx)
 	rd := roundDigits.GetOptionalArg(argIdx)
-	pssLen := *queues
+	pssLen := getMaxConcurrency()
 	if n := cgroup.AvailableCPUs(); pssLen > n {
 		// There is no sense in running more than availableCPUs concurrent pendingSeries,
 		// since every pendingSeries can saturate up to a single CPU.
@@ -929,6 +962,9 @@ func (rwctx *remoteWriteCtx) TryPush(tss []prompbmarshal.TimeSeries, forceDropSa
 	rwctx.rowsPushedAfterRelabel.Add(rowsCount)
 
 	// Apply stream aggregation or deduplication if they are configured
+	if *streamAggrStateSaveInterval > 0 {
+		rwctx.sasReloadLock.RLock()
+	}
 	sas := rwctx.sas.Load()
 	if sas.IsEnabled() {
 		matchIdxs := matchIdxsPool.Get()
@@ -944,6 +980,9 @@ func (rwctx *remoteWriteCtx) TryPush(tss []prompbmarshal.TimeSeries, forceDropSa
 		}
 		matchIdxsPool.Put(matchIdxs)
 	}
+	if *streamAggrStateSaveInterval > 0 {
+		rwctx.sasReloadLock.RUnlock()
+	}
 	if rwctx.deduplicator != nil {
 		rwctx.deduplicator.Push(tss)
 		return true
@@ -3,6 +3,8 @@ package remotewrite
 import (
 	"flag"
 	"fmt"
+	"net/url"
+	"path/filepath"
 	"strings"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
@@ -65,12 +67,20 @@ var (
 	streamAggrEnableWindows = flagutil.NewArrayBool("remoteWrite.streamAggr.enableWindows", "Enables aggregation within fixed windows for all remote write's aggregators. "+
 		"This allows to get more precise results, but impacts resource usage as it requires twice more memory to store two states. "+
 		"See https://docs.victoriametrics.com/victoriametrics/stream-aggregation/#aggregation-windows.")
+
+	streamAggrStateSaveInterval = flag.Duration("streamAggr.stateSaveInterval", 0, "Interval for saving the state of total, increase, rate_* and histogram_bucket outputs "+
+		"for -streamAggr.config and -remoteWrite.streamAggr.config to -remoteWrite.tmpDataPath . The state is also saved on graceful shutdown "+
+		"and is restored on startup. Zero value disables the state persistence. "+
+		"See https://docs.victoriametrics.com/victoriametrics/stream-aggregation/#state-persistence")
 )
 
+// streamAggrStateDirname is the directory inside -remoteWrite.tmpDataPath for persisting stream aggregation state.
+const streamAggrStateDirname = "streamaggr-state"
+
 // CheckStreamAggrConfigs checks -remoteWrite.streamAggr.config and -streamAggr.config.
 func CheckStreamAggrConfigs() error {
 	// Check global config
-	sas, err := newStreamAggrConfigGlobal()
+	sas, err := newStreamAggrConfigGlobal(false)
 	if err != nil {
 		return err
 	}
@@ -82,7 +92,7 @@ func CheckStreamAggrConfigs() error {
 
 	pushNoop := func(_ []prompbmarshal.TimeSeries) {}
 	for idx := range *streamAggrConfig {
-		sas, err := newStreamAggrConfigPerURL(idx, pushNoop)
+		sas, err := newStreamAggrConfigPerURL(idx, pushNoop, false)
 		if err != nil {
 			return err
 		}
@@ -107,7 +117,7 @@ func reloadStreamAggrConfigGlobal() {
 	logger.Infof("reloading stream aggregation configs pointed by -streamAggr.config=%q", path)
 	metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reloads_total{path=%q}`, path)).Inc()
 
-	sasNew, err := newStreamAggrConfigGlobal()
+	sasNew, err := newStreamAggrConfigGlobal(false)
 	if err != nil {
 		metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reloads_errors_total{path=%q}`, path)).Inc()
 		metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reload_successful{path=%q}`, path)).Set(0)
@@ -117,8 +127,28 @@ func reloadStreamAggrConfigGlobal() {
 
 	sas := sasGlobal.Load()
 	if !sasNew.Equal(sas) {
-		sasOld := sasGlobal.Swap(sasNew)
-		sasOld.MustStop()
+		if *streamAggrStateSaveInterval > 0 {
+			// The state of the previous aggregators must be saved before it is restored by the new aggregators,
+			// so the previous aggregators must be stopped before creating the new ones.
+			// The lock is held only while the previous aggregators are detached, so they are stopped after in-flight pushes to them are finished.
+			// Samples pushed until the new aggregators are created aren't aggregated, so the ingestion isn't blocked by the reload.
+			sasNew.MustStop()
+			sasGlobalReloadLock.Lock()
+			sasOld := sasGlobal.Swap(nil)
+			sasGlobalReloadLock.Unlock()
+			sasOld.MustStop()
+			sasNew, err = newStreamAggrConfigGlobal(true)
+			sasGlobal.Store(sasNew)
+			if err != nil {
+				metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reloads_errors_total{path=%q}`, path)).Inc()
+				metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reload_successful{path=%q}`, path)).Set(0)
+				logger.Errorf("cannot reload -streamAggr.config=%q; the aggregation is disabled until the next successful reload; error: %s", path, err)
+				return
+			}
+		} else {
+			sasOld := sasGlobal.Swap(sasNew)
+			sasOld.MustStop()
+		}
 		logger.Infof("successfully reloaded -streamAggr.config=%q", path)
 	} else {
 		sasNew.MustStop()
@@ -129,7 +159,7 @@ func reloadStreamAggrConfigGlobal() {
 }
 
 func initStreamAggrConfigGlobal() {
-	sas, err := newStreamAggrConfigGlobal()
+	sas, err := newStreamAggrConfigGlobal(true)
 	if err != nil {
 		logger.Fatalf("cannot initialize global stream aggregators: %s", err)
 	}
@@ -148,7 +178,7 @@ func initStreamAggrConfigGlobal() {
 func (rwctx *remoteWriteCtx) initStreamAggrConfig() {
 	idx := rwctx.idx
 
-	sas, err := rwctx.newStreamAggrConfig()
+	sas, err := rwctx.newStreamAggrConfig(true)
 	if err != nil {
 		logger.Fatalf("cannot initialize stream aggregators: %s", err)
 	}
@@ -180,7 +210,7 @@ func (rwctx *remoteWriteCtx) reloadStreamAggrConfig() {
 	logger.Infof("reloading stream aggregation configs pointed by -remoteWrite.streamAggr.config=%q", path)
 	metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reloads_total{path=%q}`, path)).Inc()
 
-	sasNew, err := rwctx.newStreamAggrConfig()
+	sasNew, err := rwctx.newStreamAggrConfig(false)
 	if err != nil {
 		metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reloads_errors_total{path=%q}`, path)).Inc()
 		metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reload_successful{path=%q}`, path)).Set(0)
@@ -190,8 +220,28 @@ func (rwctx *remoteWriteCtx) reloadStreamAggrConfig() {
 
 	sas := rwctx.sas.Load()
 	if !sasNew.Equal(sas) {
-		sasOld := rwctx.sas.Swap(sasNew)
-		sasOld.MustStop()
+		if *streamAggrStateSaveInterval > 0 {
+			// The state of the previous aggregators must be saved before it is restored by the new aggregators,
+			// so the previous aggregators must be stopped before creating the new ones.
+			// The lock is held only while the previous aggregators are detached, so they are stopped after in-flight pushes to them are finished.
+			// Samples pushed until the new aggregators are created aren't aggregated, so the ingestion isn't blocked by the reload.
+			sasNew.MustStop()
+			rwctx.sasReloadLock.Lock()
+			sasOld := rwctx.sas.Swap(nil)
+			rwctx.sasReloadLock.Unlock()
+			sasOld.MustStop()
+			sasNew, err = rwctx.newStreamAggrConfig(true)
+			rwctx.sas.Store(sasNew)
+			if err != nil {
+				metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reloads_errors_total{path=%q}`, path)).Inc()
+				metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reload_successful{path=%q}`, path)).Set(0)
+				logger.Errorf("cannot reload -remoteWrite.streamAggr.config=%q; the aggregation is disabled until the next successful reload; error: %s", path, err)
+				return
+			}
+		} else {
+			sasOld := rwctx.sas.Swap(sasNew)
+			sasOld.MustStop()
+		}
 		logger.Infof("successfully reloaded -remoteWrite.streamAggr.config=%q", path)
 	} else {
 		sasNew.MustStop()
@@ -201,7 +251,10 @@ func (rwctx *remoteWriteCtx) reloadStreamAggrConfig() {
 	metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_streamaggr_config_reload_success_timestamp_seconds{path=%q}`, path)).Set(fasttime.UnixTimestamp())
 }
 
-func newStreamAggrConfigGlobal() (*streamaggr.Aggregators, error) {
+// newStreamAggrConfigGlobal returns aggregators for -streamAggr.config.
+//
+// The aggregators persist their state if persistState is set and -streamAggr.stateSaveInterval is positive.
+func newStreamAggrConfigGlobal(persistState bool) (*streamaggr.Aggregators, error) {
 	path := *streamAggrGlobalConfig
 	if path == "" {
 		return nil, nil
@@ -215,6 +268,9 @@ func newStreamAggrConfigGlobal() (*streamaggr.Aggregators, error) {
 		KeepInput:            *streamAggrGlobalKeepInput,
 		EnableWindows:        *streamAggrGlobalEnableWindows,
 	}
+	if persistState {
+		setStreamAggrStateOptions(opts, "global")
+	}
 
 	sas, err := streamaggr.LoadFromFile(path, pushToRemoteStoragesTrackDropped, opts, "global")
 	if err != nil {
@@ -223,11 +279,14 @@ func newStreamAggrConfigGlobal() (*streamaggr.Aggregators, error) {
 	return sas, nil
 }
 
-func (rwctx *remoteWriteCtx) newStreamAggrConfig() (*streamaggr.Aggregators, error) {
-	return newStreamAggrConfigPerURL(rwctx.idx, rwctx.pushInternalTrackDropped)
+func (rwctx *remoteWriteCtx) newStreamAggrConfig(persistState bool) (*streamaggr.Aggregators, error) {
+	return newStreamAggrConfigPerURL(rwctx.idx, rwctx.pushInternalTrackDropped, persistState)
 }
 
-func newStreamAggrConfigPerURL(idx int, pushFunc streamaggr.PushFunc) (*streamaggr.Aggregators, error) {
+// newStreamAggrConfigPerURL returns aggregators for -remoteWrite.streamAggr.config at the given idx.
+//
+// The aggregators persist their state if persistState is set and -streamAggr.stateSaveInterval is positive.
+func newStreamAggrConfigPerURL(idx int, pushFunc streamaggr.PushFunc, persistState bool) (*streamaggr.Aggregators, error) {
 	path := streamAggrConfig.GetOptionalArg(idx)
 	if path == "" {
 		return nil, nil
@@ -249,6 +308,9 @@ func newStreamAggrConfigPerURL(idx int, pushFunc streamaggr.PushFunc) (*streamag
 		KeepInput:            streamAggrKeepInput.GetOptionalArg(idx),
 		EnableWindows:        streamAggrEnableWindows.GetOptionalArg(idx),
 	}
+	if persistState {
+		setStreamAggrStateOptions(opts, getStreamAggrStateNamePerURL(idx))
+	}
 
 	sas, err := streamaggr.LoadFromFile(path, pushFunc, opts, alias)
 	if err != nil {
@@ -256,3 +318,25 @@ func newStreamAggrConfigPerURL(idx int, pushFunc streamaggr.PushFunc) (*streamag
 	}
 	return sas, nil
 }
+
+// setStreamAggrStateOptions enables state persistence in opts at the directory with the given name
+// inside -remoteWrite.tmpDataPath if -streamAggr.stateSaveInterval is set.
+func setStreamAggrStateOptions(opts *streamaggr.Options, name string) {
+	if *streamAggrStateSaveInterval <= 0 {
+		return
+	}
+	opts.StateDir = filepath.Join(*tmpDataPath, streamAggrStateDirname, name)
+	opts.StateSaveInterval = *streamAggrStateSaveInterval
+}
+
+// getStreamAggrStateNamePerURL returns the name of the directory with the persisted stream aggregation state
+// for the -remoteWrite.url at the given idx.
+//
+// The name is stable across query args changes in the same way as the name for the persistent queue.
+func getStreamAggrStateNamePerURL(idx int) string {
+	remoteWriteURL, err := url.Parse(remoteWriteURLs.GetOptionalArg(idx))
+	if err != nil {
+		logger.Fatalf("invalid -remoteWrite.url=%q: %s", remoteWriteURLs.GetOptionalArg(idx), err)
+	}
+	return fmt.Sprintf("%d_%016X", idx+1, getRemoteWriteURLHash(remoteWriteURL))
+}
@@ -0,0 +1,104 @@
+package remotewrite
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
+)
+
+func TestReloadStreamAggrConfigGlobalWithStateSaveInterval(t *testing.T) {
+	dataPath := t.TempDir()
+	configPath := filepath.Join(dataPath, "streamaggr.yaml")
+
+	origConfig, origTmpDataPath, origStateSaveInterval := *streamAggrGlobalConfig, *tmpDataPath, *streamAggrStateSaveInterval
+	defer func() {
+		*streamAggrGlobalConfig, *tmpDataPath, *streamAggrStateSaveInterval = origConfig, origTmpDataPath, origStateSaveInterval
+	}()
+	*streamAggrGlobalConfig = configPath
+	*tmpDataPath = dataPath
+	*streamAggrStateSaveInterval = time.Hour
+
+	writeConfig := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+			t.Fatalf("cannot write config: %s", err)
+		}
+	}
+	writeConfig(`
+- interval: 1m
+  outputs: [total]
+`)
+	initStreamAggrConfigGlobal()
+	defer func() {
+		sasGlobal.Swap(nil).MustStop()
+	}()
+
+	// Push samples concurrently with reloads in the same way as TryPush does.
+	stopCh := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var matchIdxs []byte
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+			tss := prometheus.MustParsePromMetrics(`foo{job="bar"} 123`, time.Now().UnixMilli())
+			sasGlobalReloadLock.RLock()
+			if sas := sasGlobal.Load(); sas.IsEnabled() {
+				matchIdxs = sas.Push(tss, matchIdxs)
+			}
+			sasGlobalReloadLock.RUnlock()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	f := func(config string) {
+		t.Helper()
+		writeConfig(config)
+		sasPrev := sasGlobal.Load()
+		reloadStreamAggrConfigGlobal()
+		sas := sasGlobal.Load()
+		if !sas.IsEnabled() {
+			t.Fatalf("stream aggregation must be enabled after the reload")
+		}
+		if sasPrev.IsEnabled() {
+			t.Fatalf("the previous aggregators must be stopped after the reload")
+		}
+	}
+
+	f(`
+- interval: 1m
+  outputs: [total]
+- interval: 5m
+  outputs: [count_samples]
+`)
+	f(`
+- interval: 1m
+  outputs: [total]
+`)
+
+	close(stopCh)
+	wg.Wait()
+
+	// The state must be saved by the stopped aggregators.
+	stateFilePath := filepath.Join(dataPath, streamAggrStateDirname, "global", "1.bin")
+	if !fs.IsPathExist(stateFilePath) {
+		t.Fatalf("missing stream aggregation state at %q", stateFilePath)
+	}
+
+	// Unchanged config must keep the current aggregators.
+	sas := sasGlobal.Load()
+	reloadStreamAggrConfigGlobal()
+	if sasGlobal.Load() != sas {
+		t.Fatalf("unexpected aggregators change after the reload of unchanged config")
+	}
+}
@@ -0,0 +1,38 @@
+package remotewrite
+
+import (
+	"flag"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
+)
+
+var tmpDataEncryptionKeyFile = flag.String("remoteWrite.tmpDataEncryptionKeyFile", "", "Optional path to file with AES keys for encrypting pending data "+
+	"stored at -remoteWrite.tmpDataPath. The file must contain a hex- or base64-encoded key with 16, 24 or 32 bytes per line. "+
+	"The first key is used for encrypting new data, while the remaining keys are used for decrypting the data encrypted with previous keys. "+
+	"The file is re-read on SIGHUP. See https://docs.victoriametrics.com/victoriametrics/vmagent/#on-disk-persistence-encryption")
+
+func initTmpDataEncryption() {
+	if *tmpDataEncryptionKeyFile == "" {
+		return
+	}
+	ek, err := persistentqueue.ReadEncryptionKeysFile(*tmpDataEncryptionKeyFile)
+	if err != nil {
+		logger.Fatalf("cannot initialize encryption for -remoteWrite.tmpDataPath: %s", err)
+	}
+	persistentqueue.SetEncryptionKeys(ek)
+}
+
+func reloadTmpDataEncryption() {
+	if *tmpDataEncryptionKeyFile == "" {
+		return
+	}
+	logger.Infof("reloading encryption keys from -remoteWrite.tmpDataEncryptionKeyFile=%q", *tmpDataEncryptionKeyFile)
+	ek, err := persistentqueue.ReadEncryptionKeysFile(*tmpDataEncryptionKeyFile)
+	if err != nil {
+		logger.Errorf("cannot reload encryption keys; preserving the previous keys; error: %s", err)
+		return
+	}
+	persistentqueue.SetEncryptionKeys(ek)
+	logger.Infof("successfully reloaded encryption keys")
+}
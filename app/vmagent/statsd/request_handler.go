@@ -0,0 +1,53 @@
+package statsd
+
+import (
+	"io"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/remotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd/stream"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	rowsInserted   = metrics.NewCounter(`vmagent_rows_inserted_total{type="statsd"}`)
+	rowsPerInsert  = metrics.NewHistogram(`vmagent_rows_per_insert{type="statsd"}`)
+	samplesDropped = metrics.NewCounter(`vmagent_statsd_aggregated_samples_dropped_total`)
+)
+
+var aggr *stream.Aggregator
+
+// Init initializes aggregation for StatsD data.
+//
+// MustStop must be called when the aggregation is no longer needed.
+func Init() {
+	aggr = stream.MustNewAggregator(pushAggregatedSeries)
+}
+
+// MustStop stops aggregation for StatsD data and pushes the pending aggregated data to remote storage.
+func MustStop() {
+	aggr.MustStop()
+	aggr = nil
+}
+
+// InsertHandler processes StatsD plaintext protocol data.
+//
+// See https://github.com/statsd/statsd/blob/master/docs/metric_types.md
+func InsertHandler(r io.Reader) error {
+	return stream.Parse(r, func(rows []parser.Row) error {
+		aggr.Push(rows)
+		rowsInserted.Add(len(rows))
+		rowsPerInsert.Update(float64(len(rows)))
+		return nil
+	})
+}
+
+func pushAggregatedSeries(tss []prompbmarshal.TimeSeries) {
+	wr := prompbmarshal.WriteRequest{
+		Timeseries: tss,
+	}
+	if !remotewrite.TryPush(nil, &wr) {
+		samplesDropped.Add(len(tss))
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/pushmetrics"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querylint"
 )
 
 var (
@@ -78,7 +79,12 @@ absolute path to all .tpl files in root.
 	externalLabels = flagutil.NewArrayString("external.label", "Optional label in the form 'Name=value' to add to all generated recording rules and alerts. "+
 		"In case of conflicts, original labels are kept with prefix `exported_`.")
 
-	dryRun = flag.Bool("dryRun", false, "Whether to check only config files without running vmalert. The rules file are validated. The -rule flag must be specified.")
+	dryRun               = flag.Bool("dryRun", false, "Whether to check only config files without running vmalert. The rules file are validated. The -rule flag must be specified.")
+	dryRunScrapeInterval = flag.Duration("dryRun.scrapeInterval", 0, "The expected scrape interval for the queried metrics. "+
+		"If set, then -dryRun warns about rollup windows shorter than two scrape intervals in rule expressions. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmalert/#linting-rules")
+	dryRunFailOnWarnings = flag.Bool("dryRun.failOnWarnings", false, "Whether to exit with non-zero code if -dryRun finds warnings for rule expressions. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmalert/#linting-rules")
 )
 
 var (
@@ -117,6 +123,16 @@ func main() {
 		if len(groups) == 0 {
 			logger.Fatalf("No rules for validation. Please specify path to file(s) with alerting and/or recording rules using `-rule` flag")
 		}
+		opts := &querylint.Options{
+			ScrapeInterval: *dryRunScrapeInterval,
+		}
+		warnings := lintGroups(groups, opts)
+		for _, w := range warnings {
+			logger.Warnf("%s", w)
+		}
+		if len(warnings) > 0 && *dryRunFailOnWarnings {
+			logger.Fatalf("found %d warnings in rule expressions; see the log above for details", len(warnings))
+		}
 		return
 	}
 
@@ -320,6 +336,28 @@ func getAlertURLGenerator(externalURL *url.URL, externalAlertSource string, vali
 	}, nil
 }
 
+// lintGroups returns warnings for expressions of rules in groups with prometheus type.
+func lintGroups(groups []config.Group, opts *querylint.Options) []string {
+	var warnings []string
+	for _, g := range groups {
+		if g.Type.String() != "prometheus" {
+			continue
+		}
+		for _, r := range g.Rules {
+			ws, err := querylint.Lint(r.Expr, opts)
+			if err != nil {
+				// This shouldn't happen, since the expression is already validated by config.Parse.
+				warnings = append(warnings, fmt.Sprintf("file %q, group %q, rule %q: cannot lint expression: %s", g.File, g.Name, r.Name(), err))
+				continue
+			}
+			for _, w := range ws {
+				warnings = append(warnings, fmt.Sprintf("file %q, group %q, rule %q: %s", g.File, g.Name, r.Name(), w.String()))
+			}
+		}
+	}
+	return warnings
+}
+
 func usage() {
 	const s = `
 vmalert processes alerts and recording rules.
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/remotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/rule"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querylint"
 )
 
 func init() {
@@ -48,6 +51,51 @@ func TestGetExternalURL(t *testing.T) {
 	}
 }
 
+func TestLintGroups(t *testing.T) {
+	groups := []config.Group{
+		{
+			File: "rules.yml",
+			Name: "good",
+			Rules: []config.Rule{
+				{Alert: "HighErrorRate", Expr: `sum(rate(http_errors_total[5m])) > 10`},
+				{Record: "job:requests:rate5m", Expr: `sum(rate(http_requests_total[5m])) by (job)`},
+			},
+		},
+		{
+			File: "rules.yml",
+			Name: "bad",
+			Rules: []config.Rule{
+				{Alert: "TemperatureGrowth", Expr: `rate(temperature_celsius[30s]) > 0`},
+				{Alert: "JobMismatch", Expr: `up{job="a"} / up{job="b"} < 1`},
+			},
+		},
+		{
+			File: "rules.yml",
+			Name: "graphite",
+			Type: config.NewGraphiteType(),
+			Rules: []config.Rule{
+				{Alert: "Graphite", Expr: `sumSeries(foo.*.bar)`},
+			},
+		},
+	}
+	opts := &querylint.Options{
+		ScrapeInterval: 30 * time.Second,
+	}
+	warnings := lintGroups(groups, opts)
+	if len(warnings) != 3 {
+		t.Fatalf("unexpected number of warnings; got %d; want 3; warnings:\n%s", len(warnings), strings.Join(warnings, "\n"))
+	}
+	for i, prefix := range []string{
+		`file "rules.yml", group "bad", rule "TemperatureGrowth": rate_over_gauge: `,
+		`file "rules.yml", group "bad", rule "TemperatureGrowth": short_rollup_window: `,
+		`file "rules.yml", group "bad", rule "JobMismatch": binary_op_label_mismatch: `,
+	} {
+		if !strings.HasPrefix(warnings[i], prefix) {
+			t.Fatalf("unexpected warning #%d; got %q; want prefix %q", i, warnings[i], prefix)
+		}
+	}
+}
+
 func TestGetAlertURLGenerator(t *testing.T) {
 	testAlert := notifier.Alert{GroupID: 42, ID: 2, Value: 4, Labels: map[string]string{"tenant": "baz"}}
 	u, _ := url.Parse("https://victoriametrics.com/path")
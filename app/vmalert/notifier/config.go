@@ -91,6 +91,7 @@ func (cfg *Config) UnmarshalYAML(unmarshal func(any) error) error {
 	cfg.parsedRelabelConfigs = rCfg
 	arCfg, err := promrelabel.ParseRelabelConfigs(cfg.AlertRelabelConfigs)
 	if err != nil {
+		rCfg.MustStop()
 		return fmt.Errorf("failed to parse alert relabeling config: %w", err)
 	}
 	cfg.parsedAlertRelabelConfigs = arCfg
@@ -105,6 +106,12 @@ func (cfg *Config) UnmarshalYAML(unmarshal func(any) error) error {
 	return nil
 }
 
+// mustStopRelabeling releases resources held by the parsed relabeling rules at cfg.
+func (cfg *Config) mustStopRelabeling() {
+	cfg.parsedRelabelConfigs.MustStop()
+	cfg.parsedAlertRelabelConfigs.MustStop()
+}
+
 func parseConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -120,10 +127,12 @@ func parseConfig(path string) (*Config, error) {
 		for k := range cfg.XXX {
 			keys = append(keys, k)
 		}
+		cfg.mustStopRelabeling()
 		return nil, fmt.Errorf("unknown fields in %s", strings.Join(keys, ", "))
 	}
 	absPath, err := filepath.Abs(path)
 	if err != nil {
+		cfg.mustStopRelabeling()
 		return nil, fmt.Errorf("cannot obtain abs path for %q: %w", path, err)
 	}
 	cfg.baseDir = filepath.Dir(absPath)
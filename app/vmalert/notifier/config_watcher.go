@@ -71,6 +71,7 @@ func (cw *configWatcher) reload(path string) error {
 		return err
 	}
 	if cfg.Checksum == cw.cfg.Checksum {
+		cfg.mustStopRelabeling()
 		return nil
 	}
 
@@ -226,6 +227,7 @@ func (cw *configWatcher) mustStop() {
 	for i := range cw.cfg.ConsulSDConfigs {
 		cw.cfg.ConsulSDConfigs[i].MustStop()
 	}
+	cw.cfg.mustStopRelabeling()
 	cw.cfg = nil
 }
 
@@ -767,6 +767,128 @@ var (
 	}
 )
 
+const (
+	rwqEncryptionKeyFile = "rwq-encryption-key-file"
+
+	rwqExportFormat = "rwq-export-format"
+	rwqExportOutput = "rwq-export-output"
+
+	rwqReplayURL                = "rwq-replay-url"
+	rwqReplayUser               = "rwq-replay-user"
+	rwqReplayPassword           = "rwq-replay-password"
+	rwqReplayBearerToken        = "rwq-replay-bearer-token"
+	rwqReplayHeaders            = "rwq-replay-headers"
+	rwqReplayHTTPTimeout        = "rwq-replay-http-timeout"
+	rwqReplayCertFile           = "rwq-replay-cert-file"
+	rwqReplayKeyFile            = "rwq-replay-key-file"
+	rwqReplayCAFile             = "rwq-replay-CA-file"
+	rwqReplayServerName         = "rwq-replay-server-name"
+	rwqReplayInsecureSkipVerify = "rwq-replay-insecure-skip-verify"
+	rwqReplayBackoffRetries     = "rwq-replay-backoff-retries"
+	rwqReplayBackoffFactor      = "rwq-replay-backoff-factor"
+	rwqReplayBackoffMinDuration = "rwq-replay-backoff-min-duration"
+
+	rwqTruncateBefore = "rwq-truncate-before"
+)
+
+var (
+	rwqFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name: rwqEncryptionKeyFile,
+			Usage: "Optional path to file with keys for decrypting persistent queue. " +
+				"It must be set to the same file as -remoteWrite.tmpDataEncryptionKeyFile at vmagent if the encryption is enabled",
+		},
+	}
+	rwqExportFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  rwqExportFormat,
+			Usage: "Format for exported data. Supported values: 'jsonl' - JSON line format accepted by /api/v1/import, 'native' - native format accepted by /api/v1/import/native",
+			Value: "jsonl",
+		},
+		&cli.StringFlag{
+			Name:  rwqExportOutput,
+			Usage: "Path to the file for exported data. The data is written to stdout if the flag isn't set",
+			Value: "-",
+		},
+	}
+	rwqReplayFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name:     rwqReplayURL,
+			Usage:    "Remote write URL to replay the queue to. For example, http://victoria-metrics:8428/api/v1/write",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:    rwqReplayUser,
+			Usage:   "Optional basic auth username for --rwq-replay-url",
+			EnvVars: []string{"RWQ_REPLAY_USERNAME"},
+		},
+		&cli.StringFlag{
+			Name:    rwqReplayPassword,
+			Usage:   "Optional basic auth password for --rwq-replay-url",
+			EnvVars: []string{"RWQ_REPLAY_PASSWORD"},
+		},
+		&cli.StringFlag{
+			Name:    rwqReplayBearerToken,
+			Usage:   "Optional bearer auth token for --rwq-replay-url",
+			EnvVars: []string{"RWQ_REPLAY_BEARER_TOKEN"},
+		},
+		&cli.StringFlag{
+			Name: rwqReplayHeaders,
+			Usage: "Optional HTTP headers to send with each request to --rwq-replay-url. \n" +
+				"Multiple headers must be delimited by '^^': --rwq-replay-headers='header1:value1^^header2:value2'",
+		},
+		&cli.DurationFlag{
+			Name:  rwqReplayHTTPTimeout,
+			Usage: "Timeout for sending a single block to --rwq-replay-url",
+			Value: time.Minute,
+		},
+		&cli.StringFlag{
+			Name:  rwqReplayCertFile,
+			Usage: "Optional path to client-side TLS certificate file to use when connecting to --rwq-replay-url",
+		},
+		&cli.StringFlag{
+			Name:  rwqReplayKeyFile,
+			Usage: "Optional path to client-side TLS key to use when connecting to --rwq-replay-url",
+		},
+		&cli.StringFlag{
+			Name:  rwqReplayCAFile,
+			Usage: "Optional path to TLS CA file to use for verifying connections to --rwq-replay-url. By default, system CA is used",
+		},
+		&cli.StringFlag{
+			Name:  rwqReplayServerName,
+			Usage: "Optional TLS server name to use for connections to --rwq-replay-url. By default, the server name from --rwq-replay-url is used",
+		},
+		&cli.BoolFlag{
+			Name:  rwqReplayInsecureSkipVerify,
+			Usage: "Whether to skip TLS certificate verification when connecting to --rwq-replay-url",
+			Value: false,
+		},
+		&cli.IntFlag{
+			Name:  rwqReplayBackoffRetries,
+			Value: 10,
+			Usage: "How many retries to perform before giving up on sending a block to --rwq-replay-url.",
+		},
+		&cli.Float64Flag{
+			Name:  rwqReplayBackoffFactor,
+			Value: 1.8,
+			Usage: "Factor to multiply the base duration after each failed retry. Must be greater than 1.0",
+		},
+		&cli.DurationFlag{
+			Name:  rwqReplayBackoffMinDuration,
+			Value: time.Second * 2,
+			Usage: "Minimum duration to wait before the first retry. Each subsequent retry will be multiplied by the backoff factor.",
+		},
+	}
+	rwqTruncateFlags = []cli.Flag{
+		&cli.TimestampFlag{
+			Name:     rwqTruncateBefore,
+			Usage:    "Samples with timestamps older than the given time in RFC3339 format are removed from the queue. E.g. '2020-01-01T20:07:00Z'",
+			Layout:   time.RFC3339,
+			Required: true,
+		},
+	}
+)
+
 func mergeFlags(flags ...[]cli.Flag) []cli.Flag {
 	var result []cli.Flag
 	for _, f := range flags {
@@ -13,12 +13,14 @@ import (
 	"time"
 
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/auth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/backoff"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/barpool"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/native"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/remoteread"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/rwqueue"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/influx"
@@ -30,6 +32,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/native/stream"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/protoparserutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/streamaggr"
 )
 
 func main() {
@@ -48,6 +51,12 @@ func main() {
 		netutil.EnableIPv6()
 		return nil
 	}
+	rwqBeforeFn := func(c *cli.Context) error {
+		if err := beforeFn(c); err != nil {
+			return err
+		}
+		return rwqueueInitEncryption(c.String(rwqEncryptionKeyFile))
+	}
 	app := &cli.App{
 		Name:    "vmctl",
 		Usage:   "VictoriaMetrics command-line tool",
@@ -385,6 +394,108 @@ func main() {
 					return p.run(ctx)
 				},
 			},
+			{
+				Name:  "remote-write-queue",
+				Usage: "Inspect, export, replay and truncate vmagent persistent queues stored at -remoteWrite.tmpDataPath",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "list",
+						Usage:     "List persistent queues and their sizes",
+						ArgsUsage: "<path to -remoteWrite.tmpDataPath>",
+						Flags:     globalFlags,
+						Before:    beforeFn,
+						Action: func(c *cli.Context) error {
+							path := c.Args().First()
+							if len(path) == 0 {
+								return cli.Exit("you must provide path to -remoteWrite.tmpDataPath", 1)
+							}
+							return rwqueueList(path)
+						},
+					},
+					{
+						Name:      "inspect",
+						Usage:     "Decode blocks from persistent queue and print the number of series, samples and the time range for them",
+						ArgsUsage: "<path to persistent queue>",
+						Flags:     mergeFlags(globalFlags, rwqFlags),
+						Before:    rwqBeforeFn,
+						Action: func(c *cli.Context) error {
+							path := c.Args().First()
+							if len(path) == 0 {
+								return cli.Exit("you must provide path to persistent queue", 1)
+							}
+							return rwqueueInspect(ctx, path, c.Bool(globalVerbose))
+						},
+					},
+					{
+						Name:      "export",
+						Usage:     "Export samples from persistent queue in JSON line or native format",
+						ArgsUsage: "<path to persistent queue>",
+						Flags:     mergeFlags(globalFlags, rwqFlags, rwqExportFlags),
+						Before:    rwqBeforeFn,
+						Action: func(c *cli.Context) error {
+							path := c.Args().First()
+							if len(path) == 0 {
+								return cli.Exit("you must provide path to persistent queue", 1)
+							}
+							return rwqueueExport(ctx, path, c.String(rwqExportFormat), c.String(rwqExportOutput))
+						},
+					},
+					{
+						Name:      "replay",
+						Usage:     "Send blocks from persistent queue to the given remote write URL",
+						ArgsUsage: "<path to persistent queue>",
+						Flags:     mergeFlags(globalFlags, rwqFlags, rwqReplayFlags),
+						Before:    rwqBeforeFn,
+						Action: func(c *cli.Context) error {
+							path := c.Args().First()
+							if len(path) == 0 {
+								return cli.Exit("you must provide path to persistent queue", 1)
+							}
+							addr := c.String(rwqReplayURL)
+							if err := httputil.CheckURL(addr); err != nil {
+								return fmt.Errorf("invalid -%s: %w", rwqReplayURL, err)
+							}
+							tr, err := promauth.NewTLSTransport(c.String(rwqReplayCertFile), c.String(rwqReplayKeyFile), c.String(rwqReplayCAFile),
+								c.String(rwqReplayServerName), c.Bool(rwqReplayInsecureSkipVerify), "vmctl_rwq_replay")
+							if err != nil {
+								return fmt.Errorf("failed to create transport for -%s=%q: %s", rwqReplayURL, addr, err)
+							}
+							bf, err := backoff.New(c.Int(rwqReplayBackoffRetries), c.Float64(rwqReplayBackoffFactor), c.Duration(rwqReplayBackoffMinDuration))
+							if err != nil {
+								return fmt.Errorf("failed to create backoff object: %s", err)
+							}
+							s, err := rwqueue.NewSender(rwqueue.SenderConfig{
+								URL:         addr,
+								Transport:   tr,
+								Timeout:     c.Duration(rwqReplayHTTPTimeout),
+								Username:    c.String(rwqReplayUser),
+								Password:    c.String(rwqReplayPassword),
+								BearerToken: c.String(rwqReplayBearerToken),
+								Headers:     c.String(rwqReplayHeaders),
+								Backoff:     bf,
+							})
+							if err != nil {
+								return fmt.Errorf("failed to create sender: %s", err)
+							}
+							return rwqueueReplay(ctx, path, s)
+						},
+					},
+					{
+						Name:      "truncate",
+						Usage:     "Remove samples older than the given time from persistent queue",
+						ArgsUsage: "<path to persistent queue>",
+						Flags:     mergeFlags(globalFlags, rwqFlags, rwqTruncateFlags),
+						Before:    rwqBeforeFn,
+						Action: func(c *cli.Context) error {
+							path := c.Args().First()
+							if len(path) == 0 {
+								return cli.Exit("you must provide path to persistent queue", 1)
+							}
+							return rwqueueTruncate(path, *c.Timestamp(rwqTruncateBefore), c.Bool(globalSilent))
+						},
+					},
+				},
+			},
 			{
 				Name:  "verify-block",
 				Usage: "Verifies exported block with VictoriaMetrics Native format",
@@ -423,6 +534,33 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:      "carbon-aggregation-rules",
+				Usage:     "Converts carbon-aggregator aggregation-rules.conf into stream aggregation config and writes it to stdout",
+				ArgsUsage: "<path to aggregation-rules.conf>",
+				Action: func(c *cli.Context) error {
+					rulesPath := c.Args().First()
+					if len(rulesPath) == 0 {
+						return cli.Exit("you must provide path for carbon aggregation rules file", 1)
+					}
+					data, err := os.ReadFile(rulesPath)
+					if err != nil {
+						return cli.Exit(fmt.Errorf("cannot read carbon aggregation rules at path=%q err=%w", rulesPath, err), 1)
+					}
+					cfgs, err := streamaggr.ConvertCarbonAggregationRules(data)
+					if err != nil {
+						return cli.Exit(fmt.Errorf("cannot convert carbon aggregation rules at path=%q err=%w", rulesPath, err), 1)
+					}
+					out, err := yaml.Marshal(cfgs)
+					if err != nil {
+						return cli.Exit(fmt.Errorf("cannot marshal stream aggregation config: %w", err), 1)
+					}
+					if _, err := os.Stdout.Write(out); err != nil {
+						return cli.Exit(fmt.Errorf("cannot write stream aggregation config to stdout: %w", err), 1)
+					}
+					return nil
+				},
+			},
 		},
 	}
 
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/rwqueue"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
+)
+
+// rwqueueList prints information about vmagent persistent queues located at tmpDataPath.
+func rwqueueList(tmpDataPath string) error {
+	qis, err := rwqueue.ListQueues(tmpDataPath)
+	if err != nil {
+		return err
+	}
+	if len(qis) == 0 {
+		log.Printf("no persistent queues found at %q", tmpDataPath)
+		return nil
+	}
+	for _, qi := range qis {
+		fmt.Printf("path=%q name=%q pendingBytes=%d chunkFiles=%d diskSize=%d\n", qi.Path, qi.Name, qi.PendingBytes(), qi.ChunkFiles, qi.DiskSize)
+	}
+	return nil
+}
+
+// rwqueueInitEncryption loads keys for decrypting persistent queues from keyFile.
+func rwqueueInitEncryption(keyFile string) error {
+	if keyFile == "" {
+		return nil
+	}
+	ek, err := persistentqueue.ReadEncryptionKeysFile(keyFile)
+	if err != nil {
+		return err
+	}
+	persistentqueue.SetEncryptionKeys(ek)
+	return nil
+}
+
+// forEachQueueBlock calls f for every pending block in persistent queue at path.
+//
+// b is nil if the block cannot be decoded. data is empty if the block is corrupted.
+func forEachQueueBlock(ctx context.Context, path string, f func(offset uint64, data []byte, b *rwqueue.Block) error) error {
+	r, err := persistentqueue.OpenBlockReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.MustClose()
+
+	var data []byte
+	var b rwqueue.Block
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		offset := r.Offset()
+		data, err = r.ReadBlock(data[:0])
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, persistentqueue.ErrCorruptedBlock) {
+			log.Printf("skipping %s", err)
+			if err := f(offset, nil, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if err := b.Unmarshal(data); err != nil {
+			log.Printf("skipping invalid block at offset %d: %s", offset, err)
+			if err := f(offset, data, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f(offset, data, &b); err != nil {
+			return err
+		}
+	}
+}
+
+// rwqueueInspect prints stats for blocks in persistent queue at path.
+//
+// Stats per each block are printed if verbose is set.
+func rwqueueInspect(ctx context.Context, path string, verbose bool) error {
+	var stats rwqueue.Stats
+	err := forEachQueueBlock(ctx, path, func(offset uint64, data []byte, b *rwqueue.Block) error {
+		if b == nil {
+			stats.UpdateInvalid(len(data))
+			return nil
+		}
+		stats.Update(b)
+		if verbose {
+			encoding := "snappy"
+			if b.IsZstd {
+				encoding = "zstd"
+			}
+			minTimestamp, maxTimestamp, _ := b.TimeRange()
+			fmt.Printf("offset=%d size=%d encoding=%s series=%d samples=%d metadata=%d timeRange=[%s..%s]\n",
+				offset, b.Size, encoding, len(b.WriteRequest.Timeseries), b.SamplesCount(), len(b.WriteRequest.Metadata),
+				formatTimestamp(minTimestamp), formatTimestamp(maxTimestamp))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("blocks=%d zstdBlocks=%d invalidBlocks=%d bytes=%d series=%d samples=%d metadata=%d\n",
+		stats.Blocks, stats.ZstdBlocks, stats.InvalidBlocks, stats.Bytes, stats.Series, stats.Samples, stats.Metadata)
+	if stats.Samples > 0 {
+		fmt.Printf("timeRange=[%s..%s]\n", formatTimestamp(stats.MinTimestamp), formatTimestamp(stats.MaxTimestamp))
+	}
+	return nil
+}
+
+func formatTimestamp(timestamp int64) string {
+	return time.UnixMilli(timestamp).UTC().Format(time.RFC3339Nano)
+}
+
+// rwqueueExport exports samples from persistent queue at path to output in the given format.
+//
+// The data is written to stdout if output is empty or equals to "-".
+func rwqueueExport(ctx context.Context, path, format, output string) error {
+	w := os.Stdout
+	if output != "" && output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("cannot create output file: %w", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		w = f
+	}
+	bw := bufio.NewWriterSize(w, 64*1024)
+	e, err := rwqueue.NewExporter(bw, format)
+	if err != nil {
+		return err
+	}
+	var stats rwqueue.Stats
+	err = forEachQueueBlock(ctx, path, func(_ uint64, data []byte, b *rwqueue.Block) error {
+		if b == nil {
+			stats.UpdateInvalid(len(data))
+			return nil
+		}
+		stats.Update(b)
+		return e.Export(b)
+	})
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot flush exported data: %w", err)
+	}
+	log.Printf("exported %d series with %d samples from %d blocks; skipped %d invalid blocks", stats.Series, stats.Samples, stats.Blocks-stats.InvalidBlocks, stats.InvalidBlocks)
+	return nil
+}
+
+// rwqueueReplay sends blocks from persistent queue at path to remote storage via s.
+//
+// The queue isn't modified, so it can be truncated after the successful replay.
+func rwqueueReplay(ctx context.Context, path string, s *rwqueue.Sender) error {
+	var stats rwqueue.Stats
+	var buf []byte
+	err := forEachQueueBlock(ctx, path, func(offset uint64, data []byte, b *rwqueue.Block) error {
+		if b == nil {
+			stats.UpdateInvalid(len(data))
+			return nil
+		}
+		buf = b.AppendSnappy(buf[:0], data)
+		if err := s.Send(ctx, buf); err != nil {
+			return fmt.Errorf("cannot send block at offset %d: %w", offset, err)
+		}
+		stats.Update(b)
+		return nil
+	})
+	log.Printf("sent %d series with %d samples from %d blocks; skipped %d invalid blocks", stats.Series, stats.Samples, stats.Blocks-stats.InvalidBlocks, stats.InvalidBlocks)
+	return err
+}
+
+// rwqueueTruncate removes samples older than minTime from persistent queue at path.
+//
+// Blocks without samples after the truncation are removed from the queue.
+func rwqueueTruncate(path string, minTime time.Time, silent bool) error {
+	qi, err := persistentqueue.ReadQueueInfo(path)
+	if err != nil {
+		return err
+	}
+	question := fmt.Sprintf("Samples older than %s will be removed from the queue %q with %d pending bytes. Continue?",
+		minTime.UTC().Format(time.RFC3339), qi.Path, qi.PendingBytes())
+	if !silent && !prompt(question) {
+		return nil
+	}
+	if !flag.Parsed() {
+		// vmctl uses its own command-line parser, while lib/persistentqueue relies on lib/memory,
+		// which must be used only after flag.Parse call. Use the default values for lib flags.
+		if err := flag.CommandLine.Parse(nil); err != nil {
+			return fmt.Errorf("cannot initialize flags: %w", err)
+		}
+	}
+	minTimestamp := minTime.UnixMilli()
+	blocksModified := 0
+	var b rwqueue.Block
+	blocksDropped, err := persistentqueue.RewriteBlocks(path, func(dst, block []byte) []byte {
+		if err := b.Unmarshal(block); err != nil {
+			log.Printf("leaving invalid block as is: %s", err)
+			return append(dst, block...)
+		}
+		dst, ok := b.AppendTruncated(dst, minTimestamp)
+		if !ok {
+			return append(dst, block...)
+		}
+		blocksModified++
+		return dst
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("removed old samples from %d blocks; %d of these blocks became empty and were removed from the queue", blocksModified, blocksDropped)
+	return nil
+}
@@ -0,0 +1,239 @@
+package rwqueue
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding/zstd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+// persistentQueueDirname is the name of directory with persistent queues inside vmagent -remoteWrite.tmpDataPath.
+const persistentQueueDirname = "persistent-queue"
+
+// ListQueues returns information about persistent queues located at the given vmagent -remoteWrite.tmpDataPath directory.
+//
+// The path may also point to the persistent-queue directory inside -remoteWrite.tmpDataPath.
+func ListQueues(path string) ([]*persistentqueue.QueueInfo, error) {
+	queuesDir := path
+	if fi, err := os.Stat(filepath.Join(path, persistentQueueDirname)); err == nil && fi.IsDir() {
+		queuesDir = filepath.Join(path, persistentQueueDirname)
+	}
+	des, err := os.ReadDir(queuesDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read persistent queues directory: %w", err)
+	}
+	var qis []*persistentqueue.QueueInfo
+	for _, de := range des {
+		if !de.IsDir() {
+			continue
+		}
+		qi, err := persistentqueue.ReadQueueInfo(filepath.Join(queuesDir, de.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read queue at %q: %w", de.Name(), err)
+		}
+		qis = append(qis, qi)
+	}
+	return qis, nil
+}
+
+// Block is a decoded block from vmagent persistent queue.
+//
+// The block contains Prometheus remote write request compressed either with snappy
+// or with zstd if VictoriaMetrics remote write protocol is used.
+type Block struct {
+	// IsZstd is set to true if the block is compressed with zstd.
+	IsZstd bool
+
+	// Size is the size of the compressed block in bytes.
+	Size int
+
+	// WriteRequest is the decoded remote write request.
+	WriteRequest prompb.WriteRequest
+
+	buf []byte
+}
+
+// Unmarshal decodes the block from src.
+//
+// src mustn't change while b is in use, since b may refer to src.
+func (b *Block) Unmarshal(src []byte) error {
+	b.IsZstd = encoding.IsZstd(src)
+	b.Size = len(src)
+	var err error
+	if b.IsZstd {
+		b.buf, err = zstd.Decompress(b.buf[:0], src)
+	} else {
+		b.buf, err = snappy.Decode(b.buf[:cap(b.buf)], src)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot decompress block with size %d bytes: %w", len(src), err)
+	}
+	if err := b.WriteRequest.UnmarshalProtobuf(b.buf); err != nil {
+		return fmt.Errorf("cannot unmarshal remote write request from %d bytes: %w", len(b.buf), err)
+	}
+	return nil
+}
+
+// SamplesCount returns the number of samples in b.
+func (b *Block) SamplesCount() int {
+	n := 0
+	for _, ts := range b.WriteRequest.Timeseries {
+		n += len(ts.Samples)
+	}
+	return n
+}
+
+// TimeRange returns the minimum and the maximum timestamps in milliseconds for the samples in b.
+//
+// false is returned if b has no samples.
+func (b *Block) TimeRange() (int64, int64, bool) {
+	minTimestamp := int64(math.MaxInt64)
+	maxTimestamp := int64(math.MinInt64)
+	for _, ts := range b.WriteRequest.Timeseries {
+		for _, s := range ts.Samples {
+			if s.Timestamp < minTimestamp {
+				minTimestamp = s.Timestamp
+			}
+			if s.Timestamp > maxTimestamp {
+				maxTimestamp = s.Timestamp
+			}
+		}
+	}
+	return minTimestamp, maxTimestamp, minTimestamp <= maxTimestamp
+}
+
+// Stats contains stats for blocks in persistent queue.
+type Stats struct {
+	Blocks        uint64
+	ZstdBlocks    uint64
+	InvalidBlocks uint64
+	Bytes         uint64
+	Series        uint64
+	Samples       uint64
+	Metadata      uint64
+
+	// MinTimestamp and MaxTimestamp contain the time range in milliseconds for all the samples.
+	//
+	// They are valid only if Samples > 0.
+	MinTimestamp int64
+	MaxTimestamp int64
+}
+
+// Update updates s with the given b.
+func (s *Stats) Update(b *Block) {
+	s.Blocks++
+	if b.IsZstd {
+		s.ZstdBlocks++
+	}
+	s.Bytes += uint64(b.Size)
+	s.Series += uint64(len(b.WriteRequest.Timeseries))
+	s.Metadata += uint64(len(b.WriteRequest.Metadata))
+	minTimestamp, maxTimestamp, ok := b.TimeRange()
+	if !ok {
+		return
+	}
+	if s.Samples == 0 || minTimestamp < s.MinTimestamp {
+		s.MinTimestamp = minTimestamp
+	}
+	if s.Samples == 0 || maxTimestamp > s.MaxTimestamp {
+		s.MaxTimestamp = maxTimestamp
+	}
+	s.Samples += uint64(b.SamplesCount())
+}
+
+// UpdateInvalid registers invalid block with the given size at s.
+func (s *Stats) UpdateInvalid(size int) {
+	s.Blocks++
+	s.InvalidBlocks++
+	s.Bytes += uint64(size)
+}
+
+// AppendTruncated appends b with samples older than minTimestamp removed to dst and returns the result.
+//
+// The result is compressed with the same compression as the original block.
+// Nothing is appended to dst if b contains no samples and metadata after the truncation.
+// The second returned value is set to false if b has no samples older than minTimestamp, so it must be left as is.
+func (b *Block) AppendTruncated(dst []byte, minTimestamp int64) ([]byte, bool) {
+	wr := &b.WriteRequest
+	hasOldSamples := false
+	for _, ts := range wr.Timeseries {
+		for _, s := range ts.Samples {
+			if s.Timestamp < minTimestamp {
+				hasOldSamples = true
+				break
+			}
+		}
+	}
+	if !hasOldSamples {
+		return dst, false
+	}
+
+	var wrm prompbmarshal.WriteRequest
+	for _, ts := range wr.Timeseries {
+		var samples []prompbmarshal.Sample
+		for _, s := range ts.Samples {
+			if s.Timestamp >= minTimestamp {
+				samples = append(samples, prompbmarshal.Sample{
+					Value:     s.Value,
+					Timestamp: s.Timestamp,
+				})
+			}
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		labels := make([]prompbmarshal.Label, len(ts.Labels))
+		for i, label := range ts.Labels {
+			labels[i] = prompbmarshal.Label{
+				Name:  label.Name,
+				Value: label.Value,
+			}
+		}
+		wrm.Timeseries = append(wrm.Timeseries, prompbmarshal.TimeSeries{
+			Labels:  labels,
+			Samples: samples,
+		})
+	}
+	for _, mm := range wr.Metadata {
+		wrm.Metadata = append(wrm.Metadata, prompbmarshal.MetricMetadata{
+			Type:             mm.Type,
+			MetricFamilyName: mm.MetricFamilyName,
+			Help:             mm.Help,
+			Unit:             mm.Unit,
+		})
+	}
+	if len(wrm.Timeseries) == 0 && len(wrm.Metadata) == 0 {
+		return dst, true
+	}
+	data := wrm.MarshalProtobuf(nil)
+	if b.IsZstd {
+		return zstd.CompressLevel(dst, data, 0), true
+	}
+	return append(dst, snappy.Encode(nil, data)...), true
+}
+
+// AppendSnappy appends b re-encoded into Prometheus remote write format to dst and returns the result.
+//
+// The original block must be passed in src.
+func (b *Block) AppendSnappy(dst, src []byte) []byte {
+	if !b.IsZstd {
+		return append(dst, src...)
+	}
+	return append(dst, snappy.Encode(nil, b.buf)...)
+}
+
+// sortSamples sorts samples by timestamp.
+func sortSamples(samples []prompb.Sample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp < samples[j].Timestamp
+	})
+}
@@ -0,0 +1,258 @@
+package rwqueue
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/golang/snappy"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding/zstd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/native/stream"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/protoparserutil"
+)
+
+func newTestBlock(isZstd bool) []byte {
+	wr := &prompbmarshal.WriteRequest{
+		Timeseries: []prompbmarshal.TimeSeries{
+			{
+				Labels: []prompbmarshal.Label{
+					{Name: "__name__", Value: "foo"},
+					{Name: "job", Value: `a"b`},
+				},
+				Samples: []prompbmarshal.Sample{
+					{Value: 2, Timestamp: 2000},
+					{Value: 1, Timestamp: 1000},
+					{Value: math.Inf(1), Timestamp: 3000},
+				},
+			},
+			{
+				Labels: []prompbmarshal.Label{
+					{Name: "__name__", Value: "bar"},
+				},
+				Samples: []prompbmarshal.Sample{
+					{Value: 1.5, Timestamp: 1500},
+				},
+			},
+		},
+		Metadata: []prompbmarshal.MetricMetadata{
+			{
+				Type:             1,
+				MetricFamilyName: "foo",
+				Help:             "some help",
+			},
+		},
+	}
+	data := wr.MarshalProtobuf(nil)
+	if isZstd {
+		return zstd.CompressLevel(nil, data, 1)
+	}
+	return snappy.Encode(nil, data)
+}
+
+func TestBlockUnmarshal(t *testing.T) {
+	f := func(isZstd bool) {
+		t.Helper()
+		var b Block
+		if err := b.Unmarshal(newTestBlock(isZstd)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if b.IsZstd != isZstd {
+			t.Fatalf("unexpected IsZstd; got %v; want %v", b.IsZstd, isZstd)
+		}
+		if n := len(b.WriteRequest.Timeseries); n != 2 {
+			t.Fatalf("unexpected number of series; got %d; want 2", n)
+		}
+		if n := b.SamplesCount(); n != 4 {
+			t.Fatalf("unexpected number of samples; got %d; want 4", n)
+		}
+		minTimestamp, maxTimestamp, ok := b.TimeRange()
+		if !ok || minTimestamp != 1000 || maxTimestamp != 3000 {
+			t.Fatalf("unexpected time range; got [%d..%d], ok=%v; want [1000..3000], ok=true", minTimestamp, maxTimestamp, ok)
+		}
+
+		var stats Stats
+		stats.Update(&b)
+		stats.UpdateInvalid(10)
+		statsExpected := Stats{
+			Blocks:        2,
+			InvalidBlocks: 1,
+			Bytes:         uint64(b.Size + 10),
+			Series:        2,
+			Samples:       4,
+			Metadata:      1,
+			MinTimestamp:  1000,
+			MaxTimestamp:  3000,
+		}
+		if isZstd {
+			statsExpected.ZstdBlocks = 1
+		}
+		if !reflect.DeepEqual(stats, statsExpected) {
+			t.Fatalf("unexpected stats;\ngot\n%+v\nwant\n%+v", stats, statsExpected)
+		}
+
+		// Re-encoding to snappy
+		data := b.AppendSnappy(nil, newTestBlock(isZstd))
+		var bSnappy Block
+		if err := bSnappy.Unmarshal(data); err != nil {
+			t.Fatalf("cannot unmarshal snappy block: %s", err)
+		}
+		if bSnappy.IsZstd {
+			t.Fatalf("the block must be compressed with snappy")
+		}
+		if !reflect.DeepEqual(bSnappy.WriteRequest.Timeseries, b.WriteRequest.Timeseries) {
+			t.Fatalf("unexpected series in snappy block;\ngot\n%+v\nwant\n%+v", bSnappy.WriteRequest.Timeseries, b.WriteRequest.Timeseries)
+		}
+	}
+	f(false)
+	f(true)
+
+	var b Block
+	if err := b.Unmarshal([]byte("invalid block")); err == nil {
+		t.Fatalf("expecting non-nil error for invalid block")
+	}
+}
+
+func TestBlockAppendTruncated(t *testing.T) {
+	f := func(isZstd bool, minTimestamp int64, resultExpected string, okExpected bool) {
+		t.Helper()
+		var b Block
+		if err := b.Unmarshal(newTestBlock(isZstd)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		data, ok := b.AppendTruncated(nil, minTimestamp)
+		if ok != okExpected {
+			t.Fatalf("unexpected ok; got %v; want %v", ok, okExpected)
+		}
+		if !ok {
+			if len(data) > 0 {
+				t.Fatalf("unexpected data for the block without old samples; got %d bytes", len(data))
+			}
+			return
+		}
+		var bTruncated Block
+		if err := bTruncated.Unmarshal(data); err != nil {
+			t.Fatalf("cannot unmarshal truncated block: %s", err)
+		}
+		if bTruncated.IsZstd != isZstd {
+			t.Fatalf("unexpected compression for truncated block; got IsZstd=%v; want %v", bTruncated.IsZstd, isZstd)
+		}
+		if len(bTruncated.WriteRequest.Metadata) != 1 {
+			t.Fatalf("metadata must be preserved in truncated block")
+		}
+		var bb bytes.Buffer
+		e, err := NewExporter(&bb, FormatJSONLine)
+		if err != nil {
+			t.Fatalf("cannot create exporter: %s", err)
+		}
+		if err := e.Export(&bTruncated); err != nil {
+			t.Fatalf("cannot export truncated block: %s", err)
+		}
+		if result := bb.String(); result != resultExpected {
+			t.Fatalf("unexpected truncated block;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	for _, isZstd := range []bool{false, true} {
+		// nothing to truncate
+		f(isZstd, 1000, "", false)
+
+		f(isZstd, 1500, `{"metric":{"__name__":"foo","job":"a\"b"},"values":[2,"Infinity"],"timestamps":[2000,3000]}
+{"metric":{"__name__":"bar"},"values":[1.5],"timestamps":[1500]}
+`, true)
+		f(isZstd, 2500, `{"metric":{"__name__":"foo","job":"a\"b"},"values":["Infinity"],"timestamps":[3000]}
+`, true)
+	}
+
+	// All the samples are truncated, but metadata remains.
+	var b Block
+	if err := b.Unmarshal(newTestBlock(false)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, ok := b.AppendTruncated(nil, 5000)
+	if !ok || len(data) == 0 {
+		t.Fatalf("the block with metadata mustn't be dropped; ok=%v, len(data)=%d", ok, len(data))
+	}
+
+	// The block without metadata is dropped when all the samples are truncated.
+	b.WriteRequest.Metadata = nil
+	data, ok = b.AppendTruncated(nil, 5000)
+	if !ok || len(data) != 0 {
+		t.Fatalf("the block must be dropped; ok=%v, len(data)=%d", ok, len(data))
+	}
+}
+
+func TestExporterJSONLine(t *testing.T) {
+	var b Block
+	if err := b.Unmarshal(newTestBlock(true)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b.WriteRequest.Timeseries[1].Samples[0].Value = math.NaN()
+
+	var bb bytes.Buffer
+	e, err := NewExporter(&bb, FormatJSONLine)
+	if err != nil {
+		t.Fatalf("cannot create exporter: %s", err)
+	}
+	if err := e.Export(&b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resultExpected := `{"metric":{"__name__":"foo","job":"a\"b"},"values":[1,2,"Infinity"],"timestamps":[1000,2000,3000]}
+{"metric":{"__name__":"bar"},"values":[null],"timestamps":[1500]}
+`
+	if result := bb.String(); result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+
+	if _, err := NewExporter(&bb, "csv"); err == nil {
+		t.Fatalf("expecting non-nil error for unsupported format")
+	}
+}
+
+func TestExporterNative(t *testing.T) {
+	var bb bytes.Buffer
+	e, err := NewExporter(&bb, FormatNative)
+	if err != nil {
+		t.Fatalf("cannot create exporter: %s", err)
+	}
+	for _, isZstd := range []bool{false, true} {
+		var b Block
+		if err := b.Unmarshal(newTestBlock(isZstd)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := e.Export(&b); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	protoparserutil.StartUnmarshalWorkers()
+	defer protoparserutil.StopUnmarshalWorkers()
+
+	var mu sync.Mutex
+	samples := make(map[string][]int64)
+	err = stream.Parse(&bb, "", func(block *stream.Block) error {
+		mu.Lock()
+		defer mu.Unlock()
+		key := string(block.MetricName.MetricGroup)
+		samples[key] = append(samples[key], block.Timestamps...)
+		if key == "foo" && string(block.MetricName.GetTagValue("job")) != `a"b` {
+			t.Errorf("unexpected job label for %s: %q", key, block.MetricName.GetTagValue("job"))
+		}
+		if key == "foo" && !math.IsInf(block.Values[len(block.Values)-1], 1) {
+			t.Errorf("unexpected last value for %s: %v", key, block.Values)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("cannot parse exported native data: %s", err)
+	}
+	samplesExpected := map[string][]int64{
+		"foo": {1000, 2000, 3000, 1000, 2000, 3000},
+		"bar": {1500, 1500},
+	}
+	if !reflect.DeepEqual(samples, samplesExpected) {
+		t.Fatalf("unexpected samples;\ngot\n%v\nwant\n%v", samples, samplesExpected)
+	}
+}
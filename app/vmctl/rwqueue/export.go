@@ -0,0 +1,161 @@
+package rwqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// Supported export formats.
+const (
+	// FormatJSONLine is JSON line format used by /api/v1/export and /api/v1/import handlers.
+	FormatJSONLine = "jsonl"
+
+	// FormatNative is native format used by /api/v1/export/native and /api/v1/import/native handlers.
+	FormatNative = "native"
+)
+
+// Exporter writes blocks from persistent queue to the underlying writer in the given format.
+type Exporter struct {
+	w      io.Writer
+	format string
+
+	headerWritten bool
+
+	buf         []byte
+	tmpBuf      []byte
+	mn          storage.MetricName
+	block       storage.Block
+	timestamps  []int64
+	floatValues []float64
+	values      []int64
+}
+
+// NewExporter returns new exporter, which writes data to w in the given format.
+func NewExporter(w io.Writer, format string) (*Exporter, error) {
+	switch format {
+	case FormatJSONLine, FormatNative:
+	default:
+		return nil, fmt.Errorf("unsupported export format %q; supported formats: %s, %s", format, FormatJSONLine, FormatNative)
+	}
+	e := &Exporter{
+		w:      w,
+		format: format,
+	}
+	return e, nil
+}
+
+// Export writes b to the underlying writer.
+func (e *Exporter) Export(b *Block) error {
+	if e.format == FormatNative && !e.headerWritten {
+		// Native format starts with the time range of the exported data.
+		// Export all the samples, since the time range isn't known in advance.
+		e.buf = encoding.MarshalInt64(e.buf[:0], math.MinInt64)
+		e.buf = encoding.MarshalInt64(e.buf, math.MaxInt64)
+		if _, err := e.w.Write(e.buf); err != nil {
+			return fmt.Errorf("cannot write native format header: %w", err)
+		}
+		e.headerWritten = true
+	}
+	e.buf = e.buf[:0]
+	for i := range b.WriteRequest.Timeseries {
+		ts := &b.WriteRequest.Timeseries[i]
+		if len(ts.Samples) == 0 {
+			continue
+		}
+		sortSamples(ts.Samples)
+		if e.format == FormatJSONLine {
+			e.buf = appendJSONLine(e.buf, ts.Labels, ts.Samples)
+		} else {
+			e.buf = e.appendNative(e.buf, ts.Labels, ts.Samples)
+		}
+	}
+	if _, err := e.w.Write(e.buf); err != nil {
+		return fmt.Errorf("cannot write exported data: %w", err)
+	}
+	return nil
+}
+
+func (e *Exporter) appendNative(dst []byte, labels []prompb.Label, samples []prompb.Sample) []byte {
+	e.mn.Reset()
+	for _, label := range labels {
+		e.mn.AddTag(label.Name, label.Value)
+	}
+	e.timestamps = e.timestamps[:0]
+	e.floatValues = e.floatValues[:0]
+	for _, s := range samples {
+		e.timestamps = append(e.timestamps, s.Timestamp)
+		e.floatValues = append(e.floatValues, s.Value)
+	}
+	var scale int16
+	e.values, scale = decimal.AppendFloatToDecimal(e.values[:0], e.floatValues)
+	e.block.Init(&storage.TSID{}, e.timestamps, e.values, scale, 64)
+
+	e.tmpBuf = e.mn.MarshalNoAccountIDProjectID(e.tmpBuf[:0])
+	dst = encoding.MarshalUint32(dst, uint32(len(e.tmpBuf)))
+	dst = append(dst, e.tmpBuf...)
+
+	e.tmpBuf = e.block.MarshalPortable(e.tmpBuf[:0])
+	dst = encoding.MarshalUint32(dst, uint32(len(e.tmpBuf)))
+	dst = append(dst, e.tmpBuf...)
+	return dst
+}
+
+// appendJSONLine appends the given series in the format used by /api/v1/export to dst and returns the result.
+func appendJSONLine(dst []byte, labels []prompb.Label, samples []prompb.Sample) []byte {
+	dst = append(dst, `{"metric":{`...)
+	for i, label := range labels {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, label.Name)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, label.Value)
+	}
+	dst = append(dst, `},"values":[`...)
+	for i, s := range samples {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONValue(dst, s.Value)
+	}
+	dst = append(dst, `],"timestamps":[`...)
+	for i, s := range samples {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = strconv.AppendInt(dst, s.Timestamp, 10)
+	}
+	dst = append(dst, "]}\n"...)
+	return dst
+}
+
+func appendJSONString(dst []byte, s string) []byte {
+	data, err := json.Marshal(s)
+	if err != nil {
+		// This cannot happen for strings.
+		return strconv.AppendQuote(dst, s)
+	}
+	return append(dst, data...)
+}
+
+// appendJSONValue appends v to dst in the same way as /api/v1/export does.
+func appendJSONValue(dst []byte, v float64) []byte {
+	switch {
+	case math.IsNaN(v):
+		return append(dst, "null"...)
+	case math.IsInf(v, 1):
+		return append(dst, `"Infinity"`...)
+	case math.IsInf(v, -1):
+		return append(dst, `"-Infinity"`...)
+	default:
+		return strconv.AppendFloat(dst, v, 'g', -1, 64)
+	}
+}
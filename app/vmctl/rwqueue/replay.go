@@ -0,0 +1,110 @@
+package rwqueue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/auth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/backoff"
+)
+
+// SenderConfig is the config for Sender.
+type SenderConfig struct {
+	// URL is the remote write URL to send data to.
+	URL string
+
+	// Transport is an optional transport to use for requests.
+	Transport *http.Transport
+
+	// Timeout is the timeout for sending a single block.
+	Timeout time.Duration
+
+	// Username and Password are optional basic auth credentials.
+	Username string
+	Password string
+
+	// BearerToken is an optional bearer token.
+	BearerToken string
+
+	// Headers contains optional HTTP headers delimited by '^^'.
+	Headers string
+
+	// Backoff is the backoff policy for failed requests.
+	Backoff *backoff.Backoff
+}
+
+// Sender sends blocks from persistent queue to remote storage via Prometheus remote write protocol.
+type Sender struct {
+	url     string
+	c       *http.Client
+	authCfg *auth.Config
+	backoff *backoff.Backoff
+}
+
+// NewSender returns new Sender for the given cfg.
+func NewSender(cfg SenderConfig) (*Sender, error) {
+	authCfg, err := auth.Generate(
+		auth.WithBasicAuth(cfg.Username, cfg.Password),
+		auth.WithBearer(cfg.BearerToken),
+		auth.WithHeaders(cfg.Headers))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create auth config: %w", err)
+	}
+	c := &http.Client{
+		Timeout: cfg.Timeout,
+	}
+	if cfg.Transport != nil {
+		c.Transport = cfg.Transport
+	}
+	s := &Sender{
+		url:     cfg.URL,
+		c:       c,
+		authCfg: authCfg,
+		backoff: cfg.Backoff,
+	}
+	return s, nil
+}
+
+// Send sends block to remote storage.
+//
+// The block must be compressed with snappy. Failed requests are retried according to the configured backoff policy.
+func (s *Sender) Send(ctx context.Context, block []byte) error {
+	_, err := s.backoff.Retry(ctx, func() error {
+		return s.send(ctx, block)
+	})
+	return err
+}
+
+func (s *Sender) send(ctx context.Context, block []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(block))
+	if err != nil {
+		return fmt.Errorf("cannot create request to %q: %w", s.url, err)
+	}
+	s.authCfg.SetHeaders(req, true)
+	h := req.Header
+	h.Set("User-Agent", "vmctl")
+	h.Set("Content-Type", "application/x-protobuf")
+	h.Set("Content-Encoding", "snappy")
+	h.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := s.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send block with size %d bytes to %q: %w", len(block), s.url, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusConflict:
+		// The remote storage rejected the block. There is no sense in retrying it.
+		return fmt.Errorf("%w: block with size %d bytes was rejected by %q with status code %d; response body: %s",
+			backoff.ErrBadRequest, len(block), s.url, resp.StatusCode, body)
+	default:
+		return fmt.Errorf("unexpected status code %d received from %q; response body: %s", resp.StatusCode, s.url, body)
+	}
+}
@@ -27,6 +27,15 @@ func InsertHandler(at *auth.Token, r io.Reader) error {
 	})
 }
 
+// PickleInsertHandler processes remote write for graphite pickle protocol.
+//
+// See https://graphite.readthedocs.io/en/latest/feeding-carbon.html#the-pickle-protocol
+func PickleInsertHandler(at *auth.Token, r io.Reader) error {
+	return stream.ParsePickle(r, func(rows []parser.Row) error {
+		return insertRows(at, rows)
+	})
+}
+
 func insertRows(at *auth.Token, rows []parser.Row) error {
 	ctx := netstorage.GetInsertCtx()
 	defer netstorage.PutInsertCtx(ctx)
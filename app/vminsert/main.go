@@ -28,6 +28,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/prometheusimport"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/promremotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/statsd"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/vmimport"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/buildinfo"
@@ -39,8 +40,10 @@ import (
 	clusternativeserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/clusternative"
 	graphiteserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/graphite"
 	influxserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/influx"
+	opentelemetryserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentelemetry"
 	opentsdbserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentsdb"
 	opentsdbhttpserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentsdbhttp"
+	statsdserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/statsd"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/firehose"
@@ -56,6 +59,10 @@ var (
 		"See also -graphiteListenAddr.useProxyProtocol")
 	graphiteUseProxyProtocol = flag.Bool("graphiteListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted at -graphiteListenAddr . "+
 		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
+	graphitePickleListenAddr = flag.String("graphitePickleListenAddr", "", "TCP address to listen for Graphite pickle protocol data. Usually :2004 must be set. Doesn't work if empty. "+
+		"See https://docs.victoriametrics.com/victoriametrics/integrations/graphite/#pickle-protocol . See also -graphitePickleListenAddr.useProxyProtocol")
+	graphitePickleUseProxyProtocol = flag.Bool("graphitePickleListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted at -graphitePickleListenAddr . "+
+		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
 	influxListenAddr = flag.String("influxListenAddr", "", "TCP and UDP address to listen for InfluxDB line protocol data. Usually :8089 must be set. Doesn't work if empty. "+
 		"This flag isn't needed when ingesting data over HTTP - just send it to http://<victoriametrics>:8428/write . "+
 		"See also -influxListenAddr.useProxyProtocol")
@@ -71,6 +78,14 @@ var (
 		"See also -opentsdbHTTPListenAddr.useProxyProtocol")
 	opentsdbHTTPUseProxyProtocol = flag.Bool("opentsdbHTTPListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted "+
 		"at -opentsdbHTTPListenAddr . See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
+	opentelemetryGRPCListenAddr = flag.String("opentelemetry.grpcListenAddr", "", "TCP address to listen for OpenTelemetry metrics via OTLP/gRPC protocol. Usually :4317 must be set. Doesn't work if empty. "+
+		"See https://docs.victoriametrics.com/victoriametrics/cluster-victoriametrics/#opentelemetry-grpc-listener . See also -opentelemetry.grpcListenAddr.useProxyProtocol")
+	opentelemetryGRPCUseProxyProtocol = flag.Bool("opentelemetry.grpcListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted "+
+		"at -opentelemetry.grpcListenAddr . See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
+	statsdListenAddr = flag.String("statsdListenAddr", "", "TCP and UDP address to listen for StatsD plaintext data. Usually :8125 must be set. Doesn't work if empty. "+
+		"See https://docs.victoriametrics.com/victoriametrics/cluster-victoriametrics/#statsd-listener . See also -statsdListenAddr.useProxyProtocol")
+	statsdUseProxyProtocol = flag.Bool("statsdListenAddr.useProxyProtocol", false, "Whether to use proxy protocol for connections accepted at -statsdListenAddr . "+
+		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
 	httpListenAddrs  = flagutil.NewArrayString("httpListenAddr", "Address to listen for incoming http requests. See also -httpListenAddr.useProxyProtocol")
 	useProxyProtocol = flagutil.NewArrayBool("httpListenAddr.useProxyProtocol", "Whether to use proxy protocol for connections accepted at the given -httpListenAddr . "+
 		"See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt . "+
@@ -84,11 +99,14 @@ var (
 )
 
 var (
-	clusternativeServer *clusternativeserver.Server
-	graphiteServer      *graphiteserver.Server
-	influxServer        *influxserver.Server
-	opentsdbServer      *opentsdbserver.Server
-	opentsdbhttpServer  *opentsdbhttpserver.Server
+	clusternativeServer  *clusternativeserver.Server
+	graphiteServer       *graphiteserver.Server
+	graphitePickleServer *graphiteserver.PickleServer
+	influxServer         *influxserver.Server
+	opentsdbServer       *opentsdbserver.Server
+	opentsdbhttpServer   *opentsdbhttpserver.Server
+	opentelemetryServer  *opentelemetryserver.Server
+	statsdServer         *statsdserver.Server
 )
 
 func main() {
@@ -133,6 +151,11 @@ func main() {
 			return graphite.InsertHandler(nil, r)
 		})
 	}
+	if len(*graphitePickleListenAddr) > 0 {
+		graphitePickleServer = graphiteserver.MustStartPickle(*graphitePickleListenAddr, *graphitePickleUseProxyProtocol, func(r io.Reader) error {
+			return graphite.PickleInsertHandler(nil, r)
+		})
+	}
 	if len(*influxListenAddr) > 0 {
 		influxServer = influxserver.MustStart(*influxListenAddr, *influxUseProxyProtocol, func(r io.Reader) error {
 			return influx.InsertHandlerForReader(nil, r)
@@ -146,6 +169,13 @@ func main() {
 	if len(*opentsdbHTTPListenAddr) > 0 {
 		opentsdbhttpServer = opentsdbhttpserver.MustStart(*opentsdbHTTPListenAddr, *opentsdbHTTPUseProxyProtocol, opentsdbhttp.InsertHandler)
 	}
+	if len(*opentelemetryGRPCListenAddr) > 0 {
+		opentelemetryServer = opentelemetryserver.MustStart(*opentelemetryGRPCListenAddr, *opentelemetryGRPCUseProxyProtocol, opentelemetry.GRPCInsertHandler)
+	}
+	if len(*statsdListenAddr) > 0 {
+		statsd.Init()
+		statsdServer = statsdserver.MustStart(*statsdListenAddr, *statsdUseProxyProtocol, statsd.InsertHandler)
+	}
 
 	listenAddrs := *httpListenAddrs
 	if len(listenAddrs) == 0 {
@@ -171,6 +201,9 @@ func main() {
 	if len(*graphiteListenAddr) > 0 {
 		graphiteServer.MustStop()
 	}
+	if len(*graphitePickleListenAddr) > 0 {
+		graphitePickleServer.MustStop()
+	}
 	if len(*influxListenAddr) > 0 {
 		influxServer.MustStop()
 	}
@@ -180,6 +213,13 @@ func main() {
 	if len(*opentsdbHTTPListenAddr) > 0 {
 		opentsdbhttpServer.MustStop()
 	}
+	if len(*opentelemetryGRPCListenAddr) > 0 {
+		opentelemetryServer.MustStop()
+	}
+	if len(*statsdListenAddr) > 0 {
+		statsdServer.MustStop()
+		statsd.MustStop()
+	}
 	protoparserutil.StopUnmarshalWorkers()
 
 	logger.Infof("shutting down neststorage...")
@@ -42,6 +42,11 @@ func InsertHandler(at *auth.Token, req *http.Request) error {
 	})
 }
 
+// GRPCInsertHandler processes OpenTelemetry metrics received via OTLP/gRPC.
+func GRPCInsertHandler(at *auth.Token, extraLabels []prompbmarshal.Label, tss []prompbmarshal.TimeSeries) error {
+	return insertRows(at, tss, extraLabels)
+}
+
 func insertRows(at *auth.Token, tss []prompbmarshal.TimeSeries, extraLabels []prompbmarshal.Label) error {
 	ctx := netstorage.GetInsertCtx()
 	defer netstorage.PutInsertCtx(ctx)
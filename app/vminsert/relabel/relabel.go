@@ -91,8 +91,10 @@ func Init() {
 				// do not update configTimestamp as config version remains old.
 				configSuccess.Set(1)
 				noChangesLogFn()
+				pcsNew.MustStop()
 				continue
 			}
+			pcs.MustStop()
 			pcs = pcsNew
 			pcsGlobal.Store(pcsNew)
 
@@ -129,8 +131,12 @@ var pcsGlobal atomic.Pointer[promrelabel.ParsedConfigs]
 
 // CheckRelabelConfig checks config pointed by -relabelConfig
 func CheckRelabelConfig() error {
-	_, err := loadRelabelConfig()
-	return err
+	pcs, err := loadRelabelConfig()
+	if err != nil {
+		return err
+	}
+	pcs.MustStop()
+	return nil
 }
 
 func loadRelabelConfig() (*promrelabel.ParsedConfigs, error) {
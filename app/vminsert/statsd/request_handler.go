@@ -0,0 +1,86 @@
+package statsd
+
+import (
+	"io"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd/stream"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/tenantmetrics"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	rowsInserted       = metrics.NewCounter(`vm_rows_inserted_total{type="statsd"}`)
+	rowsTenantInserted = tenantmetrics.NewCounterMap(`vm_tenant_inserted_rows_total{type="statsd"}`)
+	rowsPerInsert      = metrics.NewHistogram(`vm_rows_per_insert{type="statsd"}`)
+)
+
+var aggr *stream.Aggregator
+
+// Init initializes aggregation for StatsD data.
+//
+// MustStop must be called when the aggregation is no longer needed.
+func Init() {
+	aggr = stream.MustNewAggregator(pushAggregatedSeries)
+}
+
+// MustStop stops aggregation for StatsD data and writes the pending aggregated data to vmstorage nodes.
+func MustStop() {
+	aggr.MustStop()
+	aggr = nil
+}
+
+// InsertHandler processes StatsD plaintext protocol data.
+//
+// The tenant for the aggregated data is obtained from `vm_account_id` and `vm_project_id` tags.
+//
+// See https://github.com/statsd/statsd/blob/master/docs/metric_types.md
+func InsertHandler(r io.Reader) error {
+	return stream.Parse(r, func(rows []parser.Row) error {
+		aggr.Push(rows)
+		return nil
+	})
+}
+
+func pushAggregatedSeries(tss []prompbmarshal.TimeSeries) {
+	if err := insertRows(nil, tss); err != nil {
+		logger.Errorf("cannot insert aggregated StatsD data: %s", err)
+	}
+}
+
+func insertRows(at *auth.Token, tss []prompbmarshal.TimeSeries) error {
+	ctx := netstorage.GetInsertCtx()
+	defer netstorage.PutInsertCtx(ctx)
+
+	ctx.Reset() // This line is required for initializing ctx internals.
+	perTenantRows := make(map[auth.Token]int)
+	hasRelabeling := relabel.HasRelabeling()
+	rowsTotal := 0
+	for i := range tss {
+		ts := &tss[i]
+		ctx.Labels = ctx.Labels[:0]
+		for _, label := range ts.Labels {
+			ctx.AddLabel(label.Name, label.Value)
+		}
+		if !ctx.TryPrepareLabels(hasRelabeling) {
+			continue
+		}
+		atLocal := ctx.GetLocalAuthToken(at)
+		for _, sample := range ts.Samples {
+			if err := ctx.WriteDataPoint(atLocal, ctx.Labels, sample.Timestamp, sample.Value); err != nil {
+				return err
+			}
+		}
+		perTenantRows[*atLocal] += len(ts.Samples)
+		rowsTotal += len(ts.Samples)
+	}
+	rowsInserted.Add(rowsTotal)
+	rowsTenantInserted.MultiAdd(perTenantRows)
+	rowsPerInsert.Update(float64(rowsTotal))
+	return ctx.FlushBufs()
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/promql"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/cgroup"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputil"
@@ -48,9 +49,9 @@ func NewVMSelectServer(addr string) (*vmselectapi.Server, error) {
 type vmstorageAPI struct{}
 
 func (api *vmstorageAPI) InitSearch(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline uint64) (vmselectapi.BlockIterator, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	bi := newBlockIterator(qt, denyPartialResponse, sq, dl)
+	bi := newBlockIterator(qt, pr, sq, dl)
 	return bi, nil
 }
 
@@ -60,48 +61,55 @@ func (api *vmstorageAPI) Tenants(qt *querytracer.Tracer, tr storage.TimeRange, d
 }
 
 func (api *vmstorageAPI) SearchMetricNames(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline uint64) ([]string, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	metricNames, _, err := netstorage.SearchMetricNames(qt, denyPartialResponse, sq, dl)
+	metricNames, _, err := netstorage.SearchMetricNames(qt, pr, sq, dl)
 	return metricNames, err
 }
 
 func (api *vmstorageAPI) LabelValues(qt *querytracer.Tracer, sq *storage.SearchQuery, labelName string, maxLabelValues int, deadline uint64) ([]string, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	labelValues, _, err := netstorage.LabelValues(qt, denyPartialResponse, labelName, sq, maxLabelValues, dl)
+	labelValues, _, err := netstorage.LabelValues(qt, pr, labelName, sq, maxLabelValues, dl)
 	return labelValues, err
 }
 
 func (api *vmstorageAPI) TagValueSuffixes(qt *querytracer.Tracer, accountID, projectID uint32, tr storage.TimeRange, tagKey, tagValuePrefix string, delimiter byte,
 	maxSuffixes int, deadline uint64) ([]string, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	suffixes, _, err := netstorage.TagValueSuffixes(qt, accountID, projectID, denyPartialResponse, tr, tagKey, tagValuePrefix, delimiter, maxSuffixes, dl)
+	suffixes, _, err := netstorage.TagValueSuffixes(qt, accountID, projectID, pr, tr, tagKey, tagValuePrefix, delimiter, maxSuffixes, dl)
 	return suffixes, err
 }
 
 func (api *vmstorageAPI) LabelNames(qt *querytracer.Tracer, sq *storage.SearchQuery, maxLabelNames int, deadline uint64) ([]string, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	labelNames, _, err := netstorage.LabelNames(qt, denyPartialResponse, sq, maxLabelNames, dl)
+	labelNames, _, err := netstorage.LabelNames(qt, pr, sq, maxLabelNames, dl)
 	return labelNames, err
 }
 
 func (api *vmstorageAPI) SeriesCount(qt *querytracer.Tracer, accountID, projectID uint32, deadline uint64) (uint64, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	seriesCount, _, err := netstorage.SeriesCount(qt, accountID, projectID, denyPartialResponse, dl)
+	seriesCount, _, err := netstorage.SeriesCount(qt, accountID, projectID, pr, dl)
 	return seriesCount, err
 }
 
 func (api *vmstorageAPI) TSDBStatus(qt *querytracer.Tracer, sq *storage.SearchQuery, focusLabel string, topN int, deadline uint64) (*storage.TSDBStatus, error) {
-	denyPartialResponse := httputil.GetDenyPartialResponse(nil)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
 	dl := searchutil.DeadlineFromTimestamp(deadline)
-	tsdbStatus, _, err := netstorage.TSDBStatus(qt, denyPartialResponse, sq, focusLabel, topN, dl)
+	tsdbStatus, _, err := netstorage.TSDBStatus(qt, pr, sq, focusLabel, topN, dl)
 	return tsdbStatus, err
 }
 
+func (api *vmstorageAPI) NewSeriesStatus(qt *querytracer.Tracer, sq *storage.SearchQuery, focusLabel string, topN int, deadline uint64) (*storage.TSDBStatus, error) {
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(nil))
+	dl := searchutil.DeadlineFromTimestamp(deadline)
+	status, _, err := netstorage.NewSeriesStatus(qt, pr, sq, focusLabel, topN, dl)
+	return status, err
+}
+
 func (api *vmstorageAPI) DeleteSeries(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline uint64) (int, error) {
 	dl := searchutil.DeadlineFromTimestamp(deadline)
 	return netstorage.DeleteSeries(qt, sq, dl)
@@ -122,6 +130,26 @@ func (api *vmstorageAPI) GetMetricNamesUsageStats(qt *querytracer.Tracer, tt *st
 	return netstorage.GetMetricNamesStats(qt, tt, le, limit, matchPattern, dl)
 }
 
+// CheckRollupResultCacheAuthKey implements vmselectapi.RollupResultCacheAPI
+func (api *vmstorageAPI) CheckRollupResultCacheAuthKey(authKey []byte) error {
+	return netstorage.CheckRollupResultCachePeerAuthKey(authKey)
+}
+
+// GetRollupResultCacheEntry implements vmselectapi.RollupResultCacheAPI
+func (api *vmstorageAPI) GetRollupResultCacheEntry(dst, key []byte, isBig bool) []byte {
+	return promql.GetRollupResultCacheEntry(dst, key, isBig)
+}
+
+// SetRollupResultCacheEntry implements vmselectapi.RollupResultCacheAPI
+func (api *vmstorageAPI) SetRollupResultCacheEntry(key, value []byte, isBig bool) {
+	promql.SetRollupResultCacheEntry(key, value, isBig)
+}
+
+// UpdateRollupResultCacheMetainfo implements vmselectapi.RollupResultCacheAPI
+func (api *vmstorageAPI) UpdateRollupResultCacheMetainfo(key, update []byte) error {
+	return promql.UpdateRollupResultCacheMetainfo(key, update)
+}
+
 // blockIterator implements vmselectapi.BlockIterator
 type blockIterator struct {
 	workCh chan workItem
@@ -134,12 +162,12 @@ type workItem struct {
 	doneCh chan struct{}
 }
 
-func newBlockIterator(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.SearchQuery, deadline searchutil.Deadline) *blockIterator {
+func newBlockIterator(qt *querytracer.Tracer, pr *netstorage.PartialResponse, sq *storage.SearchQuery, deadline searchutil.Deadline) *blockIterator {
 	var bi blockIterator
 	bi.workCh = make(chan workItem, 16)
 	bi.wg.Add(1)
 	go func() {
-		_, err := netstorage.ProcessBlocks(qt, denyPartialResponse, sq, func(mb *storage.MetricBlock, _ uint) error {
+		_, err := netstorage.ProcessBlocks(qt, pr, sq, func(mb *storage.MetricBlock, _ uint) error {
 			wi := workItem{
 				mb:     mb,
 				doneCh: make(chan struct{}),
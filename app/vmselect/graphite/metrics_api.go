@@ -79,8 +79,8 @@ func MetricsFindHandler(startTime time.Time, at *auth.Token, w http.ResponseWrit
 		MinTimestamp: from,
 		MaxTimestamp: until,
 	}
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
-	paths, isPartial, err := metricsFind(at, denyPartialResponse, tr, label, "", query, delimiter[0], false, deadline)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
+	paths, isPartial, err := metricsFind(at, pr, tr, label, "", query, delimiter[0], false, deadline)
 	if err != nil {
 		return err
 	}
@@ -156,9 +156,9 @@ func MetricsExpandHandler(startTime time.Time, at *auth.Token, w http.ResponseWr
 	}
 	m := make(map[string][]string, len(queries))
 	isPartialResponse := false
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
 	for _, query := range queries {
-		paths, isPartial, err := metricsFind(at, denyPartialResponse, tr, label, "", query, delimiter[0], true, deadline)
+		paths, isPartial, err := metricsFind(at, pr, tr, label, "", query, delimiter[0], true, deadline)
 		if err != nil {
 			return err
 		}
@@ -209,9 +209,9 @@ func MetricsExpandHandler(startTime time.Time, at *auth.Token, w http.ResponseWr
 func MetricsIndexHandler(startTime time.Time, at *auth.Token, w http.ResponseWriter, r *http.Request) error {
 	deadline := searchutil.GetDeadlineForQuery(r, startTime)
 	jsonp := r.FormValue("jsonp")
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
 	sq := storage.NewSearchQuery(at.AccountID, at.ProjectID, 0, 0, nil, 0)
-	metricNames, isPartial, err := netstorage.LabelValues(nil, denyPartialResponse, "__name__", sq, 0, deadline)
+	metricNames, isPartial, err := netstorage.LabelValues(nil, pr, "__name__", sq, 0, deadline)
 	if err != nil {
 		return fmt.Errorf(`cannot obtain metric names: %w`, err)
 	}
@@ -228,12 +228,12 @@ func MetricsIndexHandler(startTime time.Time, at *auth.Token, w http.ResponseWri
 }
 
 // metricsFind searches for label values that match the given qHead and qTail.
-func metricsFind(at *auth.Token, denyPartialResponse bool, tr storage.TimeRange, label, qHead, qTail string, delimiter byte,
+func metricsFind(at *auth.Token, pr *netstorage.PartialResponse, tr storage.TimeRange, label, qHead, qTail string, delimiter byte,
 	isExpand bool, deadline searchutil.Deadline) ([]string, bool, error) {
 	n := strings.IndexAny(qTail, "*{[")
 	if n < 0 {
 		query := qHead + qTail
-		suffixes, isPartial, err := netstorage.TagValueSuffixes(nil, at.AccountID, at.ProjectID, denyPartialResponse, tr, label, query, delimiter, *maxTagValueSuffixes, deadline)
+		suffixes, isPartial, err := netstorage.TagValueSuffixes(nil, at.AccountID, at.ProjectID, pr, tr, label, query, delimiter, *maxTagValueSuffixes, deadline)
 		if err != nil {
 			return nil, false, err
 		}
@@ -253,7 +253,7 @@ func metricsFind(at *auth.Token, denyPartialResponse bool, tr storage.TimeRange,
 	}
 	if n == len(qTail)-1 && strings.HasSuffix(qTail, "*") {
 		query := qHead + qTail[:len(qTail)-1]
-		suffixes, isPartial, err := netstorage.TagValueSuffixes(nil, at.AccountID, at.ProjectID, denyPartialResponse, tr, label, query, delimiter, *maxTagValueSuffixes, deadline)
+		suffixes, isPartial, err := netstorage.TagValueSuffixes(nil, at.AccountID, at.ProjectID, pr, tr, label, query, delimiter, *maxTagValueSuffixes, deadline)
 		if err != nil {
 			return nil, false, err
 		}
@@ -267,7 +267,7 @@ func metricsFind(at *auth.Token, denyPartialResponse bool, tr storage.TimeRange,
 		return results, isPartial, nil
 	}
 	qHead += qTail[:n]
-	paths, isPartial, err := metricsFind(at, denyPartialResponse, tr, label, qHead, "*", delimiter, isExpand, deadline)
+	paths, isPartial, err := metricsFind(at, pr, tr, label, qHead, "*", delimiter, isExpand, deadline)
 	if err != nil {
 		return nil, false, err
 	}
@@ -291,7 +291,7 @@ func metricsFind(at *auth.Token, denyPartialResponse bool, tr storage.TimeRange,
 			results = append(results, path)
 			continue
 		}
-		fullPaths, isPartialLocal, err := metricsFind(at, denyPartialResponse, tr, label, path, qTail, delimiter, isExpand, deadline)
+		fullPaths, isPartialLocal, err := metricsFind(at, pr, tr, label, path, qTail, delimiter, isExpand, deadline)
 		if err != nil {
 			return nil, false, err
 		}
@@ -111,6 +111,7 @@ func main() {
 
 	netutil.InitConcurrentDialLimit(*maxConcurrentRequests)
 	netstorage.Init(*storageNodes)
+	netstorage.InitRollupResultCachePeers()
 	logger.Infof("started netstorage in %.3f seconds", time.Since(startTime).Seconds())
 
 	if len(*cacheDataPath) > 0 {
@@ -162,6 +163,7 @@ func main() {
 	logger.Infof("shutting down neststorage...")
 	startTime = time.Now()
 	netstorage.MustStop()
+	netstorage.MustStopRollupResultCachePeers()
 	if len(*cacheDataPath) > 0 {
 		promql.StopRollupResultCache()
 	}
@@ -399,6 +401,24 @@ func selectHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseW
 			return true
 		}
 		return true
+	case "prometheus/api/v1/status/new_series":
+		statusNewSeriesRequests.Inc()
+		httpserver.EnableCORS(w, r)
+		if err := prometheus.NewSeriesStatusHandler(qt, startTime, at, w, r); err != nil {
+			statusNewSeriesErrors.Inc()
+			httpserver.SendPrometheusError(w, r, err)
+			return true
+		}
+		return true
+	case "prometheus/api/v1/status/tsdb_diff":
+		statusTSDBDiffRequests.Inc()
+		httpserver.EnableCORS(w, r)
+		if err := prometheus.TSDBStatusDiffHandler(qt, startTime, at, w, r); err != nil {
+			statusTSDBDiffErrors.Inc()
+			httpserver.SendPrometheusError(w, r, err)
+			return true
+		}
+		return true
 	case "prometheus/api/v1/export":
 		exportRequests.Inc()
 		if err := prometheus.ExportHandler(startTime, at, w, r); err != nil {
@@ -595,6 +615,8 @@ func handleStaticAndSimpleRequests(w http.ResponseWriter, r *http.Request, path
 		fmt.Fprintf(w, `<a href="target-relabel-debug">target-level relabel debugging</a></br>`)
 		fmt.Fprintf(w, `<a href="expand-with-exprs">WITH expressions' tutorial</a></br>`)
 		fmt.Fprintf(w, `<a href="prometheus/api/v1/status/tsdb">tsdb status page</a><br>`)
+		fmt.Fprintf(w, `<a href="prometheus/api/v1/status/new_series">new series status page</a><br>`)
+		fmt.Fprintf(w, `<a href="prometheus/api/v1/status/tsdb_diff">tsdb status diff page</a><br>`)
 		fmt.Fprintf(w, `<a href="prometheus/api/v1/status/top_queries">top queries</a><br>`)
 		fmt.Fprintf(w, `<a href="prometheus/api/v1/status/active_queries">active queries</a><br>`)
 		return true
@@ -737,6 +759,14 @@ func handleStaticAndSimpleRequests(w http.ResponseWriter, r *http.Request, path
 		prettifyQueryRequests.Inc()
 		prometheus.PrettifyQuery(w, r)
 		return true
+	case "prometheus/lint-query", "lint-query":
+		lintQueryRequests.Inc()
+		if err := prometheus.LintQuery(w, r); err != nil {
+			lintQueryErrors.Inc()
+			httpserver.SendPrometheusError(w, r, err)
+			return true
+		}
+		return true
 	case "prometheus/api/v1/rules", "prometheus/rules":
 		rulesRequests.Inc()
 		if len(*vmalertProxyURL) > 0 {
@@ -837,6 +867,12 @@ var (
 	statusTSDBRequests = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/api/v1/status/tsdb"}`)
 	statusTSDBErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/select/{}/prometheus/api/v1/status/tsdb"}`)
 
+	statusNewSeriesRequests = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/api/v1/status/new_series"}`)
+	statusNewSeriesErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/select/{}/prometheus/api/v1/status/new_series"}`)
+
+	statusTSDBDiffRequests = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/api/v1/status/tsdb_diff"}`)
+	statusTSDBDiffErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/select/{}/prometheus/api/v1/status/tsdb_diff"}`)
+
 	globalStatusActiveQueriesRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/status/active_queries"}`)
 	statusActiveQueriesRequests       = metrics.NewCounter(`vm_http_requests_total{path="/select/{}prometheus/api/v1/status/active_queries"}`)
 
@@ -908,6 +944,8 @@ var (
 
 	expandWithExprsRequests = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/expand-with-exprs"}`)
 	prettifyQueryRequests   = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/prettify-query"}`)
+	lintQueryRequests       = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/lint-query"}`)
+	lintQueryErrors         = metrics.NewCounter(`vm_http_request_errors_total{path="/select/{}/prometheus/lint-query"}`)
 
 	vmalertRequests = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/vmalert"}`)
 	rulesRequests   = metrics.NewCounter(`vm_http_requests_total{path="/select/{}/prometheus/api/v1/rules"}`)
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -46,6 +47,11 @@ var (
 	skipSlowReplicas = flag.Bool("search.skipSlowReplicas", false, "Whether to skip -replicationFactor - 1 slowest vmstorage nodes during querying. "+
 		"Enabling this setting may improve query speed, but it could also lead to incomplete results if some queried data has less than -replicationFactor "+
 		"copies at vmstorage nodes. Consider enabling this setting only if all the queried data contains -replicationFactor copies in the cluster")
+	bestEffortStorageGroups = flagutil.NewArrayString("search.bestEffortStorageGroup", "Optional names of vmstorage groups, which are queried on a best-effort basis. "+
+		"Unavailable vmstorage nodes at these groups do not make the response partial and do not fail queries with -search.denyPartialResponse. "+
+		"Such nodes are still reported in VM-Missing-Storage-Nodes response header and in missingStorageNodes field of JSON responses. "+
+		"Data export and tenants list still require full responses from these groups. "+
+		"See https://docs.victoriametrics.com/victoriametrics/cluster-victoriametrics/#vmstorage-groups-at-vmselect")
 	maxSamplesPerSeries  = flag.Int("search.maxSamplesPerSeries", 30e6, "The maximum number of raw samples a single query can scan per each time series. See also -search.maxSamplesPerQuery")
 	maxSamplesPerQuery   = flag.Int("search.maxSamplesPerQuery", 1e9, "The maximum number of raw samples a single query can process across all time series. This protects from heavy queries, which select unexpectedly high number of raw samples. See also -search.maxSamplesPerSeries")
 	vmstorageDialTimeout = flag.Duration("vmstorageDialTimeout", 3*time.Second, "Timeout for establishing RPC connections from vmselect to vmstorage. "+
@@ -375,6 +381,9 @@ var (
 type packedTimeseries struct {
 	metricName string
 	addrs      []tmpBlockAddr
+
+	// replicaAddrs contains per-replica addrs for series merged by Results.MergeReplicas.
+	replicaAddrs [][]tmpBlockAddr
 }
 
 type unpackWork struct {
@@ -470,6 +479,9 @@ func (pts *packedTimeseries) Unpack(dst *Result, tbfs []*tmpBlocksFile, tr stora
 	if err := dst.MetricName.Unmarshal(bytesutil.ToUnsafeBytes(pts.metricName)); err != nil {
 		return fmt.Errorf("cannot unmarshal metricName %q: %w", pts.metricName, err)
 	}
+	if len(pts.replicaAddrs) > 0 {
+		return pts.unpackReplicas(dst, tbfs, tr)
+	}
 	sbh := getSortBlocksHeap()
 	var err error
 	sbh.sbs, err = pts.unpackTo(sbh.sbs[:0], tbfs, tr)
@@ -823,7 +835,7 @@ func RegisterMetricNames(qt *querytracer.Tracer, mrs []storage.MetricRow, deadli
 	}
 
 	// Push mrs to storage nodes in parallel.
-	snr := startStorageNodesRequest(qt, sns, true, func(qt *querytracer.Tracer, workerID uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, NewPartialResponse(true), func(qt *querytracer.Tracer, workerID uint, sn *storageNode) any {
 		sn.registerMetricNamesRequests.Inc()
 		err := sn.registerMetricNames(qt, mrsPerNode[workerID], deadline)
 		if err != nil {
@@ -858,7 +870,7 @@ func DeleteSeries(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline sear
 		return 0, err
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, true, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, NewPartialResponse(true), func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		return execSearchQuery(qt, sq, func(qt *querytracer.Tracer, requestData []byte, _ storage.TenantToken) any {
 			sn.deleteSeriesRequests.Inc()
 			deletedCount, err := sn.deleteSeries(qt, requestData, deadline)
@@ -891,7 +903,7 @@ func DeleteSeries(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline sear
 }
 
 // LabelNames returns label names matching the given sq until the given deadline.
-func LabelNames(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.SearchQuery, maxLabelNames int, deadline searchutil.Deadline) ([]string, bool, error) {
+func LabelNames(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery, maxLabelNames int, deadline searchutil.Deadline) ([]string, bool, error) {
 	qt = qt.NewChild("get labels: %s", sq)
 	defer qt.Done()
 	if deadline.Exceeded() {
@@ -907,7 +919,7 @@ func LabelNames(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.Se
 		return nil, false, err
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		return execSearchQuery(qt, sq, func(qt *querytracer.Tracer, requestData []byte, _ storage.TenantToken) any {
 			sn.labelNamesRequests.Inc()
 			labelNames, err := sn.getLabelNames(qt, requestData, maxLabelNames, deadline)
@@ -956,14 +968,14 @@ func LabelNames(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.Se
 }
 
 // GraphiteTags returns Graphite tags until the given deadline.
-func GraphiteTags(qt *querytracer.Tracer, accountID, projectID uint32, denyPartialResponse bool, filter string, limit int, deadline searchutil.Deadline) ([]string, bool, error) {
+func GraphiteTags(qt *querytracer.Tracer, accountID, projectID uint32, pr *PartialResponse, filter string, limit int, deadline searchutil.Deadline) ([]string, bool, error) {
 	qt = qt.NewChild("get graphite tags: filter=%s, limit=%d", filter, limit)
 	defer qt.Done()
 	if deadline.Exceeded() {
 		return nil, false, fmt.Errorf("timeout exceeded before starting the query processing: %s", deadline.String())
 	}
 	sq := storage.NewSearchQuery(accountID, projectID, 0, 0, nil, 0)
-	labels, isPartial, err := LabelNames(qt, denyPartialResponse, sq, 0, deadline)
+	labels, isPartial, err := LabelNames(qt, pr, sq, 0, deadline)
 	if err != nil {
 		return nil, false, err
 	}
@@ -1004,7 +1016,7 @@ func hasString(a []string, s string) bool {
 }
 
 // LabelValues returns label values matching the given labelName and sq until the given deadline.
-func LabelValues(qt *querytracer.Tracer, denyPartialResponse bool, labelName string, sq *storage.SearchQuery, maxLabelValues int, deadline searchutil.Deadline) ([]string, bool, error) {
+func LabelValues(qt *querytracer.Tracer, pr *PartialResponse, labelName string, sq *storage.SearchQuery, maxLabelValues int, deadline searchutil.Deadline) ([]string, bool, error) {
 	qt = qt.NewChild("get values for label %s: %s", labelName, sq)
 	defer qt.Done()
 	if deadline.Exceeded() {
@@ -1051,7 +1063,7 @@ func LabelValues(qt *querytracer.Tracer, denyPartialResponse bool, labelName str
 		return nil, false, err
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		return execSearchQuery(qt, sq, func(qt *querytracer.Tracer, requestData []byte, _ storage.TenantToken) any {
 			sn.labelValuesRequests.Inc()
 			labelValues, err := sn.getLabelValues(qt, labelName, requestData, maxLabelValues, deadline)
@@ -1115,7 +1127,7 @@ func Tenants(qt *querytracer.Tracer, tr storage.TimeRange, deadline searchutil.D
 	}
 	sns := getStorageNodes()
 	// Deny partial responses when obtaining the list of tenants, since partial tenants have little sense.
-	snr := startStorageNodesRequest(qt, sns, true, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, NewFullResponse(), func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		sn.tenantsRequests.Inc()
 		tenants, err := sn.getTenants(qt, tr, deadline)
 		if err != nil {
@@ -1153,7 +1165,7 @@ func Tenants(qt *querytracer.Tracer, tr storage.TimeRange, deadline searchutil.D
 }
 
 // GraphiteTagValues returns tag values for the given tagName until the given deadline.
-func GraphiteTagValues(qt *querytracer.Tracer, accountID, projectID uint32, denyPartialResponse bool, tagName, filter string, limit int, deadline searchutil.Deadline) ([]string, bool, error) {
+func GraphiteTagValues(qt *querytracer.Tracer, accountID, projectID uint32, pr *PartialResponse, tagName, filter string, limit int, deadline searchutil.Deadline) ([]string, bool, error) {
 	qt = qt.NewChild("get graphite tag values for tagName=%s, filter=%s, limit=%d", tagName, filter, limit)
 	defer qt.Done()
 	if deadline.Exceeded() {
@@ -1163,7 +1175,7 @@ func GraphiteTagValues(qt *querytracer.Tracer, accountID, projectID uint32, deny
 		tagName = ""
 	}
 	sq := storage.NewSearchQuery(accountID, projectID, 0, 0, nil, 0)
-	tagValues, isPartial, err := LabelValues(qt, denyPartialResponse, tagName, sq, 0, deadline)
+	tagValues, isPartial, err := LabelValues(qt, pr, tagName, sq, 0, deadline)
 	if err != nil {
 		return nil, false, err
 	}
@@ -1182,7 +1194,7 @@ func GraphiteTagValues(qt *querytracer.Tracer, accountID, projectID uint32, deny
 // TagValueSuffixes returns tag value suffixes for the given tagKey and the given tagValuePrefix.
 //
 // It can be used for implementing https://graphite-api.readthedocs.io/en/latest/api.html#metrics-find
-func TagValueSuffixes(qt *querytracer.Tracer, accountID, projectID uint32, denyPartialResponse bool, tr storage.TimeRange, tagKey, tagValuePrefix string,
+func TagValueSuffixes(qt *querytracer.Tracer, accountID, projectID uint32, pr *PartialResponse, tr storage.TimeRange, tagKey, tagValuePrefix string,
 	delimiter byte, maxSuffixes int, deadline searchutil.Deadline,
 ) ([]string, bool, error) {
 	qt = qt.NewChild("get tag value suffixes for tagKey=%s, tagValuePrefix=%s, maxSuffixes=%d, timeRange=%s", tagKey, tagValuePrefix, maxSuffixes, &tr)
@@ -1196,7 +1208,7 @@ func TagValueSuffixes(qt *querytracer.Tracer, accountID, projectID uint32, denyP
 		err      error
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		sn.tagValueSuffixesRequests.Inc()
 		suffixes, err := sn.getTagValueSuffixes(qt, accountID, projectID, tr, tagKey, tagValuePrefix, delimiter, maxSuffixes, deadline)
 		if err != nil {
@@ -1248,9 +1260,39 @@ func deduplicateStrings(a []string) []string {
 // TSDBStatus returns tsdb status according to https://prometheus.io/docs/prometheus/latest/querying/api/#tsdb-stats
 //
 // It accepts arbitrary filters on time series in sq.
-func TSDBStatus(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.SearchQuery, focusLabel string, topN int, deadline searchutil.Deadline) (*storage.TSDBStatus, bool, error) {
+func TSDBStatus(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery, focusLabel string, topN int, deadline searchutil.Deadline) (*storage.TSDBStatus, bool, error) {
 	qt = qt.NewChild("get tsdb stats: %s, focusLabel=%q, topN=%d", sq, focusLabel, topN)
 	defer qt.Done()
+	return getTSDBStatusFromStorageNodes(qt, pr, sq, topN, deadline, partialTSDBStatusResults, func(qt *querytracer.Tracer, sn *storageNode, requestData []byte) (*storage.TSDBStatus, error) {
+		sn.tsdbStatusRequests.Inc()
+		status, err := sn.getTSDBStatus(qt, "tsdbStatus_v6", requestData, focusLabel, topN, deadline)
+		if err != nil {
+			sn.tsdbStatusErrors.Inc()
+			err = fmt.Errorf("cannot obtain tsdb status from vmstorage %s: %w", sn.connPool.Addr(), err)
+		}
+		return status, err
+	})
+}
+
+// NewSeriesStatus returns tsdb status for series matching sq, which were created on the sq time range.
+//
+// Series are considered new if they are registered on the dates covered by sq time range and are missing on the preceding date.
+func NewSeriesStatus(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery, focusLabel string, topN int, deadline searchutil.Deadline) (*storage.TSDBStatus, bool, error) {
+	qt = qt.NewChild("get new series stats: %s, focusLabel=%q, topN=%d", sq, focusLabel, topN)
+	defer qt.Done()
+	return getTSDBStatusFromStorageNodes(qt, pr, sq, topN, deadline, partialNewSeriesStatusResults, func(qt *querytracer.Tracer, sn *storageNode, requestData []byte) (*storage.TSDBStatus, error) {
+		sn.newSeriesStatusRequests.Inc()
+		status, err := sn.getTSDBStatus(qt, "newSeriesStatus_v1", requestData, focusLabel, topN, deadline)
+		if err != nil {
+			sn.newSeriesStatusErrors.Inc()
+			err = fmt.Errorf("cannot obtain new series status from vmstorage %s: %w", sn.connPool.Addr(), err)
+		}
+		return status, err
+	})
+}
+
+func getTSDBStatusFromStorageNodes(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery, topN int, deadline searchutil.Deadline,
+	partialResults *metrics.Counter, getStatus func(qt *querytracer.Tracer, sn *storageNode, requestData []byte) (*storage.TSDBStatus, error)) (*storage.TSDBStatus, bool, error) {
 	if deadline.Exceeded() {
 		return nil, false, fmt.Errorf("timeout exceeded before starting the query processing: %s", deadline.String())
 	}
@@ -1264,14 +1306,9 @@ func TSDBStatus(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.Se
 		return nil, false, err
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		return execSearchQuery(qt, sq, func(qt *querytracer.Tracer, requestData []byte, _ storage.TenantToken) any {
-			sn.tsdbStatusRequests.Inc()
-			status, err := sn.getTSDBStatus(qt, requestData, focusLabel, topN, deadline)
-			if err != nil {
-				sn.tsdbStatusErrors.Inc()
-				err = fmt.Errorf("cannot obtain tsdb status from vmstorage %s: %w", sn.connPool.Addr(), err)
-			}
+			status, err := getStatus(qt, sn, requestData)
 			return &nodeResult{
 				status: status,
 				err:    err,
@@ -1281,7 +1318,7 @@ func TSDBStatus(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.Se
 
 	// Collect results.
 	var statuses []*storage.TSDBStatus
-	isPartial, err := snr.collectResults(partialTSDBStatusResults, func(result any) error {
+	isPartial, err := snr.collectResults(partialResults, func(result any) error {
 		for _, cr := range result.([]any) {
 			nr := cr.(*nodeResult)
 			if nr.err != nil {
@@ -1383,7 +1420,7 @@ func toTopHeapEntries(m map[string]uint64, topN int) []storage.TopHeapEntry {
 }
 
 // SeriesCount returns the number of unique series.
-func SeriesCount(qt *querytracer.Tracer, accountID, projectID uint32, denyPartialResponse bool, deadline searchutil.Deadline) (uint64, bool, error) {
+func SeriesCount(qt *querytracer.Tracer, accountID, projectID uint32, pr *PartialResponse, deadline searchutil.Deadline) (uint64, bool, error) {
 	qt = qt.NewChild("get series count")
 	defer qt.Done()
 	if deadline.Exceeded() {
@@ -1395,7 +1432,7 @@ func SeriesCount(qt *querytracer.Tracer, accountID, projectID uint32, denyPartia
 		err error
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		sn.seriesCountRequests.Inc()
 		n, err := sn.getSeriesCount(qt, accountID, projectID, deadline)
 		if err != nil {
@@ -1693,7 +1730,7 @@ func ExportBlocks(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline sear
 		samples.Add(workerID, uint64(mb.Block.RowsCount()))
 		return nil
 	}
-	_, err := processBlocks(qt, sns, true, sq, processBlock, deadline)
+	_, err := processBlocks(qt, sns, NewFullResponse(), sq, processBlock, deadline)
 	qt.Printf("export blocks=%d, samples=%d, err=%v", blocksRead.GetTotal(), samples.GetTotal(), err)
 	if err != nil {
 		return fmt.Errorf("error occured during export: %w", err)
@@ -1704,7 +1741,7 @@ func ExportBlocks(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline sear
 // SearchMetricNames returns all the metric names matching sq until the given deadline.
 //
 // The returned metric names must be unmarshaled via storage.MetricName.UnmarshalString().
-func SearchMetricNames(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.SearchQuery, deadline searchutil.Deadline) ([]string, bool, error) {
+func SearchMetricNames(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery, deadline searchutil.Deadline) ([]string, bool, error) {
 	qt = qt.NewChild("fetch metric names: %s", sq)
 	defer qt.Done()
 	if deadline.Exceeded() {
@@ -1721,7 +1758,7 @@ func SearchMetricNames(qt *querytracer.Tracer, denyPartialResponse bool, sq *sto
 		return nil, false, err
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		return execSearchQuery(qt, sq, func(qt *querytracer.Tracer, requestData []byte, t storage.TenantToken) any {
 			sn.searchMetricNamesRequests.Inc()
 			metricNames, err := sn.processSearchMetricNames(qt, requestData, deadline)
@@ -1797,7 +1834,7 @@ func (e limitExceededErr) Error() string { return e.err.Error() }
 // ProcessSearchQuery performs sq until the given deadline.
 //
 // Results.RunParallel or Results.Cancel must be called on the returned Results.
-func ProcessSearchQuery(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.SearchQuery, deadline searchutil.Deadline) (*Results, bool, error) {
+func ProcessSearchQuery(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery, deadline searchutil.Deadline) (*Results, bool, error) {
 	qt = qt.NewChild("fetch matching series: %s", sq)
 	defer qt.Done()
 	if deadline.Exceeded() {
@@ -1835,7 +1872,7 @@ func ProcessSearchQuery(qt *querytracer.Tracer, denyPartialResponse bool, sq *st
 		}
 		return nil
 	}
-	isPartial, err := processBlocks(qt, sns, denyPartialResponse, sq, processBlock, deadline)
+	isPartial, err := processBlocks(qt, sns, pr, sq, processBlock, deadline)
 	if err != nil {
 		tbfw.closeTmpBlockFiles()
 		return nil, false, fmt.Errorf("error occured during search: %w", err)
@@ -1863,14 +1900,14 @@ func ProcessSearchQuery(qt *querytracer.Tracer, denyPartialResponse bool, sq *st
 }
 
 // ProcessBlocks calls processBlock per each block matching the given sq.
-func ProcessBlocks(qt *querytracer.Tracer, denyPartialResponse bool, sq *storage.SearchQuery,
+func ProcessBlocks(qt *querytracer.Tracer, pr *PartialResponse, sq *storage.SearchQuery,
 	processBlock func(mb *storage.MetricBlock, workerID uint) error, deadline searchutil.Deadline,
 ) (bool, error) {
 	sns := getStorageNodes()
-	return processBlocks(qt, sns, denyPartialResponse, sq, processBlock, deadline)
+	return processBlocks(qt, sns, pr, sq, processBlock, deadline)
 }
 
-func processBlocks(qt *querytracer.Tracer, sns []*storageNode, denyPartialResponse bool, sq *storage.SearchQuery,
+func processBlocks(qt *querytracer.Tracer, sns []*storageNode, pr *PartialResponse, sq *storage.SearchQuery,
 	processBlock func(mb *storage.MetricBlock, workerID uint) error, deadline searchutil.Deadline,
 ) (bool, error) {
 	// Make sure that processBlock is no longer called after the exit from processBlocks() function.
@@ -1912,7 +1949,7 @@ func processBlocks(qt *querytracer.Tracer, sns []*storageNode, denyPartialRespon
 		return false, err
 	}
 	// Send the query to all the storage nodes in parallel.
-	snr := startStorageNodesRequest(qt, sns, denyPartialResponse, func(qt *querytracer.Tracer, workerID uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, pr, func(qt *querytracer.Tracer, workerID uint, sn *storageNode) any {
 		if err := execSearchQueryRequest(qt, sq, workerID, sn, f, deadline); err != nil {
 			return err
 		}
@@ -1958,9 +1995,9 @@ func populateSqTenantTokensIfNeeded(sq *storage.SearchQuery) error {
 }
 
 type storageNodesRequest struct {
-	denyPartialResponse bool
-	resultsCh           chan rpcResult
-	qt                  *querytracer.Tracer
+	pr        *PartialResponse
+	resultsCh chan rpcResult
+	qt        *querytracer.Tracer
 	// query tracers to storageAddresses mapping
 	qts map[*querytracer.Tracer]string
 	sns []*storageNode
@@ -1970,9 +2007,10 @@ type rpcResult struct {
 	data  any
 	qt    *querytracer.Tracer
 	group *storageNodesGroup
+	addr  string
 }
 
-func startStorageNodesRequest(qt *querytracer.Tracer, sns []*storageNode, denyPartialResponse bool,
+func startStorageNodesRequest(qt *querytracer.Tracer, sns []*storageNode, pr *PartialResponse,
 	f func(qt *querytracer.Tracer, workerID uint, sn *storageNode) any,
 ) *storageNodesRequest {
 	resultsCh := make(chan rpcResult, len(sns))
@@ -1992,15 +2030,16 @@ func startStorageNodesRequest(qt *querytracer.Tracer, sns []*storageNode, denyPa
 				data:  data,
 				qt:    qtOrphan,
 				group: sn.group,
+				addr:  sn.connPool.Addr(),
 			}
 		}(uint(idx), sn)
 	}
 	return &storageNodesRequest{
-		denyPartialResponse: denyPartialResponse,
-		resultsCh:           resultsCh,
-		qt:                  qt,
-		qts:                 qts,
-		sns:                 sns,
+		pr:        pr,
+		resultsCh: resultsCh,
+		qt:        qt,
+		qts:       qts,
+		sns:       sns,
 	}
 }
 
@@ -2048,6 +2087,7 @@ func (snr *storageNodesRequest) collectResults(partialResultsCounter *metrics.Co
 		return false, nil
 	}
 	groupsCount := sns[0].group.groupsCount
+	resultsCollected := 0
 	resultsCollectedPerGroup := make(map[*storageNodesGroup]int, groupsCount)
 	errsPartialPerGroup := make(map[*storageNodesGroup][]error)
 	groupsPartial := make(map[*storageNodesGroup]struct{})
@@ -2075,7 +2115,12 @@ func (snr *storageNodesRequest) collectResults(partialResultsCounter *metrics.Co
 			}
 
 			errsPartialPerGroup[group] = append(errsPartialPerGroup[group], err)
-			if snr.denyPartialResponse && len(errsPartialPerGroup[group]) >= group.replicationFactor {
+			snr.pr.addMissingNode(result.addr)
+			if snr.pr.isBestEffortGroup(group) {
+				// Errors at best-effort groups do not affect the completeness of the response.
+				continue
+			}
+			if snr.pr.IsDenied() && len(errsPartialPerGroup[group]) >= group.replicationFactor {
 				groupsPartial[group] = struct{}{}
 				if len(groupsPartial) < *globalReplicationFactor {
 					// Ignore this error, since the number of groups with partial results is smaller than the globalReplicationFactor.
@@ -2098,6 +2143,7 @@ func (snr *storageNodesRequest) collectResults(partialResultsCounter *metrics.Co
 			continue
 		}
 		snr.finishQueryTracer(result.qt, "")
+		resultsCollected++
 		resultsCollectedPerGroup[group]++
 		if *skipSlowReplicas && len(resultsCollectedPerGroup) > groupsCount-*globalReplicationFactor {
 			groupsWithFullResult := 0
@@ -2117,23 +2163,57 @@ func (snr *storageNodesRequest) collectResults(partialResultsCounter *metrics.Co
 		}
 	}
 
+	if resultsCollected == 0 {
+		// All the vmstorage nodes failed to return response, including nodes at best-effort groups.
+		// Returns 503 status code, so the caller could retry it if needed.
+		for _, errsPartial := range errsPartialPerGroup {
+			return false, &httpserver.ErrorWithStatusCode{
+				Err:        errsPartial[0],
+				StatusCode: http.StatusServiceUnavailable,
+			}
+		}
+	}
+
 	// Verify whether the full result can be returned
 	failedGroups := 0
+	failedRequiredGroups := 0
 	for g, errsPartial := range errsPartialPerGroup {
 		if len(errsPartial) >= g.replicationFactor {
+			snr.pr.addMissingGroup(g.name)
 			failedGroups++
+			if !snr.pr.isBestEffortGroup(g) {
+				failedRequiredGroups++
+			}
 		}
 	}
+	if failedRequiredGroups == 0 {
+		// Failures at best-effort groups alone do not make the result partial.
+		return false, nil
+	}
 	if failedGroups < *globalReplicationFactor {
 		// Assume that the result is full if the the number of failed groups is smaller than the globalReplicationFactor.
+		// Failed best-effort groups are counted here, since they cannot provide replicas for the data from failed required groups.
 		return false, nil
 	}
+	if snr.pr.IsDenied() {
+		// Failed required groups couldn't be compensated by replicas at other groups.
+		// Returns 503 status code, so the caller could retry it if needed.
+		snr.finishQueryTracers("cancel request because partial responses are denied and the number of failed groups reached globalReplicationFactor")
+		for g, errsPartial := range errsPartialPerGroup {
+			if len(errsPartial) >= g.replicationFactor && !snr.pr.isBestEffortGroup(g) {
+				return false, &httpserver.ErrorWithStatusCode{
+					Err:        errsPartial[0],
+					StatusCode: http.StatusServiceUnavailable,
+				}
+			}
+		}
+	}
 
 	// Verify whether at least a single node per each group successfully returned result in order to be able returning partial result.
 	missingGroups := 0
 	var firstErr error
 	for g, errsPartial := range errsPartialPerGroup {
-		if len(errsPartial) == g.nodesCount {
+		if len(errsPartial) == g.nodesCount && !snr.pr.isBestEffortGroup(g) {
 			missingGroups++
 			if firstErr == nil {
 				// Return only the first error, since it has no sense in returning all errors.
@@ -2177,6 +2257,11 @@ type storageNodesGroup struct {
 
 	// groupsCount is the number of groups in the list the given group belongs to
 	groupsCount int
+
+	// isBestEffort is set to true if the group is listed at -search.bestEffortStorageGroup.
+	//
+	// Unavailable vmstorage nodes at best-effort groups do not make the response partial.
+	isBestEffort bool
 }
 
 func initStorageNodeGroups(addrs []string) map[string]*storageNodesGroup {
@@ -2188,6 +2273,7 @@ func initStorageNodeGroups(addrs []string) map[string]*storageNodesGroup {
 			g = &storageNodesGroup{
 				name:              groupName,
 				replicationFactor: replicationFactor.Get(groupName),
+				isBestEffort:      slices.Contains(*bestEffortStorageGroups, groupName),
 			}
 			m[groupName] = g
 		}
@@ -2248,6 +2334,12 @@ type storageNode struct {
 	// The number of errors during requests to tsdb status.
 	tsdbStatusErrors *metrics.Counter
 
+	// The number of requests to new series status.
+	newSeriesStatusRequests *metrics.Counter
+
+	// The number of errors during requests to new series status.
+	newSeriesStatusErrors *metrics.Counter
+
 	// The number of requests to seriesCount.
 	seriesCountRequests *metrics.Counter
 
@@ -2371,7 +2463,7 @@ func (sn *storageNode) getTagValueSuffixes(qt *querytracer.Tracer, accountID, pr
 	return suffixes, nil
 }
 
-func (sn *storageNode) getTSDBStatus(qt *querytracer.Tracer, requestData []byte, focusLabel string, topN int, deadline searchutil.Deadline) (*storage.TSDBStatus, error) {
+func (sn *storageNode) getTSDBStatus(qt *querytracer.Tracer, rpcName string, requestData []byte, focusLabel string, topN int, deadline searchutil.Deadline) (*storage.TSDBStatus, error) {
 	var status *storage.TSDBStatus
 	f := func(bc *handshake.BufferedConn) error {
 		st, err := sn.getTSDBStatusOnConn(bc, requestData, focusLabel, topN)
@@ -2381,7 +2473,7 @@ func (sn *storageNode) getTSDBStatus(qt *querytracer.Tracer, requestData []byte,
 		status = st
 		return nil
 	}
-	if err := sn.execOnConnWithPossibleRetry(qt, "tsdbStatus_v6", f, deadline); err != nil {
+	if err := sn.execOnConnWithPossibleRetry(qt, rpcName, f, deadline); err != nil {
 		return nil, err
 	}
 	return status, nil
@@ -3219,6 +3311,8 @@ func newStorageNode(ms *metrics.Set, group *storageNodesGroup, addr string) *sto
 		tagValueSuffixesErrors:      ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="tagValueSuffixes", type="rpcClient", name="vmselect", addr=%q}`, addr)),
 		tsdbStatusRequests:          ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="tsdbStatus", type="rpcClient", name="vmselect", addr=%q}`, addr)),
 		tsdbStatusErrors:            ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="tsdbStatus", type="rpcClient", name="vmselect", addr=%q}`, addr)),
+		newSeriesStatusRequests:     ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="newSeriesStatus", type="rpcClient", name="vmselect", addr=%q}`, addr)),
+		newSeriesStatusErrors:       ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="newSeriesStatus", type="rpcClient", name="vmselect", addr=%q}`, addr)),
 		seriesCountRequests:         ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="seriesCount", type="rpcClient", name="vmselect", addr=%q}`, addr)),
 		seriesCountErrors:           ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="seriesCount", type="rpcClient", name="vmselect", addr=%q}`, addr)),
 		searchMetricNamesRequests:   ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="searchMetricNames", type="rpcClient", name="vmselect", addr=%q}`, addr)),
@@ -3246,6 +3340,7 @@ var (
 	partialLabelValuesResults       = metrics.NewCounter(`vm_partial_results_total{action="labelValues", name="vmselect"}`)
 	partialTagValueSuffixesResults  = metrics.NewCounter(`vm_partial_results_total{action="tagValueSuffixes", name="vmselect"}`)
 	partialTSDBStatusResults        = metrics.NewCounter(`vm_partial_results_total{action="tsdbStatus", name="vmselect"}`)
+	partialNewSeriesStatusResults   = metrics.NewCounter(`vm_partial_results_total{action="newSeriesStatus", name="vmselect"}`)
 	partialSeriesCountResults       = metrics.NewCounter(`vm_partial_results_total{action="seriesCount", name="vmselect"}`)
 	partialSearchMetricNamesResults = metrics.NewCounter(`vm_partial_results_total{action="searchMetricNames", name="vmselect"}`)
 	partialSearchResults            = metrics.NewCounter(`vm_partial_results_total{action="search", name="vmselect"}`)
@@ -3375,7 +3470,7 @@ func GetMetricNamesStats(qt *querytracer.Tracer, tt *storage.TenantToken, limit,
 		err  error
 	}
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, true, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, NewPartialResponse(true), func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		resp, err := sn.processGetMetricNamesStats(qt, tt, limit, le, matchPattern, deadline)
 		return nodeResult{resp: resp, err: err}
 	})
@@ -3504,7 +3599,7 @@ func readMetricNamesStatsRecords(bc *handshake.BufferedConn) ([]storage.MetricNa
 // In case of error request must be retried by the client in order to consistently reset state at all nodes
 func ResetMetricNamesStats(qt *querytracer.Tracer, deadline searchutil.Deadline) error {
 	sns := getStorageNodes()
-	snr := startStorageNodesRequest(qt, sns, true, func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
+	snr := startStorageNodesRequest(qt, sns, NewPartialResponse(true), func(qt *querytracer.Tracer, _ uint, sn *storageNode) any {
 		return sn.processResetMetricNamesUsageStats(qt, deadline)
 	})
 	if err := snr.collectAllResults(func(result any) error {
@@ -0,0 +1,116 @@
+package netstorage
+
+import (
+	"sort"
+	"sync"
+)
+
+// PartialResponse controls and tracks partial responses from vmstorage nodes during a single request.
+//
+// nil PartialResponse allows partial responses and doesn't track missing vmstorage nodes.
+//
+// It is safe calling PartialResponse methods from concurrently running goroutines.
+type PartialResponse struct {
+	deny bool
+
+	// isFull is set if the response must contain data from all the vmstorage groups including best-effort groups.
+	isFull bool
+
+	mu            sync.Mutex
+	missingGroups map[string]struct{}
+	missingNodes  map[string]struct{}
+}
+
+// NewPartialResponse returns new PartialResponse.
+//
+// If deny is set, then the request fails when the response from vmstorage nodes is partial.
+func NewPartialResponse(deny bool) *PartialResponse {
+	return &PartialResponse{
+		deny: deny,
+	}
+}
+
+// NewFullResponse returns PartialResponse, which requires full response from all the vmstorage groups
+// including groups from -search.bestEffortStorageGroup.
+//
+// It must be used by callers, which expect complete data regardless of the partial response settings, such as data export.
+func NewFullResponse() *PartialResponse {
+	return &PartialResponse{
+		deny:   true,
+		isFull: true,
+	}
+}
+
+// IsDenied returns true if partial responses are denied for pr.
+func (pr *PartialResponse) IsDenied() bool {
+	if pr == nil {
+		return false
+	}
+	return pr.deny
+}
+
+// isBestEffortGroup returns true if unavailable vmstorage nodes at g do not make the response partial for pr.
+func (pr *PartialResponse) isBestEffortGroup(g *storageNodesGroup) bool {
+	if pr != nil && pr.isFull {
+		return false
+	}
+	return g.isBestEffort
+}
+
+// MissingGroups returns sorted names of vmstorage groups, which returned incomplete data
+// because at least replicationFactor vmstorage nodes were unavailable in these groups.
+func (pr *PartialResponse) MissingGroups() []string {
+	if pr == nil {
+		return nil
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return getSortedKeys(pr.missingGroups)
+}
+
+// MissingNodes returns sorted addresses of vmstorage nodes, which failed to return response.
+func (pr *PartialResponse) MissingNodes() []string {
+	if pr == nil {
+		return nil
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return getSortedKeys(pr.missingNodes)
+}
+
+func (pr *PartialResponse) addMissingGroup(groupName string) {
+	if pr == nil || groupName == "" {
+		// Do not track the default group without name, since it cannot be distinguished by the caller.
+		return
+	}
+	pr.mu.Lock()
+	if pr.missingGroups == nil {
+		pr.missingGroups = make(map[string]struct{})
+	}
+	pr.missingGroups[groupName] = struct{}{}
+	pr.mu.Unlock()
+}
+
+func (pr *PartialResponse) addMissingNode(addr string) {
+	if pr == nil {
+		return
+	}
+	pr.mu.Lock()
+	if pr.missingNodes == nil {
+		pr.missingNodes = make(map[string]struct{})
+	}
+	pr.missingNodes[addr] = struct{}{}
+	pr.mu.Unlock()
+}
+
+func getSortedKeys(m map[string]struct{}) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	a := make([]string, 0, len(m))
+	for k := range m {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return a
+}
@@ -0,0 +1,216 @@
+package netstorage
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCollectResultsBestEffortGroups(t *testing.T) {
+	f := func(bestEffortGroups []string, deny bool, failedNodes []string, isPartialExpected, isErrorExpected bool, missingGroupsExpected []string) {
+		t.Helper()
+
+		bestEffortGroupsOrig := *bestEffortStorageGroups
+		*bestEffortStorageGroups = bestEffortGroups
+		defer func() {
+			*bestEffortStorageGroups = bestEffortGroupsOrig
+		}()
+
+		addrs := []string{"a/node1", "a/node2", "b/node3", "b/node4"}
+		groups := initStorageNodeGroups(addrs)
+		pr := NewPartialResponse(deny)
+		snr := &storageNodesRequest{
+			pr:        pr,
+			resultsCh: make(chan rpcResult, len(addrs)),
+		}
+		for _, addr := range addrs {
+			groupName := addr[:1]
+			snr.sns = append(snr.sns, &storageNode{
+				group: groups[groupName],
+			})
+			var data any
+			for _, failedNode := range failedNodes {
+				if failedNode == addr {
+					data = errors.New("node is unavailable")
+				}
+			}
+			snr.resultsCh <- rpcResult{
+				data:  data,
+				group: groups[groupName],
+				addr:  addr,
+			}
+		}
+		isPartial, err := snr.collectResults(partialSearchResults, func(result any) error {
+			if result != nil {
+				return result.(error)
+			}
+			return nil
+		})
+		if isErrorExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if isPartial != isPartialExpected {
+			t.Fatalf("unexpected isPartial; got %v; want %v", isPartial, isPartialExpected)
+		}
+		if missingGroups := pr.MissingGroups(); !reflect.DeepEqual(missingGroups, missingGroupsExpected) {
+			t.Fatalf("unexpected missing groups; got %q; want %q", missingGroups, missingGroupsExpected)
+		}
+		if missingNodes := pr.MissingNodes(); len(missingNodes) != len(failedNodes) {
+			t.Fatalf("unexpected missing nodes; got %q; want %q", missingNodes, failedNodes)
+		}
+	}
+
+	// All the nodes are available
+	f(nil, false, nil, false, false, nil)
+	f([]string{"b"}, true, nil, false, false, nil)
+
+	// A node at required group is unavailable
+	f(nil, false, []string{"a/node1"}, true, false, []string{"a"})
+	f([]string{"b"}, false, []string{"a/node1"}, true, false, []string{"a"})
+	f([]string{"b"}, true, []string{"a/node1"}, false, true, nil)
+
+	// Nodes at best-effort group are unavailable
+	f([]string{"b"}, false, []string{"b/node3"}, false, false, []string{"b"})
+	f([]string{"b"}, true, []string{"b/node3", "b/node4"}, false, false, []string{"b"})
+
+	// All the groups are best-effort
+	f([]string{"a", "b"}, true, []string{"a/node1", "b/node4"}, false, false, []string{"a", "b"})
+	f([]string{"a", "b"}, true, []string{"a/node1", "a/node2", "b/node3", "b/node4"}, false, true, nil)
+}
+
+func TestCollectResultsFullResponse(t *testing.T) {
+	bestEffortGroupsOrig := *bestEffortStorageGroups
+	*bestEffortStorageGroups = []string{"b"}
+	defer func() {
+		*bestEffortStorageGroups = bestEffortGroupsOrig
+	}()
+
+	f := func(failedNodes []string, isErrorExpected bool) {
+		t.Helper()
+
+		addrs := []string{"a/node1", "a/node2", "b/node3", "b/node4"}
+		groups := initStorageNodeGroups(addrs)
+		pr := NewFullResponse()
+		snr := &storageNodesRequest{
+			pr:        pr,
+			resultsCh: make(chan rpcResult, len(addrs)),
+		}
+		for _, addr := range addrs {
+			groupName := addr[:1]
+			snr.sns = append(snr.sns, &storageNode{
+				group: groups[groupName],
+			})
+			var data any
+			if slices.Contains(failedNodes, addr) {
+				data = errors.New("node is unavailable")
+			}
+			snr.resultsCh <- rpcResult{
+				data:  data,
+				group: groups[groupName],
+				addr:  addr,
+			}
+		}
+		isPartial, err := snr.collectResults(partialSearchResults, func(result any) error {
+			if result != nil {
+				return result.(error)
+			}
+			return nil
+		})
+		if isErrorExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if isPartial {
+			t.Fatalf("unexpected partial response")
+		}
+	}
+
+	// All the nodes are available
+	f(nil, false)
+
+	// Unavailable nodes at best-effort group must fail the request, since the full response is required
+	f([]string{"b/node3"}, true)
+	f([]string{"b/node3", "b/node4"}, true)
+
+	// Unavailable nodes at required group must fail the request
+	f([]string{"a/node1"}, true)
+}
+
+func TestCollectResultsGlobalReplicationFactor(t *testing.T) {
+	bestEffortGroupsOrig := *bestEffortStorageGroups
+	*bestEffortStorageGroups = []string{"b"}
+	globalReplicationFactorOrig := *globalReplicationFactor
+	*globalReplicationFactor = 2
+	defer func() {
+		*bestEffortStorageGroups = bestEffortGroupsOrig
+		*globalReplicationFactor = globalReplicationFactorOrig
+	}()
+
+	f := func(deny bool, failedNodes []string, isPartialExpected, isErrorExpected bool) {
+		t.Helper()
+
+		addrs := []string{"a/node1", "a/node2", "b/node3", "b/node4"}
+		groups := initStorageNodeGroups(addrs)
+		snr := &storageNodesRequest{
+			pr:        NewPartialResponse(deny),
+			resultsCh: make(chan rpcResult, len(addrs)),
+		}
+		for _, addr := range addrs {
+			groupName := addr[:1]
+			snr.sns = append(snr.sns, &storageNode{
+				group: groups[groupName],
+			})
+			var data any
+			if slices.Contains(failedNodes, addr) {
+				data = errors.New("node is unavailable")
+			}
+			snr.resultsCh <- rpcResult{
+				data:  data,
+				group: groups[groupName],
+				addr:  addr,
+			}
+		}
+		isPartial, err := snr.collectResults(partialSearchResults, func(result any) error {
+			if result != nil {
+				return result.(error)
+			}
+			return nil
+		})
+		if isErrorExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if isPartial != isPartialExpected {
+			t.Fatalf("unexpected isPartial; got %v; want %v", isPartial, isPartialExpected)
+		}
+	}
+
+	// The required group is unavailable, while the best-effort group contains the replica
+	f(false, []string{"a/node1", "a/node2"}, false, false)
+	f(true, []string{"a/node1", "a/node2"}, false, false)
+
+	// The required group is unavailable and the best-effort group is partially unavailable
+	f(false, []string{"a/node1", "a/node2", "b/node3"}, true, false)
+	f(true, []string{"a/node1", "a/node2", "b/node3"}, false, true)
+
+	// All the nodes at the required and best-effort groups are unavailable
+	f(false, []string{"a/node1", "a/node2", "b/node3", "b/node4"}, false, true)
+	f(true, []string{"a/node1", "a/node2", "b/node3", "b/node4"}, false, true)
+}
@@ -0,0 +1,168 @@
+package netstorage
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+var replicaDedupPenalty = flag.Duration("search.replicaDedupPenalty", 0, "The minimum gap in samples of the currently selected replica, "+
+	"which triggers switching to another replica when merging series by -search.replicaLabel. "+
+	"By default the gap is automatically calculated as 2x of the interval between the last two samples of the selected replica, but not less than 5s")
+
+// initialReplicaDedupPenalty is the minimum gap in milliseconds, which is needed for switching to another replica.
+const initialReplicaDedupPenalty = 5000
+
+var replicaSeriesMerged = metrics.NewCounter(`vm_replica_series_merged_total`)
+
+// MergeReplicas merges series in rss, which differ only by the given replicaLabels, into a single series per group.
+//
+// The replicaLabels are removed from the merged series. Samples for the merged series
+// are taken from a single replica until it has a gap in data, then the replica with the next sample is selected.
+// This is similar to Thanos deduplication via --deduplication.replica-label.
+//
+// MergeReplicas must be called before Results.RunParallel.
+func (rss *Results) MergeReplicas(replicaLabels []string) error {
+	if len(replicaLabels) == 0 || len(rss.packedTimeseries) == 0 {
+		return nil
+	}
+
+	var mn storage.MetricName
+	var buf []byte
+	m := make(map[string]int, len(rss.packedTimeseries))
+	pts := rss.packedTimeseries[:0:0]
+	for i := range rss.packedTimeseries {
+		src := &rss.packedTimeseries[i]
+		if err := mn.UnmarshalString(src.metricName); err != nil {
+			return fmt.Errorf("cannot unmarshal metricName %q: %w", src.metricName, err)
+		}
+		mn.RemoveTagsIgnoring(replicaLabels)
+		buf = mn.Marshal(buf[:0])
+		idx, ok := m[string(buf)]
+		if !ok {
+			metricName := string(buf)
+			idx = len(pts)
+			m[metricName] = idx
+			pts = append(pts, packedTimeseries{
+				metricName: metricName,
+			})
+		}
+		dst := &pts[idx]
+		dst.replicaAddrs = append(dst.replicaAddrs, src.addrs)
+	}
+	for i := range pts {
+		p := &pts[i]
+		if len(p.replicaAddrs) == 1 {
+			p.addrs = p.replicaAddrs[0]
+			p.replicaAddrs = nil
+			continue
+		}
+		replicaSeriesMerged.Add(len(p.replicaAddrs) - 1)
+	}
+	rss.packedTimeseries = pts
+	return nil
+}
+
+// unpackReplicas unpacks pts.replicaAddrs to dst and merges them with mergeReplicaSamples.
+func (pts *packedTimeseries) unpackReplicas(dst *Result, tbfs []*tmpBlocksFile, tr storage.TimeRange) error {
+	dedupInterval := storage.GetDedupInterval()
+	replicas := make([]Result, len(pts.replicaAddrs))
+	for i, addrs := range pts.replicaAddrs {
+		ptsReplica := packedTimeseries{
+			addrs: addrs,
+		}
+		sbh := getSortBlocksHeap()
+		var err error
+		sbh.sbs, err = ptsReplica.unpackTo(sbh.sbs[:0], tbfs, tr)
+		if err != nil {
+			putSortBlocksHeap(sbh)
+			return err
+		}
+		mergeSortBlocks(&replicas[i], sbh, dedupInterval)
+		putSortBlocksHeap(sbh)
+	}
+	pts.replicaAddrs = nil
+	dst.Timestamps, dst.Values = mergeReplicaSamples(dst.Timestamps[:0], dst.Values[:0], replicas)
+	return nil
+}
+
+// mergeReplicaSamples merges samples from replicas into dstTimestamps and dstValues.
+//
+// Samples are taken from the currently selected replica while it has no gaps exceeding the penalty.
+// Otherwise the replica with the earliest next sample is selected.
+func mergeReplicaSamples(dstTimestamps []int64, dstValues []float64, replicas []Result) ([]int64, []float64) {
+	nextIdxs := make([]int, len(replicas))
+	cur := -1
+	lastTs := int64(0)
+	for {
+		// Skip samples, which are already covered by the merged series.
+		if cur >= 0 {
+			for i := range replicas {
+				timestamps := replicas[i].Timestamps
+				for nextIdxs[i] < len(timestamps) && timestamps[nextIdxs[i]] <= lastTs {
+					nextIdxs[i]++
+				}
+			}
+		}
+
+		next := -1
+		if cur >= 0 && nextIdxs[cur] < len(replicas[cur].Timestamps) {
+			timestamps := replicas[cur].Timestamps
+			idx := nextIdxs[cur]
+			if timestamps[idx]-lastTs <= getReplicaDedupPenalty(timestamps, idx) {
+				// Stick to the currently selected replica while it has no gaps.
+				next = cur
+			}
+		}
+		if next < 0 {
+			// Select the replica with the earliest next sample.
+			// Prefer the currently selected replica on ties.
+			for i := range replicas {
+				timestamps := replicas[i].Timestamps
+				if nextIdxs[i] >= len(timestamps) {
+					continue
+				}
+				if next < 0 || timestamps[nextIdxs[i]] < replicas[next].Timestamps[nextIdxs[next]] ||
+					(i == cur && timestamps[nextIdxs[i]] == replicas[next].Timestamps[nextIdxs[next]]) {
+					next = i
+				}
+			}
+			if next < 0 {
+				// All the replicas are exhausted.
+				return dstTimestamps, dstValues
+			}
+		}
+
+		r := &replicas[next]
+		idx := nextIdxs[next]
+		lastTs = r.Timestamps[idx]
+		dstTimestamps = append(dstTimestamps, lastTs)
+		dstValues = append(dstValues, r.Values[idx])
+		nextIdxs[next] = idx + 1
+		cur = next
+	}
+}
+
+// getReplicaDedupPenalty returns the maximum allowed gap before timestamps[idx] for sticking to the replica with the given timestamps.
+//
+// The penalty is calculated as 2x of the usual interval between replica samples, but not less than initialReplicaDedupPenalty.
+func getReplicaDedupPenalty(timestamps []int64, idx int) int64 {
+	if d := replicaDedupPenalty.Milliseconds(); d > 0 {
+		return d
+	}
+	var interval int64
+	switch {
+	case idx >= 2:
+		interval = timestamps[idx-1] - timestamps[idx-2]
+	case idx+1 < len(timestamps):
+		interval = timestamps[idx+1] - timestamps[idx]
+	}
+	penalty := 2 * interval
+	if penalty < initialReplicaDedupPenalty {
+		penalty = initialReplicaDedupPenalty
+	}
+	return penalty
+}
@@ -0,0 +1,133 @@
+package netstorage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestMergeReplicaSamples(t *testing.T) {
+	f := func(replicas []Result, timestampsExpected []int64, valuesExpected []float64) {
+		t.Helper()
+		timestamps, values := mergeReplicaSamples(nil, nil, replicas)
+		if !reflect.DeepEqual(timestamps, timestampsExpected) {
+			t.Fatalf("unexpected timestamps;\ngot\n%v\nwant\n%v", timestamps, timestampsExpected)
+		}
+		if !reflect.DeepEqual(values, valuesExpected) {
+			t.Fatalf("unexpected values;\ngot\n%v\nwant\n%v", values, valuesExpected)
+		}
+	}
+
+	// No replicas
+	f(nil, nil, nil)
+
+	// Empty replicas
+	f([]Result{{}, {}}, nil, nil)
+
+	// Single replica
+	f([]Result{
+		{
+			Timestamps: []int64{10e3, 20e3, 30e3},
+			Values:     []float64{1, 2, 3},
+		},
+	}, []int64{10e3, 20e3, 30e3}, []float64{1, 2, 3})
+
+	// Replicas with shifted timestamps - samples must be taken from the replica with the earliest sample
+	f([]Result{
+		{
+			Timestamps: []int64{15e3, 25e3, 35e3},
+			Values:     []float64{10, 20, 30},
+		},
+		{
+			Timestamps: []int64{10e3, 20e3, 30e3, 40e3},
+			Values:     []float64{1, 2, 3, 4},
+		},
+	}, []int64{10e3, 20e3, 30e3, 40e3}, []float64{1, 2, 3, 4})
+
+	// The selected replica has a gap - the gap must be filled from another replica
+	f([]Result{
+		{
+			Timestamps: []int64{10e3, 20e3, 80e3, 90e3},
+			Values:     []float64{1, 2, 8, 9},
+		},
+		{
+			Timestamps: []int64{15e3, 25e3, 35e3, 45e3, 55e3},
+			Values:     []float64{10, 20, 30, 40, 50},
+		},
+	}, []int64{10e3, 20e3, 25e3, 35e3, 45e3, 55e3, 80e3, 90e3}, []float64{1, 2, 20, 30, 40, 50, 8, 9})
+
+	// The gap doesn't exceed the penalty - stick to the selected replica
+	f([]Result{
+		{
+			Timestamps: []int64{10e3, 20e3, 40e3},
+			Values:     []float64{1, 2, 4},
+		},
+		{
+			Timestamps: []int64{15e3, 25e3, 35e3},
+			Values:     []float64{10, 20, 30},
+		},
+	}, []int64{10e3, 20e3, 40e3}, []float64{1, 2, 4})
+}
+
+func TestResultsMergeReplicas(t *testing.T) {
+	newMetricName := func(labels ...string) string {
+		var mn storage.MetricName
+		mn.MetricGroup = []byte("foo")
+		for i := 0; i < len(labels); i += 2 {
+			mn.AddTag(labels[i], labels[i+1])
+		}
+		return string(mn.Marshal(nil))
+	}
+	f := func(replicaLabels []string, metricNames []string, resultExpected [][]int) {
+		t.Helper()
+		var rss Results
+		for i, metricName := range metricNames {
+			rss.packedTimeseries = append(rss.packedTimeseries, packedTimeseries{
+				metricName: metricName,
+				addrs: []tmpBlockAddr{
+					{
+						offset: uint64(i),
+					},
+				},
+			})
+		}
+		if err := rss.MergeReplicas(replicaLabels); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var result [][]int
+		for _, pts := range rss.packedTimeseries {
+			var offsets []int
+			if len(pts.replicaAddrs) == 0 {
+				offsets = append(offsets, int(pts.addrs[0].offset))
+			}
+			for _, addrs := range pts.replicaAddrs {
+				offsets = append(offsets, int(addrs[0].offset))
+			}
+			result = append(result, offsets)
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result;\ngot\n%v\nwant\n%v", result, resultExpected)
+		}
+	}
+
+	metricNames := []string{
+		newMetricName("instance", "a", "replica", "1"),
+		newMetricName("instance", "b", "replica", "1"),
+		newMetricName("instance", "a", "replica", "2"),
+		newMetricName("instance", "b"),
+		newMetricName("cluster", "x", "instance", "a", "replica", "3"),
+	}
+
+	// No replica labels
+	f(nil, metricNames, [][]int{{0}, {1}, {2}, {3}, {4}})
+
+	// Missing replica labels
+	f([]string{"foo"}, metricNames, [][]int{{0}, {1}, {2}, {3}, {4}})
+
+	// Merge by replica label
+	f([]string{"replica"}, metricNames, [][]int{{0, 2}, {1, 3}, {4}})
+
+	// Merge by multiple replica labels
+	f([]string{"replica", "cluster"}, metricNames, [][]int{{0, 2, 4}, {1, 3}})
+}
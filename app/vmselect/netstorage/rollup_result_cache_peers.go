@@ -0,0 +1,348 @@
+package netstorage
+
+import (
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/consistenthash"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/handshake"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/vmselectapi"
+)
+
+var (
+	rollupResultCachePeers = flagutil.NewArrayString("search.rollupResultCachePeers", "Optional list of -clusternativeListenAddr addresses of vmselect nodes, "+
+		"which share the rollup result cache. Every cache entry is owned by a single vmselect node from the list selected via consistent hashing. "+
+		"The list must be identical across all the vmselect nodes sharing the cache. "+
+		"See https://docs.victoriametrics.com/victoriametrics/cluster-victoriametrics/#shared-rollup-result-cache . See also -search.rollupResultCacheSelfAddr")
+	rollupResultCacheSelfAddr = flag.String("search.rollupResultCacheSelfAddr", "", "The address of the current vmselect node in -search.rollupResultCachePeers list. "+
+		"Cache entries owned by this address are stored in the local rollup result cache without network round-trips")
+	rollupResultCachePeersAuthKey = flagutil.NewPassword("search.rollupResultCachePeersAuthKey", "Optional authKey for rollup result cache requests "+
+		"between -search.rollupResultCachePeers. The same authKey must be set at all the peers. Requests with mismatching authKey are rejected")
+	rollupResultCachePeerTimeout = flag.Duration("search.rollupResultCachePeerTimeout", time.Second, "Timeout for rollup result cache requests to -search.rollupResultCachePeers. "+
+		"Requests, which fail or time out, are treated as cache misses")
+)
+
+// RollupResultCachePeer is a vmselect node, which owns a part of the shared rollup result cache.
+type RollupResultCachePeer struct {
+	addr string
+
+	// sn is used for executing rpc calls at the peer via its -clusternativeListenAddr.
+	sn *storageNode
+
+	getRequests *metrics.Counter
+	getErrors   *metrics.Counter
+	getHits     *metrics.Counter
+	putRequests *metrics.Counter
+	putErrors   *metrics.Counter
+
+	updateMetainfoRequests *metrics.Counter
+	updateMetainfoErrors   *metrics.Counter
+}
+
+type rollupResultCachePeersBucket struct {
+	ms *metrics.Set
+
+	// peers contains all the -search.rollupResultCachePeers.
+	//
+	// The peer for -search.rollupResultCacheSelfAddr has nil sn.
+	peers []*RollupResultCachePeer
+
+	// ch is used for selecting the owner for cache keys among peers.
+	ch *consistenthash.ConsistentHash
+}
+
+var rollupResultCachePeersV *rollupResultCachePeersBucket
+
+// InitRollupResultCachePeers initializes connections to -search.rollupResultCachePeers.
+//
+// MustStopRollupResultCachePeers must be called when the initialized connections are no longer needed.
+func InitRollupResultCachePeers() {
+	if len(*rollupResultCachePeers) == 0 {
+		return
+	}
+	selfAddr := *rollupResultCacheSelfAddr
+	if selfAddr != "" {
+		selfAddr = mustNormalizeRollupResultCachePeerAddr(selfAddr)
+	}
+	ms := metrics.NewSet()
+	peers := make([]*RollupResultCachePeer, 0, len(*rollupResultCachePeers))
+	hasSelf := false
+	for _, addr := range *rollupResultCachePeers {
+		addr = mustNormalizeRollupResultCachePeerAddr(addr)
+		if addr == selfAddr {
+			hasSelf = true
+			peers = append(peers, newRollupResultCachePeer(addr, nil))
+			continue
+		}
+		// There is no need in requests compression, since the cached entries are already compressed.
+		connPool := netutil.NewConnPool(ms, "vmselect_cache", addr, handshake.VMSelectClient, 0, *vmstorageDialTimeout, *vmstorageUserTimeout)
+		sn := &storageNode{
+			connPool:          connPool,
+			concurrentQueries: ms.NewCounter(fmt.Sprintf(`vm_concurrent_queries{name="vmselect_cache", addr=%q}`, addr)),
+		}
+		p := newRollupResultCachePeer(addr, sn)
+		p.getRequests = ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="rollupResultCacheGet", type="rpcClient", name="vmselect", addr=%q}`, addr))
+		p.getErrors = ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="rollupResultCacheGet", type="rpcClient", name="vmselect", addr=%q}`, addr))
+		p.getHits = ms.NewCounter(fmt.Sprintf(`vm_rollup_result_cache_peer_hits_total{addr=%q}`, addr))
+		p.putRequests = ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="rollupResultCachePut", type="rpcClient", name="vmselect", addr=%q}`, addr))
+		p.putErrors = ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="rollupResultCachePut", type="rpcClient", name="vmselect", addr=%q}`, addr))
+		p.updateMetainfoRequests = ms.NewCounter(fmt.Sprintf(`vm_requests_total{action="rollupResultCacheUpdateMetainfo", type="rpcClient", name="vmselect", addr=%q}`, addr))
+		p.updateMetainfoErrors = ms.NewCounter(fmt.Sprintf(`vm_request_errors_total{action="rollupResultCacheUpdateMetainfo", type="rpcClient", name="vmselect", addr=%q}`, addr))
+		peers = append(peers, p)
+	}
+	if selfAddr != "" && !hasSelf {
+		logger.Warnf("-search.rollupResultCacheSelfAddr=%q is missing in -search.rollupResultCachePeers; all the rollup result cache entries will be stored at peers", selfAddr)
+	}
+	metrics.RegisterSet(ms)
+	rollupResultCachePeersV = newRollupResultCachePeersBucket(ms, peers)
+}
+
+func newRollupResultCachePeersBucket(ms *metrics.Set, peers []*RollupResultCachePeer) *rollupResultCachePeersBucket {
+	addrs := make([]string, len(peers))
+	for i, p := range peers {
+		addrs[i] = p.addr
+	}
+	return &rollupResultCachePeersBucket{
+		ms:    ms,
+		peers: peers,
+		ch:    consistenthash.NewConsistentHash(addrs, 0),
+	}
+}
+
+// MustStopRollupResultCachePeers stops connections to -search.rollupResultCachePeers.
+func MustStopRollupResultCachePeers() {
+	pb := rollupResultCachePeersV
+	if pb == nil {
+		return
+	}
+	for _, p := range pb.peers {
+		if p.sn != nil {
+			p.sn.connPool.MustStop()
+		}
+	}
+	metrics.UnregisterSet(pb.ms, true)
+	rollupResultCachePeersV = nil
+}
+
+func mustNormalizeRollupResultCachePeerAddr(addr string) string {
+	normalizedAddr, err := netutil.NormalizeAddr(addr, 8401)
+	if err != nil {
+		logger.Fatalf("cannot normalize -search.rollupResultCachePeers=%q: %s", addr, err)
+	}
+	return normalizedAddr
+}
+
+func newRollupResultCachePeer(addr string, sn *storageNode) *RollupResultCachePeer {
+	return &RollupResultCachePeer{
+		addr: addr,
+		sn:   sn,
+	}
+}
+
+// IsRollupResultCacheShared returns true if the rollup result cache is shared among -search.rollupResultCachePeers.
+func IsRollupResultCacheShared() bool {
+	return rollupResultCachePeersV != nil
+}
+
+// CheckRollupResultCachePeerAuthKey returns an error if rollup result cache requests from peers with the given authKey must be rejected.
+//
+// Requests are rejected if the current vmselect node doesn't share the rollup result cache,
+// since otherwise peers could put arbitrary entries into its local cache.
+func CheckRollupResultCachePeerAuthKey(authKey []byte) error {
+	if !IsRollupResultCacheShared() {
+		return fmt.Errorf("the rollup result cache isn't shared, since -search.rollupResultCachePeers isn't set")
+	}
+	if subtle.ConstantTimeCompare(authKey, []byte(rollupResultCachePeersAuthKey.Get())) != 1 {
+		return fmt.Errorf("the provided authKey doesn't match -%s", rollupResultCachePeersAuthKey.Name())
+	}
+	return nil
+}
+
+// GetRollupResultCachePeer returns the peer, which owns the given rollup result cache key.
+//
+// nil is returned if the key is owned by the current vmselect node or if the cache isn't shared.
+func GetRollupResultCachePeer(key []byte) *RollupResultCachePeer {
+	pb := rollupResultCachePeersV
+	if pb == nil {
+		return nil
+	}
+	return pb.getKeyOwner(key).remote()
+}
+
+func (p *RollupResultCachePeer) remote() *RollupResultCachePeer {
+	if p.sn == nil {
+		return nil
+	}
+	return p
+}
+
+// getKeyOwner returns the owner for the given key among pb.peers.
+//
+// Consistent hashing is used, so only the keys owned by the added or removed peer
+// change their owner when the list of peers changes.
+func (pb *rollupResultCachePeersBucket) getKeyOwner(key []byte) *RollupResultCachePeer {
+	h := xxhash.Sum64(key)
+	idx := pb.ch.GetNodeIdx(h, nil)
+	return pb.peers[idx]
+}
+
+// Addr returns the address of p.
+func (p *RollupResultCachePeer) Addr() string {
+	return p.addr
+}
+
+// Get appends the value for the given key from p to dst and returns the result.
+//
+// The returned value is empty if the key is missing at p.
+func (p *RollupResultCachePeer) Get(qt *querytracer.Tracer, dst, key []byte, isBig bool) ([]byte, error) {
+	p.getRequests.Inc()
+	f := func(bc *handshake.BufferedConn) error {
+		v, err := getRollupResultCacheEntryOnConn(bc, dst, key, isBig)
+		dst = v
+		return err
+	}
+	deadline := searchutil.NewDeadline(time.Now(), *rollupResultCachePeerTimeout, "-search.rollupResultCachePeerTimeout")
+	if err := p.sn.execOnConn(qt, "rollupResultCacheGet_v2", f, deadline); err != nil {
+		p.getErrors.Inc()
+		return dst, err
+	}
+	if len(dst) > 0 {
+		p.getHits.Inc()
+	}
+	return dst, nil
+}
+
+// Put stores the given (key, value) at p.
+func (p *RollupResultCachePeer) Put(qt *querytracer.Tracer, key, value []byte, isBig bool) error {
+	p.putRequests.Inc()
+	f := func(bc *handshake.BufferedConn) error {
+		return putRollupResultCacheEntryOnConn(bc, key, value, isBig)
+	}
+	deadline := searchutil.NewDeadline(time.Now(), *rollupResultCachePeerTimeout, "-search.rollupResultCachePeerTimeout")
+	if err := p.sn.execOnConn(qt, "rollupResultCachePut_v2", f, deadline); err != nil {
+		p.putErrors.Inc()
+		return err
+	}
+	return nil
+}
+
+// UpdateMetainfo applies the given update to the metainfo entry stored under the given key at p.
+//
+// The update is applied by p, so concurrent updates from distinct vmselect nodes aren't lost.
+func (p *RollupResultCachePeer) UpdateMetainfo(qt *querytracer.Tracer, key, update []byte) error {
+	p.updateMetainfoRequests.Inc()
+	f := func(bc *handshake.BufferedConn) error {
+		return updateRollupResultCacheMetainfoOnConn(bc, key, update)
+	}
+	deadline := searchutil.NewDeadline(time.Now(), *rollupResultCachePeerTimeout, "-search.rollupResultCachePeerTimeout")
+	if err := p.sn.execOnConn(qt, "rollupResultCacheUpdateMetainfo_v1", f, deadline); err != nil {
+		p.updateMetainfoErrors.Inc()
+		return err
+	}
+	return nil
+}
+
+func writeRollupResultCacheAuthKey(bc *handshake.BufferedConn) error {
+	if err := writeBytes(bc, []byte(rollupResultCachePeersAuthKey.Get())); err != nil {
+		return fmt.Errorf("cannot write authKey: %w", err)
+	}
+	return nil
+}
+
+func getRollupResultCacheEntryOnConn(bc *handshake.BufferedConn, dst, key []byte, isBig bool) ([]byte, error) {
+	// Send the request to the peer.
+	if err := writeRollupResultCacheAuthKey(bc); err != nil {
+		return dst, err
+	}
+	if err := writeBytes(bc, key); err != nil {
+		return dst, fmt.Errorf("cannot write key: %w", err)
+	}
+	if err := writeBool(bc, isBig); err != nil {
+		return dst, fmt.Errorf("cannot write isBig: %w", err)
+	}
+	if err := bc.Flush(); err != nil {
+		return dst, fmt.Errorf("cannot flush rollupResultCacheGet args to conn: %w", err)
+	}
+
+	// Read response error.
+	buf, err := readBytes(nil, bc, maxErrorMessageSize)
+	if err != nil {
+		return dst, fmt.Errorf("cannot read error message: %w", err)
+	}
+	if len(buf) > 0 {
+		return dst, newErrRemote(buf)
+	}
+
+	// Read the value.
+	buf, err = readBytes(buf[:0], bc, vmselectapi.MaxRollupResultCacheValueSize)
+	if err != nil {
+		return dst, fmt.Errorf("cannot read value: %w", err)
+	}
+	dst = append(dst, buf...)
+	return dst, nil
+}
+
+func putRollupResultCacheEntryOnConn(bc *handshake.BufferedConn, key, value []byte, isBig bool) error {
+	// Send the request to the peer.
+	if err := writeRollupResultCacheAuthKey(bc); err != nil {
+		return err
+	}
+	if err := writeBytes(bc, key); err != nil {
+		return fmt.Errorf("cannot write key: %w", err)
+	}
+	if err := writeBool(bc, isBig); err != nil {
+		return fmt.Errorf("cannot write isBig: %w", err)
+	}
+	if err := writeBytes(bc, value); err != nil {
+		return fmt.Errorf("cannot write value: %w", err)
+	}
+	if err := bc.Flush(); err != nil {
+		return fmt.Errorf("cannot flush rollupResultCachePut args to conn: %w", err)
+	}
+
+	// Read response error.
+	buf, err := readBytes(nil, bc, maxErrorMessageSize)
+	if err != nil {
+		return fmt.Errorf("cannot read error message: %w", err)
+	}
+	if len(buf) > 0 {
+		return newErrRemote(buf)
+	}
+	return nil
+}
+
+func updateRollupResultCacheMetainfoOnConn(bc *handshake.BufferedConn, key, update []byte) error {
+	// Send the request to the peer.
+	if err := writeRollupResultCacheAuthKey(bc); err != nil {
+		return err
+	}
+	if err := writeBytes(bc, key); err != nil {
+		return fmt.Errorf("cannot write key: %w", err)
+	}
+	if err := writeBytes(bc, update); err != nil {
+		return fmt.Errorf("cannot write metainfo update: %w", err)
+	}
+	if err := bc.Flush(); err != nil {
+		return fmt.Errorf("cannot flush rollupResultCacheUpdateMetainfo args to conn: %w", err)
+	}
+
+	// Read response error.
+	buf, err := readBytes(nil, bc, maxErrorMessageSize)
+	if err != nil {
+		return fmt.Errorf("cannot read error message: %w", err)
+	}
+	if len(buf) > 0 {
+		return newErrRemote(buf)
+	}
+	return nil
+}
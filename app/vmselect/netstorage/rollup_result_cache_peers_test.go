@@ -0,0 +1,82 @@
+package netstorage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRollupResultCachePeersBucketGetKeyOwner(t *testing.T) {
+	newPeers := func(addrs ...string) *rollupResultCachePeersBucket {
+		peers := make([]*RollupResultCachePeer, 0, len(addrs))
+		for _, addr := range addrs {
+			peers = append(peers, newRollupResultCachePeer(addr, nil))
+		}
+		return newRollupResultCachePeersBucket(nil, peers)
+	}
+
+	pb := newPeers("vmselect-1:8401", "vmselect-2:8401", "vmselect-3:8401", "vmselect-4:8401")
+	peers := pb.peers
+	const keysCount = 10000
+	owners := make(map[string]string, keysCount)
+	ownedKeys := make(map[string]int)
+	for i := 0; i < keysCount; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		owner := pb.getKeyOwner([]byte(key))
+		owners[key] = owner.Addr()
+		ownedKeys[owner.Addr()]++
+
+		// The owner must be stable.
+		if ownerAddr := pb.getKeyOwner([]byte(key)).Addr(); ownerAddr != owners[key] {
+			t.Fatalf("unstable owner for key %q; got %q; want %q", key, ownerAddr, owners[key])
+		}
+	}
+
+	// Keys must be evenly distributed among peers.
+	for _, p := range peers {
+		n := ownedKeys[p.Addr()]
+		if n < keysCount/len(peers)*8/10 || n > keysCount/len(peers)*12/10 {
+			t.Fatalf("unexpected number of keys owned by %q: %d; want approximately %d", p.Addr(), n, keysCount/len(peers))
+		}
+	}
+
+	// Only keys owned by the removed peer must change their owner.
+	pbWithoutLast := newPeers("vmselect-1:8401", "vmselect-2:8401", "vmselect-3:8401")
+	removedAddr := "vmselect-4:8401"
+	for key, ownerAddr := range owners {
+		newOwnerAddr := pbWithoutLast.getKeyOwner([]byte(key)).Addr()
+		if ownerAddr != removedAddr && newOwnerAddr != ownerAddr {
+			t.Fatalf("unexpected owner change for key %q after peer removal; got %q; want %q", key, newOwnerAddr, ownerAddr)
+		}
+		if newOwnerAddr == removedAddr {
+			t.Fatalf("key %q is owned by the removed peer", key)
+		}
+	}
+}
+
+func TestCheckRollupResultCachePeerAuthKey(t *testing.T) {
+	// Requests must be rejected if the cache isn't shared.
+	if err := CheckRollupResultCachePeerAuthKey(nil); err == nil {
+		t.Fatalf("expecting non-nil error when the cache isn't shared")
+	}
+
+	rollupResultCachePeersV = newRollupResultCachePeersBucket(nil, []*RollupResultCachePeer{newRollupResultCachePeer("vmselect-1:8401", nil)})
+	defer func() {
+		rollupResultCachePeersV = nil
+	}()
+
+	f := func(expectedAuthKey, authKey string, resultExpected bool) {
+		t.Helper()
+		if err := rollupResultCachePeersAuthKey.Set(expectedAuthKey); err != nil {
+			t.Fatalf("cannot set -%s: %s", rollupResultCachePeersAuthKey.Name(), err)
+		}
+		err := CheckRollupResultCachePeerAuthKey([]byte(authKey))
+		if result := err == nil; result != resultExpected {
+			t.Fatalf("unexpected result for authKey=%q, -%s=%q; got %v; want %v; err: %v", authKey, rollupResultCachePeersAuthKey.Name(), expectedAuthKey, result, resultExpected, err)
+		}
+	}
+	f("", "", true)
+	f("secret", "secret", true)
+	f("secret", "", false)
+	f("secret", "foo", false)
+	f("", "foo", false)
+}
@@ -0,0 +1,87 @@
+// Code generated by qtc from "lint_query_response.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:1
+package prometheus
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:1
+import (
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querylint"
+)
+
+// LintQueryResponse generates response for /lint-query .
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:7
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:7
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:7
+func StreamLintQueryResponse(qw422016 *qt422016.Writer, warnings []querylint.Warning) {
+//line app/vmselect/prometheus/lint_query_response.qtpl:7
+	qw422016.N().S(`{"status":"success","data":{"warnings":[`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:12
+	for i := range warnings {
+//line app/vmselect/prometheus/lint_query_response.qtpl:13
+		w := &warnings[i]
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:13
+		qw422016.N().S(`{"check":`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:15
+		qw422016.N().Q(w.Check)
+//line app/vmselect/prometheus/lint_query_response.qtpl:15
+		qw422016.N().S(`,"expr":`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:16
+		qw422016.N().Q(w.Expr)
+//line app/vmselect/prometheus/lint_query_response.qtpl:16
+		qw422016.N().S(`,"message":`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:17
+		qw422016.N().Q(w.Message)
+//line app/vmselect/prometheus/lint_query_response.qtpl:17
+		qw422016.N().S(`}`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:19
+		if i+1 < len(warnings) {
+//line app/vmselect/prometheus/lint_query_response.qtpl:19
+			qw422016.N().S(`,`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:19
+		}
+//line app/vmselect/prometheus/lint_query_response.qtpl:20
+	}
+//line app/vmselect/prometheus/lint_query_response.qtpl:20
+	qw422016.N().S(`]}}`)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+}
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+func WriteLintQueryResponse(qq422016 qtio422016.Writer, warnings []querylint.Warning) {
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	StreamLintQueryResponse(qw422016, warnings)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+}
+
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+func LintQueryResponse(warnings []querylint.Warning) string {
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	WriteLintQueryResponse(qb422016, warnings)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+	return qs422016
+//line app/vmselect/prometheus/lint_query_response.qtpl:24
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querylint"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
 )
@@ -72,6 +73,11 @@ var (
 		"/api/v1/labels and /api/v1/label/.../values . This may be useful for decreasing load on VictoriaMetrics when extra filters "+
 		"match too many time series. The downside is that superfluous labels or series could be returned, which do not match the extra filters. "+
 		"See also -search.maxLabelsAPISeries and -search.maxLabelsAPIDuration")
+	replicaLabels = flagutil.NewArrayString("search.replicaLabel", "Optional label names, which identify replicas of HA pairs of Prometheus-compatible agents. "+
+		"Series, which differ only by these labels, are merged into a single series at /api/v1/query and /api/v1/query_range before calculating rollup functions. "+
+		"Samples for the merged series are taken from a single replica until it has a gap in data. "+
+		"The list can be overridden on a per-query basis via 'replica_label' query args; pass empty 'replica_label' query arg for disabling the merge. "+
+		"See also -search.replicaDedupPenalty and -dedup.minScrapeInterval")
 )
 
 // Default step used if not set.
@@ -110,6 +116,57 @@ func PrettifyQuery(w http.ResponseWriter, r *http.Request) {
 	_ = bw.Flush()
 }
 
+// LintQuery handles the request /lint-query
+//
+// It returns warnings for suspicious subexpressions in the MetricsQL query passed via `query` arg.
+// See https://docs.victoriametrics.com/victoriametrics/metricsql/#query-linter
+func LintQuery(w http.ResponseWriter, r *http.Request) error {
+	query := r.FormValue("query")
+	scrapeInterval, err := httputil.GetDuration(r, "scrape_interval", 0)
+	if err != nil {
+		return err
+	}
+	metricTypes, err := getLintMetricTypes(r)
+	if err != nil {
+		return err
+	}
+	opts := &querylint.Options{
+		ScrapeInterval: time.Duration(scrapeInterval) * time.Millisecond,
+		MetricTypes:    metricTypes,
+	}
+	warnings, err := querylint.Lint(query, opts)
+	if err != nil {
+		return &httpserver.ErrorWithStatusCode{
+			Err:        fmt.Errorf("cannot parse query %q: %w", query, err),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	httpserver.EnableCORS(w, r)
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteLintQueryResponse(bw, warnings)
+	return bw.Flush()
+}
+
+// getLintMetricTypes returns metric types from `metric_type` query args in the form `metric_name:type`.
+func getLintMetricTypes(r *http.Request) (map[string]string, error) {
+	args := r.Form["metric_type"]
+	if len(args) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]string, len(args))
+	for _, arg := range args {
+		n := strings.LastIndexByte(arg, ':')
+		if n <= 0 {
+			return nil, fmt.Errorf("cannot parse metric_type=%q; it must have the form metric_name:type", arg)
+		}
+		m[arg[:n]] = arg[n+1:]
+	}
+	return m, nil
+}
+
 // FederateHandler implements /federate . See https://prometheus.io/docs/prometheus/latest/federation/
 func FederateHandler(startTime time.Time, at *auth.Token, w http.ResponseWriter, r *http.Request) error {
 	defer federateDuration.UpdateDuration(startTime)
@@ -132,15 +189,17 @@ func FederateHandler(startTime time.Time, at *auth.Token, w http.ResponseWriter,
 	if err != nil {
 		return fmt.Errorf("cannot obtain search query: %w", err)
 	}
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
-	rss, isPartial, err := netstorage.ProcessSearchQuery(nil, denyPartialResponse, sq, cp.deadline)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
+	rss, isPartial, err := netstorage.ProcessSearchQuery(nil, pr, sq, cp.deadline)
 	if err != nil {
 		return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 	}
 	if isPartial {
-		return fmt.Errorf("cannot export federated metrics, because some of vmstorage nodes are unavailable")
+		rss.Cancel()
+		return fmt.Errorf("cannot export federated metrics, because some of vmstorage nodes are unavailable: %s", strings.Join(pr.MissingNodes(), ","))
 	}
 
+	setMissingStorageHeaders(w, pr)
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -200,8 +259,8 @@ func ExportCSVHandler(startTime time.Time, at *auth.Token, w http.ResponseWriter
 	if !reduceMemUsage {
 		// Unconditionally deny partial response for the exported data,
 		// since users usually expect that the exported data is full.
-		denyPartialResponse := true
-		rss, _, err := netstorage.ProcessSearchQuery(nil, denyPartialResponse, sq, cp.deadline)
+		pr := netstorage.NewFullResponse()
+		rss, _, err := netstorage.ProcessSearchQuery(nil, pr, sq, cp.deadline)
 		if err != nil {
 			return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 		}
@@ -425,8 +484,8 @@ func exportHandler(qt *querytracer.Tracer, at *auth.Token, w http.ResponseWriter
 	if !reduceMemUsage {
 		// Unconditionally deny partial response for the exported data,
 		// since users usually expect that the exported data is full.
-		denyPartialResponse := true
-		rss, _, err := netstorage.ProcessSearchQuery(qt, denyPartialResponse, sq, cp.deadline)
+		pr := netstorage.NewFullResponse()
+		rss, _, err := netstorage.ProcessSearchQuery(qt, pr, sq, cp.deadline)
 		if err != nil {
 			return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 		}
@@ -631,15 +690,16 @@ func LabelValuesHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.To
 	if err != nil {
 		return err
 	}
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
 	sq, err := getSearchQuery(qt, at, cp, *maxLabelsAPISeries)
 	if err != nil {
 		return err
 	}
-	labelValues, isPartial, err := netstorage.LabelValues(qt, denyPartialResponse, labelName, sq, limit, cp.deadline)
+	labelValues, isPartial, err := netstorage.LabelValues(qt, pr, labelName, sq, limit, cp.deadline)
 	if err != nil {
 		return fmt.Errorf("cannot obtain values for label %q: %w", labelName, err)
 	}
+	setMissingStorageHeaders(w, pr)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -668,47 +728,28 @@ func TSDBStatusHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Tok
 	}
 	cp.deadline = searchutil.GetDeadlineForStatusRequest(r, startTime)
 
-	date := fasttime.UnixDate()
-	dateStr := r.FormValue("date")
-	if len(dateStr) > 0 {
-		if dateStr == "0" {
-			date = 0
-		} else {
-			t, err := time.Parse("2006-01-02", dateStr)
-			if err != nil {
-				return fmt.Errorf("cannot parse `date` arg %q: %w", dateStr, err)
-			}
-			date = uint64(t.Unix()) / secsPerDay
-		}
+	date, err := getTSDBStatusDate(r, "date", fasttime.UnixDate())
+	if err != nil {
+		return err
 	}
 	focusLabel := r.FormValue("focusLabel")
-	topN := 10
-	topNStr := r.FormValue("topN")
-	if len(topNStr) > 0 {
-		n, err := strconv.Atoi(topNStr)
-		if err != nil {
-			return fmt.Errorf("cannot parse `topN` arg %q: %w", topNStr, err)
-		}
-		if n <= 0 {
-			n = 1
-		}
-		if n > *maxTSDBStatusTopNSeries {
-			n = *maxTSDBStatusTopNSeries
-		}
-		topN = n
+	topN, err := getTSDBStatusTopN(r)
+	if err != nil {
+		return err
 	}
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
 	cp.start = int64(date*secsPerDay) * 1000
 	cp.end = int64((date+1)*secsPerDay)*1000 - 1
 	sq, err := getSearchQuery(qt, at, cp, *maxTSDBStatusSeries)
 	if err != nil {
 		return err
 	}
-	status, isPartial, err := netstorage.TSDBStatus(qt, denyPartialResponse, sq, focusLabel, topN, cp.deadline)
+	status, isPartial, err := netstorage.TSDBStatus(qt, pr, sq, focusLabel, topN, cp.deadline)
 	if err != nil {
 		return fmt.Errorf("cannot obtain tsdb stats: %w", err)
 	}
 
+	setMissingStorageHeaders(w, pr)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -721,6 +762,184 @@ func TSDBStatusHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Tok
 
 var tsdbStatusDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/status/tsdb"}`)
 
+// NewSeriesStatusHandler processes /api/v1/status/new_series request.
+//
+// It returns tsdb status for series created during the given number of `hours` ending at the given `end`.
+// The hour containing `end` is counted as the last hour. By default the series created during the current hour are returned.
+//
+// It can accept `match[]` filters in order to narrow down the search.
+func NewSeriesStatusHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token, w http.ResponseWriter, r *http.Request) error {
+	defer newSeriesStatusDuration.UpdateDuration(startTime)
+
+	cp, err := getCommonParams(r, startTime, false)
+	if err != nil {
+		return err
+	}
+	cp.deadline = searchutil.GetDeadlineForStatusRequest(r, startTime)
+	hours, err := getNewSeriesStatusHours(r)
+	if err != nil {
+		return err
+	}
+	endHour := cp.end / msecsPerHour
+	if hours > endHour+1 {
+		hours = endHour + 1
+	}
+	cp.start = (endHour - hours + 1) * msecsPerHour
+	cp.end = (endHour+1)*msecsPerHour - 1
+	focusLabel := r.FormValue("focusLabel")
+	topN, err := getTSDBStatusTopN(r)
+	if err != nil {
+		return err
+	}
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
+	sq, err := getSearchQuery(qt, at, cp, *maxTSDBStatusSeries)
+	if err != nil {
+		return err
+	}
+	status, isPartial, err := netstorage.NewSeriesStatus(qt, pr, sq, focusLabel, topN, cp.deadline)
+	if err != nil {
+		return fmt.Errorf("cannot obtain new series stats: %w", err)
+	}
+
+	setMissingStorageHeaders(w, pr)
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteTSDBStatusResponse(bw, isPartial, status, qt)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot send new series status response to remote client: %w", err)
+	}
+	return nil
+}
+
+var newSeriesStatusDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/status/new_series"}`)
+
+// TSDBStatusDiffHandler processes /api/v1/status/tsdb_diff request.
+//
+// It compares tsdb status for the `date` with tsdb status for the `baseDate`.
+// By default the current day is compared with the previous day.
+//
+// It can accept `match[]` filters in order to narrow down the search.
+func TSDBStatusDiffHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token, w http.ResponseWriter, r *http.Request) error {
+	defer tsdbStatusDiffDuration.UpdateDuration(startTime)
+
+	cp, err := getCommonParams(r, startTime, false)
+	if err != nil {
+		return err
+	}
+	cp.deadline = searchutil.GetDeadlineForStatusRequest(r, startTime)
+	date, err := getTSDBStatusDate(r, "date", fasttime.UnixDate())
+	if err != nil {
+		return err
+	}
+	defaultBaseDate := uint64(0)
+	if date > 0 {
+		defaultBaseDate = date - 1
+	}
+	baseDate, err := getTSDBStatusDate(r, "baseDate", defaultBaseDate)
+	if err != nil {
+		return err
+	}
+	focusLabel := r.FormValue("focusLabel")
+	topN, err := getTSDBStatusTopN(r)
+	if err != nil {
+		return err
+	}
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
+
+	// Request the maximum number of entries for both dates, so entries, which are missing in the top for one of the dates,
+	// are compared properly with the other date.
+	getStatus := func(date uint64) (*storage.TSDBStatus, bool, error) {
+		cpCopy := *cp
+		cpCopy.start = int64(date*secsPerDay) * 1000
+		cpCopy.end = int64((date+1)*secsPerDay)*1000 - 1
+		sq, err := getSearchQuery(qt, at, &cpCopy, *maxTSDBStatusSeries)
+		if err != nil {
+			return nil, false, err
+		}
+		status, isPartial, err := netstorage.TSDBStatus(qt, pr, sq, focusLabel, *maxTSDBStatusTopNSeries, cp.deadline)
+		if err != nil {
+			return nil, false, fmt.Errorf("cannot obtain tsdb stats for date=%s: %w", time.Unix(int64(date*secsPerDay), 0).UTC().Format("2006-01-02"), err)
+		}
+		return status, isPartial, nil
+	}
+	status, isPartial, err := getStatus(date)
+	if err != nil {
+		return err
+	}
+	baseStatus, isBasePartial, err := getStatus(baseDate)
+	if err != nil {
+		return err
+	}
+	diff := newTSDBStatusDiff(status, baseStatus, topN)
+
+	setMissingStorageHeaders(w, pr)
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteTSDBStatusDiffResponse(bw, isPartial || isBasePartial, diff, qt)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot send tsdb status diff response to remote client: %w", err)
+	}
+	return nil
+}
+
+var tsdbStatusDiffDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/status/tsdb_diff"}`)
+
+// getTSDBStatusDate returns the date from YYYY-MM-DD arg with the given argKey at r.
+//
+// The special value `0` means the global index.
+func getTSDBStatusDate(r *http.Request, argKey string, defaultDate uint64) (uint64, error) {
+	dateStr := r.FormValue(argKey)
+	if len(dateStr) == 0 {
+		return defaultDate, nil
+	}
+	if dateStr == "0" {
+		return 0, nil
+	}
+	t, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse `%s` arg %q: %w", argKey, dateStr, err)
+	}
+	return uint64(t.Unix()) / secsPerDay, nil
+}
+
+const msecsPerHour = 3600 * 1000
+
+func getNewSeriesStatusHours(r *http.Request) (int64, error) {
+	hoursStr := r.FormValue("hours")
+	if len(hoursStr) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(hoursStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse `hours` arg %q: %w", hoursStr, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("`hours` arg must be positive")
+	}
+	return n, nil
+}
+
+func getTSDBStatusTopN(r *http.Request) (int, error) {
+	topN := 10
+	topNStr := r.FormValue("topN")
+	if len(topNStr) > 0 {
+		n, err := strconv.Atoi(topNStr)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse `topN` arg %q: %w", topNStr, err)
+		}
+		if n <= 0 {
+			n = 1
+		}
+		if n > *maxTSDBStatusTopNSeries {
+			n = *maxTSDBStatusTopNSeries
+		}
+		topN = n
+	}
+	return topN, nil
+}
+
 // LabelsHandler processes /api/v1/labels request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#getting-label-names
@@ -735,16 +954,17 @@ func LabelsHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token,
 	if err != nil {
 		return err
 	}
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
 	sq, err := getSearchQuery(qt, at, cp, *maxLabelsAPISeries)
 	if err != nil {
 		return err
 	}
-	labels, isPartial, err := netstorage.LabelNames(qt, denyPartialResponse, sq, limit, cp.deadline)
+	labels, isPartial, err := netstorage.LabelNames(qt, pr, sq, limit, cp.deadline)
 	if err != nil {
 		return fmt.Errorf("cannot obtain labels: %w", err)
 	}
 
+	setMissingStorageHeaders(w, pr)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -776,12 +996,13 @@ func SeriesCountHandler(startTime time.Time, at *auth.Token, w http.ResponseWrit
 		return fmt.Errorf("multi-tenant request to /api/v1/series/count is not supported")
 	}
 	deadline := searchutil.GetDeadlineForStatusRequest(r, startTime)
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
-	n, isPartial, err := netstorage.SeriesCount(nil, at.AccountID, at.ProjectID, denyPartialResponse, deadline)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
+	n, isPartial, err := netstorage.SeriesCount(nil, at.AccountID, at.ProjectID, pr, deadline)
 	if err != nil {
 		return fmt.Errorf("cannot obtain series count: %w", err)
 	}
 
+	setMissingStorageHeaders(w, pr)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -817,11 +1038,12 @@ func SeriesHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token,
 	if err != nil {
 		return err
 	}
-	denyPartialResponse := httputil.GetDenyPartialResponse(r)
-	metricNames, isPartial, err := netstorage.SearchMetricNames(qt, denyPartialResponse, sq, cp.deadline)
+	pr := netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r))
+	metricNames, isPartial, err := netstorage.SearchMetricNames(qt, pr, sq, cp.deadline)
 	if err != nil {
 		return fmt.Errorf("cannot fetch time series for %q: %w", sq, err)
 	}
+	setMissingStorageHeaders(w, pr)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -957,7 +1179,8 @@ func QueryHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token, w
 			return httpserver.GetRequestURI(r)
 		},
 
-		DenyPartialResponse: httputil.GetDenyPartialResponse(r),
+		PartialResponse: netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r)),
+		ReplicaLabels:   getReplicaLabels(r),
 	}
 	err = populateAuthTokens(qt, ec, at, deadline)
 	if err != nil {
@@ -983,6 +1206,7 @@ func QueryHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token, w
 		}
 	}
 
+	setMissingStorageHeaders(w, ec.PartialResponse)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -990,7 +1214,7 @@ func QueryHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Token, w
 		qt.Donef("query=%s, time=%d: series=%d", query, start, len(result))
 	}
 
-	WriteQueryResponse(bw, ec.IsPartialResponse.Load(), result, qt, qtDone, qs)
+	WriteQueryResponse(bw, ec.IsPartialResponse.Load(), ec.PartialResponse, result, qt, qtDone, qs)
 	if err := bw.Flush(); err != nil {
 		return fmt.Errorf("cannot flush query response to remote client: %w", err)
 	}
@@ -1073,7 +1297,8 @@ func queryRangeHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Tok
 			return httpserver.GetRequestURI(r)
 		},
 
-		DenyPartialResponse: httputil.GetDenyPartialResponse(r),
+		PartialResponse: netstorage.NewPartialResponse(httputil.GetDenyPartialResponse(r)),
+		ReplicaLabels:   getReplicaLabels(r),
 	}
 	err = populateAuthTokens(qt, ec, at, deadline)
 	if err != nil {
@@ -1100,13 +1325,14 @@ func queryRangeHandler(qt *querytracer.Tracer, startTime time.Time, at *auth.Tok
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/153
 	result = removeEmptyValuesAndTimeseries(result)
 
+	setMissingStorageHeaders(w, ec.PartialResponse)
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
 	qtDone := func() {
 		qt.Donef("start=%d, end=%d, step=%d, query=%q: series=%d", start, end, step, query, len(result))
 	}
-	WriteQueryRangeResponse(bw, ec.IsPartialResponse.Load(), result, qt, qtDone, qs)
+	WriteQueryRangeResponse(bw, ec.IsPartialResponse.Load(), ec.PartialResponse, result, qt, qtDone, qs)
 	if err := bw.Flush(); err != nil {
 		return fmt.Errorf("cannot send query range response to remote client: %w", err)
 	}
@@ -1257,6 +1483,31 @@ func getRoundDigits(r *http.Request) int {
 	return n
 }
 
+// setMissingStorageHeaders sets response headers with vmstorage groups and nodes, which failed to return response for pr.
+//
+// This allows showing degraded data banners at dashboards. See -search.bestEffortStorageGroup.
+func setMissingStorageHeaders(w http.ResponseWriter, pr *netstorage.PartialResponse) {
+	if groups := pr.MissingGroups(); len(groups) > 0 {
+		w.Header().Set("VM-Missing-Storage-Groups", strings.Join(groups, ","))
+	}
+	if nodes := pr.MissingNodes(); len(nodes) > 0 {
+		w.Header().Set("VM-Missing-Storage-Nodes", strings.Join(nodes, ","))
+	}
+}
+
+func getReplicaLabels(r *http.Request) []string {
+	if err := r.ParseForm(); err != nil || !r.Form.Has("replica_label") {
+		return *replicaLabels
+	}
+	var labels []string
+	for _, label := range r.Form["replica_label"] {
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
 func getLatencyOffsetMilliseconds(r *http.Request) (int64, error) {
 	d := latencyOffset.Milliseconds()
 	if d < 0 {
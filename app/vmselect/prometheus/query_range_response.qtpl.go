@@ -27,7 +27,7 @@ var (
 )
 
 //line app/vmselect/prometheus/query_range_response.qtpl:10
-func StreamQueryRangeResponse(qw422016 *qt422016.Writer, isPartial bool, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
+func StreamQueryRangeResponse(qw422016 *qt422016.Writer, isPartial bool, pr *netstorage.PartialResponse, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
 //line app/vmselect/prometheus/query_range_response.qtpl:10
 	qw422016.N().S(`{`)
 //line app/vmselect/prometheus/query_range_response.qtpl:13
@@ -47,33 +47,37 @@ func StreamQueryRangeResponse(qw422016 *qt422016.Writer, isPartial bool, rs []ne
 //line app/vmselect/prometheus/query_range_response.qtpl:17
 	}
 //line app/vmselect/prometheus/query_range_response.qtpl:17
-	qw422016.N().S(`,"data":{"resultType":"matrix","result":[`)
-//line app/vmselect/prometheus/query_range_response.qtpl:21
-	if len(rs) > 0 {
+	qw422016.N().S(`,`)
+//line app/vmselect/prometheus/query_range_response.qtpl:18
+	streammissingStorageNodes(qw422016, pr)
+//line app/vmselect/prometheus/query_range_response.qtpl:18
+	qw422016.N().S(`"data":{"resultType":"matrix","result":[`)
 //line app/vmselect/prometheus/query_range_response.qtpl:22
-		streamqueryRangeLine(qw422016, &rs[0])
+	if len(rs) > 0 {
 //line app/vmselect/prometheus/query_range_response.qtpl:23
+		streamqueryRangeLine(qw422016, &rs[0])
+//line app/vmselect/prometheus/query_range_response.qtpl:24
 		pointsCount += len(rs[0].Values)
 
-//line app/vmselect/prometheus/query_range_response.qtpl:24
+//line app/vmselect/prometheus/query_range_response.qtpl:25
 		rs = rs[1:]
 
-//line app/vmselect/prometheus/query_range_response.qtpl:25
+//line app/vmselect/prometheus/query_range_response.qtpl:26
 		for i := range rs {
-//line app/vmselect/prometheus/query_range_response.qtpl:25
-			qw422016.N().S(`,`)
 //line app/vmselect/prometheus/query_range_response.qtpl:26
-			streamqueryRangeLine(qw422016, &rs[i])
+			qw422016.N().S(`,`)
 //line app/vmselect/prometheus/query_range_response.qtpl:27
+			streamqueryRangeLine(qw422016, &rs[i])
+//line app/vmselect/prometheus/query_range_response.qtpl:28
 			pointsCount += len(rs[i].Values)
 
-//line app/vmselect/prometheus/query_range_response.qtpl:28
-		}
 //line app/vmselect/prometheus/query_range_response.qtpl:29
+		}
+//line app/vmselect/prometheus/query_range_response.qtpl:30
 	}
-//line app/vmselect/prometheus/query_range_response.qtpl:29
+//line app/vmselect/prometheus/query_range_response.qtpl:30
 	qw422016.N().S(`]},"stats":{`)
-//line app/vmselect/prometheus/query_range_response.qtpl:34
+//line app/vmselect/prometheus/query_range_response.qtpl:35
 	// seriesFetched is string instead of int because of historical reasons.
 	// It cannot be converted to int without breaking backwards compatibility at vmalert :(
 	executionDuration := int64(0)
@@ -81,90 +85,90 @@ func StreamQueryRangeResponse(qw422016 *qt422016.Writer, isPartial bool, rs []ne
 		executionDuration = ed.Milliseconds()
 	}
 
-//line app/vmselect/prometheus/query_range_response.qtpl:40
-	qw422016.N().S(`"seriesFetched": "`)
 //line app/vmselect/prometheus/query_range_response.qtpl:41
+	qw422016.N().S(`"seriesFetched": "`)
+//line app/vmselect/prometheus/query_range_response.qtpl:42
 	qw422016.N().DL(qs.SeriesFetched.Load())
-//line app/vmselect/prometheus/query_range_response.qtpl:41
-	qw422016.N().S(`","executionTimeMsec":`)
 //line app/vmselect/prometheus/query_range_response.qtpl:42
+	qw422016.N().S(`","executionTimeMsec":`)
+//line app/vmselect/prometheus/query_range_response.qtpl:43
 	qw422016.N().DL(executionDuration)
-//line app/vmselect/prometheus/query_range_response.qtpl:42
+//line app/vmselect/prometheus/query_range_response.qtpl:43
 	qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_range_response.qtpl:45
+//line app/vmselect/prometheus/query_range_response.qtpl:46
 	qt.Printf("generate /api/v1/query_range response for series=%d, points=%d", seriesCount, pointsCount)
 	qtDone()
 
-//line app/vmselect/prometheus/query_range_response.qtpl:48
+//line app/vmselect/prometheus/query_range_response.qtpl:49
 	streamdumpQueryTrace(qw422016, qt)
-//line app/vmselect/prometheus/query_range_response.qtpl:48
+//line app/vmselect/prometheus/query_range_response.qtpl:49
 	qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 }
 
-//line app/vmselect/prometheus/query_range_response.qtpl:50
-func WriteQueryRangeResponse(qq422016 qtio422016.Writer, isPartial bool, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
+func WriteQueryRangeResponse(qq422016 qtio422016.Writer, isPartial bool, pr *netstorage.PartialResponse, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
-	StreamQueryRangeResponse(qw422016, isPartial, rs, qt, qtDone, qs)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
+	StreamQueryRangeResponse(qw422016, isPartial, pr, rs, qt, qtDone, qs)
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 }
 
-//line app/vmselect/prometheus/query_range_response.qtpl:50
-func QueryRangeResponse(isPartial bool, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) string {
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
+func QueryRangeResponse(isPartial bool, pr *netstorage.PartialResponse, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) string {
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/prometheus/query_range_response.qtpl:50
-	WriteQueryRangeResponse(qb422016, isPartial, rs, qt, qtDone, qs)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
+	WriteQueryRangeResponse(qb422016, isPartial, pr, rs, qt, qtDone, qs)
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 	qs422016 := string(qb422016.B)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 	return qs422016
-//line app/vmselect/prometheus/query_range_response.qtpl:50
+//line app/vmselect/prometheus/query_range_response.qtpl:51
 }
 
-//line app/vmselect/prometheus/query_range_response.qtpl:52
+//line app/vmselect/prometheus/query_range_response.qtpl:53
 func streamqueryRangeLine(qw422016 *qt422016.Writer, r *netstorage.Result) {
-//line app/vmselect/prometheus/query_range_response.qtpl:52
+//line app/vmselect/prometheus/query_range_response.qtpl:53
 	qw422016.N().S(`{"metric":`)
-//line app/vmselect/prometheus/query_range_response.qtpl:54
+//line app/vmselect/prometheus/query_range_response.qtpl:55
 	streammetricNameObject(qw422016, &r.MetricName)
-//line app/vmselect/prometheus/query_range_response.qtpl:54
-	qw422016.N().S(`,"values":`)
 //line app/vmselect/prometheus/query_range_response.qtpl:55
+	qw422016.N().S(`,"values":`)
+//line app/vmselect/prometheus/query_range_response.qtpl:56
 	streamvaluesWithTimestamps(qw422016, r.Values, r.Timestamps)
-//line app/vmselect/prometheus/query_range_response.qtpl:55
+//line app/vmselect/prometheus/query_range_response.qtpl:56
 	qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 }
 
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 func writequeryRangeLine(qq422016 qtio422016.Writer, r *netstorage.Result) {
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	streamqueryRangeLine(qw422016, r)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 }
 
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 func queryRangeLine(r *netstorage.Result) string {
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	writequeryRangeLine(qb422016, r)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	qs422016 := string(qb422016.B)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 	return qs422016
-//line app/vmselect/prometheus/query_range_response.qtpl:57
+//line app/vmselect/prometheus/query_range_response.qtpl:58
 }
@@ -27,7 +27,7 @@ var (
 )
 
 //line app/vmselect/prometheus/query_response.qtpl:10
-func StreamQueryResponse(qw422016 *qt422016.Writer, isPartial bool, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
+func StreamQueryResponse(qw422016 *qt422016.Writer, isPartial bool, pr *netstorage.PartialResponse, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
 //line app/vmselect/prometheus/query_response.qtpl:10
 	qw422016.N().S(`{`)
 //line app/vmselect/prometheus/query_response.qtpl:12
@@ -46,44 +46,48 @@ func StreamQueryResponse(qw422016 *qt422016.Writer, isPartial bool, rs []netstor
 //line app/vmselect/prometheus/query_response.qtpl:14
 	}
 //line app/vmselect/prometheus/query_response.qtpl:14
-	qw422016.N().S(`,"data":{"resultType":"vector","result":[`)
-//line app/vmselect/prometheus/query_response.qtpl:18
+	qw422016.N().S(`,`)
+//line app/vmselect/prometheus/query_response.qtpl:15
+	streammissingStorageNodes(qw422016, pr)
+//line app/vmselect/prometheus/query_response.qtpl:15
+	qw422016.N().S(`"data":{"resultType":"vector","result":[`)
+//line app/vmselect/prometheus/query_response.qtpl:19
 	if len(rs) > 0 {
-//line app/vmselect/prometheus/query_response.qtpl:18
+//line app/vmselect/prometheus/query_response.qtpl:19
 		qw422016.N().S(`{"metric":`)
-//line app/vmselect/prometheus/query_response.qtpl:20
+//line app/vmselect/prometheus/query_response.qtpl:21
 		streammetricNameObject(qw422016, &rs[0].MetricName)
-//line app/vmselect/prometheus/query_response.qtpl:20
-		qw422016.N().S(`,"value":`)
 //line app/vmselect/prometheus/query_response.qtpl:21
+		qw422016.N().S(`,"value":`)
+//line app/vmselect/prometheus/query_response.qtpl:22
 		streammetricRow(qw422016, rs[0].Timestamps[0], rs[0].Values[0])
-//line app/vmselect/prometheus/query_response.qtpl:21
+//line app/vmselect/prometheus/query_response.qtpl:22
 		qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_response.qtpl:23
+//line app/vmselect/prometheus/query_response.qtpl:24
 		rs = rs[1:]
 
-//line app/vmselect/prometheus/query_response.qtpl:24
-		for i := range rs {
 //line app/vmselect/prometheus/query_response.qtpl:25
+		for i := range rs {
+//line app/vmselect/prometheus/query_response.qtpl:26
 			r := &rs[i]
 
-//line app/vmselect/prometheus/query_response.qtpl:25
+//line app/vmselect/prometheus/query_response.qtpl:26
 			qw422016.N().S(`,{"metric":`)
-//line app/vmselect/prometheus/query_response.qtpl:27
+//line app/vmselect/prometheus/query_response.qtpl:28
 			streammetricNameObject(qw422016, &r.MetricName)
-//line app/vmselect/prometheus/query_response.qtpl:27
-			qw422016.N().S(`,"value":`)
 //line app/vmselect/prometheus/query_response.qtpl:28
+			qw422016.N().S(`,"value":`)
+//line app/vmselect/prometheus/query_response.qtpl:29
 			streammetricRow(qw422016, r.Timestamps[0], r.Values[0])
-//line app/vmselect/prometheus/query_response.qtpl:28
+//line app/vmselect/prometheus/query_response.qtpl:29
 			qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_response.qtpl:30
-		}
 //line app/vmselect/prometheus/query_response.qtpl:31
+		}
+//line app/vmselect/prometheus/query_response.qtpl:32
 	}
-//line app/vmselect/prometheus/query_response.qtpl:31
+//line app/vmselect/prometheus/query_response.qtpl:32
 	qw422016.N().S(`]},"stats":{`)
-//line app/vmselect/prometheus/query_response.qtpl:36
+//line app/vmselect/prometheus/query_response.qtpl:37
 	// seriesFetched is string instead of int because of historical reasons.
 	// It cannot be converted to int without breaking backwards compatibility at vmalert :(
 	executionDuration := int64(0)
@@ -91,49 +95,49 @@ func StreamQueryResponse(qw422016 *qt422016.Writer, isPartial bool, rs []netstor
 		executionDuration = ed.Milliseconds()
 	}
 
-//line app/vmselect/prometheus/query_response.qtpl:42
-	qw422016.N().S(`"seriesFetched": "`)
 //line app/vmselect/prometheus/query_response.qtpl:43
+	qw422016.N().S(`"seriesFetched": "`)
+//line app/vmselect/prometheus/query_response.qtpl:44
 	qw422016.N().DL(qs.SeriesFetched.Load())
-//line app/vmselect/prometheus/query_response.qtpl:43
-	qw422016.N().S(`","executionTimeMsec":`)
 //line app/vmselect/prometheus/query_response.qtpl:44
+	qw422016.N().S(`","executionTimeMsec":`)
+//line app/vmselect/prometheus/query_response.qtpl:45
 	qw422016.N().DL(executionDuration)
-//line app/vmselect/prometheus/query_response.qtpl:44
+//line app/vmselect/prometheus/query_response.qtpl:45
 	qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_response.qtpl:47
+//line app/vmselect/prometheus/query_response.qtpl:48
 	qt.Printf("generate /api/v1/query response for series=%d", seriesCount)
 	qtDone()
 
-//line app/vmselect/prometheus/query_response.qtpl:50
+//line app/vmselect/prometheus/query_response.qtpl:51
 	streamdumpQueryTrace(qw422016, qt)
-//line app/vmselect/prometheus/query_response.qtpl:50
+//line app/vmselect/prometheus/query_response.qtpl:51
 	qw422016.N().S(`}`)
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
 }
 
-//line app/vmselect/prometheus/query_response.qtpl:52
-func WriteQueryResponse(qq422016 qtio422016.Writer, isPartial bool, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
+func WriteQueryResponse(qq422016 qtio422016.Writer, isPartial bool, pr *netstorage.PartialResponse, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) {
+//line app/vmselect/prometheus/query_response.qtpl:53
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/prometheus/query_response.qtpl:52
-	StreamQueryResponse(qw422016, isPartial, rs, qt, qtDone, qs)
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
+	StreamQueryResponse(qw422016, isPartial, pr, rs, qt, qtDone, qs)
+//line app/vmselect/prometheus/query_response.qtpl:53
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
 }
 
-//line app/vmselect/prometheus/query_response.qtpl:52
-func QueryResponse(isPartial bool, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) string {
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
+func QueryResponse(isPartial bool, pr *netstorage.PartialResponse, rs []netstorage.Result, qt *querytracer.Tracer, qtDone func(), qs *promql.QueryStats) string {
+//line app/vmselect/prometheus/query_response.qtpl:53
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/prometheus/query_response.qtpl:52
-	WriteQueryResponse(qb422016, isPartial, rs, qt, qtDone, qs)
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
+	WriteQueryResponse(qb422016, isPartial, pr, rs, qt, qtDone, qs)
+//line app/vmselect/prometheus/query_response.qtpl:53
 	qs422016 := string(qb422016.B)
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
 	return qs422016
-//line app/vmselect/prometheus/query_response.qtpl:52
+//line app/vmselect/prometheus/query_response.qtpl:53
 }
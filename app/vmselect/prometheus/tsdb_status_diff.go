@@ -0,0 +1,96 @@
+package prometheus
+
+import (
+	"sort"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// TSDBStatusDiff contains the difference between two tsdb statuses.
+type TSDBStatusDiff struct {
+	TotalSeries                  TSDBStatusDiffEntry
+	TotalLabelValuePairs         TSDBStatusDiffEntry
+	SeriesCountByMetricName      []TSDBStatusDiffEntry
+	SeriesCountByLabelName       []TSDBStatusDiffEntry
+	SeriesCountByFocusLabelValue []TSDBStatusDiffEntry
+	SeriesCountByLabelValuePair  []TSDBStatusDiffEntry
+	LabelValueCountByLabelName   []TSDBStatusDiffEntry
+}
+
+// TSDBStatusDiffEntry contains the difference for a single tsdb status entry.
+type TSDBStatusDiffEntry struct {
+	Name      string
+	Value     uint64
+	BaseValue uint64
+}
+
+// Diff returns the difference between e.Value and e.BaseValue.
+func (e *TSDBStatusDiffEntry) Diff() int64 {
+	return int64(e.Value) - int64(e.BaseValue)
+}
+
+func newTSDBStatusDiff(status, baseStatus *storage.TSDBStatus, topN int) *TSDBStatusDiff {
+	return &TSDBStatusDiff{
+		TotalSeries: TSDBStatusDiffEntry{
+			Value:     status.TotalSeries,
+			BaseValue: baseStatus.TotalSeries,
+		},
+		TotalLabelValuePairs: TSDBStatusDiffEntry{
+			Value:     status.TotalLabelValuePairs,
+			BaseValue: baseStatus.TotalLabelValuePairs,
+		},
+		SeriesCountByMetricName:      getTSDBStatusDiffEntries(status.SeriesCountByMetricName, baseStatus.SeriesCountByMetricName, topN),
+		SeriesCountByLabelName:       getTSDBStatusDiffEntries(status.SeriesCountByLabelName, baseStatus.SeriesCountByLabelName, topN),
+		SeriesCountByFocusLabelValue: getTSDBStatusDiffEntries(status.SeriesCountByFocusLabelValue, baseStatus.SeriesCountByFocusLabelValue, topN),
+		SeriesCountByLabelValuePair:  getTSDBStatusDiffEntries(status.SeriesCountByLabelValuePair, baseStatus.SeriesCountByLabelValuePair, topN),
+		LabelValueCountByLabelName:   getTSDBStatusDiffEntries(status.LabelValueCountByLabelName, baseStatus.LabelValueCountByLabelName, topN),
+	}
+}
+
+// getTSDBStatusDiffEntries returns topN entries with the biggest absolute difference between a and baseA.
+//
+// Entries missing in a or in baseA are treated as entries with zero value.
+func getTSDBStatusDiffEntries(a, baseA []storage.TopHeapEntry, topN int) []TSDBStatusDiffEntry {
+	m := make(map[string]*TSDBStatusDiffEntry, len(a)+len(baseA))
+	for _, e := range a {
+		m[e.Name] = &TSDBStatusDiffEntry{
+			Name:  e.Name,
+			Value: e.Count,
+		}
+	}
+	for _, e := range baseA {
+		de := m[e.Name]
+		if de == nil {
+			de = &TSDBStatusDiffEntry{
+				Name: e.Name,
+			}
+			m[e.Name] = de
+		}
+		de.BaseValue = e.Count
+	}
+	entries := make([]TSDBStatusDiffEntry, 0, len(m))
+	for _, de := range m {
+		if de.Diff() == 0 {
+			continue
+		}
+		entries = append(entries, *de)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := absInt64(entries[i].Diff()), absInt64(entries[j].Diff())
+		if a != b {
+			return a > b
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > topN {
+		entries = entries[:topN]
+	}
+	return entries
+}
+
+func absInt64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
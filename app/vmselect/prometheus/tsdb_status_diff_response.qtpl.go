@@ -0,0 +1,216 @@
+// Code generated by qtc from "tsdb_status_diff_response.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:1
+package prometheus
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:1
+import (
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+)
+
+// TSDBStatusDiffResponse generates response for /api/v1/status/tsdb_diff .
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:7
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:7
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:7
+func StreamTSDBStatusDiffResponse(qw422016 *qt422016.Writer, isPartial bool, diff *TSDBStatusDiff, qt *querytracer.Tracer) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:7
+	qw422016.N().S(`{"status":"success","isPartial":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:10
+	if isPartial {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:10
+		qw422016.N().S(`true`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:10
+	} else {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:10
+		qw422016.N().S(`false`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:10
+	}
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:10
+	qw422016.N().S(`,"data":{"totalSeries":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:12
+	streamtsdbStatusDiffValue(qw422016, &diff.TotalSeries)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:12
+	qw422016.N().S(`,"totalLabelValuePairs":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:13
+	streamtsdbStatusDiffValue(qw422016, &diff.TotalLabelValuePairs)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:13
+	qw422016.N().S(`,"seriesCountByMetricName":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:14
+	streamtsdbStatusDiffEntries(qw422016, diff.SeriesCountByMetricName)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:14
+	qw422016.N().S(`,"seriesCountByLabelName":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:15
+	streamtsdbStatusDiffEntries(qw422016, diff.SeriesCountByLabelName)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:15
+	qw422016.N().S(`,"seriesCountByFocusLabelValue":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:16
+	streamtsdbStatusDiffEntries(qw422016, diff.SeriesCountByFocusLabelValue)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:16
+	qw422016.N().S(`,"seriesCountByLabelValuePair":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:17
+	streamtsdbStatusDiffEntries(qw422016, diff.SeriesCountByLabelValuePair)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:17
+	qw422016.N().S(`,"labelValueCountByLabelName":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:18
+	streamtsdbStatusDiffEntries(qw422016, diff.LabelValueCountByLabelName)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:18
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:20
+	qt.Done()
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:21
+	streamdumpQueryTrace(qw422016, qt)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:21
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+func WriteTSDBStatusDiffResponse(qq422016 qtio422016.Writer, isPartial bool, diff *TSDBStatusDiff, qt *querytracer.Tracer) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	StreamTSDBStatusDiffResponse(qw422016, isPartial, diff, qt)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+func TSDBStatusDiffResponse(isPartial bool, diff *TSDBStatusDiff, qt *querytracer.Tracer) string {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	WriteTSDBStatusDiffResponse(qb422016, isPartial, diff, qt)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+	return qs422016
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:23
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:25
+func streamtsdbStatusDiffValue(qw422016 *qt422016.Writer, e *TSDBStatusDiffEntry) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:25
+	qw422016.N().S(`{"value":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:27
+	qw422016.N().DUL(e.Value)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:27
+	qw422016.N().S(`,"baseValue":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:28
+	qw422016.N().DUL(e.BaseValue)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:28
+	qw422016.N().S(`,"diff":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:29
+	qw422016.N().DL(e.Diff())
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:29
+	qw422016.N().S(`}`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+func writetsdbStatusDiffValue(qq422016 qtio422016.Writer, e *TSDBStatusDiffEntry) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	streamtsdbStatusDiffValue(qw422016, e)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+func tsdbStatusDiffValue(e *TSDBStatusDiffEntry) string {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	writetsdbStatusDiffValue(qb422016, e)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+	return qs422016
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:31
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:33
+func streamtsdbStatusDiffEntries(qw422016 *qt422016.Writer, a []TSDBStatusDiffEntry) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:33
+	qw422016.N().S(`[`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:35
+	for i := range a {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:36
+		e := &a[i]
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:36
+		qw422016.N().S(`{"name":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:38
+		qw422016.N().Q(e.Name)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:38
+		qw422016.N().S(`,"value":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:39
+		qw422016.N().DUL(e.Value)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:39
+		qw422016.N().S(`,"baseValue":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:40
+		qw422016.N().DUL(e.BaseValue)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:40
+		qw422016.N().S(`,"diff":`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:41
+		qw422016.N().DL(e.Diff())
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:41
+		qw422016.N().S(`}`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:43
+		if i+1 < len(a) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:43
+			qw422016.N().S(`,`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:43
+		}
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:44
+	}
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:44
+	qw422016.N().S(`]`)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+func writetsdbStatusDiffEntries(qq422016 qtio422016.Writer, a []TSDBStatusDiffEntry) {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	streamtsdbStatusDiffEntries(qw422016, a)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+}
+
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+func tsdbStatusDiffEntries(a []TSDBStatusDiffEntry) string {
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	writetsdbStatusDiffEntries(qb422016, a)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	qs422016 := string(qb422016.B)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+	return qs422016
+//line app/vmselect/prometheus/tsdb_status_diff_response.qtpl:46
+}
@@ -0,0 +1,52 @@
+package prometheus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestGetTSDBStatusDiffEntries(t *testing.T) {
+	f := func(a, baseA []storage.TopHeapEntry, topN int, resultExpected []TSDBStatusDiffEntry) {
+		t.Helper()
+		result := getTSDBStatusDiffEntries(a, baseA, topN)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result;\ngot\n%v\nwant\n%v", result, resultExpected)
+		}
+	}
+
+	// Empty entries
+	f(nil, nil, 10, []TSDBStatusDiffEntry{})
+
+	// Entries without changes are skipped
+	f([]storage.TopHeapEntry{
+		{Name: "foo", Count: 10},
+	}, []storage.TopHeapEntry{
+		{Name: "foo", Count: 10},
+	}, 10, []TSDBStatusDiffEntry{})
+
+	// Entries are sorted by the absolute difference
+	f([]storage.TopHeapEntry{
+		{Name: "foo", Count: 100},
+		{Name: "bar", Count: 10},
+		{Name: "new", Count: 30},
+	}, []storage.TopHeapEntry{
+		{Name: "foo", Count: 90},
+		{Name: "bar", Count: 60},
+		{Name: "old", Count: 30},
+	}, 10, []TSDBStatusDiffEntry{
+		{Name: "bar", Value: 10, BaseValue: 60},
+		{Name: "new", Value: 30},
+		{Name: "old", BaseValue: 30},
+		{Name: "foo", Value: 100, BaseValue: 90},
+	})
+
+	// The number of entries is limited by topN
+	f([]storage.TopHeapEntry{
+		{Name: "foo", Count: 100},
+		{Name: "bar", Count: 10},
+	}, nil, 1, []TSDBStatusDiffEntry{
+		{Name: "foo", Value: 100},
+	})
+}
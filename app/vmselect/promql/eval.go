@@ -155,8 +155,14 @@ type EvalConfig struct {
 	// The request URI isn't stored here because its' construction may take non-trivial amounts of CPU.
 	GetRequestURI func() string
 
-	// Whether to deny partial response.
-	DenyPartialResponse bool
+	// PartialResponse controls whether to deny partial response and tracks vmstorage nodes, which failed to return response.
+	PartialResponse *netstorage.PartialResponse
+
+	// ReplicaLabels contains label names, which must be removed from the selected series
+	// before merging series from distinct replicas into a single series.
+	//
+	// See netstorage.Results.MergeReplicas for details.
+	ReplicaLabels []string
 
 	// IsPartialResponse is set during query execution and can be used by Exec caller after query execution.
 	IsPartialResponse atomic.Bool
@@ -187,7 +193,8 @@ func copyEvalConfig(src *EvalConfig) *EvalConfig {
 	ec.EnforcedTagFilterss = src.EnforcedTagFilterss
 	ec.CacheTagFilters = src.CacheTagFilters
 	ec.GetRequestURI = src.GetRequestURI
-	ec.DenyPartialResponse = src.DenyPartialResponse
+	ec.PartialResponse = src.PartialResponse
+	ec.ReplicaLabels = src.ReplicaLabels
 	ec.IsPartialResponse.Store(src.IsPartialResponse.Load())
 	ec.QueryStats = src.QueryStats
 
@@ -1117,12 +1124,12 @@ func evalInstantRollup(qt *querytracer.Tracer, ec *EvalConfig, funcName string,
 		at = ec.AuthTokens[0]
 	}
 	deleteCachedSeries := func(qt *querytracer.Tracer) {
-		rollupResultCacheV.DeleteInstantValues(qt, at, expr, window, ec.Step, ec.EnforcedTagFilterss)
+		rollupResultCacheV.DeleteInstantValues(qt, at, expr, window, ec.Step, ec.EnforcedTagFilterss, ec.ReplicaLabels)
 	}
 	getCachedSeries := func(qt *querytracer.Tracer) ([]*timeseries, int64, error) {
 	again:
 		offset := int64(0)
-		tssCached := rollupResultCacheV.GetInstantValues(qt, at, expr, window, ec.Step, ec.EnforcedTagFilterss)
+		tssCached := rollupResultCacheV.GetInstantValues(qt, at, expr, window, ec.Step, ec.EnforcedTagFilterss, ec.ReplicaLabels)
 		if len(tssCached) == 0 {
 			// Cache miss. Re-populate the missing data.
 			start := int64(fasttime.UnixTimestamp()*1000) - cacheTimestampOffset.Milliseconds()
@@ -1147,7 +1154,7 @@ func evalInstantRollup(qt *querytracer.Tracer, ec *EvalConfig, funcName string,
 				tss, err := evalAt(qt, timestamp, window)
 				return tss, 0, err
 			}
-			rollupResultCacheV.PutInstantValues(qt, at, expr, window, ec.Step, ec.EnforcedTagFilterss, tss)
+			rollupResultCacheV.PutInstantValues(qt, at, expr, window, ec.Step, ec.EnforcedTagFilterss, ec.ReplicaLabels, tss)
 			return tss, offset, nil
 		}
 		// Cache hit. Verify whether it is OK to use the cached data.
@@ -1749,11 +1756,15 @@ func evalRollupFuncNoCache(qt *querytracer.Tracer, ec *EvalConfig, funcName stri
 	} else {
 		sq = storage.NewSearchQuery(ec.AuthTokens[0].AccountID, ec.AuthTokens[0].ProjectID, minTimestamp, ec.End, tfss, ec.MaxSeries)
 	}
-	rss, isPartial, err := netstorage.ProcessSearchQuery(qt, ec.DenyPartialResponse, sq, ec.Deadline)
+	rss, isPartial, err := netstorage.ProcessSearchQuery(qt, ec.PartialResponse, sq, ec.Deadline)
 	if err != nil {
 		return nil, err
 	}
 	ec.updateIsPartialResponse(isPartial)
+	if err := rss.MergeReplicas(ec.ReplicaLabels); err != nil {
+		rss.Cancel()
+		return nil, fmt.Errorf("cannot merge replicas by labels %q: %w", ec.ReplicaLabels, err)
+	}
 	qs := ec.QueryStats
 	rssLen := rss.Len()
 	if rssLen == 0 {
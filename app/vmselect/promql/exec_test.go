@@ -7559,6 +7559,83 @@ func TestExecSuccess(t *testing.T) {
 		resultExpected := []netstorage.Result{r}
 		f(q, resultExpected)
 	})
+	t.Run(`seasonal_naive_over_time()`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_naive_over_time(time()[600s:200s], 200s)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{700, 900, 1100, 1300, 1500, 1700},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_zscore_over_time()`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_zscore_over_time(time()[600s:200s], 200s)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{3, 3, 3, 3, 3, 3},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_zscore_over_time(const)`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_zscore_over_time(1[600s:200s], 200s)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{0, 0, 0, 0, 0, 0},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_mad_score_over_time()`, func(t *testing.T) {
+		t.Parallel()
+		q := `round(seasonal_mad_score_over_time(time()[600s:200s], 200s), 0.01)`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{2.02, 2.02, 2.02, 2.02, 2.02, 2.02},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_decompose_over_time(trend)`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_decompose_over_time(time()[1000s:100s], 300s, "trend")`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{900, 1100, 1300, 1500, 1700, 1900},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_decompose_over_time(seasonal)`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_decompose_over_time(time()[1000s:100s], 300s, "seasonal")`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{100, 100, 100, 100, 100, 100},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
+	t.Run(`seasonal_decompose_over_time(residual)`, func(t *testing.T) {
+		t.Parallel()
+		q := `seasonal_decompose_over_time(time()[1000s:100s], 300s, "residual")`
+		r := netstorage.Result{
+			MetricName: metricNameExpected,
+			Values:     []float64{0, 0, 0, 0, 0, 0},
+			Timestamps: timestampsExpected,
+		}
+		resultExpected := []netstorage.Result{r}
+		f(q, resultExpected)
+	})
 	t.Run(`integrate(1)`, func(t *testing.T) {
 		t.Parallel()
 		q := `integrate(1)`
@@ -9992,6 +10069,12 @@ func TestExecError(t *testing.T) {
 	f(`mode_over_time()`)
 	f(`rate_over_sum()`)
 	f(`zscore_over_time()`)
+	f(`seasonal_naive_over_time()`)
+	f(`seasonal_naive_over_time(foo)`)
+	f(`seasonal_zscore_over_time(foo)`)
+	f(`seasonal_mad_score_over_time(foo)`)
+	f(`seasonal_decompose_over_time(foo, 1h)`)
+	f(`seasonal_decompose_over_time(foo, 1h, "bar")`)
 	f(`mode()`)
 	f(`share()`)
 	f(`zscore()`)
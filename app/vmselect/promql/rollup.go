@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,79 +23,83 @@ var minStalenessInterval = flag.Duration("search.minStalenessInterval", 0, "The
 	"See also '-search.maxStalenessInterval'")
 
 var rollupFuncs = map[string]newRollupFunc{
-	"absent_over_time":        newRollupFuncOneArg(rollupAbsent),
-	"aggr_over_time":          newRollupFuncTwoArgs(rollupFake),
-	"ascent_over_time":        newRollupFuncOneArg(rollupAscentOverTime),
-	"avg_over_time":           newRollupFuncOneArg(rollupAvg),
-	"changes":                 newRollupFuncOneArg(rollupChanges),
-	"changes_prometheus":      newRollupFuncOneArg(rollupChangesPrometheus),
-	"count_eq_over_time":      newRollupCountEQ,
-	"count_gt_over_time":      newRollupCountGT,
-	"count_le_over_time":      newRollupCountLE,
-	"count_ne_over_time":      newRollupCountNE,
-	"count_over_time":         newRollupFuncOneArg(rollupCount),
-	"count_values_over_time":  newRollupCountValues,
-	"decreases_over_time":     newRollupFuncOneArg(rollupDecreases),
-	"default_rollup":          newRollupFuncOneArg(rollupDefault), // default rollup func
-	"delta":                   newRollupFuncOneArg(rollupDelta),
-	"delta_prometheus":        newRollupFuncOneArg(rollupDeltaPrometheus),
-	"deriv":                   newRollupFuncOneArg(rollupDerivSlow),
-	"deriv_fast":              newRollupFuncOneArg(rollupDerivFast),
-	"descent_over_time":       newRollupFuncOneArg(rollupDescentOverTime),
-	"distinct_over_time":      newRollupFuncOneArg(rollupDistinct),
-	"duration_over_time":      newRollupDurationOverTime,
-	"first_over_time":         newRollupFuncOneArg(rollupFirst),
-	"geomean_over_time":       newRollupFuncOneArg(rollupGeomean),
-	"histogram_over_time":     newRollupFuncOneArg(rollupHistogram),
-	"hoeffding_bound_lower":   newRollupHoeffdingBoundLower,
-	"hoeffding_bound_upper":   newRollupHoeffdingBoundUpper,
-	"holt_winters":            newRollupHoltWinters,
-	"idelta":                  newRollupFuncOneArg(rollupIdelta),
-	"ideriv":                  newRollupFuncOneArg(rollupIderiv),
-	"increase":                newRollupFuncOneArg(rollupDelta),           // + rollupFuncsRemoveCounterResets
-	"increase_prometheus":     newRollupFuncOneArg(rollupDeltaPrometheus), // + rollupFuncsRemoveCounterResets
-	"increase_pure":           newRollupFuncOneArg(rollupIncreasePure),    // + rollupFuncsRemoveCounterResets
-	"increases_over_time":     newRollupFuncOneArg(rollupIncreases),
-	"integrate":               newRollupFuncOneArg(rollupIntegrate),
-	"irate":                   newRollupFuncOneArg(rollupIderiv), // + rollupFuncsRemoveCounterResets
-	"lag":                     newRollupFuncOneArg(rollupLag),
-	"last_over_time":          newRollupFuncOneArg(rollupLast),
-	"lifetime":                newRollupFuncOneArg(rollupLifetime),
-	"mad_over_time":           newRollupFuncOneArg(rollupMAD),
-	"max_over_time":           newRollupFuncOneArg(rollupMax),
-	"median_over_time":        newRollupFuncOneArg(rollupMedian),
-	"min_over_time":           newRollupFuncOneArg(rollupMin),
-	"mode_over_time":          newRollupFuncOneArg(rollupModeOverTime),
-	"outlier_iqr_over_time":   newRollupFuncOneArg(rollupOutlierIQR),
-	"predict_linear":          newRollupPredictLinear,
-	"present_over_time":       newRollupFuncOneArg(rollupPresent),
-	"quantile_over_time":      newRollupQuantile,
-	"quantiles_over_time":     newRollupQuantiles,
-	"range_over_time":         newRollupFuncOneArg(rollupRange),
-	"rate":                    newRollupFuncOneArg(rollupDerivFast),           // + rollupFuncsRemoveCounterResets
-	"rate_prometheus":         newRollupFuncOneArg(rollupDerivFastPrometheus), // + rollupFuncsRemoveCounterResets
-	"rate_over_sum":           newRollupFuncOneArg(rollupRateOverSum),
-	"resets":                  newRollupFuncOneArg(rollupResets),
-	"rollup":                  newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_candlestick":      newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_delta":            newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_deriv":            newRollupFuncOneOrTwoArgs(rollupFake),
-	"rollup_increase":         newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
-	"rollup_rate":             newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
-	"rollup_scrape_interval":  newRollupFuncOneOrTwoArgs(rollupFake),
-	"scrape_interval":         newRollupFuncOneArg(rollupScrapeInterval),
-	"share_eq_over_time":      newRollupShareEQ,
-	"share_gt_over_time":      newRollupShareGT,
-	"share_le_over_time":      newRollupShareLE,
-	"stale_samples_over_time": newRollupFuncOneArg(rollupStaleSamples),
-	"stddev_over_time":        newRollupFuncOneArg(rollupStddev),
-	"stdvar_over_time":        newRollupFuncOneArg(rollupStdvar),
-	"sum_eq_over_time":        newRollupSumEQ,
-	"sum_gt_over_time":        newRollupSumGT,
-	"sum_le_over_time":        newRollupSumLE,
-	"sum_over_time":           newRollupFuncOneArg(rollupSum),
-	"sum2_over_time":          newRollupFuncOneArg(rollupSum2),
-	"tfirst_over_time":        newRollupFuncOneArg(rollupTfirst),
+	"absent_over_time":             newRollupFuncOneArg(rollupAbsent),
+	"aggr_over_time":               newRollupFuncTwoArgs(rollupFake),
+	"ascent_over_time":             newRollupFuncOneArg(rollupAscentOverTime),
+	"avg_over_time":                newRollupFuncOneArg(rollupAvg),
+	"changes":                      newRollupFuncOneArg(rollupChanges),
+	"changes_prometheus":           newRollupFuncOneArg(rollupChangesPrometheus),
+	"count_eq_over_time":           newRollupCountEQ,
+	"count_gt_over_time":           newRollupCountGT,
+	"count_le_over_time":           newRollupCountLE,
+	"count_ne_over_time":           newRollupCountNE,
+	"count_over_time":              newRollupFuncOneArg(rollupCount),
+	"count_values_over_time":       newRollupCountValues,
+	"decreases_over_time":          newRollupFuncOneArg(rollupDecreases),
+	"default_rollup":               newRollupFuncOneArg(rollupDefault), // default rollup func
+	"delta":                        newRollupFuncOneArg(rollupDelta),
+	"delta_prometheus":             newRollupFuncOneArg(rollupDeltaPrometheus),
+	"deriv":                        newRollupFuncOneArg(rollupDerivSlow),
+	"deriv_fast":                   newRollupFuncOneArg(rollupDerivFast),
+	"descent_over_time":            newRollupFuncOneArg(rollupDescentOverTime),
+	"distinct_over_time":           newRollupFuncOneArg(rollupDistinct),
+	"duration_over_time":           newRollupDurationOverTime,
+	"first_over_time":              newRollupFuncOneArg(rollupFirst),
+	"geomean_over_time":            newRollupFuncOneArg(rollupGeomean),
+	"histogram_over_time":          newRollupFuncOneArg(rollupHistogram),
+	"hoeffding_bound_lower":        newRollupHoeffdingBoundLower,
+	"hoeffding_bound_upper":        newRollupHoeffdingBoundUpper,
+	"holt_winters":                 newRollupHoltWinters,
+	"idelta":                       newRollupFuncOneArg(rollupIdelta),
+	"ideriv":                       newRollupFuncOneArg(rollupIderiv),
+	"increase":                     newRollupFuncOneArg(rollupDelta),           // + rollupFuncsRemoveCounterResets
+	"increase_prometheus":          newRollupFuncOneArg(rollupDeltaPrometheus), // + rollupFuncsRemoveCounterResets
+	"increase_pure":                newRollupFuncOneArg(rollupIncreasePure),    // + rollupFuncsRemoveCounterResets
+	"increases_over_time":          newRollupFuncOneArg(rollupIncreases),
+	"integrate":                    newRollupFuncOneArg(rollupIntegrate),
+	"irate":                        newRollupFuncOneArg(rollupIderiv), // + rollupFuncsRemoveCounterResets
+	"lag":                          newRollupFuncOneArg(rollupLag),
+	"last_over_time":               newRollupFuncOneArg(rollupLast),
+	"lifetime":                     newRollupFuncOneArg(rollupLifetime),
+	"mad_over_time":                newRollupFuncOneArg(rollupMAD),
+	"max_over_time":                newRollupFuncOneArg(rollupMax),
+	"median_over_time":             newRollupFuncOneArg(rollupMedian),
+	"min_over_time":                newRollupFuncOneArg(rollupMin),
+	"mode_over_time":               newRollupFuncOneArg(rollupModeOverTime),
+	"outlier_iqr_over_time":        newRollupFuncOneArg(rollupOutlierIQR),
+	"predict_linear":               newRollupPredictLinear,
+	"present_over_time":            newRollupFuncOneArg(rollupPresent),
+	"quantile_over_time":           newRollupQuantile,
+	"quantiles_over_time":          newRollupQuantiles,
+	"range_over_time":              newRollupFuncOneArg(rollupRange),
+	"rate":                         newRollupFuncOneArg(rollupDerivFast),           // + rollupFuncsRemoveCounterResets
+	"rate_prometheus":              newRollupFuncOneArg(rollupDerivFastPrometheus), // + rollupFuncsRemoveCounterResets
+	"rate_over_sum":                newRollupFuncOneArg(rollupRateOverSum),
+	"resets":                       newRollupFuncOneArg(rollupResets),
+	"rollup":                       newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_candlestick":           newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_delta":                 newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_deriv":                 newRollupFuncOneOrTwoArgs(rollupFake),
+	"rollup_increase":              newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
+	"rollup_rate":                  newRollupFuncOneOrTwoArgs(rollupFake), // + rollupFuncsRemoveCounterResets
+	"rollup_scrape_interval":       newRollupFuncOneOrTwoArgs(rollupFake),
+	"scrape_interval":              newRollupFuncOneArg(rollupScrapeInterval),
+	"seasonal_decompose_over_time": newRollupSeasonalDecompose,
+	"seasonal_mad_score_over_time": newRollupSeasonalMADScore,
+	"seasonal_naive_over_time":     newRollupSeasonalNaive,
+	"seasonal_zscore_over_time":    newRollupSeasonalZScore,
+	"share_eq_over_time":           newRollupShareEQ,
+	"share_gt_over_time":           newRollupShareGT,
+	"share_le_over_time":           newRollupShareLE,
+	"stale_samples_over_time":      newRollupFuncOneArg(rollupStaleSamples),
+	"stddev_over_time":             newRollupFuncOneArg(rollupStddev),
+	"stdvar_over_time":             newRollupFuncOneArg(rollupStdvar),
+	"sum_eq_over_time":             newRollupSumEQ,
+	"sum_gt_over_time":             newRollupSumGT,
+	"sum_le_over_time":             newRollupSumLE,
+	"sum_over_time":                newRollupFuncOneArg(rollupSum),
+	"sum2_over_time":               newRollupFuncOneArg(rollupSum2),
+	"tfirst_over_time":             newRollupFuncOneArg(rollupTfirst),
 	// `timestamp` function must return timestamp for the last datapoint on the current window
 	// in order to properly handle offset and timestamps unaligned to the current step.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/415 for details.
@@ -265,25 +270,26 @@ var rollupFuncsSamplesScannedPerCall = map[string]int{
 // These functions don't change physical meaning of input time series,
 // so they don't drop metric name
 var rollupFuncsKeepMetricName = map[string]bool{
-	"avg_over_time":         true,
-	"default_rollup":        true,
-	"first_over_time":       true,
-	"geomean_over_time":     true,
-	"hoeffding_bound_lower": true,
-	"hoeffding_bound_upper": true,
-	"holt_winters":          true,
-	"iqr_over_time":         true,
-	"last_over_time":        true,
-	"max_over_time":         true,
-	"median_over_time":      true,
-	"min_over_time":         true,
-	"mode_over_time":        true,
-	"predict_linear":        true,
-	"quantile_over_time":    true,
-	"quantiles_over_time":   true,
-	"rollup":                true,
-	"rollup_candlestick":    true,
-	"timestamp_with_name":   true,
+	"avg_over_time":            true,
+	"default_rollup":           true,
+	"first_over_time":          true,
+	"geomean_over_time":        true,
+	"hoeffding_bound_lower":    true,
+	"hoeffding_bound_upper":    true,
+	"holt_winters":             true,
+	"iqr_over_time":            true,
+	"last_over_time":           true,
+	"max_over_time":            true,
+	"median_over_time":         true,
+	"min_over_time":            true,
+	"mode_over_time":           true,
+	"predict_linear":           true,
+	"quantile_over_time":       true,
+	"quantiles_over_time":      true,
+	"rollup":                   true,
+	"rollup_candlestick":       true,
+	"seasonal_naive_over_time": true,
+	"timestamp_with_name":      true,
 }
 
 func getRollupAggrFuncNames(expr metricsql.Expr) ([]string, error) {
@@ -1102,6 +1108,194 @@ func newRollupPredictLinear(args []any) (rollupFunc, error) {
 	return rf, nil
 }
 
+func newRollupSeasonalNaive(args []any) (rollupFunc, error) {
+	if err := expectRollupArgsNum(args, 2); err != nil {
+		return nil, err
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		// Calculate the average value at the same phase over the previous seasons.
+		// This is a seasonal naive forecast if the window covers a single season.
+		period := getSeasonalPeriod(periods, rfa.idx)
+		a := getFloat64s()
+		a.A = appendSeasonalValues(a.A[:0], rfa.values, rfa.timestamps, rfa.currTimestamp, period)
+		v := avgValue(a.A)
+		putFloat64s(a)
+		return v
+	}
+	return rf, nil
+}
+
+func newRollupSeasonalZScore(args []any) (rollupFunc, error) {
+	if err := expectRollupArgsNum(args, 2); err != nil {
+		return nil, err
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		values := rfa.values
+		if len(values) == 0 {
+			return nan
+		}
+		period := getSeasonalPeriod(periods, rfa.idx)
+		a := getFloat64s()
+		a.A = appendSeasonalValues(a.A[:0], values, rfa.timestamps, rfa.currTimestamp, period)
+		v := nan
+		if len(a.A) >= 2 {
+			d := values[len(values)-1] - avgValue(a.A)
+			if d == 0 {
+				v = 0
+			} else {
+				v = d / stddev(a.A)
+			}
+		}
+		putFloat64s(a)
+		return v
+	}
+	return rf, nil
+}
+
+func newRollupSeasonalMADScore(args []any) (rollupFunc, error) {
+	if err := expectRollupArgsNum(args, 2); err != nil {
+		return nil, err
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		values := rfa.values
+		if len(values) == 0 {
+			return nan
+		}
+		period := getSeasonalPeriod(periods, rfa.idx)
+		a := getFloat64s()
+		a.A = appendSeasonalValues(a.A[:0], values, rfa.timestamps, rfa.currTimestamp, period)
+		v := nan
+		if len(a.A) >= 2 {
+			// See https://en.wikipedia.org/wiki/Median_absolute_deviation#Relation_to_standard_deviation
+			d := values[len(values)-1] - quantile(0.5, a.A)
+			if d == 0 {
+				v = 0
+			} else {
+				v = d / (1.4826 * mad(a.A))
+			}
+		}
+		putFloat64s(a)
+		return v
+	}
+	return rf, nil
+}
+
+func newRollupSeasonalDecompose(args []any) (rollupFunc, error) {
+	if err := expectRollupArgsNum(args, 3); err != nil {
+		return nil, err
+	}
+	periods, err := getScalar(args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	tssComponent, ok := args[2].([]*timeseries)
+	if !ok {
+		return nil, fmt.Errorf(`unexpected type for component arg; got %T; want %T`, args[2], tssComponent)
+	}
+	component, err := getString(tssComponent, 2)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get component: %w", err)
+	}
+	switch component {
+	case "trend", "seasonal", "residual":
+	default:
+		return nil, fmt.Errorf("unexpected component: %q; want `trend`, `seasonal` or `residual`", component)
+	}
+	rf := func(rfa *rollupFuncArg) float64 {
+		// Additive decomposition: value = trend + seasonal + residual.
+		// See https://en.wikipedia.org/wiki/Decomposition_of_time_series
+		values := rfa.values
+		timestamps := rfa.timestamps
+		period := getSeasonalPeriod(periods, rfa.idx)
+		if len(values) == 0 || period <= 0 {
+			return nan
+		}
+		t := rfa.currTimestamp
+		trend := avgValueOnTimeRange(values, timestamps, t-period, t)
+		if component == "trend" {
+			return trend
+		}
+		seasonal := getSeasonalComponent(values, timestamps, t, period)
+		if component == "seasonal" {
+			return seasonal
+		}
+		return values[len(values)-1] - trend - seasonal
+	}
+	return rf, nil
+}
+
+// getSeasonalPeriod returns the period in milliseconds from periods in seconds at the given idx.
+//
+// 0 is returned for invalid periods.
+func getSeasonalPeriod(periods []float64, idx int) int64 {
+	p := periods[idx]
+	if math.IsNaN(p) || p <= 0 {
+		return 0
+	}
+	return int64(p * 1000)
+}
+
+// appendSeasonalValues appends to dst the values at the same phase as currTimestamp in the previous seasons with the given period.
+//
+// The value for the phase is the last value at or before it, so the seasons must be fully covered by timestamps.
+func appendSeasonalValues(dst, values []float64, timestamps []int64, currTimestamp, period int64) []float64 {
+	if period <= 0 || len(timestamps) == 0 {
+		return dst
+	}
+	for ts := currTimestamp - period; ts >= timestamps[0]; ts -= period {
+		idx := getLastSampleIdx(timestamps, ts)
+		dst = append(dst, values[idx])
+	}
+	return dst
+}
+
+// getSeasonalComponent returns the average deviation of values from the trend at the same phase as currTimestamp in the previous seasons.
+//
+// Only seasons with fully covered trend window are taken into account.
+func getSeasonalComponent(values []float64, timestamps []int64, currTimestamp, period int64) float64 {
+	sum := float64(0)
+	n := 0
+	for ts := currTimestamp - period; ts-period >= timestamps[0]; ts -= period {
+		idx := getLastSampleIdx(timestamps, ts)
+		trend := avgValueOnTimeRange(values, timestamps, ts-period, ts)
+		sum += values[idx] - trend
+		n++
+	}
+	if n == 0 {
+		return nan
+	}
+	return sum / float64(n)
+}
+
+// avgValueOnTimeRange returns the average of values with timestamps on the (start ... end] time range.
+func avgValueOnTimeRange(values []float64, timestamps []int64, start, end int64) float64 {
+	i := getLastSampleIdx(timestamps, start) + 1
+	j := getLastSampleIdx(timestamps, end) + 1
+	return avgValue(values[i:j])
+}
+
+// getLastSampleIdx returns the index of the last timestamp at or before ts.
+//
+// -1 is returned if all the timestamps are bigger than ts.
+func getLastSampleIdx(timestamps []int64, ts int64) int {
+	n := sort.Search(len(timestamps), func(i int) bool {
+		return timestamps[i] > ts
+	})
+	return n - 1
+}
+
 func linearRegression(values []float64, timestamps []int64, interceptTime int64) (float64, float64) {
 	if len(values) == 0 {
 		return nan, nan
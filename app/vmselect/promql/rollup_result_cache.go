@@ -2,6 +2,7 @@ package promql
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"github.com/VictoriaMetrics/metrics"
 	"github.com/VictoriaMetrics/metricsql"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
@@ -151,8 +153,9 @@ func StopRollupResultCache() {
 		rollupResultCachePath, time.Since(startTime).Seconds(), fcs.EntriesCount, fcs.BytesSize)
 }
 
-// TODO: convert this cache to distributed cache shared among vmselect
-// instances in the cluster.
+// rollupResultCache may be shared among vmselect nodes listed in -search.rollupResultCachePeers.
+//
+// See https://docs.victoriametrics.com/victoriametrics/cluster-victoriametrics/#shared-rollup-result-cache
 type rollupResultCache struct {
 	c *workingsetcache.Cache
 }
@@ -166,7 +169,7 @@ func ResetRollupResultCache() {
 	logger.Infof("rollupResult cache has been cleared")
 }
 
-func (rrc *rollupResultCache) GetInstantValues(qt *querytracer.Tracer, at *auth.Token, expr metricsql.Expr, window, step int64, etfss [][]storage.TagFilter) []*timeseries {
+func (rrc *rollupResultCache) GetInstantValues(qt *querytracer.Tracer, at *auth.Token, expr metricsql.Expr, window, step int64, etfss [][]storage.TagFilter, replicaLabels []string) []*timeseries {
 	if qt.Enabled() {
 		query := string(expr.AppendString(nil))
 		query = stringsutil.LimitStringLen(query, 300)
@@ -177,7 +180,7 @@ func (rrc *rollupResultCache) GetInstantValues(qt *querytracer.Tracer, at *auth.
 	// Obtain instant values from the cache
 	bb := bbPool.Get()
 	defer bbPool.Put(bb)
-	bb.B = marshalRollupResultCacheKeyForInstantValues(bb.B[:0], at, expr, window, step, etfss)
+	bb.B = marshalRollupResultCacheKeyForInstantValues(bb.B[:0], at, expr, window, step, etfss, replicaLabels)
 	tss, ok := rrc.getSeriesFromCache(qt, bb.B)
 	if !ok || len(tss) == 0 {
 		return nil
@@ -187,7 +190,7 @@ func (rrc *rollupResultCache) GetInstantValues(qt *querytracer.Tracer, at *auth.
 	return tss
 }
 
-func (rrc *rollupResultCache) PutInstantValues(qt *querytracer.Tracer, at *auth.Token, expr metricsql.Expr, window, step int64, etfss [][]storage.TagFilter, tss []*timeseries) {
+func (rrc *rollupResultCache) PutInstantValues(qt *querytracer.Tracer, at *auth.Token, expr metricsql.Expr, window, step int64, etfss [][]storage.TagFilter, replicaLabels []string, tss []*timeseries) {
 	if qt.Enabled() {
 		query := string(expr.AppendString(nil))
 		query = stringsutil.LimitStringLen(query, 300)
@@ -207,14 +210,14 @@ func (rrc *rollupResultCache) PutInstantValues(qt *querytracer.Tracer, at *auth.
 
 	bb := bbPool.Get()
 	defer bbPool.Put(bb)
-	bb.B = marshalRollupResultCacheKeyForInstantValues(bb.B[:0], at, expr, window, step, etfss)
+	bb.B = marshalRollupResultCacheKeyForInstantValues(bb.B[:0], at, expr, window, step, etfss, replicaLabels)
 	_ = rrc.putSeriesToCache(qt, bb.B, step, tss)
 }
 
-func (rrc *rollupResultCache) DeleteInstantValues(qt *querytracer.Tracer, at *auth.Token, expr metricsql.Expr, window, step int64, etfss [][]storage.TagFilter) {
+func (rrc *rollupResultCache) DeleteInstantValues(qt *querytracer.Tracer, at *auth.Token, expr metricsql.Expr, window, step int64, etfss [][]storage.TagFilter, replicaLabels []string) {
 	bb := bbPool.Get()
 	defer bbPool.Put(bb)
-	bb.B = marshalRollupResultCacheKeyForInstantValues(bb.B[:0], at, expr, window, step, etfss)
+	bb.B = marshalRollupResultCacheKeyForInstantValues(bb.B[:0], at, expr, window, step, etfss, replicaLabels)
 	if !rrc.putSeriesToCache(qt, bb.B, step, nil) {
 		logger.Panicf("BUG: cannot store zero series to cache")
 	}
@@ -241,8 +244,8 @@ func (rrc *rollupResultCache) GetSeries(qt *querytracer.Tracer, ec *EvalConfig,
 		at = ec.AuthTokens[0]
 	}
 
-	bb.B = marshalRollupResultCacheKeyForSeries(bb.B[:0], at, expr, window, ec.Step, ec.CacheTagFilters)
-	metainfoBuf := rrc.c.Get(nil, bb.B)
+	bb.B = marshalRollupResultCacheKeyForSeries(bb.B[:0], at, expr, window, ec.Step, ec.CacheTagFilters, ec.ReplicaLabels)
+	metainfoBuf := rrc.get(qt, nil, bb.B, false)
 	if len(metainfoBuf) == 0 {
 		qt.Printf("nothing found")
 		return nil, ec.Start
@@ -261,10 +264,14 @@ func (rrc *rollupResultCache) GetSeries(qt *querytracer.Tracer, ec *EvalConfig,
 	bb.B = key.Marshal(bb.B[:0])
 	tss, ok = rrc.getSeriesFromCache(qt, bb.B)
 	if !ok {
-		mi.RemoveKey(key)
-		metainfoBuf = mi.Marshal(metainfoBuf[:0])
-		bb.B = marshalRollupResultCacheKeyForSeries(bb.B[:0], at, expr, window, ec.Step, ec.CacheTagFilters)
-		rrc.c.Set(bb.B, metainfoBuf)
+		u := rollupResultCacheMetainfoUpdate{
+			isRemove: true,
+			entry: rollupResultCacheMetainfoEntry{
+				key: key,
+			},
+		}
+		bb.B = marshalRollupResultCacheKeyForSeries(bb.B[:0], at, expr, window, ec.Step, ec.CacheTagFilters, ec.ReplicaLabels)
+		rrc.updateMetainfo(qt, bb.B, &u)
 		return nil, ec.Start
 	}
 
@@ -373,8 +380,8 @@ func (rrc *rollupResultCache) PutSeries(qt *querytracer.Tracer, ec *EvalConfig,
 	if !ec.IsMultiTenant {
 		at = ec.AuthTokens[0]
 	}
-	metainfoKey.B = marshalRollupResultCacheKeyForSeries(metainfoKey.B[:0], at, expr, window, ec.Step, ec.CacheTagFilters)
-	metainfoBuf.B = rrc.c.Get(metainfoBuf.B[:0], metainfoKey.B)
+	metainfoKey.B = marshalRollupResultCacheKeyForSeries(metainfoKey.B[:0], at, expr, window, ec.Step, ec.CacheTagFilters, ec.ReplicaLabels)
+	metainfoBuf.B = rrc.get(qt, metainfoBuf.B[:0], metainfoKey.B, false)
 	var mi rollupResultCacheMetainfo
 	if len(metainfoBuf.B) > 0 {
 		if err := mi.Unmarshal(metainfoBuf.B); err != nil {
@@ -404,9 +411,14 @@ func (rrc *rollupResultCache) PutSeries(qt *querytracer.Tracer, ec *EvalConfig,
 		return
 	}
 
-	mi.AddKey(key, timestamps[0], timestamps[len(timestamps)-1])
-	metainfoBuf.B = mi.Marshal(metainfoBuf.B[:0])
-	rrc.c.Set(metainfoKey.B, metainfoBuf.B)
+	u := rollupResultCacheMetainfoUpdate{
+		entry: rollupResultCacheMetainfoEntry{
+			start: timestamps[0],
+			end:   timestamps[len(timestamps)-1],
+			key:   key,
+		},
+	}
+	rrc.updateMetainfo(qt, metainfoKey.B, &u)
 }
 
 var (
@@ -420,7 +432,7 @@ var (
 
 func (rrc *rollupResultCache) getSeriesFromCache(qt *querytracer.Tracer, key []byte) ([]*timeseries, bool) {
 	compressedResultBuf := resultBufPool.Get()
-	compressedResultBuf.B = rrc.c.GetBig(compressedResultBuf.B[:0], key)
+	compressedResultBuf.B = rrc.get(qt, compressedResultBuf.B[:0], key, true)
 	if len(compressedResultBuf.B) == 0 {
 		qt.Printf("nothing found in the cache")
 		resultBufPool.Put(compressedResultBuf)
@@ -459,11 +471,199 @@ func (rrc *rollupResultCache) putSeriesToCache(qt *querytracer.Tracer, key []byt
 	compressedResultBuf.B = encoding.CompressZSTDLevel(compressedResultBuf.B[:0], resultBuf.B, 1)
 	qt.Printf("compress %d bytes into %d bytes", len(resultBuf.B), len(compressedResultBuf.B))
 
-	rrc.c.SetBig(key, compressedResultBuf.B)
+	rrc.set(qt, key, compressedResultBuf.B, true)
 	qt.Printf("store %d bytes in the cache", len(compressedResultBuf.B))
 	return true
 }
 
+// get appends the value for the given key to dst and returns the result.
+//
+// The value is obtained from the owner of the key if the cache is shared among -search.rollupResultCachePeers.
+func (rrc *rollupResultCache) get(qt *querytracer.Tracer, dst, key []byte, isBig bool) []byte {
+	if !netstorage.IsRollupResultCacheShared() {
+		return rrc.getLocal(dst, key, isBig)
+	}
+	p := netstorage.GetRollupResultCachePeer(key)
+	if p == nil {
+		return rrc.getOwned(dst, key, isBig)
+	}
+	dstLen := len(dst)
+	dst, err := p.Get(qt, dst, key, isBig)
+	if err != nil {
+		// Treat peer errors as cache misses, since the query can be executed without the cache.
+		rollupResultCachePeerLogger.Warnf("cannot obtain rollup result cache entry from peer %s: %s", p.Addr(), err)
+		return dst[:dstLen]
+	}
+	return dst
+}
+
+// set stores the given (key, value) in the cache.
+//
+// The entry is sent to the owner of the key if the cache is shared among -search.rollupResultCachePeers.
+func (rrc *rollupResultCache) set(qt *querytracer.Tracer, key, value []byte, isBig bool) {
+	if !netstorage.IsRollupResultCacheShared() {
+		rrc.setLocal(key, value, isBig)
+		return
+	}
+	p := netstorage.GetRollupResultCachePeer(key)
+	if p == nil {
+		rrc.setOwned(key, value, isBig)
+		return
+	}
+	if err := p.Put(qt, key, value, isBig); err != nil {
+		// Do not store the entry in the local cache, since it is never read from there.
+		rollupResultCachePeerLogger.Warnf("cannot store rollup result cache entry at peer %s: %s", p.Addr(), err)
+	}
+}
+
+// updateMetainfo applies u to the metainfo stored under the given key.
+//
+// The update is applied by the owner of the key if the cache is shared among -search.rollupResultCachePeers,
+// so concurrent updates from distinct vmselect nodes aren't lost.
+func (rrc *rollupResultCache) updateMetainfo(qt *querytracer.Tracer, key []byte, u *rollupResultCacheMetainfoUpdate) {
+	if !netstorage.IsRollupResultCacheShared() {
+		rrc.updateMetainfoLocal(key, u)
+		return
+	}
+	p := netstorage.GetRollupResultCachePeer(key)
+	if p == nil {
+		rrc.updateMetainfoOwned(key, u)
+		return
+	}
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	bb.B = u.Marshal(bb.B[:0])
+	if err := p.UpdateMetainfo(qt, key, bb.B); err != nil {
+		rollupResultCachePeerLogger.Warnf("cannot update rollup result cache metainfo at peer %s: %s", p.Addr(), err)
+	}
+}
+
+var rollupResultCachePeerLogger = logger.WithThrottler("rollupResultCachePeer", 5*time.Second)
+
+func (rrc *rollupResultCache) getLocal(dst, key []byte, isBig bool) []byte {
+	if isBig {
+		return rrc.c.GetBig(dst, key)
+	}
+	return rrc.c.Get(dst, key)
+}
+
+func (rrc *rollupResultCache) setLocal(key, value []byte, isBig bool) {
+	if isBig {
+		rrc.c.SetBig(key, value)
+		return
+	}
+	rrc.c.Set(key, value)
+}
+
+// getOwned returns the value for the shared key owned by the current vmselect node.
+func (rrc *rollupResultCache) getOwned(dst, key []byte, isBig bool) []byte {
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	bb.B = appendOwnedRollupResultCacheKey(bb.B[:0], key)
+	if len(bb.B) == 0 {
+		return dst
+	}
+	return rrc.getLocal(dst, bb.B, isBig)
+}
+
+// setOwned stores the value for the shared key owned by the current vmselect node.
+func (rrc *rollupResultCache) setOwned(key, value []byte, isBig bool) {
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	bb.B = appendOwnedRollupResultCacheKey(bb.B[:0], key)
+	if len(bb.B) == 0 {
+		return
+	}
+	rrc.setLocal(bb.B, value, isBig)
+}
+
+// updateMetainfoOwned applies u to the metainfo for the shared key owned by the current vmselect node.
+func (rrc *rollupResultCache) updateMetainfoOwned(key []byte, u *rollupResultCacheMetainfoUpdate) {
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	bb.B = appendOwnedRollupResultCacheKey(bb.B[:0], key)
+	if len(bb.B) == 0 {
+		return
+	}
+	rrc.updateMetainfoLocal(bb.B, u)
+}
+
+// updateMetainfoLocal applies u to the metainfo stored under the given key in the local cache.
+func (rrc *rollupResultCache) updateMetainfoLocal(key []byte, u *rollupResultCacheMetainfoUpdate) {
+	metainfoBuf := bbPool.Get()
+	defer bbPool.Put(metainfoBuf)
+
+	// Serialize read-modify-write for metainfo, so concurrent updates aren't lost.
+	rollupResultCacheMetainfoLock.Lock()
+	defer rollupResultCacheMetainfoLock.Unlock()
+
+	metainfoBuf.B = rrc.c.Get(metainfoBuf.B[:0], key)
+	var mi rollupResultCacheMetainfo
+	if len(metainfoBuf.B) > 0 {
+		if err := mi.Unmarshal(metainfoBuf.B); err != nil {
+			logger.Panicf("BUG: cannot unmarshal rollupResultCacheMetainfo: %s; it looks like it was improperly saved", err)
+		}
+	}
+	mi.ApplyUpdate(u)
+	metainfoBuf.B = mi.Marshal(metainfoBuf.B[:0])
+	rrc.c.Set(key, metainfoBuf.B)
+}
+
+var rollupResultCacheMetainfoLock sync.Mutex
+
+// appendOwnedRollupResultCacheKey appends the local cache key for the shared key to dst and returns the result.
+//
+// Shared keys derived from queries contain zero prefix, since they must be identical among all the vmselect nodes
+// sharing the cache. The local rollupResultCacheKeyPrefix is mixed into the key by its owner,
+// so ResetRollupResultCache at the owner invalidates all the entries it owns.
+//
+// dst is returned unchanged if the key is malformed.
+func appendOwnedRollupResultCacheKey(dst, key []byte) []byte {
+	if len(key) < 9 {
+		return dst
+	}
+	dstLen := len(dst)
+	dst = append(dst, key...)
+	prefix := encoding.UnmarshalUint64(key[1:9]) ^ rollupResultCacheKeyPrefix.Load()
+	binary.BigEndian.PutUint64(dst[dstLen+1:], prefix)
+	return dst
+}
+
+// getRollupResultCacheKeyPrefix returns the prefix for the cache keys derived from queries.
+func getRollupResultCacheKeyPrefix() uint64 {
+	if netstorage.IsRollupResultCacheShared() {
+		// The prefix is applied by the owner of the key. See appendOwnedRollupResultCacheKey.
+		return 0
+	}
+	return rollupResultCacheKeyPrefix.Load()
+}
+
+// GetRollupResultCacheEntry appends the value for the given key owned by the current vmselect node to dst and returns the result.
+//
+// It is called by vmselect nodes sharing the rollup result cache via -search.rollupResultCachePeers.
+func GetRollupResultCacheEntry(dst, key []byte, isBig bool) []byte {
+	return rollupResultCacheV.getOwned(dst, key, isBig)
+}
+
+// SetRollupResultCacheEntry stores the given (key, value) entry owned by the current vmselect node.
+//
+// It is called by vmselect nodes sharing the rollup result cache via -search.rollupResultCachePeers.
+func SetRollupResultCacheEntry(key, value []byte, isBig bool) {
+	rollupResultCacheV.setOwned(key, value, isBig)
+}
+
+// UpdateRollupResultCacheMetainfo applies the given update to the metainfo owned by the current vmselect node.
+//
+// It is called by vmselect nodes sharing the rollup result cache via -search.rollupResultCachePeers.
+func UpdateRollupResultCacheMetainfo(key, update []byte) error {
+	var u rollupResultCacheMetainfoUpdate
+	if err := u.Unmarshal(update); err != nil {
+		return fmt.Errorf("cannot unmarshal rollup result cache metainfo update: %w", err)
+	}
+	rollupResultCacheV.updateMetainfoOwned(key, &u)
+	return nil
+}
+
 func newRollupResultCacheKeyPrefix() uint64 {
 	var buf [8]byte
 	if _, err := rand.Read(buf[:]); err != nil {
@@ -502,16 +702,16 @@ func mustSaveRollupResultCacheKeyPrefix(path string) {
 var tooBigRollupResults = metrics.NewCounter("vm_too_big_rollup_results_total")
 
 // Increment this value every time the format of the cache changes.
-const rollupResultCacheVersion = 11
+const rollupResultCacheVersion = 12
 
 const (
 	rollupResultCacheTypeSeries        = 0
 	rollupResultCacheTypeInstantValues = 1
 )
 
-func marshalRollupResultCacheKeyForSeries(dst []byte, at *auth.Token, expr metricsql.Expr, window, step int64, etfs [][]storage.TagFilter) []byte {
+func marshalRollupResultCacheKeyForSeries(dst []byte, at *auth.Token, expr metricsql.Expr, window, step int64, etfs [][]storage.TagFilter, replicaLabels []string) []byte {
 	dst = append(dst, rollupResultCacheVersion)
-	dst = encoding.MarshalUint64(dst, rollupResultCacheKeyPrefix.Load())
+	dst = encoding.MarshalUint64(dst, getRollupResultCacheKeyPrefix())
 	dst = append(dst, rollupResultCacheTypeSeries)
 	if at != nil {
 		dst = encoding.MarshalUint32(dst, at.AccountID)
@@ -520,13 +720,14 @@ func marshalRollupResultCacheKeyForSeries(dst []byte, at *auth.Token, expr metri
 	dst = encoding.MarshalInt64(dst, window)
 	dst = encoding.MarshalInt64(dst, step)
 	dst = marshalTagFiltersForRollupResultCacheKey(dst, etfs)
+	dst = marshalReplicaLabelsForRollupResultCacheKey(dst, replicaLabels)
 	dst = expr.AppendString(dst)
 	return dst
 }
 
-func marshalRollupResultCacheKeyForInstantValues(dst []byte, at *auth.Token, expr metricsql.Expr, window, step int64, etfs [][]storage.TagFilter) []byte {
+func marshalRollupResultCacheKeyForInstantValues(dst []byte, at *auth.Token, expr metricsql.Expr, window, step int64, etfs [][]storage.TagFilter, replicaLabels []string) []byte {
 	dst = append(dst, rollupResultCacheVersion)
-	dst = encoding.MarshalUint64(dst, rollupResultCacheKeyPrefix.Load())
+	dst = encoding.MarshalUint64(dst, getRollupResultCacheKeyPrefix())
 	dst = append(dst, rollupResultCacheTypeInstantValues)
 	if at != nil {
 		dst = encoding.MarshalUint32(dst, at.AccountID)
@@ -535,6 +736,7 @@ func marshalRollupResultCacheKeyForInstantValues(dst []byte, at *auth.Token, exp
 	dst = encoding.MarshalInt64(dst, window)
 	dst = encoding.MarshalInt64(dst, step)
 	dst = marshalTagFiltersForRollupResultCacheKey(dst, etfs)
+	dst = marshalReplicaLabelsForRollupResultCacheKey(dst, replicaLabels)
 	dst = expr.AppendString(dst)
 	return dst
 }
@@ -551,6 +753,14 @@ func marshalTagFiltersForRollupResultCacheKey(dst []byte, etfs [][]storage.TagFi
 	return dst
 }
 
+func marshalReplicaLabelsForRollupResultCacheKey(dst []byte, replicaLabels []string) []byte {
+	dst = encoding.MarshalVarUint64(dst, uint64(len(replicaLabels)))
+	for _, label := range replicaLabels {
+		dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(label))
+	}
+	return dst
+}
+
 func equalTimestamps(a, b []int64) bool {
 	if len(a) != len(b) {
 		return false
@@ -773,6 +983,51 @@ func (mi *rollupResultCacheMetainfo) RemoveKey(key rollupResultCacheKey) {
 	}
 }
 
+// ApplyUpdate applies u to mi.
+//
+// Updates are idempotent, so applying the same update multiple times gives the same result.
+func (mi *rollupResultCacheMetainfo) ApplyUpdate(u *rollupResultCacheMetainfoUpdate) {
+	if u.isRemove {
+		mi.RemoveKey(u.entry.key)
+		return
+	}
+	for i := range mi.entries {
+		if mi.entries[i].key == u.entry.key {
+			return
+		}
+	}
+	mi.AddKey(u.entry.key, u.entry.start, u.entry.end)
+}
+
+// rollupResultCacheMetainfoUpdate is an update for rollupResultCacheMetainfo.
+type rollupResultCacheMetainfoUpdate struct {
+	// isRemove is set to true if entry.key must be removed from the metainfo.
+	// Otherwise the entry must be added to the metainfo.
+	isRemove bool
+
+	entry rollupResultCacheMetainfoEntry
+}
+
+func (u *rollupResultCacheMetainfoUpdate) Marshal(dst []byte) []byte {
+	dst = encoding.MarshalBool(dst, u.isRemove)
+	return u.entry.Marshal(dst)
+}
+
+func (u *rollupResultCacheMetainfoUpdate) Unmarshal(src []byte) error {
+	if len(src) < 1 {
+		return fmt.Errorf("cannot unmarshal isRemove from %d bytes; need at least %d bytes", len(src), 1)
+	}
+	u.isRemove = encoding.UnmarshalBool(src)
+	tail, err := u.entry.Unmarshal(src[1:])
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal entry: %w", err)
+	}
+	if len(tail) > 0 {
+		return fmt.Errorf("unexpected non-empty tail left after unmarshaling rollupResultCacheMetainfoUpdate; len(tail)=%d", len(tail))
+	}
+	return nil
+}
+
 type rollupResultCacheMetainfoEntry struct {
 	start int64
 	end   int64
@@ -2,6 +2,7 @@ package promql
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/VictoriaMetrics/metricsql"
@@ -379,6 +380,128 @@ func TestRollupResultCache(t *testing.T) {
 	})
 }
 
+func TestRollupResultCacheOwnedEntries(t *testing.T) {
+	InitRollupResultCache("")
+	defer StopRollupResultCache()
+
+	ResetRollupResultCache()
+
+	// Shared keys contain zero prefix.
+	sharedKey := []byte{rollupResultCacheVersion, 0, 0, 0, 0, 0, 0, 0, 0, rollupResultCacheTypeSeries, 'f', 'o', 'o'}
+
+	f := func(isBig bool) {
+		t.Helper()
+
+		value := []byte(fmt.Sprintf("value_isBig=%v", isBig))
+		SetRollupResultCacheEntry(sharedKey, value, isBig)
+		result := GetRollupResultCacheEntry(nil, sharedKey, isBig)
+		if string(result) != string(value) {
+			t.Fatalf("unexpected value for the owned entry; got %q; want %q", result, value)
+		}
+
+		// The entry must be stored under the key with the local prefix.
+		localKey := appendOwnedRollupResultCacheKey(nil, sharedKey)
+		if string(localKey) == string(sharedKey) {
+			t.Fatalf("the local key mustn't match the shared key %q", sharedKey)
+		}
+		result = rollupResultCacheV.getLocal(nil, localKey, isBig)
+		if string(result) != string(value) {
+			t.Fatalf("unexpected value for the local key; got %q; want %q", result, value)
+		}
+
+		// Cache reset must invalidate owned entries.
+		ResetRollupResultCache()
+		result = GetRollupResultCacheEntry(nil, sharedKey, isBig)
+		if len(result) > 0 {
+			t.Fatalf("unexpected non-empty value after cache reset: %q", result)
+		}
+	}
+	f(false)
+	f(true)
+
+	// Malformed keys must be ignored.
+	SetRollupResultCacheEntry([]byte("foo"), []byte("bar"), false)
+	if result := GetRollupResultCacheEntry(nil, []byte("foo"), false); len(result) > 0 {
+		t.Fatalf("unexpected non-empty value for malformed key: %q", result)
+	}
+}
+
+func TestRollupResultCacheMetainfoUpdates(t *testing.T) {
+	InitRollupResultCache("")
+	defer StopRollupResultCache()
+
+	ResetRollupResultCache()
+
+	sharedKey := []byte{rollupResultCacheVersion, 0, 0, 0, 0, 0, 0, 0, 0, rollupResultCacheTypeSeries, 'f', 'o', 'o'}
+	getKeys := func() []rollupResultCacheKey {
+		t.Helper()
+		localKey := appendOwnedRollupResultCacheKey(nil, sharedKey)
+		var mi rollupResultCacheMetainfo
+		if err := mi.Unmarshal(rollupResultCacheV.getLocal(nil, localKey, false)); err != nil {
+			t.Fatalf("cannot unmarshal metainfo: %s", err)
+		}
+		var keys []rollupResultCacheKey
+		for _, e := range mi.entries {
+			keys = append(keys, e.key)
+		}
+		return keys
+	}
+	newUpdate := func(isRemove bool, suffix uint64) []byte {
+		u := rollupResultCacheMetainfoUpdate{
+			isRemove: isRemove,
+			entry: rollupResultCacheMetainfoEntry{
+				start: 1000,
+				end:   2000,
+				key: rollupResultCacheKey{
+					prefix: 1,
+					suffix: suffix,
+				},
+			},
+		}
+		return u.Marshal(nil)
+	}
+
+	// Concurrent updates from distinct peers mustn't be lost.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(suffix uint64) {
+			defer wg.Done()
+			if err := UpdateRollupResultCacheMetainfo(sharedKey, newUpdate(false, suffix)); err != nil {
+				panic(fmt.Errorf("unexpected error: %w", err))
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+	if keys := getKeys(); len(keys) != 5 {
+		t.Fatalf("unexpected number of metainfo entries; got %d; want %d", len(keys), 5)
+	}
+
+	// Updates must be idempotent.
+	for i := 0; i < 3; i++ {
+		if err := UpdateRollupResultCacheMetainfo(sharedKey, newUpdate(false, 0)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := UpdateRollupResultCacheMetainfo(sharedKey, newUpdate(true, 1)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	keys := getKeys()
+	if len(keys) != 4 {
+		t.Fatalf("unexpected number of metainfo entries; got %d; want %d", len(keys), 4)
+	}
+	for _, key := range keys {
+		if key.suffix == 1 {
+			t.Fatalf("the removed key mustn't exist in metainfo")
+		}
+	}
+
+	// Malformed updates must be rejected.
+	if err := UpdateRollupResultCacheMetainfo(sharedKey, []byte("foo")); err == nil {
+		t.Fatalf("expecting non-nil error for malformed update")
+	}
+}
+
 func TestMergeSeries(t *testing.T) {
 	ec := &EvalConfig{
 		Start:              1000,
@@ -716,6 +716,10 @@ func TestRollupNewRollupFuncError(t *testing.T) {
 	f("predict_linear", nil)
 	f("quantile_over_time", nil)
 	f("quantiles_over_time", nil)
+	f("seasonal_naive_over_time", nil)
+	f("seasonal_zscore_over_time", nil)
+	f("seasonal_mad_score_over_time", nil)
+	f("seasonal_decompose_over_time", nil)
 
 	// Invalid arg type
 	scalarTs := []*timeseries{{
@@ -730,6 +734,8 @@ func TestRollupNewRollupFuncError(t *testing.T) {
 	f("predict_linear", []any{me, 123})
 	f("quantile_over_time", []any{123, 123})
 	f("quantiles_over_time", []any{123, 123})
+	f("seasonal_naive_over_time", []any{me, 123})
+	f("seasonal_decompose_over_time", []any{me, scalarTs, 123})
 }
 
 func TestRollupNoWindowNoPoints(t *testing.T) {
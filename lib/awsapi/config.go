@@ -1,6 +1,7 @@
 package awsapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -131,6 +132,34 @@ func (cfg *Config) GetEC2APIResponse(action, filtersQueryString, nextPageToken s
 	return readResponseBody(resp, apiURL)
 }
 
+// GetJSONAPIResponse performs AWS JSON API request for the given target with the given body.
+//
+// The request is sent to the given endpoint. If endpoint is empty, then https://<service>.<region>.amazonaws.com/ is used,
+// where service is the service passed to NewConfig.
+// This is the API protocol used by ECS, Lightsail and other AWS services.
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/Welcome.html
+func (cfg *Config) GetJSONAPIResponse(endpoint, target string, body []byte) ([]byte, error) {
+	ac, err := cfg.getFreshAPICredentials()
+	if err != nil {
+		return nil, err
+	}
+	apiURL := buildAPIEndpoint(endpoint, cfg.region, cfg.service)
+	req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create http request for %q: %w", apiURL, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	if err := signRequestWithTime(req, cfg.service, cfg.region, HashHex(body), ac, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("cannot sign request to %q: %w", apiURL, err)
+	}
+	resp, err := cfg.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot perform http request to %q for %q: %w", apiURL, target, err)
+	}
+	return readResponseBody(resp, apiURL)
+}
+
 // SignRequest signs request for service access and payloadHash.
 func (cfg *Config) SignRequest(req *http.Request, payloadHash string) error {
 	ac, err := cfg.getFreshAPICredentials()
@@ -2,7 +2,11 @@ package awsapi
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -145,6 +149,48 @@ func TestParseARNCredentialsSuccess(t *testing.T) {
 	f(s2, "AssumeRoleWithWebIdentity", credsExpected2)
 }
 
+func TestGetJSONAPIResponse(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("X-Amz-Target") != "Lightsail_20161128.GetInstances" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/x-amz-json-1.1" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=foo/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/lightsail/aws4_request") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer s.Close()
+
+	cfg, err := NewConfig("", "", "eu-west-1", "", "foo", "bar", "lightsail")
+	if err != nil {
+		t.Fatalf("cannot create config: %s", err)
+	}
+	data, err := cfg.GetJSONAPIResponse(s.URL, "Lightsail_20161128.GetInstances", []byte(`{"pageToken":"abc"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(data) != `{"pageToken":"abc"}` {
+		t.Fatalf("unexpected response; got %q; want %q", data, `{"pageToken":"abc"}`)
+	}
+
+	// unexpected target must result in error
+	if _, err := cfg.GetJSONAPIResponse(s.URL, "Lightsail_20161128.GetBuckets", nil); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
+
 func mustParseRFC3339(s string) time.Time {
 	expTime, err := time.Parse(time.RFC3339, s)
 	if err != nil {
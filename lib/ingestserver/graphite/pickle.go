@@ -0,0 +1,111 @@
+package graphite
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	pickleWriteRequests = metrics.NewCounter(`vm_ingestserver_requests_total{type="graphite_pickle", name="write", net="tcp"}`)
+	pickleWriteErrors   = metrics.NewCounter(`vm_ingestserver_request_errors_total{type="graphite_pickle", name="write", net="tcp"}`)
+)
+
+// PickleServer accepts Graphite pickle protocol messages over TCP.
+//
+// See https://graphite.readthedocs.io/en/latest/feeding-carbon.html#the-pickle-protocol
+type PickleServer struct {
+	addr string
+	ln   net.Listener
+	wg   sync.WaitGroup
+	cm   ingestserver.ConnsMap
+}
+
+// MustStartPickle starts graphite pickle server on the given addr.
+//
+// The incoming connections are processed with insertHandler.
+//
+// If useProxyProtocol is set to true, then the incoming connections are accepted via proxy protocol.
+// See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+//
+// MustStop must be called on the returned server when it is no longer needed.
+func MustStartPickle(addr string, useProxyProtocol bool, insertHandler func(r io.Reader) error) *PickleServer {
+	logger.Infof("starting TCP Graphite pickle server at %q", addr)
+	ln, err := netutil.NewTCPListener("graphite_pickle", addr, useProxyProtocol, nil)
+	if err != nil {
+		logger.Fatalf("cannot start TCP Graphite pickle server at %q: %s", addr, err)
+	}
+	logger.Infof("started TCP Graphite pickle server at %q", ln.Addr().String())
+
+	s := &PickleServer{
+		addr: addr,
+		ln:   ln,
+	}
+	s.cm.Init("graphite_pickle")
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.serve(insertHandler)
+		logger.Infof("stopped TCP Graphite pickle server at %q", addr)
+	}()
+	return s
+}
+
+// MustStop stops the server.
+func (s *PickleServer) MustStop() {
+	logger.Infof("stopping TCP Graphite pickle server at %q...", s.addr)
+	if err := s.ln.Close(); err != nil {
+		logger.Errorf("cannot close TCP Graphite pickle server: %s", err)
+	}
+	s.cm.CloseAll(0)
+	s.wg.Wait()
+	logger.Infof("TCP Graphite pickle server at %q has been stopped", s.addr)
+}
+
+func (s *PickleServer) serve(insertHandler func(r io.Reader) error) {
+	var wg sync.WaitGroup
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) {
+				if ne.Temporary() {
+					logger.Errorf("graphite: temporary error when listening for TCP addr %q: %s", s.ln.Addr(), err)
+					time.Sleep(time.Second)
+					continue
+				}
+				if strings.Contains(err.Error(), "use of closed network connection") {
+					break
+				}
+				logger.Fatalf("unrecoverable error when accepting TCP Graphite pickle connections: %s", err)
+			}
+			logger.Fatalf("unexpected error when accepting TCP Graphite pickle connections: %s", err)
+		}
+		if !s.cm.Add(c) {
+			_ = c.Close()
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				s.cm.Delete(c)
+				_ = c.Close()
+				wg.Done()
+			}()
+			pickleWriteRequests.Inc()
+			if err := insertHandler(c); err != nil {
+				pickleWriteErrors.Inc()
+				logger.Errorf("error in TCP Graphite pickle conn %q<->%q: %s", c.LocalAddr(), c.RemoteAddr(), err)
+			}
+		}()
+	}
+	wg.Wait()
+}
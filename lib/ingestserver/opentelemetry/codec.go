@@ -0,0 +1,78 @@
+package opentelemetry
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/stream"
+)
+
+// rawMessage holds protobuf-encoded gRPC message.
+//
+// The message is parsed by the server in order to avoid dependency on generated protobuf code.
+type rawMessage struct {
+	data []byte
+}
+
+// rawCodec passes protobuf-encoded messages as is.
+type rawCodec struct{}
+
+// Marshal implements encoding.Codec interface.
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(*rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("BUG: unexpected message type %T; want *rawMessage", v)
+	}
+	return m.data, nil
+}
+
+// Unmarshal implements encoding.Codec interface.
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("BUG: unexpected message type %T; want *rawMessage", v)
+	}
+	// Copy data, since it is released after returning from Unmarshal.
+	m.data = append(m.data[:0], data...)
+	return nil
+}
+
+// Name implements encoding.Codec interface.
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// gzipDecompressor decompresses gzip-compressed requests, since OpenTelemetry SDKs and collector may compress requests with gzip.
+//
+// It is passed to the server via grpc.RPCDecompressor instead of registering gzip compressor in the global gRPC registry,
+// so other gRPC users in the process aren't affected.
+type gzipDecompressor struct{}
+
+// Do implements grpc.Decompressor interface.
+func (gzipDecompressor) Do(r io.Reader) ([]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	// Limit the size of decompressed data, since gRPC verifies it only after the decompression.
+	maxSize := stream.MaxRequestSize()
+	data, err := io.ReadAll(io.LimitReader(zr, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("too big decompressed request; it mustn't exceed -opentelemetry.maxRequestSize=%d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// Type implements grpc.Decompressor interface.
+func (gzipDecompressor) Type() string {
+	return "gzip"
+}
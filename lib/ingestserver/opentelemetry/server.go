@@ -0,0 +1,208 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/stream"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/writeconcurrencylimiter"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	writeRequests = metrics.NewCounter(`vm_ingestserver_requests_total{type="opentelemetry", name="write", net="grpc"}`)
+	writeErrors   = metrics.NewCounter(`vm_ingestserver_request_errors_total{type="opentelemetry", name="write", net="grpc"}`)
+)
+
+// InsertHandler must insert tss for the given tenant at and with the given extraLabels.
+//
+// at is nil if the tenant isn't set in the request metadata.
+// InsertHandler shouldn't hold tss after returning.
+type InsertHandler func(at *auth.Token, extraLabels []prompbmarshal.Label, tss []prompbmarshal.TimeSeries) error
+
+// Server accepts OpenTelemetry metrics via OTLP/gRPC protocol.
+type Server struct {
+	gs *grpc.Server
+	ln net.Listener
+	wg sync.WaitGroup
+
+	insertHandler InsertHandler
+}
+
+// MustStart starts OTLP/gRPC server on the given addr.
+//
+// If useProxyProtocol is set to true, then the incoming connections are accepted via proxy protocol.
+// See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+//
+// MustStop must be called on the returned server when it is no longer needed.
+func MustStart(addr string, useProxyProtocol bool, insertHandler InsertHandler) *Server {
+	logger.Infof("starting OpenTelemetry gRPC server at %q", addr)
+	lnTCP, err := netutil.NewTCPListener("opentelemetry", addr, useProxyProtocol, nil)
+	if err != nil {
+		logger.Fatalf("cannot start OpenTelemetry gRPC server at %q: %s", addr, err)
+	}
+	return MustServe(lnTCP, insertHandler)
+}
+
+// MustServe serves OTLP/gRPC requests from ln.
+//
+// MustStop must be called on the returned server when it is no longer needed.
+func MustServe(ln net.Listener, insertHandler InsertHandler) *Server {
+	s := &Server{
+		ln:            ln,
+		insertHandler: insertHandler,
+	}
+	s.gs = grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.RPCDecompressor(gzipDecompressor{}),
+		grpc.MaxRecvMsgSize(stream.MaxRequestSize()),
+		// Limit the number of concurrent requests per connection, since every request may occupy up to -opentelemetry.maxRequestSize bytes of memory.
+		grpc.MaxConcurrentStreams(uint32(writeconcurrencylimiter.MaxConcurrentInserts())),
+	)
+	s.gs.RegisterService(&metricsServiceDesc, s)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.gs.Serve(s.ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logger.Fatalf("error serving OpenTelemetry gRPC at %q: %s", s.ln.Addr(), err)
+		}
+	}()
+	return s
+}
+
+// MustStop stops the server.
+func (s *Server) MustStop() {
+	logger.Infof("stopping OpenTelemetry gRPC server at %q...", s.ln.Addr())
+	s.gs.GracefulStop()
+	s.wg.Wait()
+	logger.Infof("OpenTelemetry gRPC server at %q has been stopped", s.ln.Addr())
+}
+
+// export processes ExportMetricsServiceRequest at data.
+//
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/collector/metrics/v1/metrics_service.proto
+func (s *Server) export(ctx context.Context, data []byte) error {
+	writeRequests.Inc()
+	at, extraLabels, err := parseMetadata(ctx)
+	if err != nil {
+		writeErrors.Inc()
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	var insertErr error
+	err = stream.ParseProtobuf(data, func(tss []prompbmarshal.TimeSeries) error {
+		insertErr = s.insertHandler(at, extraLabels, tss)
+		return insertErr
+	})
+	if err != nil {
+		writeErrors.Inc()
+		if insertErr != nil {
+			// The client must retry the request according to OTLP specification.
+			// See https://opentelemetry.io/docs/specs/otlp/#failures
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// parseMetadata returns the tenant and extra labels from the request metadata.
+//
+// The tenant is read from `AccountID` and `ProjectID` metadata keys, while extra labels are read from `extra_label` metadata keys
+// in the same format as `extra_label` query args at HTTP endpoints.
+func parseMetadata(ctx context.Context) (*auth.Token, []prompbmarshal.Label, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil, nil
+	}
+	var at *auth.Token
+	accountIDs := md.Get("accountid")
+	projectIDs := md.Get("projectid")
+	if len(accountIDs) > 0 || len(projectIDs) > 0 {
+		accountID, err := parseTenantID(accountIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse AccountID: %w", err)
+		}
+		projectID, err := parseTenantID(projectIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse ProjectID: %w", err)
+		}
+		at = &auth.Token{
+			AccountID: accountID,
+			ProjectID: projectID,
+		}
+	}
+	var labels []prompbmarshal.Label
+	for _, label := range md.Get("extra_label") {
+		name, value, ok := strings.Cut(label, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("`extra_label` metadata must have the format `name=value`; got %q", label)
+		}
+		labels = append(labels, prompbmarshal.Label{
+			Name:  name,
+			Value: value,
+		})
+	}
+	return at, labels, nil
+}
+
+func parseTenantID(values []string) (uint32, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(n), nil
+}
+
+// metricsService is the interface for OpenTelemetry MetricsService.
+type metricsService interface {
+	export(ctx context.Context, data []byte) error
+}
+
+var metricsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.metrics.v1.MetricsService",
+	HandlerType: (*metricsService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    exportHandler,
+		},
+	},
+	Metadata: "opentelemetry/proto/collector/metrics/v1/metrics_service.proto",
+}
+
+func exportHandler(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+	// Limit the number of concurrently processed requests in the same way as for HTTP-based insert requests.
+	if err := writeconcurrencylimiter.IncConcurrency(); err != nil {
+		writeRequests.Inc()
+		writeErrors.Inc()
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	defer writeconcurrencylimiter.DecConcurrency()
+
+	var req rawMessage
+	if err := dec(&req); err != nil {
+		return nil, err
+	}
+	if err := srv.(metricsService).export(ctx, req.data); err != nil {
+		return nil, err
+	}
+	// Return empty ExportMetricsServiceResponse.
+	return &rawMessage{}, nil
+}
@@ -0,0 +1,127 @@
+package opentelemetry
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/auth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/opentelemetry/pb"
+)
+
+func TestServerExport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot create listener: %s", err)
+	}
+
+	var mu sync.Mutex
+	var insertedAt *auth.Token
+	var insertedExtraLabels []prompbmarshal.Label
+	var insertedNames []string
+	s := MustServe(ln, func(at *auth.Token, extraLabels []prompbmarshal.Label, tss []prompbmarshal.TimeSeries) error {
+		mu.Lock()
+		defer mu.Unlock()
+		insertedAt = at
+		insertedExtraLabels = append(insertedExtraLabels[:0], extraLabels...)
+		for _, ts := range tss {
+			for _, label := range ts.Labels {
+				if label.Name == "__name__" {
+					insertedNames = append(insertedNames, label.Value)
+				}
+			}
+		}
+		return nil
+	})
+	defer s.MustStop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("cannot create gRPC client: %s", err)
+	}
+	defer conn.Close()
+
+	gzipConn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithCompressor(grpc.NewGZIPCompressor()))
+	if err != nil {
+		t.Fatalf("cannot create gRPC client: %s", err)
+	}
+	defer gzipConn.Close()
+
+	f := func(conn *grpc.ClientConn, md metadata.MD, data []byte, codeExpected codes.Code) {
+		t.Helper()
+		ctx := metadata.NewOutgoingContext(context.Background(), md)
+		req := &rawMessage{
+			data: data,
+		}
+		var resp rawMessage
+		err := conn.Invoke(ctx, "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export", req, &resp, grpc.ForceCodec(rawCodec{}))
+		if code := status.Code(err); code != codeExpected {
+			t.Fatalf("unexpected status code; got %s; want %s; err: %v", code, codeExpected, err)
+		}
+	}
+
+	v := float64(42)
+	req := &pb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*pb.ResourceMetrics{
+			{
+				ScopeMetrics: []*pb.ScopeMetrics{
+					{
+						Metrics: []*pb.Metric{
+							{
+								Name: "foo",
+								Gauge: &pb.Gauge{
+									DataPoints: []*pb.NumberDataPoint{
+										{
+											TimeUnixNano: 1e9,
+											DoubleValue:  &v,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	data := req.MarshalProtobuf(nil)
+
+	// Request without metadata
+	f(conn, nil, data, codes.OK)
+	mu.Lock()
+	if insertedAt != nil {
+		t.Fatalf("unexpected tenant: %s", insertedAt)
+	}
+	if len(insertedNames) != 1 || insertedNames[0] != "foo" {
+		t.Fatalf("unexpected inserted metric names: %q", insertedNames)
+	}
+	mu.Unlock()
+
+	// Request with tenant and extra labels compressed with gzip
+	md := metadata.Pairs("AccountID", "12", "ProjectID", "34", "extra_label", "job=bar")
+	f(gzipConn, md, data, codes.OK)
+	mu.Lock()
+	if insertedAt == nil || insertedAt.AccountID != 12 || insertedAt.ProjectID != 34 {
+		t.Fatalf("unexpected tenant: %v", insertedAt)
+	}
+	if len(insertedExtraLabels) != 1 || insertedExtraLabels[0].Name != "job" || insertedExtraLabels[0].Value != "bar" {
+		t.Fatalf("unexpected extra labels: %v", insertedExtraLabels)
+	}
+	mu.Unlock()
+
+	// Invalid metadata
+	f(conn, metadata.Pairs("AccountID", "foo"), data, codes.InvalidArgument)
+	f(conn, metadata.Pairs("extra_label", "foo"), data, codes.InvalidArgument)
+
+	// Invalid request
+	f(conn, nil, []byte("invalid request"), codes.InvalidArgument)
+	f(gzipConn, nil, []byte("invalid request"), codes.InvalidArgument)
+}
@@ -0,0 +1,113 @@
+package persistentqueue
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+)
+
+// metainfoVersion is the version of the format for blocks stored in chunk files.
+//
+// Version 0 is used by queues created by older releases. Blocks in such queues are prepended by 8-byte length.
+// Version 1 adds checksums and optional encryption for blocks. See appendBlockHeader for details.
+const metainfoVersion = 1
+
+const (
+	// blockHeaderSize is the size of the header, which precedes every block in chunk files.
+	blockHeaderSize = 8
+
+	// blockEncryptedFlag is set in the block length stored in the header if the block is encrypted.
+	blockEncryptedFlag = 1 << 31
+)
+
+// ErrCorruptedBlock is returned when the block read from persistent queue is corrupted.
+var ErrCorruptedBlock = errors.New("corrupted block")
+
+// errChecksumMismatch is returned when the block checksum doesn't match the block contents.
+//
+// The block length from the header cannot be trusted in this case, so the next block cannot be located.
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendBlockHeader appends the header for the given block to dst and returns the result.
+//
+// The header consists of 4-byte block length with optional blockEncryptedFlag bit
+// and 4-byte CRC32C checksum for the block length and the block contents.
+// The block length is protected by the checksum, so corrupted headers are detected too.
+// The position of the next block is unknown after the checksum mismatch, since the block length may be corrupted.
+func appendBlockHeader(dst, block []byte, isEncrypted bool) []byte {
+	lenAndFlags := uint32(len(block))
+	if isEncrypted {
+		lenAndFlags |= blockEncryptedFlag
+	}
+	dstLen := len(dst)
+	dst = encoding.MarshalUint32(dst, lenAndFlags)
+	checksum := crc32.Update(crc32.Checksum(dst[dstLen:], crc32cTable), crc32cTable, block)
+	return encoding.MarshalUint32(dst, checksum)
+}
+
+// blockHeader is the header for the block stored in chunk file.
+type blockHeader struct {
+	// size is the size of the stored block in bytes.
+	size uint64
+
+	// isEncrypted is set to true if the block is encrypted.
+	isEncrypted bool
+
+	// isLegacy is set to true for blocks written by older releases.
+	//
+	// Such blocks have no checksums and cannot be encrypted.
+	isLegacy bool
+
+	// lenAndFlags and checksum are the raw values from the header.
+	lenAndFlags uint32
+	checksum    uint32
+}
+
+// unmarshal unmarshals bh from src with blockHeaderSize bytes.
+func (bh *blockHeader) unmarshal(src []byte, isLegacy bool) {
+	if len(src) != blockHeaderSize {
+		panic(fmt.Errorf("BUG: unexpected block header size; got %d bytes; want %d bytes", len(src), blockHeaderSize))
+	}
+	bh.isLegacy = isLegacy
+	if isLegacy {
+		bh.size = encoding.UnmarshalUint64(src)
+		bh.isEncrypted = false
+		bh.lenAndFlags = 0
+		bh.checksum = 0
+		return
+	}
+	bh.lenAndFlags = encoding.UnmarshalUint32(src)
+	bh.checksum = encoding.UnmarshalUint32(src[4:])
+	bh.size = uint64(bh.lenAndFlags &^ blockEncryptedFlag)
+	bh.isEncrypted = bh.lenAndFlags&blockEncryptedFlag != 0
+}
+
+// verifyChecksum returns true if the checksum from bh matches the given block contents.
+func (bh *blockHeader) verifyChecksum(block []byte) bool {
+	if bh.isLegacy {
+		return true
+	}
+	var buf [4]byte
+	lenBuf := encoding.MarshalUint32(buf[:0], bh.lenAndFlags)
+	checksum := crc32.Update(crc32.Checksum(lenBuf, crc32cTable), crc32cTable, block)
+	return checksum == bh.checksum
+}
+
+// appendDecodedBlock verifies the checksum for the block stored with the given bh,
+// decrypts the block if needed, appends the result to dst and returns it.
+//
+// ErrCorruptedBlock is returned if the block is corrupted.
+// errMissingEncryptionKey is returned if the key for decrypting the block isn't set via SetEncryptionKeys.
+func appendDecodedBlock(dst []byte, bh *blockHeader, block []byte) ([]byte, error) {
+	if !bh.verifyChecksum(block) {
+		return dst, fmt.Errorf("%w: %w for block with size %d bytes", ErrCorruptedBlock, errChecksumMismatch, len(block))
+	}
+	if !bh.isEncrypted {
+		return append(dst, block...), nil
+	}
+	return getEncryptionKeys().appendDecrypted(dst, block)
+}
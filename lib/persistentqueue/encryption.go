@@ -0,0 +1,189 @@
+package persistentqueue
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+)
+
+const (
+	encryptionKeyIDSize = 4
+	encryptionNonceSize = 12
+	encryptionTagSize   = 16
+
+	// blockEncryptionOverhead is the number of bytes added to the block by encryption.
+	blockEncryptionOverhead = encryptionKeyIDSize + encryptionNonceSize + encryptionTagSize
+)
+
+// errMissingEncryptionKey is returned when the block is encrypted with the key missing in the keys passed to SetEncryptionKeys.
+var errMissingEncryptionKey = errors.New("missing encryption key")
+
+// EncryptionKeys contains AES keys for encrypting blocks stored in persistent queues.
+//
+// The first key is used for encrypting new blocks, while all the keys are used for decrypting the stored blocks.
+// This allows rotating keys without losing the data encrypted with the previous keys.
+type EncryptionKeys struct {
+	keys []*encryptionKey
+}
+
+type encryptionKey struct {
+	// id is the key identifier, which is stored in every block encrypted with the key.
+	id   uint32
+	aead cipher.AEAD
+}
+
+// ReadEncryptionKeysFile reads encryption keys from the file at the given path.
+//
+// See ParseEncryptionKeys for the file format.
+func ReadEncryptionKeysFile(path string) (*EncryptionKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read encryption keys: %w", err)
+	}
+	ek, err := ParseEncryptionKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse encryption keys from %q: %w", path, err)
+	}
+	return ek, nil
+}
+
+// ParseEncryptionKeys parses encryption keys from data.
+//
+// data must contain a key per line. Every key must be 16, 24 or 32 bytes long for using AES-128, AES-192 or AES-256.
+// Keys must be encoded in hex or base64. Empty lines and lines starting with # are ignored.
+// The first key is used for encrypting new blocks.
+func ParseEncryptionKeys(data []byte) (*EncryptionKeys, error) {
+	var ek EncryptionKeys
+	ids := make(map[uint32]struct{})
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		s := strings.TrimSpace(string(line))
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		key, err := decodeEncryptionKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode key at line %d: %w", i+1, err)
+		}
+		k, err := newEncryptionKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key at line %d: %w", i+1, err)
+		}
+		if _, ok := ids[k.id]; ok {
+			return nil, fmt.Errorf("duplicate key at line %d", i+1)
+		}
+		ids[k.id] = struct{}{}
+		ek.keys = append(ek.keys, k)
+	}
+	if len(ek.keys) == 0 {
+		return nil, fmt.Errorf("missing encryption keys")
+	}
+	return &ek, nil
+}
+
+func decodeEncryptionKey(s string) ([]byte, error) {
+	if key, err := hex.DecodeString(s); err == nil {
+		return key, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("the key must be encoded in hex or base64")
+	}
+	return key, nil
+}
+
+func newEncryptionKey(key []byte) (*encryptionKey, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("unexpected key size: %d bytes; supported sizes: 16, 24 or 32 bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(key)
+	k := &encryptionKey{
+		id:   encoding.UnmarshalUint32(h[:]),
+		aead: aead,
+	}
+	return k, nil
+}
+
+// appendEncrypted appends block encrypted with the first key from ek to dst and returns the result.
+//
+// The result contains the key id, the random nonce and the encrypted block with GCM tag.
+func (ek *EncryptionKeys) appendEncrypted(dst, block []byte) []byte {
+	k := ek.keys[0]
+	dst = encoding.MarshalUint32(dst, k.id)
+	dstLen := len(dst)
+	dst = append(dst, make([]byte, encryptionNonceSize)...)
+	nonce := dst[dstLen:]
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Errorf("FATAL: cannot generate nonce for block encryption: %w", err))
+	}
+	return k.aead.Seal(dst, nonce, block, nil)
+}
+
+// appendDecrypted appends the decrypted block to dst and returns the result.
+//
+// ek may be nil. In this case errMissingEncryptionKey is returned.
+func (ek *EncryptionKeys) appendDecrypted(dst, block []byte) ([]byte, error) {
+	if len(block) < blockEncryptionOverhead {
+		return dst, fmt.Errorf("%w: too short encrypted block: %d bytes; it must contain at least %d bytes", ErrCorruptedBlock, len(block), blockEncryptionOverhead)
+	}
+	id := encoding.UnmarshalUint32(block)
+	k := ek.getKey(id)
+	if k == nil {
+		return dst, fmt.Errorf("%w: the block is encrypted with the key %08X", errMissingEncryptionKey, id)
+	}
+	nonce := block[encryptionKeyIDSize : encryptionKeyIDSize+encryptionNonceSize]
+	ciphertext := block[encryptionKeyIDSize+encryptionNonceSize:]
+	result, err := k.aead.Open(dst, nonce, ciphertext, nil)
+	if err != nil {
+		return dst, fmt.Errorf("%w: cannot decrypt block with the key %08X: %w", ErrCorruptedBlock, id, err)
+	}
+	return result, nil
+}
+
+func (ek *EncryptionKeys) getKey(id uint32) *encryptionKey {
+	if ek == nil {
+		return nil
+	}
+	for _, k := range ek.keys {
+		if k.id == id {
+			return k
+		}
+	}
+	return nil
+}
+
+var encryptionKeys atomic.Pointer[EncryptionKeys]
+
+// SetEncryptionKeys sets keys for encrypting and decrypting blocks in persistent queues.
+//
+// New blocks are written unencrypted if ek is nil.
+// Reading blocks encrypted with keys missing in ek results in error.
+//
+// The keys may be changed at any time. This allows rotating keys without restart.
+func SetEncryptionKeys(ek *EncryptionKeys) {
+	encryptionKeys.Store(ek)
+}
+
+func getEncryptionKeys() *EncryptionKeys {
+	return encryptionKeys.Load()
+}
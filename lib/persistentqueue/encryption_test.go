@@ -0,0 +1,220 @@
+package persistentqueue
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEncryptionKeysFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		if _, err := ParseEncryptionKeys([]byte(data)); err == nil {
+			t.Fatalf("expecting non-nil error for %q", data)
+		}
+	}
+	// missing keys
+	f("")
+	f("# comment\n\n")
+
+	// invalid encoding
+	f("foobar!")
+
+	// invalid key size
+	f("00112233")
+	f("MDAxMTIyMzM=")
+
+	// duplicate keys
+	f("00112233445566778899aabbccddeeff\n00112233445566778899aabbccddeeff")
+}
+
+func TestParseEncryptionKeysSuccess(t *testing.T) {
+	f := func(data string, keysExpected int) {
+		t.Helper()
+		ek, err := ParseEncryptionKeys([]byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(ek.keys) != keysExpected {
+			t.Fatalf("unexpected number of keys; got %d; want %d", len(ek.keys), keysExpected)
+		}
+	}
+	// AES-128 in hex
+	f("00112233445566778899aabbccddeeff", 1)
+
+	// AES-256 in base64 with comments and empty lines
+	f(`
+# the current key
+MDAxMTIyMzM0NDU1NjY3Nzg4OTlhYWJiY2NkZGVlZmY=
+
+# the previous key
+  00112233445566778899aabbccddeeff0011223344556677
+`, 2)
+}
+
+func TestEncryptionKeysRoundtrip(t *testing.T) {
+	ek1 := mustParseEncryptionKeys("00112233445566778899aabbccddeeff")
+	ek2 := mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100\n00112233445566778899aabbccddeeff")
+
+	block := []byte("foobar")
+	encrypted := ek1.appendEncrypted(nil, block)
+	if len(encrypted) != len(block)+blockEncryptionOverhead {
+		t.Fatalf("unexpected encrypted block size; got %d bytes; want %d bytes", len(encrypted), len(block)+blockEncryptionOverhead)
+	}
+	if bytes.Contains(encrypted, block) {
+		t.Fatalf("the encrypted block mustn't contain the original block")
+	}
+
+	// The block must be decrypted with the rotated keys.
+	decrypted, err := ek2.appendDecrypted([]byte("prefix"), encrypted)
+	if err != nil {
+		t.Fatalf("cannot decrypt block: %s", err)
+	}
+	if string(decrypted) != "prefixfoobar" {
+		t.Fatalf("unexpected decrypted block; got %q; want %q", decrypted, "prefixfoobar")
+	}
+
+	// The block cannot be decrypted without the key.
+	ek3 := mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100")
+	if _, err := ek3.appendDecrypted(nil, encrypted); !errors.Is(err, errMissingEncryptionKey) {
+		t.Fatalf("unexpected error; got %v; want %v", err, errMissingEncryptionKey)
+	}
+	var ekNil *EncryptionKeys
+	if _, err := ekNil.appendDecrypted(nil, encrypted); !errors.Is(err, errMissingEncryptionKey) {
+		t.Fatalf("unexpected error; got %v; want %v", err, errMissingEncryptionKey)
+	}
+
+	// Modified block must be detected.
+	encrypted[len(encrypted)-1] ^= 0xff
+	if _, err := ek2.appendDecrypted(nil, encrypted); !errors.Is(err, ErrCorruptedBlock) {
+		t.Fatalf("unexpected error; got %v; want %v", err, ErrCorruptedBlock)
+	}
+	if _, err := ek2.appendDecrypted(nil, encrypted[:blockEncryptionOverhead-1]); !errors.Is(err, ErrCorruptedBlock) {
+		t.Fatalf("unexpected error; got %v; want %v", err, ErrCorruptedBlock)
+	}
+}
+
+func TestQueueEncryption(t *testing.T) {
+	path := "queue-encryption"
+	mustDeleteDir(path)
+	defer mustDeleteDir(path)
+	defer SetEncryptionKeys(nil)
+
+	q := mustOpen(path, "foobar", 0)
+	q.MustWriteBlock([]byte("plain_block"))
+	SetEncryptionKeys(mustParseEncryptionKeys("00112233445566778899aabbccddeeff"))
+	q.MustWriteBlock([]byte("secret_block_0"))
+
+	// Rotate the key.
+	SetEncryptionKeys(mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100\n00112233445566778899aabbccddeeff"))
+	q.MustWriteBlock([]byte("secret_block_1"))
+	q.MustClose()
+
+	data, err := os.ReadFile(filepath.Join(path, fmt.Sprintf("%016X", 0)))
+	if err != nil {
+		t.Fatalf("cannot read chunk file: %s", err)
+	}
+	if !bytes.Contains(data, []byte("plain_block")) {
+		t.Fatalf("the chunk file must contain unencrypted block")
+	}
+	if bytes.Contains(data, []byte("secret_block")) {
+		t.Fatalf("the chunk file mustn't contain encrypted blocks in plaintext")
+	}
+
+	// Blocks encrypted with the removed key cannot be read.
+	SetEncryptionKeys(mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100"))
+	r, err := OpenBlockReader(path)
+	if err != nil {
+		t.Fatalf("cannot open block reader: %s", err)
+	}
+	block, err := r.ReadBlock(nil)
+	if err != nil || string(block) != "plain_block" {
+		t.Fatalf("unexpected block; got %q, err=%v; want %q", block, err, "plain_block")
+	}
+	if _, err := r.ReadBlock(nil); !errors.Is(err, errMissingEncryptionKey) {
+		t.Fatalf("unexpected error; got %v; want %v", err, errMissingEncryptionKey)
+	}
+	r.MustClose()
+
+	// All the blocks must be read with the rotated keys.
+	SetEncryptionKeys(mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100\n00112233445566778899aabbccddeeff"))
+	q = mustOpen(path, "foobar", 0)
+	defer q.MustClose()
+	for _, blockExpected := range []string{"plain_block", "secret_block_0", "secret_block_1"} {
+		block, ok := q.MustReadBlockNonblocking(nil)
+		if !ok {
+			t.Fatalf("unexpected ok=false")
+		}
+		if string(block) != blockExpected {
+			t.Fatalf("unexpected block read; got %q; want %q", block, blockExpected)
+		}
+	}
+}
+
+func TestQueueEncryptionRemovedKey(t *testing.T) {
+	path := "queue-encryption-removed-key"
+	mustDeleteDir(path)
+	defer mustDeleteDir(path)
+	defer SetEncryptionKeys(nil)
+
+	q := mustOpen(path, "foobar", 0)
+	q.MustWriteBlock([]byte("plain_block_0"))
+	SetEncryptionKeys(mustParseEncryptionKeys("00112233445566778899aabbccddeeff"))
+	q.MustWriteBlock([]byte("secret_block_0"))
+	q.MustWriteBlock([]byte("secret_block_1"))
+	SetEncryptionKeys(mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100"))
+	q.MustWriteBlock([]byte("secret_block_2"))
+	SetEncryptionKeys(nil)
+	q.MustWriteBlock([]byte("plain_block_1"))
+	q.MustClose()
+
+	// Blocks encrypted with the removed key must be skipped.
+	SetEncryptionKeys(mustParseEncryptionKeys("ffeeddccbbaa99887766554433221100"))
+	q = mustOpen(path, "foobar", 0)
+	for _, blockExpected := range []string{"plain_block_0", "secret_block_2", "plain_block_1"} {
+		block, ok := q.MustReadBlockNonblocking(nil)
+		if !ok {
+			t.Fatalf("unexpected ok=false")
+		}
+		if string(block) != blockExpected {
+			t.Fatalf("unexpected block read; got %q; want %q", block, blockExpected)
+		}
+	}
+	if _, ok := q.MustReadBlockNonblocking(nil); ok {
+		t.Fatalf("unexpected ok=true for empty queue")
+	}
+	if n := q.blocksUndecryptable.Get(); n != 2 {
+		t.Fatalf("unexpected number of undecryptable blocks; got %d; want 2", n)
+	}
+	if n := q.blocksCorrupted.Get(); n != 0 {
+		t.Fatalf("unexpected number of corrupted blocks; got %d; want 0", n)
+	}
+	q.MustClose()
+
+	// Blocks encrypted with the removed key must be dropped when the queue size is limited.
+	SetEncryptionKeys(mustParseEncryptionKeys("00112233445566778899aabbccddeeff"))
+	q = mustOpen(path, "foobar", 0)
+	q.MustWriteBlock([]byte("secret_block_3"))
+	q.MustClose()
+	SetEncryptionKeys(nil)
+	q = mustOpen(path, "foobar", 1)
+	defer q.MustClose()
+	q.MustWriteBlock([]byte("plain_block_2"))
+	if n := q.GetPendingBytes(); n != 0 {
+		t.Fatalf("unexpected non-zero number of pending bytes: %d", n)
+	}
+	if _, ok := q.MustReadBlockNonblocking(nil); ok {
+		t.Fatalf("unexpected ok=true for empty queue")
+	}
+}
+
+func mustParseEncryptionKeys(s string) *EncryptionKeys {
+	ek, err := ParseEncryptionKeys([]byte(s))
+	if err != nil {
+		panic(fmt.Errorf("cannot parse encryption keys: %w", err))
+	}
+	return ek
+}
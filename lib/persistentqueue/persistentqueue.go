@@ -2,6 +2,7 @@ package persistentqueue
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,7 +12,6 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
-	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/filestream"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
@@ -51,6 +51,11 @@ type queue struct {
 	writerLocalOffset   uint64
 	writerFlushedOffset uint64
 
+	// legacyBlocksEndOffset is the end offset for blocks written by older releases without checksums.
+	//
+	// Such blocks are read in the legacy format, while all the blocks starting from this offset have checksums.
+	legacyBlocksEndOffset uint64
+
 	lastMetainfoFlushTime uint64
 
 	blocksDropped *metrics.Counter
@@ -61,6 +66,12 @@ type queue struct {
 
 	blocksRead *metrics.Counter
 	bytesRead  *metrics.Counter
+
+	blocksCorrupted *metrics.Counter
+	bytesCorrupted  *metrics.Counter
+
+	blocksUndecryptable *metrics.Counter
+	bytesUndecryptable  *metrics.Counter
 }
 
 // ResetIfEmpty resets q if it is empty.
@@ -93,6 +104,8 @@ func (q *queue) mustResetFiles() {
 	q.readerOffset = 0
 	q.readerLocalOffset = 0
 
+	q.legacyBlocksEndOffset = 0
+
 	q.writerPath = q.chunkFilePath(q.writerOffset)
 	w := filestream.MustCreate(q.writerPath, false)
 	q.writer = w
@@ -127,7 +140,7 @@ func mustOpen(path, name string, maxPendingBytes int64) *queue {
 }
 
 func mustOpenInternal(path, name string, chunkFileSize, maxBlockSize, maxPendingBytes uint64) *queue {
-	if chunkFileSize < 8 || chunkFileSize-8 < maxBlockSize {
+	if chunkFileSize < blockHeaderSize+blockEncryptionOverhead || chunkFileSize-blockHeaderSize-blockEncryptionOverhead < maxBlockSize {
 		logger.Panicf("BUG: too small chunkFileSize=%d for maxBlockSize=%d; chunkFileSize must fit at least one block", chunkFileSize, maxBlockSize)
 	}
 	if maxBlockSize <= 0 {
@@ -160,6 +173,10 @@ func tryOpeningQueue(path, name string, chunkFileSize, maxBlockSize, maxPendingB
 	q.bytesWritten = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_bytes_written_total{path=%q}`, path))
 	q.blocksRead = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_blocks_read_total{path=%q}`, path))
 	q.bytesRead = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_bytes_read_total{path=%q}`, path))
+	q.blocksCorrupted = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_blocks_corrupted_total{path=%q}`, path))
+	q.bytesCorrupted = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_bytes_corrupted_total{path=%q}`, path))
+	q.blocksUndecryptable = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_blocks_undecryptable_total{path=%q}`, path))
+	q.bytesUndecryptable = metrics.GetOrCreateCounter(fmt.Sprintf(`vm_persistentqueue_bytes_undecryptable_total{path=%q}`, path))
 
 	cleanOnError := func() {
 		if q.reader != nil {
@@ -193,6 +210,7 @@ func tryOpeningQueue(path, name string, chunkFileSize, maxBlockSize, maxPendingB
 		q.flockF = fs.MustCreateFlockFile(path)
 		mi.Reset()
 		mi.Name = q.name
+		mi.Version = metainfoVersion
 		if err := mi.WriteToFile(metainfoPath); err != nil {
 			return nil, fmt.Errorf("cannot create %q: %w", metainfoPath, err)
 		}
@@ -201,6 +219,7 @@ func tryOpeningQueue(path, name string, chunkFileSize, maxBlockSize, maxPendingB
 		filepath := q.chunkFilePath(0)
 		fs.MustWriteAtomic(filepath, nil, false)
 	}
+	q.legacyBlocksEndOffset = mi.getLegacyBlocksEndOffset()
 
 	// Locate reader and writer chunks in the path.
 	des := fs.MustReadDir(path)
@@ -341,6 +360,16 @@ func (q *queue) metainfoPath() string {
 	return filepath.Join(q.dir, metainfoFilename)
 }
 
+// maxStoredBlockSize returns the maximum size of the block stored at the given offset.
+//
+// Blocks with checksums may be encrypted, so they may exceed q.maxBlockSize by blockEncryptionOverhead.
+func (q *queue) maxStoredBlockSize(offset uint64) uint64 {
+	if offset < q.legacyBlocksEndOffset {
+		return q.maxBlockSize
+	}
+	return q.maxBlockSize + blockEncryptionOverhead
+}
+
 // MustWriteBlock writes block to q.
 //
 // The block size cannot exceed MaxBlockSize.
@@ -391,20 +420,29 @@ func (q *queue) writeBlock(block []byte) error {
 	defer func() {
 		writeDurationSeconds.Add(time.Since(startTime).Seconds())
 	}()
-	if q.writerLocalOffset+q.maxBlockSize+8 > q.chunkFileSize {
+	if q.writerLocalOffset+q.maxStoredBlockSize(q.writerOffset)+blockHeaderSize > q.chunkFileSize {
 		if err := q.nextChunkFileForWrite(); err != nil {
 			return fmt.Errorf("cannot create next chunk file: %w", err)
 		}
 	}
 
-	// Write block len.
-	blockLen := uint64(len(block))
+	blockLen := len(block)
+	isEncrypted := false
+	if ek := getEncryptionKeys(); ek != nil {
+		bb := encryptedBlockBufPool.Get()
+		bb.B = ek.appendEncrypted(bb.B[:0], block)
+		defer encryptedBlockBufPool.Put(bb)
+		block = bb.B
+		isEncrypted = true
+	}
+
+	// Write block header.
 	header := headerBufPool.Get()
-	header.B = encoding.MarshalUint64(header.B, blockLen)
+	header.B = appendBlockHeader(header.B[:0], block, isEncrypted)
 	err := q.write(header.B)
 	headerBufPool.Put(header)
 	if err != nil {
-		return fmt.Errorf("cannot write header with size 8 bytes to %q: %w", q.writerPath, err)
+		return fmt.Errorf("cannot write header with size %d bytes to %q: %w", blockHeaderSize, q.writerPath, err)
 	}
 
 	// Write block contents.
@@ -412,10 +450,12 @@ func (q *queue) writeBlock(block []byte) error {
 		return fmt.Errorf("cannot write block contents with size %d bytes to %q: %w", len(block), q.writerPath, err)
 	}
 	q.blocksWritten.Inc()
-	q.bytesWritten.Add(len(block))
+	q.bytesWritten.Add(blockLen)
 	return q.flushWriterMetainfoIfNeeded()
 }
 
+var encryptedBlockBufPool bytesutil.ByteBufferPool
+
 var writeDurationSeconds = metrics.NewFloatCounter(`vm_persistentqueue_write_duration_seconds_total`)
 
 func (q *queue) nextChunkFileForWrite() error {
@@ -464,28 +504,34 @@ func (q *queue) readBlock(dst []byte) ([]byte, error) {
 	defer func() {
 		readDurationSeconds.Add(time.Since(startTime).Seconds())
 	}()
-	if q.readerLocalOffset+q.maxBlockSize+8 > q.chunkFileSize {
+
+again:
+	if q.readerLocalOffset+q.maxStoredBlockSize(q.readerOffset)+blockHeaderSize > q.chunkFileSize {
 		if err := q.nextChunkFileForRead(); err != nil {
 			return dst, fmt.Errorf("cannot open next chunk file: %w", err)
 		}
 	}
+	blockOffset := q.readerOffset
+	maxStoredBlockSize := q.maxStoredBlockSize(blockOffset)
 
-again:
-	// Read block len.
+	// Read block header.
+	var bh blockHeader
 	header := headerBufPool.Get()
-	header.B = bytesutil.ResizeNoCopyMayOverallocate(header.B, 8)
+	header.B = bytesutil.ResizeNoCopyMayOverallocate(header.B, blockHeaderSize)
 	err := q.readFull(header.B)
-	blockLen := encoding.UnmarshalUint64(header.B)
+	if err == nil {
+		bh.unmarshal(header.B, blockOffset < q.legacyBlocksEndOffset)
+	}
 	headerBufPool.Put(header)
 	if err != nil {
-		logger.Errorf("skipping corrupted %q, since header with size 8 bytes cannot be read from it: %s", q.readerPath, err)
+		logger.Errorf("skipping corrupted %q, since header with size %d bytes cannot be read from it: %s", q.readerPath, blockHeaderSize, err)
 		if err := q.skipBrokenChunkFile(); err != nil {
 			return dst, err
 		}
 		goto again
 	}
-	if blockLen > q.maxBlockSize {
-		logger.Errorf("skipping corrupted %q, since too big block size is read from it: %d bytes; cannot exceed %d bytes", q.readerPath, blockLen, q.maxBlockSize)
+	if bh.size > maxStoredBlockSize {
+		logger.Errorf("skipping corrupted %q, since too big block size is read from it: %d bytes; cannot exceed %d bytes", q.readerPath, bh.size, maxStoredBlockSize)
 		if err := q.skipBrokenChunkFile(); err != nil {
 			return dst, err
 		}
@@ -493,17 +539,55 @@ again:
 	}
 
 	// Read block contents.
-	dstLen := len(dst)
-	dst = bytesutil.ResizeWithCopyMayOverallocate(dst, dstLen+int(blockLen))
-	if err := q.readFull(dst[dstLen:]); err != nil {
-		logger.Errorf("skipping corrupted %q, since contents with size %d bytes cannot be read from it: %s", q.readerPath, blockLen, err)
+	bb := blockBufPool.Get()
+	bb.B = bytesutil.ResizeNoCopyMayOverallocate(bb.B, int(bh.size))
+	if err := q.readFull(bb.B); err != nil {
+		blockBufPool.Put(bb)
+		logger.Errorf("skipping corrupted %q, since contents with size %d bytes cannot be read from it: %s", q.readerPath, bh.size, err)
 		if err := q.skipBrokenChunkFile(); err != nil {
-			return dst[:dstLen], err
+			return dst, err
+		}
+		goto again
+	}
+	dstLen := len(dst)
+	dst, err = appendDecodedBlock(dst, &bh, bb.B)
+	blockBufPool.Put(bb)
+	if err != nil {
+		dst = dst[:dstLen]
+		if errors.Is(err, errChecksumMismatch) {
+			// The block size from the header cannot be trusted, so the next block cannot be located.
+			// Skip the rest of the chunk file in order to avoid reading garbage instead of blocks.
+			skippedBytes := min(blockOffset-blockOffset%q.chunkFileSize+q.chunkFileSize, q.writerOffset) - blockOffset
+			logger.Errorf("skipping %d bytes at offset %d till the end of corrupted %q: %s", skippedBytes, blockOffset, q.readerPath, err)
+			q.blocksCorrupted.Inc()
+			q.bytesCorrupted.Add(int(skippedBytes))
+			if err := q.skipBrokenChunkFile(); err != nil {
+				return dst, err
+			}
+			goto again
+		}
+		// Skip the block only, since the next block can be located with the block size protected by the verified checksum.
+		if errors.Is(err, errMissingEncryptionKey) {
+			// The block cannot be decrypted, since its key has been removed from the configured keys or the encryption has been disabled.
+			logger.Errorf("skipping block with size %d bytes at offset %d in %q, since it cannot be decrypted: %s; "+
+				"add the key to the configured encryption keys in order to preserve such blocks", bh.size, blockOffset, q.readerPath, err)
+			q.blocksUndecryptable.Inc()
+			q.bytesUndecryptable.Add(int(bh.size))
+		} else {
+			logger.Errorf("skipping corrupted block with size %d bytes at offset %d in %q: %s", bh.size, blockOffset, q.readerPath, err)
+			q.blocksCorrupted.Inc()
+			q.bytesCorrupted.Add(int(bh.size))
+		}
+		if q.readerOffset >= q.writerOffset {
+			if err := q.flushReaderMetainfoIfNeeded(); err != nil {
+				return dst, err
+			}
+			return dst, errEmptyQueue
 		}
 		goto again
 	}
 	q.blocksRead.Inc()
-	q.bytesRead.Add(int(blockLen))
+	q.bytesRead.Add(len(dst) - dstLen)
 	if err := q.flushReaderMetainfoIfNeeded(); err != nil {
 		return dst, err
 	}
@@ -616,6 +700,10 @@ func (q *queue) flushMetainfo() error {
 		Name:         q.name,
 		ReaderOffset: q.readerOffset,
 		WriterOffset: q.writerOffset,
+		Version:      metainfoVersion,
+	}
+	if q.legacyBlocksEndOffset > q.readerOffset {
+		mi.LegacyBlocksEndOffset = q.legacyBlocksEndOffset
 	}
 	metainfoPath := q.metainfoPath()
 	if err := mi.WriteToFile(metainfoPath); err != nil {
@@ -630,11 +718,28 @@ type metainfo struct {
 	Name         string
 	ReaderOffset uint64
 	WriterOffset uint64
+
+	// Version is the version of the format for blocks stored in chunk files. See metainfoVersion.
+	Version uint64 `json:",omitempty"`
+
+	// LegacyBlocksEndOffset is the end offset for blocks without checksums written by older releases.
+	LegacyBlocksEndOffset uint64 `json:",omitempty"`
 }
 
 func (mi *metainfo) Reset() {
 	mi.ReaderOffset = 0
 	mi.WriterOffset = 0
+	mi.Version = 0
+	mi.LegacyBlocksEndOffset = 0
+}
+
+// getLegacyBlocksEndOffset returns the end offset for blocks without checksums.
+func (mi *metainfo) getLegacyBlocksEndOffset() uint64 {
+	if mi.Version == 0 {
+		// The queue has been created by older release, so all the pending blocks have no checksums.
+		return mi.WriterOffset
+	}
+	return mi.LegacyBlocksEndOffset
 }
 
 func (mi *metainfo) WriteToFile(path string) error {
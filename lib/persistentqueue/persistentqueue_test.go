@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 func TestQueueOpenClose(t *testing.T) {
@@ -370,6 +372,132 @@ func TestQueueLimitedSize(t *testing.T) {
 	}
 }
 
+func TestQueueCorruptedBlock(t *testing.T) {
+	path := "queue-corrupted-block"
+	mustDeleteDir(path)
+	defer mustDeleteDir(path)
+
+	// Use small chunk files in order to verify that reading continues from the next chunk file after the corrupted block.
+	const chunkFileSize = 128
+	const maxBlockSize = 16
+	q := mustOpenInternal(path, "foobar", chunkFileSize, maxBlockSize, 0)
+	var blocks []string
+	for i := 0; i < 10; i++ {
+		block := fmt.Sprintf("block_%02d", i)
+		q.MustWriteBlock([]byte(block))
+		blocks = append(blocks, block)
+	}
+	q.MustClose()
+
+	// Corrupt the size of the second block, so it remains smaller than the max block size.
+	// The rest of the chunk file must be skipped, since the position of the next block is unknown.
+	chunkPath := filepath.Join(path, fmt.Sprintf("%016X", 0))
+	data, err := os.ReadFile(chunkPath)
+	if err != nil {
+		t.Fatalf("cannot read chunk file: %s", err)
+	}
+	blocksPerChunk := len(data) / (blockHeaderSize + len(blocks[0]))
+	if blocksPerChunk < 3 || blocksPerChunk >= len(blocks) {
+		t.Fatalf("unexpected number of blocks per chunk file: %d", blocksPerChunk)
+	}
+	offset := blockHeaderSize + len(blocks[0])
+	data[offset+3] = 3
+	mustCreateFile(chunkPath, string(data))
+
+	q = mustOpenInternal(path, "foobar", chunkFileSize, maxBlockSize, 0)
+	blocksExpected := append([]string{blocks[0]}, blocks[blocksPerChunk:]...)
+	for _, blockExpected := range blocksExpected {
+		block, ok := q.MustReadBlockNonblocking(nil)
+		if !ok {
+			t.Fatalf("unexpected ok=false")
+		}
+		if string(block) != blockExpected {
+			t.Fatalf("unexpected block read; got %q; want %q", block, blockExpected)
+		}
+	}
+	if _, ok := q.MustReadBlockNonblocking(nil); ok {
+		t.Fatalf("unexpected ok=true for empty queue")
+	}
+	if n := q.blocksCorrupted.Get(); n != 1 {
+		t.Fatalf("unexpected number of corrupted blocks; got %d; want 1", n)
+	}
+	q.MustClose()
+	mustDeleteDir(path)
+
+	// The corrupted block at the end of the queue must be skipped.
+	q = mustOpen(path, "foobar", 0)
+	q.MustWriteBlock([]byte("block_0"))
+	q.MustClose()
+	q = mustOpen(path, "foobar", 0)
+	if _, ok := q.MustReadBlockNonblocking(nil); !ok {
+		t.Fatalf("unexpected ok=false")
+	}
+	q.MustWriteBlock([]byte("block_3"))
+	q.MustClose()
+	data, err = os.ReadFile(chunkPath)
+	if err != nil {
+		t.Fatalf("cannot read chunk file: %s", err)
+	}
+	data[len(data)-1] ^= 0xff
+	mustCreateFile(chunkPath, string(data))
+	q = mustOpen(path, "foobar", 0)
+	if _, ok := q.MustReadBlockNonblocking(nil); ok {
+		t.Fatalf("unexpected ok=true for the queue with a single corrupted block")
+	}
+	if n := q.GetPendingBytes(); n != 0 {
+		t.Fatalf("unexpected non-zero number of pending bytes: %d", n)
+	}
+	q.MustClose()
+}
+
+func TestQueueLegacyBlocks(t *testing.T) {
+	path := "queue-legacy-blocks"
+	mustCreateDir(path)
+	defer mustDeleteDir(path)
+
+	// Create the queue in the format used by older releases.
+	legacyBlocks := []string{"legacy_0", "legacy_1"}
+	var data []byte
+	for _, block := range legacyBlocks {
+		data = encoding.MarshalUint64(data, uint64(len(block)))
+		data = append(data, block...)
+	}
+	mustCreateFile(filepath.Join(path, fmt.Sprintf("%016X", 0)), string(data))
+	mi := &metainfo{
+		Name:         "foobar",
+		WriterOffset: uint64(len(data)),
+	}
+	if err := mi.WriteToFile(filepath.Join(path, metainfoFilename)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// New blocks must be readable after the legacy blocks, even after the queue is re-opened.
+	q := mustOpen(path, "foobar", 0)
+	q.MustWriteBlock([]byte("block_0"))
+	q.MustClose()
+	q = mustOpen(path, "foobar", 0)
+	defer q.MustClose()
+	q.MustWriteBlock([]byte("block_1"))
+	for _, blockExpected := range append(legacyBlocks, "block_0", "block_1") {
+		block, ok := q.MustReadBlockNonblocking(nil)
+		if !ok {
+			t.Fatalf("unexpected ok=false")
+		}
+		if string(block) != blockExpected {
+			t.Fatalf("unexpected block read; got %q; want %q", block, blockExpected)
+		}
+	}
+	if err := q.flushMetainfo(); err != nil {
+		t.Fatalf("cannot flush metainfo: %s", err)
+	}
+	if err := mi.ReadFromFile(filepath.Join(path, metainfoFilename)); err != nil {
+		t.Fatalf("cannot read metainfo: %s", err)
+	}
+	if mi.Version != metainfoVersion || mi.LegacyBlocksEndOffset != 0 {
+		t.Fatalf("unexpected metainfo after reading legacy blocks: %#v", mi)
+	}
+}
+
 func mustCreateFile(path, contents string) {
 	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
 		panic(fmt.Errorf("cannot create file %q with %d bytes contents: %w", path, len(contents), err))
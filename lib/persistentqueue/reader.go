@@ -0,0 +1,267 @@
+package persistentqueue
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+// QueueInfo contains information about persistent queue.
+type QueueInfo struct {
+	// Path is the path to the queue directory.
+	Path string
+
+	// Name is the queue name. vmagent uses the sanitized -remoteWrite.url as the queue name.
+	Name string
+
+	// ReaderOffset is the offset of the next block to read from the queue.
+	ReaderOffset uint64
+
+	// WriterOffset is the offset for the next block to write to the queue.
+	WriterOffset uint64
+
+	// ChunkFiles is the number of chunk files in the queue directory.
+	ChunkFiles int
+
+	// DiskSize is the size of chunk files in the queue directory.
+	DiskSize uint64
+}
+
+// PendingBytes returns the number of pending bytes in the queue.
+func (qi *QueueInfo) PendingBytes() uint64 {
+	return qi.WriterOffset - qi.ReaderOffset
+}
+
+// ReadQueueInfo reads information about persistent queue at the given path.
+//
+// The queue isn't modified by the call, so it may be used for queues owned by other processes.
+func ReadQueueInfo(path string) (*QueueInfo, error) {
+	var mi metainfo
+	metainfoPath := filepath.Join(path, metainfoFilename)
+	if err := mi.ReadFromFile(metainfoPath); err != nil {
+		return nil, fmt.Errorf("cannot read persistent queue metainfo: %w", err)
+	}
+	qi := &QueueInfo{
+		Path:         path,
+		Name:         mi.Name,
+		ReaderOffset: mi.ReaderOffset,
+		WriterOffset: mi.WriterOffset,
+	}
+	des, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read persistent queue directory: %w", err)
+	}
+	for _, de := range des {
+		if de.IsDir() || !chunkFileNameRegex.MatchString(de.Name()) {
+			continue
+		}
+		qi.ChunkFiles++
+		qi.DiskSize += fs.MustFileSize(filepath.Join(path, de.Name()))
+	}
+	return qi, nil
+}
+
+// BlockReader reads blocks from persistent queue without removing them from the queue.
+//
+// BlockReader doesn't lock the queue, so the queue must not be modified by other processes while it is read.
+type BlockReader struct {
+	dir           string
+	chunkFileSize uint64
+	maxBlockSize  uint64
+
+	offset                uint64
+	endOffset             uint64
+	legacyBlocksEndOffset uint64
+
+	f          *os.File
+	br         *bufio.Reader
+	fileOffset uint64
+
+	header [blockHeaderSize]byte
+	buf    []byte
+}
+
+// OpenBlockReader opens persistent queue at the given path for reading.
+//
+// MustClose must be called on the returned reader when it is no longer needed.
+func OpenBlockReader(path string) (*BlockReader, error) {
+	return openBlockReaderInternal(path, DefaultChunkFileSize, MaxBlockSize)
+}
+
+func openBlockReaderInternal(path string, chunkFileSize, maxBlockSize uint64) (*BlockReader, error) {
+	var mi metainfo
+	metainfoPath := filepath.Join(path, metainfoFilename)
+	if err := mi.ReadFromFile(metainfoPath); err != nil {
+		return nil, fmt.Errorf("cannot read persistent queue metainfo: %w", err)
+	}
+	r := &BlockReader{
+		dir:           path,
+		chunkFileSize: chunkFileSize,
+		maxBlockSize:  maxBlockSize,
+		offset:        mi.ReaderOffset,
+		endOffset:     mi.WriterOffset,
+
+		legacyBlocksEndOffset: mi.getLegacyBlocksEndOffset(),
+	}
+	return r, nil
+}
+
+// Offset returns the queue offset for the next block to read.
+func (r *BlockReader) Offset() uint64 {
+	return r.offset
+}
+
+// MustClose closes r.
+func (r *BlockReader) MustClose() {
+	r.closeFile()
+}
+
+func (r *BlockReader) closeFile() {
+	if r.f == nil {
+		return
+	}
+	fs.MustClose(r.f)
+	r.f = nil
+	r.br = nil
+}
+
+// ReadBlock appends the next block from the queue to dst and returns the result.
+//
+// io.EOF is returned if there are no more blocks in the queue.
+// Encrypted blocks are decrypted with the keys passed to SetEncryptionKeys.
+// ErrCorruptedBlock is returned if the block is corrupted. The next call to ReadBlock reads the block after the corrupted block.
+// If the block checksum doesn't match, then the next call to ReadBlock reads the first block from the next chunk file,
+// since the position of the next block is unknown.
+func (r *BlockReader) ReadBlock(dst []byte) ([]byte, error) {
+	if r.offset >= r.endOffset {
+		return dst, io.EOF
+	}
+	localOffset := r.offset % r.chunkFileSize
+	maxStoredBlockSize := r.maxStoredBlockSize()
+	if localOffset+maxStoredBlockSize+blockHeaderSize > r.chunkFileSize {
+		// The remaining part of the chunk file cannot contain blocks. Go to the next chunk file.
+		r.offset += r.chunkFileSize - localOffset
+		if r.offset >= r.endOffset {
+			return dst, io.EOF
+		}
+		maxStoredBlockSize = r.maxStoredBlockSize()
+	}
+	if err := r.openChunkFileIfNeeded(); err != nil {
+		return dst, err
+	}
+	chunkPath := r.f.Name()
+
+	if _, err := io.ReadFull(r.br, r.header[:]); err != nil {
+		return dst, fmt.Errorf("cannot read block header at offset %d from %q: %w", r.offset, chunkPath, err)
+	}
+	var bh blockHeader
+	bh.unmarshal(r.header[:], r.offset < r.legacyBlocksEndOffset)
+	if bh.size > maxStoredBlockSize {
+		return dst, fmt.Errorf("too big block size at offset %d in %q: %d bytes; it cannot exceed %d bytes; the chunk file is likely corrupted",
+			r.offset, chunkPath, bh.size, maxStoredBlockSize)
+	}
+	if r.offset+blockHeaderSize+bh.size > r.endOffset {
+		return dst, fmt.Errorf("block with size %d bytes at offset %d in %q exceeds the queue end offset %d", bh.size, r.offset, chunkPath, r.endOffset)
+	}
+	r.buf = bytesutil.ResizeNoCopyMayOverallocate(r.buf, int(bh.size))
+	if _, err := io.ReadFull(r.br, r.buf); err != nil {
+		return dst, fmt.Errorf("cannot read block with size %d bytes at offset %d from %q: %w", bh.size, r.offset, chunkPath, err)
+	}
+	blockOffset := r.offset
+	r.offset += blockHeaderSize + bh.size
+	dst, err := appendDecodedBlock(dst, &bh, r.buf)
+	if err != nil {
+		if errors.Is(err, errChecksumMismatch) {
+			// The block size cannot be trusted. Go to the next chunk file.
+			r.offset = blockOffset - blockOffset%r.chunkFileSize + r.chunkFileSize
+		}
+		return dst, fmt.Errorf("cannot read block at offset %d from %q: %w", blockOffset, chunkPath, err)
+	}
+	return dst, nil
+}
+
+func (r *BlockReader) maxStoredBlockSize() uint64 {
+	if r.offset < r.legacyBlocksEndOffset {
+		return r.maxBlockSize
+	}
+	return r.maxBlockSize + blockEncryptionOverhead
+}
+
+func (r *BlockReader) openChunkFileIfNeeded() error {
+	fileOffset := r.offset - r.offset%r.chunkFileSize
+	if r.f != nil && r.fileOffset == fileOffset {
+		return nil
+	}
+	r.closeFile()
+	chunkPath := filepath.Join(r.dir, fmt.Sprintf("%016X", fileOffset))
+	f, err := os.Open(chunkPath)
+	if err != nil {
+		return fmt.Errorf("cannot open chunk file: %w", err)
+	}
+	if _, err := f.Seek(int64(r.offset-fileOffset), io.SeekStart); err != nil {
+		fs.MustClose(f)
+		return fmt.Errorf("cannot seek to offset %d at %q: %w", r.offset-fileOffset, chunkPath, err)
+	}
+	r.f = f
+	r.br = bufio.NewReaderSize(f, 64*1024)
+	r.fileOffset = fileOffset
+	return nil
+}
+
+// RewriteBlocks passes every pending block in persistent queue at the given path to f
+// and replaces the block with the result returned by f.
+//
+// f must append the new block contents to dst and return the result. The block is dropped if f returns an empty result.
+// f must return the original block if it must be left as is. The original block is left as is if f returns too big block.
+//
+// The queue is locked during the call, so it cannot be used by other processes.
+// The number of dropped blocks is returned.
+func RewriteBlocks(path string, f func(dst, block []byte) []byte) (int, error) {
+	return rewriteBlocksInternal(path, DefaultChunkFileSize, MaxBlockSize, f)
+}
+
+func rewriteBlocksInternal(path string, chunkFileSize, maxBlockSize uint64, f func(dst, block []byte) []byte) (int, error) {
+	// Verify the queue exists before opening it, since tryOpeningQueue re-creates the queue with missing or broken metainfo.
+	qi, err := ReadQueueInfo(path)
+	if err != nil {
+		return 0, err
+	}
+	q, err := tryOpeningQueue(path, qi.Name, chunkFileSize, maxBlockSize, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer q.MustClose()
+
+	// Blocks returned by f are appended to the end of the queue, so only the blocks up to the current end of the queue must be processed.
+	endOffset := q.writerOffset
+	blocksDropped := 0
+	var block, newBlock []byte
+	for q.readerOffset < endOffset {
+		block, err = q.readBlock(block[:0])
+		if err == errEmptyQueue {
+			break
+		}
+		if err != nil {
+			return blocksDropped, err
+		}
+		newBlock = f(newBlock[:0], block)
+		if len(newBlock) == 0 {
+			blocksDropped++
+			continue
+		}
+		if uint64(len(newBlock)) > maxBlockSize {
+			// Leave the original block as is, since the new block cannot be stored in the queue.
+			newBlock = append(newBlock[:0], block...)
+		}
+		if err := q.writeBlock(newBlock); err != nil {
+			return blocksDropped, err
+		}
+	}
+	return blocksDropped, nil
+}
@@ -0,0 +1,177 @@
+package persistentqueue
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBlockReader(t *testing.T) {
+	path := "queue-block-reader"
+	mustDeleteDir(path)
+	defer mustDeleteDir(path)
+
+	// Use small chunk files in order to verify reading blocks across chunk files.
+	const chunkFileSize = 128
+	const maxBlockSize = 16
+	q := mustOpenInternal(path, "foobar", chunkFileSize, maxBlockSize, 0)
+	var blocks []string
+	for i := 0; i < 20; i++ {
+		block := fmt.Sprintf("block-%d", i)
+		q.MustWriteBlock([]byte(block))
+		blocks = append(blocks, block)
+	}
+	// Read a few blocks from the queue, so they mustn't be returned by the reader.
+	for i := 0; i < 5; i++ {
+		if _, ok := q.MustReadBlockNonblocking(nil); !ok {
+			t.Fatalf("cannot read block #%d", i)
+		}
+	}
+	blocks = blocks[5:]
+	q.MustClose()
+
+	qi, err := ReadQueueInfo(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if qi.Name != "foobar" {
+		t.Fatalf("unexpected queue name; got %q; want %q", qi.Name, "foobar")
+	}
+	if qi.PendingBytes() == 0 {
+		t.Fatalf("pending bytes must be non-zero")
+	}
+	if qi.ChunkFiles < 2 {
+		t.Fatalf("unexpected number of chunk files; got %d; want at least 2", qi.ChunkFiles)
+	}
+
+	readAll := func() []string {
+		t.Helper()
+		r, err := openBlockReaderInternal(path, chunkFileSize, maxBlockSize)
+		if err != nil {
+			t.Fatalf("cannot open block reader: %s", err)
+		}
+		defer r.MustClose()
+		var result []string
+		var block []byte
+		for {
+			block, err = r.ReadBlock(block[:0])
+			if err == io.EOF {
+				return result
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			result = append(result, string(block))
+		}
+	}
+	if result := readAll(); !reflect.DeepEqual(result, blocks) {
+		t.Fatalf("unexpected blocks read;\ngot\n%q\nwant\n%q", result, blocks)
+	}
+
+	// The reader mustn't remove blocks from the queue.
+	if result := readAll(); !reflect.DeepEqual(result, blocks) {
+		t.Fatalf("unexpected blocks read on the second pass;\ngot\n%q\nwant\n%q", result, blocks)
+	}
+
+	// Drop blocks ending with odd digits and modify the remaining blocks.
+	blocksDropped, err := rewriteBlocksInternal(path, chunkFileSize, maxBlockSize, func(dst, block []byte) []byte {
+		if (block[len(block)-1]-'0')%2 == 1 {
+			return dst
+		}
+		return append(dst, strings.ToUpper(string(block))...)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error in rewriteBlocks: %s", err)
+	}
+	if blocksDropped != 8 {
+		t.Fatalf("unexpected number of dropped blocks; got %d; want 8", blocksDropped)
+	}
+	var blocksExpected []string
+	for _, block := range blocks {
+		if (block[len(block)-1]-'0')%2 == 0 {
+			blocksExpected = append(blocksExpected, strings.ToUpper(block))
+		}
+	}
+	if result := readAll(); !reflect.DeepEqual(result, blocksExpected) {
+		t.Fatalf("unexpected blocks after rewrite;\ngot\n%q\nwant\n%q", result, blocksExpected)
+	}
+}
+
+func TestBlockReaderChecksumMismatch(t *testing.T) {
+	path := "queue-block-reader-checksum-mismatch"
+	mustDeleteDir(path)
+	defer mustDeleteDir(path)
+
+	const chunkFileSize = 128
+	const maxBlockSize = 16
+	q := mustOpenInternal(path, "foobar", chunkFileSize, maxBlockSize, 0)
+	var blocks []string
+	for i := 0; i < 10; i++ {
+		block := fmt.Sprintf("block_%02d", i)
+		q.MustWriteBlock([]byte(block))
+		blocks = append(blocks, block)
+	}
+	q.MustClose()
+
+	// Corrupt the size of the second block. The reader must continue from the next chunk file.
+	chunkPath := filepath.Join(path, fmt.Sprintf("%016X", 0))
+	data, err := os.ReadFile(chunkPath)
+	if err != nil {
+		t.Fatalf("cannot read chunk file: %s", err)
+	}
+	blocksPerChunk := len(data) / (blockHeaderSize + len(blocks[0]))
+	data[blockHeaderSize+len(blocks[0])+3] = 3
+	mustCreateFile(chunkPath, string(data))
+
+	r, err := openBlockReaderInternal(path, chunkFileSize, maxBlockSize)
+	if err != nil {
+		t.Fatalf("cannot open block reader: %s", err)
+	}
+	defer r.MustClose()
+	var result []string
+	corruptedBlocks := 0
+	var block []byte
+	for {
+		block, err = r.ReadBlock(block[:0])
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, ErrCorruptedBlock) {
+			corruptedBlocks++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result = append(result, string(block))
+	}
+	if corruptedBlocks != 1 {
+		t.Fatalf("unexpected number of corrupted blocks; got %d; want 1", corruptedBlocks)
+	}
+	blocksExpected := append([]string{blocks[0]}, blocks[blocksPerChunk:]...)
+	if !reflect.DeepEqual(result, blocksExpected) {
+		t.Fatalf("unexpected blocks read;\ngot\n%q\nwant\n%q", result, blocksExpected)
+	}
+}
+
+func TestRewriteBlocksMissingQueue(t *testing.T) {
+	path := "queue-rewrite-blocks-missing"
+	mustDeleteDir(path)
+	mustCreateDir(path)
+	defer mustDeleteDir(path)
+
+	_, err := RewriteBlocks(path, func(dst, block []byte) []byte {
+		return append(dst, block...)
+	})
+	if err == nil {
+		t.Fatalf("expecting non-nil error for missing queue")
+	}
+	if _, err := OpenBlockReader(path); err == nil {
+		t.Fatalf("expecting non-nil error for missing queue")
+	}
+}
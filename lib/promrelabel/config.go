@@ -10,6 +10,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/envtemplate"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/regexutil"
 	"gopkg.in/yaml.v2"
 )
@@ -42,6 +43,19 @@ type RelabelConfig struct {
 	//     job: '$1'
 	//     instance: '${2}:8080'
 	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Table, KeyColumns, TargetLabels and Default are used for `action: lookup`. For example:
+	// - action: lookup
+	//   source_labels: [instance]
+	//   table: /path/to/cmdb.csv
+	//   key_columns: [host]
+	//   target_labels: [team, region]
+	//   default:
+	//     team: unknown
+	Table        string            `yaml:"table,omitempty"`
+	KeyColumns   []string          `yaml:"key_columns,flow,omitempty"`
+	TargetLabels []string          `yaml:"target_labels,flow,omitempty"`
+	Default      map[string]string `yaml:"default,omitempty"`
 }
 
 // MultiLineRegex contains a regex, which can be split into multiple lines.
@@ -132,6 +146,21 @@ func (pcs *ParsedConfigs) Len() int {
 	return len(pcs.prcs)
 }
 
+// MustStop releases resources held by pcs such as lookup tables.
+//
+// It must be called exactly once when pcs is no longer needed.
+// pcs may be still used by in-flight calls after MustStop, but lookup tables referred by pcs aren't reloaded anymore.
+func (pcs *ParsedConfigs) MustStop() {
+	if pcs == nil {
+		return
+	}
+	for _, prc := range pcs.prcs {
+		if prc.lookupTable != nil {
+			putLookupTable(prc.lookupTable)
+		}
+	}
+}
+
 // String returns human-readabale representation for pcs.
 func (pcs *ParsedConfigs) String() string {
 	if pcs == nil {
@@ -189,6 +218,11 @@ func ParseRelabelConfigs(rcs []RelabelConfig) (*ParsedConfigs, error) {
 	for i := range rcs {
 		prc, err := parseRelabelConfig(&rcs[i])
 		if err != nil {
+			// Release resources held by the already parsed configs.
+			pcs := &ParsedConfigs{
+				prcs: prcs[:i],
+			}
+			pcs.MustStop()
 			return nil, fmt.Errorf("error when parsing `relabel_config` #%d: %w", i+1, err)
 		}
 		prcs[i] = prc
@@ -260,7 +294,38 @@ func parseRelabelConfig(rc *RelabelConfig) (*parsedRelabelConfig, error) {
 	if rc.Labels != nil {
 		graphiteLabelRules = newGraphiteLabelRules(rc.Labels)
 	}
+	var lt *lookupTable
+	var lookupDefault []prompbmarshal.Label
 	switch action {
+	case "lookup":
+		if rc.Table == "" {
+			return nil, fmt.Errorf("missing `table` for `action=lookup`; see https://docs.victoriametrics.com/victoriametrics/relabeling/#lookup-relabeling")
+		}
+		if len(sourceLabels) == 0 {
+			return nil, fmt.Errorf("missing `source_labels` for `action=lookup`; see https://docs.victoriametrics.com/victoriametrics/relabeling/#lookup-relabeling")
+		}
+		keyColumns := rc.KeyColumns
+		if len(keyColumns) == 0 {
+			keyColumns = sourceLabels
+		}
+		if len(keyColumns) != len(sourceLabels) {
+			return nil, fmt.Errorf("`key_columns` must contain the same number of entries as `source_labels` for `action=lookup`; got %q vs %q", keyColumns, sourceLabels)
+		}
+		if targetLabel != "" {
+			return nil, fmt.Errorf("`target_label` cannot be used with `action=lookup`; use `target_labels` instead")
+		}
+		if rc.Regex != nil {
+			return nil, fmt.Errorf("`regex` cannot be used for `action=lookup`")
+		}
+		if rc.Replacement != nil {
+			return nil, fmt.Errorf("`replacement` cannot be used for `action=lookup`; use `default` instead")
+		}
+		var err error
+		lt, err = getLookupTable(rc.Table, keyColumns, rc.TargetLabels)
+		if err != nil {
+			return nil, err
+		}
+		lookupDefault = newLookupDefault(rc.Default)
 	case "graphite":
 		if graphiteMatchTemplate == nil {
 			return nil, fmt.Errorf("missing `match` for `action=graphite`; see https://docs.victoriametrics.com/victoriametrics/vmagent/#graphite-relabeling")
@@ -395,6 +460,20 @@ func parseRelabelConfig(rc *RelabelConfig) (*parsedRelabelConfig, error) {
 	default:
 		return nil, fmt.Errorf("unknown `action` %q", action)
 	}
+	if action != "lookup" {
+		if rc.Table != "" {
+			return nil, fmt.Errorf("`table` config cannot be applied to `action=%s`; it is applied only to `action=lookup`", action)
+		}
+		if len(rc.KeyColumns) > 0 {
+			return nil, fmt.Errorf("`key_columns` config cannot be applied to `action=%s`; it is applied only to `action=lookup`", action)
+		}
+		if len(rc.TargetLabels) > 0 {
+			return nil, fmt.Errorf("`target_labels` config cannot be applied to `action=%s`; it is applied only to `action=lookup`", action)
+		}
+		if len(rc.Default) > 0 {
+			return nil, fmt.Errorf("`default` config cannot be applied to `action=%s`; it is applied only to `action=lookup`", action)
+		}
+	}
 	if action != "graphite" {
 		if graphiteMatchTemplate != nil {
 			return nil, fmt.Errorf("`match` config cannot be applied to `action=%s`; it is applied only to `action=graphite`", action)
@@ -422,6 +501,9 @@ func parseRelabelConfig(rc *RelabelConfig) (*parsedRelabelConfig, error) {
 		graphiteMatchTemplate: graphiteMatchTemplate,
 		graphiteLabelRules:    graphiteLabelRules,
 
+		lookupTable:   lt,
+		lookupDefault: lookupDefault,
+
 		regex:         promRegex,
 		regexOriginal: regexOriginalCompiled,
 
@@ -614,6 +614,82 @@ func TestParseRelabelConfigsFailure(t *testing.T) {
 			},
 		},
 	})
+
+	// lookup-missing-table
+	f([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"instance"},
+		},
+	})
+
+	// lookup-missing-table-file
+	f([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"instance"},
+			Table:        "testdata/missing.csv",
+		},
+	})
+
+	// lookup-missing-source-labels
+	f([]RelabelConfig{
+		{
+			Action: "lookup",
+			Table:  "testdata/lookup.csv",
+		},
+	})
+
+	// lookup-key-columns-mismatch
+	f([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"instance"},
+			Table:        "testdata/lookup.csv",
+			KeyColumns:   []string{"host", "dc"},
+		},
+	})
+
+	// lookup-duplicate-keys
+	f([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"host"},
+			Table:        "testdata/lookup.csv",
+		},
+	})
+
+	// lookup-target-label
+	f([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"host", "dc"},
+			Table:        "testdata/lookup.csv",
+			TargetLabel:  "team",
+		},
+	})
+
+	// non-lookup-superflouos-table
+	f([]RelabelConfig{
+		{
+			Action:       "uppercase",
+			SourceLabels: []string{"foo"},
+			TargetLabel:  "foo",
+			Table:        "testdata/lookup.csv",
+		},
+	})
+
+	// non-lookup-superflouos-default
+	f([]RelabelConfig{
+		{
+			Action:       "uppercase",
+			SourceLabels: []string{"foo"},
+			TargetLabel:  "foo",
+			Default: map[string]string{
+				"foo": "bar",
+			},
+		},
+	})
 }
 
 func TestIsDefaultRegex(t *testing.T) {
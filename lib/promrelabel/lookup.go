@@ -0,0 +1,324 @@
+package promrelabel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var lookupTableCheckInterval = flag.Duration("relabel.lookupTableCheckInterval", 0, "Interval for checking for changes in lookup table files "+
+	"used by 'action: lookup' relabeling rules. By default the checking is disabled. Send SIGHUP signal in order to force tables check for changes. "+
+	"See https://docs.victoriametrics.com/victoriametrics/relabeling/#lookup-relabeling")
+
+// lookupKeySeparator is used for joining values of multiple source labels and key columns.
+const lookupKeySeparator = "\xff"
+
+// lookupTable is a table used by `action: lookup` relabeling rules.
+//
+// The table is re-read from the file on SIGHUP and every -relabel.lookupTableCheckInterval.
+type lookupTable struct {
+	path         string
+	keyColumns   []string
+	targetLabels []string
+
+	data atomic.Pointer[lookupTableData]
+
+	// refs is the number of parsed relabeling rules, which refer to the table.
+	//
+	// It is protected by lookupTablesLock.
+	refs int
+}
+
+type lookupTableData struct {
+	// raw contains the original file contents. It is used for detecting changes in the file.
+	raw []byte
+
+	// rows maps key columns values joined with lookupKeySeparator to labels from the matching row.
+	rows map[string][]prompbmarshal.Label
+}
+
+// lookup returns labels for the row matching the given key.
+func (lt *lookupTable) lookup(key string) ([]prompbmarshal.Label, bool) {
+	data := lt.data.Load()
+	labels, ok := data.rows[key]
+	return labels, ok
+}
+
+func newLookupDefault(m map[string]string) []prompbmarshal.Label {
+	if len(m) == 0 {
+		return nil
+	}
+	labels := make([]prompbmarshal.Label, 0, len(m))
+	for name, value := range m {
+		labels = append(labels, prompbmarshal.Label{
+			Name:  name,
+			Value: value,
+		})
+	}
+	slices.SortFunc(labels, func(a, b prompbmarshal.Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return labels
+}
+
+func (lt *lookupTable) key() string {
+	return lt.path + lookupKeySeparator + strings.Join(lt.keyColumns, ",") + lookupKeySeparator + strings.Join(lt.targetLabels, ",")
+}
+
+// reload re-reads lt from the file.
+func (lt *lookupTable) reload() error {
+	raw, err := fscore.ReadFileOrHTTP(lt.path)
+	if err != nil {
+		return fmt.Errorf("cannot read lookup table: %w", err)
+	}
+	if data := lt.data.Load(); data != nil && bytes.Equal(data.raw, raw) {
+		// Nothing changed
+		return nil
+	}
+	rows, err := parseLookupTable(raw, lt.path, lt.keyColumns, lt.targetLabels)
+	if err != nil {
+		return fmt.Errorf("cannot parse lookup table %q: %w", lt.path, err)
+	}
+	lt.data.Store(&lookupTableData{
+		raw:  raw,
+		rows: rows,
+	})
+	return nil
+}
+
+// parseLookupTable parses lookup table from data.
+//
+// The table is parsed as JSON array of objects if path ends with .json. Otherwise it is parsed as CSV with the header row.
+func parseLookupTable(data []byte, path string, keyColumns, targetLabels []string) (map[string][]prompbmarshal.Label, error) {
+	var records []map[string]string
+	var err error
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		records, err = parseLookupTableJSON(data)
+	} else {
+		records, err = parseLookupTableCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string][]prompbmarshal.Label, len(records))
+	var key []byte
+	for i, record := range records {
+		key = key[:0]
+		for j, column := range keyColumns {
+			if j > 0 {
+				key = append(key, lookupKeySeparator...)
+			}
+			value, ok := record[column]
+			if !ok {
+				return nil, fmt.Errorf("missing key column %q at row #%d", column, i+1)
+			}
+			key = append(key, value...)
+		}
+		keyStr := bytesutil.InternBytes(key)
+		if _, ok := rows[keyStr]; ok {
+			return nil, fmt.Errorf("duplicate key %q at row #%d", strings.ReplaceAll(keyStr, lookupKeySeparator, ","), i+1)
+		}
+
+		var labels []prompbmarshal.Label
+		for _, name := range getLookupTargetLabels(record, keyColumns, targetLabels) {
+			value := record[name]
+			if value == "" {
+				// Empty cells do not change the existing labels.
+				continue
+			}
+			labels = append(labels, prompbmarshal.Label{
+				Name:  bytesutil.InternString(name),
+				Value: bytesutil.InternString(value),
+			})
+		}
+		rows[keyStr] = labels
+	}
+	return rows, nil
+}
+
+func getLookupTargetLabels(record map[string]string, keyColumns, targetLabels []string) []string {
+	if len(targetLabels) > 0 {
+		return targetLabels
+	}
+	names := make([]string, 0, len(record))
+	for name := range record {
+		if !slices.Contains(keyColumns, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func parseLookupTableCSV(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.ReuseRecord = true
+	r.Comment = '#'
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read header row: %w", err)
+	}
+	header = slices.Clone(header)
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+	}
+
+	var records []map[string]string
+	for {
+		row, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return nil, err
+		}
+		record := make(map[string]string, len(header))
+		for i, name := range header {
+			record[name] = strings.TrimSpace(row[i])
+		}
+		records = append(records, record)
+	}
+}
+
+func parseLookupTableJSON(data []byte) ([]map[string]string, error) {
+	var rows []map[string]any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("cannot parse JSON array of objects: %w", err)
+	}
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		record := make(map[string]string, len(row))
+		for name, v := range row {
+			switch t := v.(type) {
+			case nil:
+				record[name] = ""
+			case string:
+				record[name] = t
+			case float64:
+				record[name] = strconv.FormatFloat(t, 'f', -1, 64)
+			case bool:
+				record[name] = strconv.FormatBool(t)
+			default:
+				return nil, fmt.Errorf("unexpected type for %q at row #%d: %T; want string, number or bool", name, i+1, v)
+			}
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+var (
+	// lookupTables holds tables referred by relabeling rules.
+	// Tables are deleted from lookupTables when they are no longer referenced by relabeling rules - see putLookupTable.
+	lookupTablesLock sync.Mutex
+	lookupTables     = make(map[string]*lookupTable)
+
+	lookupTablesReloaderOnce sync.Once
+
+	lookupTableReloads      = metrics.NewCounter(`vm_relabel_lookup_table_reloads_total`)
+	lookupTableReloadErrors = metrics.NewCounter(`vm_relabel_lookup_table_reload_errors_total`)
+)
+
+// getLookupTable returns lookup table for the given path, keyColumns and targetLabels.
+//
+// Tables are shared among all the relabeling rules, which refer to the same file, and are re-read from files
+// on SIGHUP and every -relabel.lookupTableCheckInterval.
+//
+// The returned table must be passed to putLookupTable when it is no longer needed.
+func getLookupTable(path string, keyColumns, targetLabels []string) (*lookupTable, error) {
+	lt := &lookupTable{
+		path:         path,
+		keyColumns:   keyColumns,
+		targetLabels: targetLabels,
+	}
+	key := lt.key()
+
+	lookupTablesLock.Lock()
+	defer lookupTablesLock.Unlock()
+
+	if ltExisting := lookupTables[key]; ltExisting != nil {
+		// Re-read the table, so config reload picks up table changes.
+		if err := ltExisting.reload(); err != nil {
+			return nil, err
+		}
+		ltExisting.refs++
+		return ltExisting, nil
+	}
+	if err := lt.reload(); err != nil {
+		return nil, err
+	}
+	lt.refs = 1
+	lookupTables[key] = lt
+	lookupTablesReloaderOnce.Do(startLookupTablesReloader)
+	return lt, nil
+}
+
+// putLookupTable releases lt obtained via getLookupTable.
+//
+// The table is deleted from lookupTables when it is no longer referenced by relabeling rules.
+// The table contents remain available to the callers, which still hold lt, but it isn't reloaded anymore.
+func putLookupTable(lt *lookupTable) {
+	lookupTablesLock.Lock()
+	defer lookupTablesLock.Unlock()
+
+	lt.refs--
+	if lt.refs > 0 {
+		return
+	}
+	if lt.refs < 0 {
+		logger.Panicf("BUG: unexpected negative number of references to lookup table %q: %d", lt.path, lt.refs)
+	}
+	delete(lookupTables, lt.key())
+}
+
+func startLookupTablesReloader() {
+	sighupCh := procutil.NewSighupChan()
+	go func() {
+		var tickerCh <-chan time.Time
+		if *lookupTableCheckInterval > 0 {
+			ticker := time.NewTicker(*lookupTableCheckInterval)
+			tickerCh = ticker.C
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-sighupCh:
+			case <-tickerCh:
+			}
+			reloadLookupTables()
+		}
+	}()
+}
+
+func reloadLookupTables() {
+	lookupTablesLock.Lock()
+	defer lookupTablesLock.Unlock()
+
+	for _, lt := range lookupTables {
+		lookupTableReloads.Inc()
+		if err := lt.reload(); err != nil {
+			lookupTableReloadErrors.Inc()
+			logger.Errorf("cannot reload lookup table; preserving the previous contents: %s", err)
+		}
+	}
+}
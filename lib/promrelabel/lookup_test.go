@@ -0,0 +1,156 @@
+package promrelabel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func TestParseLookupTableFailure(t *testing.T) {
+	f := func(data, path string) {
+		t.Helper()
+		rows, err := parseLookupTable([]byte(data), path, []string{"host"}, nil)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if rows != nil {
+			t.Fatalf("expecting nil rows")
+		}
+	}
+
+	// missing key column
+	f("instance,team\nhost1,infra\n", "table.csv")
+
+	// invalid number of fields
+	f("host,team\nhost1,infra,foo\n", "table.csv")
+
+	// duplicate key
+	f("host,team\nhost1,infra\nhost1,search\n", "table.csv")
+
+	// invalid JSON
+	f(`{"host":"host1"}`, "table.json")
+
+	// unsupported JSON value
+	f(`[{"host":"host1","team":["infra"]}]`, "table.json")
+}
+
+func TestLookupTableReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table.csv")
+	mustWriteFile := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("cannot write lookup table: %s", err)
+		}
+	}
+	mustWriteFile("host,team\nhost1,infra\n")
+
+	pcs, err := ParseRelabelConfigs([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"host"},
+			Table:        path,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f := func(resultExpected string) {
+		t.Helper()
+		labels := promutil.MustNewLabelsFromString(`up{host="host1"}`)
+		result := LabelsToString(pcs.Apply(labels.GetLabels(), 0))
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(`up{host="host1",team="infra"}`)
+
+	// The table must be updated after the reload
+	mustWriteFile("host,team\nhost1,search\n")
+	reloadLookupTables()
+	f(`up{host="host1",team="search"}`)
+
+	// The previous table contents must be preserved on invalid table
+	mustWriteFile("host,team\nhost1,search\nhost1,infra\n")
+	reloadLookupTables()
+	f(`up{host="host1",team="search"}`)
+}
+
+func TestLookupTableEviction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table.csv")
+	if err := os.WriteFile(path, []byte("host,team\nhost1,infra\n"), 0644); err != nil {
+		t.Fatalf("cannot write lookup table: %s", err)
+	}
+	getTablesCount := func() int {
+		lookupTablesLock.Lock()
+		defer lookupTablesLock.Unlock()
+
+		n := 0
+		for key := range lookupTables {
+			if strings.HasPrefix(key, path+lookupKeySeparator) {
+				n++
+			}
+		}
+		return n
+	}
+	mustParseConfigs := func(targetLabels []string) *ParsedConfigs {
+		t.Helper()
+		pcs, err := ParseRelabelConfigs([]RelabelConfig{
+			{
+				Action:       "lookup",
+				SourceLabels: []string{"host"},
+				Table:        path,
+				TargetLabels: targetLabels,
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return pcs
+	}
+	f := func(nExpected int) {
+		t.Helper()
+		if n := getTablesCount(); n != nExpected {
+			t.Fatalf("unexpected number of lookup tables; got %d; want %d", n, nExpected)
+		}
+	}
+
+	pcs1 := mustParseConfigs(nil)
+	pcs2 := mustParseConfigs([]string{"team"})
+	f(2)
+
+	// The table must be evicted after the config referring to it is stopped
+	pcs1.MustStop()
+	f(1)
+
+	// The table must be shared among configs referring to it
+	pcs3 := mustParseConfigs([]string{"team"})
+	if pcs3.prcs[0].lookupTable != pcs2.prcs[0].lookupTable {
+		t.Fatalf("expecting the same lookup table for the same config")
+	}
+	f(1)
+
+	// The table must be preserved while it is referenced by at least a single config
+	pcs2.MustStop()
+	f(1)
+	pcs3.MustStop()
+	f(0)
+
+	// The table must be released if the config cannot be parsed
+	_, err := ParseRelabelConfigs([]RelabelConfig{
+		{
+			Action:       "lookup",
+			SourceLabels: []string{"host"},
+			Table:        path,
+		},
+		{
+			Action: "foobar",
+		},
+	})
+	if err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+	f(0)
+}
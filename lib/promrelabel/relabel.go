@@ -33,6 +33,9 @@ type parsedRelabelConfig struct {
 	graphiteMatchTemplate *graphiteMatchTemplate
 	graphiteLabelRules    []graphiteLabelRule
 
+	lookupTable   *lookupTable
+	lookupDefault []prompbmarshal.Label
+
 	regex         *regexutil.PromRegex
 	regexOriginal *regexp.Regexp
 
@@ -191,6 +194,19 @@ func (prc *parsedRelabelConfig) apply(labels []prompbmarshal.Label, labelsOffset
 		relabelBufPool.Put(bb)
 		graphiteMatchesPool.Put(gm)
 		return labels
+	case "lookup":
+		// Set labels from the lookup table row matching `source_labels`
+		bb := relabelBufPool.Get()
+		bb.B = concatLabelValues(bb.B[:0], src, prc.SourceLabels, lookupKeySeparator)
+		rowLabels, ok := prc.lookupTable.lookup(bytesutil.ToUnsafeString(bb.B))
+		relabelBufPool.Put(bb)
+		if !ok {
+			rowLabels = prc.lookupDefault
+		}
+		for _, label := range rowLabels {
+			labels = setLabelValue(labels, labelsOffset, label.Name, label.Value)
+		}
+		return labels
 	case "replace":
 		// Store `replacement` at `target_label` if the `regex` matches `source_labels` joined with `separator`
 		replacement := prc.Replacement
@@ -911,6 +911,56 @@ func TestParsedRelabelConfigsApply(t *testing.T) {
     job: ${1}-zz
 `, `foo.bar.bazz`, true, `foo.bar.bazz`)
 
+	// lookup-match
+	f(`
+- action: lookup
+  source_labels: [host, dc]
+  table: testdata/lookup.csv
+  key_columns: [host, dc]
+`, `up{host="host1",dc="dc2"}`, true, `up{cost_center="cc-300",dc="dc2",host="host1",region="ap-south",team="search"}`)
+
+	// lookup-match-empty-cell
+	f(`
+- action: lookup
+  source_labels: [host, dc]
+  table: testdata/lookup.csv
+  key_columns: [host, dc]
+`, `up{host="host2",dc="dc1",cost_center="unknown"}`, true, `up{cost_center="unknown",dc="dc1",host="host2",region="eu-west",team="payments"}`)
+
+	// lookup-target-labels
+	f(`
+- action: lookup
+  source_labels: [host, dc]
+  table: testdata/lookup.csv
+  key_columns: [host, dc]
+  target_labels: [team]
+`, `up{host="host1",dc="dc1"}`, true, `up{dc="dc1",host="host1",team="infra"}`)
+
+	// lookup-mismatch
+	f(`
+- action: lookup
+  source_labels: [host, dc]
+  table: testdata/lookup.csv
+  key_columns: [host, dc]
+`, `up{host="host3",dc="dc1"}`, true, `up{dc="dc1",host="host3"}`)
+
+	// lookup-mismatch-default
+	f(`
+- action: lookup
+  source_labels: [host, dc]
+  table: testdata/lookup.csv
+  key_columns: [host, dc]
+  default:
+    team: unknown
+`, `up{host="host3",dc="dc1"}`, true, `up{dc="dc1",host="host3",team="unknown"}`)
+
+	// lookup-json
+	f(`
+- action: lookup
+  source_labels: [instance]
+  table: testdata/lookup.json
+`, `up{instance="host1:9100"}`, true, `up{instance="host1:9100",team="infra",tier="1"}`)
+
 	// replacement-with-label-refs
 	// no regex
 	f(`
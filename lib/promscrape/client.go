@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -12,10 +13,14 @@ import (
 
 	"github.com/VictoriaMetrics/metrics"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/chunkedbuffer"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus/openmetrics"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus/protobuf"
 )
 
 var (
@@ -30,6 +35,9 @@ var (
 	streamParse = flag.Bool("promscrape.streamParse", false, "Whether to enable stream parsing for metrics obtained from scrape targets. This may be useful "+
 		"for reducing memory usage when millions of metrics are exposed per each scrape target. "+
 		"It is possible to set 'stream_parse: true' individually per each 'scrape_config' section in '-promscrape.config' for fine-grained control")
+	openMetricsExemplarsAsSeries = flag.Bool("promscrape.openMetricsExemplarsAsSeries", false, "Whether to convert exemplars from scrape responses in OpenMetrics format "+
+		"to series with '_exemplar' suffix in metric names. Exemplars are dropped by default, since VictoriaMetrics cannot store them. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#scrape-protocols")
 )
 
 type client struct {
@@ -41,6 +49,13 @@ type client struct {
 	setProxyHeaders         func(req *http.Request) error
 	maxScrapeSize           int64
 	disableCompression      bool
+
+	// acceptHeader is the value for `Accept` request header.
+	acceptHeader string
+
+	// negotiateProtocol is set to true if `scrape_protocols` are configured for the target.
+	// In this case the response is converted to Prometheus text exposition format according to its Content-Type header.
+	negotiateProtocol bool
 }
 
 func newClient(ctx context.Context, sw *ScrapeWork) (*client, error) {
@@ -111,6 +126,8 @@ func newClient(ctx context.Context, sw *ScrapeWork) (*client, error) {
 		setProxyHeaders:         setProxyHeaders,
 		maxScrapeSize:           sw.MaxScrapeSize,
 		disableCompression:      *disableCompression || sw.DisableCompression,
+		acceptHeader:            getAcceptHeader(sw.ScrapeProtocols),
+		negotiateProtocol:       len(sw.ScrapeProtocols) > 0,
 	}
 	return c, nil
 }
@@ -124,12 +141,7 @@ func (c *client) ReadData(dst *chunkedbuffer.Buffer) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("cannot create request for %q: %w", c.scrapeURL, err)
 	}
-	// The following `Accept` header has been copied from Prometheus sources.
-	// See https://github.com/prometheus/prometheus/blob/f9d21f10ecd2a343a381044f131ea4e46381ce09/scrape/scrape.go#L532 .
-	// This is needed as a workaround for scraping stupid Java-based servers such as Spring Boot.
-	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/608 for details.
-	// Do not bloat the `Accept` header with OpenMetrics shit, since it looks like dead standard now.
-	req.Header.Set("Accept", "text/plain;version=0.0.4;q=1,*/*;q=0.1")
+	req.Header.Set("Accept", c.acceptHeader)
 	// Set X-Prometheus-Scrape-Timeout-Seconds like Prometheus does, since it is used by some exporters such as PushProx.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/1179#issuecomment-813117162
 	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", c.scrapeTimeoutSecondsStr)
@@ -182,12 +194,133 @@ func (c *client) ReadData(dst *chunkedbuffer.Buffer) (bool, error) {
 	}
 
 	isGzipped := resp.Header.Get("Content-Encoding") == "gzip"
+	if c.negotiateProtocol {
+		format := getScrapeFormat(resp.Header.Get("Content-Type"))
+		if format != scrapeFormatPrometheusText {
+			if err := convertScrapeResponse(dst, isGzipped, format); err != nil {
+				scrapeResponseConversionErrors.Inc()
+				return false, fmt.Errorf("cannot convert response from %q to Prometheus text exposition format: %w", c.scrapeURL, err)
+			}
+			return false, nil
+		}
+	}
 	return isGzipped, nil
 }
 
+// defaultAcceptHeader is used when `scrape_protocols` isn't set.
+//
+// It has been copied from Prometheus sources.
+// See https://github.com/prometheus/prometheus/blob/f9d21f10ecd2a343a381044f131ea4e46381ce09/scrape/scrape.go#L532 .
+// This is needed as a workaround for scraping stupid Java-based servers such as Spring Boot.
+// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/608 for details.
+const defaultAcceptHeader = "text/plain;version=0.0.4;q=1,*/*;q=0.1"
+
+// scrapeProtocolHeaders contains `Accept` header values for the supported `scrape_protocols`.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#scrape_config
+var scrapeProtocolHeaders = map[string]string{
+	"PrometheusProto":      protobuf.ContentType + ";proto=io.prometheus.client.MetricFamily;encoding=delimited",
+	"PrometheusText0.0.4":  "text/plain;version=0.0.4",
+	"PrometheusText1.0.0":  "text/plain;version=1.0.0",
+	"OpenMetricsText0.0.1": openmetrics.ContentType + ";version=0.0.1",
+	"OpenMetricsText1.0.0": openmetrics.ContentType + ";version=1.0.0",
+}
+
+// getAcceptHeader returns `Accept` header value for the given scrapeProtocols.
+//
+// Protocols are preferred in the order they are listed in the same way as Prometheus does.
+func getAcceptHeader(scrapeProtocols []string) string {
+	if len(scrapeProtocols) == 0 {
+		return defaultAcceptHeader
+	}
+	a := make([]string, 0, len(scrapeProtocols)+1)
+	weight := len(scrapeProtocolHeaders) + 1
+	for _, sp := range scrapeProtocols {
+		a = append(a, fmt.Sprintf("%s;q=0.%d", scrapeProtocolHeaders[sp], weight))
+		weight--
+	}
+	a = append(a, fmt.Sprintf("*/*;q=0.%d", weight))
+	return strings.Join(a, ",")
+}
+
+func checkScrapeProtocols(scrapeProtocols []string) error {
+	seen := make(map[string]bool, len(scrapeProtocols))
+	for _, sp := range scrapeProtocols {
+		if _, ok := scrapeProtocolHeaders[sp]; !ok {
+			return fmt.Errorf("unsupported scrape protocol %q; supported protocols: PrometheusProto, PrometheusText0.0.4, PrometheusText1.0.0, "+
+				"OpenMetricsText0.0.1, OpenMetricsText1.0.0", sp)
+		}
+		if seen[sp] {
+			return fmt.Errorf("duplicate scrape protocol %q", sp)
+		}
+		seen[sp] = true
+	}
+	return nil
+}
+
+type scrapeFormat int
+
+const (
+	scrapeFormatPrometheusText = scrapeFormat(iota)
+	scrapeFormatOpenMetrics
+	scrapeFormatProtobuf
+)
+
+// getScrapeFormat returns the format of scrape response with the given contentType.
+//
+// Prometheus text exposition format is returned for unknown or missing contentType.
+func getScrapeFormat(contentType string) scrapeFormat {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return scrapeFormatPrometheusText
+	}
+	switch mediaType {
+	case openmetrics.ContentType:
+		return scrapeFormatOpenMetrics
+	case protobuf.ContentType:
+		if params["proto"] == "io.prometheus.client.MetricFamily" && params["encoding"] == "delimited" {
+			return scrapeFormatProtobuf
+		}
+		return scrapeFormatPrometheusText
+	default:
+		return scrapeFormatPrometheusText
+	}
+}
+
+// convertScrapeResponse converts the response in the given format at cb to Prometheus text exposition format.
+func convertScrapeResponse(cb *chunkedbuffer.Buffer, isGzipped bool, format scrapeFormat) error {
+	bb := convertBufPool.Get()
+	defer convertBufPool.Put(bb)
+	if err := readFromBuffer(bb, cb, isGzipped); err != nil {
+		return err
+	}
+
+	result := convertBufPool.Get()
+	defer convertBufPool.Put(result)
+	switch format {
+	case scrapeFormatOpenMetrics:
+		result.B = openmetrics.AppendText(result.B[:0], bytesutil.ToUnsafeString(bb.B), *openMetricsExemplarsAsSeries)
+	case scrapeFormatProtobuf:
+		var err error
+		result.B, err = protobuf.AppendText(result.B[:0], bb.B)
+		if err != nil {
+			return err
+		}
+	default:
+		logger.Panicf("BUG: unexpected scrape format: %d", format)
+	}
+	cb.Reset()
+	cb.MustWrite(result.B)
+	return nil
+}
+
+var convertBufPool bytesutil.ByteBufferPool
+
 var (
 	maxScrapeSizeExceeded = metrics.NewCounter(`vm_promscrape_max_scrape_size_exceeded_errors_total`)
 	scrapesTimedout       = metrics.NewCounter(`vm_promscrape_scrapes_timed_out_total`)
 	scrapesOK             = metrics.NewCounter(`vm_promscrape_scrapes_total{status_code="200"}`)
 	scrapeRequests        = metrics.NewCounter(`vm_promscrape_scrape_requests_total`)
+
+	scrapeResponseConversionErrors = metrics.NewCounter(`vm_promscrape_scrape_response_conversion_errors_total`)
 )
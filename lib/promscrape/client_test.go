@@ -207,3 +207,90 @@ func TestClientProxyReadOk(t *testing.T) {
 	// backend tls and proxy auth
 	f(true, false, nil, &promauth.BasicAuthConfig{Username: "proxy-test", Password: promauth.NewSecret("1234")})
 }
+
+func TestGetAcceptHeader(t *testing.T) {
+	f := func(scrapeProtocols []string, resultExpected string) {
+		t.Helper()
+		result := getAcceptHeader(scrapeProtocols)
+		if result != resultExpected {
+			t.Fatalf("unexpected Accept header;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(nil, defaultAcceptHeader)
+	f([]string{"PrometheusText0.0.4"}, "text/plain;version=0.0.4;q=0.6,*/*;q=0.5")
+	f([]string{"PrometheusProto", "OpenMetricsText1.0.0", "PrometheusText0.0.4"},
+		"application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.6,"+
+			"application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.4,*/*;q=0.3")
+}
+
+func TestGetScrapeFormat(t *testing.T) {
+	f := func(contentType string, formatExpected scrapeFormat) {
+		t.Helper()
+		format := getScrapeFormat(contentType)
+		if format != formatExpected {
+			t.Fatalf("unexpected format for Content-Type %q; got %d; want %d", contentType, format, formatExpected)
+		}
+	}
+	f("", scrapeFormatPrometheusText)
+	f("foobar", scrapeFormatPrometheusText)
+	f("text/plain; version=0.0.4; charset=utf-8", scrapeFormatPrometheusText)
+	f("application/openmetrics-text; version=1.0.0; charset=utf-8", scrapeFormatOpenMetrics)
+	f("application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited", scrapeFormatProtobuf)
+	f("application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text", scrapeFormatPrometheusText)
+}
+
+func TestClientReadDataScrapeProtocols(t *testing.T) {
+	f := func(scrapeProtocols []string, contentType, response, resultExpected string) {
+		t.Helper()
+
+		var acceptHeader string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			acceptHeader = r.Header.Get("Accept")
+			w.Header().Set("Content-Type", contentType)
+			w.Write([]byte(response))
+		}))
+		defer backend.Close()
+
+		c, err := newClient(context.Background(), &ScrapeWork{
+			ScrapeURL:       backend.URL,
+			ScrapeTimeout:   5 * time.Second,
+			AuthConfig:      newTestAuthConfig(t, false, nil),
+			ProxyAuthConfig: newTestAuthConfig(t, false, nil),
+			MaxScrapeSize:   16000,
+			ScrapeProtocols: scrapeProtocols,
+		})
+		if err != nil {
+			t.Fatalf("failed to create client: %s", err)
+		}
+
+		var cb chunkedbuffer.Buffer
+		isGzipped, err := c.ReadData(&cb)
+		if err != nil {
+			t.Fatalf("unexpected error at ReadData: %s", err)
+		}
+		if isGzipped {
+			t.Fatalf("the response mustn't be gzipped")
+		}
+		if acceptHeaderExpected := getAcceptHeader(scrapeProtocols); acceptHeader != acceptHeaderExpected {
+			t.Fatalf("unexpected Accept header; got %q; want %q", acceptHeader, acceptHeaderExpected)
+		}
+		result, err := io.ReadAll(cb.NewReader())
+		if err != nil {
+			t.Fatalf("cannot read response: %s", err)
+		}
+		if string(result) != resultExpected {
+			t.Fatalf("unexpected response;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	openMetricsResponse := "# TYPE foo counter\nfoo_total 1 # {trace_id=\"a\"} 1\nfoo_created 123\n# EOF\n"
+
+	// OpenMetrics response without scrape_protocols is passed as is
+	f(nil, "application/openmetrics-text; version=1.0.0", openMetricsResponse, openMetricsResponse)
+
+	// OpenMetrics response with scrape_protocols is converted to Prometheus text exposition format
+	f([]string{"OpenMetricsText1.0.0", "PrometheusText0.0.4"}, "application/openmetrics-text; version=1.0.0", openMetricsResponse, "# TYPE foo counter\nfoo_total 1\nfoo_created 123\n")
+
+	// Prometheus text response is passed as is
+	f([]string{"OpenMetricsText1.0.0", "PrometheusText0.0.4"}, "text/plain; version=0.0.4", "foo 1\n", "foo 1\n")
+}
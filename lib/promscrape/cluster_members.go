@@ -0,0 +1,350 @@
+package promscrape
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/consistenthash"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
+)
+
+var (
+	clusterMembers = flagutil.NewArrayString("promscrape.cluster.members", "Optional list of vmagent instances in the cluster of scrapers. "+
+		"Scrape targets are spread among the members via consistent hashing, so only a small part of targets is re-distributed when members join or leave the cluster. "+
+		"Every item may contain a member address, 'file:/path/to/members' with a member per line, 'srv+name' for members discovered via DNS SRV records "+
+		"or 'dns+name' for members discovered via DNS A/AAAA records. The list of members is re-read every -promscrape.cluster.membersRefreshInterval. "+
+		"The current member is detected via -promscrape.cluster.memberAddr. This flag cannot be used together with -promscrape.cluster.membersCount. "+
+		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#dynamic-cluster-membership")
+	clusterMemberAddr = flag.String("promscrape.cluster.memberAddr", "", "The address of the current vmagent instance in the -promscrape.cluster.members list. "+
+		"The member matches the address if it equals to the address, if its host equals to the address or if its host starts with the address followed by a dot. "+
+		"Hostname and IP addresses of local network interfaces are used by default. See https://docs.victoriametrics.com/victoriametrics/vmagent/#dynamic-cluster-membership")
+	clusterMembersRefreshInterval = flag.Duration("promscrape.cluster.membersRefreshInterval", 10*time.Second, "Interval for re-reading the list of members "+
+		"from -promscrape.cluster.members")
+)
+
+// clusterMembership holds the current list of members for the cluster of scrapers set via -promscrape.cluster.members.
+type clusterMembership struct {
+	// members contains sorted list of unique members.
+	members []string
+
+	// selfIdx is the index of the current vmagent instance in members.
+	selfIdx int
+
+	// ch is used for selecting members for scrape targets.
+	ch *consistenthash.ConsistentHash
+}
+
+var (
+	clusterMembershipV atomic.Pointer[clusterMembership]
+
+	// clusterMembershipChangedCh is notified when the list of members changes.
+	clusterMembershipChangedCh = make(chan struct{}, 1)
+
+	clusterMembershipStopCh chan struct{}
+	clusterMembershipWG     sync.WaitGroup
+
+	clusterMembershipChanges = metrics.NewCounter(`vm_promscrape_cluster_membership_changes_total`)
+	clusterMembershipErrors  = metrics.NewCounter(`vm_promscrape_cluster_membership_errors_total`)
+	clusterMembershipMembers = metrics.NewGauge(`vm_promscrape_cluster_members`, nil)
+)
+
+// newClusterMembership returns clusterMembership for the given members.
+//
+// The current member is detected by any of selfAddrs. The first item in selfAddrs is added to members if the current member is missing there.
+func newClusterMembership(members, selfAddrs []string) *clusterMembership {
+	members = append([]string{}, members...)
+	sort.Strings(members)
+	members = slices.Compact(members)
+	selfIdx := -1
+	for i, member := range members {
+		if slices.ContainsFunc(selfAddrs, func(self string) bool {
+			return isClusterMemberSelf(member, self)
+		}) {
+			selfIdx = i
+			break
+		}
+	}
+	if selfIdx < 0 {
+		// The current member is missing in the list. Add it to the list, so it scrapes its share of targets.
+		// This may result in duplicate scrapes until the list is updated at the other members.
+		self := selfAddrs[0]
+		members = append(members, self)
+		sort.Strings(members)
+		selfIdx = slices.Index(members, self)
+	}
+	return &clusterMembership{
+		members: members,
+		selfIdx: selfIdx,
+		ch:      consistenthash.NewConsistentHash(members, 0),
+	}
+}
+
+// isClusterMemberSelf returns true if the given member corresponds to the self address.
+func isClusterMemberSelf(member, self string) bool {
+	if member == self {
+		return true
+	}
+	host := member
+	if h, _, err := net.SplitHostPort(member); err == nil {
+		host = h
+	}
+	return host == self || strings.HasPrefix(host, self+".")
+}
+
+// getMemberNums returns indexes of members, which must scrape the target with the given key.
+//
+// The number of returned indexes equals to replicasCount if there are enough members.
+func (cm *clusterMembership) getMemberNums(key string, replicasCount int) []int {
+	if replicasCount < 1 {
+		replicasCount = 1
+	}
+	if replicasCount > len(cm.members) {
+		replicasCount = len(cm.members)
+	}
+	h := xxhash.Sum64(bytesutil.ToUnsafeBytes(key))
+	memberNums := make([]int, 0, replicasCount)
+	for len(memberNums) < replicasCount {
+		idx := cm.ch.GetNodeIdx(h, memberNums)
+		memberNums = append(memberNums, idx)
+	}
+	return memberNums
+}
+
+func (cm *clusterMembership) self() string {
+	return cm.members[cm.selfIdx]
+}
+
+func (cm *clusterMembership) equal(other *clusterMembership) bool {
+	return cm.selfIdx == other.selfIdx && slices.Equal(cm.members, other.members)
+}
+
+// getClusterMembership returns the current list of members set via -promscrape.cluster.members.
+//
+// nil is returned if -promscrape.cluster.members isn't set.
+func getClusterMembership() *clusterMembership {
+	return clusterMembershipV.Load()
+}
+
+// getClusterMemberName returns human-readable name for the cluster member with the given memberNum.
+func getClusterMemberName(memberNum int) string {
+	if cm := getClusterMembership(); cm != nil && memberNum < len(cm.members) {
+		return cm.members[memberNum]
+	}
+	return "shard-" + strconv.Itoa(memberNum)
+}
+
+// getClusterMemberURL returns -promscrape.cluster.memberURLTemplate for the member with the given memberNum.
+//
+// Every %d in the template is substituted with memberNum, while every %s is substituted with the member address from -promscrape.cluster.members.
+func getClusterMemberURL(memberNum int) string {
+	s := strings.ReplaceAll(*clusterMemberURLTemplate, "%d", strconv.Itoa(memberNum))
+	if cm := getClusterMembership(); cm != nil && memberNum < len(cm.members) {
+		s = strings.ReplaceAll(s, "%s", cm.members[memberNum])
+	}
+	return s
+}
+
+// getClusterMemberLabelValue returns the value for -promscrape.cluster.memberLabel.
+func getClusterMemberLabelValue() string {
+	if cm := getClusterMembership(); cm != nil {
+		return cm.self()
+	}
+	return *clusterMemberNum
+}
+
+// getClusterMemberIDString returns the identifier of the current member in the cluster of scrapers.
+func getClusterMemberIDString() string {
+	if cm := getClusterMembership(); cm != nil {
+		return cm.self()
+	}
+	return strconv.Itoa(clusterMemberID)
+}
+
+func mustInitClusterMembership() {
+	if len(*clusterMembers) == 0 {
+		return
+	}
+	if *clusterMembersCount > 1 {
+		logger.Fatalf("-promscrape.cluster.members cannot be used together with -promscrape.cluster.membersCount=%d", *clusterMembersCount)
+	}
+	selfAddrs := mustGetClusterMemberSelfAddrs()
+	members, err := resolveClusterMembers(*clusterMembers)
+	if err != nil {
+		clusterMembershipErrors.Inc()
+		logger.Errorf("cannot resolve -promscrape.cluster.members: %s; the current member scrapes all the targets until the next successful attempt", err)
+	}
+	cm := newClusterMembership(members, selfAddrs)
+	clusterMembershipV.Store(cm)
+	logClusterMembership(cm)
+
+	clusterMembershipStopCh = make(chan struct{})
+	clusterMembershipWG.Add(1)
+	go func() {
+		defer clusterMembershipWG.Done()
+		runClusterMembershipWatcher(selfAddrs, clusterMembershipStopCh)
+	}()
+}
+
+// mustGetClusterMemberSelfAddrs returns addresses, which may identify the current vmagent instance in -promscrape.cluster.members.
+//
+// -promscrape.cluster.memberAddr is returned if it is set. Otherwise the hostname and IP addresses of local network interfaces are returned,
+// so the current instance is detected in members discovered via DNS A/AAAA records.
+func mustGetClusterMemberSelfAddrs() []string {
+	if *clusterMemberAddr != "" {
+		return []string{*clusterMemberAddr}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		logger.Fatalf("cannot determine hostname for -promscrape.cluster.memberAddr: %s", err)
+	}
+	selfAddrs := []string{hostname}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		logger.Warnf("cannot obtain IP addresses of local network interfaces: %s; detecting the current member in -promscrape.cluster.members only by hostname %q", err, hostname)
+		return selfAddrs
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			selfAddrs = append(selfAddrs, ipNet.IP.String())
+		}
+	}
+	return selfAddrs
+}
+
+func mustStopClusterMembership() {
+	if clusterMembershipStopCh == nil {
+		return
+	}
+	close(clusterMembershipStopCh)
+	clusterMembershipWG.Wait()
+	clusterMembershipStopCh = nil
+}
+
+func runClusterMembershipWatcher(selfAddrs []string, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(*clusterMembersRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		members, err := resolveClusterMembers(*clusterMembers)
+		if err != nil {
+			clusterMembershipErrors.Inc()
+			logger.Errorf("cannot resolve -promscrape.cluster.members: %s; continuing with the previous list of members", err)
+			continue
+		}
+		cm := newClusterMembership(members, selfAddrs)
+		if cm.equal(getClusterMembership()) {
+			continue
+		}
+		clusterMembershipV.Store(cm)
+		clusterMembershipChanges.Inc()
+		logClusterMembership(cm)
+		select {
+		case clusterMembershipChangedCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func logClusterMembership(cm *clusterMembership) {
+	clusterMembershipMembers.Set(float64(len(cm.members)))
+	logger.Infof("cluster of scrapers contains %d members: %s; the current member is %q", len(cm.members), cm.members, cm.self())
+}
+
+// resolveClusterMembers resolves the list of members from -promscrape.cluster.members items.
+func resolveClusterMembers(items []string) ([]string, error) {
+	var members []string
+	for _, item := range items {
+		a, err := resolveClusterMembersItem(item)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, a...)
+	}
+	return members, nil
+}
+
+func resolveClusterMembersItem(item string) ([]string, error) {
+	switch {
+	case strings.HasPrefix(item, "file:"):
+		path := strings.TrimPrefix(item, "file:")
+		data, err := fscore.ReadFileOrHTTP(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read members from %q: %w", path, err)
+		}
+		return parseClusterMembersFile(data), nil
+	case strings.HasPrefix(item, "srv+"):
+		name := strings.TrimPrefix(item, "srv+")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, addrs, err := netutil.Resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot discover members via SRV records for %q: %w", name, err)
+		}
+		members := make([]string, len(addrs))
+		for i, addr := range addrs {
+			host := strings.TrimSuffix(addr.Target, ".")
+			members[i] = net.JoinHostPort(host, strconv.FormatUint(uint64(addr.Port), 10))
+		}
+		return members, nil
+	case strings.HasPrefix(item, "dns+"):
+		hostPort := strings.TrimPrefix(item, "dns+")
+		host, port, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			host = hostPort
+			port = ""
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		addrs, err := netutil.Resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("cannot discover members via DNS records for %q: %w", host, err)
+		}
+		members := make([]string, len(addrs))
+		for i, addr := range addrs {
+			if port == "" {
+				members[i] = addr.String()
+			} else {
+				members[i] = net.JoinHostPort(addr.String(), port)
+			}
+		}
+		return members, nil
+	default:
+		return []string{item}, nil
+	}
+}
+
+// parseClusterMembersFile parses members from data.
+//
+// Every non-empty line must contain a single member. Lines starting with # are ignored.
+func parseClusterMembersFile(data []byte) []string {
+	var members []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		members = append(members, line)
+	}
+	return members
+}
@@ -0,0 +1,128 @@
+package promscrape
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestIsClusterMemberSelf(t *testing.T) {
+	f := func(member, self string, resultExpected bool) {
+		t.Helper()
+		result := isClusterMemberSelf(member, self)
+		if result != resultExpected {
+			t.Fatalf("unexpected result for isClusterMemberSelf(%q, %q); got %v; want %v", member, self, result, resultExpected)
+		}
+	}
+	f("vmagent-0", "vmagent-0", true)
+	f("vmagent-0:8429", "vmagent-0:8429", true)
+	f("vmagent-0:8429", "vmagent-0", true)
+	f("vmagent-0.vmagent.default.svc.cluster.local:8429", "vmagent-0", true)
+	f("10.0.0.1:8429", "10.0.0.1", true)
+
+	f("vmagent-1:8429", "vmagent-0", false)
+	f("vmagent-10:8429", "vmagent-1", false)
+	f("vmagent-0.vmagent:8429", "vmagent", false)
+	f("10.0.0.10:8429", "10.0.0.1", false)
+}
+
+func TestNewClusterMembership(t *testing.T) {
+	f := func(members, selfAddrs []string, membersExpected []string, selfExpected string) {
+		t.Helper()
+		cm := newClusterMembership(members, selfAddrs)
+		if !reflect.DeepEqual(cm.members, membersExpected) {
+			t.Fatalf("unexpected members; got %q; want %q", cm.members, membersExpected)
+		}
+		if s := cm.self(); s != selfExpected {
+			t.Fatalf("unexpected self member; got %q; want %q", s, selfExpected)
+		}
+	}
+	f([]string{"b:8429", "a:8429", "b:8429"}, []string{"a"}, []string{"a:8429", "b:8429"}, "a:8429")
+	f([]string{"b:8429", "a:8429"}, []string{"b:8429"}, []string{"a:8429", "b:8429"}, "b:8429")
+
+	// The current member is detected by IP address for members discovered via DNS A/AAAA records
+	f([]string{"10.0.0.2:8429", "10.0.0.1:8429"}, []string{"vmagent-0", "127.0.0.1", "10.0.0.1"}, []string{"10.0.0.1:8429", "10.0.0.2:8429"}, "10.0.0.1:8429")
+	f([]string{"[fd00::2]:8429", "[fd00::1]:8429"}, []string{"vmagent-0", "fd00::2"}, []string{"[fd00::1]:8429", "[fd00::2]:8429"}, "[fd00::2]:8429")
+
+	// The current member is missing in the list
+	f([]string{"b:8429", "c:8429"}, []string{"a"}, []string{"a", "b:8429", "c:8429"}, "a")
+	f([]string{"10.0.0.2:8429"}, []string{"vmagent-0", "10.0.0.1"}, []string{"10.0.0.2:8429", "vmagent-0"}, "vmagent-0")
+	f(nil, []string{"a"}, []string{"a"}, "a")
+}
+
+func TestClusterMembershipGetMemberNums(t *testing.T) {
+	newMembers := func(n int) []string {
+		members := make([]string, n)
+		for i := range members {
+			members[i] = fmt.Sprintf("vmagent-%d:8429", i)
+		}
+		return members
+	}
+	const keysCount = 10000
+	keys := make([]string, keysCount)
+	for i := range keys {
+		keys[i] = fmt.Sprintf(`{instance="host-%d:9100",job="node_exporter"}`, i)
+	}
+
+	cm := newClusterMembership(newMembers(4), []string{"vmagent-0"})
+	owners := make(map[string][]int, keysCount)
+	perMemberKeys := make([]int, len(cm.members))
+	for _, key := range keys {
+		memberNums := cm.getMemberNums(key, 2)
+		if len(memberNums) != 2 {
+			t.Fatalf("unexpected number of members for key %q; got %d; want 2", key, len(memberNums))
+		}
+		if memberNums[0] == memberNums[1] {
+			t.Fatalf("replicas for key %q must be located at distinct members; got %d", key, memberNums)
+		}
+		if memberNumsNew := cm.getMemberNums(key, 2); !reflect.DeepEqual(memberNumsNew, memberNums) {
+			t.Fatalf("unstable members for key %q; got %d; want %d", key, memberNumsNew, memberNums)
+		}
+		owners[key] = memberNums
+		for _, n := range memberNums {
+			perMemberKeys[n]++
+		}
+	}
+
+	// Targets must be evenly distributed among members.
+	keysPerMember := 2 * keysCount / len(cm.members)
+	for i, n := range perMemberKeys {
+		if n < keysPerMember*8/10 || n > keysPerMember*12/10 {
+			t.Fatalf("unexpected number of targets at member %q: %d; want approximately %d", cm.members[i], n, keysPerMember)
+		}
+	}
+
+	// Only targets from the removed member must be moved to other members.
+	cmNew := newClusterMembership(newMembers(3), []string{"vmagent-0"})
+	for _, key := range keys {
+		memberNums := owners[key]
+		memberNumsNew := cmNew.getMemberNums(key, 2)
+		for _, n := range memberNums {
+			if n < len(cmNew.members) && !slices.Contains(memberNumsNew, n) {
+				t.Fatalf("target %q has been moved from the member %q after removing another member; new members: %d", key, cm.members[n], memberNumsNew)
+			}
+		}
+	}
+
+	// The replication factor cannot exceed the number of members.
+	cmSingle := newClusterMembership(newMembers(1), []string{"vmagent-0"})
+	if memberNums := cmSingle.getMemberNums("foo", 3); !reflect.DeepEqual(memberNums, []int{0}) {
+		t.Fatalf("unexpected members for a single-member cluster; got %d; want [0]", memberNums)
+	}
+}
+
+func TestParseClusterMembersFile(t *testing.T) {
+	data := []byte(`
+# vmagent members
+vmagent-0:8429
+  vmagent-1:8429
+
+vmagent-2:8429
+`)
+	members := parseClusterMembersFile(data)
+	membersExpected := []string{"vmagent-0:8429", "vmagent-1:8429", "vmagent-2:8429"}
+	if !reflect.DeepEqual(members, membersExpected) {
+		t.Fatalf("unexpected members; got %q; want %q", members, membersExpected)
+	}
+}
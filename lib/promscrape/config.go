@@ -33,19 +33,26 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/docker"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/dockerswarm"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/ec2"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/ecs"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/eureka"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/gce"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/hetzner"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/http"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/ionos"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/kubernetes"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/kuma"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/lightsail"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/linode"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/marathon"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/nomad"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/openstack"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/ovhcloud"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/puppetdb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/scaleway"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/stackit"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/vultr"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/yandexcloud"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/probe"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
@@ -75,6 +82,7 @@ var (
 	clusterMemberURLTemplate = flag.String("promscrape.cluster.memberURLTemplate", "", "An optional template for URL to access vmagent instance with the given -promscrape.cluster.memberNum value. "+
 		"Every %d occurrence in the template is substituted with -promscrape.cluster.memberNum at urls to vmagent instances responsible for scraping the given target "+
 		"at /service-discovery page. For example -promscrape.cluster.memberURLTemplate='http://vmagent-%d:8429/targets'. "+
+		"Every %s occurrence in the template is substituted with the member address from -promscrape.cluster.members. "+
 		"See https://docs.victoriametrics.com/victoriametrics/vmagent/#scraping-big-number-of-targets for more details")
 	clusterReplicationFactor = flag.Int("promscrape.cluster.replicationFactor", 1, "The number of members in the cluster, which scrape the same targets. "+
 		"If the replication factor is greater than 1, then the deduplication must be enabled at remote storage side. "+
@@ -116,6 +124,14 @@ type Config struct {
 	ScrapeConfigs     []*ScrapeConfig `yaml:"scrape_configs,omitempty"`
 	ScrapeConfigFiles []string        `yaml:"scrape_config_files,omitempty"`
 
+	// PrometheusOperatorConfigs contains configs for generating scrape configs from Prometheus Operator objects.
+	PrometheusOperatorConfigs []*PrometheusOperatorConfig `yaml:"prometheus_operator_configs,omitempty"`
+
+	// ProbeConfigs contains configs for probing targets with the built-in prober.
+	//
+	// They are converted to ScrapeConfigs with non-nil Probe field at parseData.
+	ProbeConfigs []*ProbeConfig `yaml:"probe_configs,omitempty"`
+
 	// This is set to the directory from where the config has been loaded.
 	baseDir string
 }
@@ -137,7 +153,21 @@ func (cfg *Config) unmarshal(data []byte, isStrict bool) error {
 }
 
 func (cfg *Config) marshal() []byte {
-	data, err := yaml.Marshal(cfg)
+	// Show scrape configs obtained from `probe_configs` in the `probe_configs` section.
+	cfgCopy := *cfg
+	cfgCopy.ScrapeConfigs = nil
+	cfgCopy.ProbeConfigs = append([]*ProbeConfig{}, cfg.ProbeConfigs...)
+	for _, sc := range cfg.ScrapeConfigs {
+		if sc.Probe == nil {
+			cfgCopy.ScrapeConfigs = append(cfgCopy.ScrapeConfigs, sc)
+			continue
+		}
+		cfgCopy.ProbeConfigs = append(cfgCopy.ProbeConfigs, &ProbeConfig{
+			ScrapeConfig: *sc,
+			Config:       *sc.Probe,
+		})
+	}
+	data, err := yaml.Marshal(&cfgCopy)
 	if err != nil {
 		logger.Panicf("BUG: cannot marshal Config: %s", err)
 	}
@@ -150,6 +180,9 @@ func (cfg *Config) mustStart() {
 	for _, sc := range cfg.ScrapeConfigs {
 		sc.mustStart(cfg.baseDir)
 	}
+	for _, poc := range cfg.PrometheusOperatorConfigs {
+		poc.mustStart(cfg.baseDir)
+	}
 	jobNames := cfg.getJobNames()
 	tsmGlobal.registerJobNames(jobNames)
 	logger.Infof("started %d service discovery routines in %.3f seconds", len(cfg.ScrapeConfigs), time.Since(startTime).Seconds())
@@ -185,10 +218,12 @@ func (cfg *Config) mustRestart(prevCfg *Config) bool {
 		if !needGlobalRestart && areEqualScrapeConfigs(scPrev, sc) {
 			// The scrape config didn't change, so no need to restart it.
 			// Use the reference to the previous job, so it could be stopped properly later.
+			sc.swc.mustStop()
 			cfg.ScrapeConfigs[i] = scPrev
 		} else {
 			// The scrape config has been changed. Stop the previous scrape config and start new one.
 			scPrev.mustStop()
+			scPrev.swc.mustStop()
 			sc.mustStart(cfg.baseDir)
 			restarted++
 		}
@@ -197,9 +232,24 @@ func (cfg *Config) mustRestart(prevCfg *Config) bool {
 	for _, scPrev := range prevCfg.ScrapeConfigs {
 		if _, ok := currentJobNames[scPrev.JobName]; !ok {
 			scPrev.mustStop()
+			scPrev.swc.mustStop()
 			stopped++
 		}
 	}
+	// Restart prometheus_operator_configs on Global config change, since the generated scrape configs depend on it.
+	if !needGlobalRestart && areEqualPrometheusOperatorConfigs(cfg.PrometheusOperatorConfigs, prevCfg.PrometheusOperatorConfigs) {
+		// Use the references to the previous configs, so the previously generated scrape configs could be stopped properly later.
+		cfg.PrometheusOperatorConfigs = prevCfg.PrometheusOperatorConfigs
+	} else {
+		for _, pocPrev := range prevCfg.PrometheusOperatorConfigs {
+			pocPrev.mustStop()
+			stopped++
+		}
+		for _, poc := range cfg.PrometheusOperatorConfigs {
+			poc.mustStart(cfg.baseDir)
+			started++
+		}
+	}
 	jobNames := cfg.getJobNames()
 	tsmGlobal.registerJobNames(jobNames)
 	updated := started + stopped + restarted
@@ -243,11 +293,32 @@ func (gc *GlobalConfig) marshalJSON() []byte {
 	return data
 }
 
+// mustRestartKubernetesSD restarts scrape jobs with kubernetes_sd_configs at cfg.
+//
+// This is needed for re-distributing cached scrape targets among members of the cluster of scrapers
+// after the list of members changes. Other service discovery types re-calculate their targets on every update.
+func (cfg *Config) mustRestartKubernetesSD() {
+	for _, sc := range cfg.ScrapeConfigs {
+		if len(sc.KubernetesSDConfigs) == 0 {
+			continue
+		}
+		sc.mustStop()
+		sc.mustStart(cfg.baseDir)
+	}
+	for _, poc := range cfg.PrometheusOperatorConfigs {
+		poc.mustRestartKubernetesSD(cfg.baseDir)
+	}
+}
+
 func (cfg *Config) mustStop() {
 	startTime := time.Now()
 	logger.Infof("stopping service discovery routines...")
 	for _, sc := range cfg.ScrapeConfigs {
 		sc.mustStop()
+		sc.swc.mustStop()
+	}
+	for _, poc := range cfg.PrometheusOperatorConfigs {
+		poc.mustStop()
 	}
 	logger.Infof("stopped %d service discovery routines in %.3f seconds", len(cfg.ScrapeConfigs), time.Since(startTime).Seconds())
 }
@@ -270,6 +341,7 @@ type GlobalConfig struct {
 	ExternalLabels       *promutil.Labels            `yaml:"external_labels,omitempty"`
 	RelabelConfigs       []promrelabel.RelabelConfig `yaml:"relabel_configs,omitempty"`
 	MetricRelabelConfigs []promrelabel.RelabelConfig `yaml:"metric_relabel_configs,omitempty"`
+	ScrapeProtocols      []string                    `yaml:"scrape_protocols,omitempty"`
 }
 
 // ScrapeConfig represents essential parts for `scrape_config` section of Prometheus config.
@@ -295,6 +367,7 @@ type ScrapeConfig struct {
 	RelabelConfigs       []promrelabel.RelabelConfig `yaml:"relabel_configs,omitempty"`
 	MetricRelabelConfigs []promrelabel.RelabelConfig `yaml:"metric_relabel_configs,omitempty"`
 	SampleLimit          int                         `yaml:"sample_limit,omitempty"`
+	ScrapeProtocols      []string                    `yaml:"scrape_protocols,omitempty"`
 
 	// This silly option is needed for compatibility with Prometheus.
 	// vmagent was supporting disable_compression option since the beginning, while Prometheus developers
@@ -310,18 +383,24 @@ type ScrapeConfig struct {
 	DockerSDConfigs       []docker.SDConfig       `yaml:"docker_sd_configs,omitempty"`
 	DockerSwarmSDConfigs  []dockerswarm.SDConfig  `yaml:"dockerswarm_sd_configs,omitempty"`
 	EC2SDConfigs          []ec2.SDConfig          `yaml:"ec2_sd_configs,omitempty"`
+	ECSSDConfigs          []ecs.SDConfig          `yaml:"ecs_sd_configs,omitempty"`
 	EurekaSDConfigs       []eureka.SDConfig       `yaml:"eureka_sd_configs,omitempty"`
 	FileSDConfigs         []FileSDConfig          `yaml:"file_sd_configs,omitempty"`
 	GCESDConfigs          []gce.SDConfig          `yaml:"gce_sd_configs,omitempty"`
 	HetznerSDConfigs      []hetzner.SDConfig      `yaml:"hetzner_sd_configs,omitempty"`
 	HTTPSDConfigs         []http.SDConfig         `yaml:"http_sd_configs,omitempty"`
+	IONOSSDConfigs        []ionos.SDConfig        `yaml:"ionos_sd_configs,omitempty"`
 	KubernetesSDConfigs   []kubernetes.SDConfig   `yaml:"kubernetes_sd_configs,omitempty"`
 	KumaSDConfigs         []kuma.SDConfig         `yaml:"kuma_sd_configs,omitempty"`
+	LightsailSDConfigs    []lightsail.SDConfig    `yaml:"lightsail_sd_configs,omitempty"`
+	LinodeSDConfigs       []linode.SDConfig       `yaml:"linode_sd_configs,omitempty"`
 	MarathonSDConfigs     []marathon.SDConfig     `yaml:"marathon_sd_configs,omitempty"`
 	NomadSDConfigs        []nomad.SDConfig        `yaml:"nomad_sd_configs,omitempty"`
 	OpenStackSDConfigs    []openstack.SDConfig    `yaml:"openstack_sd_configs,omitempty"`
 	OVHCloudSDConfigs     []ovhcloud.SDConfig     `yaml:"ovhcloud_sd_configs,omitempty"`
 	PuppetDBSDConfigs     []puppetdb.SDConfig     `yaml:"puppetdb_sd_configs,omitempty"`
+	ScalewaySDConfigs     []scaleway.SDConfig     `yaml:"scaleway_sd_configs,omitempty"`
+	STACKITSDConfigs      []stackit.SDConfig      `yaml:"stackit_sd_configs,omitempty"`
 	StaticConfigs         []StaticConfig          `yaml:"static_configs,omitempty"`
 	VultrSDConfigs        []vultr.SDConfig        `yaml:"vultr_configs,omitempty"`
 	YandexCloudSDConfigs  []yandexcloud.SDConfig  `yaml:"yandexcloud_sd_configs,omitempty"`
@@ -336,10 +415,32 @@ type ScrapeConfig struct {
 	NoStaleMarkers      *bool                      `yaml:"no_stale_markers,omitempty"`
 	ProxyClientConfig   promauth.ProxyClientConfig `yaml:",inline"`
 
+	// Probe contains probe options for scrape configs obtained from `probe_configs` section.
+	//
+	// It cannot be set in `scrape_configs` section.
+	Probe *probe.Config `yaml:"-"`
+
 	// This is set in loadConfig
 	swc *scrapeWorkConfig
 }
 
+// ProbeConfig represents an item in `probe_configs` section.
+//
+// It contains the same options as ScrapeConfig plus probe options, which define how to probe the discovered targets.
+//
+// See https://docs.victoriametrics.com/victoriametrics/vmagent/#probing-targets
+type ProbeConfig struct {
+	ScrapeConfig `yaml:",inline"`
+	probe.Config `yaml:",inline"`
+}
+
+func (pc *ProbeConfig) toScrapeConfig() *ScrapeConfig {
+	sc := pc.ScrapeConfig
+	probeCfg := pc.Config
+	sc.Probe = &probeCfg
+	return &sc
+}
+
 func (sc *ScrapeConfig) mustStart(baseDir string) {
 	swosFunc := func(metaLabels *promutil.Labels) any {
 		target := metaLabels.Get("__address__")
@@ -380,6 +481,9 @@ func (sc *ScrapeConfig) mustStop() {
 	for i := range sc.EC2SDConfigs {
 		sc.EC2SDConfigs[i].MustStop()
 	}
+	for i := range sc.ECSSDConfigs {
+		sc.ECSSDConfigs[i].MustStop()
+	}
 	for i := range sc.EurekaSDConfigs {
 		sc.EurekaSDConfigs[i].MustStop()
 	}
@@ -392,12 +496,21 @@ func (sc *ScrapeConfig) mustStop() {
 	for i := range sc.HTTPSDConfigs {
 		sc.HTTPSDConfigs[i].MustStop()
 	}
+	for i := range sc.IONOSSDConfigs {
+		sc.IONOSSDConfigs[i].MustStop()
+	}
 	for i := range sc.KubernetesSDConfigs {
 		sc.KubernetesSDConfigs[i].MustStop()
 	}
 	for i := range sc.KumaSDConfigs {
 		sc.KumaSDConfigs[i].MustStop()
 	}
+	for i := range sc.LightsailSDConfigs {
+		sc.LightsailSDConfigs[i].MustStop()
+	}
+	for i := range sc.LinodeSDConfigs {
+		sc.LinodeSDConfigs[i].MustStop()
+	}
 	for i := range sc.NomadSDConfigs {
 		sc.NomadSDConfigs[i].MustStop()
 	}
@@ -410,6 +523,12 @@ func (sc *ScrapeConfig) mustStop() {
 	for i := range sc.PuppetDBSDConfigs {
 		sc.PuppetDBSDConfigs[i].MustStop()
 	}
+	for i := range sc.ScalewaySDConfigs {
+		sc.ScalewaySDConfigs[i].MustStop()
+	}
+	for i := range sc.STACKITSDConfigs {
+		sc.STACKITSDConfigs[i].MustStop()
+	}
 	for i := range sc.VultrSDConfigs {
 		sc.VultrSDConfigs[i].MustStop()
 	}
@@ -531,13 +650,27 @@ func (cfg *Config) parseData(data []byte, path string) error {
 	cfg.ScrapeConfigFiles = nil
 	cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scs...)
 
+	// Convert cfg.ProbeConfigs into cfg.ScrapeConfigs, so the probed targets are discovered, relabeled
+	// and distributed among vmagent cluster members in the same way as scrape targets.
+	for _, pc := range cfg.ProbeConfigs {
+		cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, pc.toScrapeConfig())
+	}
+	cfg.ProbeConfigs = nil
+
+	for i, poc := range cfg.PrometheusOperatorConfigs {
+		if err := poc.Validate(); err != nil {
+			cfg.ScrapeConfigs = nil
+			return fmt.Errorf("invalid `prometheus_operator_configs` #%d: %w", i+1, err)
+		}
+	}
+
 	// Check that all the scrape configs have unique JobName
 	m := make(map[string]struct{}, len(cfg.ScrapeConfigs))
 	for _, sc := range cfg.ScrapeConfigs {
 		jobName := sc.JobName
 		if _, ok := m[jobName]; ok {
 			cfg.ScrapeConfigs = nil
-			return fmt.Errorf("duplicate `job_name` in `scrape_configs` loaded from %q: %q", path, jobName)
+			return fmt.Errorf("duplicate `job_name` in `scrape_configs` and `probe_configs` loaded from %q: %q", path, jobName)
 		}
 		m[jobName] = struct{}{}
 	}
@@ -663,6 +796,16 @@ func (cfg *Config) getEC2SDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	return cfg.getScrapeWorkGeneric(visitConfigs, "ec2_sd_config", prev)
 }
 
+// getECSSDScrapeWork returns `ecs_sd_configs` ScrapeWork from cfg.
+func (cfg *Config) getECSSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
+		for i := range sc.ECSSDConfigs {
+			visitor(&sc.ECSSDConfigs[i])
+		}
+	}
+	return cfg.getScrapeWorkGeneric(visitConfigs, "ecs_sd_config", prev)
+}
+
 // getEurekaSDScrapeWork returns `eureka_sd_configs` ScrapeWork from cfg.
 func (cfg *Config) getEurekaSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
@@ -715,6 +858,16 @@ func (cfg *Config) getHTTPDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	return cfg.getScrapeWorkGeneric(visitConfigs, "http_sd_config", prev)
 }
 
+// getIONOSSDScrapeWork returns `ionos_sd_configs` ScrapeWork from cfg.
+func (cfg *Config) getIONOSSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
+		for i := range sc.IONOSSDConfigs {
+			visitor(&sc.IONOSSDConfigs[i])
+		}
+	}
+	return cfg.getScrapeWorkGeneric(visitConfigs, "ionos_sd_config", prev)
+}
+
 // getKubernetesSDScrapeWork returns `kubernetes_sd_configs` ScrapeWork from cfg.
 func (cfg *Config) getKubernetesSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	const discoveryType = "kubernetes_sd_config"
@@ -753,6 +906,26 @@ func (cfg *Config) getKumaSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	return cfg.getScrapeWorkGeneric(visitConfigs, "kuma_sd_config", prev)
 }
 
+// getLightsailSDScrapeWork returns `lightsail_sd_configs` ScrapeWork from cfg.
+func (cfg *Config) getLightsailSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
+		for i := range sc.LightsailSDConfigs {
+			visitor(&sc.LightsailSDConfigs[i])
+		}
+	}
+	return cfg.getScrapeWorkGeneric(visitConfigs, "lightsail_sd_config", prev)
+}
+
+// getLinodeSDScrapeWork returns `linode_sd_configs` ScrapeWork from cfg.
+func (cfg *Config) getLinodeSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
+		for i := range sc.LinodeSDConfigs {
+			visitor(&sc.LinodeSDConfigs[i])
+		}
+	}
+	return cfg.getScrapeWorkGeneric(visitConfigs, "linode_sd_config", prev)
+}
+
 // getMarathonSDScrapeWork returns `marathon_sd_configs` ScrapeWork from cfg.
 func (cfg *Config) getMarathonSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
@@ -803,6 +976,26 @@ func (cfg *Config) getPuppetDBSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	return cfg.getScrapeWorkGeneric(visitConfigs, "puppetdb_sd_config", prev)
 }
 
+// getScalewaySDScrapeWork returns `scaleway_sd_configs` ScrapeWork from cfg.
+func (cfg *Config) getScalewaySDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
+		for i := range sc.ScalewaySDConfigs {
+			visitor(&sc.ScalewaySDConfigs[i])
+		}
+	}
+	return cfg.getScrapeWorkGeneric(visitConfigs, "scaleway_sd_config", prev)
+}
+
+// getSTACKITSDScrapeWork returns `stackit_sd_configs` ScrapeWork from cfg.
+func (cfg *Config) getSTACKITSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
+		for i := range sc.STACKITSDConfigs {
+			visitor(&sc.STACKITSDConfigs[i])
+		}
+	}
+	return cfg.getScrapeWorkGeneric(visitConfigs, "stackit_sd_config", prev)
+}
+
 // getVultrSDScrapeWork returns `vultr_sd_configs` ScrapeWork from cfg.
 func (cfg *Config) getVultrSDScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
 	visitConfigs := func(sc *ScrapeConfig, visitor func(sdc targetLabelsGetter)) {
@@ -917,6 +1110,9 @@ func getScrapeWorkConfig(sc *ScrapeConfig, baseDir string, globalCfg *GlobalConf
 	metricsPath := sc.MetricsPath
 	if metricsPath == "" {
 		metricsPath = "/metrics"
+		if sc.Probe != nil {
+			metricsPath = "/"
+		}
 	}
 	scheme := strings.ToLower(sc.Scheme)
 	if scheme == "" {
@@ -952,6 +1148,7 @@ func getScrapeWorkConfig(sc *ScrapeConfig, baseDir string, globalCfg *GlobalConf
 	}
 	metricRelabelConfigs, err := promrelabel.ParseRelabelConfigs(mrcs)
 	if err != nil {
+		relabelConfigs.MustStop()
 		return nil, fmt.Errorf("cannot parse `metric_relabel_configs` for `job_name` %q: %w", jobName, err)
 	}
 	externalLabels := globalCfg.ExternalLabels
@@ -967,6 +1164,27 @@ func getScrapeWorkConfig(sc *ScrapeConfig, baseDir string, globalCfg *GlobalConf
 	if sc.EnableCompression != nil {
 		disableCompression = !*sc.EnableCompression
 	}
+	scrapeProtocols := sc.ScrapeProtocols
+	if len(scrapeProtocols) == 0 {
+		scrapeProtocols = globalCfg.ScrapeProtocols
+	}
+	if err := checkScrapeProtocols(scrapeProtocols); err != nil {
+		relabelConfigs.MustStop()
+		metricRelabelConfigs.MustStop()
+		return nil, fmt.Errorf("cannot parse `scrape_protocols` for `job_name` %q: %w", jobName, err)
+	}
+	var probeConfig *probe.ParsedConfig
+	if sc.Probe != nil {
+		pc, err := sc.Probe.Parse()
+		if err != nil {
+			relabelConfigs.MustStop()
+			metricRelabelConfigs.MustStop()
+			return nil, fmt.Errorf("cannot parse probe options for `job_name` %q: %w", jobName, err)
+		}
+		probeConfig = pc
+	}
+	// Probes must establish new connection every time in order to detect connection issues and certificate changes.
+	disableKeepAlive := sc.DisableKeepAlive || sc.Probe != nil
 	swc := &scrapeWorkConfig{
 		scrapeInterval:       scrapeInterval,
 		scrapeIntervalString: scrapeInterval.String(),
@@ -988,16 +1206,26 @@ func getScrapeWorkConfig(sc *ScrapeConfig, baseDir string, globalCfg *GlobalConf
 		metricRelabelConfigs: metricRelabelConfigs,
 		sampleLimit:          sc.SampleLimit,
 		disableCompression:   disableCompression,
-		disableKeepAlive:     sc.DisableKeepAlive,
+		scrapeProtocols:      scrapeProtocols,
+		disableKeepAlive:     disableKeepAlive,
 		streamParse:          sc.StreamParse,
 		scrapeAlignInterval:  sc.ScrapeAlignInterval.Duration(),
 		scrapeOffset:         sc.ScrapeOffset.Duration(),
 		seriesLimit:          seriesLimit,
 		noStaleMarkers:       noStaleTracking,
+		probeConfig:          probeConfig,
 	}
 	return swc, nil
 }
 
+// mustStop releases resources held by swc.
+//
+// It must be called when swc is no longer needed.
+func (swc *scrapeWorkConfig) mustStop() {
+	swc.relabelConfigs.MustStop()
+	swc.metricRelabelConfigs.MustStop()
+}
+
 type scrapeWorkConfig struct {
 	scrapeInterval       time.Duration
 	scrapeIntervalString string
@@ -1019,12 +1247,14 @@ type scrapeWorkConfig struct {
 	metricRelabelConfigs *promrelabel.ParsedConfigs
 	sampleLimit          int
 	disableCompression   bool
+	scrapeProtocols      []string
 	disableKeepAlive     bool
 	streamParse          bool
 	scrapeAlignInterval  time.Duration
 	scrapeOffset         time.Duration
 	seriesLimit          int
 	noStaleMarkers       bool
+	probeConfig          *probe.ParsedConfig
 }
 
 func appendScrapeWorkForTargetLabels(dst []*ScrapeWork, swc *scrapeWorkConfig, targetLabels []*promutil.Labels, discoveryType string) []*ScrapeWork {
@@ -1163,6 +1393,19 @@ func getClusterMemberNumsForScrapeWork(key string, membersCount, replicasCount i
 var scrapeWorkKeyBufPool bytesutil.ByteBufferPool
 
 func (swc *scrapeWorkConfig) getScrapeWork(target string, extraLabels, metaLabels *promutil.Labels) (*ScrapeWork, error) {
+	return swc.getScrapeWorkInternal(target, extraLabels, metaLabels, false)
+}
+
+// getTestScrapeWork returns ScrapeWork for on-demand test scrape of the given target.
+//
+// Unlike getScrapeWork, it doesn't apply `-promscrape.cluster.*` sharding and doesn't register dropped targets,
+// since test scrapes aren't a part of regular scraping.
+// Nil ScrapeWork is returned if the target is dropped during relabeling.
+func (swc *scrapeWorkConfig) getTestScrapeWork(target string, extraLabels *promutil.Labels) (*ScrapeWork, error) {
+	return swc.getScrapeWorkInternal(target, extraLabels, nil, true)
+}
+
+func (swc *scrapeWorkConfig) getScrapeWorkInternal(target string, extraLabels, metaLabels *promutil.Labels, isTestScrape bool) (*ScrapeWork, error) {
 	labels := promutil.GetLabels()
 	defer promutil.PutLabels(labels)
 
@@ -1177,8 +1420,10 @@ func (swc *scrapeWorkConfig) getScrapeWork(target string, extraLabels, metaLabel
 
 	if labels.Len() == 0 {
 		// Drop target without labels.
-		originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
-		droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonRelabeling, nil)
+		if !isTestScrape {
+			originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
+			droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonRelabeling, nil)
+		}
 		return nil, nil
 	}
 
@@ -1186,24 +1431,49 @@ func (swc *scrapeWorkConfig) getScrapeWork(target string, extraLabels, metaLabel
 	// Perform the verification on labels after the relabeling in order to guarantee that targets with the same set of labels
 	// go to the same vmagent shard.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/1687#issuecomment-940629495
-	if *clusterMembersCount > 1 {
-		bb := scrapeWorkKeyBufPool.Get()
-		bb.B = appendScrapeWorkKey(bb.B[:0], labels)
-		memberNums := getClusterMemberNumsForScrapeWork(bytesutil.ToUnsafeString(bb.B), *clusterMembersCount, *clusterReplicationFactor)
-		scrapeWorkKeyBufPool.Put(bb)
-		if !slices.Contains(memberNums, clusterMemberID) {
-			originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
-			droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonSharding, memberNums)
-			return nil, nil
+	if !isTestScrape {
+		if cm := getClusterMembership(); cm != nil {
+			bb := scrapeWorkKeyBufPool.Get()
+			bb.B = appendScrapeWorkKey(bb.B[:0], labels)
+			memberNums := cm.getMemberNums(bytesutil.ToUnsafeString(bb.B), *clusterReplicationFactor)
+			scrapeWorkKeyBufPool.Put(bb)
+			if !slices.Contains(memberNums, cm.selfIdx) {
+				originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
+				droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonSharding, memberNums)
+				return nil, nil
+			}
+		} else if *clusterMembersCount > 1 {
+			bb := scrapeWorkKeyBufPool.Get()
+			bb.B = appendScrapeWorkKey(bb.B[:0], labels)
+			memberNums := getClusterMemberNumsForScrapeWork(bytesutil.ToUnsafeString(bb.B), *clusterMembersCount, *clusterReplicationFactor)
+			scrapeWorkKeyBufPool.Put(bb)
+			if !slices.Contains(memberNums, clusterMemberID) {
+				originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
+				droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonSharding, memberNums)
+				return nil, nil
+			}
 		}
 	}
 	scrapeURL, address := promrelabel.GetScrapeURL(labels, swc.params)
 	if scrapeURL == "" {
 		// Drop target without URL.
-		originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
-		droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonMissingScrapeURL, nil)
+		if !isTestScrape {
+			originalLabels = sortOriginalLabelsIfNeeded(originalLabels)
+			droppedTargetsMap.Register(originalLabels, swc.relabelConfigs, targetDropReasonMissingScrapeURL, nil)
+		}
 		return nil, nil
 	}
+	if swc.probeConfig != nil {
+		// Use the original target address for the `instance` label of the probed target
+		// in the same way as it is usually done for blackbox_exporter targets.
+		probeAddress := labels.Get("__address__")
+		probeURL, err := swc.probeConfig.GetProbeURL(scrapeURL, probeAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid probe target for job=%q: %w", swc.jobName, err)
+		}
+		scrapeURL = probeURL
+		address = probeAddress
+	}
 	if _, err := url.Parse(scrapeURL); err != nil {
 		return nil, fmt.Errorf("invalid target url=%q for job=%q: %w", scrapeURL, swc.jobName, err)
 	}
@@ -1271,8 +1541,10 @@ func (swc *scrapeWorkConfig) getScrapeWork(target string, extraLabels, metaLabel
 	if labels.Get("instance") == "" {
 		labels.Add("instance", address)
 	}
-	if *clusterMemberLabel != "" && *clusterMemberNum != "" {
-		labels.Add(*clusterMemberLabel, *clusterMemberNum)
+	if *clusterMemberLabel != "" {
+		if v := getClusterMemberLabelValue(); v != "" {
+			labels.Add(*clusterMemberLabel, v)
+		}
 	}
 	// Remove references to deleted labels, so GC could clean strings for label name and label value past len(labels.Labels).
 	// This should reduce memory usage when relabeling creates big number of temporary labels with long names and/or values.
@@ -1302,6 +1574,7 @@ func (swc *scrapeWorkConfig) getScrapeWork(target string, extraLabels, metaLabel
 		MetricRelabelConfigs: swc.metricRelabelConfigs,
 		SampleLimit:          sampleLimit,
 		DisableCompression:   swc.disableCompression,
+		ScrapeProtocols:      swc.scrapeProtocols,
 		DisableKeepAlive:     swc.disableKeepAlive,
 		StreamParse:          streamParse,
 		ScrapeAlignInterval:  swc.scrapeAlignInterval,
@@ -1309,6 +1582,7 @@ func (swc *scrapeWorkConfig) getScrapeWork(target string, extraLabels, metaLabel
 		SeriesLimit:          seriesLimit,
 		NoStaleMarkers:       swc.noStaleMarkers,
 		AuthToken:            at,
+		ProbeConfig:          swc.probeConfig,
 
 		jobNameOriginal: swc.jobName,
 	}
@@ -109,6 +109,19 @@ scrape_configs:
   - 'My-Auth-Header: top-secret'
 `)
 	f(`
+probe_configs:
+- job_name: foo
+  scrape_interval: 30s
+  static_configs:
+  - targets:
+    - https://example.com
+  prober: http
+  http:
+    valid_status_codes:
+    - 200
+    - 301
+`)
+	f(`
 global:
   scrape_interval: 10s
   relabel_configs:
@@ -532,6 +545,25 @@ scrape_configs:
   - targets: ["s"]
 `, []*ScrapeWork{})
 
+	// Scrape config with unsupported scrape_protocols must be skipped
+	f(`
+scrape_configs:
+- job_name: aa
+  scrape_protocols: [PrometheusText0.0.4, foobar]
+  static_configs:
+  - targets: ["s"]
+`, []*ScrapeWork{})
+
+	// Scrape config with duplicate scrape_protocols must be skipped
+	f(`
+global:
+  scrape_protocols: [PrometheusProto, PrometheusProto]
+scrape_configs:
+- job_name: aa
+  static_configs:
+  - targets: ["s"]
+`, []*ScrapeWork{})
+
 	// Scrape config with invalid action in relabel_configs must be skipped
 	f(`
 scrape_configs:
@@ -1187,6 +1219,43 @@ scrape_configs:
 		},
 	})
 	f(`
+global:
+  scrape_protocols: [OpenMetricsText1.0.0, PrometheusText0.0.4]
+scrape_configs:
+- job_name: global-protocols
+  static_configs:
+  - targets: ["foo.bar:1234"]
+- job_name: job-protocols
+  scrape_protocols: [PrometheusProto]
+  static_configs:
+  - targets: ["foo.bar:1234"]
+`, []*ScrapeWork{
+		{
+			ScrapeURL:      "http://foo.bar:1234/metrics",
+			ScrapeInterval: defaultScrapeInterval,
+			ScrapeTimeout:  defaultScrapeTimeout,
+			MaxScrapeSize:  maxScrapeSize.N,
+			Labels: promutil.NewLabelsFromMap(map[string]string{
+				"instance": "foo.bar:1234",
+				"job":      "global-protocols",
+			}),
+			ScrapeProtocols: []string{"OpenMetricsText1.0.0", "PrometheusText0.0.4"},
+			jobNameOriginal: "global-protocols",
+		},
+		{
+			ScrapeURL:      "http://foo.bar:1234/metrics",
+			ScrapeInterval: defaultScrapeInterval,
+			ScrapeTimeout:  defaultScrapeTimeout,
+			MaxScrapeSize:  maxScrapeSize.N,
+			Labels: promutil.NewLabelsFromMap(map[string]string{
+				"instance": "foo.bar:1234",
+				"job":      "job-protocols",
+			}),
+			ScrapeProtocols: []string{"PrometheusProto"},
+			jobNameOriginal: "job-protocols",
+		},
+	})
+	f(`
 scrape_configs:
 - job_name: path wo slash
   enable_compression: false
@@ -1342,6 +1411,158 @@ func TestScrapeConfigClone(t *testing.T) {
 	})
 }
 
+func TestGetProbeScrapeWorkSuccess(t *testing.T) {
+	f := func(data string, scrapeURLsExpected, instancesExpected, probeConfigsExpected []string) {
+		t.Helper()
+
+		sws, err := getStaticScrapeWork([]byte(data), "non-existing-file")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var scrapeURLs, instances, probeConfigs []string
+		for _, sw := range sws {
+			scrapeURLs = append(scrapeURLs, sw.ScrapeURL)
+			instances = append(instances, sw.Labels.Get("instance"))
+			probeConfigs = append(probeConfigs, sw.ProbeConfig.String())
+			if sw.ProbeConfig != nil && !sw.DisableKeepAlive {
+				t.Fatalf("keep-alive connections must be disabled for probe %q", sw.ScrapeURL)
+			}
+		}
+		if !reflect.DeepEqual(scrapeURLs, scrapeURLsExpected) {
+			t.Fatalf("unexpected scrape urls;\ngot\n%q\nwant\n%q", scrapeURLs, scrapeURLsExpected)
+		}
+		if !reflect.DeepEqual(instances, instancesExpected) {
+			t.Fatalf("unexpected instance labels;\ngot\n%q\nwant\n%q", instances, instancesExpected)
+		}
+		if !reflect.DeepEqual(probeConfigs, probeConfigsExpected) {
+			t.Fatalf("unexpected probe configs;\ngot\n%q\nwant\n%q", probeConfigs, probeConfigsExpected)
+		}
+	}
+
+	// http probes
+	f(`
+probe_configs:
+- job_name: http
+  prober: http
+  static_configs:
+  - targets:
+    - example.com
+    - https://example.com/health?foo=bar
+`, []string{
+		"http://example.com/",
+		"https://example.com/health?foo=bar",
+	}, []string{
+		"example.com",
+		"https://example.com/health?foo=bar",
+	}, []string{
+		"prober=http, http=<nil>, tcp=<nil>, dns=<nil>",
+		"prober=http, http=<nil>, tcp=<nil>, dns=<nil>",
+	})
+
+	// tcp, tls and dns probes together with scrape configs
+	f(`
+scrape_configs:
+- job_name: scrape
+  static_configs:
+  - targets: ["foo:1234"]
+probe_configs:
+- job_name: tcp
+  prober: tcp
+  tcp:
+    expect: "^SSH-2.0-"
+  static_configs:
+  - targets: ["host1:22"]
+- job_name: tls
+  prober: tls
+  static_configs:
+  - targets: ["host2", "host3:8443"]
+- job_name: dns
+  prober: dns
+  dns:
+    query_name: example.com
+  static_configs:
+  - targets: ["8.8.8.8"]
+  relabel_configs:
+  - target_label: instance
+    replacement: google-dns
+`, []string{
+		"http://foo:1234/metrics",
+		"tcp://host1:22",
+		"tls://host2:443",
+		"tls://host3:8443",
+		"dns://8.8.8.8:53",
+	}, []string{
+		"foo:1234",
+		"host1:22",
+		"host2",
+		"host3:8443",
+		"google-dns",
+	}, []string{
+		"",
+		`prober=tcp, http=<nil>, tcp=&{Send: Expect:^SSH-2.0-}, dns=<nil>`,
+		"prober=tls, http=<nil>, tcp=<nil>, dns=<nil>",
+		"prober=tls, http=<nil>, tcp=<nil>, dns=<nil>",
+		`prober=dns, http=<nil>, tcp=<nil>, dns=&{QueryName:example.com QueryType:}`,
+	})
+
+	// probe config with invalid options must be skipped
+	f(`
+probe_configs:
+- job_name: foo
+  prober: dns
+  static_configs:
+  - targets: ["8.8.8.8"]
+`, nil, nil, nil)
+
+	// tcp probe target without port must be skipped
+	f(`
+probe_configs:
+- job_name: foo
+  prober: tcp
+  static_configs:
+  - targets: ["host1"]
+`, nil, nil, nil)
+}
+
+func TestGetProbeScrapeWorkFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+
+		if _, err := getStaticScrapeWork([]byte(data), "non-existing-file"); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// duplicate job_name in scrape_configs and probe_configs
+	f(`
+scrape_configs:
+- job_name: foo
+  static_configs:
+  - targets: ["foo:1234"]
+probe_configs:
+- job_name: foo
+  prober: http
+  static_configs:
+  - targets: ["example.com"]
+`)
+
+	// unknown option in probe_configs
+	f(`
+probe_configs:
+- job_name: foo
+  prober: http
+  http:
+    foo: bar
+`)
+
+	// probe options in scrape_configs
+	f(`
+scrape_configs:
+- job_name: foo
+  prober: http
+`)
+}
+
 func checkEqualScrapeWorks(t *testing.T, got, want []*ScrapeWork) {
 	t.Helper()
 
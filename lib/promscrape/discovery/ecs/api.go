@@ -0,0 +1,67 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/awsapi"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+type apiConfig struct {
+	awsConfig *awsapi.Config
+	endpoint  string
+	clusters  []string
+	port      int
+}
+
+var configMap = discoveryutil.NewConfigMap()
+
+func getAPIConfig(sdc *SDConfig) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (any, error) { return newAPIConfig(sdc) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig) (*apiConfig, error) {
+	port := 80
+	if sdc.Port != nil {
+		port = *sdc.Port
+	}
+	stsEndpoint := sdc.STSEndpoint
+	if stsEndpoint == "" {
+		stsEndpoint = sdc.Endpoint
+	}
+	// EC2 API is used for obtaining IP addresses of container instances for tasks running in bridge and host network modes.
+	awsCfg, err := awsapi.NewConfig(sdc.EC2Endpoint, stsEndpoint, sdc.Region, sdc.RoleARN, sdc.AccessKey, sdc.SecretKey.String(), "ecs")
+	if err != nil {
+		return nil, err
+	}
+	cfg := &apiConfig{
+		awsConfig: awsCfg,
+		endpoint:  sdc.Endpoint,
+		clusters:  sdc.Clusters,
+		port:      port,
+	}
+	return cfg, nil
+}
+
+// doRequest performs ECS API request for the given action with the given request and stores the parsed response to resp.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/Welcome.html
+func (cfg *apiConfig) doRequest(action string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("BUG: cannot marshal %s request: %w", action, err)
+	}
+	data, err := cfg.awsConfig.GetJSONAPIResponse(cfg.endpoint, "AmazonEC2ContainerServiceV20141113."+action, body)
+	if err != nil {
+		return fmt.Errorf("cannot perform %s request: %w", action, err)
+	}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("cannot parse %s response %q: %w", action, data, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package ecs
+
+import (
+	"slices"
+)
+
+// getClusters returns ECS clusters to discover tasks in.
+//
+// If cfg.clusters is empty, then all the clusters available in the region are returned.
+func getClusters(cfg *apiConfig) ([]Cluster, error) {
+	clusterArns := cfg.clusters
+	if len(clusterArns) == 0 {
+		arns, err := listClusters(cfg)
+		if err != nil {
+			return nil, err
+		}
+		clusterArns = arns
+	}
+	var clusters []Cluster
+	// DescribeClusters accepts up to 100 clusters per request.
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeClusters.html
+	for arns := range slices.Chunk(clusterArns, 100) {
+		req := &describeClustersRequest{
+			Clusters: arns,
+			Include:  []string{"TAGS"},
+		}
+		var resp DescribeClustersResponse
+		if err := cfg.doRequest("DescribeClusters", req, &resp); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, resp.Clusters...)
+	}
+	return clusters, nil
+}
+
+func listClusters(cfg *apiConfig) ([]string, error) {
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ListClusters.html
+	var clusterArns []string
+	req := &listRequest{
+		MaxResults: 100,
+	}
+	for {
+		var resp ListClustersResponse
+		if err := cfg.doRequest("ListClusters", req, &resp); err != nil {
+			return nil, err
+		}
+		clusterArns = append(clusterArns, resp.ClusterArns...)
+		if len(resp.NextToken) == 0 {
+			return clusterArns, nil
+		}
+		req.NextToken = resp.NextToken
+	}
+}
+
+// listRequest represents request for ECS List* API calls.
+type listRequest struct {
+	Cluster     string `json:"cluster,omitempty"`
+	MaxResults  int    `json:"maxResults,omitempty"`
+	NextToken   string `json:"nextToken,omitempty"`
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+type describeClustersRequest struct {
+	Clusters []string `json:"clusters"`
+	Include  []string `json:"include"`
+}
+
+// ListClustersResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ListClusters.html
+type ListClustersResponse struct {
+	ClusterArns []string `json:"clusterArns"`
+	NextToken   string   `json:"nextToken"`
+}
+
+// DescribeClustersResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeClusters.html
+type DescribeClustersResponse struct {
+	Clusters []Cluster `json:"clusters"`
+}
+
+// Cluster represents ECS cluster.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_Cluster.html
+type Cluster struct {
+	ClusterArn  string `json:"clusterArn"`
+	ClusterName string `json:"clusterName"`
+	Status      string `json:"status"`
+	Tags        []Tag  `json:"tags"`
+}
+
+// Tag represents ECS resource tag.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_Tag.html
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
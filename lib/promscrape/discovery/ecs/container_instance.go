@@ -0,0 +1,133 @@
+package ecs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// containerInstance contains EC2 instance information for ECS container instance.
+type containerInstance struct {
+	ec2InstanceID string
+	privateIP     string
+}
+
+// getContainerInstances returns container instances for tasks running at EC2 in the given clusterArn.
+//
+// The returned map is keyed by container instance ARN.
+// Container instances are needed for determining IP addresses of tasks running in bridge and host network modes,
+// since such tasks share the network with EC2 instance they run on.
+func getContainerInstances(cfg *apiConfig, clusterArn string, tasks []Task) (map[string]*containerInstance, error) {
+	var arns []string
+	for i := range tasks {
+		arn := tasks[i].ContainerInstanceArn
+		if len(arn) > 0 && !slices.Contains(arns, arn) {
+			arns = append(arns, arn)
+		}
+	}
+	cis := make(map[string]*containerInstance, len(arns))
+	if len(arns) == 0 {
+		return cis, nil
+	}
+
+	// DescribeContainerInstances accepts up to 100 container instances per request.
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeContainerInstances.html
+	for chunk := range slices.Chunk(arns, 100) {
+		req := &describeContainerInstancesRequest{
+			Cluster:            clusterArn,
+			ContainerInstances: chunk,
+		}
+		var resp DescribeContainerInstancesResponse
+		if err := cfg.doRequest("DescribeContainerInstances", req, &resp); err != nil {
+			return nil, err
+		}
+		for _, ci := range resp.ContainerInstances {
+			cis[ci.ContainerInstanceArn] = &containerInstance{
+				ec2InstanceID: ci.EC2InstanceID,
+			}
+		}
+	}
+
+	instanceIDs := make([]string, 0, len(cis))
+	for _, ci := range cis {
+		if len(ci.ec2InstanceID) > 0 && !slices.Contains(instanceIDs, ci.ec2InstanceID) {
+			instanceIDs = append(instanceIDs, ci.ec2InstanceID)
+		}
+	}
+	slices.Sort(instanceIDs)
+	privateIPs, err := getEC2InstancesPrivateIPs(cfg, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, ci := range cis {
+		ci.privateIP = privateIPs[ci.ec2InstanceID]
+	}
+	return cis, nil
+}
+
+// getEC2InstancesPrivateIPs returns private IP addresses for the given EC2 instanceIDs.
+func getEC2InstancesPrivateIPs(cfg *apiConfig, instanceIDs []string) (map[string]string, error) {
+	m := make(map[string]string, len(instanceIDs))
+	// DescribeInstances filter accepts up to 200 values.
+	// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeInstances.html
+	for ids := range slices.Chunk(instanceIDs, 200) {
+		args := make([]string, 0, len(ids)+1)
+		args = append(args, "Filter.1.Name=instance-id")
+		for i, id := range ids {
+			args = append(args, fmt.Sprintf("Filter.1.Value.%d=%s", i+1, url.QueryEscape(id)))
+		}
+		filters := strings.Join(args, "&")
+		pageToken := ""
+		for {
+			data, err := cfg.awsConfig.GetEC2APIResponse("DescribeInstances", filters, pageToken)
+			if err != nil {
+				return nil, fmt.Errorf("cannot obtain EC2 instances for ECS container instances: %w", err)
+			}
+			var ir ec2InstancesResponse
+			if err := xml.Unmarshal(data, &ir); err != nil {
+				return nil, fmt.Errorf("cannot parse EC2 DescribeInstances response %q: %w", data, err)
+			}
+			for _, r := range ir.Reservations {
+				for _, inst := range r.Instances {
+					m[inst.ID] = inst.PrivateIPAddress
+				}
+			}
+			if len(ir.NextPageToken) == 0 {
+				break
+			}
+			pageToken = ir.NextPageToken
+		}
+	}
+	return m, nil
+}
+
+type describeContainerInstancesRequest struct {
+	Cluster            string   `json:"cluster"`
+	ContainerInstances []string `json:"containerInstances"`
+}
+
+// DescribeContainerInstancesResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeContainerInstances.html
+type DescribeContainerInstancesResponse struct {
+	ContainerInstances []ContainerInstance `json:"containerInstances"`
+}
+
+// ContainerInstance represents ECS container instance.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ContainerInstance.html
+type ContainerInstance struct {
+	ContainerInstanceArn string `json:"containerInstanceArn"`
+	EC2InstanceID        string `json:"ec2InstanceId"`
+}
+
+// ec2InstancesResponse contains the needed subset of fields from https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeInstances.html
+type ec2InstancesResponse struct {
+	Reservations []struct {
+		Instances []struct {
+			ID               string `xml:"instanceId"`
+			PrivateIPAddress string `xml:"privateIpAddress"`
+		} `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+	NextPageToken string `xml:"nextToken"`
+}
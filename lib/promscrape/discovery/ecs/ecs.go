@@ -0,0 +1,56 @@
+package ecs
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.ecsSDCheckInterval", time.Minute, "Interval for checking for changes in AWS ECS. "+
+	"This works only if ecs_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/victoriametrics/sd_configs/#ecs_sd_configs for details")
+
+// SDConfig represents service discovery config for AWS ECS.
+//
+// See https://docs.victoriametrics.com/victoriametrics/sd_configs/#ecs_sd_configs
+type SDConfig struct {
+	Region      string           `yaml:"region,omitempty"`
+	Endpoint    string           `yaml:"endpoint,omitempty"`
+	EC2Endpoint string           `yaml:"ec2_endpoint,omitempty"`
+	STSEndpoint string           `yaml:"sts_endpoint,omitempty"`
+	AccessKey   string           `yaml:"access_key,omitempty"`
+	SecretKey   *promauth.Secret `yaml:"secret_key,omitempty"`
+	RoleARN     string           `yaml:"role_arn,omitempty"`
+
+	// Clusters is an optional list of cluster names or ARNs to discover tasks in.
+	// Tasks in all the clusters are discovered if Clusters is empty.
+	Clusters []string `yaml:"clusters,omitempty"`
+
+	// Port is the port to use for tasks without container port mappings.
+	Port *int `yaml:"port,omitempty"`
+
+	// RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// refresh_interval is obtained from `-promscrape.ecsSDCheckInterval` command-line option.
+}
+
+// GetLabels returns ECS labels according to sdc.
+func (sdc *SDConfig) GetLabels(_ string) ([]*promutil.Labels, error) {
+	cfg, err := getAPIConfig(sdc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	ms, err := getTasksLabels(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error when fetching tasks data from ECS: %w", err)
+	}
+	return ms, nil
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	configMap.Delete(sdc)
+}
@@ -0,0 +1,75 @@
+package ecs
+
+import (
+	"slices"
+)
+
+// getServices returns services for the given clusterArn.
+//
+// The returned map is keyed by service name, since tasks refer to services via `service:<name>` group.
+func getServices(cfg *apiConfig, clusterArn string) (map[string]*Service, error) {
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ListServices.html
+	var serviceArns []string
+	req := &listRequest{
+		Cluster:    clusterArn,
+		MaxResults: 100,
+	}
+	for {
+		var resp ListServicesResponse
+		if err := cfg.doRequest("ListServices", req, &resp); err != nil {
+			return nil, err
+		}
+		serviceArns = append(serviceArns, resp.ServiceArns...)
+		if len(resp.NextToken) == 0 {
+			break
+		}
+		req.NextToken = resp.NextToken
+	}
+
+	services := make(map[string]*Service, len(serviceArns))
+	// DescribeServices accepts up to 10 services per request.
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeServices.html
+	for arns := range slices.Chunk(serviceArns, 10) {
+		req := &describeServicesRequest{
+			Cluster:  clusterArn,
+			Services: arns,
+			Include:  []string{"TAGS"},
+		}
+		var resp DescribeServicesResponse
+		if err := cfg.doRequest("DescribeServices", req, &resp); err != nil {
+			return nil, err
+		}
+		for i := range resp.Services {
+			s := &resp.Services[i]
+			services[s.ServiceName] = s
+		}
+	}
+	return services, nil
+}
+
+type describeServicesRequest struct {
+	Cluster  string   `json:"cluster"`
+	Services []string `json:"services"`
+	Include  []string `json:"include"`
+}
+
+// ListServicesResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ListServices.html
+type ListServicesResponse struct {
+	ServiceArns []string `json:"serviceArns"`
+	NextToken   string   `json:"nextToken"`
+}
+
+// DescribeServicesResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeServices.html
+type DescribeServicesResponse struct {
+	Services []Service `json:"services"`
+}
+
+// Service represents ECS service.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_Service.html
+type Service struct {
+	ServiceArn  string `json:"serviceArn"`
+	ServiceName string `json:"serviceName"`
+	Status      string `json:"status"`
+	Tags        []Tag  `json:"tags"`
+}
@@ -0,0 +1,385 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// getTasksLabels returns labels for ECS tasks obtained from the given cfg.
+func getTasksLabels(cfg *apiConfig) ([]*promutil.Labels, error) {
+	clusters, err := getClusters(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain clusters: %w", err)
+	}
+	region := cfg.awsConfig.GetRegion()
+
+	// Task definitions are immutable, so they are cached by ARN during a single discovery round.
+	taskDefinitions := make(map[string]*TaskDefinition)
+	var ms []*promutil.Labels
+	for i := range clusters {
+		c := &clusters[i]
+		services, err := getServices(cfg, c.ClusterArn)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain services for cluster %q: %w", c.ClusterName, err)
+		}
+		tasks, err := getTasks(cfg, c.ClusterArn)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain tasks for cluster %q: %w", c.ClusterName, err)
+		}
+		cis, err := getContainerInstances(cfg, c.ClusterArn, tasks)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain container instances for cluster %q: %w", c.ClusterName, err)
+		}
+		for j := range tasks {
+			t := &tasks[j]
+			td, err := getTaskDefinition(cfg, taskDefinitions, t.TaskDefinitionArn)
+			if err != nil {
+				return nil, fmt.Errorf("cannot obtain task definition for task %q: %w", t.TaskArn, err)
+			}
+			ms = t.appendTargetLabels(ms, c, services[t.serviceName()], td, cis[t.ContainerInstanceArn], region, cfg.port)
+		}
+	}
+	return ms, nil
+}
+
+func getTasks(cfg *apiConfig, clusterArn string) ([]Task, error) {
+	// ListTasks returns only tasks with RUNNING desired status by default.
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ListTasks.html
+	var taskArns []string
+	req := &listRequest{
+		Cluster:    clusterArn,
+		MaxResults: 100,
+	}
+	for {
+		var resp ListTasksResponse
+		if err := cfg.doRequest("ListTasks", req, &resp); err != nil {
+			return nil, err
+		}
+		taskArns = append(taskArns, resp.TaskArns...)
+		if len(resp.NextToken) == 0 {
+			break
+		}
+		req.NextToken = resp.NextToken
+	}
+
+	var tasks []Task
+	// DescribeTasks accepts up to 100 tasks per request.
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeTasks.html
+	for arns := range slices.Chunk(taskArns, 100) {
+		req := &describeTasksRequest{
+			Cluster: clusterArn,
+			Tasks:   arns,
+			Include: []string{"TAGS"},
+		}
+		var resp DescribeTasksResponse
+		if err := cfg.doRequest("DescribeTasks", req, &resp); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, resp.Tasks...)
+	}
+	return tasks, nil
+}
+
+func getTaskDefinition(cfg *apiConfig, cache map[string]*TaskDefinition, taskDefinitionArn string) (*TaskDefinition, error) {
+	if td, ok := cache[taskDefinitionArn]; ok {
+		return td, nil
+	}
+	// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeTaskDefinition.html
+	req := &describeTaskDefinitionRequest{
+		TaskDefinition: taskDefinitionArn,
+	}
+	var resp DescribeTaskDefinitionResponse
+	if err := cfg.doRequest("DescribeTaskDefinition", req, &resp); err != nil {
+		return nil, err
+	}
+	td := &resp.TaskDefinition
+	cache[taskDefinitionArn] = td
+	return td, nil
+}
+
+type describeTasksRequest struct {
+	Cluster string   `json:"cluster"`
+	Tasks   []string `json:"tasks"`
+	Include []string `json:"include"`
+}
+
+type describeTaskDefinitionRequest struct {
+	TaskDefinition string `json:"taskDefinition"`
+}
+
+// ListTasksResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ListTasks.html
+type ListTasksResponse struct {
+	TaskArns  []string `json:"taskArns"`
+	NextToken string   `json:"nextToken"`
+}
+
+// DescribeTasksResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeTasks.html
+type DescribeTasksResponse struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// DescribeTaskDefinitionResponse represents response to https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_DescribeTaskDefinition.html
+type DescribeTaskDefinitionResponse struct {
+	TaskDefinition TaskDefinition `json:"taskDefinition"`
+}
+
+// Task represents ECS task.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_Task.html
+type Task struct {
+	TaskArn              string       `json:"taskArn"`
+	TaskDefinitionArn    string       `json:"taskDefinitionArn"`
+	ContainerInstanceArn string       `json:"containerInstanceArn"`
+	Group                string       `json:"group"`
+	LaunchType           string       `json:"launchType"`
+	AvailabilityZone     string       `json:"availabilityZone"`
+	DesiredStatus        string       `json:"desiredStatus"`
+	LastStatus           string       `json:"lastStatus"`
+	HealthStatus         string       `json:"healthStatus"`
+	PlatformFamily       string       `json:"platformFamily"`
+	PlatformVersion      string       `json:"platformVersion"`
+	Attachments          []Attachment `json:"attachments"`
+	Containers           []Container  `json:"containers"`
+	Tags                 []Tag        `json:"tags"`
+}
+
+// Attachment represents ECS task attachment.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_Attachment.html
+type Attachment struct {
+	Type    string         `json:"type"`
+	Details []KeyValuePair `json:"details"`
+}
+
+// KeyValuePair represents https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_KeyValuePair.html
+type KeyValuePair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Container represents ECS container running in a task.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_Container.html
+type Container struct {
+	Name            string           `json:"name"`
+	NetworkBindings []NetworkBinding `json:"networkBindings"`
+}
+
+// NetworkBinding represents https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_NetworkBinding.html
+type NetworkBinding struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort"`
+	Protocol      string `json:"protocol"`
+}
+
+// TaskDefinition represents ECS task definition.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_TaskDefinition.html
+type TaskDefinition struct {
+	NetworkMode          string                `json:"networkMode"`
+	ContainerDefinitions []ContainerDefinition `json:"containerDefinitions"`
+}
+
+// ContainerDefinition represents https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_ContainerDefinition.html
+type ContainerDefinition struct {
+	Name         string        `json:"name"`
+	PortMappings []PortMapping `json:"portMappings"`
+}
+
+// PortMapping represents https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_PortMapping.html
+type PortMapping struct {
+	Name          string `json:"name"`
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort"`
+	Protocol      string `json:"protocol"`
+}
+
+// serviceName returns the name of the service the task belongs to.
+//
+// An empty string is returned for standalone tasks.
+func (t *Task) serviceName() string {
+	name, ok := strings.CutPrefix(t.Group, "service:")
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// getENIDetail returns the value for the given name from the elastic network interface attachment of the task.
+//
+// Tasks in awsvpc network mode, including all Fargate tasks, have such an attachment.
+func (t *Task) getENIDetail(name string) string {
+	for _, a := range t.Attachments {
+		if a.Type != "ElasticNetworkInterface" {
+			continue
+		}
+		for _, d := range a.Details {
+			if d.Name == name {
+				return d.Value
+			}
+		}
+	}
+	return ""
+}
+
+// taskPort represents a port exposed by a container in ECS task.
+type taskPort struct {
+	containerName string
+	portName      string
+	containerPort int
+	hostPort      int
+	protocol      string
+
+	// port is the port to scrape.
+	port int
+}
+
+// getPorts returns ports exposed by containers in t.
+func (t *Task) getPorts(td *TaskDefinition, networkMode string) []taskPort {
+	var ports []taskPort
+	if networkMode == "awsvpc" {
+		// Containers in awsvpc network mode are reachable via task ENI at container ports.
+		// Network bindings are empty for such tasks, so take ports from the task definition.
+		for _, cd := range td.ContainerDefinitions {
+			for _, pm := range cd.PortMappings {
+				ports = append(ports, taskPort{
+					containerName: cd.Name,
+					portName:      pm.Name,
+					containerPort: pm.ContainerPort,
+					hostPort:      pm.HostPort,
+					protocol:      pm.Protocol,
+					port:          pm.ContainerPort,
+				})
+			}
+		}
+		return ports
+	}
+	// Containers in bridge and host network modes are reachable via container instance at host ports.
+	// Host ports may be assigned dynamically, so take them from network bindings.
+	for _, c := range t.Containers {
+		for _, nb := range c.NetworkBindings {
+			ports = append(ports, taskPort{
+				containerName: c.Name,
+				portName:      td.getPortName(c.Name, nb.ContainerPort),
+				containerPort: nb.ContainerPort,
+				hostPort:      nb.HostPort,
+				protocol:      nb.Protocol,
+				port:          nb.HostPort,
+			})
+		}
+	}
+	return ports
+}
+
+func (td *TaskDefinition) getPortName(containerName string, containerPort int) string {
+	for _, cd := range td.ContainerDefinitions {
+		if cd.Name != containerName {
+			continue
+		}
+		for _, pm := range cd.PortMappings {
+			if pm.ContainerPort == containerPort {
+				return pm.Name
+			}
+		}
+	}
+	return ""
+}
+
+func (t *Task) appendTargetLabels(ms []*promutil.Labels, c *Cluster, s *Service, td *TaskDefinition, ci *containerInstance, region string, defaultPort int) []*promutil.Labels {
+	networkMode := td.NetworkMode
+	if networkMode == "" {
+		// See https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task_definition_parameters.html#network_mode
+		if t.LaunchType == "FARGATE" {
+			networkMode = "awsvpc"
+		} else {
+			networkMode = "bridge"
+		}
+	}
+	var ip string
+	if networkMode == "awsvpc" {
+		ip = t.getENIDetail("privateIPv4Address")
+	} else if ci != nil {
+		ip = ci.privateIP
+	}
+	if len(ip) == 0 {
+		// Cannot scrape task without IP address
+		return ms
+	}
+
+	commonLabels := promutil.NewLabels(24)
+	commonLabels.Add("__meta_ecs_availability_zone", t.AvailabilityZone)
+	commonLabels.Add("__meta_ecs_cluster_arn", c.ClusterArn)
+	commonLabels.Add("__meta_ecs_cluster_name", c.ClusterName)
+	commonLabels.Add("__meta_ecs_desired_status", t.DesiredStatus)
+	commonLabels.Add("__meta_ecs_health_status", t.HealthStatus)
+	commonLabels.Add("__meta_ecs_ip_address", ip)
+	commonLabels.Add("__meta_ecs_last_status", t.LastStatus)
+	commonLabels.Add("__meta_ecs_launch_type", t.LaunchType)
+	commonLabels.Add("__meta_ecs_network_mode", networkMode)
+	commonLabels.Add("__meta_ecs_region", region)
+	commonLabels.Add("__meta_ecs_task_arn", t.TaskArn)
+	commonLabels.Add("__meta_ecs_task_definition_arn", t.TaskDefinitionArn)
+	commonLabels.Add("__meta_ecs_task_group", t.Group)
+	if len(t.PlatformFamily) > 0 {
+		commonLabels.Add("__meta_ecs_platform_family", t.PlatformFamily)
+	}
+	if len(t.PlatformVersion) > 0 {
+		commonLabels.Add("__meta_ecs_platform_version", t.PlatformVersion)
+	}
+	if subnetID := t.getENIDetail("subnetId"); len(subnetID) > 0 {
+		commonLabels.Add("__meta_ecs_subnet_id", subnetID)
+	}
+	if len(t.ContainerInstanceArn) > 0 {
+		commonLabels.Add("__meta_ecs_container_instance_arn", t.ContainerInstanceArn)
+	}
+	if ci != nil && len(ci.ec2InstanceID) > 0 {
+		commonLabels.Add("__meta_ecs_ec2_instance_id", ci.ec2InstanceID)
+	}
+	if s != nil {
+		commonLabels.Add("__meta_ecs_service_arn", s.ServiceArn)
+		commonLabels.Add("__meta_ecs_service_name", s.ServiceName)
+		commonLabels.Add("__meta_ecs_service_status", s.Status)
+		addTagLabels(commonLabels, "__meta_ecs_service_tag_", s.Tags)
+	}
+	addTagLabels(commonLabels, "__meta_ecs_cluster_tag_", c.Tags)
+	addTagLabels(commonLabels, "__meta_ecs_task_tag_", t.Tags)
+
+	ports := t.getPorts(td, networkMode)
+	if len(ports) == 0 {
+		// Tasks without port mappings are scraped at the default port.
+		m := promutil.NewLabels(commonLabels.Len() + 1)
+		m.Add("__address__", discoveryutil.JoinHostPort(ip, defaultPort))
+		m.AddFrom(commonLabels)
+		return append(ms, m)
+	}
+	for _, p := range ports {
+		m := promutil.NewLabels(commonLabels.Len() + 6)
+		m.Add("__address__", discoveryutil.JoinHostPort(ip, p.port))
+		m.Add("__meta_ecs_container_name", p.containerName)
+		m.Add("__meta_ecs_container_port_number", strconv.Itoa(p.containerPort))
+		m.Add("__meta_ecs_container_port_protocol", p.protocol)
+		if len(p.portName) > 0 {
+			m.Add("__meta_ecs_container_port_name", p.portName)
+		}
+		if p.hostPort > 0 {
+			m.Add("__meta_ecs_container_host_port", strconv.Itoa(p.hostPort))
+		}
+		m.AddFrom(commonLabels)
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func addTagLabels(m *promutil.Labels, prefix string, tags []Tag) {
+	for _, t := range tags {
+		if len(t.Key) == 0 || len(t.Value) == 0 {
+			continue
+		}
+		m.Add(discoveryutil.SanitizeLabelName(prefix+t.Key), t.Value)
+	}
+}
@@ -0,0 +1,281 @@
+package ecs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func TestGetTasksLabels(t *testing.T) {
+	responses := map[string]string{
+		"ListClusters": `{"clusterArns":["arn:aws:ecs:us-east-1:123456789012:cluster/prod"]}`,
+		"DescribeClusters": `{"clusters":[{
+  "clusterArn":"arn:aws:ecs:us-east-1:123456789012:cluster/prod",
+  "clusterName":"prod",
+  "status":"ACTIVE",
+  "tags":[{"key":"team","value":"infra"}]
+}]}`,
+		"ListServices": `{"serviceArns":["arn:aws:ecs:us-east-1:123456789012:service/prod/api"]}`,
+		"DescribeServices": `{"services":[{
+  "serviceArn":"arn:aws:ecs:us-east-1:123456789012:service/prod/api",
+  "serviceName":"api",
+  "status":"ACTIVE",
+  "tags":[{"key":"app","value":"api"}]
+}]}`,
+		"ListTasks": `{"taskArns":[
+  "arn:aws:ecs:us-east-1:123456789012:task/prod/aaa",
+  "arn:aws:ecs:us-east-1:123456789012:task/prod/bbb"
+]}`,
+		"DescribeTasks": `{"tasks":[{
+  "taskArn":"arn:aws:ecs:us-east-1:123456789012:task/prod/aaa",
+  "taskDefinitionArn":"arn:aws:ecs:us-east-1:123456789012:task-definition/api:3",
+  "group":"service:api",
+  "launchType":"FARGATE",
+  "availabilityZone":"us-east-1a",
+  "desiredStatus":"RUNNING",
+  "lastStatus":"RUNNING",
+  "healthStatus":"HEALTHY",
+  "platformFamily":"Linux",
+  "platformVersion":"1.4.0",
+  "attachments":[{
+    "type":"ElasticNetworkInterface",
+    "details":[
+      {"name":"subnetId","value":"subnet-1"},
+      {"name":"privateIPv4Address","value":"10.0.1.5"}
+    ]
+  }],
+  "containers":[{"name":"api","networkBindings":[]}],
+  "tags":[{"key":"version","value":"v3"}]
+},{
+  "taskArn":"arn:aws:ecs:us-east-1:123456789012:task/prod/bbb",
+  "taskDefinitionArn":"arn:aws:ecs:us-east-1:123456789012:task-definition/exporter:1",
+  "containerInstanceArn":"arn:aws:ecs:us-east-1:123456789012:container-instance/prod/ci1",
+  "group":"family:exporter",
+  "launchType":"EC2",
+  "availabilityZone":"us-east-1b",
+  "desiredStatus":"RUNNING",
+  "lastStatus":"RUNNING",
+  "healthStatus":"UNKNOWN",
+  "containers":[{
+    "name":"exporter",
+    "networkBindings":[{"bindIP":"0.0.0.0","containerPort":9100,"hostPort":32768,"protocol":"tcp"}]
+  }]
+}]}`,
+		"DescribeContainerInstances": `{"containerInstances":[{
+  "containerInstanceArn":"arn:aws:ecs:us-east-1:123456789012:container-instance/prod/ci1",
+  "ec2InstanceId":"i-0123"
+}]}`,
+	}
+	taskDefinitions := map[string]string{
+		"arn:aws:ecs:us-east-1:123456789012:task-definition/api:3": `{"taskDefinition":{
+  "networkMode":"awsvpc",
+  "containerDefinitions":[
+    {"name":"api","portMappings":[{"name":"http","containerPort":8080,"hostPort":8080,"protocol":"tcp"}]},
+    {"name":"sidecar","portMappings":[{"containerPort":9090,"protocol":"tcp"}]}
+  ]
+}}`,
+		"arn:aws:ecs:us-east-1:123456789012:task-definition/exporter:1": `{"taskDefinition":{
+  "networkMode":"bridge",
+  "containerDefinitions":[
+    {"name":"exporter","portMappings":[{"name":"metrics","containerPort":9100,"hostPort":0,"protocol":"tcp"}]}
+  ]
+}}`,
+	}
+	ec2Response := `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <reservationSet>
+    <item>
+      <instancesSet>
+        <item>
+          <instanceId>i-0123</instanceId>
+          <privateIpAddress>10.0.2.7</privateIpAddress>
+        </item>
+      </instancesSet>
+    </item>
+  </reservationSet>
+</DescribeInstancesResponse>`
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Action") == "DescribeInstances" {
+			if r.URL.Query().Get("Filter.1.Value.1") != "i-0123" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(ec2Response))
+			return
+		}
+		target := r.Header.Get("X-Amz-Target")
+		const prefix = "AmazonEC2ContainerServiceV20141113."
+		if len(target) <= len(prefix) || target[:len(prefix)] != prefix {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		action := target[len(prefix):]
+		if action == "DescribeTaskDefinition" {
+			body, _ := io.ReadAll(r.Body)
+			var req describeTaskDefinitionRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp, ok := taskDefinitions[req.TaskDefinition]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(resp))
+			return
+		}
+		resp, ok := responses[action]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resp))
+	}))
+	defer s.Close()
+
+	sdc := &SDConfig{
+		Region:      "us-east-1",
+		Endpoint:    s.URL,
+		EC2Endpoint: s.URL,
+		STSEndpoint: s.URL,
+		AccessKey:   "foo",
+		SecretKey:   promauth.NewSecret("bar"),
+	}
+	cfg, err := newAPIConfig(sdc)
+	if err != nil {
+		t.Fatalf("cannot create API config: %s", err)
+	}
+	labelss, err := getTasksLabels(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	apiTaskLabels := map[string]string{
+		"__meta_ecs_availability_zone":   "us-east-1a",
+		"__meta_ecs_cluster_arn":         "arn:aws:ecs:us-east-1:123456789012:cluster/prod",
+		"__meta_ecs_cluster_name":        "prod",
+		"__meta_ecs_cluster_tag_team":    "infra",
+		"__meta_ecs_desired_status":      "RUNNING",
+		"__meta_ecs_health_status":       "HEALTHY",
+		"__meta_ecs_ip_address":          "10.0.1.5",
+		"__meta_ecs_last_status":         "RUNNING",
+		"__meta_ecs_launch_type":         "FARGATE",
+		"__meta_ecs_network_mode":        "awsvpc",
+		"__meta_ecs_platform_family":     "Linux",
+		"__meta_ecs_platform_version":    "1.4.0",
+		"__meta_ecs_region":              "us-east-1",
+		"__meta_ecs_service_arn":         "arn:aws:ecs:us-east-1:123456789012:service/prod/api",
+		"__meta_ecs_service_name":        "api",
+		"__meta_ecs_service_status":      "ACTIVE",
+		"__meta_ecs_service_tag_app":     "api",
+		"__meta_ecs_subnet_id":           "subnet-1",
+		"__meta_ecs_task_arn":            "arn:aws:ecs:us-east-1:123456789012:task/prod/aaa",
+		"__meta_ecs_task_definition_arn": "arn:aws:ecs:us-east-1:123456789012:task-definition/api:3",
+		"__meta_ecs_task_group":          "service:api",
+		"__meta_ecs_task_tag_version":    "v3",
+	}
+	withLabels := func(m map[string]string, extra map[string]string) *promutil.Labels {
+		result := make(map[string]string, len(m)+len(extra))
+		for k, v := range m {
+			result[k] = v
+		}
+		for k, v := range extra {
+			result[k] = v
+		}
+		return promutil.NewLabelsFromMap(result)
+	}
+	expectedLabels := []*promutil.Labels{
+		withLabels(apiTaskLabels, map[string]string{
+			"__address__":                        "10.0.1.5:8080",
+			"__meta_ecs_container_name":          "api",
+			"__meta_ecs_container_port_name":     "http",
+			"__meta_ecs_container_port_number":   "8080",
+			"__meta_ecs_container_port_protocol": "tcp",
+			"__meta_ecs_container_host_port":     "8080",
+		}),
+		withLabels(apiTaskLabels, map[string]string{
+			"__address__":                        "10.0.1.5:9090",
+			"__meta_ecs_container_name":          "sidecar",
+			"__meta_ecs_container_port_number":   "9090",
+			"__meta_ecs_container_port_protocol": "tcp",
+		}),
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                        "10.0.2.7:32768",
+			"__meta_ecs_availability_zone":       "us-east-1b",
+			"__meta_ecs_cluster_arn":             "arn:aws:ecs:us-east-1:123456789012:cluster/prod",
+			"__meta_ecs_cluster_name":            "prod",
+			"__meta_ecs_cluster_tag_team":        "infra",
+			"__meta_ecs_container_host_port":     "32768",
+			"__meta_ecs_container_instance_arn":  "arn:aws:ecs:us-east-1:123456789012:container-instance/prod/ci1",
+			"__meta_ecs_container_name":          "exporter",
+			"__meta_ecs_container_port_name":     "metrics",
+			"__meta_ecs_container_port_number":   "9100",
+			"__meta_ecs_container_port_protocol": "tcp",
+			"__meta_ecs_desired_status":          "RUNNING",
+			"__meta_ecs_ec2_instance_id":         "i-0123",
+			"__meta_ecs_health_status":           "UNKNOWN",
+			"__meta_ecs_ip_address":              "10.0.2.7",
+			"__meta_ecs_last_status":             "RUNNING",
+			"__meta_ecs_launch_type":             "EC2",
+			"__meta_ecs_network_mode":            "bridge",
+			"__meta_ecs_region":                  "us-east-1",
+			"__meta_ecs_task_arn":                "arn:aws:ecs:us-east-1:123456789012:task/prod/bbb",
+			"__meta_ecs_task_definition_arn":     "arn:aws:ecs:us-east-1:123456789012:task-definition/exporter:1",
+			"__meta_ecs_task_group":              "family:exporter",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabels)
+}
+
+func TestTaskAppendTargetLabelsDefaultPort(t *testing.T) {
+	task := &Task{
+		TaskArn:           "arn:aws:ecs:us-east-1:123456789012:task/prod/ccc",
+		TaskDefinitionArn: "arn:aws:ecs:us-east-1:123456789012:task-definition/worker:2",
+		LaunchType:        "FARGATE",
+		Attachments: []Attachment{{
+			Type: "ElasticNetworkInterface",
+			Details: []KeyValuePair{{
+				Name:  "privateIPv4Address",
+				Value: "10.0.3.4",
+			}},
+		}},
+	}
+	c := &Cluster{
+		ClusterArn:  "arn:aws:ecs:us-east-1:123456789012:cluster/prod",
+		ClusterName: "prod",
+	}
+	labelss := task.appendTargetLabels(nil, c, nil, &TaskDefinition{}, nil, "us-east-1", 9100)
+	expectedLabels := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                    "10.0.3.4:9100",
+			"__meta_ecs_availability_zone":   "",
+			"__meta_ecs_cluster_arn":         "arn:aws:ecs:us-east-1:123456789012:cluster/prod",
+			"__meta_ecs_cluster_name":        "prod",
+			"__meta_ecs_desired_status":      "",
+			"__meta_ecs_health_status":       "",
+			"__meta_ecs_ip_address":          "10.0.3.4",
+			"__meta_ecs_last_status":         "",
+			"__meta_ecs_launch_type":         "FARGATE",
+			"__meta_ecs_network_mode":        "awsvpc",
+			"__meta_ecs_region":              "us-east-1",
+			"__meta_ecs_task_arn":            "arn:aws:ecs:us-east-1:123456789012:task/prod/ccc",
+			"__meta_ecs_task_definition_arn": "arn:aws:ecs:us-east-1:123456789012:task-definition/worker:2",
+			"__meta_ecs_task_group":          "",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabels)
+
+	// Tasks without IP address must be skipped
+	task.Attachments = nil
+	labelss = task.appendTargetLabels(nil, c, nil, &TaskDefinition{}, nil, "us-east-1", 9100)
+	if len(labelss) != 0 {
+		t.Fatalf("expecting empty labels for task without IP address; got %v", labelss)
+	}
+}
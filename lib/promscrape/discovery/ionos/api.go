@@ -0,0 +1,56 @@
+package ionos
+
+import (
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+var configMap = discoveryutil.NewConfigMap()
+
+type apiConfig struct {
+	client       *discoveryutil.Client
+	port         int
+	datacenterID string
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (any, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	if sdc.DatacenterID == "" {
+		return nil, fmt.Errorf("missing `datacenter_id` option")
+	}
+	hcc := sdc.HTTPClientConfig
+	if hcc.BasicAuth == nil && hcc.Authorization == nil && hcc.BearerToken == nil && hcc.BearerTokenFile == "" && hcc.OAuth2 == nil {
+		return nil, fmt.Errorf("either `basic_auth` or `authorization` option must be set")
+	}
+	ac, err := hcc.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	// See https://api.ionos.com/docs/cloud/v6/
+	apiServer := "https://api.ionos.com/cloudapi/v6"
+	client, err := discoveryutil.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC, &sdc.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	cfg := &apiConfig{
+		client:       client,
+		port:         80,
+		datacenterID: sdc.DatacenterID,
+	}
+	if sdc.Port != nil {
+		cfg.port = *sdc.Port
+	}
+	return cfg, nil
+}
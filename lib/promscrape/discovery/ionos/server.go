@@ -0,0 +1,161 @@
+package ionos
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// Servers represents a collection of IONOS Cloud servers.
+//
+// See https://api.ionos.com/docs/cloud/v6/#tag/Servers/operation/datacentersServersGet
+type Servers struct {
+	ID    string   `json:"id"`
+	Items []Server `json:"items"`
+}
+
+// Server represents IONOS Cloud server.
+type Server struct {
+	ID         string           `json:"id"`
+	Metadata   ServerMetadata   `json:"metadata"`
+	Properties ServerProperties `json:"properties"`
+	Entities   ServerEntities   `json:"entities"`
+}
+
+// ServerMetadata represents IONOS Cloud server metadata.
+type ServerMetadata struct {
+	State string `json:"state"`
+}
+
+// ServerProperties represents IONOS Cloud server properties.
+type ServerProperties struct {
+	Name             string      `json:"name"`
+	Type             string      `json:"type"`
+	AvailabilityZone string      `json:"availabilityZone"`
+	CPUFamily        string      `json:"cpuFamily"`
+	VMState          string      `json:"vmState"`
+	BootCdrom        *BootCdrom  `json:"bootCdrom"`
+	BootVolume       *BootVolume `json:"bootVolume"`
+}
+
+// BootCdrom represents IONOS Cloud boot CD-ROM.
+type BootCdrom struct {
+	ID string `json:"id"`
+}
+
+// BootVolume represents IONOS Cloud boot volume.
+type BootVolume struct {
+	ID         string                `json:"id"`
+	Properties *BootVolumeProperties `json:"properties"`
+}
+
+// BootVolumeProperties represents IONOS Cloud boot volume properties.
+type BootVolumeProperties struct {
+	Image string `json:"image"`
+}
+
+// ServerEntities represents IONOS Cloud server entities.
+type ServerEntities struct {
+	NICs NICs `json:"nics"`
+}
+
+// NICs represents a collection of IONOS Cloud network interfaces.
+type NICs struct {
+	Items []NIC `json:"items"`
+}
+
+// NIC represents IONOS Cloud network interface.
+type NIC struct {
+	Properties NICProperties `json:"properties"`
+}
+
+// NICProperties represents IONOS Cloud network interface properties.
+type NICProperties struct {
+	Name string   `json:"name"`
+	IPs  []string `json:"ips"`
+}
+
+func getServerLabels(cfg *apiConfig) ([]*promutil.Labels, error) {
+	var ms []*promutil.Labels
+	for offset := 0; ; offset += pageSize {
+		servers, err := getServers(cfg, offset)
+		if err != nil {
+			return nil, err
+		}
+		ms = appendServerLabels(ms, servers, cfg.port)
+		if len(servers.Items) < pageSize {
+			return ms, nil
+		}
+	}
+}
+
+const pageSize = 1000
+
+func getServers(cfg *apiConfig, offset int) (*Servers, error) {
+	// depth=3 is needed for obtaining NICs with IPs and boot volume properties in a single request.
+	path := fmt.Sprintf("/datacenters/%s/servers?depth=3&offset=%d&limit=%d", url.PathEscape(cfg.datacenterID), offset, pageSize)
+	data, err := cfg.client.GetAPIResponse(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain servers: %w", err)
+	}
+	var servers Servers
+	if err := json.Unmarshal(data, &servers); err != nil {
+		return nil, fmt.Errorf("cannot parse servers response %q: %w", data, err)
+	}
+	return &servers, nil
+}
+
+func appendServerLabels(ms []*promutil.Labels, servers *Servers, port int) []*promutil.Labels {
+	for i := range servers.Items {
+		server := &servers.Items[i]
+
+		var ips []string
+		ipsByNICName := make(map[string][]string)
+		for _, nic := range server.Entities.NICs.Items {
+			// IPs of the last NIC go first in order to be consistent with Prometheus.
+			nicName := nic.Properties.Name
+			ips = slices.Concat(nic.Properties.IPs, ips)
+			ipsByNICName[nicName] = slices.Concat(nic.Properties.IPs, ipsByNICName[nicName])
+		}
+		if len(ips) == 0 {
+			// Skip servers without IP addresses, since they cannot be scraped.
+			continue
+		}
+
+		m := promutil.NewLabels(16)
+		m.Add("__address__", discoveryutil.JoinHostPort(ips[0], port))
+		m.Add("__meta_ionos_server_availability_zone", server.Properties.AvailabilityZone)
+		m.Add("__meta_ionos_server_cpu_family", server.Properties.CPUFamily)
+		m.Add("__meta_ionos_server_servers_id", servers.ID)
+		m.Add("__meta_ionos_server_id", server.ID)
+		m.Add("__meta_ionos_server_lifecycle", server.Metadata.State)
+		m.Add("__meta_ionos_server_name", server.Properties.Name)
+		m.Add("__meta_ionos_server_state", server.Properties.VMState)
+		m.Add("__meta_ionos_server_type", server.Properties.Type)
+		if bc := server.Properties.BootCdrom; bc != nil {
+			m.Add("__meta_ionos_server_boot_cdrom_id", bc.ID)
+		}
+		if bv := server.Properties.BootVolume; bv != nil {
+			m.Add("__meta_ionos_server_boot_volume_id", bv.ID)
+			if bv.Properties != nil && bv.Properties.Image != "" {
+				m.Add("__meta_ionos_server_boot_image_id", bv.Properties.Image)
+			}
+		}
+		m.Add("__meta_ionos_server_ip", joinIPs(ips))
+		for nicName, nicIPs := range ipsByNICName {
+			labelName := discoveryutil.SanitizeLabelName("__meta_ionos_server_nic_ip_" + nicName)
+			m.Add(labelName, joinIPs(nicIPs))
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func joinIPs(ips []string) string {
+	return "," + strings.Join(ips, ",") + ","
+}
@@ -0,0 +1,210 @@
+package ionos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func TestNewAPIConfigFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		if _, err := newAPIConfig(sdc, ""); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// missing datacenter_id
+	f(&SDConfig{
+		HTTPClientConfig: promauth.HTTPClientConfig{
+			BearerToken: promauth.NewSecret("token"),
+		},
+	})
+
+	// missing auth
+	f(&SDConfig{
+		DatacenterID: "8feda53f-15f0-447f-badf-ebe32dad2fc0",
+	})
+}
+
+func TestGetServerLabels(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RequestURI() != "/datacenters/8feda53f-15f0-447f-badf-ebe32dad2fc0/servers?depth=3&offset=0&limit=1000" {
+			t.Errorf("unexpected request: %q", r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(testServers))
+	}))
+	defer s.Close()
+
+	client, err := discoveryutil.NewClient(s.URL, nil, nil, nil, &promauth.HTTPClientConfig{})
+	if err != nil {
+		t.Fatalf("cannot create client: %s", err)
+	}
+	defer client.Stop()
+	cfg := &apiConfig{
+		client:       client,
+		port:         9100,
+		datacenterID: "8feda53f-15f0-447f-badf-ebe32dad2fc0",
+	}
+	labelss, err := getServerLabels(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabelss := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                           "85.215.238.118:9100",
+			"__meta_ionos_server_availability_zone": "ZONE_2",
+			"__meta_ionos_server_boot_cdrom_id":     "0e4d57f9-cd78-11e9-b88c-525400f64d8d",
+			"__meta_ionos_server_cpu_family":        "INTEL_SKYLAKE",
+			"__meta_ionos_server_id":                "b501942c-4e08-43e6-8ec1-00e59c64e0e4",
+			"__meta_ionos_server_ip":                ",85.215.238.118,185.56.150.9,85.215.243.177,",
+			"__meta_ionos_server_lifecycle":         "AVAILABLE",
+			"__meta_ionos_server_name":              "prometheus-3",
+			"__meta_ionos_server_nic_ip_metrics":    ",85.215.243.177,",
+			"__meta_ionos_server_nic_ip_unnamed":    ",85.215.238.118,185.56.150.9,",
+			"__meta_ionos_server_servers_id":        "8feda53f-15f0-447f-badf-ebe32dad2fc0/servers",
+			"__meta_ionos_server_state":             "RUNNING",
+			"__meta_ionos_server_type":              "ENTERPRISE",
+		}),
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                           "85.215.248.84:9100",
+			"__meta_ionos_server_availability_zone": "ZONE_1",
+			"__meta_ionos_server_boot_image_id":     "ca2b4fe3-c6a6-11ec-8b8e-8a0fe8a9a2d6",
+			"__meta_ionos_server_boot_volume_id":    "b8fe1a63-8a03-4d62-a5a6-9a3bf2d94b0c",
+			"__meta_ionos_server_cpu_family":        "INTEL_SKYLAKE",
+			"__meta_ionos_server_id":                "523415e6-ff8c-4dc0-86d3-09c256039b30",
+			"__meta_ionos_server_ip":                ",85.215.248.84,",
+			"__meta_ionos_server_lifecycle":         "AVAILABLE",
+			"__meta_ionos_server_name":              "prometheus-1",
+			"__meta_ionos_server_nic_ip_unnamed":    ",85.215.248.84,",
+			"__meta_ionos_server_servers_id":        "8feda53f-15f0-447f-badf-ebe32dad2fc0/servers",
+			"__meta_ionos_server_state":             "RUNNING",
+			"__meta_ionos_server_type":              "ENTERPRISE",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+const testServers = `{
+  "id": "8feda53f-15f0-447f-badf-ebe32dad2fc0/servers",
+  "type": "collection",
+  "href": "https://api.ionos.com/cloudapi/v6/datacenters/8feda53f-15f0-447f-badf-ebe32dad2fc0/servers",
+  "items": [
+    {
+      "id": "b501942c-4e08-43e6-8ec1-00e59c64e0e4",
+      "type": "server",
+      "metadata": {
+        "etag": "fe405a5f2e041d506086ee0c2159b678",
+        "createdDate": "2019-09-02T11:51:53Z",
+        "lastModifiedDate": "2019-09-02T11:51:53Z",
+        "state": "AVAILABLE"
+      },
+      "properties": {
+        "name": "prometheus-3",
+        "cores": 2,
+        "ram": 4096,
+        "availabilityZone": "ZONE_2",
+        "vmState": "RUNNING",
+        "bootCdrom": {
+          "id": "0e4d57f9-cd78-11e9-b88c-525400f64d8d",
+          "type": "image"
+        },
+        "bootVolume": null,
+        "cpuFamily": "INTEL_SKYLAKE",
+        "type": "ENTERPRISE"
+      },
+      "entities": {
+        "nics": {
+          "id": "b501942c-4e08-43e6-8ec1-00e59c64e0e4/nics",
+          "type": "collection",
+          "items": [
+            {
+              "id": "6a4ff4b3-0b68-4a3f-b2b6-3a7b7b7b1e8c",
+              "type": "nic",
+              "properties": {
+                "name": "metrics",
+                "mac": "02:01:a3:b6:11:b0",
+                "ips": ["85.215.243.177"],
+                "dhcp": true,
+                "lan": 2
+              }
+            },
+            {
+              "id": "9b8a8b6c-0b68-4a3f-b2b6-3a7b7b7b1e8c",
+              "type": "nic",
+              "properties": {
+                "name": "unnamed",
+                "mac": "02:01:b3:b6:11:b0",
+                "ips": ["85.215.238.118", "185.56.150.9"],
+                "dhcp": true,
+                "lan": 1
+              }
+            }
+          ]
+        }
+      }
+    },
+    {
+      "id": "523415e6-ff8c-4dc0-86d3-09c256039b30",
+      "type": "server",
+      "metadata": {
+        "state": "AVAILABLE"
+      },
+      "properties": {
+        "name": "prometheus-1",
+        "availabilityZone": "ZONE_1",
+        "vmState": "RUNNING",
+        "bootCdrom": null,
+        "bootVolume": {
+          "id": "b8fe1a63-8a03-4d62-a5a6-9a3bf2d94b0c",
+          "type": "volume",
+          "properties": {
+            "name": "prometheus-1-boot",
+            "image": "ca2b4fe3-c6a6-11ec-8b8e-8a0fe8a9a2d6"
+          }
+        },
+        "cpuFamily": "INTEL_SKYLAKE",
+        "type": "ENTERPRISE"
+      },
+      "entities": {
+        "nics": {
+          "items": [
+            {
+              "properties": {
+                "name": "unnamed",
+                "ips": ["85.215.248.84"]
+              }
+            }
+          ]
+        }
+      }
+    },
+    {
+      "id": "e2b4c7a1-ff8c-4dc0-86d3-09c256039b30",
+      "type": "server",
+      "metadata": {
+        "state": "BUSY"
+      },
+      "properties": {
+        "name": "server-without-nics",
+        "availabilityZone": "AUTO",
+        "vmState": "SHUTOFF",
+        "cpuFamily": "INTEL_SKYLAKE",
+        "type": "CUBE"
+      },
+      "entities": {
+        "nics": {
+          "items": []
+        }
+      }
+    }
+  ],
+  "offset": 0,
+  "limit": 1000
+}`
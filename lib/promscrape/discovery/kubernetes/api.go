@@ -13,9 +13,25 @@ func newAPIConfig(sdc *SDConfig, baseDir string, swcFunc ScrapeWorkConstructorFu
 	role := sdc.role()
 	switch role {
 	case "node", "pod", "service", "endpoints", "endpointslice", "ingress":
+	case "kubelet":
+		kw, err := newKubeletWatcher(sdc, baseDir, swcFunc)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &apiConfig{
+			kw: kw,
+		}
+		return cfg, nil
 	default:
-		return nil, fmt.Errorf("unexpected `role`: %q; must be one of `node`, `pod`, `service`, `endpoints`, `endpointslice` or `ingress`", role)
+		return nil, fmt.Errorf("unexpected `role`: %q; must be one of `node`, `pod`, `service`, `endpoints`, `endpointslice`, `ingress` or `kubelet`", role)
 	}
+	return newAPIConfigForRole(sdc, baseDir, swcFunc)
+}
+
+// newAPIConfigForRole returns apiConfig for sdc without checking sdc.Role.
+//
+// It is used for internal roles such as Prometheus Operator objects, which cannot be set in kubernetes_sd_configs.
+func newAPIConfigForRole(sdc *SDConfig, baseDir string, swcFunc ScrapeWorkConstructorFunc) (*apiConfig, error) {
 	cc := &sdc.HTTPClientConfig
 	ac, err := cc.NewConfig(baseDir)
 	if err != nil {
@@ -166,6 +166,29 @@ func TestGetAPIPathsWithNamespaces(t *testing.T) {
 		"/apis/networking.k8s.io/v1/namespaces/x/ingresses?labelSelector=cde%2Cbaaa&fieldSelector=abc",
 		"/apis/networking.k8s.io/v1/namespaces/y/ingresses?labelSelector=cde%2Cbaaa&fieldSelector=abc",
 	})
+
+	// Prometheus Operator objects
+	f("servicemonitor", nil, nil, []string{"/apis/monitoring.coreos.com/v1/servicemonitors"})
+	f("podmonitor", []string{"x"}, nil, []string{"/apis/monitoring.coreos.com/v1/namespaces/x/podmonitors"})
+	f("probe", nil, []Selector{
+		{
+			Role:  "probe",
+			Label: "team=infra",
+		},
+	}, []string{"/apis/monitoring.coreos.com/v1/probes?labelSelector=team%3Dinfra"})
+	f("scrapeconfig", []string{"x", "y"}, nil, []string{
+		"/apis/monitoring.coreos.com/v1alpha1/namespaces/x/scrapeconfigs",
+		"/apis/monitoring.coreos.com/v1alpha1/namespaces/y/scrapeconfigs",
+	})
+
+	// objects referred by Prometheus Operator objects
+	f("secret", []string{"x"}, nil, []string{"/api/v1/namespaces/x/secrets"})
+	f("configmap", nil, []Selector{
+		{
+			Role:  "servicemonitor",
+			Label: "foo",
+		},
+	}, []string{"/api/v1/configmaps"})
 }
 
 func TestParseBookmark(t *testing.T) {
@@ -0,0 +1,790 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// CRDConfig represents config for discovering Prometheus Operator objects such as ServiceMonitor, PodMonitor, Probe and ScrapeConfig.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/
+type CRDConfig struct {
+	APIServer string `yaml:"api_server,omitempty"`
+
+	// The filepath to kube config.
+	// If defined any cluster connection information from HTTPClientConfig is ignored.
+	KubeConfigFile string `yaml:"kubeconfig_file,omitempty"`
+
+	HTTPClientConfig promauth.HTTPClientConfig `yaml:",inline"`
+	ProxyURL         *proxy.URL                `yaml:"proxy_url,omitempty"`
+	Namespaces       Namespaces                `yaml:"namespaces,omitempty"`
+
+	// Selectors may contain selectors for servicemonitor, podmonitor, probe and scrapeconfig roles.
+	Selectors []Selector `yaml:"selectors,omitempty"`
+
+	// Kinds contains the list of object kinds to discover. All the supported kinds are discovered if it is empty.
+	//
+	// Supported kinds: ServiceMonitor, PodMonitor, Probe and ScrapeConfig.
+	Kinds []string `yaml:"kinds,omitempty"`
+
+	aws      []*apiWatcher
+	startErr error
+}
+
+// crdRoles contains internal roles for Prometheus Operator objects in the order of their processing.
+var crdRoles = []string{"servicemonitor", "podmonitor", "probe", "scrapeconfig"}
+
+// crdSecretRoles contains internal roles for objects, which may be referred by Prometheus Operator objects.
+//
+// These objects are watched in the same namespaces as Prometheus Operator objects,
+// so the referred values are read from the local cache instead of querying Kubernetes API server on every access.
+var crdSecretRoles = []string{"secret", "configmap"}
+
+func isCRDRole(role string) bool {
+	for _, r := range crdRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate validates cc.
+func (cc *CRDConfig) Validate() error {
+	_, err := cc.roles()
+	return err
+}
+
+func (cc *CRDConfig) roles() ([]string, error) {
+	if len(cc.Kinds) == 0 {
+		return crdRoles, nil
+	}
+	roles := make([]string, 0, len(cc.Kinds))
+	for _, kind := range cc.Kinds {
+		role := strings.ToLower(kind)
+		if !isCRDRole(role) {
+			return nil, fmt.Errorf("unexpected kind %q; supported kinds: ServiceMonitor, PodMonitor, Probe, ScrapeConfig", kind)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// MustStart starts watching for Prometheus Operator objects according to cc.
+func (cc *CRDConfig) MustStart(baseDir string) {
+	roles, err := cc.roles()
+	if err != nil {
+		cc.startErr = err
+		return
+	}
+	var aws []*apiWatcher
+	for _, role := range append(roles, crdSecretRoles...) {
+		selectors := cc.Selectors
+		if !isCRDRole(role) {
+			// Referred secrets and configmaps must be visible regardless of the selectors for Prometheus Operator objects.
+			selectors = nil
+		}
+		sdc := &SDConfig{
+			APIServer:        cc.APIServer,
+			Role:             role,
+			KubeConfigFile:   cc.KubeConfigFile,
+			HTTPClientConfig: cc.HTTPClientConfig,
+			ProxyURL:         cc.ProxyURL,
+			Namespaces:       cc.Namespaces,
+			Selectors:        selectors,
+		}
+		// Prometheus Operator objects do not generate scrape targets, so swcFunc isn't needed.
+		cfg, err := newAPIConfigForRole(sdc, baseDir, nil)
+		if err != nil {
+			for _, aw := range aws {
+				aw.mustStop()
+			}
+			cc.startErr = fmt.Errorf("cannot create API config for kubernetes: %w", err)
+			return
+		}
+		cfg.aw.mustStart()
+		aws = append(aws, cfg.aw)
+	}
+	cc.aws = aws
+}
+
+// MustStop stops watching for Prometheus Operator objects.
+func (cc *CRDConfig) MustStop() {
+	for _, aw := range cc.aws {
+		aw.mustStop()
+	}
+}
+
+// CRDObjects contains Prometheus Operator objects.
+type CRDObjects struct {
+	ServiceMonitors []*ServiceMonitor
+	PodMonitors     []*PodMonitor
+	Probes          []*Probe
+	ScrapeConfigs   []*ScrapeConfig
+}
+
+// GetObjects returns the current state of Prometheus Operator objects discovered by cc.
+//
+// This function must be called after MustStart call.
+func (cc *CRDConfig) GetObjects() (*CRDObjects, error) {
+	if cc.startErr != nil {
+		return nil, cc.startErr
+	}
+	var objs CRDObjects
+	for _, aw := range cc.aws {
+		for _, o := range aw.getObjects() {
+			switch t := o.(type) {
+			case *ServiceMonitor:
+				objs.ServiceMonitors = append(objs.ServiceMonitors, t)
+			case *PodMonitor:
+				objs.PodMonitors = append(objs.PodMonitors, t)
+			case *Probe:
+				objs.Probes = append(objs.Probes, t)
+			case *ScrapeConfig:
+				objs.ScrapeConfigs = append(objs.ScrapeConfigs, t)
+			}
+		}
+	}
+	return &objs, nil
+}
+
+// GetSecretValue returns the value for the given key from the Secret with the given namespace and name.
+//
+// The Secret is read from the local cache, which is kept up to date via Kubernetes watch API.
+func (cc *CRDConfig) GetSecretValue(namespace, name, key string) (string, error) {
+	o, err := cc.getObject("secret", namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if o == nil {
+		return "", fmt.Errorf("cannot find secret %s/%s", namespace, name)
+	}
+	secret := o.(*Secret)
+	v, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("missing key %q in secret %s/%s", key, namespace, name)
+	}
+	data, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode key %q in secret %s/%s: %w", key, namespace, name, err)
+	}
+	return string(data), nil
+}
+
+// GetConfigMapValue returns the value for the given key from the ConfigMap with the given namespace and name.
+//
+// The ConfigMap is read from the local cache, which is kept up to date via Kubernetes watch API.
+func (cc *CRDConfig) GetConfigMapValue(namespace, name, key string) (string, error) {
+	o, err := cc.getObject("configmap", namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if o == nil {
+		return "", fmt.Errorf("cannot find configmap %s/%s", namespace, name)
+	}
+	cm := o.(*ConfigMap)
+	v, ok := cm.Data[key]
+	if !ok {
+		return "", fmt.Errorf("missing key %q in configmap %s/%s", key, namespace, name)
+	}
+	return v, nil
+}
+
+// getObject returns the watched object with the given role, namespace and name.
+//
+// nil is returned if there is no such object.
+func (cc *CRDConfig) getObject(role, namespace, name string) (object, error) {
+	if cc.startErr != nil {
+		return nil, cc.startErr
+	}
+	for _, aw := range cc.aws {
+		if aw.role != role {
+			continue
+		}
+		gw := aw.gw
+		gw.mu.Lock()
+		o := gw.getObjectByRoleLocked(role, namespace, name)
+		gw.mu.Unlock()
+		return o, nil
+	}
+	return nil, fmt.Errorf("BUG: CRDConfig.MustStart must be called before reading %s objects", role)
+}
+
+// getObjects returns objects for aw.role, which are watched by aw.
+func (aw *apiWatcher) getObjects() []object {
+	gw := aw.gw
+	var keys []string
+	m := make(map[string]object)
+	gw.mu.Lock()
+	for _, uw := range gw.m {
+		if uw.role != aw.role {
+			continue
+		}
+		_, ok := uw.aws[aw]
+		_, okPending := uw.awsPending[aw]
+		if !ok && !okPending {
+			continue
+		}
+		for key, o := range uw.objectsByKey {
+			if _, ok := m[key]; !ok {
+				keys = append(keys, key)
+			}
+			m[key] = o
+		}
+	}
+	gw.mu.Unlock()
+
+	sort.Strings(keys)
+	objs := make([]object, len(keys))
+	for i, key := range keys {
+		objs[i] = m[key]
+	}
+	return objs
+}
+
+// LabelSelector represents label selector in k8s.
+//
+// See https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.30/#labelselector-v1-meta
+type LabelSelector struct {
+	MatchLabels      map[string]string
+	MatchExpressions []LabelSelectorRequirement
+}
+
+// LabelSelectorRequirement represents label selector requirement in k8s.
+//
+// See https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.30/#labelselectorrequirement-v1-meta
+type LabelSelectorRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// NamespaceSelector represents namespace selector for Prometheus Operator objects.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.NamespaceSelector
+type NamespaceSelector struct {
+	Any        bool
+	MatchNames []string
+}
+
+// SecretKeySelector represents a reference to the key in Secret or ConfigMap.
+type SecretKeySelector struct {
+	Name string
+	Key  string
+}
+
+// SecretOrConfigMap represents a reference to the key in Secret or ConfigMap.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.SecretOrConfigMap
+type SecretOrConfigMap struct {
+	Secret    *SecretKeySelector
+	ConfigMap *SecretKeySelector
+}
+
+// SafeTLSConfig represents TLS config for Prometheus Operator objects.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.TLSConfig
+type SafeTLSConfig struct {
+	CA                 SecretOrConfigMap
+	Cert               SecretOrConfigMap
+	KeySecret          *SecretKeySelector
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// BasicAuth represents basic auth config for Prometheus Operator objects.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.BasicAuth
+type BasicAuth struct {
+	Username SecretKeySelector
+	Password SecretKeySelector
+}
+
+// SafeAuthorization represents authorization config for Prometheus Operator objects.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.SafeAuthorization
+type SafeAuthorization struct {
+	Type        string
+	Credentials *SecretKeySelector
+}
+
+// RelabelConfig represents relabeling config for Prometheus Operator objects.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.RelabelConfig
+type RelabelConfig struct {
+	SourceLabels []string
+	Separator    *string
+	TargetLabel  string
+	Regex        string
+	Modulus      uint64
+	Replacement  *string
+	Action       string
+}
+
+// IntOrString represents a value, which can be either integer or string.
+type IntOrString string
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (is *IntOrString) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*is = IntOrString(strconv.Itoa(n))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("cannot parse %q as integer or string: %w", data, err)
+	}
+	*is = IntOrString(s)
+	return nil
+}
+
+// IsInt returns true if is contains integer value.
+func (is IntOrString) IsInt() bool {
+	_, err := strconv.Atoi(string(is))
+	return err == nil
+}
+
+// AttachMetadataCRD represents attachMetadata option for Prometheus Operator objects.
+type AttachMetadataCRD struct {
+	Node bool
+}
+
+// MonitorEndpoint represents scrape endpoint for ServiceMonitor and PodMonitor.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.Endpoint
+// and https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.PodMetricsEndpoint
+type MonitorEndpoint struct {
+	Port                 string
+	TargetPort           *IntOrString
+	Path                 string
+	Scheme               string
+	Params               map[string][]string
+	Interval             string
+	ScrapeTimeout        string
+	HonorLabels          bool
+	HonorTimestamps      *bool
+	FollowRedirects      *bool
+	ProxyURL             string `json:"proxyUrl"`
+	BearerTokenFile      string
+	BearerTokenSecret    *SecretKeySelector
+	BasicAuth            *BasicAuth
+	Authorization        *SafeAuthorization
+	TLSConfig            *SafeTLSConfig
+	RelabelConfigs       []RelabelConfig `json:"relabelings"`
+	MetricRelabelConfigs []RelabelConfig `json:"metricRelabelings"`
+}
+
+// ServiceMonitor represents ServiceMonitor object from Prometheus Operator.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ServiceMonitor
+type ServiceMonitor struct {
+	Metadata ObjectMeta
+	Spec     ServiceMonitorSpec
+}
+
+// ServiceMonitorSpec represents ServiceMonitor spec.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ServiceMonitorSpec
+type ServiceMonitorSpec struct {
+	JobLabel          string
+	TargetLabels      []string
+	PodTargetLabels   []string
+	Endpoints         []MonitorEndpoint
+	Selector          LabelSelector
+	NamespaceSelector NamespaceSelector
+	SampleLimit       int
+	AttachMetadata    *AttachMetadataCRD
+}
+
+// PodMonitor represents PodMonitor object from Prometheus Operator.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.PodMonitor
+type PodMonitor struct {
+	Metadata ObjectMeta
+	Spec     PodMonitorSpec
+}
+
+// PodMonitorSpec represents PodMonitor spec.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.PodMonitorSpec
+type PodMonitorSpec struct {
+	JobLabel            string
+	PodTargetLabels     []string
+	PodMetricsEndpoints []MonitorEndpoint
+	Selector            LabelSelector
+	NamespaceSelector   NamespaceSelector
+	SampleLimit         int
+	AttachMetadata      *AttachMetadataCRD
+}
+
+// Probe represents Probe object from Prometheus Operator.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.Probe
+type Probe struct {
+	Metadata ObjectMeta
+	Spec     ProbeSpec
+}
+
+// ProbeSpec represents Probe spec.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ProbeSpec
+type ProbeSpec struct {
+	JobName              string
+	Prober               ProberSpec
+	Module               string
+	Targets              ProbeTargets
+	Interval             string
+	ScrapeTimeout        string
+	BearerTokenSecret    *SecretKeySelector
+	BasicAuth            *BasicAuth
+	Authorization        *SafeAuthorization
+	TLSConfig            *SafeTLSConfig
+	MetricRelabelConfigs []RelabelConfig `json:"metricRelabelings"`
+	SampleLimit          int
+}
+
+// ProberSpec represents prober spec for Probe.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ProberSpec
+type ProberSpec struct {
+	URL      string
+	Scheme   string
+	Path     string
+	ProxyURL string `json:"proxyUrl"`
+}
+
+// ProbeTargets represents targets for Probe.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ProbeTargets
+type ProbeTargets struct {
+	StaticConfig *ProbeTargetStaticConfig
+	Ingress      *ProbeTargetIngress
+}
+
+// ProbeTargetStaticConfig represents static targets for Probe.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ProbeTargetStaticConfig
+type ProbeTargetStaticConfig struct {
+	Targets        []string `json:"static"`
+	Labels         map[string]string
+	RelabelConfigs []RelabelConfig `json:"relabelingConfigs"`
+}
+
+// ProbeTargetIngress represents ingress targets for Probe.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.ProbeTargetIngress
+type ProbeTargetIngress struct {
+	Selector          LabelSelector
+	NamespaceSelector NamespaceSelector
+	RelabelConfigs    []RelabelConfig `json:"relabelingConfigs"`
+}
+
+// ScrapeConfig represents ScrapeConfig object from Prometheus Operator.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1alpha1.ScrapeConfig
+type ScrapeConfig struct {
+	Metadata ObjectMeta
+	Spec     ScrapeConfigSpec
+}
+
+// ScrapeConfigSpec represents ScrapeConfig spec.
+//
+// See https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1alpha1.ScrapeConfigSpec
+type ScrapeConfigSpec struct {
+	JobName              string
+	StaticConfigs        []StaticConfigCRD
+	FileSDConfigs        []FileSDConfigCRD
+	HTTPSDConfigs        []HTTPSDConfigCRD
+	KubernetesSDConfigs  []KubernetesSDConfigCRD
+	RelabelConfigs       []RelabelConfig `json:"relabelings"`
+	MetricsPath          string
+	ScrapeInterval       string
+	ScrapeTimeout        string
+	HonorTimestamps      *bool
+	HonorLabels          bool
+	Params               map[string][]string
+	Scheme               string
+	BasicAuth            *BasicAuth
+	Authorization        *SafeAuthorization
+	TLSConfig            *SafeTLSConfig
+	SampleLimit          int
+	MetricRelabelConfigs []RelabelConfig `json:"metricRelabelings"`
+}
+
+// StaticConfigCRD represents static config for ScrapeConfig.
+type StaticConfigCRD struct {
+	Targets []string
+	Labels  map[string]string
+}
+
+// FileSDConfigCRD represents file-based service discovery config for ScrapeConfig.
+type FileSDConfigCRD struct {
+	Files []string
+}
+
+// HTTPSDConfigCRD represents http-based service discovery config for ScrapeConfig.
+type HTTPSDConfigCRD struct {
+	URL string
+}
+
+// KubernetesSDConfigCRD represents kubernetes-based service discovery config for ScrapeConfig.
+type KubernetesSDConfigCRD struct {
+	Role       string
+	Namespaces *NamespaceDiscoveryCRD
+	Selectors  []Selector
+}
+
+// NamespaceDiscoveryCRD represents namespaces for kubernetes-based service discovery config for ScrapeConfig.
+type NamespaceDiscoveryCRD struct {
+	OwnNamespace bool
+	Names        []string
+}
+
+func (sm *ServiceMonitor) key() string {
+	return sm.Metadata.key()
+}
+
+func (pm *PodMonitor) key() string {
+	return pm.Metadata.key()
+}
+
+func (p *Probe) key() string {
+	return p.Metadata.key()
+}
+
+func (sc *ScrapeConfig) key() string {
+	return sc.Metadata.key()
+}
+
+// getTargetLabels returns nil, since Prometheus Operator objects do not represent scrape targets.
+func (sm *ServiceMonitor) getTargetLabels(_ *groupWatcher) []*promutil.Labels {
+	return nil
+}
+
+// getTargetLabels returns nil, since Prometheus Operator objects do not represent scrape targets.
+func (pm *PodMonitor) getTargetLabels(_ *groupWatcher) []*promutil.Labels {
+	return nil
+}
+
+// getTargetLabels returns nil, since Prometheus Operator objects do not represent scrape targets.
+func (p *Probe) getTargetLabels(_ *groupWatcher) []*promutil.Labels {
+	return nil
+}
+
+// getTargetLabels returns nil, since Prometheus Operator objects do not represent scrape targets.
+func (sc *ScrapeConfig) getTargetLabels(_ *groupWatcher) []*promutil.Labels {
+	return nil
+}
+
+func parseServiceMonitorList(r io.Reader) (map[string]object, ListMeta, error) {
+	var l ServiceMonitorList
+	d := json.NewDecoder(r)
+	if err := d.Decode(&l); err != nil {
+		return nil, l.Metadata, fmt.Errorf("cannot unmarshal ServiceMonitorList: %w", err)
+	}
+	objectsByKey := make(map[string]object)
+	for _, sm := range l.Items {
+		objectsByKey[sm.key()] = sm
+	}
+	return objectsByKey, l.Metadata, nil
+}
+
+func parseServiceMonitor(data []byte) (object, error) {
+	var sm ServiceMonitor
+	if err := json.Unmarshal(data, &sm); err != nil {
+		return nil, err
+	}
+	return &sm, nil
+}
+
+// ServiceMonitorList represents a list of ServiceMonitor objects.
+type ServiceMonitorList struct {
+	Metadata ListMeta
+	Items    []*ServiceMonitor
+}
+
+func parsePodMonitorList(r io.Reader) (map[string]object, ListMeta, error) {
+	var l PodMonitorList
+	d := json.NewDecoder(r)
+	if err := d.Decode(&l); err != nil {
+		return nil, l.Metadata, fmt.Errorf("cannot unmarshal PodMonitorList: %w", err)
+	}
+	objectsByKey := make(map[string]object)
+	for _, pm := range l.Items {
+		objectsByKey[pm.key()] = pm
+	}
+	return objectsByKey, l.Metadata, nil
+}
+
+func parsePodMonitor(data []byte) (object, error) {
+	var pm PodMonitor
+	if err := json.Unmarshal(data, &pm); err != nil {
+		return nil, err
+	}
+	return &pm, nil
+}
+
+// PodMonitorList represents a list of PodMonitor objects.
+type PodMonitorList struct {
+	Metadata ListMeta
+	Items    []*PodMonitor
+}
+
+func parseProbeList(r io.Reader) (map[string]object, ListMeta, error) {
+	var l ProbeList
+	d := json.NewDecoder(r)
+	if err := d.Decode(&l); err != nil {
+		return nil, l.Metadata, fmt.Errorf("cannot unmarshal ProbeList: %w", err)
+	}
+	objectsByKey := make(map[string]object)
+	for _, p := range l.Items {
+		objectsByKey[p.key()] = p
+	}
+	return objectsByKey, l.Metadata, nil
+}
+
+func parseProbe(data []byte) (object, error) {
+	var p Probe
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ProbeList represents a list of Probe objects.
+type ProbeList struct {
+	Metadata ListMeta
+	Items    []*Probe
+}
+
+func parseScrapeConfigList(r io.Reader) (map[string]object, ListMeta, error) {
+	var l ScrapeConfigList
+	d := json.NewDecoder(r)
+	if err := d.Decode(&l); err != nil {
+		return nil, l.Metadata, fmt.Errorf("cannot unmarshal ScrapeConfigList: %w", err)
+	}
+	objectsByKey := make(map[string]object)
+	for _, sc := range l.Items {
+		objectsByKey[sc.key()] = sc
+	}
+	return objectsByKey, l.Metadata, nil
+}
+
+func parseScrapeConfig(data []byte) (object, error) {
+	var sc ScrapeConfig
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// ScrapeConfigList represents a list of ScrapeConfig objects.
+type ScrapeConfigList struct {
+	Metadata ListMeta
+	Items    []*ScrapeConfig
+}
+
+// Secret represents Kubernetes Secret object.
+//
+// Only the fields needed for reading values referred by Prometheus Operator objects are parsed.
+//
+// See https://kubernetes.io/docs/reference/kubernetes-api/config-and-storage-resources/secret-v1/
+type Secret struct {
+	Metadata ObjectMeta
+
+	// Data contains base64-encoded values.
+	Data map[string]string
+}
+
+// SecretList represents a list of Secret objects.
+type SecretList struct {
+	Metadata ListMeta
+	Items    []*Secret
+}
+
+// ConfigMap represents Kubernetes ConfigMap object.
+//
+// See https://kubernetes.io/docs/reference/kubernetes-api/config-and-storage-resources/config-map-v1/
+type ConfigMap struct {
+	Metadata ObjectMeta
+	Data     map[string]string
+}
+
+// ConfigMapList represents a list of ConfigMap objects.
+type ConfigMapList struct {
+	Metadata ListMeta
+	Items    []*ConfigMap
+}
+
+func (s *Secret) key() string {
+	return s.Metadata.key()
+}
+
+func (cm *ConfigMap) key() string {
+	return cm.Metadata.key()
+}
+
+// getTargetLabels returns nil, since Secret objects do not represent scrape targets.
+func (s *Secret) getTargetLabels(_ *groupWatcher) []*promutil.Labels {
+	return nil
+}
+
+// getTargetLabels returns nil, since ConfigMap objects do not represent scrape targets.
+func (cm *ConfigMap) getTargetLabels(_ *groupWatcher) []*promutil.Labels {
+	return nil
+}
+
+func parseSecretList(r io.Reader) (map[string]object, ListMeta, error) {
+	var l SecretList
+	d := json.NewDecoder(r)
+	if err := d.Decode(&l); err != nil {
+		return nil, l.Metadata, fmt.Errorf("cannot unmarshal SecretList: %w", err)
+	}
+	objectsByKey := make(map[string]object)
+	for _, s := range l.Items {
+		objectsByKey[s.key()] = s
+	}
+	return objectsByKey, l.Metadata, nil
+}
+
+func parseSecret(data []byte) (object, error) {
+	var s Secret
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func parseConfigMapList(r io.Reader) (map[string]object, ListMeta, error) {
+	var l ConfigMapList
+	d := json.NewDecoder(r)
+	if err := d.Decode(&l); err != nil {
+		return nil, l.Metadata, fmt.Errorf("cannot unmarshal ConfigMapList: %w", err)
+	}
+	objectsByKey := make(map[string]object)
+	for _, cm := range l.Items {
+		objectsByKey[cm.key()] = cm
+	}
+	return objectsByKey, l.Metadata, nil
+}
+
+func parseConfigMap(data []byte) (object, error) {
+	var cm ConfigMap
+	if err := json.Unmarshal(data, &cm); err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
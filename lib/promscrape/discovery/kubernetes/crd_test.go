@@ -0,0 +1,211 @@
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestParseServiceMonitorList(t *testing.T) {
+	data := `{
+  "apiVersion": "monitoring.coreos.com/v1",
+  "kind": "ServiceMonitorList",
+  "metadata": {
+    "resourceVersion": "1234"
+  },
+  "items": [
+    {
+      "apiVersion": "monitoring.coreos.com/v1",
+      "kind": "ServiceMonitor",
+      "metadata": {
+        "name": "node-exporter",
+        "namespace": "monitoring",
+        "labels": {
+          "team": "infra"
+        }
+      },
+      "spec": {
+        "jobLabel": "app.kubernetes.io/name",
+        "selector": {
+          "matchLabels": {
+            "app.kubernetes.io/name": "node-exporter"
+          }
+        },
+        "namespaceSelector": {
+          "matchNames": ["monitoring", "kube-system"]
+        },
+        "endpoints": [
+          {
+            "port": "https",
+            "scheme": "https",
+            "interval": "15s",
+            "targetPort": 9100,
+            "relabelings": [
+              {
+                "action": "replace",
+                "sourceLabels": ["__meta_kubernetes_pod_node_name"],
+                "targetLabel": "instance"
+              }
+            ]
+          }
+        ]
+      }
+    }
+  ]
+}`
+	objectsByKey, meta, err := parseServiceMonitorList(bytes.NewBufferString(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if meta.ResourceVersion != "1234" {
+		t.Fatalf("unexpected resource version; got %q; want %q", meta.ResourceVersion, "1234")
+	}
+	if len(objectsByKey) != 1 {
+		t.Fatalf("unexpected number of objects; got %d; want 1", len(objectsByKey))
+	}
+	sm := objectsByKey["monitoring/node-exporter"].(*ServiceMonitor)
+	if sm.Spec.JobLabel != "app.kubernetes.io/name" {
+		t.Fatalf("unexpected jobLabel: %q", sm.Spec.JobLabel)
+	}
+	if sm.Spec.Selector.MatchLabels["app.kubernetes.io/name"] != "node-exporter" {
+		t.Fatalf("unexpected selector: %v", sm.Spec.Selector)
+	}
+	if len(sm.Spec.NamespaceSelector.MatchNames) != 2 {
+		t.Fatalf("unexpected namespaceSelector: %v", sm.Spec.NamespaceSelector)
+	}
+	if len(sm.Spec.Endpoints) != 1 {
+		t.Fatalf("unexpected number of endpoints; got %d; want 1", len(sm.Spec.Endpoints))
+	}
+	ep := sm.Spec.Endpoints[0]
+	if ep.Port != "https" || ep.Interval != "15s" || ep.TargetPort == nil || *ep.TargetPort != "9100" || !ep.TargetPort.IsInt() {
+		t.Fatalf("unexpected endpoint: %+v", ep)
+	}
+	if len(ep.RelabelConfigs) != 1 || ep.RelabelConfigs[0].TargetLabel != "instance" || ep.RelabelConfigs[0].SourceLabels[0] != "__meta_kubernetes_pod_node_name" {
+		t.Fatalf("unexpected relabelings: %+v", ep.RelabelConfigs)
+	}
+	if labels := sm.getTargetLabels(nil); labels != nil {
+		t.Fatalf("expecting nil target labels for ServiceMonitor; got %v", labels)
+	}
+}
+
+func TestCRDConfigValidate(t *testing.T) {
+	f := func(kinds []string, rolesExpected string, errExpected bool) {
+		t.Helper()
+
+		cc := &CRDConfig{
+			Kinds: kinds,
+		}
+		roles, err := cc.roles()
+		if errExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if s := fmt.Sprintf("%s", roles); s != rolesExpected {
+			t.Fatalf("unexpected roles; got %s; want %s", s, rolesExpected)
+		}
+	}
+
+	f(nil, "[servicemonitor podmonitor probe scrapeconfig]", false)
+	f([]string{"ServiceMonitor", "scrapeconfig"}, "[servicemonitor scrapeconfig]", false)
+	f([]string{"Pod"}, "", true)
+	f([]string{"PrometheusRule"}, "", true)
+}
+
+func TestCRDConfigGetSecretValue(t *testing.T) {
+	secrets, _, err := parseSecretList(bytes.NewBufferString(`{
+  "metadata": {"resourceVersion": "1"},
+  "items": [
+    {
+      "metadata": {"name": "auth", "namespace": "default"},
+      "data": {"password": "cGFzcw==", "broken": "%%%"}
+    }
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("cannot parse SecretList: %s", err)
+	}
+	configMaps, _, err := parseConfigMapList(bytes.NewBufferString(`{
+  "metadata": {"resourceVersion": "1"},
+  "items": [
+    {
+      "metadata": {"name": "ca", "namespace": "default"},
+      "data": {"ca.crt": "cert"}
+    }
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("cannot parse ConfigMapList: %s", err)
+	}
+	gw := &groupWatcher{
+		m: map[string]*urlWatcher{
+			"secrets": {
+				role:         "secret",
+				objectsByKey: secrets,
+			},
+			"configmaps": {
+				role:         "configmap",
+				objectsByKey: configMaps,
+			},
+		},
+	}
+	cc := &CRDConfig{
+		aws: []*apiWatcher{
+			{
+				role: "secret",
+				gw:   gw,
+			},
+			{
+				role: "configmap",
+				gw:   gw,
+			},
+		},
+	}
+
+	f := func(kind, namespace, name, key, valueExpected string, errExpected bool) {
+		t.Helper()
+
+		var v string
+		var err error
+		if kind == "secret" {
+			v, err = cc.GetSecretValue(namespace, name, key)
+		} else {
+			v, err = cc.GetConfigMapValue(namespace, name, key)
+		}
+		if errExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if v != valueExpected {
+			t.Fatalf("unexpected value; got %q; want %q", v, valueExpected)
+		}
+	}
+
+	// existing values
+	f("secret", "default", "auth", "password", "pass", false)
+	f("configmap", "default", "ca", "ca.crt", "cert", false)
+
+	// missing key
+	f("secret", "default", "auth", "username", "", true)
+	f("configmap", "default", "ca", "tls.crt", "", true)
+
+	// invalid base64 value
+	f("secret", "default", "auth", "broken", "", true)
+
+	// missing object
+	f("secret", "kube-system", "auth", "password", "", true)
+	f("configmap", "default", "auth", "password", "", true)
+
+	// MustStart wasn't called
+	cc = &CRDConfig{}
+	f("secret", "default", "auth", "password", "", true)
+}
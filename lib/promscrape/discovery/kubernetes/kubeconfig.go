@@ -15,6 +15,9 @@ import (
 // apiConfig contains config for API server
 type apiConfig struct {
 	aw *apiWatcher
+
+	// kw is set instead of aw for `role: kubelet`
+	kw *kubeletWatcher
 }
 
 // Config represent configuration file for kubernetes API server connection
@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+// kubeletWatcher discovers pods running at the node via kubelet `/pods` endpoint.
+//
+// Kubelet doesn't support watching for pod changes, so the list of pods is re-read
+// on every getScrapeWorkObjects call, e.g. every -promscrape.kubernetesSDCheckInterval.
+// This allows running vmagent as a DaemonSet without watching all the pods in the cluster via Kubernetes API server.
+type kubeletWatcher struct {
+	client *discoveryutil.Client
+
+	// namespaces contains optional namespaces to discover pods in.
+	// Pods from all the namespaces are discovered if namespaces is empty.
+	namespaces []string
+
+	// Constructor for creating ScrapeWork objects from labels
+	swcFunc ScrapeWorkConstructorFunc
+}
+
+// kubeletGroupWatcher is passed to Pod.getTargetLabels for pods obtained from kubelet.
+//
+// Kubelet doesn't provide node objects, so attach_metadata.node isn't supported for `role: kubelet`.
+var kubeletGroupWatcher = &groupWatcher{}
+
+func newKubeletWatcher(sdc *SDConfig, baseDir string, swcFunc ScrapeWorkConstructorFunc) (*kubeletWatcher, error) {
+	apiServer := sdc.APIServer
+	if len(apiServer) == 0 {
+		return nil, fmt.Errorf("`api_server` must contain kubelet address for `role: kubelet`; for example, `api_server: https://%%{NODE_IP}:10250`, " +
+			"where NODE_IP env var is set to `status.hostIP` via Kubernetes downward API")
+	}
+	if len(sdc.KubeConfigFile) > 0 {
+		return nil, fmt.Errorf("`kubeconfig_file` option isn't supported for `role: kubelet`")
+	}
+	if len(sdc.Selectors) > 0 {
+		return nil, fmt.Errorf("`selectors` option isn't supported for `role: kubelet`; use relabeling for filtering pods instead")
+	}
+	if !strings.Contains(apiServer, "://") {
+		apiServer = "https://" + apiServer
+	}
+	apiServer = strings.TrimRight(apiServer, "/")
+
+	cc := &sdc.HTTPClientConfig
+	opts := &promauth.Options{
+		BaseDir:         baseDir,
+		Authorization:   cc.Authorization,
+		BasicAuth:       cc.BasicAuth,
+		BearerToken:     cc.BearerToken.String(),
+		BearerTokenFile: cc.BearerTokenFile,
+		OAuth2:          cc.OAuth2,
+		TLSConfig:       cc.TLSConfig,
+		Headers:         cc.Headers,
+	}
+	if opts.Authorization == nil && opts.BasicAuth == nil && opts.BearerToken == "" && opts.BearerTokenFile == "" && opts.OAuth2 == nil {
+		// Authenticate at kubelet with the service account token by default.
+		// See https://kubernetes.io/docs/reference/access-authn-authz/kubelet-authn-authz/
+		opts.BearerTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	}
+	if opts.TLSConfig == nil {
+		opts.TLSConfig = &promauth.TLSConfig{
+			CAFile: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		}
+	}
+	ac, err := opts.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize auth config for kubelet: %w", err)
+	}
+	client, err := discoveryutil.NewClient(apiServer, ac, sdc.ProxyURL, nil, cc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	namespaces := sdc.Namespaces.Names
+	if len(namespaces) == 0 && sdc.Namespaces.OwnNamespace {
+		namespace, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err != nil {
+			client.Stop()
+			return nil, fmt.Errorf("cannot determine namespace for the current pod according to `own_namespace: true` option in kubernetes_sd_config: %w", err)
+		}
+		namespaces = []string{string(namespace)}
+	}
+	kw := &kubeletWatcher{
+		client:     client,
+		namespaces: namespaces,
+		swcFunc:    swcFunc,
+	}
+	return kw, nil
+}
+
+// getScrapeWorkObjects returns ScrapeWork objects for pods returned by kubelet.
+func (kw *kubeletWatcher) getScrapeWorkObjects() ([]any, error) {
+	data, err := kw.client.GetAPIResponse("/pods")
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain pods from kubelet: %w", err)
+	}
+	objectsByKey, _, err := parsePodList(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(objectsByKey))
+	for key, o := range objectsByKey {
+		p := o.(*Pod)
+		if len(kw.namespaces) > 0 && !slices.Contains(kw.namespaces, p.Metadata.Namespace) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	// Sort keys in order to return ScrapeWork objects in stable order.
+	slices.Sort(keys)
+
+	var swos []any
+	for _, key := range keys {
+		labelss := objectsByKey[key].getTargetLabels(kubeletGroupWatcher)
+		swos = append(swos, getScrapeWorkObjectsForLabels(kw.swcFunc, labelss)...)
+		putLabelssToPool(labelss)
+	}
+	return swos, nil
+}
+
+func (kw *kubeletWatcher) mustStop() {
+	kw.client.Stop()
+}
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func TestKubeletWatcherGetScrapeWorkObjects(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer foobar" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testPodsList))
+	}))
+	defer s.Close()
+
+	f := func(namespaces []string, expectedLabelss []*promutil.Labels) {
+		t.Helper()
+		sdc := &SDConfig{
+			APIServer: s.URL,
+			Role:      "kubelet",
+			HTTPClientConfig: promauth.HTTPClientConfig{
+				BearerToken: promauth.NewSecret("foobar"),
+				TLSConfig: &promauth.TLSConfig{
+					InsecureSkipVerify: true,
+				},
+			},
+			Namespaces: Namespaces{
+				Names: namespaces,
+			},
+		}
+		swcFunc := func(metaLabels *promutil.Labels) any {
+			labels := metaLabels.Clone()
+			labels.Sort()
+			return labels
+		}
+		sdc.MustStart("", swcFunc)
+		defer sdc.MustStop()
+
+		swos, err := sdc.GetScrapeWorkObjects()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var labelss []*promutil.Labels
+		for _, swo := range swos {
+			labelss = append(labelss, swo.(*promutil.Labels))
+		}
+		if !areEqualLabelss(labelss, expectedLabelss) {
+			t.Fatalf("unexpected labels:\ngot\n%v\nwant\n%v", labelss, expectedLabelss)
+		}
+	}
+
+	// The kubelet role must return the same labels as the pod role without attached node metadata
+	objectsByKey, _, err := parsePodList(bytes.NewBufferString(testPodsList))
+	if err != nil {
+		t.Fatalf("cannot parse pod list: %s", err)
+	}
+	var podLabelss []*promutil.Labels
+	for _, o := range objectsByKey {
+		for _, labels := range o.getTargetLabels(&groupWatcher{}) {
+			labels.Sort()
+			podLabelss = append(podLabelss, labels)
+		}
+	}
+	if len(podLabelss) == 0 {
+		t.Fatalf("expecting non-empty labels for the pod role")
+	}
+	f(nil, podLabelss)
+	f([]string{"kube-system"}, podLabelss)
+
+	// pods from other namespaces must be skipped
+	f([]string{"default"}, nil)
+}
+
+func TestKubeletWatcherFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		sdc.Role = "kubelet"
+		if _, err := newAPIConfig(sdc, "", nil); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// missing api_server
+	f(&SDConfig{})
+
+	// kubeconfig_file isn't supported
+	f(&SDConfig{
+		APIServer:      "https://127.0.0.1:10250",
+		KubeConfigFile: "testdata/good_kubeconfig",
+	})
+
+	// selectors aren't supported
+	f(&SDConfig{
+		APIServer: "https://127.0.0.1:10250",
+		Selectors: []Selector{{
+			Role:  "pod",
+			Label: "app=foo",
+		}},
+	})
+}
@@ -79,6 +79,9 @@ func (sdc *SDConfig) GetScrapeWorkObjects() ([]any, error) {
 	if sdc.cfg == nil {
 		return nil, sdc.startErr
 	}
+	if sdc.cfg.kw != nil {
+		return sdc.cfg.kw.getScrapeWorkObjects()
+	}
 	return sdc.cfg.aw.getScrapeWorkObjects(), nil
 }
 
@@ -91,7 +94,9 @@ func (sdc *SDConfig) MustStart(baseDir string, swcFunc ScrapeWorkConstructorFunc
 		sdc.startErr = fmt.Errorf("cannot create API config for kubernetes: %w", err)
 		return
 	}
-	cfg.aw.mustStart()
+	if cfg.aw != nil {
+		cfg.aw.mustStart()
+	}
 	sdc.cfg = cfg
 }
 
@@ -99,6 +104,10 @@ func (sdc *SDConfig) MustStart(baseDir string, swcFunc ScrapeWorkConstructorFunc
 func (sdc *SDConfig) MustStop() {
 	if sdc.cfg != nil {
 		// sdc.cfg can be nil on MustStart error.
-		sdc.cfg.aw.mustStop()
+		if sdc.cfg.kw != nil {
+			sdc.cfg.kw.mustStop()
+		} else {
+			sdc.cfg.aw.mustStop()
+		}
 	}
 }
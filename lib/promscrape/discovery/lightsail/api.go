@@ -0,0 +1,43 @@
+package lightsail
+
+import (
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/awsapi"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+type apiConfig struct {
+	awsConfig *awsapi.Config
+	endpoint  string
+	port      int
+}
+
+var configMap = discoveryutil.NewConfigMap()
+
+func getAPIConfig(sdc *SDConfig) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (any, error) { return newAPIConfig(sdc) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig) (*apiConfig, error) {
+	port := 80
+	if sdc.Port != nil {
+		port = *sdc.Port
+	}
+	stsEndpoint := sdc.STSEndpoint
+	if stsEndpoint == "" {
+		stsEndpoint = sdc.Endpoint
+	}
+	awsCfg, err := awsapi.NewConfig("", stsEndpoint, sdc.Region, sdc.RoleARN, sdc.AccessKey, sdc.SecretKey.String(), "lightsail")
+	if err != nil {
+		return nil, err
+	}
+	cfg := &apiConfig{
+		awsConfig: awsCfg,
+		endpoint:  sdc.Endpoint,
+		port:      port,
+	}
+	return cfg, nil
+}
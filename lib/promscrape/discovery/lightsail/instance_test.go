@@ -0,0 +1,116 @@
+package lightsail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func TestGetInstancesLabels(t *testing.T) {
+	pages := []string{
+		`{
+  "instances": [
+    {
+      "name": "web-1",
+      "supportCode": "123456789012/i-0aaa",
+      "blueprintId": "ubuntu_22_04",
+      "bundleId": "small_3_0",
+      "location": {"availabilityZone": "eu-west-1a", "regionName": "eu-west-1"},
+      "state": {"code": 16, "name": "running"},
+      "privateIpAddress": "172.26.1.10",
+      "publicIpAddress": "3.250.1.2",
+      "ipv6Addresses": ["2a05:d018::1", "2a05:d018::2"],
+      "tags": [{"key": "env", "value": "prod"}, {"key": "empty"}]
+    }
+  ],
+  "nextPageToken": "page2"
+}`,
+		`{
+  "instances": [
+    {
+      "name": "db-1",
+      "supportCode": "123456789012/i-0bbb",
+      "blueprintId": "debian_12",
+      "bundleId": "medium_3_0",
+      "location": {"availabilityZone": "eu-west-1b", "regionName": "eu-west-1"},
+      "state": {"code": 80, "name": "stopped"},
+      "privateIpAddress": "172.26.2.20"
+    },
+    {
+      "name": "pending-1",
+      "state": {"code": 0, "name": "pending"}
+    }
+  ]
+}`,
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "Lightsail_20161128.GetInstances" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req getInstancesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch req.PageToken {
+		case "":
+			w.Write([]byte(pages[0]))
+		case "page2":
+			w.Write([]byte(pages[1]))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer s.Close()
+
+	port := 9100
+	sdc := &SDConfig{
+		Region:    "eu-west-1",
+		Endpoint:  s.URL,
+		AccessKey: "foo",
+		SecretKey: promauth.NewSecret("bar"),
+		Port:      &port,
+	}
+	cfg, err := newAPIConfig(sdc)
+	if err != nil {
+		t.Fatalf("cannot create API config: %s", err)
+	}
+	labelss, err := getInstancesLabels(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabels := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                            "172.26.1.10:9100",
+			"__meta_lightsail_availability_zone":     "eu-west-1a",
+			"__meta_lightsail_blueprint_id":          "ubuntu_22_04",
+			"__meta_lightsail_bundle_id":             "small_3_0",
+			"__meta_lightsail_instance_name":         "web-1",
+			"__meta_lightsail_instance_state":        "running",
+			"__meta_lightsail_instance_support_code": "123456789012/i-0aaa",
+			"__meta_lightsail_ipv6_addresses":        ",2a05:d018::1,2a05:d018::2,",
+			"__meta_lightsail_private_ip":            "172.26.1.10",
+			"__meta_lightsail_public_ip":             "3.250.1.2",
+			"__meta_lightsail_region":                "eu-west-1",
+			"__meta_lightsail_tag_env":               "prod",
+		}),
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                            "172.26.2.20:9100",
+			"__meta_lightsail_availability_zone":     "eu-west-1b",
+			"__meta_lightsail_blueprint_id":          "debian_12",
+			"__meta_lightsail_bundle_id":             "medium_3_0",
+			"__meta_lightsail_instance_name":         "db-1",
+			"__meta_lightsail_instance_state":        "stopped",
+			"__meta_lightsail_instance_support_code": "123456789012/i-0bbb",
+			"__meta_lightsail_private_ip":            "172.26.2.20",
+			"__meta_lightsail_region":                "eu-west-1",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabels)
+}
@@ -0,0 +1,108 @@
+package linode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+var configMap = discoveryutil.NewConfigMap()
+
+type apiConfig struct {
+	client       *discoveryutil.Client
+	port         int
+	tagSeparator string
+
+	// filter is an optional value for X-Filter request header.
+	// See https://techdocs.akamai.com/linode-api/reference/filtering-and-sorting
+	filter string
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (any, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	hcc := sdc.HTTPClientConfig
+	if hcc.Authorization == nil && hcc.BearerToken == nil && hcc.BearerTokenFile == "" && hcc.OAuth2 == nil {
+		return nil, fmt.Errorf("missing `authorization` option with Linode API token")
+	}
+	ac, err := hcc.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	// See https://techdocs.akamai.com/linode-api/reference/api
+	apiServer := "https://api.linode.com"
+	client, err := discoveryutil.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC, &sdc.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	cfg := &apiConfig{
+		client:       client,
+		port:         80,
+		tagSeparator: ",",
+	}
+	if sdc.Port != nil {
+		cfg.port = *sdc.Port
+	}
+	if sdc.TagSeparator != nil {
+		cfg.tagSeparator = *sdc.TagSeparator
+	}
+	if sdc.Region != "" {
+		filter, err := json.Marshal(map[string]string{
+			"region": sdc.Region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal region filter: %w", err)
+		}
+		cfg.filter = string(filter)
+	}
+	return cfg, nil
+}
+
+// pageResponse is a common part of Linode API list responses.
+//
+// See https://techdocs.akamai.com/linode-api/reference/pagination
+type pageResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Page  int             `json:"page"`
+	Pages int             `json:"pages"`
+}
+
+// getAllPages calls f for the data from every page of the list response at the given path.
+func getAllPages(cfg *apiConfig, path, filter string, f func(data []byte) error) error {
+	modifyRequest := func(req *http.Request) {
+		if filter != "" {
+			req.Header.Set("X-Filter", filter)
+		}
+	}
+	page := 1
+	for {
+		data, err := cfg.client.GetAPIResponseWithReqParams(path+"?page_size=500&page="+strconv.Itoa(page), modifyRequest)
+		if err != nil {
+			return fmt.Errorf("cannot obtain data from %q: %w", path, err)
+		}
+		var pr pageResponse
+		if err := json.Unmarshal(data, &pr); err != nil {
+			return fmt.Errorf("cannot parse response from %q: %w", path, err)
+		}
+		if err := f(pr.Data); err != nil {
+			return fmt.Errorf("cannot parse response from %q: %w", path, err)
+		}
+		if pr.Page >= pr.Pages {
+			return nil
+		}
+		page++
+	}
+}
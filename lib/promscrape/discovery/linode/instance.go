@@ -0,0 +1,215 @@
+package linode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// Instance represents Linode instance.
+//
+// See https://techdocs.akamai.com/linode-api/reference/get-linode-instances
+type Instance struct {
+	ID         int            `json:"id"`
+	Label      string         `json:"label"`
+	Image      string         `json:"image"`
+	Region     string         `json:"region"`
+	Type       string         `json:"type"`
+	Status     string         `json:"status"`
+	Tags       []string       `json:"tags"`
+	Group      string         `json:"group"`
+	Hypervisor string         `json:"hypervisor"`
+	IPv4       []string       `json:"ipv4"`
+	IPv6       string         `json:"ipv6"`
+	Backups    InstanceBackup `json:"backups"`
+	Specs      InstanceSpecs  `json:"specs"`
+}
+
+// InstanceBackup represents backups info for Linode instance.
+type InstanceBackup struct {
+	Enabled bool `json:"enabled"`
+}
+
+// InstanceSpecs represents specs for Linode instance.
+type InstanceSpecs struct {
+	// Disk, Memory and Transfer are measured in megabytes.
+	Disk     int64 `json:"disk"`
+	Memory   int64 `json:"memory"`
+	VCPUs    int   `json:"vcpus"`
+	Transfer int64 `json:"transfer"`
+	GPUs     int   `json:"gpus"`
+}
+
+// IPAddress represents IP address from Linode networking API.
+//
+// See https://techdocs.akamai.com/linode-api/reference/get-ips
+type IPAddress struct {
+	Address  string `json:"address"`
+	Type     string `json:"type"`
+	Public   bool   `json:"public"`
+	RDNS     string `json:"rdns"`
+	LinodeID int    `json:"linode_id"`
+}
+
+// IPv6Range represents IPv6 range from Linode networking API.
+//
+// See https://techdocs.akamai.com/linode-api/reference/get-ipv6-ranges
+type IPv6Range struct {
+	Range       string `json:"range"`
+	Prefix      int    `json:"prefix"`
+	RouteTarget string `json:"route_target"`
+}
+
+func getInstanceLabels(cfg *apiConfig) ([]*promutil.Labels, error) {
+	instances, err := getInstances(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := getIPAddresses(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ipv6Ranges, err := getIPv6Ranges(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return appendInstanceLabels(nil, instances, ips, ipv6Ranges, cfg.port, cfg.tagSeparator), nil
+}
+
+func getInstances(cfg *apiConfig) ([]Instance, error) {
+	var instances []Instance
+	err := getAllPages(cfg, "/v4/linode/instances", cfg.filter, func(data []byte) error {
+		var is []Instance
+		if err := json.Unmarshal(data, &is); err != nil {
+			return err
+		}
+		instances = append(instances, is...)
+		return nil
+	})
+	return instances, err
+}
+
+func getIPAddresses(cfg *apiConfig) ([]IPAddress, error) {
+	var ips []IPAddress
+	err := getAllPages(cfg, "/v4/networking/ips", "", func(data []byte) error {
+		var a []IPAddress
+		if err := json.Unmarshal(data, &a); err != nil {
+			return err
+		}
+		ips = append(ips, a...)
+		return nil
+	})
+	return ips, err
+}
+
+func getIPv6Ranges(cfg *apiConfig) ([]IPv6Range, error) {
+	var ranges []IPv6Range
+	err := getAllPages(cfg, "/v4/networking/ipv6/ranges", "", func(data []byte) error {
+		var a []IPv6Range
+		if err := json.Unmarshal(data, &a); err != nil {
+			return err
+		}
+		ranges = append(ranges, a...)
+		return nil
+	})
+	return ranges, err
+}
+
+func appendInstanceLabels(ms []*promutil.Labels, instances []Instance, ips []IPAddress, ipv6Ranges []IPv6Range, port int, tagSeparator string) []*promutil.Labels {
+	ipsByLinodeID := make(map[int][]IPAddress)
+	for _, ip := range ips {
+		ipsByLinodeID[ip.LinodeID] = append(ipsByLinodeID[ip.LinodeID], ip)
+	}
+	for i := range instances {
+		instance := &instances[i]
+		if len(instance.IPv4) == 0 {
+			// Skip instances without IPv4 addresses, since they cannot be scraped.
+			continue
+		}
+		var privateIPv4, publicIPv4, publicIPv6 string
+		var privateIPv4RDNS, publicIPv4RDNS, publicIPv6RDNS string
+		var extraIPs, ranges []string
+		instanceIPs := ipsByLinodeID[instance.ID]
+		for _, addr := range instance.IPv4 {
+			for _, ip := range instanceIPs {
+				if ip.Address != addr {
+					continue
+				}
+				switch {
+				case ip.Public && publicIPv4 == "":
+					publicIPv4 = ip.Address
+					publicIPv4RDNS = ip.RDNS
+				case !ip.Public && privateIPv4 == "":
+					privateIPv4 = ip.Address
+					privateIPv4RDNS = ip.RDNS
+				default:
+					extraIPs = append(extraIPs, ip.Address)
+				}
+			}
+		}
+		if instance.IPv6 != "" {
+			slaac, _, _ := strings.Cut(instance.IPv6, "/")
+			for _, ip := range instanceIPs {
+				if ip.Address == slaac {
+					publicIPv6 = ip.Address
+					publicIPv6RDNS = ip.RDNS
+				}
+			}
+			for _, r := range ipv6Ranges {
+				if r.RouteTarget == slaac {
+					ranges = append(ranges, fmt.Sprintf("%s/%d", r.Range, r.Prefix))
+				}
+			}
+		}
+
+		m := promutil.NewLabels(32)
+		m.Add("__address__", discoveryutil.JoinHostPort(instance.IPv4[0], port))
+		m.Add("__meta_linode_instance_id", strconv.Itoa(instance.ID))
+		m.Add("__meta_linode_instance_label", instance.Label)
+		m.Add("__meta_linode_image", instance.Image)
+		m.Add("__meta_linode_private_ipv4", privateIPv4)
+		m.Add("__meta_linode_public_ipv4", publicIPv4)
+		m.Add("__meta_linode_public_ipv6", publicIPv6)
+		m.Add("__meta_linode_region", instance.Region)
+		m.Add("__meta_linode_type", instance.Type)
+		m.Add("__meta_linode_status", instance.Status)
+		m.Add("__meta_linode_group", instance.Group)
+		m.Add("__meta_linode_gpus", strconv.Itoa(instance.Specs.GPUs))
+		m.Add("__meta_linode_hypervisor", instance.Hypervisor)
+		backups := "disabled"
+		if instance.Backups.Enabled {
+			backups = "enabled"
+		}
+		m.Add("__meta_linode_backups", backups)
+		m.Add("__meta_linode_specs_disk_bytes", strconv.FormatInt(instance.Specs.Disk*megabyte, 10))
+		m.Add("__meta_linode_specs_memory_bytes", strconv.FormatInt(instance.Specs.Memory*megabyte, 10))
+		m.Add("__meta_linode_specs_vcpus", strconv.Itoa(instance.Specs.VCPUs))
+		m.Add("__meta_linode_specs_transfer_bytes", strconv.FormatInt(instance.Specs.Transfer*megabyte, 10))
+		if privateIPv4RDNS != "" {
+			m.Add("__meta_linode_private_ipv4_rdns", privateIPv4RDNS)
+		}
+		if publicIPv4RDNS != "" {
+			m.Add("__meta_linode_public_ipv4_rdns", publicIPv4RDNS)
+		}
+		if publicIPv6RDNS != "" {
+			m.Add("__meta_linode_public_ipv6_rdns", publicIPv6RDNS)
+		}
+		if len(instance.Tags) > 0 {
+			m.Add("__meta_linode_tags", tagSeparator+strings.Join(instance.Tags, tagSeparator)+tagSeparator)
+		}
+		if len(extraIPs) > 0 {
+			m.Add("__meta_linode_extra_ips", tagSeparator+strings.Join(extraIPs, tagSeparator)+tagSeparator)
+		}
+		if len(ranges) > 0 {
+			m.Add("__meta_linode_ipv6_ranges", tagSeparator+strings.Join(ranges, tagSeparator)+tagSeparator)
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+const megabyte = 1024 * 1024
@@ -0,0 +1,181 @@
+package linode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func TestGetInstanceLabels(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v4/linode/instances":
+			if filter := r.Header.Get("X-Filter"); filter != `{"region":"us-east"}` {
+				t.Errorf("unexpected X-Filter header: %q", filter)
+			}
+			if r.URL.Query().Get("page") == "1" {
+				w.Write([]byte(testInstancesPage1))
+			} else {
+				w.Write([]byte(testInstancesPage2))
+			}
+		case "/v4/networking/ips":
+			w.Write([]byte(testIPAddresses))
+		case "/v4/networking/ipv6/ranges":
+			w.Write([]byte(testIPv6Ranges))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	client, err := discoveryutil.NewClient(s.URL, nil, nil, nil, &promauth.HTTPClientConfig{})
+	if err != nil {
+		t.Fatalf("cannot create client: %s", err)
+	}
+	defer client.Stop()
+	cfg := &apiConfig{
+		client:       client,
+		port:         9100,
+		tagSeparator: ",",
+		filter:       `{"region":"us-east"}`,
+	}
+	labelss, err := getInstanceLabels(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabelss := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                        "45.33.82.151:9100",
+			"__meta_linode_instance_id":          "26838044",
+			"__meta_linode_instance_label":       "prometheus-linode-sd-exporter-1",
+			"__meta_linode_image":                "linode/arch",
+			"__meta_linode_private_ipv4":         "192.168.170.51",
+			"__meta_linode_public_ipv4":          "45.33.82.151",
+			"__meta_linode_public_ipv6":          "2600:3c03::f03c:92ff:fe1a:1382",
+			"__meta_linode_public_ipv4_rdns":     "li1028-151.members.linode.com",
+			"__meta_linode_region":               "us-east",
+			"__meta_linode_type":                 "g6-standard-2",
+			"__meta_linode_status":               "running",
+			"__meta_linode_group":                "",
+			"__meta_linode_gpus":                 "0",
+			"__meta_linode_hypervisor":           "kvm",
+			"__meta_linode_backups":              "disabled",
+			"__meta_linode_specs_disk_bytes":     "85899345920",
+			"__meta_linode_specs_memory_bytes":   "4294967296",
+			"__meta_linode_specs_vcpus":          "2",
+			"__meta_linode_specs_transfer_bytes": "4194304000",
+			"__meta_linode_tags":                 ",monitoring,",
+			"__meta_linode_extra_ips":            ",96.126.108.16,",
+			"__meta_linode_ipv6_ranges":          ",2600:3c03:e000:123::/64,",
+		}),
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                        "139.162.196.43:9100",
+			"__meta_linode_instance_id":          "26848419",
+			"__meta_linode_instance_label":       "prometheus-linode-sd-exporter-2",
+			"__meta_linode_image":                "linode/debian10",
+			"__meta_linode_private_ipv4":         "",
+			"__meta_linode_public_ipv4":          "139.162.196.43",
+			"__meta_linode_public_ipv6":          "2a01:7e01::f03c:92ff:fe1a:9976",
+			"__meta_linode_public_ipv4_rdns":     "li1359-43.members.linode.com",
+			"__meta_linode_region":               "us-east",
+			"__meta_linode_type":                 "g6-standard-2",
+			"__meta_linode_status":               "running",
+			"__meta_linode_group":                "",
+			"__meta_linode_gpus":                 "0",
+			"__meta_linode_hypervisor":           "kvm",
+			"__meta_linode_backups":              "enabled",
+			"__meta_linode_specs_disk_bytes":     "85899345920",
+			"__meta_linode_specs_memory_bytes":   "4294967296",
+			"__meta_linode_specs_vcpus":          "2",
+			"__meta_linode_specs_transfer_bytes": "4194304000",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+const testInstancesPage1 = `{
+  "data": [
+    {
+      "id": 26838044,
+      "label": "prometheus-linode-sd-exporter-1",
+      "group": "",
+      "status": "running",
+      "created": "2021-05-12T04:23:44",
+      "updated": "2021-05-12T04:23:44",
+      "type": "g6-standard-2",
+      "ipv4": ["45.33.82.151", "96.126.108.16", "192.168.170.51"],
+      "ipv6": "2600:3c03::f03c:92ff:fe1a:1382/128",
+      "image": "linode/arch",
+      "region": "us-east",
+      "specs": {"disk": 81920, "memory": 4096, "vcpus": 2, "gpus": 0, "transfer": 4000},
+      "alerts": {"cpu": 180, "network_in": 10, "network_out": 10, "transfer_quota": 80, "io": 10000},
+      "backups": {"enabled": false, "schedule": {"day": null, "window": null}, "last_successful": null},
+      "hypervisor": "kvm",
+      "watchdog_enabled": true,
+      "tags": ["monitoring"]
+    }
+  ],
+  "page": 1,
+  "pages": 2,
+  "results": 2
+}`
+
+const testInstancesPage2 = `{
+  "data": [
+    {
+      "id": 26848419,
+      "label": "prometheus-linode-sd-exporter-2",
+      "group": "",
+      "status": "running",
+      "type": "g6-standard-2",
+      "ipv4": ["139.162.196.43"],
+      "ipv6": "2a01:7e01::f03c:92ff:fe1a:9976/128",
+      "image": "linode/debian10",
+      "region": "us-east",
+      "specs": {"disk": 81920, "memory": 4096, "vcpus": 2, "gpus": 0, "transfer": 4000},
+      "backups": {"enabled": true},
+      "hypervisor": "kvm",
+      "tags": []
+    },
+    {
+      "id": 26848420,
+      "label": "instance-without-ipv4",
+      "status": "provisioning",
+      "type": "g6-nanode-1",
+      "ipv4": [],
+      "ipv6": null,
+      "region": "us-east",
+      "specs": {"disk": 25600, "memory": 1024, "vcpus": 1, "gpus": 0, "transfer": 1000}
+    }
+  ],
+  "page": 2,
+  "pages": 2,
+  "results": 3
+}`
+
+const testIPAddresses = `{
+  "data": [
+    {"address": "45.33.82.151", "gateway": "45.33.82.1", "subnet_mask": "255.255.255.0", "prefix": 24, "type": "ipv4", "public": true, "rdns": "li1028-151.members.linode.com", "linode_id": 26838044, "region": "us-east"},
+    {"address": "96.126.108.16", "gateway": "96.126.108.1", "subnet_mask": "255.255.255.0", "prefix": 24, "type": "ipv4", "public": true, "rdns": "li365-16.members.linode.com", "linode_id": 26838044, "region": "us-east"},
+    {"address": "192.168.170.51", "gateway": null, "subnet_mask": "255.255.128.0", "prefix": 17, "type": "ipv4", "public": false, "rdns": null, "linode_id": 26838044, "region": "us-east"},
+    {"address": "2600:3c03::f03c:92ff:fe1a:1382", "gateway": "fe80::1", "subnet_mask": "ffff:ffff:ffff:ffff::", "prefix": 64, "type": "ipv6", "public": true, "rdns": null, "linode_id": 26838044, "region": "us-east"},
+    {"address": "139.162.196.43", "gateway": "139.162.196.1", "subnet_mask": "255.255.255.0", "prefix": 24, "type": "ipv4", "public": true, "rdns": "li1359-43.members.linode.com", "linode_id": 26848419, "region": "us-east"},
+    {"address": "2a01:7e01::f03c:92ff:fe1a:9976", "gateway": "fe80::1", "subnet_mask": "ffff:ffff:ffff:ffff::", "prefix": 64, "type": "ipv6", "public": true, "rdns": null, "linode_id": 26848419, "region": "us-east"}
+  ],
+  "page": 1,
+  "pages": 1,
+  "results": 6
+}`
+
+const testIPv6Ranges = `{
+  "data": [
+    {"range": "2600:3c03:e000:123::", "prefix": 64, "region": "us-east", "route_target": "2600:3c03::f03c:92ff:fe1a:1382"}
+  ],
+  "page": 1,
+  "pages": 1,
+  "results": 1
+}`
@@ -0,0 +1,54 @@
+package linode
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.linodeSDCheckInterval", time.Minute, "Interval for checking for changes in Linode API. "+
+	"This works only if linode_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/victoriametrics/sd_configs/#linode_sd_configs for details")
+
+// SDConfig represents service discovery config for Linode.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#linode_sd_config
+type SDConfig struct {
+	// Region is an optional region to discover instances in.
+	Region string `yaml:"region,omitempty"`
+
+	// The port to scrape metrics from. Default 80.
+	Port *int `yaml:"port,omitempty"`
+
+	// TagSeparator is the string by which Linode instance tags are joined into the tag label. Default `,`.
+	TagSeparator *string `yaml:"tag_separator,omitempty"`
+
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+
+	// refresh_interval is obtained from `-promscrape.linodeSDCheckInterval` command-line option.
+}
+
+// GetLabels returns Linode instance labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutil.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	return getInstanceLabels(cfg)
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	v := configMap.Delete(sdc)
+	if v != nil {
+		cfg := v.(*apiConfig)
+		cfg.client.Stop()
+	}
+}
@@ -0,0 +1,138 @@
+package scaleway
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+var configMap = discoveryutil.NewConfigMap()
+
+type apiConfig struct {
+	client    *discoveryutil.Client
+	port      int
+	zone      string
+	secretKey string
+
+	projectID  string
+	nameFilter string
+	tagsFilter []string
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (any, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	switch sdc.Role {
+	case "instance", "baremetal":
+	default:
+		return nil, fmt.Errorf("unexpected role=%q; must be one of `instance` or `baremetal`", sdc.Role)
+	}
+	if sdc.ProjectID == "" {
+		return nil, fmt.Errorf("missing `project_id` option")
+	}
+	if sdc.AccessKey == "" {
+		return nil, fmt.Errorf("missing `access_key` option")
+	}
+	secretKey := sdc.SecretKey.String()
+	if sdc.SecretKeyFile != "" {
+		if secretKey != "" {
+			return nil, fmt.Errorf("`secret_key` and `secret_key_file` options cannot be set simultaneously")
+		}
+		path := fscore.GetFilepath(baseDir, sdc.SecretKeyFile)
+		s, err := fscore.ReadPasswordFromFileOrHTTP(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read `secret_key_file`: %w", err)
+		}
+		secretKey = s
+	}
+	if secretKey == "" {
+		return nil, fmt.Errorf("missing `secret_key` or `secret_key_file` option")
+	}
+
+	ac, err := sdc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	apiServer := sdc.APIURL
+	if apiServer == "" {
+		apiServer = "https://api.scaleway.com"
+	}
+	apiServer = strings.TrimSuffix(apiServer, "/")
+	client, err := discoveryutil.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC, &sdc.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+
+	cfg := &apiConfig{
+		client:    client,
+		port:      80,
+		zone:      sdc.Zone,
+		secretKey: secretKey,
+
+		projectID:  sdc.ProjectID,
+		nameFilter: sdc.NameFilter,
+		tagsFilter: sdc.TagsFilter,
+	}
+	if sdc.Port != nil {
+		cfg.port = *sdc.Port
+	}
+	if cfg.zone == "" {
+		cfg.zone = "fr-par-1"
+	}
+	return cfg, nil
+}
+
+// getAPIResponse returns response for the given path from Scaleway API.
+//
+// See https://www.scaleway.com/en/developers/api/#authentication
+func (cfg *apiConfig) getAPIResponse(path string) ([]byte, error) {
+	return cfg.client.GetAPIResponseWithReqParams(path, func(req *http.Request) {
+		req.Header.Set("X-Auth-Token", cfg.secretKey)
+	})
+}
+
+// getListQueryArgs returns query args for listing servers at the given page.
+func (cfg *apiConfig) getListQueryArgs(projectIDArg, pageSizeArg string, page int) string {
+	qa := url.Values{}
+	qa.Set(projectIDArg, cfg.projectID)
+	if cfg.nameFilter != "" {
+		qa.Set("name", cfg.nameFilter)
+	}
+	if len(cfg.tagsFilter) > 0 {
+		qa.Set("tags", strings.Join(cfg.tagsFilter, ","))
+	}
+	qa.Set(pageSizeArg, fmt.Sprintf("%d", pageSize))
+	qa.Set("page", fmt.Sprintf("%d", page))
+	return qa.Encode()
+}
+
+const pageSize = 100
+
+// getRegion returns region for the given zone.
+//
+// For example, the region for `fr-par-1` zone is `fr-par`.
+func getRegion(zone string) string {
+	n := strings.LastIndexByte(zone, '-')
+	if n < 0 {
+		return ""
+	}
+	return zone[:n]
+}
+
+func joinTags(tags []string) string {
+	return "," + strings.Join(tags, ",") + ","
+}
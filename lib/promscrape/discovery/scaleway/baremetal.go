@@ -0,0 +1,180 @@
+package scaleway
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// BaremetalServer represents Scaleway Elastic Metal server.
+//
+// See https://www.scaleway.com/en/developers/api/elastic-metal/#path-elastic-metal-servers-list-elastic-metal-servers
+type BaremetalServer struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	ProjectID string            `json:"project_id"`
+	Status    string            `json:"status"`
+	Zone      string            `json:"zone"`
+	Tags      []string          `json:"tags"`
+	OfferID   string            `json:"offer_id"`
+	Install   *BaremetalInstall `json:"install"`
+	IPs       []BaremetalIP     `json:"ips"`
+}
+
+// BaremetalInstall represents installation info for Scaleway Elastic Metal server.
+type BaremetalInstall struct {
+	OSID string `json:"os_id"`
+}
+
+// BaremetalIP represents IP address of Scaleway Elastic Metal server.
+type BaremetalIP struct {
+	Address string `json:"address"`
+	// Version is either `IPv4` or `IPv6`.
+	Version string `json:"version"`
+}
+
+// BaremetalOffer represents Scaleway Elastic Metal offer.
+type BaremetalOffer struct {
+	Name string `json:"name"`
+}
+
+// BaremetalOS represents Scaleway Elastic Metal operating system.
+type BaremetalOS struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type listBaremetalServersResponse struct {
+	Servers []BaremetalServer `json:"servers"`
+}
+
+func getBaremetalLabels(cfg *apiConfig) ([]*promutil.Labels, error) {
+	servers, err := getBaremetalServers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Offers and operating systems are shared among servers, so cache them during a single discovery round.
+	offers := make(map[string]*BaremetalOffer)
+	oses := make(map[string]*BaremetalOS)
+	var ms []*promutil.Labels
+	for i := range servers {
+		server := &servers[i]
+		offer := offers[server.OfferID]
+		if offer == nil && server.OfferID != "" {
+			offer, err = getBaremetalOffer(cfg, server.OfferID)
+			if err != nil {
+				return nil, err
+			}
+			offers[server.OfferID] = offer
+		}
+		var os *BaremetalOS
+		if server.Install != nil && server.Install.OSID != "" {
+			os = oses[server.Install.OSID]
+			if os == nil {
+				os, err = getBaremetalOS(cfg, server.Install.OSID)
+				if err != nil {
+					return nil, err
+				}
+				oses[server.Install.OSID] = os
+			}
+		}
+		ms = appendBaremetalLabels(ms, server, offer, os, cfg.port)
+	}
+	return ms, nil
+}
+
+func getBaremetalServers(cfg *apiConfig) ([]BaremetalServer, error) {
+	var servers []BaremetalServer
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/baremetal/v1/zones/%s/servers?%s", cfg.zone, cfg.getListQueryArgs("project_id", "page_size", page))
+		data, err := cfg.getAPIResponse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain baremetal servers: %w", err)
+		}
+		var resp listBaremetalServersResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("cannot parse baremetal servers response %q: %w", data, err)
+		}
+		servers = append(servers, resp.Servers...)
+		if len(resp.Servers) < pageSize {
+			return servers, nil
+		}
+	}
+}
+
+func getBaremetalOffer(cfg *apiConfig, offerID string) (*BaremetalOffer, error) {
+	data, err := cfg.getAPIResponse(fmt.Sprintf("/baremetal/v1/zones/%s/offers/%s", cfg.zone, offerID))
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain baremetal offer %q: %w", offerID, err)
+	}
+	var offer BaremetalOffer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, fmt.Errorf("cannot parse baremetal offer %q: %w", data, err)
+	}
+	return &offer, nil
+}
+
+func getBaremetalOS(cfg *apiConfig, osID string) (*BaremetalOS, error) {
+	data, err := cfg.getAPIResponse(fmt.Sprintf("/baremetal/v1/zones/%s/os/%s", cfg.zone, osID))
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain baremetal os %q: %w", osID, err)
+	}
+	var os BaremetalOS
+	if err := json.Unmarshal(data, &os); err != nil {
+		return nil, fmt.Errorf("cannot parse baremetal os %q: %w", data, err)
+	}
+	return &os, nil
+}
+
+func appendBaremetalLabels(ms []*promutil.Labels, server *BaremetalServer, offer *BaremetalOffer, os *BaremetalOS, port int) []*promutil.Labels {
+	m := promutil.NewLabels(16)
+	m.Add("__meta_scaleway_baremetal_id", server.ID)
+	m.Add("__meta_scaleway_baremetal_name", server.Name)
+	m.Add("__meta_scaleway_baremetal_project_id", server.ProjectID)
+	m.Add("__meta_scaleway_baremetal_status", server.Status)
+	m.Add("__meta_scaleway_baremetal_zone", server.Zone)
+	if offer != nil {
+		m.Add("__meta_scaleway_baremetal_type", offer.Name)
+	}
+	if os != nil {
+		m.Add("__meta_scaleway_baremetal_os_name", os.Name)
+		m.Add("__meta_scaleway_baremetal_os_version", os.Version)
+	}
+	if len(server.Tags) > 0 {
+		m.Add("__meta_scaleway_baremetal_tags", joinTags(server.Tags))
+	}
+
+	// Prefer public IPv4 address over IPv6 address for scraping.
+	var publicIPv4, publicIPv6 string
+	for _, ip := range server.IPs {
+		switch ip.Version {
+		case "IPv4":
+			if publicIPv4 == "" {
+				publicIPv4 = ip.Address
+			}
+		case "IPv6":
+			if publicIPv6 == "" {
+				publicIPv6 = ip.Address
+			}
+		}
+	}
+	addr := publicIPv4
+	if publicIPv4 != "" {
+		m.Add("__meta_scaleway_baremetal_public_ipv4", publicIPv4)
+	}
+	if publicIPv6 != "" {
+		m.Add("__meta_scaleway_baremetal_public_ipv6", publicIPv6)
+		if addr == "" {
+			addr = publicIPv6
+		}
+	}
+	if addr == "" {
+		// Skip servers without IP addresses, since they cannot be scraped.
+		return ms
+	}
+	m.Add("__address__", discoveryutil.JoinHostPort(addr, port))
+	return append(ms, m)
+}
@@ -0,0 +1,170 @@
+package scaleway
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// InstanceServer represents Scaleway instance server.
+//
+// See https://www.scaleway.com/en/developers/api/instance/#path-instances-list-all-instances
+type InstanceServer struct {
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Hostname       string                 `json:"hostname"`
+	Organization   string                 `json:"organization"`
+	Project        string                 `json:"project"`
+	CommercialType string                 `json:"commercial_type"`
+	BootType       string                 `json:"boot_type"`
+	State          string                 `json:"state"`
+	Zone           string                 `json:"zone"`
+	Tags           []string               `json:"tags"`
+	Image          *InstanceImage         `json:"image"`
+	Location       *InstanceLocation      `json:"location"`
+	SecurityGroup  *InstanceSecurityGroup `json:"security_group"`
+	PrivateIP      *string                `json:"private_ip"`
+	PublicIP       *InstanceIP            `json:"public_ip"`
+	PublicIPs      []InstanceIP           `json:"public_ips"`
+	IPv6           *InstanceIPv6          `json:"ipv6"`
+}
+
+// InstanceImage represents Scaleway instance image.
+type InstanceImage struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Arch string `json:"arch"`
+}
+
+// InstanceLocation represents Scaleway instance location.
+type InstanceLocation struct {
+	ClusterID    string `json:"cluster_id"`
+	HypervisorID string `json:"hypervisor_id"`
+	NodeID       string `json:"node_id"`
+}
+
+// InstanceSecurityGroup represents Scaleway instance security group.
+type InstanceSecurityGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// InstanceIP represents Scaleway instance public IP.
+type InstanceIP struct {
+	Address string `json:"address"`
+	// Family is either `inet` or `inet6`.
+	Family string `json:"family"`
+}
+
+// InstanceIPv6 represents Scaleway instance IPv6 address.
+type InstanceIPv6 struct {
+	Address string `json:"address"`
+}
+
+type listInstanceServersResponse struct {
+	Servers []InstanceServer `json:"servers"`
+}
+
+func getInstanceLabels(cfg *apiConfig) ([]*promutil.Labels, error) {
+	servers, err := getInstanceServers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return appendInstanceLabels(nil, servers, cfg.port), nil
+}
+
+func getInstanceServers(cfg *apiConfig) ([]InstanceServer, error) {
+	var servers []InstanceServer
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/instance/v1/zones/%s/servers?%s", cfg.zone, cfg.getListQueryArgs("project", "per_page", page))
+		data, err := cfg.getAPIResponse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain instance servers: %w", err)
+		}
+		var resp listInstanceServersResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("cannot parse instance servers response %q: %w", data, err)
+		}
+		servers = append(servers, resp.Servers...)
+		if len(resp.Servers) < pageSize {
+			return servers, nil
+		}
+	}
+}
+
+func appendInstanceLabels(ms []*promutil.Labels, servers []InstanceServer, port int) []*promutil.Labels {
+	for i := range servers {
+		server := &servers[i]
+
+		m := promutil.NewLabels(32)
+		m.Add("__meta_scaleway_instance_boot_type", server.BootType)
+		m.Add("__meta_scaleway_instance_hostname", server.Hostname)
+		m.Add("__meta_scaleway_instance_id", server.ID)
+		m.Add("__meta_scaleway_instance_name", server.Name)
+		m.Add("__meta_scaleway_instance_organization_id", server.Organization)
+		m.Add("__meta_scaleway_instance_project_id", server.Project)
+		m.Add("__meta_scaleway_instance_status", server.State)
+		m.Add("__meta_scaleway_instance_type", server.CommercialType)
+		m.Add("__meta_scaleway_instance_zone", server.Zone)
+		if region := getRegion(server.Zone); region != "" {
+			m.Add("__meta_scaleway_instance_region", region)
+		}
+		if image := server.Image; image != nil {
+			m.Add("__meta_scaleway_instance_image_arch", image.Arch)
+			m.Add("__meta_scaleway_instance_image_id", image.ID)
+			m.Add("__meta_scaleway_instance_image_name", image.Name)
+		}
+		if location := server.Location; location != nil {
+			m.Add("__meta_scaleway_instance_location_cluster_id", location.ClusterID)
+			m.Add("__meta_scaleway_instance_location_hypervisor_id", location.HypervisorID)
+			m.Add("__meta_scaleway_instance_location_node_id", location.NodeID)
+		}
+		if sg := server.SecurityGroup; sg != nil {
+			m.Add("__meta_scaleway_instance_security_group_id", sg.ID)
+			m.Add("__meta_scaleway_instance_security_group_name", sg.Name)
+		}
+		if len(server.Tags) > 0 {
+			m.Add("__meta_scaleway_instance_tags", joinTags(server.Tags))
+		}
+
+		var ipv4Addresses, ipv6Addresses []string
+		for _, ip := range server.PublicIPs {
+			switch ip.Family {
+			case "inet":
+				ipv4Addresses = append(ipv4Addresses, ip.Address)
+			case "inet6":
+				ipv6Addresses = append(ipv6Addresses, ip.Address)
+			}
+		}
+		if len(ipv4Addresses) > 0 {
+			m.Add("__meta_scaleway_instance_public_ipv4_addresses", joinTags(ipv4Addresses))
+		}
+		if len(ipv6Addresses) > 0 {
+			m.Add("__meta_scaleway_instance_public_ipv6_addresses", joinTags(ipv6Addresses))
+		}
+
+		// The address is selected in the following order: private IPv4, public IPv4, public IPv6.
+		var addr string
+		if server.IPv6 != nil && server.IPv6.Address != "" {
+			m.Add("__meta_scaleway_instance_public_ipv6", server.IPv6.Address)
+			addr = server.IPv6.Address
+		}
+		if server.PublicIP != nil && server.PublicIP.Address != "" {
+			m.Add("__meta_scaleway_instance_public_ipv4", server.PublicIP.Address)
+			addr = server.PublicIP.Address
+		}
+		if server.PrivateIP != nil && *server.PrivateIP != "" {
+			m.Add("__meta_scaleway_instance_private_ipv4", *server.PrivateIP)
+			addr = *server.PrivateIP
+		}
+		if addr == "" {
+			// Skip servers without IP addresses, since they cannot be scraped.
+			continue
+		}
+		m.Add("__address__", discoveryutil.JoinHostPort(addr, port))
+		ms = append(ms, m)
+	}
+	return ms
+}
@@ -0,0 +1,75 @@
+package scaleway
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.scalewaySDCheckInterval", time.Minute, "Interval for checking for changes in Scaleway API. "+
+	"This works only if scaleway_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/victoriametrics/sd_configs/#scaleway_sd_configs for details")
+
+// SDConfig represents service discovery config for Scaleway.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#scaleway_sd_config
+type SDConfig struct {
+	// Role must be either `instance` or `baremetal`.
+	Role string `yaml:"role"`
+
+	// The port to scrape metrics from. Default 80.
+	Port *int `yaml:"port,omitempty"`
+
+	// APIURL is an optional Scaleway API url. Default https://api.scaleway.com .
+	APIURL string `yaml:"api_url,omitempty"`
+
+	ProjectID     string           `yaml:"project_id"`
+	Zone          string           `yaml:"zone,omitempty"`
+	AccessKey     string           `yaml:"access_key"`
+	SecretKey     *promauth.Secret `yaml:"secret_key,omitempty"`
+	SecretKeyFile string           `yaml:"secret_key_file,omitempty"`
+
+	// NameFilter is an optional filter for instance names.
+	NameFilter string `yaml:"name_filter,omitempty"`
+
+	// TagsFilter is an optional filter for instance tags.
+	// Only instances with all the given tags are discovered.
+	TagsFilter []string `yaml:"tags_filter,omitempty"`
+
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+
+	// refresh_interval is obtained from `-promscrape.scalewaySDCheckInterval` command-line option.
+}
+
+// GetLabels returns Scaleway target labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutil.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	switch sdc.Role {
+	case "instance":
+		return getInstanceLabels(cfg)
+	case "baremetal":
+		return getBaremetalLabels(cfg)
+	default:
+		// The sdc.Role must be already verified by getAPIConfig().
+		panic(fmt.Errorf("BUG: unexpected role=%q; must be one of `instance` or `baremetal`", sdc.Role))
+	}
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	v := configMap.Delete(sdc)
+	if v != nil {
+		cfg := v.(*apiConfig)
+		cfg.client.Stop()
+	}
+}
@@ -0,0 +1,294 @@
+package scaleway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+func newMockScalewayServer(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get("X-Auth-Token"); token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request: %q", r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resp))
+	}))
+}
+
+func TestGetLabelsFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		if _, err := sdc.GetLabels(""); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// unsupported role
+	f(&SDConfig{
+		Role:      "foobar",
+		ProjectID: "project",
+		AccessKey: "access",
+		SecretKey: promauth.NewSecret("secret"),
+	})
+
+	// missing project_id
+	f(&SDConfig{
+		Role:      "instance",
+		AccessKey: "access",
+		SecretKey: promauth.NewSecret("secret"),
+	})
+
+	// missing secret_key
+	f(&SDConfig{
+		Role:      "instance",
+		ProjectID: "project",
+		AccessKey: "access",
+	})
+
+	// secret_key and secret_key_file are set simultaneously
+	f(&SDConfig{
+		Role:          "instance",
+		ProjectID:     "project",
+		AccessKey:     "access",
+		SecretKey:     promauth.NewSecret("secret"),
+		SecretKeyFile: "/path/to/secret",
+	})
+}
+
+func TestGetInstanceLabels(t *testing.T) {
+	s := newMockScalewayServer(t, map[string]string{
+		"/instance/v1/zones/fr-par-1/servers?page=1&per_page=100&project=11111111-1111-1111-1111-111111111111&tags=prometheus": testInstanceServers,
+	})
+	defer s.Close()
+
+	sdc := &SDConfig{
+		Role:       "instance",
+		APIURL:     s.URL,
+		ProjectID:  "11111111-1111-1111-1111-111111111111",
+		AccessKey:  "SCWXXXXXXXXXXXXXXXXX",
+		SecretKey:  promauth.NewSecret("secret"),
+		TagsFilter: []string{"prometheus"},
+	}
+	defer sdc.MustStop()
+	labelss, err := sdc.GetLabels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabelss := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                                     "10.70.60.57:80",
+			"__meta_scaleway_instance_boot_type":              "local",
+			"__meta_scaleway_instance_hostname":               "scw-nervous-shirley",
+			"__meta_scaleway_instance_id":                     "93c18a61-b681-49d0-a1cc-62b43883ae89",
+			"__meta_scaleway_instance_image_arch":             "x86_64",
+			"__meta_scaleway_instance_image_id":               "45a86b35-eca6-4055-9b34-ca69845da146",
+			"__meta_scaleway_instance_image_name":             "Ubuntu 20.04 Focal Fossa",
+			"__meta_scaleway_instance_location_cluster_id":    "40",
+			"__meta_scaleway_instance_location_hypervisor_id": "1601",
+			"__meta_scaleway_instance_location_node_id":       "29",
+			"__meta_scaleway_instance_name":                   "scw-nervous-shirley",
+			"__meta_scaleway_instance_organization_id":        "cb334986-b054-4725-9d3a-40850fdc6015",
+			"__meta_scaleway_instance_private_ipv4":           "10.70.60.57",
+			"__meta_scaleway_instance_project_id":             "11111111-1111-1111-1111-111111111111",
+			"__meta_scaleway_instance_public_ipv4":            "51.158.183.115",
+			"__meta_scaleway_instance_public_ipv4_addresses":  ",51.158.183.115,",
+			"__meta_scaleway_instance_public_ipv6":            "2001:bc8:630:1e1c::1",
+			"__meta_scaleway_instance_public_ipv6_addresses":  ",2001:bc8:630:1e1c::1,",
+			"__meta_scaleway_instance_region":                 "fr-par",
+			"__meta_scaleway_instance_security_group_id":      "984414da-9fc2-49c0-a925-fed6266fe092",
+			"__meta_scaleway_instance_security_group_name":    "Default security group",
+			"__meta_scaleway_instance_status":                 "running",
+			"__meta_scaleway_instance_tags":                   ",prometheus,node,",
+			"__meta_scaleway_instance_type":                   "DEV1-S",
+			"__meta_scaleway_instance_zone":                   "fr-par-1",
+		}),
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                                    "[2001:bc8:630:1e1c::2]:80",
+			"__meta_scaleway_instance_boot_type":             "local",
+			"__meta_scaleway_instance_hostname":              "scw-ipv6-only",
+			"__meta_scaleway_instance_id":                    "5b6198b4-c677-41b5-9c05-04557264ae1f",
+			"__meta_scaleway_instance_name":                  "scw-ipv6-only",
+			"__meta_scaleway_instance_organization_id":       "cb334986-b054-4725-9d3a-40850fdc6015",
+			"__meta_scaleway_instance_project_id":            "11111111-1111-1111-1111-111111111111",
+			"__meta_scaleway_instance_public_ipv6":           "2001:bc8:630:1e1c::2",
+			"__meta_scaleway_instance_public_ipv6_addresses": ",2001:bc8:630:1e1c::2,",
+			"__meta_scaleway_instance_region":                "fr-par",
+			"__meta_scaleway_instance_status":                "running",
+			"__meta_scaleway_instance_tags":                  ",prometheus,",
+			"__meta_scaleway_instance_type":                  "DEV1-S",
+			"__meta_scaleway_instance_zone":                  "fr-par-1",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+func TestGetBaremetalLabels(t *testing.T) {
+	s := newMockScalewayServer(t, map[string]string{
+		"/baremetal/v1/zones/fr-par-2/servers?page=1&page_size=100&project_id=11111111-1111-1111-1111-111111111111": testBaremetalServers,
+		"/baremetal/v1/zones/fr-par-2/offers/3ab0dc29-2fd4-486e-88bf-d08fbf49214b":                                  `{"id":"3ab0dc29-2fd4-486e-88bf-d08fbf49214b","name":"EM-A210R-HDD","stock":"available"}`,
+		"/baremetal/v1/zones/fr-par-2/os/96e5f0f2-d216-4de2-8a15-68730d877885":                                      `{"id":"96e5f0f2-d216-4de2-8a15-68730d877885","name":"Ubuntu","version":"20.04 LTS (Focal Fossa)"}`,
+	})
+	defer s.Close()
+
+	port := 9100
+	sdc := &SDConfig{
+		Role:      "baremetal",
+		APIURL:    s.URL,
+		Port:      &port,
+		Zone:      "fr-par-2",
+		ProjectID: "11111111-1111-1111-1111-111111111111",
+		AccessKey: "SCWXXXXXXXXXXXXXXXXX",
+		SecretKey: promauth.NewSecret("secret"),
+	}
+	defer sdc.MustStop()
+	labelss, err := sdc.GetLabels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabelss := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                           "51.159.4.121:9100",
+			"__meta_scaleway_baremetal_id":          "5ab0de76-7b02-4a5d-ab4b-ae1d6f0d0a55",
+			"__meta_scaleway_baremetal_name":        "scw-nervous-shirley",
+			"__meta_scaleway_baremetal_os_name":     "Ubuntu",
+			"__meta_scaleway_baremetal_os_version":  "20.04 LTS (Focal Fossa)",
+			"__meta_scaleway_baremetal_project_id":  "11111111-1111-1111-1111-111111111111",
+			"__meta_scaleway_baremetal_public_ipv4": "51.159.4.121",
+			"__meta_scaleway_baremetal_public_ipv6": "2001:bc8:1200:4::1",
+			"__meta_scaleway_baremetal_status":      "ready",
+			"__meta_scaleway_baremetal_tags":        ",prometheus,node,",
+			"__meta_scaleway_baremetal_type":        "EM-A210R-HDD",
+			"__meta_scaleway_baremetal_zone":        "fr-par-2",
+		}),
+	}
+	discoveryutil.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+const testInstanceServers = `{
+  "servers": [
+    {
+      "id": "93c18a61-b681-49d0-a1cc-62b43883ae89",
+      "name": "scw-nervous-shirley",
+      "arch": "x86_64",
+      "commercial_type": "DEV1-S",
+      "boot_type": "local",
+      "organization": "cb334986-b054-4725-9d3a-40850fdc6015",
+      "project": "11111111-1111-1111-1111-111111111111",
+      "hostname": "scw-nervous-shirley",
+      "image": {
+        "id": "45a86b35-eca6-4055-9b34-ca69845da146",
+        "name": "Ubuntu 20.04 Focal Fossa",
+        "arch": "x86_64"
+      },
+      "volumes": {},
+      "tags": ["prometheus", "node"],
+      "state": "running",
+      "private_ip": "10.70.60.57",
+      "public_ip": {
+        "id": "af7f8a8d-1bf8-4d2e-a8e1-0a5a1ee6c7b5",
+        "address": "51.158.183.115",
+        "dynamic": false,
+        "family": "inet"
+      },
+      "public_ips": [
+        {"id": "af7f8a8d-1bf8-4d2e-a8e1-0a5a1ee6c7b5", "address": "51.158.183.115", "family": "inet"},
+        {"id": "a8e1bf8a-1bf8-4d2e-a8e1-0a5a1ee6c7b5", "address": "2001:bc8:630:1e1c::1", "family": "inet6"}
+      ],
+      "ipv6": {
+        "address": "2001:bc8:630:1e1c::1",
+        "gateway": "2001:bc8:630:1e1c::",
+        "netmask": "64"
+      },
+      "location": {
+        "cluster_id": "40",
+        "hypervisor_id": "1601",
+        "node_id": "29",
+        "platform_id": "14",
+        "zone_id": "par1"
+      },
+      "security_group": {
+        "id": "984414da-9fc2-49c0-a925-fed6266fe092",
+        "name": "Default security group"
+      },
+      "zone": "fr-par-1"
+    },
+    {
+      "id": "5b6198b4-c677-41b5-9c05-04557264ae1f",
+      "name": "scw-ipv6-only",
+      "commercial_type": "DEV1-S",
+      "boot_type": "local",
+      "organization": "cb334986-b054-4725-9d3a-40850fdc6015",
+      "project": "11111111-1111-1111-1111-111111111111",
+      "hostname": "scw-ipv6-only",
+      "image": null,
+      "tags": ["prometheus"],
+      "state": "running",
+      "private_ip": null,
+      "public_ip": null,
+      "public_ips": [
+        {"id": "b8e1bf8a-1bf8-4d2e-a8e1-0a5a1ee6c7b5", "address": "2001:bc8:630:1e1c::2", "family": "inet6"}
+      ],
+      "ipv6": {
+        "address": "2001:bc8:630:1e1c::2"
+      },
+      "location": null,
+      "security_group": null,
+      "zone": "fr-par-1"
+    },
+    {
+      "id": "ed51a0e1-c9b5-4e2e-a3e0-9d3a0f8d0a3c",
+      "name": "scw-stopped",
+      "commercial_type": "DEV1-S",
+      "project": "11111111-1111-1111-1111-111111111111",
+      "tags": ["prometheus"],
+      "state": "stopped",
+      "private_ip": null,
+      "public_ip": null,
+      "public_ips": [],
+      "ipv6": null,
+      "zone": "fr-par-1"
+    }
+  ]
+}`
+
+const testBaremetalServers = `{
+  "total_count": 1,
+  "servers": [
+    {
+      "id": "5ab0de76-7b02-4a5d-ab4b-ae1d6f0d0a55",
+      "organization_id": "cb334986-b054-4725-9d3a-40850fdc6015",
+      "project_id": "11111111-1111-1111-1111-111111111111",
+      "name": "scw-nervous-shirley",
+      "description": "",
+      "updated_at": "2022-01-06T16:05:32.465529Z",
+      "created_at": "2022-01-05T13:51:07.074655Z",
+      "status": "ready",
+      "offer_id": "3ab0dc29-2fd4-486e-88bf-d08fbf49214b",
+      "offer_name": "EM-A210R-HDD",
+      "tags": ["prometheus", "node"],
+      "ips": [
+        {"id": "1f9e68a1-7d3c-4b5e-9d5a-6b7bd2e9b8c5", "address": "51.159.4.121", "reverse": "51-159-4-121.rev.poneytelecom.eu", "version": "IPv4"},
+        {"id": "9a6c8a7e-1c1e-4a5d-8a2e-5d8b7a6c1e2f", "address": "2001:bc8:1200:4::1", "reverse": "", "version": "IPv6"}
+      ],
+      "domain": "5ab0de76-7b02-4a5d-ab4b-ae1d6f0d0a55.fr-par-2.baremetal.scw.cloud",
+      "boot_type": "normal",
+      "zone": "fr-par-2",
+      "install": {
+        "os_id": "96e5f0f2-d216-4de2-8a15-68730d877885",
+        "hostname": "scw-nervous-shirley",
+        "status": "completed"
+      }
+    }
+  ]
+}`
@@ -0,0 +1,153 @@
+package stackit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs/fscore"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httputil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+var configMap = discoveryutil.NewConfigMap()
+
+type apiConfig struct {
+	client  *discoveryutil.Client
+	port    int
+	project string
+
+	// ks is set if access tokens must be obtained via STACKIT key flow.
+	ks *keyFlowTokenSource
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (any, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	if sdc.Project == "" {
+		return nil, fmt.Errorf("missing `project` option")
+	}
+	hcc := sdc.HTTPClientConfig
+	ac, err := hcc.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	ks, err := newKeyFlowTokenSourceFromConfig(sdc, baseDir, ac, proxyAC)
+	if err != nil {
+		return nil, err
+	}
+	hasHTTPAuth := hcc.Authorization != nil || hcc.BearerToken != nil || hcc.BearerTokenFile != "" || hcc.OAuth2 != nil
+	if ks == nil && !hasHTTPAuth {
+		return nil, fmt.Errorf("either `service_account_key`, `service_account_key_path` or `authorization` option must be set")
+	}
+	if ks != nil && hasHTTPAuth {
+		return nil, fmt.Errorf("`service_account_key` and `authorization` options cannot be set simultaneously")
+	}
+	apiServer := sdc.Endpoint
+	if apiServer == "" {
+		region := sdc.Region
+		if region == "" {
+			region = "eu01"
+		}
+		apiServer = fmt.Sprintf("https://iaas.api.%s.stackit.cloud", region)
+	}
+	apiServer = strings.TrimSuffix(apiServer, "/")
+	client, err := discoveryutil.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC, &sdc.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	cfg := &apiConfig{
+		client:  client,
+		port:    80,
+		project: sdc.Project,
+		ks:      ks,
+	}
+	if sdc.Port != nil {
+		cfg.port = *sdc.Port
+	}
+	return cfg, nil
+}
+
+func newKeyFlowTokenSourceFromConfig(sdc *SDConfig, baseDir string, ac, proxyAC *promauth.Config) (*keyFlowTokenSource, error) {
+	serviceAccountKey := sdc.ServiceAccountKey.String()
+	if sdc.ServiceAccountKeyPath != "" {
+		if serviceAccountKey != "" {
+			return nil, fmt.Errorf("`service_account_key` and `service_account_key_path` options cannot be set simultaneously")
+		}
+		path := fscore.GetFilepath(baseDir, sdc.ServiceAccountKeyPath)
+		data, err := fscore.ReadFileOrHTTP(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read `service_account_key_path`: %w", err)
+		}
+		serviceAccountKey = string(data)
+	}
+	if serviceAccountKey == "" {
+		if sdc.PrivateKeyPath != "" {
+			return nil, fmt.Errorf("`private_key_path` option requires `service_account_key` or `service_account_key_path` option")
+		}
+		return nil, nil
+	}
+	var privateKey []byte
+	if sdc.PrivateKeyPath != "" {
+		path := fscore.GetFilepath(baseDir, sdc.PrivateKeyPath)
+		data, err := fscore.ReadFileOrHTTP(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read `private_key_path`: %w", err)
+		}
+		privateKey = data
+	}
+	tokenURL := sdc.TokenURL
+	if tokenURL == "" {
+		tokenURL = "https://service-account.api.stackit.cloud/token"
+	}
+	// Use the same tls_config and proxy_url for token requests as for STACKIT API requests.
+	tr := httputil.NewTransport(false, "vm_promscrape_discovery_stackit")
+	if pu := sdc.ProxyURL.GetURL(); pu != nil {
+		tr.Proxy = http.ProxyURL(pu)
+	}
+	client := &http.Client{
+		Timeout:   discoveryutil.DefaultClientReadTimeout,
+		Transport: ac.NewRoundTripper(tr),
+	}
+	setProxyHeaders := func(_ *http.Request) error { return nil }
+	if proxyAC != nil {
+		setProxyHeaders = func(req *http.Request) error {
+			return sdc.ProxyURL.SetHeaders(proxyAC, req)
+		}
+	}
+	ks, err := newKeyFlowTokenSource(serviceAccountKey, privateKey, tokenURL, client, setProxyHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize STACKIT key flow: %w", err)
+	}
+	return ks, nil
+}
+
+// getAPIResponse returns response for the given path from STACKIT API.
+func (cfg *apiConfig) getAPIResponse(path string) ([]byte, error) {
+	if cfg.ks == nil {
+		return cfg.client.GetAPIResponse(path)
+	}
+	token, err := cfg.ks.getToken()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.client.GetAPIResponseWithReqParams(path, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+func getServersPath(project string) string {
+	return fmt.Sprintf("/v1/projects/%s/servers?details=true", url.PathEscape(project))
+}
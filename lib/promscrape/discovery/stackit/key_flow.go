@@ -0,0 +1,221 @@
+package stackit
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceAccountKey represents STACKIT service account key.
+//
+// See https://docs.stackit.cloud/stackit/en/service-account-keys-93945917.html
+type serviceAccountKey struct {
+	Credentials serviceAccountKeyCredentials `json:"credentials"`
+}
+
+type serviceAccountKeyCredentials struct {
+	Kid        string `json:"kid"`
+	Iss        string `json:"iss"`
+	Sub        string `json:"sub"`
+	Aud        string `json:"aud"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// keyFlowTokenSource obtains STACKIT access tokens via key flow.
+//
+// See https://docs.stackit.cloud/stackit/en/usage-of-the-service-account-keys-in-stackit-175112464.html
+type keyFlowTokenSource struct {
+	key        serviceAccountKeyCredentials
+	privateKey *rsa.PrivateKey
+	tokenURL   string
+
+	// client is used for requesting access tokens from tokenURL.
+	//
+	// It uses the same tls_config and proxy_url as the client for STACKIT API.
+	client *http.Client
+
+	// setProxyHeaders sets proxy auth headers for requests to tokenURL.
+	setProxyHeaders func(req *http.Request) error
+
+	// mu protects token and refreshDeadline
+	mu              sync.Mutex
+	token           string
+	refreshDeadline time.Time
+}
+
+// defaultTokenLifetime is the lifetime of access tokens obtained without `expires_in` field.
+const defaultTokenLifetime = 10 * time.Minute
+
+// minTokenLifetime is the minimum lifetime of access tokens.
+//
+// This prevents from requesting a new token per each API call if STACKIT returns too small `expires_in` value.
+const minTokenLifetime = 10 * time.Second
+
+func newKeyFlowTokenSource(serviceAccountKeyJSON string, privateKeyPEM []byte, tokenURL string, client *http.Client, setProxyHeaders func(req *http.Request) error) (*keyFlowTokenSource, error) {
+	var sak serviceAccountKey
+	if err := json.Unmarshal([]byte(serviceAccountKeyJSON), &sak); err != nil {
+		return nil, fmt.Errorf("cannot parse service account key: %w", err)
+	}
+	if len(privateKeyPEM) == 0 {
+		privateKeyPEM = []byte(sak.Credentials.PrivateKey)
+	}
+	if len(privateKeyPEM) == 0 {
+		return nil, fmt.Errorf("missing private key in service account key; set it via `private_key_path` option")
+	}
+	privateKey, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	ks := &keyFlowTokenSource{
+		key:             sak.Credentials,
+		privateKey:      privateKey,
+		tokenURL:        tokenURL,
+		client:          client,
+		setProxyHeaders: setProxyHeaders,
+	}
+	return ks, nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("cannot decode PEM block with private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected private key type %T; want RSA private key", key)
+	}
+	return rsaKey, nil
+}
+
+// getToken returns access token, which isn't going to expire soon.
+func (ks *keyFlowTokenSource) getToken() (string, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.token != "" && time.Now().Before(ks.refreshDeadline) {
+		return ks.token, nil
+	}
+	assertion, err := ks.newAssertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	token, lifetime, err := ks.requestToken(assertion)
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain STACKIT access token from %q: %w", ks.tokenURL, err)
+	}
+	ks.token = token
+	ks.refreshDeadline = time.Now().Add(getTokenRefreshInterval(lifetime))
+	return token, nil
+}
+
+// getTokenRefreshInterval returns the interval for refreshing access token with the given lifetime.
+//
+// The token is refreshed a minute before its expiration, or in the middle of its lifetime for short-lived tokens.
+func getTokenRefreshInterval(lifetime time.Duration) time.Duration {
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	if lifetime < minTokenLifetime {
+		lifetime = minTokenLifetime
+	}
+	return lifetime - min(time.Minute, lifetime/2)
+}
+
+// newAssertion returns self-signed JWT for the service account key.
+func (ks *keyFlowTokenSource) newAssertion(now time.Time) (string, error) {
+	var jti [16]byte
+	if _, err := rand.Read(jti[:]); err != nil {
+		return "", fmt.Errorf("cannot generate jti: %w", err)
+	}
+	header := map[string]string{
+		"alg": "RS512",
+		"typ": "JWT",
+		"kid": ks.key.Kid,
+	}
+	claims := map[string]any{
+		"iss": ks.key.Iss,
+		"sub": ks.key.Sub,
+		"aud": ks.key.Aud,
+		"jti": hex.EncodeToString(jti[:]),
+		"iat": now.Unix(),
+		"exp": now.Add(10 * time.Minute).Unix(),
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal JWT header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal JWT claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	hash := sha512.Sum512([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, ks.privateKey, crypto.SHA512, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("cannot sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// requestToken requests access token for the given assertion and returns it together with its lifetime.
+//
+// Zero lifetime is returned if the response doesn't contain `expires_in` field.
+func (ks *keyFlowTokenSource) requestToken(assertion string) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequest(http.MethodPost, ks.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := ks.setProxyHeaders(req); err != nil {
+		return "", 0, fmt.Errorf("cannot set proxy auth headers: %w", err)
+	}
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("unexpected status code %d; response body: %q", resp.StatusCode, data)
+	}
+	var tr tokenResponse
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return "", 0, fmt.Errorf("cannot parse response %q: %w", data, err)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, fmt.Errorf("missing access_token in response %q", data)
+	}
+	return tr.AccessToken, time.Duration(tr.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,96 @@
+package stackit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// Server represents STACKIT server.
+//
+// See https://docs.api.stackit.cloud/documentation/iaas/version/v1#tag/Servers/operation/v1ListServersInProject
+type Server struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	Status           string         `json:"status"`
+	PowerStatus      string         `json:"powerStatus"`
+	AvailabilityZone string         `json:"availabilityZone"`
+	Labels           map[string]any `json:"labels"`
+	NICs             []ServerNIC    `json:"nics"`
+}
+
+// ServerNIC represents STACKIT server network interface.
+type ServerNIC struct {
+	IPv4        string `json:"ipv4"`
+	PublicIP    string `json:"publicIp"`
+	NetworkName string `json:"networkName"`
+}
+
+type listServersResponse struct {
+	Items []Server `json:"items"`
+}
+
+func getServerLabels(cfg *apiConfig) ([]*promutil.Labels, error) {
+	data, err := cfg.getAPIResponse(getServersPath(cfg.project))
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain servers: %w", err)
+	}
+	var resp listServersResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("cannot parse servers response %q: %w", data, err)
+	}
+	return appendServerLabels(nil, resp.Items, cfg.project, cfg.port), nil
+}
+
+func appendServerLabels(ms []*promutil.Labels, servers []Server, project string, port int) []*promutil.Labels {
+	for i := range servers {
+		server := &servers[i]
+
+		m := promutil.NewLabels(16)
+		m.Add("__meta_stackit_project", project)
+		m.Add("__meta_stackit_id", server.ID)
+		m.Add("__meta_stackit_name", server.Name)
+		m.Add("__meta_stackit_availability_zone", server.AvailabilityZone)
+		m.Add("__meta_stackit_status", server.Status)
+		m.Add("__meta_stackit_power_status", server.PowerStatus)
+
+		var addr, publicIP string
+		for _, nic := range server.NICs {
+			if nic.PublicIP != "" && publicIP == "" {
+				publicIP = nic.PublicIP
+				m.Add("__meta_stackit_public_ipv4", publicIP)
+			}
+			if nic.IPv4 == "" {
+				continue
+			}
+			if addr == "" {
+				addr = nic.IPv4
+			}
+			if nic.NetworkName != "" {
+				labelName := discoveryutil.SanitizeLabelName("__meta_stackit_private_ipv4_" + nic.NetworkName)
+				m.Add(labelName, nic.IPv4)
+			}
+		}
+		if addr == "" {
+			// Skip servers without private IPv4 address, since they cannot be scraped.
+			continue
+		}
+		m.Add("__address__", discoveryutil.JoinHostPort(addr, port))
+
+		for k, v := range server.Labels {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			labelName := discoveryutil.SanitizeLabelName("__meta_stackit_labelpresent_" + k)
+			m.Add(labelName, "true")
+
+			labelName = discoveryutil.SanitizeLabelName("__meta_stackit_label_" + k)
+			m.Add(labelName, s)
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
@@ -0,0 +1,73 @@
+package stackit
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.stackitSDCheckInterval", time.Minute, "Interval for checking for changes in STACKIT API. "+
+	"This works only if stackit_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/victoriametrics/sd_configs/#stackit_sd_configs for details")
+
+// SDConfig represents service discovery config for STACKIT.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#stackit_sd_config
+type SDConfig struct {
+	// Endpoint is an optional STACKIT IaaS API endpoint. Default https://iaas.api.<region>.stackit.cloud .
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	// Region is an optional STACKIT region. Default eu01.
+	Region string `yaml:"region,omitempty"`
+
+	// Project is the ID of STACKIT project to discover servers in.
+	Project string `yaml:"project"`
+
+	// ServiceAccountKey and ServiceAccountKeyPath contain STACKIT service account key in JSON format,
+	// which is used for obtaining access tokens via key flow.
+	// See https://docs.stackit.cloud/stackit/en/service-account-keys-93945917.html
+	ServiceAccountKey     *promauth.Secret `yaml:"service_account_key,omitempty"`
+	ServiceAccountKeyPath string           `yaml:"service_account_key_path,omitempty"`
+
+	// PrivateKeyPath is an optional path to RSA private key for the service account key.
+	// It must be set if the service account key doesn't contain the private key.
+	PrivateKeyPath string `yaml:"private_key_path,omitempty"`
+
+	// TokenURL is an optional url for obtaining access tokens. Default https://service-account.api.stackit.cloud/token .
+	TokenURL string `yaml:"token_url,omitempty"`
+
+	// The port to scrape metrics from. Default 80.
+	Port *int `yaml:"port,omitempty"`
+
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+
+	// refresh_interval is obtained from `-promscrape.stackitSDCheckInterval` command-line option.
+}
+
+// GetLabels returns STACKIT server labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutil.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	return getServerLabels(cfg)
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	v := configMap.Delete(sdc)
+	if v != nil {
+		cfg := v.(*apiConfig)
+		cfg.client.Stop()
+		if cfg.ks != nil {
+			cfg.ks.client.CloseIdleConnections()
+		}
+	}
+}
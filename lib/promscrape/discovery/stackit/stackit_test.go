@@ -0,0 +1,239 @@
+package stackit
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+func TestNewAPIConfigFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		if _, err := newAPIConfig(sdc, ""); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// missing project
+	f(&SDConfig{
+		HTTPClientConfig: promauth.HTTPClientConfig{
+			BearerToken: promauth.NewSecret("token"),
+		},
+	})
+
+	// missing auth
+	f(&SDConfig{
+		Project: "project",
+	})
+
+	// invalid service account key
+	f(&SDConfig{
+		Project:           "project",
+		ServiceAccountKey: promauth.NewSecret("foobar"),
+	})
+
+	// missing private key
+	f(&SDConfig{
+		Project:           "project",
+		ServiceAccountKey: promauth.NewSecret(`{"credentials":{"kid":"foo"}}`),
+	})
+
+	// private_key_path without service account key
+	f(&SDConfig{
+		Project:        "project",
+		PrivateKeyPath: "/path/to/key",
+		HTTPClientConfig: promauth.HTTPClientConfig{
+			BearerToken: promauth.NewSecret("token"),
+		},
+	})
+}
+
+func TestGetServerLabels(t *testing.T) {
+	testGetServerLabels(t, false)
+}
+
+func TestGetServerLabelsViaProxy(t *testing.T) {
+	// Both API and token requests must be sent via proxy_url.
+	testGetServerLabels(t, true)
+}
+
+func testGetServerLabels(t *testing.T, useProxy bool) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate private key: %s", err)
+	}
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	sak, err := json.Marshal(map[string]any{
+		"id": "f4b7c3c4-0d1a-4b38-9fbd-3b3a6f7c0b5a",
+		"credentials": map[string]string{
+			"kid":        "key-id",
+			"iss":        "sa@sa.stackit.cloud",
+			"sub":        "2d2e6d5f-4f0f-4d4f-8f6c-4e1c7d1e0b2a",
+			"aud":        "https://stackit-service-account-prod.apps.01.cf.eu01.stackit.cloud",
+			"privateKey": string(privateKeyPEM),
+		},
+	})
+	if err != nil {
+		t.Fatalf("cannot marshal service account key: %s", err)
+	}
+
+	tokenRequests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("cannot parse token request: %s", err)
+			}
+			if grantType := r.PostForm.Get("grant_type"); grantType != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+				t.Errorf("unexpected grant_type: %q", grantType)
+			}
+			verifyAssertion(t, r.PostForm.Get("assertion"), &privateKey.PublicKey)
+			w.Write([]byte(`{"access_token":"access-token","expires_in":3600,"token_type":"Bearer"}`))
+		case "/v1/projects/00000000-0000-0000-0000-000000000000/servers":
+			if auth := r.Header.Get("Authorization"); auth != "Bearer access-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("details") != "true" {
+				t.Errorf("missing details=true query arg")
+			}
+			w.Write([]byte(testServers))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	sdc := &SDConfig{
+		Endpoint:          s.URL,
+		TokenURL:          s.URL + "/token",
+		Project:           "00000000-0000-0000-0000-000000000000",
+		ServiceAccountKey: promauth.NewSecret(string(sak)),
+	}
+	if useProxy {
+		sdc.Endpoint = "http://stackit-api.test"
+		sdc.TokenURL = "http://stackit-token.test/token"
+		sdc.ProxyURL = proxy.MustNewURL(s.URL)
+	}
+	defer sdc.MustStop()
+
+	expectedLabelss := []*promutil.Labels{
+		promutil.NewLabelsFromMap(map[string]string{
+			"__address__":                          "10.0.0.2:80",
+			"__meta_stackit_availability_zone":     "eu01-3",
+			"__meta_stackit_id":                    "1af3a9bd-8e0a-4a5c-8a40-4c0b9b2d4f2c",
+			"__meta_stackit_label_env":             "prod",
+			"__meta_stackit_labelpresent_env":      "true",
+			"__meta_stackit_name":                  "server-1",
+			"__meta_stackit_power_status":          "RUNNING",
+			"__meta_stackit_private_ipv4_internal": "10.0.0.2",
+			"__meta_stackit_project":               "00000000-0000-0000-0000-000000000000",
+			"__meta_stackit_public_ipv4":           "193.148.164.10",
+			"__meta_stackit_status":                "ACTIVE",
+		}),
+	}
+	for i := 0; i < 2; i++ {
+		labelss, err := sdc.GetLabels("")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		discoveryutil.TestEqualLabelss(t, labelss, expectedLabelss)
+	}
+	// The access token must be cached until its expiration.
+	if tokenRequests != 1 {
+		t.Fatalf("unexpected number of token requests; got %d; want 1", tokenRequests)
+	}
+}
+
+func TestGetTokenRefreshInterval(t *testing.T) {
+	f := func(lifetime, intervalExpected time.Duration) {
+		t.Helper()
+		if interval := getTokenRefreshInterval(lifetime); interval != intervalExpected {
+			t.Fatalf("unexpected refresh interval for lifetime=%s; got %s; want %s", lifetime, interval, intervalExpected)
+		}
+	}
+
+	// missing expires_in
+	f(0, 9*time.Minute)
+	f(-time.Second, 9*time.Minute)
+
+	// too small expires_in
+	f(time.Second, 5*time.Second)
+
+	// short-lived token
+	f(time.Minute, 30*time.Second)
+
+	// long-lived token
+	f(time.Hour, 59*time.Minute)
+}
+
+func verifyAssertion(t *testing.T, assertion string, publicKey *rsa.PublicKey) {
+	t.Helper()
+	n := strings.LastIndexByte(assertion, '.')
+	if n < 0 {
+		t.Errorf("unexpected assertion: %q", assertion)
+		return
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(assertion[n+1:])
+	if err != nil {
+		t.Errorf("cannot decode signature: %s", err)
+		return
+	}
+	hash := sha512.Sum512([]byte(assertion[:n]))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA512, hash[:], signature); err != nil {
+		t.Errorf("invalid assertion signature: %s", err)
+	}
+}
+
+const testServers = `{
+  "items": [
+    {
+      "id": "1af3a9bd-8e0a-4a5c-8a40-4c0b9b2d4f2c",
+      "name": "server-1",
+      "status": "ACTIVE",
+      "powerStatus": "RUNNING",
+      "availabilityZone": "eu01-3",
+      "machineType": "c1.2",
+      "labels": {
+        "env": "prod",
+        "replicas": 3
+      },
+      "nics": [
+        {
+          "ipv4": "10.0.0.2",
+          "publicIp": "193.148.164.10",
+          "networkId": "8c3f0c5a-3b1a-4b1f-9b6a-2f1c3d4e5f6a",
+          "networkName": "internal",
+          "nicId": "5e2c6d7a-0b1c-4d2e-8f3a-4b5c6d7e8f9a"
+        }
+      ]
+    },
+    {
+      "id": "2bf3a9bd-8e0a-4a5c-8a40-4c0b9b2d4f2c",
+      "name": "server-without-nics",
+      "status": "INACTIVE",
+      "powerStatus": "STOPPED",
+      "availabilityZone": "eu01-1",
+      "nics": []
+    }
+  ]
+}`
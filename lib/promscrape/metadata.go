@@ -0,0 +1,93 @@
+package promscrape
+
+import (
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+// metadataEnabled is set to true by EnableMetadata.
+var metadataEnabled bool
+
+// EnableMetadata enables passing metric metadata from `# TYPE` and `# UNIT` lines of scrape responses
+// to pushData via WriteRequest.Metadata.
+//
+// Responses in Prometheus text exposition and OpenMetrics formats contain metadata lines,
+// while protobuf responses are converted to Prometheus text exposition format without metadata.
+//
+// EnableMetadata must be called before Init.
+func EnableMetadata() {
+	metadataEnabled = true
+}
+
+// Metric types from Prometheus metadata.
+//
+// See https://github.com/prometheus/prometheus/blob/c5282933765ec322a0664d0a0268f8276e83b156/prompb/types.proto#L21
+var metricMetadataTypes = map[string]uint32{
+	"counter":        1,
+	"gauge":          2,
+	"histogram":      3,
+	"gaugehistogram": 4,
+	"summary":        5,
+	"info":           6,
+	"stateset":       7,
+}
+
+// appendMetadata appends metadata from `# TYPE` and `# UNIT` lines in Prometheus text exposition format at s to dst and returns the result.
+//
+// The returned metadata refers to s.
+func appendMetadata(dst []prompbmarshal.MetricMetadata, s string) []prompbmarshal.MetricMetadata {
+	dstLen := len(dst)
+	for len(s) > 0 {
+		var line string
+		n := strings.IndexByte(s, '\n')
+		if n < 0 {
+			line = s
+			s = ""
+		} else {
+			line = s[:n]
+			s = s[n+1:]
+		}
+		if len(line) == 0 || line[0] != '#' {
+			continue
+		}
+		tail, isType := strings.CutPrefix(line, "# TYPE ")
+		if !isType {
+			var ok bool
+			tail, ok = strings.CutPrefix(line, "# UNIT ")
+			if !ok {
+				continue
+			}
+		}
+		name, value, ok := strings.Cut(tail, " ")
+		if !ok || name == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		// Metadata lines for the same metric family are usually located next to each other.
+		var mm *prompbmarshal.MetricMetadata
+		if len(dst) > dstLen && dst[len(dst)-1].MetricFamilyName == name {
+			mm = &dst[len(dst)-1]
+		} else {
+			dst = append(dst, prompbmarshal.MetricMetadata{
+				MetricFamilyName: name,
+			})
+			mm = &dst[len(dst)-1]
+		}
+		if isType {
+			mm.Type = metricMetadataTypes[value]
+		} else {
+			mm.Unit = value
+		}
+	}
+	return dst
+}
+
+// addMetadata adds metadata from `# TYPE` and `# UNIT` lines at s to wc if EnableMetadata has been called.
+func (wc *writeRequestCtx) addMetadata(s string) {
+	if !metadataEnabled {
+		return
+	}
+	wc.writeRequest.Metadata = appendMetadata(wc.writeRequest.Metadata, s)
+}
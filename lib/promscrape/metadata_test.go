@@ -0,0 +1,55 @@
+package promscrape
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+func TestAppendMetadata(t *testing.T) {
+	f := func(s string, resultExpected []prompbmarshal.MetricMetadata) {
+		t.Helper()
+		result := appendMetadata(nil, s)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result\ngot\n%v\nwant\n%v", result, resultExpected)
+		}
+	}
+
+	// no metadata
+	f("", nil)
+	f("foo 1\nbar{a=\"b\"} 2\n", nil)
+
+	// type and unit lines
+	f(`# HELP requests_total The number of requests.
+# TYPE requests_total counter
+requests_total 10
+# TYPE latency_seconds histogram
+# UNIT latency_seconds seconds
+latency_seconds_bucket{le="+Inf"} 2
+latency_seconds_sum 3
+latency_seconds_count 2
+# TYPE temperature gauge
+temperature 20`, []prompbmarshal.MetricMetadata{
+		{
+			MetricFamilyName: "requests_total",
+			Type:             1,
+		},
+		{
+			MetricFamilyName: "latency_seconds",
+			Type:             3,
+			Unit:             "seconds",
+		},
+		{
+			MetricFamilyName: "temperature",
+			Type:             2,
+		},
+	})
+
+	// unknown type and invalid lines
+	f("# TYPE foo untyped\n# TYPE bar\n# TYPE  counter\n# foo bar\n", []prompbmarshal.MetricMetadata{
+		{
+			MetricFamilyName: "foo",
+		},
+	})
+}
@@ -0,0 +1,230 @@
+package probe
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Config represents probe options for the `probe_configs` section of -promscrape.config.
+//
+// See https://docs.victoriametrics.com/victoriametrics/vmagent/#probing-targets
+type Config struct {
+	// Prober is the type of the probe. Supported values: http, tcp, tls, dns.
+	Prober string      `yaml:"prober"`
+	HTTP   *HTTPConfig `yaml:"http,omitempty"`
+	TCP    *TCPConfig  `yaml:"tcp,omitempty"`
+	DNS    *DNSConfig  `yaml:"dns,omitempty"`
+}
+
+// HTTPConfig represents options for `prober: http`.
+type HTTPConfig struct {
+	// Method is HTTP method to use for the probe. GET is used by default.
+	Method string `yaml:"method,omitempty"`
+
+	// Body is an optional request body to send to the target.
+	Body string `yaml:"body,omitempty"`
+
+	// ValidStatusCodes contains the list of accepted response status codes. 2xx codes are accepted by default.
+	ValidStatusCodes []int `yaml:"valid_status_codes,omitempty"`
+
+	FailIfSSL                  bool     `yaml:"fail_if_ssl,omitempty"`
+	FailIfNotSSL               bool     `yaml:"fail_if_not_ssl,omitempty"`
+	FailIfBodyMatchesRegexp    []string `yaml:"fail_if_body_matches_regexp,omitempty"`
+	FailIfBodyNotMatchesRegexp []string `yaml:"fail_if_body_not_matches_regexp,omitempty"`
+}
+
+// TCPConfig represents options for `prober: tcp`.
+type TCPConfig struct {
+	// Send is an optional data to send to the target after the connection is established.
+	Send string `yaml:"send,omitempty"`
+
+	// Expect is an optional regexp, which must match a line in the response from the target.
+	Expect string `yaml:"expect,omitempty"`
+}
+
+// DNSConfig represents options for `prober: dns`.
+type DNSConfig struct {
+	// QueryName is the name to resolve at the DNS server from the target.
+	QueryName string `yaml:"query_name"`
+
+	// QueryType is DNS record type to query. A is used by default.
+	QueryType string `yaml:"query_type,omitempty"`
+}
+
+var supportedDNSQueryTypes = []string{"A", "AAAA", "MX", "NS", "PTR", "SRV", "TXT"}
+
+// ParsedConfig is parsed Config, which can be used for probing targets.
+type ParsedConfig struct {
+	prober string
+
+	httpMethod                 string
+	httpBody                   string
+	httpValidStatusCodes       []int
+	httpFailIfSSL              bool
+	httpFailIfNotSSL           bool
+	httpFailIfBodyMatches      []*regexp.Regexp
+	httpFailIfBodyNotMatches   []*regexp.Regexp
+	tcpSend                    string
+	tcpExpect                  *regexp.Regexp
+	dnsQueryName, dnsQueryType string
+
+	// s contains string representation for the ParsedConfig.
+	s string
+}
+
+// Parse parses cfg.
+func (cfg *Config) Parse() (*ParsedConfig, error) {
+	pc := &ParsedConfig{
+		prober: strings.ToLower(cfg.Prober),
+	}
+	switch pc.prober {
+	case "http":
+		if err := pc.parseHTTPConfig(cfg.HTTP); err != nil {
+			return nil, fmt.Errorf("cannot parse `http` section: %w", err)
+		}
+	case "tcp":
+		if err := pc.parseTCPConfig(cfg.TCP); err != nil {
+			return nil, fmt.Errorf("cannot parse `tcp` section: %w", err)
+		}
+	case "tls":
+	case "dns":
+		if err := pc.parseDNSConfig(cfg.DNS); err != nil {
+			return nil, fmt.Errorf("cannot parse `dns` section: %w", err)
+		}
+	case "":
+		return nil, fmt.Errorf("missing `prober` option; supported values: http, tcp, tls, dns")
+	default:
+		return nil, fmt.Errorf("unsupported `prober: %q`; supported values: http, tcp, tls, dns", cfg.Prober)
+	}
+	if cfg.HTTP != nil && pc.prober != "http" {
+		return nil, fmt.Errorf("`http` section cannot be set for `prober: %s`", pc.prober)
+	}
+	if cfg.TCP != nil && pc.prober != "tcp" {
+		return nil, fmt.Errorf("`tcp` section cannot be set for `prober: %s`", pc.prober)
+	}
+	if cfg.DNS != nil && pc.prober != "dns" {
+		return nil, fmt.Errorf("`dns` section cannot be set for `prober: %s`", pc.prober)
+	}
+	pc.s = fmt.Sprintf("prober=%s, http=%+v, tcp=%+v, dns=%+v", pc.prober, cfg.HTTP, cfg.TCP, cfg.DNS)
+	return pc, nil
+}
+
+func (pc *ParsedConfig) parseHTTPConfig(hc *HTTPConfig) error {
+	if hc == nil {
+		hc = &HTTPConfig{}
+	}
+	pc.httpMethod = strings.ToUpper(hc.Method)
+	if pc.httpMethod == "" {
+		pc.httpMethod = "GET"
+	}
+	pc.httpBody = hc.Body
+	for _, code := range hc.ValidStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid status code in `valid_status_codes`: %d; it must be in the range [100..599]", code)
+		}
+	}
+	pc.httpValidStatusCodes = hc.ValidStatusCodes
+	if hc.FailIfSSL && hc.FailIfNotSSL {
+		return fmt.Errorf("`fail_if_ssl` and `fail_if_not_ssl` cannot be set simultaneously")
+	}
+	pc.httpFailIfSSL = hc.FailIfSSL
+	pc.httpFailIfNotSSL = hc.FailIfNotSSL
+	for _, expr := range hc.FailIfBodyMatchesRegexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("cannot parse `fail_if_body_matches_regexp` %q: %w", expr, err)
+		}
+		pc.httpFailIfBodyMatches = append(pc.httpFailIfBodyMatches, re)
+	}
+	for _, expr := range hc.FailIfBodyNotMatchesRegexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("cannot parse `fail_if_body_not_matches_regexp` %q: %w", expr, err)
+		}
+		pc.httpFailIfBodyNotMatches = append(pc.httpFailIfBodyNotMatches, re)
+	}
+	return nil
+}
+
+func (pc *ParsedConfig) parseTCPConfig(tc *TCPConfig) error {
+	if tc == nil {
+		return nil
+	}
+	pc.tcpSend = tc.Send
+	if tc.Expect != "" {
+		re, err := regexp.Compile(tc.Expect)
+		if err != nil {
+			return fmt.Errorf("cannot parse `expect` %q: %w", tc.Expect, err)
+		}
+		pc.tcpExpect = re
+	}
+	return nil
+}
+
+func (pc *ParsedConfig) parseDNSConfig(dc *DNSConfig) error {
+	if dc == nil || dc.QueryName == "" {
+		return fmt.Errorf("missing `query_name` option")
+	}
+	pc.dnsQueryName = dc.QueryName
+	pc.dnsQueryType = strings.ToUpper(dc.QueryType)
+	if pc.dnsQueryType == "" {
+		pc.dnsQueryType = "A"
+	}
+	if !slices.Contains(supportedDNSQueryTypes, pc.dnsQueryType) {
+		return fmt.Errorf("unsupported `query_type: %q`; supported values: %s", dc.QueryType, strings.Join(supportedDNSQueryTypes, ", "))
+	}
+	return nil
+}
+
+// String returns string representation for pc.
+func (pc *ParsedConfig) String() string {
+	if pc == nil {
+		return ""
+	}
+	return pc.s
+}
+
+// IsHTTP returns true if pc probes targets over HTTP.
+func (pc *ParsedConfig) IsHTTP() bool {
+	return pc.prober == "http"
+}
+
+// GetProbeURL returns the url to probe for the given scrapeURL and address obtained from target labels.
+//
+// The scrapeURL is returned as is for http prober, while `<prober>://<host>:<port>` url is returned for other probers.
+func (pc *ParsedConfig) GetProbeURL(scrapeURL, address string) (string, error) {
+	switch pc.prober {
+	case "http":
+		return scrapeURL, nil
+	case "tcp":
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return "", fmt.Errorf("cannot parse tcp probe target %q; it must be in the form host:port: %w", address, err)
+		}
+		return "tcp://" + address, nil
+	case "tls":
+		return "tls://" + addMissingPort(address, "443"), nil
+	case "dns":
+		return "dns://" + addMissingPort(address, "53"), nil
+	default:
+		return "", fmt.Errorf("BUG: unexpected prober %q", pc.prober)
+	}
+}
+
+func addMissingPort(address, port string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), port)
+}
+
+func getProbeAddress(probeURL string) (string, error) {
+	u, err := url.Parse(probeURL)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse probe url %q: %w", probeURL, err)
+	}
+	return u.Host, nil
+}
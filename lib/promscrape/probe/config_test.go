@@ -0,0 +1,135 @@
+package probe
+
+import (
+	"testing"
+)
+
+func TestConfigParseFailure(t *testing.T) {
+	f := func(cfg *Config) {
+		t.Helper()
+
+		pc, err := cfg.Parse()
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if pc != nil {
+			t.Fatalf("expecting nil ParsedConfig; got %s", pc)
+		}
+	}
+
+	// missing prober
+	f(&Config{})
+
+	// unsupported prober
+	f(&Config{
+		Prober: "icmp",
+	})
+
+	// invalid status code
+	f(&Config{
+		Prober: "http",
+		HTTP: &HTTPConfig{
+			ValidStatusCodes: []int{2000},
+		},
+	})
+
+	// fail_if_ssl and fail_if_not_ssl
+	f(&Config{
+		Prober: "http",
+		HTTP: &HTTPConfig{
+			FailIfSSL:    true,
+			FailIfNotSSL: true,
+		},
+	})
+
+	// invalid regexps
+	f(&Config{
+		Prober: "http",
+		HTTP: &HTTPConfig{
+			FailIfBodyMatchesRegexp: []string{"("},
+		},
+	})
+	f(&Config{
+		Prober: "http",
+		HTTP: &HTTPConfig{
+			FailIfBodyNotMatchesRegexp: []string{"["},
+		},
+	})
+	f(&Config{
+		Prober: "tcp",
+		TCP: &TCPConfig{
+			Expect: "(",
+		},
+	})
+
+	// missing query_name
+	f(&Config{
+		Prober: "dns",
+	})
+
+	// unsupported query_type
+	f(&Config{
+		Prober: "dns",
+		DNS: &DNSConfig{
+			QueryName: "example.com",
+			QueryType: "SOA",
+		},
+	})
+
+	// options for another prober
+	f(&Config{
+		Prober: "tcp",
+		HTTP:   &HTTPConfig{},
+	})
+	f(&Config{
+		Prober: "http",
+		TCP:    &TCPConfig{},
+	})
+	f(&Config{
+		Prober: "tls",
+		DNS: &DNSConfig{
+			QueryName: "example.com",
+		},
+	})
+}
+
+func TestGetProbeURL(t *testing.T) {
+	f := func(prober, scrapeURL, address, probeURLExpected string) {
+		t.Helper()
+
+		cfg := &Config{
+			Prober: prober,
+		}
+		if prober == "dns" {
+			cfg.DNS = &DNSConfig{
+				QueryName: "example.com",
+			}
+		}
+		pc, err := cfg.Parse()
+		if err != nil {
+			t.Fatalf("cannot parse config: %s", err)
+		}
+		probeURL, err := pc.GetProbeURL(scrapeURL, address)
+		if probeURLExpected == "" {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if probeURL != probeURLExpected {
+			t.Fatalf("unexpected probe url; got %q; want %q", probeURL, probeURLExpected)
+		}
+	}
+
+	f("http", "https://example.com/foo?bar=baz", "https://example.com/foo?bar=baz", "https://example.com/foo?bar=baz")
+	f("tcp", "http://host:22/", "host:22", "tcp://host:22")
+	f("tcp", "http://host/", "host", "")
+	f("tls", "http://host/", "host", "tls://host:443")
+	f("tls", "http://host:8443/", "host:8443", "tls://host:8443")
+	f("dns", "http://1.1.1.1/", "1.1.1.1", "dns://1.1.1.1:53")
+	f("dns", "http://[::1]:5353/", "[::1]:5353", "dns://[::1]:5353")
+	f("dns", "http://[::1]/", "[::1]", "dns://[::1]:53")
+}
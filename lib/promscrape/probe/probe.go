@@ -0,0 +1,359 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options contains options for NewProber.
+type Options struct {
+	// HTTPClient is used for http probes.
+	HTTPClient *http.Client
+
+	// SetHeaders is called for setting headers for http probe requests.
+	SetHeaders func(req *http.Request) error
+
+	// TLSConfig is used for tls probes.
+	TLSConfig *tls.Config
+
+	// MaxBodySize is the maximum response body size to read for http probes. Zero means no limit.
+	MaxBodySize int64
+}
+
+// Prober probes a single target according to ParsedConfig.
+type Prober struct {
+	pc       *ParsedConfig
+	probeURL string
+	address  string
+	opts     Options
+	resolver *net.Resolver
+}
+
+// NewProber returns new Prober for the given probeURL obtained via pc.GetProbeURL.
+func NewProber(pc *ParsedConfig, probeURL string, opts *Options) (*Prober, error) {
+	address, err := getProbeAddress(probeURL)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prober{
+		pc:       pc,
+		probeURL: probeURL,
+		address:  address,
+		opts:     *opts,
+	}
+	switch pc.prober {
+	case "http":
+		if p.opts.HTTPClient == nil {
+			return nil, fmt.Errorf("BUG: missing HTTPClient for http prober")
+		}
+	case "tls":
+		tlsCfg := &tls.Config{}
+		if p.opts.TLSConfig != nil {
+			tlsCfg = p.opts.TLSConfig.Clone()
+		}
+		if tlsCfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse tls probe target %q: %w", address, err)
+			}
+			tlsCfg.ServerName = host
+		}
+		p.opts.TLSConfig = tlsCfg
+	case "dns":
+		p.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				// Send all the DNS queries to the probed DNS server instead of the system DNS servers.
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		}
+	}
+	return p, nil
+}
+
+// Probe probes the target and appends the results in Prometheus text exposition format to dst.
+//
+// The probe is aborted when ctx is canceled. The reason of probe failure is appended to dst as a comment.
+func (p *Prober) Probe(ctx context.Context, dst []byte) []byte {
+	startTime := time.Now()
+	var metrics []byte
+	var err error
+	switch p.pc.prober {
+	case "http":
+		metrics, err = p.probeHTTP(ctx, metrics)
+	case "tcp":
+		metrics, err = p.probeTCP(ctx, metrics)
+	case "tls":
+		metrics, err = p.probeTLS(ctx, metrics)
+	case "dns":
+		metrics, err = p.probeDNS(ctx, metrics)
+	default:
+		err = fmt.Errorf("BUG: unexpected prober %q", p.pc.prober)
+	}
+	duration := time.Since(startTime).Seconds()
+
+	if err != nil {
+		// Replace newlines in the error message, since the comment must fit a single line.
+		dst = append(dst, "# probe failed: "...)
+		dst = append(dst, strings.ReplaceAll(err.Error(), "\n", " ")...)
+		dst = append(dst, '\n')
+	}
+	dst = appendMetric(dst, "probe_success", boolToFloat(err == nil))
+	dst = appendMetric(dst, "probe_duration_seconds", duration)
+	return append(dst, metrics...)
+}
+
+type httpResult struct {
+	statusCode    int
+	contentLength int64
+	bodyLength    int
+	redirects     int
+	tlsState      *tls.ConnectionState
+}
+
+func (p *Prober) probeHTTP(ctx context.Context, dst []byte) ([]byte, error) {
+	var hr httpResult
+	err := p.doHTTPRequest(ctx, &hr)
+	dst = appendMetric(dst, "probe_http_status_code", float64(hr.statusCode))
+	dst = appendMetric(dst, "probe_http_content_length", float64(hr.contentLength))
+	dst = appendMetric(dst, "probe_http_uncompressed_body_length", float64(hr.bodyLength))
+	dst = appendMetric(dst, "probe_http_redirects", float64(hr.redirects))
+	dst = appendMetric(dst, "probe_http_ssl", boolToFloat(hr.tlsState != nil))
+	if hr.tlsState != nil {
+		dst = appendTLSMetrics(dst, hr.tlsState)
+	}
+	return dst, err
+}
+
+func (p *Prober) doHTTPRequest(ctx context.Context, hr *httpResult) error {
+	pc := p.pc
+	var body io.Reader
+	if pc.httpBody != "" {
+		body = strings.NewReader(pc.httpBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, pc.httpMethod, p.probeURL, body)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+	if p.opts.SetHeaders != nil {
+		if err := p.opts.SetHeaders(req); err != nil {
+			return fmt.Errorf("cannot set request headers: %w", err)
+		}
+	}
+
+	// Make a shallow copy of the client in order to count redirects for the current request.
+	hc := *p.opts.HTTPClient
+	checkRedirect := hc.CheckRedirect
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		hr.redirects = len(via)
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot perform request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	hr.statusCode = resp.StatusCode
+	hr.contentLength = resp.ContentLength
+	hr.tlsState = resp.TLS
+
+	r := resp.Body
+	if p.opts.MaxBodySize > 0 {
+		r = io.NopCloser(io.LimitReader(r, p.opts.MaxBodySize+1))
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cannot read response body: %w", err)
+	}
+	if p.opts.MaxBodySize > 0 && int64(len(data)) > p.opts.MaxBodySize {
+		return fmt.Errorf("response body exceeds %d bytes", p.opts.MaxBodySize)
+	}
+	hr.bodyLength = len(data)
+
+	if !pc.isValidStatusCode(resp.StatusCode) {
+		return fmt.Errorf("unexpected response status code %d", resp.StatusCode)
+	}
+	if pc.httpFailIfSSL && resp.TLS != nil {
+		return fmt.Errorf("the response is received via TLS, while `fail_if_ssl` is set")
+	}
+	if pc.httpFailIfNotSSL && resp.TLS == nil {
+		return fmt.Errorf("the response is received without TLS, while `fail_if_not_ssl` is set")
+	}
+	for _, re := range pc.httpFailIfBodyMatches {
+		if re.Match(data) {
+			return fmt.Errorf("response body matches `fail_if_body_matches_regexp` %q", re)
+		}
+	}
+	for _, re := range pc.httpFailIfBodyNotMatches {
+		if !re.Match(data) {
+			return fmt.Errorf("response body doesn't match `fail_if_body_not_matches_regexp` %q", re)
+		}
+	}
+	return nil
+}
+
+func (pc *ParsedConfig) isValidStatusCode(statusCode int) bool {
+	if len(pc.httpValidStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	return slices.Contains(pc.httpValidStatusCodes, statusCode)
+}
+
+func (p *Prober) probeTCP(ctx context.Context, dst []byte) ([]byte, error) {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return dst, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	pc := p.pc
+	if pc.tcpSend != "" {
+		if _, err := io.WriteString(conn, pc.tcpSend); err != nil {
+			return dst, fmt.Errorf("cannot send data to %s: %w", p.address, err)
+		}
+	}
+	if pc.tcpExpect == nil {
+		return dst, nil
+	}
+	br := bufio.NewReader(conn)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" && pc.tcpExpect.MatchString(line) {
+			return dst, nil
+		}
+		if err != nil {
+			return dst, fmt.Errorf("cannot find response line matching `expect` %q: %w", pc.tcpExpect, err)
+		}
+	}
+}
+
+func (p *Prober) probeTLS(ctx context.Context, dst []byte) ([]byte, error) {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return dst, err
+	}
+	tc := tls.Client(conn, p.opts.TLSConfig)
+	defer func() {
+		_ = tc.Close()
+	}()
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return dst, fmt.Errorf("cannot perform TLS handshake with %s: %w", p.address, err)
+	}
+	cs := tc.ConnectionState()
+	return appendTLSMetrics(dst, &cs), nil
+}
+
+func (p *Prober) dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", p.address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("cannot set deadline for connection to %s: %w", p.address, err)
+		}
+	}
+	return conn, nil
+}
+
+func (p *Prober) probeDNS(ctx context.Context, dst []byte) ([]byte, error) {
+	pc := p.pc
+	n, err := p.lookupDNS(ctx)
+	dst = appendMetric(dst, "probe_dns_answer_rrs", float64(n))
+	if err != nil {
+		return dst, fmt.Errorf("cannot resolve %s record for %q at %s: %w", pc.dnsQueryType, pc.dnsQueryName, p.address, err)
+	}
+	if n == 0 {
+		return dst, fmt.Errorf("missing %s records for %q at %s", pc.dnsQueryType, pc.dnsQueryName, p.address)
+	}
+	return dst, nil
+}
+
+func (p *Prober) lookupDNS(ctx context.Context) (int, error) {
+	pc := p.pc
+	r := p.resolver
+	if pc.dnsQueryType == "PTR" {
+		names, err := r.LookupAddr(ctx, pc.dnsQueryName)
+		return len(names), err
+	}
+
+	// Make the name fully qualified, so it isn't resolved via search domains from /etc/resolv.conf
+	name := pc.dnsQueryName
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	switch pc.dnsQueryType {
+	case "A":
+		ips, err := r.LookupIP(ctx, "ip4", name)
+		return len(ips), err
+	case "AAAA":
+		ips, err := r.LookupIP(ctx, "ip6", name)
+		return len(ips), err
+	case "MX":
+		mxs, err := r.LookupMX(ctx, name)
+		return len(mxs), err
+	case "NS":
+		nss, err := r.LookupNS(ctx, name)
+		return len(nss), err
+	case "SRV":
+		_, srvs, err := r.LookupSRV(ctx, "", "", name)
+		return len(srvs), err
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, name)
+		return len(txts), err
+	default:
+		return 0, fmt.Errorf("BUG: unexpected query_type %q", pc.dnsQueryType)
+	}
+}
+
+func appendTLSMetrics(dst []byte, cs *tls.ConnectionState) []byte {
+	var expiry time.Time
+	for _, cert := range cs.PeerCertificates {
+		if expiry.IsZero() || cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+	if !expiry.IsZero() {
+		dst = appendMetric(dst, "probe_ssl_earliest_cert_expiry", float64(expiry.Unix()))
+	}
+	dst = append(dst, `probe_tls_version_info{version=`...)
+	dst = strconv.AppendQuote(dst, tls.VersionName(cs.Version))
+	return append(dst, "} 1\n"...)
+}
+
+func appendMetric(dst []byte, name string, value float64) []byte {
+	dst = append(dst, name...)
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, value, 'f', -1, 64)
+	return append(dst, '\n')
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
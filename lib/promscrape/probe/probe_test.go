@@ -0,0 +1,265 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProberHTTP(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/ok":
+			fmt.Fprintf(w, "status: ok")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "status: not found")
+		}
+	}))
+	defer s.Close()
+
+	f := func(hc *HTTPConfig, path string, metricsExpected map[string]string) {
+		t.Helper()
+
+		opts := &Options{
+			HTTPClient: s.Client(),
+		}
+		metrics := mustProbe(t, &Config{Prober: "http", HTTP: hc}, s.URL+path, opts)
+		checkMetrics(t, metrics, metricsExpected)
+	}
+
+	// successful probe
+	f(nil, "/ok", map[string]string{
+		"probe_success":                       "1",
+		"probe_http_status_code":              "200",
+		"probe_http_uncompressed_body_length": "10",
+		"probe_http_redirects":                "0",
+		"probe_http_ssl":                      "0",
+	})
+
+	// successful probe with redirect
+	f(nil, "/redirect", map[string]string{
+		"probe_success":          "1",
+		"probe_http_status_code": "200",
+		"probe_http_redirects":   "1",
+	})
+
+	// unexpected status code
+	f(nil, "/missing", map[string]string{
+		"probe_success":          "0",
+		"probe_http_status_code": "404",
+	})
+
+	// valid_status_codes
+	f(&HTTPConfig{
+		ValidStatusCodes: []int{404},
+	}, "/missing", map[string]string{
+		"probe_success":          "1",
+		"probe_http_status_code": "404",
+	})
+
+	// fail_if_body_matches_regexp
+	f(&HTTPConfig{
+		FailIfBodyMatchesRegexp: []string{"ok$"},
+	}, "/ok", map[string]string{
+		"probe_success": "0",
+	})
+
+	// fail_if_body_not_matches_regexp
+	f(&HTTPConfig{
+		FailIfBodyNotMatchesRegexp: []string{"ok$"},
+	}, "/ok", map[string]string{
+		"probe_success": "1",
+	})
+	f(&HTTPConfig{
+		FailIfBodyNotMatchesRegexp: []string{"^foo"},
+	}, "/ok", map[string]string{
+		"probe_success": "0",
+	})
+
+	// fail_if_not_ssl
+	f(&HTTPConfig{
+		FailIfNotSSL: true,
+	}, "/ok", map[string]string{
+		"probe_success":  "0",
+		"probe_http_ssl": "0",
+	})
+}
+
+func TestProberHTTPS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "ok")
+	}))
+	defer s.Close()
+
+	opts := &Options{
+		HTTPClient: s.Client(),
+	}
+	metrics := mustProbe(t, &Config{Prober: "http"}, s.URL, opts)
+	checkMetrics(t, metrics, map[string]string{
+		"probe_success":                  "1",
+		"probe_http_ssl":                 "1",
+		"probe_ssl_earliest_cert_expiry": fmt.Sprintf("%d", s.Certificate().NotAfter.Unix()),
+	})
+
+	// response body exceeding max size
+	opts.MaxBodySize = 1
+	metrics = mustProbe(t, &Config{Prober: "http"}, s.URL, opts)
+	checkMetrics(t, metrics, map[string]string{
+		"probe_success": "0",
+	})
+}
+
+func TestProberTLS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	defer s.Close()
+
+	address := s.Listener.Addr().String()
+	tlsCfg := s.Client().Transport.(*http.Transport).TLSClientConfig
+	opts := &Options{
+		TLSConfig: tlsCfg,
+	}
+	metrics := mustProbe(t, &Config{Prober: "tls"}, "tls://"+address, opts)
+	checkMetrics(t, metrics, map[string]string{
+		"probe_success":                  "1",
+		"probe_ssl_earliest_cert_expiry": fmt.Sprintf("%d", s.Certificate().NotAfter.Unix()),
+	})
+	if !strings.Contains(metrics, `probe_tls_version_info{version="TLS 1.3"} 1`) {
+		t.Fatalf("missing probe_tls_version_info metric in\n%s", metrics)
+	}
+
+	// untrusted certificate
+	metrics = mustProbe(t, &Config{Prober: "tls"}, "tls://"+address, &Options{
+		TLSConfig: &tls.Config{},
+	})
+	checkMetrics(t, metrics, map[string]string{
+		"probe_success": "0",
+	})
+}
+
+func TestProberTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot start listener: %s", err)
+	}
+	defer func() {
+		_ = ln.Close()
+	}()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() {
+					_ = c.Close()
+				}()
+				_, _ = fmt.Fprintf(c, "SSH-2.0-OpenSSH_9.6\r\n")
+				_ = c.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+				line, _ := bufio.NewReader(c).ReadString('\n')
+				_, _ = fmt.Fprintf(c, "echo: %s", line)
+			}()
+		}
+	}()
+	address := ln.Addr().String()
+
+	f := func(tc *TCPConfig, successExpected string) {
+		t.Helper()
+
+		metrics := mustProbe(t, &Config{Prober: "tcp", TCP: tc}, "tcp://"+address, &Options{})
+		checkMetrics(t, metrics, map[string]string{
+			"probe_success": successExpected,
+		})
+	}
+
+	f(nil, "1")
+	f(&TCPConfig{
+		Expect: "^SSH-2.0-",
+	}, "1")
+	f(&TCPConfig{
+		Send:   "foo\n",
+		Expect: "^echo: foo",
+	}, "1")
+	f(&TCPConfig{
+		Expect: "^SMTP",
+	}, "0")
+
+	// closed port
+	_ = ln.Close()
+	f(nil, "0")
+}
+
+func TestProberDNSFailure(t *testing.T) {
+	// Obtain a free port without DNS server.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot start listener: %s", err)
+	}
+	address := ln.Addr().String()
+	_ = ln.Close()
+
+	cfg := &Config{
+		Prober: "dns",
+		DNS: &DNSConfig{
+			QueryName: "example.com",
+		},
+	}
+	metrics := mustProbe(t, cfg, "dns://"+address, &Options{})
+	checkMetrics(t, metrics, map[string]string{
+		"probe_success":        "0",
+		"probe_dns_answer_rrs": "0",
+	})
+	if !strings.Contains(metrics, "# probe failed: ") {
+		t.Fatalf("missing the reason of probe failure in\n%s", metrics)
+	}
+}
+
+func mustProbe(t *testing.T, cfg *Config, probeURL string, opts *Options) string {
+	t.Helper()
+
+	pc, err := cfg.Parse()
+	if err != nil {
+		t.Fatalf("cannot parse config: %s", err)
+	}
+	p, err := NewProber(pc, probeURL, opts)
+	if err != nil {
+		t.Fatalf("cannot create prober: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return string(p.Probe(ctx, nil))
+}
+
+func checkMetrics(t *testing.T, metrics string, metricsExpected map[string]string) {
+	t.Helper()
+
+	m := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(metrics), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, " ")
+		if !ok {
+			t.Fatalf("unexpected line %q in\n%s", line, metrics)
+		}
+		m[name] = value
+	}
+	if _, ok := m["probe_duration_seconds"]; !ok {
+		t.Fatalf("missing probe_duration_seconds metric in\n%s", metrics)
+	}
+	for name, valueExpected := range metricsExpected {
+		if value := m[name]; value != valueExpected {
+			t.Fatalf("unexpected value for %s; got %q; want %q; metrics:\n%s", name, value, valueExpected, metrics)
+		}
+	}
+}
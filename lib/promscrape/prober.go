@@ -0,0 +1,70 @@
+package promscrape
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/chunkedbuffer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/probe"
+)
+
+// prober probes targets from `probe_configs` section.
+//
+// See https://docs.victoriametrics.com/victoriametrics/vmagent/#probing-targets
+type prober struct {
+	ctx     context.Context
+	timeout time.Duration
+	p       *probe.Prober
+}
+
+func newProber(ctx context.Context, sw *ScrapeWork) (*prober, error) {
+	opts := &probe.Options{
+		MaxBodySize: sw.MaxScrapeSize,
+	}
+	if sw.ProbeConfig.IsHTTP() {
+		// Re-use the http client for scrapes, since it properly handles auth, tls and proxy options from the config.
+		c, err := newClient(ctx, sw)
+		if err != nil {
+			return nil, err
+		}
+		opts.HTTPClient = c.c
+		opts.SetHeaders = func(req *http.Request) error {
+			if err := c.setHeaders(req); err != nil {
+				return err
+			}
+			return c.setProxyHeaders(req)
+		}
+	} else {
+		tlsCfg, err := sw.AuthConfig.GetTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("cannot initialize tls config for probing %q: %w", sw.ScrapeURL, err)
+		}
+		opts.TLSConfig = tlsCfg
+	}
+	p, err := probe.NewProber(sw.ProbeConfig, sw.ScrapeURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize prober for %q: %w", sw.ScrapeURL, err)
+	}
+	pr := &prober{
+		ctx:     ctx,
+		timeout: sw.ScrapeTimeout,
+		p:       p,
+	}
+	return pr, nil
+}
+
+// ReadData probes the target and writes the results in Prometheus text exposition format to dst.
+//
+// It doesn't return probe errors, since they are exposed via probe_success metric in the same way as blackbox_exporter does.
+func (pr *prober) ReadData(dst *chunkedbuffer.Buffer) (bool, error) {
+	ctx, cancel := context.WithTimeout(pr.ctx, pr.timeout)
+	defer cancel()
+
+	bb := bbPool.Get()
+	bb.B = pr.p.Probe(ctx, bb.B[:0])
+	dst.MustWrite(bb.B)
+	bbPool.Put(bb)
+	return false, nil
+}
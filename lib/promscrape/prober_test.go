@@ -0,0 +1,78 @@
+package promscrape
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/chunkedbuffer"
+)
+
+func TestProberReadData(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer foo" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, "ok")
+	}))
+	defer s.Close()
+
+	f := func(bearerToken string, resultExpected []string) {
+		t.Helper()
+
+		data := fmt.Sprintf(`
+probe_configs:
+- job_name: foo
+  prober: http
+  bearer_token: %q
+  static_configs:
+  - targets: [%q]
+`, bearerToken, s.URL+"/health")
+		sws, err := getStaticScrapeWork([]byte(data), "non-existing-file")
+		if err != nil {
+			t.Fatalf("cannot parse config: %s", err)
+		}
+		if len(sws) != 1 {
+			t.Fatalf("unexpected number of scrape works; got %d; want 1", len(sws))
+		}
+		p, err := newProber(context.Background(), sws[0])
+		if err != nil {
+			t.Fatalf("cannot create prober: %s", err)
+		}
+		cb := chunkedbuffer.Get()
+		defer chunkedbuffer.Put(cb)
+		isGzipped, err := p.ReadData(cb)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if isGzipped {
+			t.Fatalf("unexpected gzipped response")
+		}
+		var sb strings.Builder
+		cb.MustWriteTo(&sb)
+		result := sb.String()
+		for _, s := range resultExpected {
+			if !strings.Contains(result, s) {
+				t.Fatalf("missing %q in the result:\n%s", s, result)
+			}
+		}
+	}
+
+	f("foo", []string{
+		"probe_success 1\n",
+		"probe_http_status_code 200\n",
+	})
+	f("bar", []string{
+		"# probe failed: unexpected response status code 401\n",
+		"probe_success 0\n",
+		"probe_http_status_code 401\n",
+	})
+}
@@ -0,0 +1,1068 @@
+package promscrape
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+	"gopkg.in/yaml.v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/kubernetes"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutil"
+)
+
+// PrometheusOperatorConfig represents `prometheus_operator_configs` section of -promscrape.config.
+//
+// It discovers ServiceMonitor, PodMonitor, Probe and ScrapeConfig objects via Kubernetes API
+// and generates scrape configs from them in-process, so Prometheus Operator isn't needed for generating scrape configs.
+//
+// See https://docs.victoriametrics.com/victoriametrics/sd_configs/#prometheus_operator_configs
+type PrometheusOperatorConfig struct {
+	kubernetes.CRDConfig `yaml:",inline"`
+
+	// mu protects objectConfigs and stopped.
+	mu sync.Mutex
+
+	// objectConfigs contains scrape configs generated per each discovered object.
+	objectConfigs map[string]*operatorObjectConfigs
+
+	// stopped is set to true after mustStop call.
+	stopped bool
+}
+
+// operatorObjectConfigs contains scrape configs generated from a single Prometheus Operator object.
+type operatorObjectConfigs struct {
+	// spec is JSON-encoded object, which was used for generating scrapeConfigs.
+	//
+	// It is used for detecting object changes.
+	spec string
+
+	// secrets contains values read from Secrets and ConfigMaps referred by the object when generating scrapeConfigs.
+	//
+	// It is used for detecting changes in the referred Secrets and ConfigMaps, such as credentials rotation.
+	secrets map[operatorSecretRef]operatorSecretValue
+
+	// scrapeConfigs contains the started scrape configs generated from the object.
+	scrapeConfigs []*ScrapeConfig
+}
+
+var operatorObjectErrors = metrics.NewCounter(`vm_promscrape_prometheus_operator_object_errors_total`)
+
+func (poc *PrometheusOperatorConfig) mustStart(baseDir string) {
+	poc.CRDConfig.MustStart(baseDir)
+}
+
+func (poc *PrometheusOperatorConfig) mustStop() {
+	poc.mu.Lock()
+	for _, oc := range poc.objectConfigs {
+		for _, sc := range oc.scrapeConfigs {
+			sc.mustStop()
+			sc.swc.mustStop()
+		}
+	}
+	poc.objectConfigs = nil
+	poc.stopped = true
+	poc.mu.Unlock()
+
+	poc.CRDConfig.MustStop()
+}
+
+// mustRestartKubernetesSD restarts kubernetes_sd_configs for scrape configs generated by poc.
+func (poc *PrometheusOperatorConfig) mustRestartKubernetesSD(baseDir string) {
+	poc.mu.Lock()
+	defer poc.mu.Unlock()
+
+	for _, oc := range poc.objectConfigs {
+		for _, sc := range oc.scrapeConfigs {
+			if len(sc.KubernetesSDConfigs) == 0 {
+				continue
+			}
+			sc.mustStop()
+			sc.mustStart(baseDir)
+		}
+	}
+}
+
+func (poc *PrometheusOperatorConfig) marshalJSON() []byte {
+	data, err := json.Marshal(&poc.CRDConfig)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal PrometheusOperatorConfig: %s", err)
+	}
+	return data
+}
+
+func areEqualPrometheusOperatorConfigs(a, b []*PrometheusOperatorConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if string(a[i].marshalJSON()) != string(b[i].marshalJSON()) {
+			return false
+		}
+	}
+	return true
+}
+
+// getPrometheusOperatorScrapeWork returns ScrapeWork for scrape configs generated from `prometheus_operator_configs`.
+func (cfg *Config) getPrometheusOperatorScrapeWork(prev []*ScrapeWork) []*ScrapeWork {
+	swsPrevByJob := getSWSByJob(prev)
+	dst := make([]*ScrapeWork, 0, len(prev))
+	for _, poc := range cfg.PrometheusOperatorConfigs {
+		scs, err := poc.getScrapeConfigs(cfg.baseDir, &cfg.Global)
+		if err != nil {
+			logger.Errorf("cannot discover Prometheus Operator objects; using the previously generated scrape configs: %s", err)
+		}
+		for _, sc := range scs {
+			dst = sc.appendOperatorScrapeWork(dst, cfg.baseDir, swsPrevByJob)
+		}
+	}
+	return dst
+}
+
+func (sc *ScrapeConfig) appendOperatorScrapeWork(dst []*ScrapeWork, baseDir string, swsPrevByJob map[string][]*ScrapeWork) []*ScrapeWork {
+	dstLen := len(dst)
+	for i := range sc.StaticConfigs {
+		dst = sc.StaticConfigs[i].appendScrapeWork(dst, sc.swc, nil)
+	}
+	for i := range sc.FileSDConfigs {
+		dst = sc.FileSDConfigs[i].appendScrapeWork(dst, baseDir, sc.swc)
+	}
+	for i := range sc.HTTPSDConfigs {
+		targetLabels, err := sc.HTTPSDConfigs[i].GetLabels(baseDir)
+		if err != nil {
+			logger.Errorf("skipping http_sd_config targets for job_name=%s because of error: %s", sc.swc.jobName, err)
+			return sc.appendPrevTargets(dst[:dstLen], swsPrevByJob, "http_sd_config")
+		}
+		dst = appendScrapeWorkForTargetLabels(dst, sc.swc, targetLabels, "http_sd_config")
+	}
+	for i := range sc.KubernetesSDConfigs {
+		swos, err := sc.KubernetesSDConfigs[i].GetScrapeWorkObjects()
+		if err != nil {
+			logger.Errorf("skipping kubernetes_sd_config targets for job_name=%s because of error: %s", sc.swc.jobName, err)
+			return sc.appendPrevTargets(dst[:dstLen], swsPrevByJob, "kubernetes_sd_config")
+		}
+		for _, swo := range swos {
+			dst = append(dst, swo.(*ScrapeWork))
+		}
+	}
+	return dst
+}
+
+// getScrapeConfigs returns the started scrape configs for the currently discovered Prometheus Operator objects.
+//
+// The previously generated scrape configs are returned together with non-nil error if objects cannot be discovered.
+func (poc *PrometheusOperatorConfig) getScrapeConfigs(baseDir string, globalCfg *GlobalConfig) ([]*ScrapeConfig, error) {
+	objs, err := poc.GetObjects()
+
+	poc.mu.Lock()
+	defer poc.mu.Unlock()
+
+	if poc.stopped {
+		return nil, nil
+	}
+	if err != nil {
+		var scs []*ScrapeConfig
+		keys := make([]string, 0, len(poc.objectConfigs))
+		for key := range poc.objectConfigs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			scs = append(scs, poc.objectConfigs[key].scrapeConfigs...)
+		}
+		return scs, err
+	}
+
+	if poc.objectConfigs == nil {
+		poc.objectConfigs = make(map[string]*operatorObjectConfigs)
+	}
+	sr := newOperatorSecretReader(&poc.CRDConfig)
+	g := &operatorConfigGenerator{
+		cc: &poc.CRDConfig,
+		sg: sr,
+	}
+	var scs []*ScrapeConfig
+	seen := make(map[string]struct{})
+	visitObject := func(kind string, metadata *kubernetes.ObjectMeta, o any, generate func() ([]*ScrapeConfig, error)) {
+		key := kind + "/" + metadata.Namespace + "/" + metadata.Name
+		seen[key] = struct{}{}
+		spec, err := json.Marshal(o)
+		if err != nil {
+			logger.Panicf("BUG: cannot marshal %s: %s", key, err)
+		}
+		oc := poc.objectConfigs[key]
+		if oc != nil && oc.spec == string(spec) && !sr.areChanged(oc.secrets) {
+			// Fast path - neither the object nor the referred secrets changed.
+			scs = append(scs, oc.scrapeConfigs...)
+			return
+		}
+		if oc == nil {
+			oc = &operatorObjectConfigs{}
+			poc.objectConfigs[key] = oc
+		}
+		oc.spec = string(spec)
+
+		g.namespace = metadata.Namespace
+		sr.startRecording()
+		scsNew, err := generate()
+		oc.secrets = sr.stopRecording()
+		if err == nil {
+			for _, sc := range scsNew {
+				swc, errLocal := getScrapeWorkConfig(sc, baseDir, globalCfg)
+				if errLocal != nil {
+					err = fmt.Errorf("invalid generated scrape config for job_name=%s: %w", sc.JobName, errLocal)
+					break
+				}
+				sc.swc = swc
+			}
+		}
+		if err != nil {
+			operatorObjectErrors.Inc()
+			logger.Errorf("cannot generate scrape configs for %s; using the previously generated scrape configs: %s", key, err)
+			for _, sc := range scsNew {
+				if sc.swc != nil {
+					sc.swc.mustStop()
+				}
+			}
+			scs = append(scs, oc.scrapeConfigs...)
+			return
+		}
+		for _, sc := range oc.scrapeConfigs {
+			sc.mustStop()
+			sc.swc.mustStop()
+		}
+		for _, sc := range scsNew {
+			sc.mustStart(baseDir)
+		}
+		oc.scrapeConfigs = scsNew
+		scs = append(scs, scsNew...)
+	}
+	for _, sm := range objs.ServiceMonitors {
+		visitObject("serviceMonitor", &sm.Metadata, sm, func() ([]*ScrapeConfig, error) {
+			return g.getServiceMonitorScrapeConfigs(sm)
+		})
+	}
+	for _, pm := range objs.PodMonitors {
+		visitObject("podMonitor", &pm.Metadata, pm, func() ([]*ScrapeConfig, error) {
+			return g.getPodMonitorScrapeConfigs(pm)
+		})
+	}
+	for _, p := range objs.Probes {
+		visitObject("probe", &p.Metadata, p, func() ([]*ScrapeConfig, error) {
+			return g.getProbeScrapeConfigs(p)
+		})
+	}
+	for _, sc := range objs.ScrapeConfigs {
+		visitObject("scrapeConfig", &sc.Metadata, sc, func() ([]*ScrapeConfig, error) {
+			return g.getScrapeConfigScrapeConfigs(sc)
+		})
+	}
+
+	// Stop scrape configs for deleted objects.
+	for key, oc := range poc.objectConfigs {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		for _, sc := range oc.scrapeConfigs {
+			sc.mustStop()
+			sc.swc.mustStop()
+		}
+		delete(poc.objectConfigs, key)
+	}
+	return scs, nil
+}
+
+// operatorSecretGetter must return values from Kubernetes secrets and configmaps.
+type operatorSecretGetter interface {
+	GetSecretValue(namespace, name, key string) (string, error)
+	GetConfigMapValue(namespace, name, key string) (string, error)
+}
+
+// operatorSecretRef refers to a key in Kubernetes Secret or ConfigMap.
+type operatorSecretRef struct {
+	kind      string
+	namespace string
+	name      string
+	key       string
+}
+
+// operatorSecretValue is the value read for operatorSecretRef.
+type operatorSecretValue struct {
+	value string
+
+	// ok is set to false if the value couldn't be read.
+	ok bool
+}
+
+// operatorSecretReader reads values from Kubernetes Secrets and ConfigMaps during a single discovery round.
+//
+// Every value is read only once per discovery round, even if it is referred by multiple objects.
+type operatorSecretReader struct {
+	sg operatorSecretGetter
+
+	values map[operatorSecretRef]operatorSecretValue
+	errs   map[operatorSecretRef]error
+
+	// recorded contains the values read since the last startRecording call.
+	recorded map[operatorSecretRef]operatorSecretValue
+}
+
+func newOperatorSecretReader(sg operatorSecretGetter) *operatorSecretReader {
+	return &operatorSecretReader{
+		sg:     sg,
+		values: make(map[operatorSecretRef]operatorSecretValue),
+		errs:   make(map[operatorSecretRef]error),
+	}
+}
+
+// GetSecretValue implements operatorSecretGetter interface.
+func (sr *operatorSecretReader) GetSecretValue(namespace, name, key string) (string, error) {
+	return sr.get(operatorSecretRef{
+		kind:      "secret",
+		namespace: namespace,
+		name:      name,
+		key:       key,
+	})
+}
+
+// GetConfigMapValue implements operatorSecretGetter interface.
+func (sr *operatorSecretReader) GetConfigMapValue(namespace, name, key string) (string, error) {
+	return sr.get(operatorSecretRef{
+		kind:      "configmap",
+		namespace: namespace,
+		name:      name,
+		key:       key,
+	})
+}
+
+func (sr *operatorSecretReader) get(ref operatorSecretRef) (string, error) {
+	sv, ok := sr.values[ref]
+	if !ok {
+		sv = sr.read(ref)
+	}
+	if sr.recorded != nil {
+		sr.recorded[ref] = sv
+	}
+	return sv.value, sr.errs[ref]
+}
+
+func (sr *operatorSecretReader) read(ref operatorSecretRef) operatorSecretValue {
+	var v string
+	var err error
+	if ref.kind == "secret" {
+		v, err = sr.sg.GetSecretValue(ref.namespace, ref.name, ref.key)
+	} else {
+		v, err = sr.sg.GetConfigMapValue(ref.namespace, ref.name, ref.key)
+	}
+	sv := operatorSecretValue{
+		value: v,
+		ok:    err == nil,
+	}
+	sr.values[ref] = sv
+	if err != nil {
+		sr.errs[ref] = err
+	}
+	return sv
+}
+
+// startRecording starts recording the values read by sr.
+func (sr *operatorSecretReader) startRecording() {
+	sr.recorded = make(map[operatorSecretRef]operatorSecretValue)
+}
+
+// stopRecording returns the values read by sr since the last startRecording call.
+func (sr *operatorSecretReader) stopRecording() map[operatorSecretRef]operatorSecretValue {
+	recorded := sr.recorded
+	sr.recorded = nil
+	return recorded
+}
+
+// areChanged returns true if the current values for the given secrets differ from the given values.
+//
+// This check doesn't query Kubernetes API server, since the current values are read from the watched secrets and configmaps.
+func (sr *operatorSecretReader) areChanged(secrets map[operatorSecretRef]operatorSecretValue) bool {
+	for ref, sv := range secrets {
+		cur, ok := sr.values[ref]
+		if !ok {
+			cur = sr.read(ref)
+		}
+		if cur != sv {
+			return true
+		}
+	}
+	return false
+}
+
+// operatorConfigGenerator generates scrape configs from Prometheus Operator objects.
+//
+// The generated scrape configs follow the rules used by Prometheus Operator, so the resulting targets and labels
+// match the targets and labels scraped by Prometheus managed by Prometheus Operator.
+type operatorConfigGenerator struct {
+	// cc is used for connecting kubernetes_sd_configs in the generated scrape configs to the same Kubernetes API server.
+	cc *kubernetes.CRDConfig
+
+	// sg is used for reading secrets referred by objects.
+	sg operatorSecretGetter
+
+	// namespace is the namespace of the currently processed object.
+	namespace string
+}
+
+func (g *operatorConfigGenerator) getServiceMonitorScrapeConfigs(sm *kubernetes.ServiceMonitor) ([]*ScrapeConfig, error) {
+	spec := &sm.Spec
+	scs := make([]*ScrapeConfig, 0, len(spec.Endpoints))
+	for i := range spec.Endpoints {
+		ep := &spec.Endpoints[i]
+		jobName := fmt.Sprintf("serviceMonitor/%s/%s/%d", sm.Metadata.Namespace, sm.Metadata.Name, i)
+		m, err := g.newEndpointScrapeConfig(jobName, ep, spec.SampleLimit)
+		if err != nil {
+			return nil, err
+		}
+		m["kubernetes_sd_configs"] = []any{
+			newOperatorKubernetesSDConfig("endpoints", &spec.NamespaceSelector, sm.Metadata.Namespace, spec.AttachMetadata),
+		}
+
+		rcs, err := appendSelectorRelabelConfigs(nil, "__meta_kubernetes_service", &spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if ep.Port != "" {
+			rcs = append(rcs, map[string]any{
+				"action":        "keep",
+				"source_labels": []string{"__meta_kubernetes_endpoint_port_name"},
+				"regex":         regexp.QuoteMeta(ep.Port),
+			})
+		} else if ep.TargetPort != nil {
+			rcs = appendTargetPortRelabelConfig(rcs, *ep.TargetPort)
+		}
+		for _, kind := range []string{"Node", "Pod"} {
+			rcs = append(rcs, map[string]any{
+				"source_labels": []string{"__meta_kubernetes_endpoint_address_target_kind", "__meta_kubernetes_endpoint_address_target_name"},
+				"separator":     ";",
+				"regex":         kind + ";(.*)",
+				"replacement":   "${1}",
+				"target_label":  strings.ToLower(kind),
+			})
+		}
+		rcs = appendPodRelabelConfigs(rcs)
+		rcs = append(rcs, map[string]any{
+			"source_labels": []string{"__meta_kubernetes_service_name"},
+			"target_label":  "service",
+		})
+		rcs = appendLabelsRelabelConfigs(rcs, "__meta_kubernetes_service_label_", spec.TargetLabels)
+		rcs = appendLabelsRelabelConfigs(rcs, "__meta_kubernetes_pod_label_", spec.PodTargetLabels)
+		rcs = append(rcs, map[string]any{
+			"source_labels": []string{"__meta_kubernetes_service_name"},
+			"target_label":  "job",
+		})
+		if spec.JobLabel != "" {
+			rcs = append(rcs, map[string]any{
+				"source_labels": []string{"__meta_kubernetes_service_label_" + discoveryutil.SanitizeLabelName(spec.JobLabel)},
+				"regex":         "(.+)",
+				"target_label":  "job",
+			})
+		}
+		rcs = appendEndpointRelabelConfig(rcs, ep)
+		rcs = appendOperatorRelabelConfigs(rcs, ep.RelabelConfigs)
+		m["relabel_configs"] = rcs
+
+		sc, err := g.newScrapeConfig(m)
+		if err != nil {
+			return nil, err
+		}
+		scs = append(scs, sc)
+	}
+	return scs, nil
+}
+
+func (g *operatorConfigGenerator) getPodMonitorScrapeConfigs(pm *kubernetes.PodMonitor) ([]*ScrapeConfig, error) {
+	spec := &pm.Spec
+	scs := make([]*ScrapeConfig, 0, len(spec.PodMetricsEndpoints))
+	for i := range spec.PodMetricsEndpoints {
+		ep := &spec.PodMetricsEndpoints[i]
+		jobName := fmt.Sprintf("podMonitor/%s/%s/%d", pm.Metadata.Namespace, pm.Metadata.Name, i)
+		m, err := g.newEndpointScrapeConfig(jobName, ep, spec.SampleLimit)
+		if err != nil {
+			return nil, err
+		}
+		m["kubernetes_sd_configs"] = []any{
+			newOperatorKubernetesSDConfig("pod", &spec.NamespaceSelector, pm.Metadata.Namespace, spec.AttachMetadata),
+		}
+
+		rcs, err := appendSelectorRelabelConfigs(nil, "__meta_kubernetes_pod", &spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if ep.Port != "" {
+			rcs = append(rcs, map[string]any{
+				"action":        "keep",
+				"source_labels": []string{"__meta_kubernetes_pod_container_port_name"},
+				"regex":         regexp.QuoteMeta(ep.Port),
+			})
+		} else if ep.TargetPort != nil {
+			rcs = appendTargetPortRelabelConfig(rcs, *ep.TargetPort)
+		}
+		rcs = appendPodRelabelConfigs(rcs)
+		rcs = appendLabelsRelabelConfigs(rcs, "__meta_kubernetes_pod_label_", spec.PodTargetLabels)
+		rcs = append(rcs, map[string]any{
+			"target_label": "job",
+			"replacement":  pm.Metadata.Namespace + "/" + pm.Metadata.Name,
+		})
+		if spec.JobLabel != "" {
+			rcs = append(rcs, map[string]any{
+				"source_labels": []string{"__meta_kubernetes_pod_label_" + discoveryutil.SanitizeLabelName(spec.JobLabel)},
+				"regex":         "(.+)",
+				"target_label":  "job",
+			})
+		}
+		rcs = appendEndpointRelabelConfig(rcs, ep)
+		rcs = appendOperatorRelabelConfigs(rcs, ep.RelabelConfigs)
+		m["relabel_configs"] = rcs
+
+		sc, err := g.newScrapeConfig(m)
+		if err != nil {
+			return nil, err
+		}
+		scs = append(scs, sc)
+	}
+	return scs, nil
+}
+
+func (g *operatorConfigGenerator) getProbeScrapeConfigs(p *kubernetes.Probe) ([]*ScrapeConfig, error) {
+	spec := &p.Spec
+	if spec.Prober.URL == "" {
+		return nil, fmt.Errorf("missing prober.url")
+	}
+	m := map[string]any{
+		"job_name":     fmt.Sprintf("probe/%s/%s", p.Metadata.Namespace, p.Metadata.Name),
+		"metrics_path": "/probe",
+	}
+	if spec.Prober.Path != "" {
+		m["metrics_path"] = spec.Prober.Path
+	}
+	if spec.Prober.Scheme != "" {
+		m["scheme"] = strings.ToLower(spec.Prober.Scheme)
+	}
+	if spec.Prober.ProxyURL != "" {
+		m["proxy_url"] = spec.Prober.ProxyURL
+	}
+	if spec.Module != "" {
+		m["params"] = map[string][]string{
+			"module": {spec.Module},
+		}
+	}
+	setOperatorScrapeOptions(m, spec.Interval, spec.ScrapeTimeout, spec.SampleLimit, spec.MetricRelabelConfigs)
+	if err := g.setHTTPClientConfig(m, spec.BearerTokenSecret, spec.BasicAuth, spec.Authorization, spec.TLSConfig); err != nil {
+		return nil, err
+	}
+
+	var rcs []map[string]any
+	var userRelabelConfigs []kubernetes.RelabelConfig
+	switch {
+	case spec.Targets.StaticConfig != nil:
+		stc := spec.Targets.StaticConfig
+		staticConfig := map[string]any{
+			"targets": stc.Targets,
+		}
+		if len(stc.Labels) > 0 {
+			staticConfig["labels"] = stc.Labels
+		}
+		m["static_configs"] = []any{staticConfig}
+		rcs = append(rcs, map[string]any{
+			"source_labels": []string{"__address__"},
+			"target_label":  "__param_target",
+		})
+		userRelabelConfigs = stc.RelabelConfigs
+	case spec.Targets.Ingress != nil:
+		ig := spec.Targets.Ingress
+		m["kubernetes_sd_configs"] = []any{
+			newOperatorKubernetesSDConfig("ingress", &ig.NamespaceSelector, p.Metadata.Namespace, nil),
+		}
+		var err error
+		rcs, err = appendSelectorRelabelConfigs(rcs, "__meta_kubernetes_ingress", &ig.Selector)
+		if err != nil {
+			return nil, err
+		}
+		rcs = append(rcs, map[string]any{
+			"source_labels": []string{"__meta_kubernetes_ingress_scheme", "__address__", "__meta_kubernetes_ingress_path"},
+			"separator":     ";",
+			"regex":         "(.+);(.+);(.+)",
+			"replacement":   "${1}://${2}${3}",
+			"target_label":  "__param_target",
+		}, map[string]any{
+			"source_labels": []string{"__meta_kubernetes_namespace"},
+			"target_label":  "namespace",
+		}, map[string]any{
+			"source_labels": []string{"__meta_kubernetes_ingress_name"},
+			"target_label":  "ingress",
+		})
+		userRelabelConfigs = ig.RelabelConfigs
+	default:
+		return nil, fmt.Errorf("missing targets; either targets.staticConfig or targets.ingress must be set")
+	}
+	rcs = append(rcs, map[string]any{
+		"source_labels": []string{"__param_target"},
+		"target_label":  "instance",
+	})
+	if spec.JobName != "" {
+		rcs = append(rcs, map[string]any{
+			"target_label": "job",
+			"replacement":  spec.JobName,
+		})
+	}
+	rcs = appendOperatorRelabelConfigs(rcs, userRelabelConfigs)
+	rcs = append(rcs, map[string]any{
+		"target_label": "__address__",
+		"replacement":  spec.Prober.URL,
+	})
+	m["relabel_configs"] = rcs
+
+	sc, err := g.newScrapeConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	return []*ScrapeConfig{sc}, nil
+}
+
+func (g *operatorConfigGenerator) getScrapeConfigScrapeConfigs(sco *kubernetes.ScrapeConfig) ([]*ScrapeConfig, error) {
+	spec := &sco.Spec
+	m := map[string]any{
+		"job_name": fmt.Sprintf("scrapeConfig/%s/%s", sco.Metadata.Namespace, sco.Metadata.Name),
+	}
+	if spec.MetricsPath != "" {
+		m["metrics_path"] = spec.MetricsPath
+	}
+	if spec.Scheme != "" {
+		m["scheme"] = strings.ToLower(spec.Scheme)
+	}
+	if len(spec.Params) > 0 {
+		m["params"] = spec.Params
+	}
+	if spec.HonorLabels {
+		m["honor_labels"] = true
+	}
+	if spec.HonorTimestamps != nil {
+		m["honor_timestamps"] = *spec.HonorTimestamps
+	}
+	setOperatorScrapeOptions(m, spec.ScrapeInterval, spec.ScrapeTimeout, spec.SampleLimit, spec.MetricRelabelConfigs)
+	if err := g.setHTTPClientConfig(m, nil, spec.BasicAuth, spec.Authorization, spec.TLSConfig); err != nil {
+		return nil, err
+	}
+
+	if len(spec.StaticConfigs) > 0 {
+		staticConfigs := make([]any, 0, len(spec.StaticConfigs))
+		for _, stc := range spec.StaticConfigs {
+			staticConfig := map[string]any{
+				"targets": stc.Targets,
+			}
+			if len(stc.Labels) > 0 {
+				staticConfig["labels"] = stc.Labels
+			}
+			staticConfigs = append(staticConfigs, staticConfig)
+		}
+		m["static_configs"] = staticConfigs
+	}
+	if len(spec.FileSDConfigs) > 0 {
+		fileSDConfigs := make([]any, 0, len(spec.FileSDConfigs))
+		for _, sdc := range spec.FileSDConfigs {
+			fileSDConfigs = append(fileSDConfigs, map[string]any{
+				"files": sdc.Files,
+			})
+		}
+		m["file_sd_configs"] = fileSDConfigs
+	}
+	if len(spec.HTTPSDConfigs) > 0 {
+		httpSDConfigs := make([]any, 0, len(spec.HTTPSDConfigs))
+		for _, sdc := range spec.HTTPSDConfigs {
+			httpSDConfigs = append(httpSDConfigs, map[string]any{
+				"url": sdc.URL,
+			})
+		}
+		m["http_sd_configs"] = httpSDConfigs
+	}
+	if len(spec.KubernetesSDConfigs) > 0 {
+		kubernetesSDConfigs := make([]any, 0, len(spec.KubernetesSDConfigs))
+		for _, sdc := range spec.KubernetesSDConfigs {
+			kubernetesSDConfig := map[string]any{
+				"role": strings.ToLower(sdc.Role),
+			}
+			if sdc.Namespaces != nil {
+				kubernetesSDConfig["namespaces"] = map[string]any{
+					"own_namespace": sdc.Namespaces.OwnNamespace,
+					"names":         sdc.Namespaces.Names,
+				}
+			}
+			if len(sdc.Selectors) > 0 {
+				selectors := make([]any, 0, len(sdc.Selectors))
+				for _, s := range sdc.Selectors {
+					selectors = append(selectors, map[string]any{
+						"role":  strings.ToLower(s.Role),
+						"label": s.Label,
+						"field": s.Field,
+					})
+				}
+				kubernetesSDConfig["selectors"] = selectors
+			}
+			kubernetesSDConfigs = append(kubernetesSDConfigs, kubernetesSDConfig)
+		}
+		m["kubernetes_sd_configs"] = kubernetesSDConfigs
+	}
+
+	var rcs []map[string]any
+	if spec.JobName != "" {
+		rcs = append(rcs, map[string]any{
+			"target_label": "job",
+			"replacement":  spec.JobName,
+		})
+	}
+	rcs = appendOperatorRelabelConfigs(rcs, spec.RelabelConfigs)
+	if len(rcs) > 0 {
+		m["relabel_configs"] = rcs
+	}
+
+	sc, err := g.newScrapeConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	return []*ScrapeConfig{sc}, nil
+}
+
+// newScrapeConfig returns ScrapeConfig for the given generated scrape config m.
+func (g *operatorConfigGenerator) newScrapeConfig(m map[string]any) (*ScrapeConfig, error) {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal generated scrape config: %w", err)
+	}
+	var sc ScrapeConfig
+	if err := yaml.UnmarshalStrict(data, &sc); err != nil {
+		return nil, fmt.Errorf("cannot parse generated scrape config: %w", err)
+	}
+	// The generated kubernetes_sd_configs must use the same Kubernetes API server as the one used for objects discovery.
+	for i := range sc.KubernetesSDConfigs {
+		sdc := &sc.KubernetesSDConfigs[i]
+		sdc.APIServer = g.cc.APIServer
+		sdc.KubeConfigFile = g.cc.KubeConfigFile
+		sdc.HTTPClientConfig = g.cc.HTTPClientConfig
+		sdc.ProxyURL = g.cc.ProxyURL
+	}
+	return &sc, nil
+}
+
+func (g *operatorConfigGenerator) newEndpointScrapeConfig(jobName string, ep *kubernetes.MonitorEndpoint, sampleLimit int) (map[string]any, error) {
+	m := map[string]any{
+		"job_name": jobName,
+	}
+	if ep.Path != "" {
+		m["metrics_path"] = ep.Path
+	}
+	if ep.Scheme != "" {
+		m["scheme"] = strings.ToLower(ep.Scheme)
+	}
+	if len(ep.Params) > 0 {
+		m["params"] = ep.Params
+	}
+	if ep.HonorLabels {
+		m["honor_labels"] = true
+	}
+	if ep.HonorTimestamps != nil {
+		m["honor_timestamps"] = *ep.HonorTimestamps
+	}
+	if ep.FollowRedirects != nil {
+		m["follow_redirects"] = *ep.FollowRedirects
+	}
+	if ep.ProxyURL != "" {
+		m["proxy_url"] = ep.ProxyURL
+	}
+	if ep.BearerTokenFile != "" {
+		m["bearer_token_file"] = ep.BearerTokenFile
+	}
+	setOperatorScrapeOptions(m, ep.Interval, ep.ScrapeTimeout, sampleLimit, ep.MetricRelabelConfigs)
+	if err := g.setHTTPClientConfig(m, ep.BearerTokenSecret, ep.BasicAuth, ep.Authorization, ep.TLSConfig); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func setOperatorScrapeOptions(m map[string]any, interval, scrapeTimeout string, sampleLimit int, metricRelabelConfigs []kubernetes.RelabelConfig) {
+	if interval != "" {
+		m["scrape_interval"] = interval
+	}
+	if scrapeTimeout != "" {
+		m["scrape_timeout"] = scrapeTimeout
+	}
+	if sampleLimit > 0 {
+		m["sample_limit"] = sampleLimit
+	}
+	if len(metricRelabelConfigs) > 0 {
+		m["metric_relabel_configs"] = appendOperatorRelabelConfigs(nil, metricRelabelConfigs)
+	}
+}
+
+// setHTTPClientConfig sets auth and tls options at m from the given options, which may refer to secrets and configmaps.
+func (g *operatorConfigGenerator) setHTTPClientConfig(m map[string]any, bearerTokenSecret *kubernetes.SecretKeySelector, basicAuth *kubernetes.BasicAuth,
+	authorization *kubernetes.SafeAuthorization, tlsConfig *kubernetes.SafeTLSConfig) error {
+
+	if bearerTokenSecret != nil && bearerTokenSecret.Name != "" {
+		token, err := g.getSecretValue(bearerTokenSecret)
+		if err != nil {
+			return fmt.Errorf("cannot read bearerTokenSecret: %w", err)
+		}
+		m["bearer_token"] = token
+	}
+	if basicAuth != nil {
+		username, err := g.getSecretValue(&basicAuth.Username)
+		if err != nil {
+			return fmt.Errorf("cannot read basicAuth.username: %w", err)
+		}
+		password, err := g.getSecretValue(&basicAuth.Password)
+		if err != nil {
+			return fmt.Errorf("cannot read basicAuth.password: %w", err)
+		}
+		m["basic_auth"] = map[string]any{
+			"username": username,
+			"password": password,
+		}
+	}
+	if authorization != nil {
+		a := make(map[string]any)
+		if authorization.Type != "" {
+			a["type"] = authorization.Type
+		}
+		if authorization.Credentials != nil {
+			credentials, err := g.getSecretValue(authorization.Credentials)
+			if err != nil {
+				return fmt.Errorf("cannot read authorization.credentials: %w", err)
+			}
+			a["credentials"] = credentials
+		}
+		m["authorization"] = a
+	}
+	if tlsConfig != nil {
+		tc := make(map[string]any)
+		ca, err := g.getSecretOrConfigMapValue(&tlsConfig.CA)
+		if err != nil {
+			return fmt.Errorf("cannot read tlsConfig.ca: %w", err)
+		}
+		if ca != "" {
+			tc["ca"] = ca
+		}
+		cert, err := g.getSecretOrConfigMapValue(&tlsConfig.Cert)
+		if err != nil {
+			return fmt.Errorf("cannot read tlsConfig.cert: %w", err)
+		}
+		if cert != "" {
+			tc["cert"] = cert
+		}
+		if tlsConfig.KeySecret != nil {
+			key, err := g.getSecretValue(tlsConfig.KeySecret)
+			if err != nil {
+				return fmt.Errorf("cannot read tlsConfig.keySecret: %w", err)
+			}
+			tc["key"] = key
+		}
+		if tlsConfig.CAFile != "" {
+			tc["ca_file"] = tlsConfig.CAFile
+		}
+		if tlsConfig.CertFile != "" {
+			tc["cert_file"] = tlsConfig.CertFile
+		}
+		if tlsConfig.KeyFile != "" {
+			tc["key_file"] = tlsConfig.KeyFile
+		}
+		if tlsConfig.ServerName != "" {
+			tc["server_name"] = tlsConfig.ServerName
+		}
+		if tlsConfig.InsecureSkipVerify {
+			tc["insecure_skip_verify"] = true
+		}
+		m["tls_config"] = tc
+	}
+	return nil
+}
+
+func (g *operatorConfigGenerator) getSecretValue(sks *kubernetes.SecretKeySelector) (string, error) {
+	return g.sg.GetSecretValue(g.namespace, sks.Name, sks.Key)
+}
+
+func (g *operatorConfigGenerator) getSecretOrConfigMapValue(sc *kubernetes.SecretOrConfigMap) (string, error) {
+	if sc.Secret != nil {
+		return g.getSecretValue(sc.Secret)
+	}
+	if sc.ConfigMap != nil {
+		return g.sg.GetConfigMapValue(g.namespace, sc.ConfigMap.Name, sc.ConfigMap.Key)
+	}
+	return "", nil
+}
+
+// newOperatorKubernetesSDConfig returns kubernetes_sd_config for the given role and namespaceSelector.
+//
+// Only the namespace of the object is used if namespaceSelector is empty.
+func newOperatorKubernetesSDConfig(role string, namespaceSelector *kubernetes.NamespaceSelector, namespace string, attachMetadata *kubernetes.AttachMetadataCRD) map[string]any {
+	sdc := map[string]any{
+		"role": role,
+	}
+	if !namespaceSelector.Any {
+		names := namespaceSelector.MatchNames
+		if len(names) == 0 {
+			names = []string{namespace}
+		}
+		sdc["namespaces"] = map[string]any{
+			"names": names,
+		}
+	}
+	if attachMetadata != nil && attachMetadata.Node {
+		sdc["attach_metadata"] = map[string]any{
+			"node": true,
+		}
+	}
+	return sdc
+}
+
+// appendSelectorRelabelConfigs appends relabeling rules, which keep only targets matching the given label selector ls.
+//
+// prefix must contain meta label prefix for the object labels such as __meta_kubernetes_service.
+func appendSelectorRelabelConfigs(dst []map[string]any, prefix string, ls *kubernetes.LabelSelector) ([]map[string]any, error) {
+	keys := make([]string, 0, len(ls.MatchLabels))
+	for key := range ls.MatchLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := discoveryutil.SanitizeLabelName(key)
+		dst = append(dst, map[string]any{
+			"action":        "keep",
+			"source_labels": []string{prefix + "_label_" + name, prefix + "_labelpresent_" + name},
+			"regex":         "(" + regexp.QuoteMeta(ls.MatchLabels[key]) + ");true",
+		})
+	}
+	for _, e := range ls.MatchExpressions {
+		name := discoveryutil.SanitizeLabelName(e.Key)
+		values := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			values[i] = regexp.QuoteMeta(v)
+		}
+		valuesRegex := "(" + strings.Join(values, "|") + ");true"
+		switch e.Operator {
+		case "In":
+			dst = append(dst, map[string]any{
+				"action":        "keep",
+				"source_labels": []string{prefix + "_label_" + name, prefix + "_labelpresent_" + name},
+				"regex":         valuesRegex,
+			})
+		case "NotIn":
+			dst = append(dst, map[string]any{
+				"action":        "drop",
+				"source_labels": []string{prefix + "_label_" + name, prefix + "_labelpresent_" + name},
+				"regex":         valuesRegex,
+			})
+		case "Exists":
+			dst = append(dst, map[string]any{
+				"action":        "keep",
+				"source_labels": []string{prefix + "_labelpresent_" + name},
+				"regex":         "true",
+			})
+		case "DoesNotExist":
+			dst = append(dst, map[string]any{
+				"action":        "drop",
+				"source_labels": []string{prefix + "_labelpresent_" + name},
+				"regex":         "true",
+			})
+		default:
+			return nil, fmt.Errorf("unsupported operator %q in label selector for key %q; supported operators: In, NotIn, Exists, DoesNotExist", e.Operator, e.Key)
+		}
+	}
+	return dst, nil
+}
+
+func appendTargetPortRelabelConfig(dst []map[string]any, targetPort kubernetes.IntOrString) []map[string]any {
+	sourceLabel := "__meta_kubernetes_pod_container_port_name"
+	if targetPort.IsInt() {
+		sourceLabel = "__meta_kubernetes_pod_container_port_number"
+	}
+	return append(dst, map[string]any{
+		"action":        "keep",
+		"source_labels": []string{sourceLabel},
+		"regex":         regexp.QuoteMeta(string(targetPort)),
+	})
+}
+
+// appendPodRelabelConfigs appends relabeling rules, which drop terminated pods and set namespace, pod and container labels.
+func appendPodRelabelConfigs(dst []map[string]any) []map[string]any {
+	return append(dst, map[string]any{
+		"action":        "drop",
+		"source_labels": []string{"__meta_kubernetes_pod_phase"},
+		"regex":         "(Failed|Succeeded)",
+	}, map[string]any{
+		"source_labels": []string{"__meta_kubernetes_namespace"},
+		"target_label":  "namespace",
+	}, map[string]any{
+		"source_labels": []string{"__meta_kubernetes_pod_name"},
+		"target_label":  "pod",
+	}, map[string]any{
+		"source_labels": []string{"__meta_kubernetes_pod_container_name"},
+		"target_label":  "container",
+	})
+}
+
+// appendLabelsRelabelConfigs appends relabeling rules, which copy the given object labels to target labels.
+func appendLabelsRelabelConfigs(dst []map[string]any, prefix string, labels []string) []map[string]any {
+	for _, label := range labels {
+		name := discoveryutil.SanitizeLabelName(label)
+		dst = append(dst, map[string]any{
+			"source_labels": []string{prefix + name},
+			"regex":         "(.+)",
+			"target_label":  name,
+		})
+	}
+	return dst
+}
+
+// appendEndpointRelabelConfig appends relabeling rule, which sets endpoint label to the port name of ep.
+func appendEndpointRelabelConfig(dst []map[string]any, ep *kubernetes.MonitorEndpoint) []map[string]any {
+	endpoint := ep.Port
+	if endpoint == "" && ep.TargetPort != nil {
+		endpoint = string(*ep.TargetPort)
+	}
+	if endpoint == "" {
+		return dst
+	}
+	return append(dst, map[string]any{
+		"target_label": "endpoint",
+		"replacement":  endpoint,
+	})
+}
+
+// appendOperatorRelabelConfigs converts relabeling rules from Prometheus Operator objects to relabel_configs.
+func appendOperatorRelabelConfigs(dst []map[string]any, rcs []kubernetes.RelabelConfig) []map[string]any {
+	for _, rc := range rcs {
+		m := make(map[string]any)
+		if rc.Action != "" {
+			m["action"] = strings.ToLower(rc.Action)
+		}
+		if len(rc.SourceLabels) > 0 {
+			m["source_labels"] = rc.SourceLabels
+		}
+		if rc.Separator != nil {
+			m["separator"] = *rc.Separator
+		}
+		if rc.TargetLabel != "" {
+			m["target_label"] = rc.TargetLabel
+		}
+		if rc.Regex != "" {
+			m["regex"] = rc.Regex
+		}
+		if rc.Modulus > 0 {
+			m["modulus"] = rc.Modulus
+		}
+		if rc.Replacement != nil {
+			m["replacement"] = *rc.Replacement
+		}
+		dst = append(dst, m)
+	}
+	return dst
+}
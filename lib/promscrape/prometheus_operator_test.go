@@ -0,0 +1,413 @@
+package promscrape
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discovery/kubernetes"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+type fakeOperatorSecretGetter map[string]string
+
+func (sg fakeOperatorSecretGetter) GetSecretValue(namespace, name, key string) (string, error) {
+	return sg.get("secret/" + namespace + "/" + name + "/" + key)
+}
+
+func (sg fakeOperatorSecretGetter) GetConfigMapValue(namespace, name, key string) (string, error) {
+	return sg.get("configmap/" + namespace + "/" + name + "/" + key)
+}
+
+func (sg fakeOperatorSecretGetter) get(key string) (string, error) {
+	v, ok := sg[key]
+	if !ok {
+		return "", fmt.Errorf("missing %s", key)
+	}
+	return v, nil
+}
+
+func newTestOperatorConfigGenerator(namespace string) *operatorConfigGenerator {
+	return &operatorConfigGenerator{
+		cc: &kubernetes.CRDConfig{
+			APIServer: "http://kube-api:8080",
+		},
+		sg: fakeOperatorSecretGetter{
+			"secret/default/auth/username": "user",
+			"secret/default/auth/password": "pass",
+			"secret/default/token/token":   "secret-token",
+			"configmap/default/tls/ca.crt": "ca-data",
+		},
+		namespace: namespace,
+	}
+}
+
+func generateOperatorScrapeConfigs(kind, data string) ([]*ScrapeConfig, error) {
+	scs, err := generateOperatorScrapeConfigsInternal(kind, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range scs {
+		swc, err := getScrapeWorkConfig(sc, ".", &GlobalConfig{})
+		if err != nil {
+			return nil, err
+		}
+		sc.swc = swc
+	}
+	return scs, nil
+}
+
+func generateOperatorScrapeConfigsInternal(kind, data string) ([]*ScrapeConfig, error) {
+	g := newTestOperatorConfigGenerator("default")
+	switch kind {
+	case "ServiceMonitor":
+		var sm kubernetes.ServiceMonitor
+		if err := json.Unmarshal([]byte(data), &sm); err != nil {
+			return nil, err
+		}
+		return g.getServiceMonitorScrapeConfigs(&sm)
+	case "PodMonitor":
+		var pm kubernetes.PodMonitor
+		if err := json.Unmarshal([]byte(data), &pm); err != nil {
+			return nil, err
+		}
+		return g.getPodMonitorScrapeConfigs(&pm)
+	case "Probe":
+		var p kubernetes.Probe
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			return nil, err
+		}
+		return g.getProbeScrapeConfigs(&p)
+	case "ScrapeConfig":
+		var sc kubernetes.ScrapeConfig
+		if err := json.Unmarshal([]byte(data), &sc); err != nil {
+			return nil, err
+		}
+		return g.getScrapeConfigScrapeConfigs(&sc)
+	default:
+		return nil, fmt.Errorf("unexpected kind %q", kind)
+	}
+}
+
+func TestOperatorScrapeConfigsFailure(t *testing.T) {
+	f := func(kind, data string) {
+		t.Helper()
+
+		scs, err := generateOperatorScrapeConfigs(kind, data)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if scs != nil {
+			t.Fatalf("expecting nil scrape configs; got %d configs", len(scs))
+		}
+	}
+
+	// unsupported label selector operator
+	f("ServiceMonitor", `{"metadata":{"name":"sm","namespace":"default"},"spec":{
+		"selector":{"matchExpressions":[{"key":"app","operator":"Foo","values":["x"]}]},
+		"endpoints":[{"port":"web"}]}}`)
+
+	// missing secret
+	f("ServiceMonitor", `{"metadata":{"name":"sm","namespace":"default"},"spec":{
+		"endpoints":[{"port":"web","bearerTokenSecret":{"name":"missing","key":"token"}}]}}`)
+
+	// invalid relabeling action
+	f("PodMonitor", `{"metadata":{"name":"pm","namespace":"default"},"spec":{
+		"podMetricsEndpoints":[{"port":"web","relabelings":[{"action":"foobar"}]}]}}`)
+
+	// missing prober url
+	f("Probe", `{"metadata":{"name":"p","namespace":"default"},"spec":{"targets":{"staticConfig":{"static":["foo"]}}}}`)
+
+	// missing probe targets
+	f("Probe", `{"metadata":{"name":"p","namespace":"default"},"spec":{"prober":{"url":"blackbox:9115"}}}`)
+
+	// invalid CA in configmap
+	f("PodMonitor", `{"metadata":{"name":"pm","namespace":"default"},"spec":{
+		"podMetricsEndpoints":[{"port":"web","tlsConfig":{"ca":{"configMap":{"name":"tls","key":"ca.crt"}}}}]}}`)
+
+	// invalid scrape interval
+	f("ScrapeConfig", `{"metadata":{"name":"sc","namespace":"default"},"spec":{"scrapeInterval":"foo"}}`)
+}
+
+func TestOperatorScrapeConfigsSuccess(t *testing.T) {
+	f := func(kind, data string, metaLabels map[string]string, scrapeURLExpected, labelsExpected string) {
+		t.Helper()
+
+		scs, err := generateOperatorScrapeConfigs(kind, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(scs) != 1 {
+			t.Fatalf("unexpected number of scrape configs; got %d; want 1", len(scs))
+		}
+		sc := scs[0]
+		for _, sdc := range sc.KubernetesSDConfigs {
+			if sdc.APIServer != "http://kube-api:8080" {
+				t.Fatalf("unexpected api_server in the generated kubernetes_sd_config; got %q", sdc.APIServer)
+			}
+		}
+		ml := promutil.NewLabelsFromMap(metaLabels)
+		sw, err := sc.swc.getScrapeWork(ml.Get("__address__"), nil, ml)
+		if err != nil {
+			t.Fatalf("cannot create scrape work: %s", err)
+		}
+		if sw == nil {
+			if scrapeURLExpected != "" {
+				t.Fatalf("unexpected dropped target; want target with scrape url %q", scrapeURLExpected)
+			}
+			return
+		}
+		if sw.ScrapeURL != scrapeURLExpected {
+			t.Fatalf("unexpected scrape url; got %q; want %q", sw.ScrapeURL, scrapeURLExpected)
+		}
+		if labels := sw.Labels.String(); labels != labelsExpected {
+			t.Fatalf("unexpected labels;\ngot\n%s\nwant\n%s", labels, labelsExpected)
+		}
+	}
+
+	serviceMonitor := `{"metadata":{"name":"sm","namespace":"default"},"spec":{
+		"jobLabel":"app",
+		"targetLabels":["team"],
+		"selector":{"matchLabels":{"app":"foo"},"matchExpressions":[{"key":"tier","operator":"NotIn","values":["canary"]}]},
+		"endpoints":[{
+			"port":"web",
+			"path":"/custom-metrics",
+			"interval":"10s",
+			"basicAuth":{"username":{"name":"auth","key":"username"},"password":{"name":"auth","key":"password"}},
+			"relabelings":[{"targetLabel":"env","replacement":"prod"}]
+		}]}}`
+	endpointLabels := map[string]string{
+		"__address__":                                    "10.0.0.1:8080",
+		"__meta_kubernetes_namespace":                    "default",
+		"__meta_kubernetes_service_name":                 "foo-svc",
+		"__meta_kubernetes_service_label_app":            "foo",
+		"__meta_kubernetes_service_labelpresent_app":     "true",
+		"__meta_kubernetes_service_label_team":           "infra",
+		"__meta_kubernetes_service_labelpresent_team":    "true",
+		"__meta_kubernetes_endpoint_port_name":           "web",
+		"__meta_kubernetes_endpoint_address_target_kind": "Pod",
+		"__meta_kubernetes_endpoint_address_target_name": "foo-0",
+		"__meta_kubernetes_pod_name":                     "foo-0",
+		"__meta_kubernetes_pod_container_name":           "app",
+		"__meta_kubernetes_pod_phase":                    "Running",
+	}
+	f("ServiceMonitor", serviceMonitor, endpointLabels, "http://10.0.0.1:8080/custom-metrics",
+		`{container="app",endpoint="web",env="prod",instance="10.0.0.1:8080",job="foo",namespace="default",pod="foo-0",service="foo-svc",team="infra"}`)
+
+	// ServiceMonitor target with non-matching port must be dropped
+	m := copyStringMap(endpointLabels)
+	m["__meta_kubernetes_endpoint_port_name"] = "metrics"
+	f("ServiceMonitor", serviceMonitor, m, "", "")
+
+	// ServiceMonitor target with non-matching selector must be dropped
+	m = copyStringMap(endpointLabels)
+	m["__meta_kubernetes_service_label_tier"] = "canary"
+	m["__meta_kubernetes_service_labelpresent_tier"] = "true"
+	f("ServiceMonitor", serviceMonitor, m, "", "")
+
+	// ServiceMonitor target for terminated pod must be dropped
+	m = copyStringMap(endpointLabels)
+	m["__meta_kubernetes_pod_phase"] = "Succeeded"
+	f("ServiceMonitor", serviceMonitor, m, "", "")
+
+	// PodMonitor
+	podMonitor := `{"metadata":{"name":"pm","namespace":"default"},"spec":{
+		"selector":{"matchExpressions":[{"key":"app","operator":"Exists"}]},
+		"podMetricsEndpoints":[{"targetPort":8080,"scheme":"HTTPS","tlsConfig":{"serverName":"bar","insecureSkipVerify":true}}]}}`
+	podLabels := map[string]string{
+		"__address__":                                 "10.0.0.2:8080",
+		"__meta_kubernetes_namespace":                 "default",
+		"__meta_kubernetes_pod_name":                  "bar-0",
+		"__meta_kubernetes_pod_container_name":        "bar",
+		"__meta_kubernetes_pod_container_port_number": "8080",
+		"__meta_kubernetes_pod_label_app":             "bar",
+		"__meta_kubernetes_pod_labelpresent_app":      "true",
+		"__meta_kubernetes_pod_phase":                 "Running",
+	}
+	f("PodMonitor", podMonitor, podLabels, "https://10.0.0.2:8080/metrics",
+		`{container="bar",endpoint="8080",instance="10.0.0.2:8080",job="default/pm",namespace="default",pod="bar-0"}`)
+
+	// PodMonitor target without the required label must be dropped
+	m = copyStringMap(podLabels)
+	delete(m, "__meta_kubernetes_pod_labelpresent_app")
+	f("PodMonitor", podMonitor, m, "", "")
+
+	// Probe with static targets
+	f("Probe", `{"metadata":{"name":"p","namespace":"default"},"spec":{
+		"jobName":"blackbox",
+		"module":"http_2xx",
+		"prober":{"url":"blackbox:9115"},
+		"bearerTokenSecret":{"name":"token","key":"token"},
+		"targets":{"staticConfig":{"static":["https://example.com"],"labels":{"env":"prod"}}}}}`,
+		map[string]string{
+			"__address__": "https://example.com",
+			"env":         "prod",
+		}, "http://blackbox:9115/probe?module=http_2xx&target=https%3A%2F%2Fexample.com",
+		`{env="prod",instance="https://example.com",job="blackbox"}`)
+
+	// Probe with ingress targets
+	f("Probe", `{"metadata":{"name":"p","namespace":"default"},"spec":{
+		"prober":{"url":"blackbox:9115","path":"/custom-probe"},
+		"targets":{"ingress":{"selector":{"matchLabels":{"probe":"yes"}}}}}}`,
+		map[string]string{
+			"__address__":                                  "example.com",
+			"__meta_kubernetes_namespace":                  "default",
+			"__meta_kubernetes_ingress_name":               "web",
+			"__meta_kubernetes_ingress_scheme":             "https",
+			"__meta_kubernetes_ingress_path":               "/health",
+			"__meta_kubernetes_ingress_label_probe":        "yes",
+			"__meta_kubernetes_ingress_labelpresent_probe": "true",
+		}, "http://blackbox:9115/custom-probe?target=https%3A%2F%2Fexample.com%2Fhealth",
+		`{ingress="web",instance="https://example.com/health",job="probe/default/p",namespace="default"}`)
+
+	// ScrapeConfig
+	f("ScrapeConfig", `{"metadata":{"name":"sc","namespace":"default"},"spec":{
+		"jobName":"node",
+		"metricsPath":"/federate",
+		"params":{"match[]":["up"]},
+		"authorization":{"credentials":{"name":"token","key":"token"}},
+		"staticConfigs":[{"targets":["node:9100"],"labels":{"dc":"eu"}}],
+		"relabelings":[{"sourceLabels":["dc"],"targetLabel":"region","regex":"(.+)","replacement":"${1}-1","action":"Replace"}]}}`,
+		map[string]string{
+			"__address__": "node:9100",
+			"dc":          "eu",
+		}, "http://node:9100/federate?match%5B%5D=up",
+		`{dc="eu",instance="node:9100",job="node",region="eu-1"}`)
+}
+
+func TestOperatorSecretReaderAreChanged(t *testing.T) {
+	secrets := fakeOperatorSecretGetter{
+		"secret/default/auth/username": "user",
+		"secret/default/auth/password": "pass",
+		"secret/default/token/token":   "secret-token",
+	}
+	var sm kubernetes.ServiceMonitor
+	data := `{"metadata":{"name":"sm","namespace":"default"},"spec":{"endpoints":[
+		{"port":"web","basicAuth":{"username":{"name":"auth","key":"username"},"password":{"name":"auth","key":"password"}}},
+		{"port":"metrics","bearerTokenSecret":{"name":"token","key":"token"}}]}}`
+	if err := json.Unmarshal([]byte(data), &sm); err != nil {
+		t.Fatalf("cannot parse ServiceMonitor: %s", err)
+	}
+
+	// Generate scrape configs and record the secrets referred by the object.
+	sr := newOperatorSecretReader(secrets)
+	g := &operatorConfigGenerator{
+		cc:        &kubernetes.CRDConfig{},
+		sg:        sr,
+		namespace: "default",
+	}
+	sr.startRecording()
+	if _, err := g.getServiceMonitorScrapeConfigs(&sm); err != nil {
+		t.Fatalf("cannot generate scrape configs: %s", err)
+	}
+	recorded := sr.stopRecording()
+	if len(recorded) != 3 {
+		t.Fatalf("unexpected number of recorded secrets; got %d; want 3; recorded: %v", len(recorded), recorded)
+	}
+
+	f := func(resultExpected bool) {
+		t.Helper()
+		sr := newOperatorSecretReader(secrets)
+		if result := sr.areChanged(recorded); result != resultExpected {
+			t.Fatalf("unexpected areChanged result; got %v; want %v", result, resultExpected)
+		}
+	}
+
+	// unchanged secrets
+	f(false)
+
+	// rotated password
+	secrets["secret/default/auth/password"] = "new-pass"
+	f(true)
+	secrets["secret/default/auth/password"] = "pass"
+	f(false)
+
+	// deleted token
+	delete(secrets, "secret/default/token/token")
+	f(true)
+}
+
+func TestOperatorScrapeConfigsJobNames(t *testing.T) {
+	scs, err := generateOperatorScrapeConfigs("ServiceMonitor", `{"metadata":{"name":"sm","namespace":"default"},"spec":{
+		"namespaceSelector":{"any":true},
+		"endpoints":[{"port":"web"},{"port":"metrics","interval":"1m"}]}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(scs) != 2 {
+		t.Fatalf("unexpected number of scrape configs; got %d; want 2", len(scs))
+	}
+	for i, sc := range scs {
+		jobNameExpected := fmt.Sprintf("serviceMonitor/default/sm/%d", i)
+		if sc.JobName != jobNameExpected {
+			t.Fatalf("unexpected job_name; got %q; want %q", sc.JobName, jobNameExpected)
+		}
+		if len(sc.KubernetesSDConfigs) != 1 {
+			t.Fatalf("unexpected number of kubernetes_sd_configs; got %d; want 1", len(sc.KubernetesSDConfigs))
+		}
+		if names := sc.KubernetesSDConfigs[0].Namespaces.Names; len(names) != 0 {
+			t.Fatalf("unexpected namespaces for namespaceSelector.any=true: %q", names)
+		}
+	}
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func TestPrometheusOperatorConfigParse(t *testing.T) {
+	f := func(data string, kindsExpected []string, errExpected bool) {
+		t.Helper()
+
+		var cfg Config
+		err := cfg.parseData([]byte(data), "sss")
+		if errExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(cfg.PrometheusOperatorConfigs) != 1 {
+			t.Fatalf("unexpected number of prometheus_operator_configs; got %d; want 1", len(cfg.PrometheusOperatorConfigs))
+		}
+		poc := cfg.PrometheusOperatorConfigs[0]
+		if !reflect.DeepEqual(poc.Kinds, kindsExpected) {
+			t.Fatalf("unexpected kinds; got %q; want %q", poc.Kinds, kindsExpected)
+		}
+	}
+
+	f(`
+prometheus_operator_configs:
+- api_server: http://kube-api:8080
+  bearer_token: foo
+  namespaces:
+    names: [monitoring]
+  kinds: [ServiceMonitor, PodMonitor]
+`, []string{"ServiceMonitor", "PodMonitor"}, false)
+
+	// all the kinds by default
+	f(`
+prometheus_operator_configs:
+- api_server: http://kube-api:8080
+`, nil, false)
+
+	// unsupported kind
+	f(`
+prometheus_operator_configs:
+- kinds: [Alertmanager]
+`, nil, true)
+
+	// unknown field
+	f(`
+prometheus_operator_configs:
+- foobar: baz
+`, nil, true)
+}
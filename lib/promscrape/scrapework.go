@@ -26,6 +26,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/probe"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
 	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus/stream"
@@ -130,6 +131,10 @@ type ScrapeWork struct {
 	// Whether to disable response compression when querying ScrapeURL.
 	DisableCompression bool
 
+	// Optional list of protocols to negotiate with the scrape target in the order of preference.
+	// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#scrape_config
+	ScrapeProtocols []string
+
 	// Whether to disable HTTP keep-alive when querying ScrapeURL.
 	DisableKeepAlive bool
 
@@ -152,6 +157,12 @@ type ScrapeWork struct {
 	// The Tenant Info
 	AuthToken *auth.Token
 
+	// Optional probe config for targets from `probe_configs` section.
+	//
+	// If it is set, then the target is probed by the built-in prober instead of scraping ScrapeURL.
+	// See https://docs.victoriametrics.com/victoriametrics/vmagent/#probing-targets
+	ProbeConfig *probe.ParsedConfig
+
 	// The original 'job_name'
 	jobNameOriginal string
 }
@@ -172,13 +183,13 @@ func (sw *ScrapeWork) key() string {
 	key := fmt.Sprintf("JobNameOriginal=%s, ScrapeURL=%s, ScrapeInterval=%s, ScrapeTimeout=%s, HonorLabels=%v, "+
 		"HonorTimestamps=%v, DenyRedirects=%v, Labels=%s, ExternalLabels=%s, MaxScrapeSize=%d, "+
 		"ProxyURL=%s, ProxyAuthConfig=%s, AuthConfig=%s, MetricRelabelConfigs=%q, "+
-		"SampleLimit=%d, DisableCompression=%v, DisableKeepAlive=%v, StreamParse=%v, "+
-		"ScrapeAlignInterval=%s, ScrapeOffset=%s, SeriesLimit=%d, NoStaleMarkers=%v",
+		"SampleLimit=%d, DisableCompression=%v, ScrapeProtocols=%q, DisableKeepAlive=%v, StreamParse=%v, "+
+		"ScrapeAlignInterval=%s, ScrapeOffset=%s, SeriesLimit=%d, NoStaleMarkers=%v, ProbeConfig=%q",
 		sw.jobNameOriginal, sw.ScrapeURL, sw.ScrapeInterval, sw.ScrapeTimeout, sw.HonorLabels,
 		sw.HonorTimestamps, sw.DenyRedirects, sw.Labels.String(), sw.ExternalLabels.String(), sw.MaxScrapeSize,
 		sw.ProxyURL.String(), sw.ProxyAuthConfig.String(), sw.AuthConfig.String(), sw.MetricRelabelConfigs.String(),
-		sw.SampleLimit, sw.DisableCompression, sw.DisableKeepAlive, sw.StreamParse,
-		sw.ScrapeAlignInterval, sw.ScrapeOffset, sw.SeriesLimit, sw.NoStaleMarkers)
+		sw.SampleLimit, sw.DisableCompression, sw.ScrapeProtocols, sw.DisableKeepAlive, sw.StreamParse,
+		sw.ScrapeAlignInterval, sw.ScrapeOffset, sw.SeriesLimit, sw.NoStaleMarkers, sw.ProbeConfig.String())
 	return key
 }
 
@@ -287,7 +298,7 @@ func (sw *scrapeWork) run(stopCh <-chan struct{}, globalStopCh <-chan struct{})
 		// scrapes replicated targets at different time offsets. This guarantees that the deduplication consistently leaves samples
 		// received from the same vmagent replica.
 		// See https://docs.victoriametrics.com/victoriametrics/vmagent/#scraping-big-number-of-targets
-		key := fmt.Sprintf("clusterName=%s, clusterMemberID=%d, ScrapeURL=%s, Labels=%s", *clusterName, clusterMemberID, sw.Config.ScrapeURL, sw.Config.Labels.String())
+		key := fmt.Sprintf("clusterName=%s, clusterMemberID=%s, ScrapeURL=%s, Labels=%s", *clusterName, getClusterMemberIDString(), sw.Config.ScrapeURL, sw.Config.Labels.String())
 		h := xxhash.Sum64(bytesutil.ToUnsafeBytes(key))
 		randSleep = uint64(float64(scrapeInterval) * (float64(h) / (1 << 64)))
 		sleepOffset := uint64(time.Now().UnixNano()) % uint64(scrapeInterval)
@@ -563,6 +574,7 @@ func (sw *scrapeWork) processDataOneShot(scrapeTimestamp, realTimestamp int64, b
 		seriesLimitSamplesDropped: samplesDropped,
 	}
 	wc.addAutoMetrics(sw, am, scrapeTimestamp)
+	wc.addMetadata(bodyString)
 
 	sw.pushData(&wc.writeRequest)
 	sw.prevLabelsLen = len(wc.labels)
@@ -662,7 +674,7 @@ func (sw *scrapeWork) processDataInStreamMode(scrapeTimestamp, realTimestamp int
 		seriesAdded:               seriesAdded,
 		seriesLimitSamplesDropped: int(samplesDroppedTotal.Load()),
 	}
-	sw.pushAutoMetrics(am, scrapeTimestamp)
+	sw.pushAutoMetrics(am, scrapeTimestamp, bodyString)
 
 	if !areIdenticalSeries {
 		// Send stale markers for disappeared metrics with the real scrape timestamp
@@ -679,10 +691,11 @@ func (sw *scrapeWork) processDataInStreamMode(scrapeTimestamp, realTimestamp int
 	return err
 }
 
-// pushAutoMetrics pushes am with the given timestamp to sw.
-func (sw *scrapeWork) pushAutoMetrics(am *autoMetrics, timestamp int64) {
+// pushAutoMetrics pushes am with the given timestamp to sw together with the metadata from the scrape response body.
+func (sw *scrapeWork) pushAutoMetrics(am *autoMetrics, timestamp int64, body string) {
 	wc := writeRequestCtxPool.Get(sw.autoMetricsLabelsLen)
 	wc.addAutoMetrics(sw, am, timestamp)
+	wc.addMetadata(body)
 	sw.pushData(&wc.writeRequest)
 	sw.autoMetricsLabelsLen = len(wc.labels)
 	writeRequestCtxPool.Put(wc)
@@ -26,6 +26,10 @@ var maxDroppedTargets = flag.Int("promscrape.maxDroppedTargets", 10000, "The max
 	"Increase this value if your setup drops more scrape targets during relabeling and you need investigating labels for all the dropped targets. "+
 	"Note that the increased number of tracked dropped targets may result in increased memory usage")
 
+var targetHistorySize = flag.Int("promscrape.targetHistorySize", 10, "The number of the most recent scrape results to keep per each scrape target. "+
+	"The results are shown at /targets page and at /api/v1/targets?history=1 page. Set it to 0 for disabling the history of scrape results. "+
+	"See https://docs.victoriametrics.com/victoriametrics/vmagent/#scrape-history")
+
 var tsmGlobal = newTargetStatusMap()
 
 // WriteTargetResponse serves requests to /target_response?id=<id>
@@ -68,13 +72,15 @@ func WriteServiceDiscovery(w http.ResponseWriter, r *http.Request) {
 }
 
 // WriteAPIV1Targets writes /api/v1/targets to w according to https://prometheus.io/docs/prometheus/latest/querying/api/#targets
-func WriteAPIV1Targets(w io.Writer, state, scrapePool string) {
+//
+// The history of the most recent scrapes is written for every active target if withHistory is set.
+func WriteAPIV1Targets(w io.Writer, state, scrapePool string, withHistory bool) {
 	if state == "" {
 		state = "any"
 	}
 	fmt.Fprintf(w, `{"status":"success","data":{"activeTargets":`)
 	if state == "active" || state == "any" {
-		tsmGlobal.WriteActiveTargetsJSON(w, scrapePool)
+		tsmGlobal.WriteActiveTargetsJSON(w, scrapePool, withHistory)
 	} else {
 		fmt.Fprintf(w, `[]`)
 	}
@@ -204,6 +210,14 @@ func (tsm *targetStatusMap) Update(sw *scrapeWork, up bool, scrapeTime, scrapeDu
 		ts.scrapesFailed++
 	}
 	ts.err = err
+	ts.addHistory(scrapeResult{
+		up:                 up,
+		scrapeTime:         scrapeTime,
+		scrapeDuration:     scrapeDuration,
+		scrapeResponseSize: scrapeResponseSize,
+		samplesScraped:     samplesScraped,
+		err:                err,
+	})
 	tsm.mu.Unlock()
 }
 
@@ -241,7 +255,12 @@ func (tsm *targetStatusMap) getActiveTargetStatuses() []targetStatus {
 	tsm.mu.Lock()
 	tss := make([]targetStatus, 0, len(tsm.m))
 	for _, ts := range tsm.m {
-		tss = append(tss, *ts)
+		tsCopy := *ts
+		// Copy the history, since it may be modified by concurrent Update() calls after tsm.mu is unlocked.
+		// The copied history is ordered from the oldest to the newest scrape result.
+		tsCopy.history = ts.getHistory()
+		tsCopy.historyNextIdx = 0
+		tss = append(tss, tsCopy)
 	}
 	tsm.mu.Unlock()
 	// Sort discovered targets by __address__ label, so they stay in consistent order across calls
@@ -254,7 +273,9 @@ func (tsm *targetStatusMap) getActiveTargetStatuses() []targetStatus {
 }
 
 // WriteActiveTargetsJSON writes `activeTargets` contents to w according to https://prometheus.io/docs/prometheus/latest/querying/api/#targets
-func (tsm *targetStatusMap) WriteActiveTargetsJSON(w io.Writer, scrapePoolFilter string) {
+//
+// The history of the most recent scrapes is written in non-standard `scrapeHistory` field if withHistory is set.
+func (tsm *targetStatusMap) WriteActiveTargetsJSON(w io.Writer, scrapePoolFilter string, withHistory bool) {
 	tss := tsm.getActiveTargetStatuses()
 	fmt.Fprintf(w, `[`)
 	var needComma bool
@@ -281,6 +302,10 @@ func (tsm *targetStatusMap) WriteActiveTargetsJSON(w io.Writer, scrapePoolFilter
 		fmt.Fprintf(w, `,"lastScrape":"%s"`, time.Unix(ts.scrapeTime/1000, (ts.scrapeTime%1000)*1e6).Format(time.RFC3339Nano))
 		fmt.Fprintf(w, `,"lastScrapeDuration":%g`, (time.Millisecond * time.Duration(ts.scrapeDuration)).Seconds())
 		fmt.Fprintf(w, `,"lastSamplesScraped":%d`, ts.samplesScraped)
+		if withHistory {
+			fmt.Fprintf(w, `,"scrapeHistory":`)
+			writeScrapeHistoryJSON(w, ts.history)
+		}
 		state := "up"
 		if !ts.up {
 			state = "down"
@@ -303,6 +328,31 @@ func writeLabelsJSON(w io.Writer, labels *promutil.Labels) {
 	fmt.Fprintf(w, `}`)
 }
 
+func writeScrapeHistoryJSON(w io.Writer, history []scrapeResult) {
+	fmt.Fprintf(w, `[`)
+	for i := range history {
+		r := &history[i]
+		if i > 0 {
+			fmt.Fprintf(w, `,`)
+		}
+		state := "up"
+		if !r.up {
+			state = "down"
+		}
+		errMsg := ""
+		if r.err != nil {
+			errMsg = r.err.Error()
+		}
+		fmt.Fprintf(w, `{"scrapeTime":"%s"`, time.UnixMilli(r.scrapeTime).Format(time.RFC3339Nano))
+		fmt.Fprintf(w, `,"scrapeDuration":%g`, (time.Millisecond * time.Duration(r.scrapeDuration)).Seconds())
+		fmt.Fprintf(w, `,"samplesScraped":%d`, r.samplesScraped)
+		fmt.Fprintf(w, `,"responseSize":%d`, r.scrapeResponseSize)
+		fmt.Fprintf(w, `,"error":%s`, stringsutil.JSONString(errMsg))
+		fmt.Fprintf(w, `,"health":%s}`, stringsutil.JSONString(state))
+	}
+	fmt.Fprintf(w, `]`)
+}
+
 type targetStatus struct {
 	sw                 *scrapeWork
 	up                 bool
@@ -313,6 +363,61 @@ type targetStatus struct {
 	scrapesTotal       int
 	scrapesFailed      int
 	err                error
+
+	// history contains up to -promscrape.targetHistorySize the most recent scrape results.
+	//
+	// It is used as a ring buffer: historyNextIdx points to the entry to overwrite
+	// on the next scrape after the buffer becomes full, e.g. to the oldest entry.
+	history        []scrapeResult
+	historyNextIdx int
+}
+
+// scrapeResult contains the outcome of a single scrape of the target.
+type scrapeResult struct {
+	up                 bool
+	scrapeTime         int64
+	scrapeDuration     int64
+	scrapeResponseSize int
+	samplesScraped     int
+	err                error
+}
+
+// addHistory adds r to ts.history, overwriting the oldest entry if the history is full.
+func (ts *targetStatus) addHistory(r scrapeResult) {
+	maxLen := *targetHistorySize
+	if maxLen <= 0 {
+		return
+	}
+	if len(ts.history) < maxLen {
+		ts.history = append(ts.history, r)
+		return
+	}
+	ts.history[ts.historyNextIdx] = r
+	ts.historyNextIdx = (ts.historyNextIdx + 1) % len(ts.history)
+}
+
+// getHistory returns a copy of ts.history ordered from the oldest to the newest scrape result.
+func (ts *targetStatus) getHistory() []scrapeResult {
+	if len(ts.history) == 0 {
+		return nil
+	}
+	history := make([]scrapeResult, 0, len(ts.history))
+	history = append(history, ts.history[ts.historyNextIdx:]...)
+	return append(history, ts.history[:ts.historyNextIdx]...)
+}
+
+// getSummary returns human-readable summary for r.
+func (r *scrapeResult) getSummary() string {
+	state := "up"
+	if !r.up {
+		state = "down"
+	}
+	scrapeTime := time.UnixMilli(r.scrapeTime).Format(time.RFC3339)
+	s := fmt.Sprintf("%s %s: duration=%dms, samples=%d, size=%.3fKiB", scrapeTime, state, r.scrapeDuration, r.samplesScraped, float64(r.scrapeResponseSize)/1024)
+	if r.err != nil {
+		s += ", error=" + r.err.Error()
+	}
+	return s
 }
 
 func (ts *targetStatus) getDurationFromLastScrape() string {
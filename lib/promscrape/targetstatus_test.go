@@ -35,7 +35,7 @@ func TestWriteActiveTargetsJSON(t *testing.T) {
 	f := func(scrapePoolFilter string, exp []activeTarget) {
 		t.Helper()
 		b := &bytes.Buffer{}
-		tsm.WriteActiveTargetsJSON(b, scrapePoolFilter)
+		tsm.WriteActiveTargetsJSON(b, scrapePoolFilter, false)
 
 		var got []activeTarget
 		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
@@ -99,3 +99,47 @@ func TestRegisterDroppedTargets(t *testing.T) {
 	})
 
 }
+
+func TestTargetStatusHistory(t *testing.T) {
+	f := func(historySize, scrapes int, scrapeTimesExpected []int64) {
+		t.Helper()
+
+		origHistorySize := *targetHistorySize
+		*targetHistorySize = historySize
+		defer func() {
+			*targetHistorySize = origHistorySize
+		}()
+
+		var ts targetStatus
+		for i := 1; i <= scrapes; i++ {
+			ts.addHistory(scrapeResult{
+				up:         true,
+				scrapeTime: int64(i),
+			})
+		}
+		history := ts.getHistory()
+		var scrapeTimes []int64
+		for _, r := range history {
+			scrapeTimes = append(scrapeTimes, r.scrapeTime)
+		}
+		if !reflect.DeepEqual(scrapeTimes, scrapeTimesExpected) {
+			t.Fatalf("unexpected scrape times in history; got %v; want %v", scrapeTimes, scrapeTimesExpected)
+		}
+	}
+
+	// disabled history
+	f(0, 5, nil)
+
+	// no scrapes
+	f(3, 0, nil)
+
+	// history isn't full yet
+	f(3, 2, []int64{1, 2})
+
+	// history is full
+	f(3, 3, []int64{1, 2, 3})
+
+	// history is overwritten
+	f(3, 4, []int64{2, 3, 4})
+	f(3, 8, []int64{6, 7, 8})
+}
@@ -0,0 +1,212 @@
+package promscrape
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/chunkedbuffer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/stringsutil"
+)
+
+// IsAdHocTestScrape returns true if r requests on-demand scrape of an arbitrary url at /test-scrape page.
+//
+// Such requests must be protected by authorization, since they may be used for sending requests
+// with arbitrary auth configs to arbitrary urls.
+func IsAdHocTestScrape(r *http.Request) bool {
+	return r.FormValue("id") == ""
+}
+
+// WriteTestScrape performs on-demand scrape according to r and writes the scraped samples to w in JSON.
+//
+// The following query args are supported:
+//
+//   - id - the id of the target to scrape. The id can be obtained from /targets page.
+//   - url - the url to scrape if id isn't set.
+//   - config - optional `scrape_config` in YAML to use for scraping the url.
+//
+// The returned samples are obtained after applying `metric_relabel_configs` from the scrape config.
+// The scrape result isn't sent to remote storage and it doesn't affect the scrape history of the target.
+func WriteTestScrape(w http.ResponseWriter, r *http.Request) error {
+	var sw *ScrapeWork
+	var readData func(dst *chunkedbuffer.Buffer) (bool, error)
+	if targetID := r.FormValue("id"); targetID != "" {
+		tsw := tsmGlobal.getScrapeWorkByTargetID(targetID)
+		if tsw == nil {
+			return fmt.Errorf("cannot find target for id=%s", targetID)
+		}
+		sw = tsw.Config
+		readData = tsw.ReadData
+	} else {
+		var err error
+		sw, err = getAdHocTestScrapeWork(r.FormValue("url"), r.FormValue("config"))
+		if err != nil {
+			return err
+		}
+		c, err := newClient(r.Context(), sw)
+		if err != nil {
+			return fmt.Errorf("cannot create client for scraping %q: %w", sw.ScrapeURL, err)
+		}
+		defer c.c.CloseIdleConnections()
+		readData = c.ReadData
+	}
+	tsr, err := performTestScrape(sw, readData)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	tsr.writeJSON(w)
+	return nil
+}
+
+// getAdHocTestScrapeWork returns ScrapeWork for scraping the given scrapeURL according to the given scrape_config in YAML.
+//
+// Environment variables aren't expanded in scrapeConfigData, since it is provided by the remote user.
+func getAdHocTestScrapeWork(scrapeURL, scrapeConfigData string) (*ScrapeWork, error) {
+	if scrapeURL == "" {
+		return nil, fmt.Errorf("missing `id` or `url` query arg")
+	}
+	u, err := url.Parse(scrapeURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse url=%q: %w", scrapeURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme in url=%q; supported schemes: http, https", scrapeURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host in url=%q", scrapeURL)
+	}
+
+	var sc ScrapeConfig
+	if err := yaml.UnmarshalStrict([]byte(scrapeConfigData), &sc); err != nil {
+		return nil, fmt.Errorf("cannot parse `config` query arg: %w", err)
+	}
+	if sc.JobName == "" {
+		sc.JobName = "test-scrape"
+	}
+	swc, err := getScrapeWorkConfig(&sc, filepath.Dir(*promscrapeConfigFile), &GlobalConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `config` query arg: %w", err)
+	}
+	// The test scrape is performed only once, so there is no need in reloading lookup tables referred by swc.
+	// The lookup tables remain usable after swc.mustStop call.
+	defer swc.mustStop()
+
+	// Override scheme, path and query args from the scrape_config with the values from the url.
+	metricsPath := u.Path
+	if metricsPath == "" {
+		metricsPath = "/"
+	}
+	var extraLabels promutil.Labels
+	extraLabels.Add("__scheme__", u.Scheme)
+	extraLabels.Add("__metrics_path__", metricsPath)
+	for k, vs := range u.Query() {
+		if len(vs) > 0 {
+			extraLabels.Add("__param_"+k, vs[0])
+		}
+	}
+	sw, err := swc.getTestScrapeWork(u.Host, &extraLabels)
+	if err != nil {
+		return nil, err
+	}
+	if sw == nil {
+		return nil, fmt.Errorf("url=%q is dropped by `relabel_configs`", scrapeURL)
+	}
+	return sw, nil
+}
+
+type testScrapeResult struct {
+	scrapeURL             string
+	scrapeTime            int64
+	scrapeDuration        time.Duration
+	responseSize          int
+	samplesScraped        int
+	samplesPostRelabeling int
+	warnings              []string
+	wc                    *writeRequestCtx
+}
+
+// performTestScrape scrapes sw with readData and returns the scraped samples after applying `metric_relabel_configs`.
+func performTestScrape(sw *ScrapeWork, readData func(dst *chunkedbuffer.Buffer) (bool, error)) (*testScrapeResult, error) {
+	startTime := time.Now()
+	cb := chunkedbuffer.Get()
+	defer chunkedbuffer.Put(cb)
+	isGzipped, err := readData(cb)
+	scrapeDuration := time.Since(startTime)
+	if err != nil {
+		return nil, fmt.Errorf("cannot scrape %q: %w", sw.ScrapeURL, err)
+	}
+
+	// Limit the number of concurrent goroutines, which process scraped data,
+	// in the same way as regular scrapes do.
+	processScrapedDataConcurrencyLimitCh <- struct{}{}
+	defer func() {
+		<-processScrapedDataConcurrencyLimitCh
+	}()
+
+	var body bytesutil.ByteBuffer
+	if err := readFromBuffer(&body, cb, isGzipped); err != nil {
+		return nil, fmt.Errorf("cannot read response from %q: %w", sw.ScrapeURL, err)
+	}
+
+	tsr := &testScrapeResult{
+		scrapeURL:      sw.ScrapeURL,
+		scrapeTime:     startTime.UnixMilli(),
+		scrapeDuration: scrapeDuration,
+		responseSize:   len(body.B),
+		wc:             &writeRequestCtx{},
+	}
+	wc := tsr.wc
+	wc.rows.UnmarshalWithErrLogger(string(body.B), func(s string) {
+		tsr.warnings = append(tsr.warnings, s)
+	})
+	tsr.samplesScraped = len(wc.rows.Rows)
+	wc.addRows(sw, wc.rows.Rows, tsr.scrapeTime, true)
+	tsr.samplesPostRelabeling = len(wc.writeRequest.Timeseries)
+	if sw.SampleLimit > 0 && tsr.samplesPostRelabeling > sw.SampleLimit {
+		tsr.warnings = append(tsr.warnings, fmt.Sprintf("the response exceeds sample_limit=%d; all the samples are dropped during regular scrapes", sw.SampleLimit))
+	}
+	return tsr, nil
+}
+
+func (tsr *testScrapeResult) writeJSON(w io.Writer) {
+	fmt.Fprintf(w, `{"status":"success","data":{`)
+	fmt.Fprintf(w, `"scrapeUrl":%s`, stringsutil.JSONString(tsr.scrapeURL))
+	fmt.Fprintf(w, `,"scrapeTime":"%s"`, time.UnixMilli(tsr.scrapeTime).Format(time.RFC3339Nano))
+	fmt.Fprintf(w, `,"scrapeDuration":%g`, tsr.scrapeDuration.Seconds())
+	fmt.Fprintf(w, `,"responseSize":%d`, tsr.responseSize)
+	fmt.Fprintf(w, `,"samplesScraped":%d`, tsr.samplesScraped)
+	fmt.Fprintf(w, `,"samplesPostRelabeling":%d`, tsr.samplesPostRelabeling)
+	fmt.Fprintf(w, `,"warnings":[`)
+	for i, warning := range tsr.warnings {
+		if i > 0 {
+			fmt.Fprintf(w, `,`)
+		}
+		fmt.Fprintf(w, `%s`, stringsutil.JSONString(warning))
+	}
+	fmt.Fprintf(w, `],"samples":[`)
+	for i := range tsr.wc.writeRequest.Timeseries {
+		ts := &tsr.wc.writeRequest.Timeseries[i]
+		if i > 0 {
+			fmt.Fprintf(w, `,`)
+		}
+		fmt.Fprintf(w, `{"metric":{`)
+		for j, label := range ts.Labels {
+			if j > 0 {
+				fmt.Fprintf(w, `,`)
+			}
+			fmt.Fprintf(w, `%s:%s`, stringsutil.JSONString(label.Name), stringsutil.JSONString(label.Value))
+		}
+		s := &ts.Samples[0]
+		fmt.Fprintf(w, `},"value":"%s","timestamp":%d}`, strconv.FormatFloat(s.Value, 'g', -1, 64), s.Timestamp)
+	}
+	fmt.Fprintf(w, `]}}`)
+}
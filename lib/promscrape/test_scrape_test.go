@@ -0,0 +1,200 @@
+package promscrape
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/chunkedbuffer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+type testScrapeSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  string            `json:"value"`
+}
+
+type testScrapeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		SamplesScraped        int                `json:"samplesScraped"`
+		SamplesPostRelabeling int                `json:"samplesPostRelabeling"`
+		Warnings              []string           `json:"warnings"`
+		Samples               []testScrapeSample `json:"samples"`
+	} `json:"data"`
+}
+
+func TestWriteTestScrapeAdHocSuccess(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/custom/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "foo{bar=\"baz\"} 1.5\nfoo_bucket{le=\"1\"} 2\nqwe %s\n", r.URL.Query().Get("value"))
+	}))
+	defer s.Close()
+
+	f := func(config string, samplesScraped int, samplesExpected []testScrapeSample) {
+		t.Helper()
+
+		qs := url.Values{}
+		qs.Set("url", s.URL+"/custom/metrics?value=42")
+		qs.Set("config", config)
+		r := httptest.NewRequest(http.MethodGet, "/test-scrape?"+qs.Encode(), nil)
+		w := httptest.NewRecorder()
+		if err := WriteTestScrape(w, r); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var resp testScrapeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("cannot parse response %q: %s", w.Body.String(), err)
+		}
+		if resp.Status != "success" {
+			t.Fatalf("unexpected status; got %q; want %q", resp.Status, "success")
+		}
+		if resp.Data.SamplesScraped != samplesScraped {
+			t.Fatalf("unexpected samplesScraped; got %d; want %d", resp.Data.SamplesScraped, samplesScraped)
+		}
+		if resp.Data.SamplesPostRelabeling != len(samplesExpected) {
+			t.Fatalf("unexpected samplesPostRelabeling; got %d; want %d", resp.Data.SamplesPostRelabeling, len(samplesExpected))
+		}
+		if !reflect.DeepEqual(resp.Data.Samples, samplesExpected) {
+			t.Fatalf("unexpected samples;\ngot\n%v\nwant\n%v", resp.Data.Samples, samplesExpected)
+		}
+	}
+
+	// empty config
+	f("", 3, []testScrapeSample{
+		{
+			Metric: map[string]string{"__name__": "foo", "bar": "baz", "instance": s.Listener.Addr().String(), "job": "test-scrape"},
+			Value:  "1.5",
+		},
+		{
+			Metric: map[string]string{"__name__": "foo_bucket", "le": "1", "instance": s.Listener.Addr().String(), "job": "test-scrape"},
+			Value:  "2",
+		},
+		{
+			Metric: map[string]string{"__name__": "qwe", "instance": s.Listener.Addr().String(), "job": "test-scrape"},
+			Value:  "42",
+		},
+	})
+
+	// metric_relabel_configs and relabel_configs
+	f(`
+job_name: abc
+relabel_configs:
+- target_label: instance
+  replacement: foobar
+metric_relabel_configs:
+- action: drop
+  source_labels: [__name__]
+  regex: "foo.*"
+`, 3, []testScrapeSample{
+		{
+			Metric: map[string]string{"__name__": "qwe", "instance": "foobar", "job": "abc"},
+			Value:  "42",
+		},
+	})
+}
+
+func TestWriteTestScrapeAdHocFailure(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	f := func(scrapeURL, config string) {
+		t.Helper()
+
+		qs := url.Values{}
+		qs.Set("url", scrapeURL)
+		qs.Set("config", config)
+		r := httptest.NewRequest(http.MethodGet, "/test-scrape?"+qs.Encode(), nil)
+		w := httptest.NewRecorder()
+		if err := WriteTestScrape(w, r); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// missing url
+	f("", "")
+
+	// unsupported scheme
+	f("file:///etc/passwd", "")
+
+	// missing host
+	f("http:///metrics", "")
+
+	// invalid config
+	f(s.URL, "foo: bar")
+
+	// the target is dropped by relabeling
+	f(s.URL, `
+relabel_configs:
+- action: drop
+  source_labels: [__address__]
+  regex: ".+"
+`)
+
+	// scrape error
+	f(s.URL, "")
+}
+
+func TestWriteTestScrapeByTargetID(t *testing.T) {
+	sw := &scrapeWork{
+		Config: &ScrapeWork{
+			ScrapeURL:       "http://host1:80/metrics",
+			jobNameOriginal: "foo",
+			OriginalLabels: promutil.NewLabelsFromMap(map[string]string{
+				"__address__": "host1:80",
+			}),
+			Labels: promutil.NewLabelsFromMap(map[string]string{
+				"instance": "host1:80",
+				"job":      "foo",
+			}),
+		},
+		ReadData: func(dst *chunkedbuffer.Buffer) (bool, error) {
+			dst.MustWrite([]byte("foo 1\nbar{x=\"y\"} 2\nbaz{\n"))
+			return false, nil
+		},
+	}
+	tsmGlobal.Register(sw)
+	defer tsmGlobal.Unregister(sw)
+
+	r := httptest.NewRequest(http.MethodGet, "/test-scrape?id="+getLabelsID(sw.Config.OriginalLabels), nil)
+	w := httptest.NewRecorder()
+	if err := WriteTestScrape(w, r); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var resp testScrapeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("cannot parse response %q: %s", w.Body.String(), err)
+	}
+	samplesExpected := []testScrapeSample{
+		{
+			Metric: map[string]string{"__name__": "foo", "instance": "host1:80", "job": "foo"},
+			Value:  "1",
+		},
+		{
+			Metric: map[string]string{"__name__": "bar", "x": "y", "instance": "host1:80", "job": "foo"},
+			Value:  "2",
+		},
+	}
+	if !reflect.DeepEqual(resp.Data.Samples, samplesExpected) {
+		t.Fatalf("unexpected samples;\ngot\n%v\nwant\n%v", resp.Data.Samples, samplesExpected)
+	}
+	if len(resp.Data.Warnings) != 1 {
+		t.Fatalf("expecting a single warning about invalid line; got %q", resp.Data.Warnings)
+	}
+
+	// unknown target
+	r = httptest.NewRequest(http.MethodGet, "/test-scrape?id=foobar", nil)
+	w = httptest.NewRecorder()
+	if err := WriteTestScrape(w, r); err == nil {
+		t.Fatalf("expecting non-nil error for unknown target")
+	}
+}
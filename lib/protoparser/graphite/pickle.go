@@ -0,0 +1,673 @@
+package graphite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/valyala/fastjson/fastfloat"
+)
+
+// UnmarshalPickle unmarshals graphite pickle protocol message from data.
+//
+// The message must contain a list of `(path, (timestamp, value))` tuples.
+// See https://graphite.readthedocs.io/en/latest/feeding-carbon.html#the-pickle-protocol
+//
+// Only the opcodes needed for encoding lists, tuples, strings and numbers are supported,
+// so arbitrary Python objects cannot be constructed from the untrusted data.
+//
+// data shouldn't be modified when rs is in use.
+func (rs *Rows) UnmarshalPickle(data []byte) error {
+	u := getUnpickler()
+	defer putUnpickler(u)
+
+	v, err := u.unpickle(data)
+	if err != nil {
+		return fmt.Errorf("cannot unpickle graphite data: %w", err)
+	}
+	items, ok := pickleItems(v)
+	if !ok {
+		return fmt.Errorf("unexpected pickled value type %T; want list of (path, (timestamp, value)) tuples", v)
+	}
+	rs.Rows = rs.Rows[:0]
+	rs.tagsPool = rs.tagsPool[:0]
+	for _, item := range items {
+		rs.Rows, rs.tagsPool = appendPickleRow(rs.Rows, item, rs.tagsPool)
+	}
+	return nil
+}
+
+func appendPickleRow(dst []Row, item any, tagsPool []Tag) ([]Row, []Tag) {
+	if cap(dst) > len(dst) {
+		dst = dst[:len(dst)+1]
+	} else {
+		dst = append(dst, Row{})
+	}
+	r := &dst[len(dst)-1]
+	tagsPoolLen := len(tagsPool)
+	var err error
+	tagsPool, err = r.unmarshalPickle(item, tagsPool)
+	if err != nil {
+		dst = dst[:len(dst)-1]
+		tagsPool = tagsPool[:tagsPoolLen]
+		logger.Errorf("cannot unmarshal Graphite pickle entry: %s", err)
+		invalidLines.Inc()
+	}
+	return dst, tagsPool
+}
+
+func (r *Row) unmarshalPickle(item any, tagsPool []Tag) ([]Tag, error) {
+	r.reset()
+	a, ok := pickleItems(item)
+	if !ok || len(a) != 2 {
+		return tagsPool, fmt.Errorf("unexpected entry %v; want (path, (timestamp, value)) tuple", item)
+	}
+	path, ok := a[0].(string)
+	if !ok {
+		return tagsPool, fmt.Errorf("unexpected type for metric path %T; want string", a[0])
+	}
+	tagsPool, err := r.UnmarshalMetricAndTags(path, tagsPool)
+	if err != nil {
+		return tagsPool, fmt.Errorf("cannot parse metric and tags from %q: %w", path, err)
+	}
+	point, ok := pickleItems(a[1])
+	if !ok || len(point) != 2 {
+		return tagsPool, fmt.Errorf("unexpected datapoint %v for %q; want (timestamp, value) tuple", a[1], path)
+	}
+	ts, err := pickleNumber(point[0])
+	if err != nil {
+		return tagsPool, fmt.Errorf("cannot parse timestamp for %q: %w", path, err)
+	}
+	v, err := pickleNumber(point[1])
+	if err != nil {
+		return tagsPool, fmt.Errorf("cannot parse value for %q: %w", path, err)
+	}
+	r.Timestamp = int64(ts)
+	r.Value = v
+	return tagsPool, nil
+}
+
+func pickleItems(v any) ([]any, bool) {
+	switch t := v.(type) {
+	case *pickleList:
+		return t.items, true
+	case pickleTuple:
+		return t, true
+	default:
+		return nil, false
+	}
+}
+
+func pickleNumber(v any) (float64, error) {
+	switch t := v.(type) {
+	case int64:
+		return float64(t), nil
+	case float64:
+		return t, nil
+	case string:
+		return fastfloat.Parse(t)
+	default:
+		return 0, fmt.Errorf("unexpected type %T; want number", v)
+	}
+}
+
+// pickleList is a Python list.
+//
+// It is stored by pointer, since lists may be modified after being stored in memo.
+type pickleList struct {
+	items []any
+}
+
+// pickleTuple is a Python tuple.
+type pickleTuple []any
+
+type unpickler struct {
+	data   []byte
+	stack  []any
+	marks  []int
+	memo   map[int64]any
+	nextID int64
+}
+
+func (u *unpickler) reset() {
+	u.data = nil
+	clear(u.stack)
+	u.stack = u.stack[:0]
+	u.marks = u.marks[:0]
+	clear(u.memo)
+	u.nextID = 0
+}
+
+func getUnpickler() *unpickler {
+	v := unpicklerPool.Get()
+	if v == nil {
+		return &unpickler{
+			memo: make(map[int64]any),
+		}
+	}
+	return v.(*unpickler)
+}
+
+func putUnpickler(u *unpickler) {
+	u.reset()
+	unpicklerPool.Put(u)
+}
+
+var unpicklerPool sync.Pool
+
+// Pickle opcodes. See https://github.com/python/cpython/blob/main/Lib/pickletools.py
+const (
+	opMark            = '('
+	opStop            = '.'
+	opPop             = '0'
+	opPopMark         = '1'
+	opDup             = '2'
+	opFloat           = 'F'
+	opInt             = 'I'
+	opBinInt          = 'J'
+	opBinInt1         = 'K'
+	opLong            = 'L'
+	opBinInt2         = 'M'
+	opNone            = 'N'
+	opString          = 'S'
+	opBinString       = 'T'
+	opShortBinString  = 'U'
+	opUnicode         = 'V'
+	opBinUnicode      = 'X'
+	opAppend          = 'a'
+	opGet             = 'g'
+	opBinGet          = 'h'
+	opLongBinGet      = 'j'
+	opList            = 'l'
+	opPut             = 'p'
+	opBinPut          = 'q'
+	opLongBinPut      = 'r'
+	opAppends         = 'e'
+	opTuple           = 't'
+	opEmptyList       = ']'
+	opEmptyTuple      = ')'
+	opBinFloat        = 'G'
+	opBinBytes        = 'B'
+	opShortBinBytes   = 'C'
+	opProto           = 0x80
+	opTuple1          = 0x85
+	opTuple2          = 0x86
+	opTuple3          = 0x87
+	opNewTrue         = 0x88
+	opNewFalse        = 0x89
+	opLong1           = 0x8a
+	opLong4           = 0x8b
+	opShortBinUnicode = 0x8c
+	opBinUnicode8     = 0x8d
+	opBinBytes8       = 0x8e
+	opMemoize         = 0x94
+	opFrame           = 0x95
+)
+
+func (u *unpickler) unpickle(data []byte) (any, error) {
+	u.data = data
+	for {
+		if len(u.data) == 0 {
+			return nil, fmt.Errorf("missing STOP opcode at the end of pickled data")
+		}
+		op := u.data[0]
+		u.data = u.data[1:]
+		switch op {
+		case opStop:
+			if len(u.stack) != 1 {
+				return nil, fmt.Errorf("unexpected number of items left on the stack after STOP opcode: %d; want 1", len(u.stack))
+			}
+			return u.stack[0], nil
+		case opProto:
+			if _, err := u.readBytes(1); err != nil {
+				return nil, err
+			}
+		case opFrame:
+			if _, err := u.readBytes(8); err != nil {
+				return nil, err
+			}
+		case opMark:
+			u.marks = append(u.marks, len(u.stack))
+		case opPop:
+			if _, err := u.pop(); err != nil {
+				return nil, err
+			}
+		case opPopMark:
+			if _, err := u.popMark(); err != nil {
+				return nil, err
+			}
+		case opDup:
+			v, err := u.top()
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, v)
+		case opNone:
+			u.stack = append(u.stack, nil)
+		case opNewTrue:
+			u.stack = append(u.stack, int64(1))
+		case opNewFalse:
+			u.stack = append(u.stack, int64(0))
+		case opInt:
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			switch line {
+			case "00":
+				u.stack = append(u.stack, int64(0))
+			case "01":
+				u.stack = append(u.stack, int64(1))
+			default:
+				n, err := strconv.ParseInt(line, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("cannot parse INT opcode argument: %w", err)
+				}
+				u.stack = append(u.stack, n)
+			}
+		case opLong:
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			line = strings.TrimSuffix(line, "L")
+			n, err := strconv.ParseInt(line, 10, 64)
+			if err != nil {
+				// Too big integer - convert it to float64.
+				f, errFloat := strconv.ParseFloat(line, 64)
+				if errFloat != nil {
+					return nil, fmt.Errorf("cannot parse LONG opcode argument: %w", err)
+				}
+				u.stack = append(u.stack, f)
+				continue
+			}
+			u.stack = append(u.stack, n)
+		case opBinInt:
+			b, err := u.readBytes(4)
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, int64(int32(binary.LittleEndian.Uint32(b))))
+		case opBinInt1:
+			b, err := u.readBytes(1)
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, int64(b[0]))
+		case opBinInt2:
+			b, err := u.readBytes(2)
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, int64(binary.LittleEndian.Uint16(b)))
+		case opLong1, opLong4:
+			var n uint64
+			if op == opLong1 {
+				b, err := u.readBytes(1)
+				if err != nil {
+					return nil, err
+				}
+				n = uint64(b[0])
+			} else {
+				b, err := u.readBytes(4)
+				if err != nil {
+					return nil, err
+				}
+				n = uint64(binary.LittleEndian.Uint32(b))
+			}
+			b, err := u.readBytes(n)
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, decodeLong(b))
+		case opFloat:
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			f, err := strconv.ParseFloat(line, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse FLOAT opcode argument: %w", err)
+			}
+			u.stack = append(u.stack, f)
+		case opBinFloat:
+			b, err := u.readBytes(8)
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, math.Float64frombits(binary.BigEndian.Uint64(b)))
+		case opString, opUnicode:
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			if op == opString {
+				if len(line) < 2 || (line[0] != '\'' && line[0] != '"') || line[len(line)-1] != line[0] {
+					return nil, fmt.Errorf("STRING opcode argument must be quoted; got %q", line)
+				}
+				line = line[1 : len(line)-1]
+			}
+			s, err := unescapePickleString(line)
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, s)
+		case opShortBinString, opShortBinBytes, opShortBinUnicode:
+			b, err := u.readBytes(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.pushString(uint64(b[0])); err != nil {
+				return nil, err
+			}
+		case opBinString, opBinBytes, opBinUnicode:
+			b, err := u.readBytes(4)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.pushString(uint64(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+		case opBinUnicode8, opBinBytes8:
+			b, err := u.readBytes(8)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.pushString(binary.LittleEndian.Uint64(b)); err != nil {
+				return nil, err
+			}
+		case opEmptyList:
+			u.stack = append(u.stack, &pickleList{})
+		case opList:
+			items, err := u.popMark()
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, &pickleList{
+				items: append([]any{}, items...),
+			})
+		case opAppend:
+			v, err := u.pop()
+			if err != nil {
+				return nil, err
+			}
+			l, err := u.topList()
+			if err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, v)
+		case opAppends:
+			items, err := u.popMark()
+			if err != nil {
+				return nil, err
+			}
+			l, err := u.topList()
+			if err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, items...)
+		case opEmptyTuple:
+			u.stack = append(u.stack, pickleTuple{})
+		case opTuple:
+			items, err := u.popMark()
+			if err != nil {
+				return nil, err
+			}
+			u.stack = append(u.stack, append(pickleTuple{}, items...))
+		case opTuple1, opTuple2, opTuple3:
+			n := int(op-opTuple1) + 1
+			if len(u.stack) < n {
+				return nil, fmt.Errorf("not enough items on the stack for TUPLE%d opcode", n)
+			}
+			t := append(pickleTuple{}, u.stack[len(u.stack)-n:]...)
+			u.stack = append(u.stack[:len(u.stack)-n], t)
+		case opPut:
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			id, err := strconv.ParseInt(line, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse PUT opcode argument: %w", err)
+			}
+			if err := u.memoPut(id); err != nil {
+				return nil, err
+			}
+		case opBinPut:
+			b, err := u.readBytes(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.memoPut(int64(b[0])); err != nil {
+				return nil, err
+			}
+		case opLongBinPut:
+			b, err := u.readBytes(4)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.memoPut(int64(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+		case opMemoize:
+			if err := u.memoPut(u.nextID); err != nil {
+				return nil, err
+			}
+		case opGet:
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			id, err := strconv.ParseInt(line, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse GET opcode argument: %w", err)
+			}
+			if err := u.memoGet(id); err != nil {
+				return nil, err
+			}
+		case opBinGet:
+			b, err := u.readBytes(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.memoGet(int64(b[0])); err != nil {
+				return nil, err
+			}
+		case opLongBinGet:
+			b, err := u.readBytes(4)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.memoGet(int64(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported pickle opcode 0x%02x; only lists of tuples with strings and numbers are allowed", op)
+		}
+	}
+}
+
+func (u *unpickler) readBytes(n uint64) ([]byte, error) {
+	if uint64(len(u.data)) < n {
+		return nil, fmt.Errorf("unexpected end of pickled data; want %d bytes; got %d bytes", n, len(u.data))
+	}
+	b := u.data[:n]
+	u.data = u.data[n:]
+	return b, nil
+}
+
+func (u *unpickler) readLine() (string, error) {
+	n := bytesutil.ToUnsafeString(u.data)
+	i := strings.IndexByte(n, '\n')
+	if i < 0 {
+		return "", fmt.Errorf("unexpected end of pickled data; missing newline")
+	}
+	u.data = u.data[i+1:]
+	return strings.TrimSuffix(n[:i], "\r"), nil
+}
+
+func (u *unpickler) pushString(n uint64) error {
+	b, err := u.readBytes(n)
+	if err != nil {
+		return err
+	}
+	u.stack = append(u.stack, bytesutil.ToUnsafeString(b))
+	return nil
+}
+
+func (u *unpickler) pop() (any, error) {
+	if len(u.stack) == 0 || (len(u.marks) > 0 && u.marks[len(u.marks)-1] == len(u.stack)) {
+		return nil, fmt.Errorf("cannot pop item from empty stack")
+	}
+	v := u.stack[len(u.stack)-1]
+	u.stack = u.stack[:len(u.stack)-1]
+	return v, nil
+}
+
+func (u *unpickler) top() (any, error) {
+	if len(u.stack) == 0 {
+		return nil, fmt.Errorf("unexpected empty stack")
+	}
+	return u.stack[len(u.stack)-1], nil
+}
+
+func (u *unpickler) topList() (*pickleList, error) {
+	v, err := u.top()
+	if err != nil {
+		return nil, err
+	}
+	l, ok := v.(*pickleList)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type on the stack %T; want list", v)
+	}
+	return l, nil
+}
+
+// popMark pops items from the stack up to the last MARK and returns them.
+//
+// The returned items are valid until the next push to the stack.
+func (u *unpickler) popMark() ([]any, error) {
+	if len(u.marks) == 0 {
+		return nil, fmt.Errorf("missing MARK opcode")
+	}
+	n := u.marks[len(u.marks)-1]
+	u.marks = u.marks[:len(u.marks)-1]
+	items := u.stack[n:]
+	u.stack = u.stack[:n]
+	return items, nil
+}
+
+func (u *unpickler) memoPut(id int64) error {
+	v, err := u.top()
+	if err != nil {
+		return err
+	}
+	if len(u.memo) >= maxPickleMemoSize {
+		return fmt.Errorf("too many memo entries; the limit is %d", maxPickleMemoSize)
+	}
+	u.memo[id] = v
+	u.nextID = int64(len(u.memo))
+	return nil
+}
+
+func (u *unpickler) memoGet(id int64) error {
+	v, ok := u.memo[id]
+	if !ok {
+		return fmt.Errorf("missing memo entry %d", id)
+	}
+	u.stack = append(u.stack, v)
+	return nil
+}
+
+// maxPickleMemoSize limits the number of memo entries in order to limit memory usage for malicious data.
+const maxPickleMemoSize = 1 << 20
+
+// decodeLong decodes little-endian two's complement integer from b.
+func decodeLong(b []byte) any {
+	if len(b) <= 8 {
+		var n uint64
+		for i := len(b) - 1; i >= 0; i-- {
+			n = n<<8 | uint64(b[i])
+		}
+		if len(b) > 0 && len(b) < 8 && b[len(b)-1]&0x80 != 0 {
+			n |= ^uint64(0) << (8 * len(b))
+		}
+		return int64(n)
+	}
+	// Too big integer - convert it to float64.
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	var x big.Int
+	x.SetBytes(be)
+	if b[len(b)-1]&0x80 != 0 {
+		var m big.Int
+		m.Lsh(big.NewInt(1), uint(8*len(b)))
+		x.Sub(&x, &m)
+	}
+	f, _ := new(big.Float).SetInt(&x).Float64()
+	return f
+}
+
+// unescapePickleString unescapes s encoded with Python string escapes.
+func unescapePickleString(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for len(s) > 0 {
+		n := strings.IndexByte(s, '\\')
+		if n < 0 {
+			sb.WriteString(s)
+			break
+		}
+		sb.WriteString(s[:n])
+		s = s[n+1:]
+		if len(s) == 0 {
+			return "", fmt.Errorf("missing escaped char at the end of string")
+		}
+		ch := s[0]
+		s = s[1:]
+		switch ch {
+		case '\\', '\'', '"':
+			sb.WriteByte(ch)
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'x', 'u', 'U':
+			size := 2
+			if ch == 'u' {
+				size = 4
+			} else if ch == 'U' {
+				size = 8
+			}
+			if len(s) < size {
+				return "", fmt.Errorf("too short \\%c escape sequence", ch)
+			}
+			n, err := strconv.ParseUint(s[:size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("cannot parse \\%c escape sequence: %w", ch, err)
+			}
+			s = s[size:]
+			if ch == 'x' {
+				sb.WriteByte(byte(n))
+			} else {
+				sb.WriteRune(rune(n))
+			}
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,104 @@
+package graphite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowsUnmarshalPickleFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		var rows Rows
+		if err := rows.UnmarshalPickle([]byte(data)); err == nil {
+			t.Fatalf("expecting non-nil error for %q", data)
+		}
+	}
+
+	// empty data
+	f("")
+
+	// missing STOP opcode
+	f("\x80\x02]q\x00")
+
+	// unexpected top-level type
+	f("\x80\x02K\x01.")
+
+	// truncated string
+	f("\x80\x02]q\x00(X\x07\x00\x00\x00foo")
+
+	// missing memo entry
+	f("\x80\x02h\x05.")
+
+	// REDUCE with arbitrary callable isn't allowed
+	f("\x80\x02]q\x00cposix\nsystem\nq\x01X\x04\x00\x00\x00echoq\x02\x85q\x03Rq\x04a.")
+}
+
+func TestRowsUnmarshalPickleSuccess(t *testing.T) {
+	f := func(data string, rowsExpected []Row) {
+		t.Helper()
+		var rows Rows
+		if err := rows.UnmarshalPickle([]byte(data)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(rows.Rows, rowsExpected) {
+			t.Fatalf("unexpected rows;\ngot\n%+v\nwant\n%+v", rows.Rows, rowsExpected)
+		}
+
+		// Try unmarshaling again
+		if err := rows.UnmarshalPickle([]byte(data)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(rows.Rows, rowsExpected) {
+			t.Fatalf("unexpected rows on the second unmarshal;\ngot\n%+v\nwant\n%+v", rows.Rows, rowsExpected)
+		}
+	}
+
+	// empty list
+	f("\x80\x02]q\x00.", nil)
+
+	// pickle.dumps([("foo.bar",(1700000000,1.5)),("baz;env=prod",(1700000001,2)),("big",(1700000002, 2**70))], protocol=N)
+	rowsExpected := []Row{
+		{
+			Metric:    "foo.bar",
+			Value:     1.5,
+			Timestamp: 1700000000,
+		},
+		{
+			Metric: "baz",
+			Tags: []Tag{{
+				Key:   "env",
+				Value: "prod",
+			}},
+			Value:     2,
+			Timestamp: 1700000001,
+		},
+		{
+			Metric:    "big",
+			Value:     1180591620717411303424,
+			Timestamp: 1700000002,
+		},
+	}
+	// protocol 0
+	f("(lp0\n(Vfoo.bar\np1\n(I1700000000\nF1.5\ntp2\ntp3\na(Vbaz;env=prod\np4\n(I1700000001\nI2\ntp5\ntp6\na(Vbig\np7\n(I1700000002\nL1180591620717411303424L\ntp8\ntp9\na.", rowsExpected)
+	// protocol 1
+	f("]q\x00((X\x07\x00\x00\x00foo.barq\x01(J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00tq\x02tq\x03(X\x0c\x00\x00\x00baz;env=prodq\x04(J\x01\xf1SeK\x02tq\x05tq\x06(X\x03\x00\x00\x00bigq\x07(J\x02\xf1SeL1180591620717411303424L\ntq\x08tq\te.", rowsExpected)
+	// protocol 2
+	f("\x80\x02]q\x00(X\x07\x00\x00\x00foo.barq\x01J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03X\x0c\x00\x00\x00baz;env=prodq\x04J\x01\xf1SeK\x02\x86q\x05\x86q\x06X\x03\x00\x00\x00bigq\x07J\x02\xf1Se\x8a\t\x00\x00\x00\x00\x00\x00\x00\x00@\x86q\x08\x86q\te.", rowsExpected)
+	// protocol 4
+	f("\x80\x04\x95U\x00\x00\x00\x00\x00\x00\x00]\x94(\x8c\x07foo.bar\x94J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00\x86\x94\x86\x94\x8c\x0cbaz;env=prod\x94J\x01\xf1SeK\x02\x86\x94\x86\x94\x8c\x03big\x94J\x02\xf1Se\x8a\t\x00\x00\x00\x00\x00\x00\x00\x00@\x86\x94\x86\x94e.", rowsExpected)
+
+	// string values, lists instead of tuples and invalid entries
+	// pickle.dumps([("x",(1,"2.5")),("bad",(1,)),("y",[2,3.0])], protocol=2)
+	f("\x80\x02]q\x00(X\x01\x00\x00\x00xq\x01K\x01X\x03\x00\x00\x002.5q\x02\x86q\x03\x86q\x04X\x03\x00\x00\x00badq\x05K\x01\x85q\x06\x86q\x07X\x01\x00\x00\x00yq\x08]q\t(K\x02G@\x08\x00\x00\x00\x00\x00\x00e\x86q\ne.", []Row{
+		{
+			Metric:    "x",
+			Value:     2.5,
+			Timestamp: 1,
+		},
+		{
+			Metric:    "y",
+			Value:     3,
+			Timestamp: 2,
+		},
+	})
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/graphite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/writeconcurrencylimiter"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var maxPickleMessageSize = flagutil.NewBytes("graphite.maxPickleMessageSize", 16*1024*1024, "The maximum size of a single message in Graphite pickle protocol "+
+	"accepted at -graphitePickleListenAddr")
+
+// ParsePickle parses Graphite pickle protocol messages from r and calls callback for the parsed rows.
+//
+// Every message must be prefixed with 4-byte big-endian length.
+// See https://graphite.readthedocs.io/en/latest/feeding-carbon.html#the-pickle-protocol
+//
+// callback shouldn't hold rows after returning.
+func ParsePickle(r io.Reader, callback func(rows []graphite.Row) error) error {
+	wcr := writeconcurrencylimiter.GetReader(r)
+	defer writeconcurrencylimiter.PutReader(wcr)
+
+	ctx := getPickleContext(wcr)
+	defer putPickleContext(ctx)
+
+	for {
+		ok, err := ctx.readMessage()
+		if err != nil {
+			pickleReadErrors.Inc()
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		pickleReadCalls.Inc()
+		if err := ctx.rows.UnmarshalPickle(ctx.buf.B); err != nil {
+			pickleUnmarshalErrors.Inc()
+			return err
+		}
+		rows := ctx.rows.Rows
+		pickleRowsRead.Add(len(rows))
+		prepareTimestamps(rows)
+		err = callback(rows)
+		wcr.DecConcurrency()
+		if err != nil {
+			return fmt.Errorf("error when processing imported data: %w", err)
+		}
+	}
+}
+
+var (
+	pickleReadCalls       = metrics.NewCounter(`vm_protoparser_read_calls_total{type="graphite_pickle"}`)
+	pickleReadErrors      = metrics.NewCounter(`vm_protoparser_read_errors_total{type="graphite_pickle"}`)
+	pickleRowsRead        = metrics.NewCounter(`vm_protoparser_rows_read_total{type="graphite_pickle"}`)
+	pickleUnmarshalErrors = metrics.NewCounter(`vm_protoparser_unmarshal_errors_total{type="graphite_pickle"}`)
+)
+
+type pickleContext struct {
+	br   *bufio.Reader
+	buf  bytesutil.ByteBuffer
+	rows graphite.Rows
+}
+
+// readMessage reads the next pickle message into ctx.buf.
+//
+// It returns false if there are no more messages.
+func (ctx *pickleContext) readMessage() (bool, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(ctx.br, header[:]); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot read pickle message length: %w", err)
+	}
+	size := uint64(binary.BigEndian.Uint32(header[:]))
+	if maxSize := uint64(maxPickleMessageSize.N); size > maxSize {
+		return false, fmt.Errorf("too big pickle message size: %d bytes; it cannot exceed -graphite.maxPickleMessageSize=%d bytes", size, maxSize)
+	}
+	ctx.buf.B = bytesutil.ResizeNoCopyNoOverallocate(ctx.buf.B, int(size))
+	if _, err := io.ReadFull(ctx.br, ctx.buf.B); err != nil {
+		return false, fmt.Errorf("cannot read pickle message with size %d bytes: %w", size, err)
+	}
+	return true, nil
+}
+
+func getPickleContext(r io.Reader) *pickleContext {
+	if v := pickleContextPool.Get(); v != nil {
+		ctx := v.(*pickleContext)
+		ctx.br.Reset(r)
+		return ctx
+	}
+	return &pickleContext{
+		br: bufio.NewReaderSize(r, 64*1024),
+	}
+}
+
+func putPickleContext(ctx *pickleContext) {
+	ctx.br.Reset(nil)
+	ctx.buf.Reset()
+	ctx.rows.Reset()
+	pickleContextPool.Put(ctx)
+}
+
+var pickleContextPool sync.Pool
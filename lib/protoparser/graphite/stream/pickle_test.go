@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/graphite"
+)
+
+func TestParsePickle(t *testing.T) {
+	f := func(messages []string, rowsExpected []graphite.Row) {
+		t.Helper()
+		var bb bytes.Buffer
+		for _, msg := range messages {
+			var header [4]byte
+			binary.BigEndian.PutUint32(header[:], uint32(len(msg)))
+			bb.Write(header[:])
+			bb.WriteString(msg)
+		}
+		var rows []graphite.Row
+		err := ParsePickle(&bb, func(rs []graphite.Row) error {
+			// Copy rows, since they refer to the internal buffer, which is re-used for the next message.
+			for _, r := range rs {
+				r.Metric = strings.Clone(r.Metric)
+				var tags []graphite.Tag
+				for _, tag := range r.Tags {
+					tags = append(tags, graphite.Tag{
+						Key:   strings.Clone(tag.Key),
+						Value: strings.Clone(tag.Value),
+					})
+				}
+				r.Tags = tags
+				rows = append(rows, r)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(rows, rowsExpected) {
+			t.Fatalf("unexpected rows;\ngot\n%+v\nwant\n%+v", rows, rowsExpected)
+		}
+	}
+
+	f(nil, nil)
+
+	// pickle.dumps([("foo",(123,1.5))], protocol=2) and pickle.dumps([("bar;a=b",(124,2))], protocol=2)
+	f([]string{
+		"\x80\x02]q\x00X\x03\x00\x00\x00fooq\x01K{G?\xf8\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03a.",
+		"\x80\x02]q\x00X\x07\x00\x00\x00bar;a=bq\x01K|K\x02\x86q\x02\x86q\x03a.",
+	}, []graphite.Row{
+		{
+			Metric:    "foo",
+			Value:     1.5,
+			Timestamp: 123000,
+		},
+		{
+			Metric: "bar",
+			Tags: []graphite.Tag{{
+				Key:   "a",
+				Value: "b",
+			}},
+			Value:     2,
+			Timestamp: 124000,
+		},
+	})
+}
+
+func TestParsePickleFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		err := ParsePickle(bytes.NewBufferString(data), func(_ []graphite.Row) error {
+			return nil
+		})
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// truncated header
+	f("\x00\x00")
+
+	// truncated message
+	f("\x00\x00\x00\x10\x80\x02")
+
+	// too big message
+	f("\xff\xff\xff\xff")
+
+	// invalid message
+	f("\x00\x00\x00\x03foo")
+}
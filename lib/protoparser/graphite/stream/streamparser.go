@@ -160,7 +160,13 @@ func (uw *unmarshalWork) Unmarshal() {
 	uw.rows.Unmarshal(bytesutil.ToUnsafeString(uw.reqBuf))
 	rows := uw.rows.Rows
 	rowsRead.Add(len(rows))
+	prepareTimestamps(rows)
+	uw.runCallback(rows)
+	putUnmarshalWork(uw)
+}
 
+// prepareTimestamps converts timestamps for rows from seconds to milliseconds.
+func prepareTimestamps(rows []graphite.Row) {
 	// Fill missing timestamps with the current timestamp rounded to seconds.
 	currentTimestamp := int64(fasttime.UnixTimestamp())
 	for i := range rows {
@@ -182,9 +188,6 @@ func (uw *unmarshalWork) Unmarshal() {
 			row.Timestamp -= row.Timestamp % tsTrim
 		}
 	}
-
-	uw.runCallback(rows)
-	putUnmarshalWork(uw)
 }
 
 func getUnmarshalWork() *unmarshalWork {
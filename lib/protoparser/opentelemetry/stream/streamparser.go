@@ -43,6 +43,21 @@ func ParseStream(r io.Reader, encoding string, processBody func(data []byte) ([]
 	return nil
 }
 
+// ParseProtobuf parses uncompressed OpenTelemetry protobuf request from data and calls callback for the parsed rows.
+//
+// callback shouldn't hold tss items after returning.
+func ParseProtobuf(data []byte, callback func(tss []prompbmarshal.TimeSeries) error) error {
+	if len(data) > MaxRequestSize() {
+		return fmt.Errorf("too big request with %d bytes; it mustn't exceed -opentelemetry.maxRequestSize=%d bytes", len(data), MaxRequestSize())
+	}
+	return parseData(data, callback)
+}
+
+// MaxRequestSize returns the maximum size in bytes of a single OpenTelemetry request.
+func MaxRequestSize() int {
+	return maxRequestSize.IntN()
+}
+
 func parseData(data []byte, callback func(tss []prompbmarshal.TimeSeries) error) error {
 	var req pb.ExportMetricsServiceRequest
 	if err := req.UnmarshalProtobuf(data); err != nil {
@@ -0,0 +1,241 @@
+package openmetrics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fastjson/fastfloat"
+)
+
+// ContentType is the value for Content-Type header for OpenMetrics text exposition format.
+const ContentType = "application/openmetrics-text"
+
+// AppendText converts OpenMetrics text exposition format data at src to Prometheus text exposition format,
+// appends the result to dst and returns it.
+//
+// See https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md
+//
+// The conversion performs the following actions:
+//
+//   - It keeps metadata lines such as `# TYPE`, `# HELP` and `# UNIT`, so the metric types and units can be read from the result.
+//   - It keeps `_created` series for counters, histograms and summaries as regular series.
+//     Their values contain unix timestamps in seconds for the creation time of the corresponding metric.
+//   - It drops exemplars if exemplarsAsSeries is false, since VictoriaMetrics cannot store them.
+//     Otherwise every exemplar is converted to a separate series - see appendExemplar for details.
+//   - It converts timestamps from seconds to milliseconds.
+//   - It stops processing at `# EOF` line.
+//
+// Lines, which cannot be parsed, are passed to dst as is, so they could be reported by the Prometheus text parser.
+func AppendText(dst []byte, src string, exemplarsAsSeries bool) []byte {
+	for len(src) > 0 {
+		var line string
+		n := strings.IndexByte(src, '\n')
+		if n < 0 {
+			line = src
+			src = ""
+		} else {
+			line = src[:n]
+			src = src[n+1:]
+		}
+		line = strings.TrimSuffix(line, "\r")
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '#' {
+			if line == "# EOF" {
+				break
+			}
+			dst = appendLine(dst, line)
+			continue
+		}
+		dst = appendSample(dst, line, exemplarsAsSeries)
+	}
+	return dst
+}
+
+func appendSample(dst []byte, line string, exemplarsAsSeries bool) []byte {
+	n := getSeriesEnd(line)
+	if n < 0 {
+		return appendLine(dst, line)
+	}
+	series := line[:n]
+	tail := strings.TrimLeft(line[n:], " ")
+
+	value, tail, _ := strings.Cut(tail, " ")
+	if value == "" {
+		return appendLine(dst, line)
+	}
+	dst = append(dst, series...)
+	dst = append(dst, ' ')
+	dst = append(dst, value...)
+
+	tail = strings.TrimLeft(tail, " ")
+	timestamp := ""
+	if tail != "" && tail[0] != '#' {
+		timestamp, tail, _ = strings.Cut(tail, " ")
+		tail = strings.TrimLeft(tail, " ")
+		if _, err := fastfloat.Parse(timestamp); err != nil {
+			// Pass the invalid timestamp as is, so it is reported by the Prometheus text parser.
+			dst = append(dst, ' ')
+			dst = append(dst, timestamp...)
+			return append(dst, '\n')
+		}
+		dst = appendTimestamp(dst, timestamp)
+	}
+	dst = append(dst, '\n')
+
+	if exemplarsAsSeries {
+		if exemplar, ok := strings.CutPrefix(tail, "#"); ok {
+			dst = appendExemplar(dst, series, strings.TrimLeft(exemplar, " "), timestamp)
+		}
+	}
+	return dst
+}
+
+// appendExemplar appends the exemplar `{labels} value [timestamp]` for the given series to dst and returns the result.
+//
+// The exemplar is converted to a series with `_exemplar` suffix appended to the metric name of the original series.
+// The series contains the labels of the original series plus the exemplar labels, which do not clash with the labels of the original series.
+// The series has the exemplar value and the exemplar timestamp. The timestamp of the original sample is used if the exemplar has no timestamp.
+//
+// Invalid exemplars are dropped.
+func appendExemplar(dst []byte, series, exemplar, sampleTimestamp string) []byte {
+	if len(exemplar) == 0 || exemplar[0] != '{' {
+		return dst
+	}
+	n := getLabelsEnd(exemplar, 0)
+	if n < 0 {
+		return dst
+	}
+	exemplarLabels := exemplar[1 : n-1]
+	value, tail, _ := strings.Cut(strings.TrimLeft(exemplar[n:], " "), " ")
+	if value == "" {
+		return dst
+	}
+	timestamp, _, _ := strings.Cut(strings.TrimLeft(tail, " "), " ")
+	if timestamp == "" {
+		timestamp = sampleTimestamp
+	}
+	if timestamp != "" {
+		if _, err := fastfloat.Parse(timestamp); err != nil {
+			return dst
+		}
+	}
+
+	metricName, seriesLabels, _ := strings.Cut(series, "{")
+	seriesLabels = strings.TrimSuffix(strings.TrimSuffix(seriesLabels, "}"), ",")
+
+	dst = append(dst, metricName...)
+	dst = append(dst, "_exemplar{"...)
+	dst = append(dst, seriesLabels...)
+	needComma := seriesLabels != ""
+	for exemplarLabels != "" {
+		name, label, tail, ok := nextLabel(exemplarLabels)
+		if !ok {
+			break
+		}
+		exemplarLabels = tail
+		if hasLabel(seriesLabels, name) {
+			continue
+		}
+		if needComma {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, label...)
+		needComma = true
+	}
+	dst = append(dst, "} "...)
+	dst = append(dst, value...)
+	if timestamp != "" {
+		dst = appendTimestamp(dst, timestamp)
+	}
+	return append(dst, '\n')
+}
+
+// appendTimestamp appends the given valid OpenMetrics timestamp in seconds to dst in milliseconds.
+func appendTimestamp(dst []byte, timestamp string) []byte {
+	ts := fastfloat.ParseBestEffort(timestamp)
+	// OpenMetrics timestamps are in seconds, while Prometheus text timestamps are in milliseconds.
+	dst = append(dst, ' ')
+	return strconv.AppendInt(dst, int64(math.Round(ts*1000)), 10)
+}
+
+// nextLabel returns the name of the first `name="value"` label at s, the label itself and the tail after the label.
+func nextLabel(s string) (string, string, string, bool) {
+	s = strings.TrimLeft(s, " ,")
+	name, _, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", "", false
+	}
+	n := len(name) + 1
+	if n >= len(s) || s[n] != '"' {
+		return "", "", "", false
+	}
+	for i := n + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return strings.TrimSpace(name), s[:i+1], s[i+1:], true
+		}
+	}
+	return "", "", "", false
+}
+
+// hasLabel returns true if labels in the form `name1="value1",...,nameN="valueN"` contain a label with the given name.
+func hasLabel(labels, name string) bool {
+	for labels != "" {
+		labelName, _, tail, ok := nextLabel(labels)
+		if !ok {
+			return false
+		}
+		if labelName == name {
+			return true
+		}
+		labels = tail
+	}
+	return false
+}
+
+func appendLine(dst []byte, line string) []byte {
+	dst = append(dst, line...)
+	return append(dst, '\n')
+}
+
+// getSeriesEnd returns the end of series name with labels at the beginning of line.
+//
+// -1 is returned if line doesn't contain valid series.
+func getSeriesEnd(line string) int {
+	n := strings.IndexAny(line, "{ ")
+	if n <= 0 {
+		return -1
+	}
+	if line[n] == ' ' {
+		return n
+	}
+	return getLabelsEnd(line, n)
+}
+
+// getLabelsEnd returns the position after the closing brace for the labels starting with the opening brace at line[n].
+//
+// -1 is returned if the closing brace is missing.
+func getLabelsEnd(line string, n int) int {
+	// Search for the closing brace outside quoted label values.
+	inQuote := false
+	for i := n + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '}':
+			if !inQuote {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
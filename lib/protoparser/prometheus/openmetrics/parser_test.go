@@ -0,0 +1,129 @@
+package openmetrics
+
+import (
+	"testing"
+)
+
+func TestAppendText(t *testing.T) {
+	f := func(s string, exemplarsAsSeries bool, resultExpected string) {
+		t.Helper()
+		result := AppendText(nil, s, exemplarsAsSeries)
+		if string(result) != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	f("", false, "")
+	f("# EOF\n", false, "")
+
+	// metadata is preserved
+	f(`# TYPE foo_seconds gauge
+# HELP foo_seconds Some help
+# UNIT foo_seconds seconds
+foo_seconds 1.5
+# EOF
+`, false, `# TYPE foo_seconds gauge
+# HELP foo_seconds Some help
+# UNIT foo_seconds seconds
+foo_seconds 1.5
+`)
+
+	// labels with special chars
+	f(`foo{bar="b a#z",x="y\"} 1 # {"} 2
+`, false, "foo{bar=\"b a#z\",x=\"y\\\"} 1 # {\"} 2\n")
+
+	// timestamps are converted to milliseconds
+	f(`foo 1 1520879607.789
+bar{a="b"} 2.5 1520879607
+`, false, `foo 1 1520879607789
+bar{a="b"} 2.5 1520879607000
+`)
+
+	// exemplars are dropped by default
+	f(`# TYPE foo counter
+foo_total 17.0 1520879607.789 # {trace_id="KOO5S4vxi0o"} 0.67
+foo_total{a="b"} 17.0 # {trace_id="Oa8Ahg"} 0.67 1520879606.2
+# TYPE bar histogram
+bar_bucket{le="0.1"} 8 # {id="abc"} 0.043
+bar_bucket{le="+Inf"} 17
+bar_count 17
+bar_sum 324789.3
+# EOF
+`, false, `# TYPE foo counter
+foo_total 17.0 1520879607789
+foo_total{a="b"} 17.0
+# TYPE bar histogram
+bar_bucket{le="0.1"} 8
+bar_bucket{le="+Inf"} 17
+bar_count 17
+bar_sum 324789.3
+`)
+
+	// exemplars are converted to series
+	f(`# TYPE foo counter
+foo_total 17.0 1520879607.789 # {trace_id="KOO5S4vxi0o"} 0.67
+foo_total{a="b"} 17.0 # {trace_id="Oa8Ahg",a="c"} 0.67 1520879606.2
+# TYPE bar histogram
+bar_bucket{le="0.1",} 8 # {id="a\"}c"} 0.043
+bar_bucket{le="+Inf"} 17 # {} 5
+bar_count 17
+# EOF
+`, true, `# TYPE foo counter
+foo_total 17.0 1520879607789
+foo_total_exemplar{trace_id="KOO5S4vxi0o"} 0.67 1520879607789
+foo_total{a="b"} 17.0
+foo_total_exemplar{a="b",trace_id="Oa8Ahg"} 0.67 1520879606200
+# TYPE bar histogram
+bar_bucket{le="0.1",} 8
+bar_bucket_exemplar{le="0.1",id="a\"}c"} 0.043
+bar_bucket{le="+Inf"} 17
+bar_bucket_exemplar{le="+Inf"} 5
+bar_count 17
+`)
+
+	// invalid exemplars are dropped
+	f(`foo 1 # trace_id="abc" 2
+bar 2 # {trace_id="abc" 2
+baz 3 # {trace_id="abc"}
+qwe 4 # {trace_id="abc"} 1 foobar
+`, true, `foo 1
+bar 2
+baz 3
+qwe 4
+`)
+
+	// _created series are preserved
+	f(`# TYPE foo counter
+foo_total 17.0
+foo_created 1520430000.123
+# TYPE bar summary
+bar_count 17.0
+bar_sum 324789.3
+bar{quantile="0.95"} 123.7
+bar_created{a="b"} 1520430000.123
+# EOF
+`, false, `# TYPE foo counter
+foo_total 17.0
+foo_created 1520430000.123
+# TYPE bar summary
+bar_count 17.0
+bar_sum 324789.3
+bar{quantile="0.95"} 123.7
+bar_created{a="b"} 1520430000.123
+`)
+
+	// data after EOF is ignored
+	f(`foo 1
+# EOF
+bar 2
+`, false, "foo 1\n")
+
+	// invalid lines are passed as is
+	f(`foo
+bar{a="b" 1
+baz 1 foobar
+`, false, `foo
+bar{a="b" 1
+baz 1 foobar
+`)
+}
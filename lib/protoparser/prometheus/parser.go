@@ -401,6 +401,11 @@ func unescapeValue(s string) string {
 	return string(b)
 }
 
+// AppendEscapedLabelValue appends escaped label value s to dst according to Prometheus text exposition format and returns the result.
+func AppendEscapedLabelValue(dst []byte, s string) []byte {
+	return appendEscapedValue(dst, s)
+}
+
 func appendEscapedValue(dst []byte, s string) []byte {
 	// label_value can be any sequence of UTF-8 characters, but the backslash (\), double-quote ("),
 	// and line feed (\n) characters have to be escaped as \\, \", and \n, respectively.
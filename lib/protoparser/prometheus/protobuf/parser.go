@@ -0,0 +1,694 @@
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/VictoriaMetrics/easyproto"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
+)
+
+// ContentType is the value for Content-Type header for Prometheus protobuf exposition format.
+const ContentType = "application/vnd.google.protobuf"
+
+// AppendText converts Prometheus protobuf exposition format data at src to Prometheus text exposition format,
+// appends the result to dst and returns it.
+//
+// src must contain length-delimited io.prometheus.client.MetricFamily messages.
+// See https://github.com/prometheus/client_model/blob/master/io/prometheus/client/metrics.proto
+//
+// Native histograms without classic buckets are converted to VictoriaMetrics histogram buckets with `vmrange` labels.
+// See https://valyala.medium.com/improving-histogram-usability-for-prometheus-and-grafana-bc7e5df0e350
+//
+// Exemplars and created timestamps are ignored.
+func AppendText(dst, src []byte) ([]byte, error) {
+	var mf metricFamily
+	for len(src) > 0 {
+		n, tail, ok := easyproto.UnmarshalMessageLen(src)
+		if !ok {
+			return dst, fmt.Errorf("cannot read MetricFamily message length")
+		}
+		if n > len(tail) {
+			return dst, fmt.Errorf("too short data for MetricFamily message; got %d bytes; want %d bytes", len(tail), n)
+		}
+		if err := mf.unmarshalProtobuf(tail[:n]); err != nil {
+			return dst, fmt.Errorf("cannot unmarshal MetricFamily: %w", err)
+		}
+		var err error
+		dst, err = mf.appendText(dst)
+		if err != nil {
+			return dst, fmt.Errorf("cannot convert MetricFamily %q: %w", mf.name, err)
+		}
+		src = tail[n:]
+	}
+	return dst, nil
+}
+
+// metricType is MetricType enum from io.prometheus.client protobuf
+type metricType int32
+
+const (
+	metricTypeCounter        = metricType(0)
+	metricTypeGauge          = metricType(1)
+	metricTypeSummary        = metricType(2)
+	metricTypeUntyped        = metricType(3)
+	metricTypeHistogram      = metricType(4)
+	metricTypeGaugeHistogram = metricType(5)
+)
+
+type metricFamily struct {
+	name    string
+	typ     metricType
+	metrics [][]byte
+
+	m metric
+}
+
+func (mf *metricFamily) unmarshalProtobuf(src []byte) (err error) {
+	// message MetricFamily {
+	//   string name = 1;
+	//   string help = 2;
+	//   MetricType type = 3;
+	//   repeated Metric metric = 4;
+	//   string unit = 5;
+	// }
+	mf.name = ""
+	mf.typ = metricTypeCounter
+	mf.metrics = mf.metrics[:0]
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			name, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read name")
+			}
+			mf.name = name
+		case 3:
+			typ, ok := fc.Int32()
+			if !ok {
+				return fmt.Errorf("cannot read type")
+			}
+			mf.typ = metricType(typ)
+		case 4:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read metric")
+			}
+			mf.metrics = append(mf.metrics, data)
+		}
+	}
+	if mf.name == "" {
+		return fmt.Errorf("missing name")
+	}
+	return nil
+}
+
+func (mf *metricFamily) appendText(dst []byte) ([]byte, error) {
+	m := &mf.m
+	for _, data := range mf.metrics {
+		if err := m.unmarshalProtobuf(data); err != nil {
+			return dst, fmt.Errorf("cannot unmarshal Metric: %w", err)
+		}
+		switch mf.typ {
+		case metricTypeCounter, metricTypeGauge, metricTypeUntyped:
+			dst = m.appendSample(dst, mf.name, "", "", m.value)
+		case metricTypeSummary:
+			dst = m.appendSummary(dst, mf.name)
+		case metricTypeHistogram, metricTypeGaugeHistogram:
+			dst = m.appendHistogram(dst, mf.name)
+		default:
+			return dst, fmt.Errorf("unsupported metric type: %d", mf.typ)
+		}
+	}
+	return dst, nil
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type quantile struct {
+	quantile float64
+	value    float64
+}
+
+type bucket struct {
+	upperBound      float64
+	cumulativeCount float64
+}
+
+type bucketSpan struct {
+	offset int32
+	length uint32
+}
+
+type metric struct {
+	labels      []label
+	value       float64
+	timestampMs int64
+
+	// summary and histogram fields
+	count     float64
+	sum       float64
+	quantiles []quantile
+	buckets   []bucket
+
+	// native histogram fields
+	schema         int32
+	zeroThreshold  float64
+	zeroCount      float64
+	negativeSpans  []bucketSpan
+	negativeDeltas []int64
+	negativeCounts []float64
+	positiveSpans  []bucketSpan
+	positiveDeltas []int64
+	positiveCounts []float64
+}
+
+func (m *metric) reset() {
+	m.labels = m.labels[:0]
+	m.value = 0
+	m.timestampMs = 0
+
+	m.count = 0
+	m.sum = 0
+	m.quantiles = m.quantiles[:0]
+	m.buckets = m.buckets[:0]
+
+	m.schema = 0
+	m.zeroThreshold = 0
+	m.zeroCount = 0
+	m.negativeSpans = m.negativeSpans[:0]
+	m.negativeDeltas = m.negativeDeltas[:0]
+	m.negativeCounts = m.negativeCounts[:0]
+	m.positiveSpans = m.positiveSpans[:0]
+	m.positiveDeltas = m.positiveDeltas[:0]
+	m.positiveCounts = m.positiveCounts[:0]
+}
+
+func (m *metric) unmarshalProtobuf(src []byte) (err error) {
+	// message Metric {
+	//   repeated LabelPair label = 1;
+	//   Gauge gauge = 2;
+	//   Counter counter = 3;
+	//   Summary summary = 4;
+	//   Untyped untyped = 5;
+	//   Histogram histogram = 7;
+	//   int64 timestamp_ms = 6;
+	// }
+	m.reset()
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read label")
+			}
+			if err := m.unmarshalLabel(data); err != nil {
+				return fmt.Errorf("cannot unmarshal label: %w", err)
+			}
+		case 2, 3, 5:
+			// message Gauge { double value = 1; }
+			// message Counter { double value = 1; Exemplar exemplar = 2; google.protobuf.Timestamp created_timestamp = 3; }
+			// message Untyped { double value = 1; }
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read value")
+			}
+			if err := m.unmarshalValue(data); err != nil {
+				return fmt.Errorf("cannot unmarshal value: %w", err)
+			}
+		case 4:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read summary")
+			}
+			if err := m.unmarshalSummary(data); err != nil {
+				return fmt.Errorf("cannot unmarshal summary: %w", err)
+			}
+		case 6:
+			ts, ok := fc.Int64()
+			if !ok {
+				return fmt.Errorf("cannot read timestamp_ms")
+			}
+			m.timestampMs = ts
+		case 7:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read histogram")
+			}
+			if err := m.unmarshalHistogram(data); err != nil {
+				return fmt.Errorf("cannot unmarshal histogram: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *metric) unmarshalLabel(src []byte) (err error) {
+	// message LabelPair {
+	//   string name = 1;
+	//   string value = 2;
+	// }
+	var l label
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			name, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read name")
+			}
+			l.name = name
+		case 2:
+			value, ok := fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read value")
+			}
+			l.value = value
+		}
+	}
+	m.labels = append(m.labels, l)
+	return nil
+}
+
+func (m *metric) unmarshalValue(src []byte) (err error) {
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		if fc.FieldNum == 1 {
+			v, ok := fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read value")
+			}
+			m.value = v
+		}
+	}
+	return nil
+}
+
+func (m *metric) unmarshalSummary(src []byte) (err error) {
+	// message Summary {
+	//   uint64 sample_count = 1;
+	//   double sample_sum = 2;
+	//   repeated Quantile quantile = 3;
+	//   google.protobuf.Timestamp created_timestamp = 4;
+	// }
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			n, ok := fc.Uint64()
+			if !ok {
+				return fmt.Errorf("cannot read sample_count")
+			}
+			m.count = float64(n)
+		case 2:
+			v, ok := fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read sample_sum")
+			}
+			m.sum = v
+		case 3:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read quantile")
+			}
+			if err := m.unmarshalQuantile(data); err != nil {
+				return fmt.Errorf("cannot unmarshal quantile: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *metric) unmarshalQuantile(src []byte) (err error) {
+	// message Quantile {
+	//   double quantile = 1;
+	//   double value = 2;
+	// }
+	var q quantile
+	var fc easyproto.FieldContext
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			v, ok := fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read quantile")
+			}
+			q.quantile = v
+		case 2:
+			v, ok := fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read value")
+			}
+			q.value = v
+		}
+	}
+	m.quantiles = append(m.quantiles, q)
+	return nil
+}
+
+func (m *metric) unmarshalHistogram(src []byte) (err error) {
+	// message Histogram {
+	//   uint64 sample_count = 1;
+	//   double sample_count_float = 4;
+	//   double sample_sum = 2;
+	//   repeated Bucket bucket = 3;
+	//   google.protobuf.Timestamp created_timestamp = 15;
+	//   sint32 schema = 5;
+	//   double zero_threshold = 6;
+	//   uint64 zero_count = 7;
+	//   double zero_count_float = 8;
+	//   repeated BucketSpan negative_span = 9;
+	//   repeated sint64 negative_delta = 10;
+	//   repeated double negative_count = 11;
+	//   repeated BucketSpan positive_span = 12;
+	//   repeated sint64 positive_delta = 13;
+	//   repeated double positive_count = 14;
+	//   repeated Exemplar exemplars = 16;
+	// }
+	var fc easyproto.FieldContext
+	var ok bool
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			n, ok := fc.Uint64()
+			if !ok {
+				return fmt.Errorf("cannot read sample_count")
+			}
+			m.count = float64(n)
+		case 4:
+			m.count, ok = fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read sample_count_float")
+			}
+		case 2:
+			m.sum, ok = fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read sample_sum")
+			}
+		case 3:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read bucket")
+			}
+			if err := m.unmarshalBucket(data); err != nil {
+				return fmt.Errorf("cannot unmarshal bucket: %w", err)
+			}
+		case 5:
+			m.schema, ok = fc.Sint32()
+			if !ok {
+				return fmt.Errorf("cannot read schema")
+			}
+		case 6:
+			m.zeroThreshold, ok = fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read zero_threshold")
+			}
+		case 7:
+			n, ok := fc.Uint64()
+			if !ok {
+				return fmt.Errorf("cannot read zero_count")
+			}
+			m.zeroCount = float64(n)
+		case 8:
+			m.zeroCount, ok = fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read zero_count_float")
+			}
+		case 9, 12:
+			data, ok := fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read bucket span")
+			}
+			span, err := unmarshalBucketSpan(data)
+			if err != nil {
+				return fmt.Errorf("cannot unmarshal bucket span: %w", err)
+			}
+			if fc.FieldNum == 9 {
+				m.negativeSpans = append(m.negativeSpans, span)
+			} else {
+				m.positiveSpans = append(m.positiveSpans, span)
+			}
+		case 10:
+			m.negativeDeltas, ok = fc.UnpackSint64s(m.negativeDeltas)
+			if !ok {
+				return fmt.Errorf("cannot read negative_delta")
+			}
+		case 11:
+			m.negativeCounts, ok = fc.UnpackDoubles(m.negativeCounts)
+			if !ok {
+				return fmt.Errorf("cannot read negative_count")
+			}
+		case 13:
+			m.positiveDeltas, ok = fc.UnpackSint64s(m.positiveDeltas)
+			if !ok {
+				return fmt.Errorf("cannot read positive_delta")
+			}
+		case 14:
+			m.positiveCounts, ok = fc.UnpackDoubles(m.positiveCounts)
+			if !ok {
+				return fmt.Errorf("cannot read positive_count")
+			}
+		}
+	}
+	return nil
+}
+
+func (m *metric) unmarshalBucket(src []byte) (err error) {
+	// message Bucket {
+	//   uint64 cumulative_count = 1;
+	//   double cumulative_count_float = 4;
+	//   double upper_bound = 2;
+	//   Exemplar exemplar = 3;
+	// }
+	var b bucket
+	var fc easyproto.FieldContext
+	var ok bool
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			n, ok := fc.Uint64()
+			if !ok {
+				return fmt.Errorf("cannot read cumulative_count")
+			}
+			b.cumulativeCount = float64(n)
+		case 4:
+			b.cumulativeCount, ok = fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read cumulative_count_float")
+			}
+		case 2:
+			b.upperBound, ok = fc.Double()
+			if !ok {
+				return fmt.Errorf("cannot read upper_bound")
+			}
+		}
+	}
+	m.buckets = append(m.buckets, b)
+	return nil
+}
+
+func unmarshalBucketSpan(src []byte) (span bucketSpan, err error) {
+	// message BucketSpan {
+	//   sint32 offset = 1;
+	//   uint32 length = 2;
+	// }
+	var fc easyproto.FieldContext
+	var ok bool
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return span, fmt.Errorf("cannot read next field: %w", err)
+		}
+		switch fc.FieldNum {
+		case 1:
+			span.offset, ok = fc.Sint32()
+			if !ok {
+				return span, fmt.Errorf("cannot read offset")
+			}
+		case 2:
+			span.length, ok = fc.Uint32()
+			if !ok {
+				return span, fmt.Errorf("cannot read length")
+			}
+		}
+	}
+	return span, nil
+}
+
+func (m *metric) appendSummary(dst []byte, name string) []byte {
+	for _, q := range m.quantiles {
+		dst = m.appendSample(dst, name, "quantile", formatFloat(q.quantile), q.value)
+	}
+	dst = m.appendSample(dst, name+"_sum", "", "", m.sum)
+	dst = m.appendSample(dst, name+"_count", "", "", m.count)
+	return dst
+}
+
+func (m *metric) appendHistogram(dst []byte, name string) []byte {
+	bucketName := name + "_bucket"
+	if len(m.buckets) == 0 && m.isNativeHistogram() {
+		dst = m.appendNativeHistogramBuckets(dst, bucketName)
+	} else {
+		hasInf := false
+		for _, b := range m.buckets {
+			if math.IsInf(b.upperBound, 1) {
+				hasInf = true
+			}
+			dst = m.appendSample(dst, bucketName, "le", formatFloat(b.upperBound), b.cumulativeCount)
+		}
+		if !hasInf {
+			// Prometheus adds the implicit +Inf bucket if it is missing in the histogram.
+			dst = m.appendSample(dst, bucketName, "le", "+Inf", m.count)
+		}
+	}
+	dst = m.appendSample(dst, name+"_sum", "", "", m.sum)
+	dst = m.appendSample(dst, name+"_count", "", "", m.count)
+	return dst
+}
+
+func (m *metric) isNativeHistogram() bool {
+	return len(m.positiveSpans) > 0 || len(m.negativeSpans) > 0 || m.zeroThreshold > 0 || m.zeroCount > 0
+}
+
+// appendNativeHistogramBuckets appends native histogram buckets from m to dst as VictoriaMetrics histogram buckets.
+//
+// See https://prometheus.io/docs/specs/native_histograms/#schema
+func (m *metric) appendNativeHistogramBuckets(dst []byte, bucketName string) []byte {
+	if m.schema < -4 || m.schema > 8 {
+		// Unsupported schema such as native histograms with custom buckets. Skip buckets.
+		return dst
+	}
+	if m.zeroCount > 0 {
+		// The zero bucket contains observations in the range [-zeroThreshold ... zeroThreshold].
+		// Put them into the bucket starting from zero like VictoriaMetrics histograms do for small values.
+		vmrange := "0..." + formatBound(m.zeroThreshold)
+		dst = m.appendSample(dst, bucketName, "vmrange", vmrange, m.zeroCount)
+	}
+	dst = m.appendNativeBuckets(dst, bucketName, m.negativeSpans, m.negativeDeltas, m.negativeCounts, true)
+	dst = m.appendNativeBuckets(dst, bucketName, m.positiveSpans, m.positiveDeltas, m.positiveCounts, false)
+	return dst
+}
+
+func (m *metric) appendNativeBuckets(dst []byte, bucketName string, spans []bucketSpan, deltas []int64, counts []float64, isNegative bool) []byte {
+	var idx int32
+	var count int64
+	n := 0
+	for i, span := range spans {
+		if i == 0 {
+			idx = span.offset
+		} else {
+			idx += span.offset
+		}
+		for j := uint32(0); j < span.length; j++ {
+			var v float64
+			if n < len(counts) {
+				v = counts[n]
+			} else if n < len(deltas) {
+				count += deltas[n]
+				v = float64(count)
+			}
+			n++
+			if v > 0 {
+				lower := getNativeBucketBound(m.schema, idx-1)
+				upper := getNativeBucketBound(m.schema, idx)
+				var vmrange string
+				if isNegative {
+					vmrange = formatBound(-upper) + "..." + formatBound(-lower)
+				} else {
+					vmrange = formatBound(lower) + "..." + formatBound(upper)
+				}
+				dst = m.appendSample(dst, bucketName, "vmrange", vmrange, v)
+			}
+			idx++
+		}
+	}
+	return dst
+}
+
+// getNativeBucketBound returns the upper bound for native histogram bucket with the given idx and the given schema.
+func getNativeBucketBound(schema, idx int32) float64 {
+	return math.Exp2(float64(idx) * math.Exp2(-float64(schema)))
+}
+
+func (m *metric) appendSample(dst []byte, name, extraLabelName, extraLabelValue string, value float64) []byte {
+	dst = append(dst, name...)
+	if len(m.labels) > 0 || extraLabelName != "" {
+		dst = append(dst, '{')
+		for i, l := range m.labels {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendLabel(dst, l.name, l.value)
+		}
+		if extraLabelName != "" {
+			if len(m.labels) > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendLabel(dst, extraLabelName, extraLabelValue)
+		}
+		dst = append(dst, '}')
+	}
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, value, 'g', -1, 64)
+	if m.timestampMs != 0 {
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, m.timestampMs, 10)
+	}
+	dst = append(dst, '\n')
+	return dst
+}
+
+func appendLabel(dst []byte, name, value string) []byte {
+	dst = append(dst, name...)
+	dst = append(dst, `="`...)
+	dst = prometheus.AppendEscapedLabelValue(dst, value)
+	dst = append(dst, '"')
+	return dst
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func formatBound(v float64) string {
+	return fmt.Sprintf("%.3e", v)
+}
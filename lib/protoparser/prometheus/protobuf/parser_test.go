@@ -0,0 +1,226 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/easyproto"
+)
+
+type testLabel struct {
+	name  string
+	value string
+}
+
+type testBucket struct {
+	upperBound      float64
+	cumulativeCount uint64
+}
+
+type testSpan struct {
+	offset int32
+	length uint32
+}
+
+type testMetric struct {
+	labels      []testLabel
+	value       float64
+	timestampMs int64
+
+	count     uint64
+	sum       float64
+	quantiles [][2]float64
+	buckets   []testBucket
+
+	schema         int32
+	zeroThreshold  float64
+	zeroCount      uint64
+	positiveSpans  []testSpan
+	positiveDeltas []int64
+	negativeSpans  []testSpan
+	negativeCounts []float64
+}
+
+func marshalMetricFamily(dst []byte, name string, typ metricType, metrics []testMetric) []byte {
+	var m easyproto.Marshaler
+	mm := m.MessageMarshaler()
+	mm.AppendString(1, name)
+	mm.AppendString(2, "some help")
+	mm.AppendInt32(3, int32(typ))
+	for _, tm := range metrics {
+		mmm := mm.AppendMessage(4)
+		for _, l := range tm.labels {
+			lm := mmm.AppendMessage(1)
+			lm.AppendString(1, l.name)
+			lm.AppendString(2, l.value)
+		}
+		switch typ {
+		case metricTypeGauge:
+			mmm.AppendMessage(2).AppendDouble(1, tm.value)
+		case metricTypeCounter:
+			mmm.AppendMessage(3).AppendDouble(1, tm.value)
+		case metricTypeUntyped:
+			mmm.AppendMessage(5).AppendDouble(1, tm.value)
+		case metricTypeSummary:
+			sm := mmm.AppendMessage(4)
+			sm.AppendUint64(1, tm.count)
+			sm.AppendDouble(2, tm.sum)
+			for _, q := range tm.quantiles {
+				qm := sm.AppendMessage(3)
+				qm.AppendDouble(1, q[0])
+				qm.AppendDouble(2, q[1])
+			}
+		case metricTypeHistogram:
+			hm := mmm.AppendMessage(7)
+			hm.AppendUint64(1, tm.count)
+			hm.AppendDouble(2, tm.sum)
+			for _, b := range tm.buckets {
+				bm := hm.AppendMessage(3)
+				bm.AppendUint64(1, b.cumulativeCount)
+				bm.AppendDouble(2, b.upperBound)
+			}
+			hm.AppendSint32(5, tm.schema)
+			hm.AppendDouble(6, tm.zeroThreshold)
+			hm.AppendUint64(7, tm.zeroCount)
+			for _, s := range tm.negativeSpans {
+				spm := hm.AppendMessage(9)
+				spm.AppendSint32(1, s.offset)
+				spm.AppendUint32(2, s.length)
+			}
+			hm.AppendDoubles(11, tm.negativeCounts)
+			for _, s := range tm.positiveSpans {
+				spm := hm.AppendMessage(12)
+				spm.AppendSint32(1, s.offset)
+				spm.AppendUint32(2, s.length)
+			}
+			hm.AppendSint64s(13, tm.positiveDeltas)
+		}
+		if tm.timestampMs != 0 {
+			mmm.AppendInt64(6, tm.timestampMs)
+		}
+	}
+	return m.MarshalWithLen(dst)
+}
+
+func TestAppendTextSuccess(t *testing.T) {
+	f := func(data []byte, resultExpected string) {
+		t.Helper()
+		result, err := AppendText(nil, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(result) != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	f(nil, "")
+
+	// counter, gauge and untyped
+	var data []byte
+	data = marshalMetricFamily(data, "http_requests_total", metricTypeCounter, []testMetric{
+		{
+			labels: []testLabel{{"method", "GET"}, {"path", `/foo"bar`}},
+			value:  123,
+		},
+		{
+			labels:      []testLabel{{"method", "POST"}},
+			value:       4.5,
+			timestampMs: 1700000000123,
+		},
+	})
+	data = marshalMetricFamily(data, "temperature", metricTypeGauge, []testMetric{
+		{
+			value: -1.25,
+		},
+	})
+	data = marshalMetricFamily(data, "foo", metricTypeUntyped, []testMetric{
+		{
+			labels: []testLabel{{"a", "b\nc"}},
+			value:  1e100,
+		},
+	})
+	f(data, `http_requests_total{method="GET",path="/foo\"bar"} 123
+http_requests_total{method="POST"} 4.5 1700000000123
+temperature -1.25
+foo{a="b\nc"} 1e+100
+`)
+
+	// summary
+	data = marshalMetricFamily(nil, "rpc_duration_seconds", metricTypeSummary, []testMetric{
+		{
+			labels:    []testLabel{{"service", "x"}},
+			count:     10,
+			sum:       5.5,
+			quantiles: [][2]float64{{0.5, 0.4}, {0.99, 1.2}},
+		},
+	})
+	f(data, `rpc_duration_seconds{service="x",quantile="0.5"} 0.4
+rpc_duration_seconds{service="x",quantile="0.99"} 1.2
+rpc_duration_seconds_sum{service="x"} 5.5
+rpc_duration_seconds_count{service="x"} 10
+`)
+
+	// classic histogram without +Inf bucket
+	data = marshalMetricFamily(nil, "request_duration_seconds", metricTypeHistogram, []testMetric{
+		{
+			count: 7,
+			sum:   3.5,
+			buckets: []testBucket{
+				{0.1, 2},
+				{1, 5},
+			},
+		},
+	})
+	f(data, `request_duration_seconds_bucket{le="0.1"} 2
+request_duration_seconds_bucket{le="1"} 5
+request_duration_seconds_bucket{le="+Inf"} 7
+request_duration_seconds_sum 3.5
+request_duration_seconds_count 7
+`)
+
+	// native histogram
+	data = marshalMetricFamily(nil, "latency_seconds", metricTypeHistogram, []testMetric{
+		{
+			labels:        []testLabel{{"job", "x"}},
+			count:         13,
+			sum:           12.5,
+			schema:        0,
+			zeroThreshold: 1e-3,
+			zeroCount:     1,
+			// Buckets (1..2], (2..4], (8..16] with counts 3, 5, 2
+			positiveSpans:  []testSpan{{1, 2}, {1, 1}},
+			positiveDeltas: []int64{3, 2, -3},
+			// Bucket (-1..-0.5] with count 2
+			negativeSpans:  []testSpan{{0, 1}},
+			negativeCounts: []float64{2},
+		},
+	})
+	f(data, `latency_seconds_bucket{job="x",vmrange="0...1.000e-03"} 1
+latency_seconds_bucket{job="x",vmrange="-1.000e+00...-5.000e-01"} 2
+latency_seconds_bucket{job="x",vmrange="1.000e+00...2.000e+00"} 3
+latency_seconds_bucket{job="x",vmrange="2.000e+00...4.000e+00"} 5
+latency_seconds_bucket{job="x",vmrange="8.000e+00...1.600e+01"} 2
+latency_seconds_sum{job="x"} 12.5
+latency_seconds_count{job="x"} 13
+`)
+}
+
+func TestAppendTextFailure(t *testing.T) {
+	f := func(data []byte) {
+		t.Helper()
+		_, err := AppendText(nil, data)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// too short data
+	data := marshalMetricFamily(nil, "foo", metricTypeGauge, []testMetric{{value: 1}})
+	f(data[:len(data)-1])
+
+	// invalid message
+	f([]byte{3, 0xff, 0xff, 0xff})
+
+	// missing name
+	f(marshalMetricFamily(nil, "", metricTypeGauge, []testMetric{{value: 1}}))
+}
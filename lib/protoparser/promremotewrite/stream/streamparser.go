@@ -22,6 +22,15 @@ var maxInsertRequestSize = flagutil.NewBytes("maxInsertRequestSize", 32*1024*102
 //
 // callback shouldn't hold tss after returning.
 func Parse(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSeries) error) error {
+	return ParseWithMetadata(r, isVMRemoteWrite, func(tss []prompb.TimeSeries, _ []prompb.MetricMetadata) error {
+		return callback(tss)
+	})
+}
+
+// ParseWithMetadata parses Prometheus remote_write message from reader and calls callback for the parsed timeseries and metadata.
+//
+// callback shouldn't hold tss and mms after returning.
+func ParseWithMetadata(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSeries, mms []prompb.MetricMetadata) error) error {
 	wcr := writeconcurrencylimiter.GetReader(r)
 	defer writeconcurrencylimiter.PutReader(wcr)
 	r = wcr
@@ -88,7 +97,7 @@ func Parse(r io.Reader, isVMRemoteWrite bool, callback func(tss []prompb.TimeSer
 	}
 	rowsRead.Add(rows)
 
-	if err := callback(tss); err != nil {
+	if err := callback(tss, wr.Metadata); err != nil {
 		return fmt.Errorf("error when processing imported data: %w", err)
 	}
 	return nil
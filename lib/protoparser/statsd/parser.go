@@ -0,0 +1,289 @@
+package statsd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/valyala/fastjson/fastfloat"
+)
+
+// MetricType is the type of StatsD metric.
+type MetricType byte
+
+const (
+	// Counter is StatsD counter (`c` type).
+	Counter MetricType = iota
+
+	// Gauge is StatsD gauge (`g` type).
+	Gauge
+
+	// Timer is StatsD timer (`ms` type), DogStatsD histogram (`h` type) or DogStatsD distribution (`d` type).
+	Timer
+
+	// Set is StatsD set (`s` type).
+	Set
+)
+
+// String returns string representation for mt.
+func (mt MetricType) String() string {
+	switch mt {
+	case Counter:
+		return "counter"
+	case Gauge:
+		return "gauge"
+	case Timer:
+		return "timer"
+	case Set:
+		return "set"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(mt))
+	}
+}
+
+// Rows contains parsed StatsD rows.
+type Rows struct {
+	Rows []Row
+
+	tagsPool []Tag
+}
+
+// Reset resets rs.
+func (rs *Rows) Reset() {
+	// Reset items, so they can be GC'ed
+
+	for i := range rs.Rows {
+		rs.Rows[i].reset()
+	}
+	rs.Rows = rs.Rows[:0]
+
+	for i := range rs.tagsPool {
+		rs.tagsPool[i].reset()
+	}
+	rs.tagsPool = rs.tagsPool[:0]
+}
+
+// Unmarshal unmarshals StatsD plaintext protocol rows from s.
+//
+// Lines with multiple values such as `foo:1:2:3|ms` are unmarshaled into multiple rows.
+//
+// See https://github.com/statsd/statsd/blob/master/docs/metric_types.md
+// and https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/
+//
+// s shouldn't be modified when rs is in use.
+func (rs *Rows) Unmarshal(s string) {
+	rs.Rows, rs.tagsPool = unmarshalRows(rs.Rows[:0], s, rs.tagsPool[:0])
+}
+
+// Row is a single StatsD row.
+type Row struct {
+	Metric string
+	Tags   []Tag
+	Type   MetricType
+
+	// Value is the parsed value for all the types except of Set.
+	Value float64
+
+	// StringValue is the original value for Set type.
+	StringValue string
+
+	// IsDelta is set to true for Gauge rows with explicit sign in front of the value.
+	IsDelta bool
+
+	// SampleRate is the sample rate from `|@rate` field. It equals to 1 by default.
+	SampleRate float64
+}
+
+func (r *Row) reset() {
+	r.Metric = ""
+	r.Tags = nil
+	r.Type = 0
+	r.Value = 0
+	r.StringValue = ""
+	r.IsDelta = false
+	r.SampleRate = 0
+}
+
+func unmarshalRows(dst []Row, s string, tagsPool []Tag) ([]Row, []Tag) {
+	for len(s) > 0 {
+		n := strings.IndexByte(s, '\n')
+		if n < 0 {
+			// The last line.
+			return unmarshalRow(dst, s, tagsPool)
+		}
+		dst, tagsPool = unmarshalRow(dst, s[:n], tagsPool)
+		s = s[n+1:]
+	}
+	return dst, tagsPool
+}
+
+func unmarshalRow(dst []Row, s string, tagsPool []Tag) ([]Row, []Tag) {
+	if len(s) > 0 && s[len(s)-1] == '\r' {
+		s = s[:len(s)-1]
+	}
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		// Skip empty line
+		return dst, tagsPool
+	}
+	dstLen := len(dst)
+	tagsPoolLen := len(tagsPool)
+	var err error
+	dst, tagsPool, err = appendRowsFromLine(dst, s, tagsPool)
+	if err != nil {
+		dst = dst[:dstLen]
+		tagsPool = tagsPool[:tagsPoolLen]
+		logger.Errorf("cannot unmarshal StatsD line %q: %s", s, err)
+		invalidLines.Inc()
+	}
+	return dst, tagsPool
+}
+
+var invalidLines = metrics.NewCounter(`vm_rows_invalid_total{type="statsd"}`)
+
+// appendRowsFromLine appends rows parsed from a single line in the format:
+//
+//	<metric>:<value>[:<value>...]|<type>[|@<sample_rate>][|#<tag1>:<value1>,...]
+func appendRowsFromLine(dst []Row, s string, tagsPool []Tag) ([]Row, []Tag, error) {
+	n := strings.IndexByte(s, '|')
+	if n < 0 {
+		return dst, tagsPool, fmt.Errorf("cannot find `|` separator between metric value and metric type")
+	}
+	metricAndValues := s[:n]
+	fields := s[n+1:]
+
+	n = strings.IndexByte(metricAndValues, ':')
+	if n < 0 {
+		return dst, tagsPool, fmt.Errorf("cannot find `:` separator between metric name and metric value")
+	}
+	metric := metricAndValues[:n]
+	if len(metric) == 0 {
+		return dst, tagsPool, fmt.Errorf("metric name cannot be empty")
+	}
+	values := metricAndValues[n+1:]
+
+	typeStr := fields
+	fields = ""
+	if n := strings.IndexByte(typeStr, '|'); n >= 0 {
+		fields = typeStr[n+1:]
+		typeStr = typeStr[:n]
+	}
+	var mt MetricType
+	switch typeStr {
+	case "c":
+		mt = Counter
+	case "g":
+		mt = Gauge
+	case "ms", "h", "d":
+		mt = Timer
+	case "s":
+		mt = Set
+	default:
+		return dst, tagsPool, fmt.Errorf("unsupported metric type %q; supported types: c, g, ms, h, d, s", typeStr)
+	}
+
+	sampleRate := float64(1)
+	tagsStart := len(tagsPool)
+	for len(fields) > 0 {
+		field := fields
+		fields = ""
+		if n := strings.IndexByte(field, '|'); n >= 0 {
+			fields = field[n+1:]
+			field = field[:n]
+		}
+		if len(field) == 0 {
+			continue
+		}
+		switch field[0] {
+		case '@':
+			v, err := fastfloat.Parse(field[1:])
+			if err != nil {
+				return dst, tagsPool, fmt.Errorf("cannot parse sample rate from %q: %w", field, err)
+			}
+			if v <= 0 || v > 1 {
+				return dst, tagsPool, fmt.Errorf("sample rate must be in the range (0..1]; got %v", v)
+			}
+			sampleRate = v
+		case '#':
+			tagsPool = unmarshalTags(tagsPool, field[1:])
+		default:
+			// Ignore unsupported DogStatsD extension fields such as `c:<container_id>` or `T<timestamp>`.
+		}
+	}
+	var tags []Tag
+	if len(tagsPool) > tagsStart {
+		tags = tagsPool[tagsStart:]
+	}
+
+	for {
+		valueStr := values
+		hasMoreValues := false
+		if mt != Set {
+			if n := strings.IndexByte(valueStr, ':'); n >= 0 {
+				values = valueStr[n+1:]
+				valueStr = valueStr[:n]
+				hasMoreValues = true
+			}
+		}
+		if len(valueStr) == 0 {
+			return dst, tagsPool, fmt.Errorf("metric value cannot be empty")
+		}
+		if cap(dst) > len(dst) {
+			dst = dst[:len(dst)+1]
+		} else {
+			dst = append(dst, Row{})
+		}
+		r := &dst[len(dst)-1]
+		r.reset()
+		r.Metric = metric
+		r.Tags = tags
+		r.Type = mt
+		r.SampleRate = sampleRate
+		if mt == Set {
+			r.StringValue = valueStr
+		} else {
+			r.IsDelta = mt == Gauge && (valueStr[0] == '+' || valueStr[0] == '-')
+			v, err := fastfloat.Parse(strings.TrimPrefix(valueStr, "+"))
+			if err != nil {
+				return dst, tagsPool, fmt.Errorf("cannot parse metric value from %q: %w", valueStr, err)
+			}
+			r.Value = v
+		}
+		if !hasMoreValues {
+			return dst, tagsPool, nil
+		}
+	}
+}
+
+func unmarshalTags(dst []Tag, s string) []Tag {
+	for len(s) > 0 {
+		tagStr := s
+		s = ""
+		if n := strings.IndexByte(tagStr, ','); n >= 0 {
+			s = tagStr[n+1:]
+			tagStr = tagStr[:n]
+		}
+		key, value, _ := strings.Cut(tagStr, ":")
+		if len(key) == 0 || len(value) == 0 {
+			// Skip tags without values, since they cannot be represented as labels.
+			continue
+		}
+		dst = append(dst, Tag{
+			Key:   key,
+			Value: value,
+		})
+	}
+	return dst
+}
+
+// Tag is a StatsD tag.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+func (t *Tag) reset() {
+	t.Key = ""
+	t.Value = ""
+}
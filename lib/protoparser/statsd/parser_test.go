@@ -0,0 +1,183 @@
+package statsd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowsUnmarshalFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		var rows Rows
+		rows.Unmarshal(s)
+		if len(rows.Rows) != 0 {
+			t.Fatalf("expecting zero rows for %q; got %d rows: %+v", s, len(rows.Rows), rows.Rows)
+		}
+
+		// Try again
+		rows.Unmarshal(s)
+		if len(rows.Rows) != 0 {
+			t.Fatalf("expecting zero rows for %q; got %d rows: %+v", s, len(rows.Rows), rows.Rows)
+		}
+	}
+
+	// Missing type
+	f("foo:1")
+
+	// Missing value
+	f("foo|c")
+	f("foo:|c")
+	f("foo:1:|ms")
+
+	// Missing metric name
+	f(":1|c")
+
+	// Unsupported type
+	f("foo:1|x")
+	f("foo:1|")
+
+	// Invalid value
+	f("foo:bar|c")
+	f("foo:1:bar|ms")
+
+	// Invalid sample rate
+	f("foo:1|c|@bar")
+	f("foo:1|c|@0")
+	f("foo:1|c|@1.5")
+}
+
+func TestRowsUnmarshalSuccess(t *testing.T) {
+	f := func(s string, rowsExpected []Row) {
+		t.Helper()
+		var rows Rows
+		rows.Unmarshal(s)
+		if !reflect.DeepEqual(rows.Rows, rowsExpected) {
+			t.Fatalf("unexpected rows;\ngot\n%+v;\nwant\n%+v", rows.Rows, rowsExpected)
+		}
+
+		// Try unmarshaling again
+		rows.Unmarshal(s)
+		if !reflect.DeepEqual(rows.Rows, rowsExpected) {
+			t.Fatalf("unexpected rows on the second unmarshal;\ngot\n%+v;\nwant\n%+v", rows.Rows, rowsExpected)
+		}
+
+		rows.Reset()
+		if len(rows.Rows) != 0 {
+			t.Fatalf("non-empty rows after reset: %+v", rows.Rows)
+		}
+	}
+
+	// Empty line
+	f("", nil)
+	f("\r\n\n", nil)
+
+	// Counter
+	f("foo.bar:1|c", []Row{{
+		Metric:     "foo.bar",
+		Type:       Counter,
+		Value:      1,
+		SampleRate: 1,
+	}})
+
+	// Counter with sample rate and tags
+	f("foo:2|c|@0.1|#env:prod,host:a,novalue", []Row{{
+		Metric: "foo",
+		Tags: []Tag{
+			{
+				Key:   "env",
+				Value: "prod",
+			},
+			{
+				Key:   "host",
+				Value: "a",
+			},
+		},
+		Type:       Counter,
+		Value:      2,
+		SampleRate: 0.1,
+	}})
+
+	// Gauge
+	f("foo:-1.5|g\nbar:10|g\nbaz:+3|g", []Row{
+		{
+			Metric:     "foo",
+			Type:       Gauge,
+			Value:      -1.5,
+			IsDelta:    true,
+			SampleRate: 1,
+		},
+		{
+			Metric:     "bar",
+			Type:       Gauge,
+			Value:      10,
+			SampleRate: 1,
+		},
+		{
+			Metric:     "baz",
+			Type:       Gauge,
+			Value:      3,
+			IsDelta:    true,
+			SampleRate: 1,
+		},
+	})
+
+	// Timers, histograms and distributions with multiple values
+	f("foo:1:2|ms|#a:b\r\nbar:3|h\nbaz:4|d|c:container|T1656581400", []Row{
+		{
+			Metric: "foo",
+			Tags: []Tag{{
+				Key:   "a",
+				Value: "b",
+			}},
+			Type:       Timer,
+			Value:      1,
+			SampleRate: 1,
+		},
+		{
+			Metric: "foo",
+			Tags: []Tag{{
+				Key:   "a",
+				Value: "b",
+			}},
+			Type:       Timer,
+			Value:      2,
+			SampleRate: 1,
+		},
+		{
+			Metric:     "bar",
+			Type:       Timer,
+			Value:      3,
+			SampleRate: 1,
+		},
+		{
+			Metric:     "baz",
+			Type:       Timer,
+			Value:      4,
+			SampleRate: 1,
+		},
+	})
+
+	// Set
+	f("users:user:123|s", []Row{{
+		Metric:      "users",
+		Type:        Set,
+		StringValue: "user:123",
+		SampleRate:  1,
+	}})
+
+	// Invalid lines are skipped
+	f("foo:1|c\nbar|c\nbaz:2|c", []Row{
+		{
+			Metric:     "foo",
+			Type:       Counter,
+			Value:      1,
+			SampleRate: 1,
+		},
+		{
+			Metric:     "baz",
+			Type:       Counter,
+			Value:      2,
+			SampleRate: 1,
+		},
+	})
+}
@@ -0,0 +1,352 @@
+package stream
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/streamaggr"
+)
+
+var (
+	flushInterval = flag.Duration("statsd.flushInterval", 10*time.Second, "Interval for aggregating StatsD metrics before sending them to remote storage. "+
+		"Must be a multiple of a second. See https://docs.victoriametrics.com/victoriametrics/integrations/statsd/")
+	timerQuantiles = flag.String("statsd.timerQuantiles", "0.5,0.9,0.99", "Comma-separated list of quantiles to calculate for StatsD timers, histograms and distributions "+
+		"per each -statsd.flushInterval. See https://docs.victoriametrics.com/victoriametrics/integrations/statsd/")
+)
+
+// seriesStateTTL is the duration after which the state for counters and gauges without updates is dropped.
+//
+// The state is needed for converting counter increments to cumulative counters
+// and for applying relative updates to gauges such as `foo:+1|g`.
+const seriesStateTTL = time.Hour
+
+// maxTimerSamplesPerRow is the maximum number of samples generated for sampled timer rows.
+const maxTimerSamplesPerRow = 1000
+
+// Aggregator aggregates StatsD rows per each -statsd.flushInterval.
+//
+// The aggregation is performed with the following stream aggregation outputs
+// (see https://docs.victoriametrics.com/victoriametrics/stream-aggregation/ ):
+//
+//   - counters are converted to cumulative counters in the same way as statsd_exporter does
+//     after dividing the value by the sample rate, and then are aggregated with max output;
+//   - gauges are aggregated with last output after applying relative updates;
+//   - timers, histograms and distributions are aggregated with quantiles, count_samples and sum_samples outputs,
+//     which are exposed as <metric>{quantile="..."}, <metric>_count and <metric>_sum;
+//   - sets are aggregated with unique_samples output.
+type Aggregator struct {
+	counters *streamaggr.Aggregators
+	gauges   *streamaggr.Aggregators
+	timers   *streamaggr.Aggregators
+	sets     *streamaggr.Aggregators
+
+	// statesLock protects counterStates and gaugeStates.
+	statesLock    sync.Mutex
+	counterStates map[string]*seriesState
+	gaugeStates   map[string]*seriesState
+
+	wg     sync.WaitGroup
+	stopCh chan struct{}
+}
+
+type seriesState struct {
+	value      float64
+	lastUpdate uint64
+}
+
+// MustNewAggregator returns new Aggregator, which calls pushFunc with the aggregated series per each -statsd.flushInterval.
+//
+// MustStop must be called on the returned Aggregator when it is no longer needed.
+func MustNewAggregator(pushFunc streamaggr.PushFunc) *Aggregator {
+	a, err := newAggregator(*flushInterval, *timerQuantiles, pushFunc)
+	if err != nil {
+		logger.Fatalf("cannot initialize StatsD aggregator: %s", err)
+	}
+	return a
+}
+
+func newAggregator(interval time.Duration, quantiles string, pushFunc streamaggr.PushFunc) (*Aggregator, error) {
+	if interval < time.Second || interval%time.Second != 0 {
+		return nil, fmt.Errorf("flush interval must be a multiple of a second; got %s", interval)
+	}
+	intervalStr := interval.String()
+
+	var as []*streamaggr.Aggregators
+	mustStopAll := func() {
+		for _, a := range as {
+			a.MustStop()
+		}
+	}
+	newAggregators := func(name, config string) (*streamaggr.Aggregators, error) {
+		data := fmt.Sprintf("- name: statsd_%s\n  interval: %s\n%s", name, intervalStr, config)
+		opts := &streamaggr.Options{
+			FlushOnShutdown: true,
+		}
+		a, err := streamaggr.LoadFromData([]byte(data), pushFunc, opts, "statsd")
+		if err != nil {
+			mustStopAll()
+			return nil, fmt.Errorf("cannot initialize aggregation for StatsD %ss: %w", name, err)
+		}
+		as = append(as, a)
+		return a, nil
+	}
+
+	// The max output is used for cumulative counters instead of the last output,
+	// since the former is resilient to reordering of concurrently pushed samples.
+	counters, err := newAggregators("counter", "  outputs: [max]\n  keep_metric_names: true\n")
+	if err != nil {
+		return nil, err
+	}
+	gauges, err := newAggregators("gauge", "  outputs: [last]\n  keep_metric_names: true\n")
+	if err != nil {
+		return nil, err
+	}
+	timers, err := newAggregators("timer", fmt.Sprintf(`  outputs: ["quantiles(%s)", count_samples, sum_samples]
+  output_relabel_configs:
+  - source_labels: [__name__]
+    regex: "(.+):%s_quantiles"
+    target_label: __name__
+    replacement: "$1"
+  - source_labels: [__name__]
+    regex: "(.+):%s_count_samples"
+    target_label: __name__
+    replacement: "${1}_count"
+  - source_labels: [__name__]
+    regex: "(.+):%s_sum_samples"
+    target_label: __name__
+    replacement: "${1}_sum"
+`, quantiles, intervalStr, intervalStr, intervalStr))
+	if err != nil {
+		return nil, err
+	}
+	sets, err := newAggregators("set", "  outputs: [unique_samples]\n  keep_metric_names: true\n")
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Aggregator{
+		counters:      counters,
+		gauges:        gauges,
+		timers:        timers,
+		sets:          sets,
+		counterStates: make(map[string]*seriesState),
+		gaugeStates:   make(map[string]*seriesState),
+		stopCh:        make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.runStatesCleaner()
+	}()
+	return a, nil
+}
+
+// MustStop stops a and flushes the pending aggregation state.
+func (a *Aggregator) MustStop() {
+	close(a.stopCh)
+	a.wg.Wait()
+
+	a.counters.MustStop()
+	a.gauges.MustStop()
+	a.timers.MustStop()
+	a.sets.MustStop()
+}
+
+func (a *Aggregator) runStatesCleaner() {
+	t := time.NewTicker(time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-t.C:
+			deadline := fasttime.UnixTimestamp() - uint64(seriesStateTTL.Seconds())
+			a.statesLock.Lock()
+			deleteStaleSeriesStates(a.counterStates, deadline)
+			deleteStaleSeriesStates(a.gaugeStates, deadline)
+			a.statesLock.Unlock()
+		}
+	}
+}
+
+func deleteStaleSeriesStates(m map[string]*seriesState, deadline uint64) {
+	for k, ss := range m {
+		if ss.lastUpdate < deadline {
+			delete(m, k)
+		}
+	}
+}
+
+// Push pushes rows to a.
+func (a *Aggregator) Push(rows []statsd.Row) {
+	ctx := getPushCtx()
+	defer putPushCtx(ctx)
+
+	timestamp := time.Now().UnixMilli()
+	a.pushRows(ctx, a.counters, rows, statsd.Counter, timestamp)
+	a.pushRows(ctx, a.gauges, rows, statsd.Gauge, timestamp)
+	a.pushRows(ctx, a.timers, rows, statsd.Timer, timestamp)
+	a.pushRows(ctx, a.sets, rows, statsd.Set, timestamp)
+}
+
+func (a *Aggregator) pushRows(ctx *pushCtx, as *streamaggr.Aggregators, rows []statsd.Row, mt statsd.MetricType, timestamp int64) {
+	ctx.reset()
+	for i := range rows {
+		r := &rows[i]
+		if r.Type != mt {
+			continue
+		}
+		labelsLen := len(ctx.labels)
+		ctx.labels = append(ctx.labels, prompbmarshal.Label{
+			Name:  "__name__",
+			Value: r.Metric,
+		})
+		for j := range r.Tags {
+			tag := &r.Tags[j]
+			ctx.labels = append(ctx.labels, prompbmarshal.Label{
+				Name:  tag.Key,
+				Value: tag.Value,
+			})
+		}
+		labels := ctx.labels[labelsLen:]
+		// Sort labels, so series with differently ordered tags are aggregated together.
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].Name < labels[j].Name
+		})
+
+		samplesLen := len(ctx.samples)
+		switch mt {
+		case statsd.Counter:
+			if r.Value < 0 {
+				// Counters cannot decrease. Skip negative increments in the same way as statsd_exporter does.
+				continue
+			}
+			ctx.appendSample(a.updateCounter(ctx, labels, r), timestamp)
+		case statsd.Gauge:
+			ctx.appendSample(a.updateGauge(ctx, labels, r), timestamp)
+		case statsd.Timer:
+			// Repeat the sample for sampled timers in order to get the correct count and sum.
+			n := int(math.Round(1 / r.SampleRate))
+			if n < 1 {
+				n = 1
+			}
+			if n > maxTimerSamplesPerRow {
+				n = maxTimerSamplesPerRow
+			}
+			for j := 0; j < n; j++ {
+				ctx.appendSample(r.Value, timestamp)
+			}
+		case statsd.Set:
+			// Convert the value to a number with exact float64 representation, so unique_samples could count it.
+			h := xxhash.Sum64(bytesutil.ToUnsafeBytes(r.StringValue))
+			ctx.appendSample(float64(h>>11), timestamp)
+		}
+		for j := samplesLen; j < len(ctx.samples); j++ {
+			ctx.tss = append(ctx.tss, prompbmarshal.TimeSeries{
+				Labels:  labels,
+				Samples: ctx.samples[j : j+1],
+			})
+		}
+	}
+	if len(ctx.tss) > 0 {
+		as.Push(ctx.tss, nil)
+	}
+}
+
+func (a *Aggregator) updateCounter(ctx *pushCtx, labels []prompbmarshal.Label, r *statsd.Row) float64 {
+	key := ctx.marshalLabels(labels)
+
+	a.statesLock.Lock()
+	defer a.statesLock.Unlock()
+
+	ss := getSeriesState(a.counterStates, key)
+	ss.value += r.Value / r.SampleRate
+	return ss.value
+}
+
+func (a *Aggregator) updateGauge(ctx *pushCtx, labels []prompbmarshal.Label, r *statsd.Row) float64 {
+	key := ctx.marshalLabels(labels)
+
+	a.statesLock.Lock()
+	defer a.statesLock.Unlock()
+
+	ss := getSeriesState(a.gaugeStates, key)
+	if r.IsDelta {
+		ss.value += r.Value
+	} else {
+		ss.value = r.Value
+	}
+	return ss.value
+}
+
+func getSeriesState(m map[string]*seriesState, key []byte) *seriesState {
+	ss := m[string(key)]
+	if ss == nil {
+		ss = &seriesState{}
+		m[string(key)] = ss
+	}
+	ss.lastUpdate = fasttime.UnixTimestamp()
+	return ss
+}
+
+type pushCtx struct {
+	tss     []prompbmarshal.TimeSeries
+	labels  []prompbmarshal.Label
+	samples []prompbmarshal.Sample
+	buf     []byte
+}
+
+func (ctx *pushCtx) reset() {
+	clear(ctx.tss)
+	ctx.tss = ctx.tss[:0]
+
+	clear(ctx.labels)
+	ctx.labels = ctx.labels[:0]
+
+	ctx.samples = ctx.samples[:0]
+	ctx.buf = ctx.buf[:0]
+}
+
+func (ctx *pushCtx) marshalLabels(labels []prompbmarshal.Label) []byte {
+	ctx.buf = ctx.buf[:0]
+	for _, label := range labels {
+		ctx.buf = append(ctx.buf, label.Name...)
+		ctx.buf = append(ctx.buf, 0)
+		ctx.buf = append(ctx.buf, label.Value...)
+		ctx.buf = append(ctx.buf, 0)
+	}
+	return ctx.buf
+}
+
+func (ctx *pushCtx) appendSample(value float64, timestamp int64) {
+	ctx.samples = append(ctx.samples, prompbmarshal.Sample{
+		Value:     value,
+		Timestamp: timestamp,
+	})
+}
+
+func getPushCtx() *pushCtx {
+	v := pushCtxPool.Get()
+	if v == nil {
+		return &pushCtx{}
+	}
+	return v.(*pushCtx)
+}
+
+func putPushCtx(ctx *pushCtx) {
+	ctx.reset()
+	pushCtxPool.Put(ctx)
+}
+
+var pushCtxPool sync.Pool
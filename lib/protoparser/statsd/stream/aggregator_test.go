@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd"
+)
+
+func TestAggregator(t *testing.T) {
+	f := func(data, resultExpected string) {
+		t.Helper()
+
+		var mu sync.Mutex
+		var result []string
+		pushFunc := func(tss []prompbmarshal.TimeSeries) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, ts := range tss {
+				result = append(result, formatTimeSeries(ts))
+			}
+		}
+		a, err := newAggregator(time.Hour, "0.5,1", pushFunc)
+		if err != nil {
+			t.Fatalf("cannot create aggregator: %s", err)
+		}
+		var rows statsd.Rows
+		rows.Unmarshal(data)
+		a.Push(rows.Rows)
+		a.MustStop()
+
+		sort.Strings(result)
+		resultStr := strings.Join(result, "\n")
+		if resultStr != resultExpected {
+			t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", resultStr, resultExpected)
+		}
+	}
+
+	// counters
+	f("foo:1|c\nfoo:2|c\nfoo:1|c|@0.5\nbar:3|c|#b:2,a:1\nbar:4|c|#a:1,b:2", `bar{a="1",b="2"} 7
+foo{} 5`)
+
+	// negative counter increments are ignored
+	f("foo:1|c\nfoo:-2|c\nfoo:3|c", `foo{} 4`)
+
+	// gauges
+	f("foo:10|g\nfoo:+2|g\nfoo:-5|g\nbar:-1|g", `bar{} -1
+foo{} 7`)
+
+	// timers
+	f("foo:1|ms\nfoo:2:3|h\nfoo:4|d|@0.5", `foo_count{} 5
+foo_sum{} 14
+foo{quantile="0.5"} 3
+foo{quantile="1"} 4`)
+
+	// sets
+	f("users:a|s\nusers:b|s\nusers:a|s\nusers:1|s", `users{} 3`)
+}
+
+func TestAggregatorCumulativeCounters(t *testing.T) {
+	var mu sync.Mutex
+	var result []string
+	pushFunc := func(tss []prompbmarshal.TimeSeries) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, ts := range tss {
+			result = append(result, formatTimeSeries(ts))
+		}
+	}
+	a, err := newAggregator(time.Hour, "0.5", pushFunc)
+	if err != nil {
+		t.Fatalf("cannot create aggregator: %s", err)
+	}
+
+	f := func(data string, valueExpected float64) {
+		t.Helper()
+		var rows statsd.Rows
+		rows.Unmarshal(data)
+		a.Push(rows.Rows)
+
+		a.statesLock.Lock()
+		defer a.statesLock.Unlock()
+		if len(a.counterStates) != 1 {
+			t.Fatalf("unexpected number of counter states; got %d; want 1", len(a.counterStates))
+		}
+		for _, ss := range a.counterStates {
+			if ss.value != valueExpected {
+				t.Fatalf("unexpected counter value; got %v; want %v", ss.value, valueExpected)
+			}
+		}
+	}
+
+	// Increments are accumulated across pushes in the same way as statsd_exporter does.
+	f("foo:1|c\nfoo:2|c|@0.5", 5)
+	f("foo:3|c", 8)
+	f("foo:-1|c", 8)
+
+	a.MustStop()
+	resultExpected := "foo{} 8"
+	if resultStr := strings.Join(result, "\n"); resultStr != resultExpected {
+		t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", resultStr, resultExpected)
+	}
+}
+
+func TestNewAggregatorFailure(t *testing.T) {
+	f := func(interval time.Duration, quantiles string) {
+		t.Helper()
+		a, err := newAggregator(interval, quantiles, nil)
+		if err == nil {
+			a.MustStop()
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	f(0, "0.5")
+	f(1500*time.Millisecond, "0.5")
+	f(time.Second, "foo")
+	f(time.Second, "2")
+}
+
+func formatTimeSeries(ts prompbmarshal.TimeSeries) string {
+	var name string
+	var labels []string
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			name = label.Value
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%s=%q", label.Name, label.Value))
+	}
+	sort.Strings(labels)
+	return fmt.Sprintf("%s{%s} %g", name, strings.Join(labels, ","), ts.Samples[0].Value)
+}
@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/protoparserutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/statsd"
+)
+
+func TestParse(t *testing.T) {
+	protoparserutil.StartUnmarshalWorkers()
+	defer protoparserutil.StopUnmarshalWorkers()
+
+	f := func(data string, metricsExpected []string) {
+		t.Helper()
+
+		var mu sync.Mutex
+		var metrics []string
+		err := Parse(strings.NewReader(data), func(rows []statsd.Row) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, r := range rows {
+				metrics = append(metrics, r.Type.String()+":"+r.Metric)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		sort.Strings(metrics)
+		if !reflect.DeepEqual(metrics, metricsExpected) {
+			t.Fatalf("unexpected metrics\ngot\n%q\nwant\n%q", metrics, metricsExpected)
+		}
+	}
+
+	f("", nil)
+	f("foo:1|c\nbar:2|g\nbaz:3:4|ms\nqux:a|s\ninvalid", []string{
+		"counter:foo",
+		"gauge:bar",
+		"set:qux",
+		"timer:baz",
+		"timer:baz",
+	})
+}
@@ -0,0 +1,398 @@
+package querylint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+// Warning is a warning returned by Lint for MetricsQL query.
+type Warning struct {
+	// Check is the name of the check, which generated the warning.
+	Check string `json:"check"`
+
+	// Expr is the string representation of the subexpression, which triggered the warning.
+	Expr string `json:"expr"`
+
+	// Message is human-readable description of the warning.
+	Message string `json:"message"`
+}
+
+// String returns string representation for w.
+func (w *Warning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Check, w.Expr, w.Message)
+}
+
+// Options contains optional settings for Lint.
+type Options struct {
+	// ScrapeInterval is the expected interval between raw samples.
+	//
+	// Windows in square brackets shorter than 2*ScrapeInterval are reported if ScrapeInterval > 0.
+	ScrapeInterval time.Duration
+
+	// MetricTypes contains Prometheus metric types such as `counter` or `gauge` keyed by metric name.
+	//
+	// If the type for the metric is missing, then it is guessed from the metric name
+	// according to https://prometheus.io/docs/practices/naming/#metric-names
+	MetricTypes map[string]string
+}
+
+// Lint parses the given MetricsQL query and returns warnings for suspicious subexpressions in it.
+//
+// An error is returned if the query cannot be parsed.
+func Lint(query string, opts *Options) ([]Warning, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	e, err := metricsql.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	l := &linter{
+		opts: opts,
+	}
+	if metricsql.IsLikelyInvalid(e) {
+		l.addWarning("implicit_conversion", e, "the query contains implicit conversion of non-series selector to subquery, which returns unexpected results most of the time; "+
+			"see https://docs.victoriametrics.com/victoriametrics/metricsql/#implicit-query-conversions")
+	}
+	metricsql.VisitAll(e, func(expr metricsql.Expr) {
+		switch t := expr.(type) {
+		case *metricsql.MetricExpr:
+			l.checkMetricExpr(t)
+		case *metricsql.FuncExpr:
+			l.checkFuncExpr(t)
+		case *metricsql.BinaryOpExpr:
+			l.checkBinaryOpExpr(t)
+		}
+	})
+	return l.warnings, nil
+}
+
+type linter struct {
+	opts     *Options
+	warnings []Warning
+}
+
+func (l *linter) addWarning(check string, e metricsql.Expr, format string, args ...any) {
+	l.warnings = append(l.warnings, Warning{
+		Check:   check,
+		Expr:    string(e.AppendString(nil)),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) checkMetricExpr(me *metricsql.MetricExpr) {
+	if me.IsEmpty() {
+		return
+	}
+	for _, lfs := range me.LabelFilterss {
+		if isUnboundedSelector(lfs) {
+			l.addWarning("unbounded_selector", me, "the selector matches all the time series in the database; add filters on metric name or labels")
+			// There is no sense in checking regexp filters for unbounded selectors.
+			continue
+		}
+		for i := range lfs {
+			lf := &lfs[i]
+			if !lf.IsRegexp || lf.IsNegative || !hasLeadingWildcard(lf.Value) {
+				continue
+			}
+			labelName := lf.Label
+			if labelName == "" {
+				labelName = "__name__"
+			}
+			l.addWarning("regexp_defeats_index", me, "regexp filter %s=~%q starts with a wildcard, so it cannot use index lookups by value prefix and requires scanning all the values for %q label; "+
+				"consider using regexp with a literal prefix or a list of exact values such as \"foo|bar\"", labelName, lf.Value, labelName)
+		}
+	}
+}
+
+// isUnboundedSelector returns true if all the lfs match any series.
+func isUnboundedSelector(lfs []metricsql.LabelFilter) bool {
+	for i := range lfs {
+		if !isMatchAllFilter(&lfs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isMatchAllFilter(lf *metricsql.LabelFilter) bool {
+	if lf.IsNegative {
+		// {label!=""} or {label!~""} match all the series with non-empty label.
+		// This is true for all the series if the label is __name__.
+		return lf.Value == "" && isMetricNameLabel(lf.Label)
+	}
+	if !lf.IsRegexp {
+		return false
+	}
+	switch lf.Value {
+	case ".*":
+		return true
+	case ".+":
+		// {__name__=~".+"} matches all the series, since every series has a name.
+		return isMetricNameLabel(lf.Label)
+	default:
+		return false
+	}
+}
+
+func isMetricNameLabel(label string) bool {
+	return label == "" || label == "__name__"
+}
+
+// hasLeadingWildcard returns true if the regexp re starts with a wildcard followed by some other chars.
+//
+// Such regexps require scanning all the label values, since they have no literal prefix.
+// Regexps matching any value such as ".*" are skipped, since they are optimized away at query time.
+func hasLeadingWildcard(re string) bool {
+	re = strings.TrimPrefix(re, "(?i)")
+	if re == ".*" || re == ".+" {
+		return false
+	}
+	return strings.HasPrefix(re, ".*") || strings.HasPrefix(re, ".+")
+}
+
+// counterFuncs contains rollup functions, which expect counters as input.
+var counterFuncs = map[string]bool{
+	"increase":            true,
+	"increase_prometheus": true,
+	"increase_pure":       true,
+	"irate":               true,
+	"rate":                true,
+	"rate_prometheus":     true,
+	"resets":              true,
+}
+
+// windowFuncs contains rollup functions, which need at least two raw samples on the lookbehind window.
+var windowFuncs = map[string]bool{
+	"delta":               true,
+	"delta_prometheus":    true,
+	"deriv":               true,
+	"deriv_fast":          true,
+	"idelta":              true,
+	"increase":            true,
+	"increase_prometheus": true,
+	"increase_pure":       true,
+	"irate":               true,
+	"rate":                true,
+	"rate_prometheus":     true,
+}
+
+func (l *linter) checkFuncExpr(fe *metricsql.FuncExpr) {
+	name := strings.ToLower(fe.Name)
+	if !counterFuncs[name] && !windowFuncs[name] {
+		return
+	}
+	if len(fe.Args) == 0 {
+		return
+	}
+	var me *metricsql.MetricExpr
+	var window *metricsql.DurationExpr
+	switch t := fe.Args[0].(type) {
+	case *metricsql.MetricExpr:
+		me = t
+	case *metricsql.RollupExpr:
+		if t.ForSubquery() {
+			return
+		}
+		me, _ = t.Expr.(*metricsql.MetricExpr)
+		window = t.Window
+	}
+	if me == nil {
+		return
+	}
+	if counterFuncs[name] {
+		l.checkRateOverGauge(fe, me)
+	}
+	if windowFuncs[name] {
+		l.checkRollupWindow(fe, window)
+	}
+}
+
+func (l *linter) checkRateOverGauge(fe *metricsql.FuncExpr, me *metricsql.MetricExpr) {
+	metricName := getMetricName(me)
+	if metricName == "" {
+		return
+	}
+	if metricType, ok := l.opts.MetricTypes[metricName]; ok {
+		if metricType == "gauge" {
+			l.addWarning("rate_over_gauge", fe, "%s() must be applied to counters, while %q is a gauge according to its metadata; "+
+				"consider using deriv() or delta() instead", fe.Name, metricName)
+		}
+		return
+	}
+	if !hasCounterSuffix(metricName) {
+		l.addWarning("rate_over_gauge", fe, "%s() must be applied to counters, while %q doesn't look like a counter, since its name has no _total, _count, _sum or _bucket suffix; "+
+			"consider using deriv() or delta() for gauges", fe.Name, metricName)
+	}
+}
+
+func hasCounterSuffix(metricName string) bool {
+	for _, suffix := range []string{"_total", "_count", "_sum", "_bucket"} {
+		if strings.HasSuffix(metricName, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) checkRollupWindow(fe *metricsql.FuncExpr, window *metricsql.DurationExpr) {
+	scrapeInterval := l.opts.ScrapeInterval.Milliseconds()
+	if scrapeInterval <= 0 || window == nil {
+		return
+	}
+	// Windows relative to step such as [1i] cannot be verified without the step, so pass zero step.
+	d := window.Duration(0)
+	if d <= 0 {
+		return
+	}
+	if d < 2*scrapeInterval {
+		l.addWarning("short_rollup_window", fe, "the window %s is shorter than two scrape intervals (%s), so %s() may return gaps when there are less than two samples on the window; "+
+			"increase the window to at least %s", time.Duration(d)*time.Millisecond, l.opts.ScrapeInterval, fe.Name, 2*l.opts.ScrapeInterval)
+	}
+}
+
+func (l *linter) checkBinaryOpExpr(be *metricsql.BinaryOpExpr) {
+	op := strings.ToLower(be.Op)
+	if op == "or" || op == "unless" || op == "default" || op == "if" || op == "ifnot" {
+		// These operations return non-empty results even if there are no matching series on the other side.
+		return
+	}
+	if be.JoinModifier.Op != "" {
+		// group_left and group_right are used for non-trivial matching.
+		return
+	}
+	leftFilters := getEqualityFilters(be.Left)
+	rightFilters := getEqualityFilters(be.Right)
+	if leftFilters != nil && rightFilters != nil {
+		labels := make([]string, 0, len(leftFilters))
+		for label, leftValue := range leftFilters {
+			rightValue, ok := rightFilters[label]
+			if !ok || leftValue == rightValue || !isMatchingLabel(&be.GroupModifier, label) {
+				continue
+			}
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			l.addWarning("binary_op_label_mismatch", be, "the left side has %s=%q, while the right side has %s=%q, so the %q operation matches no series; "+
+				"consider adding ignoring(%s) modifier", label, leftFilters[label], label, rightFilters[label], be.Op, label)
+		}
+		return
+	}
+
+	leftLabels := getAggrByLabels(be.Left)
+	rightLabels := getAggrByLabels(be.Right)
+	if leftLabels == nil || rightLabels == nil || be.GroupModifier.Op != "" {
+		return
+	}
+	if !equalStringSets(leftLabels, rightLabels) {
+		l.addWarning("binary_op_label_mismatch", be, "the left side is grouped by (%s), while the right side is grouped by (%s), so the %q operation matches no series; "+
+			"consider using the same labels in by() or adding on() modifier", strings.Join(leftLabels, ", "), strings.Join(rightLabels, ", "), be.Op)
+	}
+}
+
+// isMatchingLabel returns true if the label is used for matching series with the given modifier.
+func isMatchingLabel(modifier *metricsql.ModifierExpr, label string) bool {
+	switch strings.ToLower(modifier.Op) {
+	case "on":
+		return containsString(modifier.Args, label)
+	case "ignoring":
+		return !containsString(modifier.Args, label)
+	default:
+		return true
+	}
+}
+
+// getEqualityFilters returns label filters in the form {label="value"} for series selected by e.
+//
+// nil is returned if e isn't a series selector optionally wrapped into rollup function,
+// which keeps the original labels.
+func getEqualityFilters(e metricsql.Expr) map[string]string {
+	me := getSeriesSelector(e)
+	if me == nil || len(me.LabelFilterss) != 1 {
+		return nil
+	}
+	m := make(map[string]string)
+	for _, lf := range me.LabelFilterss[0] {
+		if lf.IsNegative || lf.IsRegexp || isMetricNameLabel(lf.Label) {
+			// Metric names are dropped before matching series in binary operations.
+			continue
+		}
+		m[lf.Label] = lf.Value
+	}
+	return m
+}
+
+func getSeriesSelector(e metricsql.Expr) *metricsql.MetricExpr {
+	switch t := e.(type) {
+	case *metricsql.MetricExpr:
+		return t
+	case *metricsql.RollupExpr:
+		if t.ForSubquery() {
+			return nil
+		}
+		return getSeriesSelector(t.Expr)
+	case *metricsql.FuncExpr:
+		if !metricsql.IsRollupFunc(t.Name) || len(t.Args) != 1 {
+			return nil
+		}
+		return getSeriesSelector(t.Args[0])
+	default:
+		return nil
+	}
+}
+
+// getAggrByLabels returns sorted labels from `by (...)` modifier for aggregate function e.
+//
+// nil is returned if e isn't an aggregate function with `by (...)` modifier.
+func getAggrByLabels(e metricsql.Expr) []string {
+	ae, ok := e.(*metricsql.AggrFuncExpr)
+	if !ok || strings.ToLower(ae.Modifier.Op) != "by" {
+		return nil
+	}
+	switch strings.ToLower(ae.Name) {
+	case "topk", "bottomk", "limitk", "outliersk", "outliers_iqr", "outliers_mad":
+		// These functions return the original series instead of the aggregated ones.
+		return nil
+	}
+	labels := append([]string{}, ae.Modifier.Args...)
+	sort.Strings(labels)
+	return labels
+}
+
+func equalStringSets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(a []string, s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func getMetricName(me *metricsql.MetricExpr) string {
+	if len(me.LabelFilterss) != 1 {
+		return ""
+	}
+	for _, lf := range me.LabelFilterss[0] {
+		if isMetricNameLabel(lf.Label) && !lf.IsNegative && !lf.IsRegexp {
+			return lf.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package querylint
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLintSuccess(t *testing.T) {
+	f := func(query string, opts *Options, checksExpected []string) {
+		t.Helper()
+
+		warnings, err := Lint(query, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var checks []string
+		for _, w := range warnings {
+			checks = append(checks, w.Check)
+		}
+		if !reflect.DeepEqual(checks, checksExpected) {
+			t.Fatalf("unexpected checks for %q\ngot\n%q\nwant\n%q\nwarnings: %v", query, checks, checksExpected, warnings)
+		}
+	}
+
+	// no warnings
+	f(`rate(http_requests_total[5m])`, nil, nil)
+	f(`sum(rate(http_requests_total{job="api"}[5m])) by (instance)`, nil, nil)
+	f(`foo{job="a"} / bar{job="a"}`, nil, nil)
+	f(`foo{job="a"} / ignoring(job) bar{job="b"}`, nil, nil)
+	f(`foo{job="a"} / on(instance) bar{job="b"}`, nil, nil)
+	f(`foo{job="a"} or bar{job="b"}`, nil, nil)
+	f(`foo{job="a"} * on() group_left bar{job="b"}`, nil, nil)
+	f(`sum(foo) by (job) / sum(bar) by (job)`, nil, nil)
+	f(`sum(foo) by (job, instance) / sum(bar) by (instance, job)`, nil, nil)
+	f(`foo{instance=~"host-.*"}`, nil, nil)
+	f(`foo{instance=~".*"}`, nil, nil)
+	f(`{__name__=~"foo.*"}`, nil, nil)
+	f(`deriv(temperature[1m])`, &Options{ScrapeInterval: 15 * time.Second}, nil)
+	f(`rate(http_requests_total[1i])`, &Options{ScrapeInterval: time.Minute}, nil)
+	f(`rate(http_requests_total[30s])`, nil, nil)
+	f(`rate(process_cpu_seconds[5m])`, &Options{MetricTypes: map[string]string{"process_cpu_seconds": "counter"}}, nil)
+
+	// rate over gauge
+	f(`rate(temperature_celsius[5m])`, nil, []string{"rate_over_gauge"})
+	f(`increase(memory_usage_bytes)`, nil, []string{"rate_over_gauge"})
+	f(`rate(foo_total[5m])`, &Options{MetricTypes: map[string]string{"foo_total": "gauge"}}, []string{"rate_over_gauge"})
+
+	// short rollup window
+	f(`rate(http_requests_total[30s])`, &Options{ScrapeInterval: 30 * time.Second}, []string{"short_rollup_window"})
+	f(`deriv(temperature[1m])`, &Options{ScrapeInterval: time.Minute}, []string{"short_rollup_window"})
+
+	// label mismatch in binary operations
+	f(`foo{job="a"} / bar{job="b"}`, nil, []string{"binary_op_label_mismatch"})
+	f(`rate(foo_total{job="a"}[5m]) > bool rate(bar_total{job="b"}[5m])`, nil, []string{"binary_op_label_mismatch"})
+	f(`foo{job="a"} / on(job) bar{job="b"}`, nil, []string{"binary_op_label_mismatch"})
+	f(`foo{job="a"} and bar{job="b"}`, nil, []string{"binary_op_label_mismatch"})
+	f(`sum(foo) by (job) / sum(bar) by (instance)`, nil, []string{"binary_op_label_mismatch"})
+
+	// regexp filters without literal prefix
+	f(`foo{instance=~".*host"}`, nil, []string{"regexp_defeats_index"})
+	f(`{__name__=~"(?i).+_errors"}`, nil, []string{"regexp_defeats_index"})
+
+	// unbounded selectors
+	f(`{__name__=~".*"}`, nil, []string{"unbounded_selector"})
+	f(`count({__name__!=""})`, nil, []string{"unbounded_selector"})
+	f(`{__name__=~".+", job=~".*"}`, nil, []string{"unbounded_selector"})
+
+	// implicit conversion
+	f(`rate(sum(foo_total))`, nil, []string{"implicit_conversion"})
+
+	// multiple warnings
+	f(`rate(temperature{job="a"}[10s]) / rate(pressure{job="b"}[10s])`, &Options{ScrapeInterval: 10 * time.Second},
+		[]string{"rate_over_gauge", "short_rollup_window", "rate_over_gauge", "short_rollup_window", "binary_op_label_mismatch"})
+}
+
+func TestLintFailure(t *testing.T) {
+	f := func(query string) {
+		t.Helper()
+
+		warnings, err := Lint(query, nil)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q", query)
+		}
+		if warnings != nil {
+			t.Fatalf("expecting nil warnings; got %v", warnings)
+		}
+	}
+
+	f(``)
+	f(`foo{`)
+	f(`unknown_func(foo)`)
+}
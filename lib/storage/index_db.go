@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -68,6 +69,9 @@ const (
 
 	// Prefix for (Date,MetricName)->TSID entries.
 	nsPrefixDateMetricNameToTSID = 7
+
+	// Prefix for (Hour,MetricID) entries for series created at the given hour.
+	nsPrefixHourToNewMetricID = 8
 )
 
 // indexDB represents an index db.
@@ -1484,6 +1488,13 @@ func (is *indexSearch) getTSDBStatus(qt *querytracer.Tracer, tfss []*TagFilters,
 		qt.Printf("no matching series for filter=%s", tfss)
 		return &TSDBStatus{}, nil
 	}
+	return is.getTSDBStatusForMetricIDs(filter, date, focusLabel, topN)
+}
+
+// getTSDBStatusForMetricIDs returns topN entries for tsdb status for the given filter, date and focusLabel.
+//
+// All the series registered at the given date are taken into account if filter is nil.
+func (is *indexSearch) getTSDBStatusForMetricIDs(filter *uint64set.Set, date uint64, focusLabel string, topN int) (*TSDBStatus, error) {
 	ts := &is.ts
 	kb := &is.kb
 	mp := &is.mp
@@ -1606,6 +1617,241 @@ func (is *indexSearch) getTSDBStatus(qt *querytracer.Tracer, tfss []*TagFilters,
 	return status, nil
 }
 
+// GetNewSeriesStatus returns topN entries for tsdb status for series matching the given tfss and focusLabel,
+// which were created on the given [minHour ... maxHour] hours.
+//
+// The status is collected from both the current and the previous indexdb, since series created
+// on the given hours may be spread among them after indexdb rotation.
+func (db *indexDB) GetNewSeriesStatus(qt *querytracer.Tracer, accountID, projectID uint32, tfss []*TagFilters, minHour, maxHour uint64, focusLabel string, topN, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
+	qtChild := qt.NewChild("search for series created on hours [%s..%s]", hourToString(minHour), hourToString(maxHour))
+	is := db.getIndexSearch(accountID, projectID, deadline)
+	metricIDs, err := is.getMetricIDsForNewSeries(tfss, minHour, maxHour, maxMetrics)
+	db.putIndexSearch(is)
+	if err != nil {
+		qtChild.Done()
+		return nil, err
+	}
+	extMetricIDs := &uint64set.Set{}
+	db.doExtDB(func(extDB *indexDB) {
+		is := extDB.getIndexSearch(accountID, projectID, deadline)
+		extMetricIDs, err = is.getMetricIDsForNewSeries(tfss, minHour, maxHour, maxMetrics)
+		extDB.putIndexSearch(is)
+	})
+	if err != nil {
+		qtChild.Done()
+		return nil, fmt.Errorf("error when searching for new series in extDB: %w", err)
+	}
+	qtChild.Donef("found %d new series in the current indexdb and %d new series in the previous indexdb", metricIDs.Len(), extMetricIDs.Len())
+	n := metricIDs.Len() + extMetricIDs.Len()
+	if n == 0 {
+		return &TSDBStatus{}, nil
+	}
+	if n > maxMetrics {
+		return nil, errTooManyNewSeriesCandidates(maxMetrics)
+	}
+
+	// Use the per-day index if all the hours belong to a single day, since it is much smaller than the global index.
+	date := globalIndexDate
+	if minHour/24 == maxHour/24 && !db.s.disablePerDayIndex {
+		date = minHour / 24
+	}
+
+	// Collect full stats from both indexdbs before selecting topN entries,
+	// since entries missing in topN for every indexdb may get into topN for the merged stats.
+	m := make(map[string]uint64)
+	if metricIDs.Len() > 0 {
+		qtChild = qt.NewChild("collect tsdb stats for new series in the current indexdb")
+		is = db.getIndexSearch(accountID, projectID, deadline)
+		err = is.updateSeriesCountByLabelValuePair(m, metricIDs, date)
+		db.putIndexSearch(is)
+		qtChild.Done()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if extMetricIDs.Len() > 0 {
+		db.doExtDB(func(extDB *indexDB) {
+			qtChild := qt.NewChild("collect tsdb stats for new series in the previous indexdb")
+			is := extDB.getIndexSearch(accountID, projectID, deadline)
+			err = is.updateSeriesCountByLabelValuePair(m, extMetricIDs, date)
+			extDB.putIndexSearch(is)
+			qtChild.Done()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error when obtaining new series status from extDB: %w", err)
+		}
+	}
+	return newTSDBStatusFromSeriesCountByLabelValuePair(m, focusLabel, topN), nil
+}
+
+// getMetricIDsForNewSeries returns metricIDs for series matching the given tfss, which were created on [minHour ... maxHour] hours.
+func (is *indexSearch) getMetricIDsForNewSeries(tfss []*TagFilters, minHour, maxHour uint64, maxMetrics int) (*uint64set.Set, error) {
+	ts := &is.ts
+	kb := &is.kb
+	metricIDs := &uint64set.Set{}
+	kb.B = is.marshalCommonPrefix(kb.B[:0], nsPrefixHourToNewMetricID)
+	prefix := append([]byte{}, kb.B...)
+	kb.B = encoding.MarshalUint64(kb.B, minHour)
+	loopsPaceLimiter := 0
+	ts.Seek(kb.B)
+	for ts.NextItem() {
+		if loopsPaceLimiter&paceLimiterFastIterationsMask == 0 {
+			if err := checkSearchDeadlineAndPace(is.deadline); err != nil {
+				return nil, err
+			}
+		}
+		loopsPaceLimiter++
+		item := ts.Item
+		if !bytes.HasPrefix(item, prefix) {
+			break
+		}
+		tail := item[len(prefix):]
+		if len(tail) != 16 {
+			return nil, fmt.Errorf("unexpected hour->metricID item len; got %d bytes; want %d bytes", len(tail), 16)
+		}
+		if hour := encoding.UnmarshalUint64(tail); hour > maxHour {
+			break
+		}
+		metricIDs.Add(encoding.UnmarshalUint64(tail[8:]))
+		if metricIDs.Len() > maxMetrics {
+			return nil, errTooManyNewSeriesCandidates(maxMetrics)
+		}
+	}
+	if err := ts.Error(); err != nil {
+		return nil, fmt.Errorf("error when searching for series created on hours [%s..%s]: %w", hourToString(minHour), hourToString(maxHour), err)
+	}
+	if metricIDs.Len() == 0 || len(tfss) == 0 {
+		return metricIDs, nil
+	}
+
+	// Narrow down the created series with tfss on the dates covering the given hours.
+	minDate, maxDate := minHour/24, maxHour/24
+	if is.db.s.disablePerDayIndex {
+		minDate, maxDate = globalIndexDate, globalIndexDate
+	}
+	matchingMetricIDs := &uint64set.Set{}
+	for date := minDate; date <= maxDate; date++ {
+		m, err := is.searchMetricIDsWithFiltersOnDate(nil, tfss, date, maxMetrics)
+		if err != nil {
+			return nil, err
+		}
+		matchingMetricIDs.UnionMayOwn(m)
+	}
+	metricIDs.Intersect(matchingMetricIDs)
+	return metricIDs, nil
+}
+
+// updateSeriesCountByLabelValuePair adds the number of series from the filter per every `label=value` pair registered at the given date to m.
+func (is *indexSearch) updateSeriesCountByLabelValuePair(m map[string]uint64, filter *uint64set.Set, date uint64) error {
+	ts := &is.ts
+	kb := &is.kb
+	mp := &is.mp
+	dmis := is.db.s.getDeletedMetricIDs()
+	var tmp []byte
+
+	loopsPaceLimiter := 0
+	nsPrefixExpected := byte(nsPrefixDateTagToMetricIDs)
+	if date == globalIndexDate {
+		nsPrefixExpected = nsPrefixTagToMetricIDs
+	}
+	kb.B = is.marshalCommonPrefixForDate(kb.B[:0], date)
+	prefix := append([]byte{}, kb.B...)
+	ts.Seek(prefix)
+	for ts.NextItem() {
+		if loopsPaceLimiter&paceLimiterFastIterationsMask == 0 {
+			if err := checkSearchDeadlineAndPace(is.deadline); err != nil {
+				return err
+			}
+		}
+		loopsPaceLimiter++
+		item := ts.Item
+		if !bytes.HasPrefix(item, prefix) {
+			break
+		}
+		if err := mp.Init(item, nsPrefixExpected); err != nil {
+			return err
+		}
+		matchingSeriesCount := mp.GetMatchingSeriesCount(filter, dmis)
+		if matchingSeriesCount == 0 {
+			// Skip rows without matching metricIDs.
+			continue
+		}
+		tmp = append(tmp[:0], mp.Tag.Key...)
+		labelName := tmp
+		if isArtificialTagKey(labelName) {
+			// Skip artificially created tag keys.
+			kb.B = append(kb.B[:0], prefix...)
+			if len(labelName) > 0 && labelName[0] == compositeTagKeyPrefix {
+				kb.B = append(kb.B, compositeTagKeyPrefix)
+			} else {
+				kb.B = marshalTagValue(kb.B, labelName)
+			}
+			kb.B[len(kb.B)-1]++
+			ts.Seek(kb.B)
+			continue
+		}
+		if len(labelName) == 0 {
+			tmp = append(tmp, "__name__"...)
+		}
+		tmp = append(tmp, '=')
+		tmp = append(tmp, mp.Tag.Value...)
+		m[string(tmp)] += uint64(matchingSeriesCount)
+	}
+	if err := ts.Error(); err != nil {
+		return fmt.Errorf("error when counting time series by label=value pairs: %w", err)
+	}
+	return nil
+}
+
+// newTSDBStatusFromSeriesCountByLabelValuePair returns topN entries for tsdb status built from m,
+// which contains the number of series per every `label=value` pair.
+func newTSDBStatusFromSeriesCountByLabelValuePair(m map[string]uint64, focusLabel string, topN int) *TSDBStatus {
+	seriesCountByLabelName := make(map[string]uint64)
+	labelValueCountByLabelName := make(map[string]uint64)
+	thSeriesCountByMetricName := newTopHeap(topN)
+	thSeriesCountByFocusLabelValue := newTopHeap(topN)
+	thSeriesCountByLabelValuePair := newTopHeap(topN)
+	var totalSeries, totalLabelValuePairs uint64
+	for labelValuePair, count := range m {
+		n := strings.IndexByte(labelValuePair, '=')
+		labelName, labelValue := labelValuePair[:n], labelValuePair[n+1:]
+		if labelName == "__name__" {
+			totalSeries += count
+			thSeriesCountByMetricName.push([]byte(labelValue), count)
+		}
+		if labelName == focusLabel {
+			thSeriesCountByFocusLabelValue.push([]byte(labelValue), count)
+		}
+		thSeriesCountByLabelValuePair.push([]byte(labelValuePair), count)
+		seriesCountByLabelName[labelName] += count
+		labelValueCountByLabelName[labelName]++
+		totalLabelValuePairs += count
+	}
+	thSeriesCountByLabelName := newTopHeap(topN)
+	for labelName, count := range seriesCountByLabelName {
+		thSeriesCountByLabelName.push([]byte(labelName), count)
+	}
+	thLabelValueCountByLabelName := newTopHeap(topN)
+	for labelName, count := range labelValueCountByLabelName {
+		thLabelValueCountByLabelName.push([]byte(labelName), count)
+	}
+	return &TSDBStatus{
+		TotalSeries:                  totalSeries,
+		TotalLabelValuePairs:         totalLabelValuePairs,
+		SeriesCountByMetricName:      thSeriesCountByMetricName.getSortedResult(),
+		SeriesCountByLabelName:       thSeriesCountByLabelName.getSortedResult(),
+		SeriesCountByFocusLabelValue: thSeriesCountByFocusLabelValue.getSortedResult(),
+		SeriesCountByLabelValuePair:  thSeriesCountByLabelValuePair.getSortedResult(),
+		LabelValueCountByLabelName:   thLabelValueCountByLabelName.getSortedResult(),
+	}
+}
+
+func errTooManyNewSeriesCandidates(maxMetrics int) error {
+	// Return an error instead of truncated results, since the truncated results may contain false new series.
+	return fmt.Errorf("the number of matching timeseries exceeds %d; "+
+		"either narrow down the search or increase -search.max* command-line flag values at vmselect", maxMetrics)
+}
+
 // TSDBStatus contains TSDB status data for /api/v1/status/tsdb.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#tsdb-stats
@@ -3022,6 +3268,18 @@ func (is *indexSearch) createPerDayIndexes(date uint64, tsid *TSID, mn *MetricNa
 	is.db.tb.AddItems(ii.Items)
 }
 
+// createNewSeriesHourIndex registers the series with the given tsid as created at the given hour.
+//
+// This index is used for detecting new series with hourly granularity.
+func (is *indexSearch) createNewSeriesHourIndex(hour uint64, tsid *TSID) {
+	kb := kbPool.Get()
+	kb.B = marshalCommonPrefix(kb.B[:0], nsPrefixHourToNewMetricID, tsid.AccountID, tsid.ProjectID)
+	kb.B = encoding.MarshalUint64(kb.B, hour)
+	kb.B = encoding.MarshalUint64(kb.B, tsid.MetricID)
+	is.db.tb.AddItems([][]byte{kb.B})
+	kbPool.Put(kb)
+}
+
 func (ii *indexItems) registerTagIndexes(prefix []byte, mn *MetricName, metricID uint64) {
 	// Add MetricGroup -> metricID entry.
 	ii.B = append(ii.B, prefix...)
@@ -2203,6 +2203,191 @@ func TestSearchTSIDWithTimeRange(t *testing.T) {
 	fs.MustRemoveAll(path)
 }
 
+func TestGetNewSeriesStatus(t *testing.T) {
+	const path = "TestGetNewSeriesStatus"
+	s := MustOpenStorage(path, OpenOptions{})
+
+	const accountID = 12345
+	const projectID = 85453
+	baseHour := uint64(time.Now().UnixMilli())/msecPerHour - 3
+
+	// Ingest samples for the previous hour and the base hour.
+	// The base hour contains 10 old series and 20 new series.
+	var mrs []MetricRow
+	addSeries := func(name, instance string, hour uint64) {
+		mn := MetricName{
+			AccountID:   accountID,
+			ProjectID:   projectID,
+			MetricGroup: []byte(name),
+		}
+		mn.AddTag("instance", instance)
+		mrs = append(mrs, MetricRow{
+			MetricNameRaw: mn.marshalRaw(nil),
+			Timestamp:     int64(hour*msecPerHour) + 1000,
+			Value:         1,
+		})
+	}
+	for i := 0; i < 10; i++ {
+		instance := fmt.Sprintf("old_%d", i)
+		addSeries("old_metric", instance, baseHour-1)
+		addSeries("old_metric", instance, baseHour)
+	}
+	for i := 0; i < 15; i++ {
+		addSeries("new_metric", fmt.Sprintf("new_%d", i), baseHour)
+	}
+	for i := 0; i < 5; i++ {
+		addSeries("old_metric", fmt.Sprintf("new_%d", i), baseHour)
+	}
+	s.AddRows(mrs, defaultPrecisionBits)
+	s.DebugFlush()
+
+	f := func(tfss []*TagFilters, minHour, maxHour uint64, totalSeriesExpected uint64, seriesCountByMetricNameExpected []TopHeapEntry) {
+		t.Helper()
+		tr := TimeRange{
+			MinTimestamp: int64(minHour * msecPerHour),
+			MaxTimestamp: int64((maxHour+1)*msecPerHour) - 1,
+		}
+		status, err := s.GetNewSeriesStatus(nil, accountID, projectID, tfss, tr, "instance", 5, 1e6, noDeadline)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if status.TotalSeries != totalSeriesExpected {
+			t.Fatalf("unexpected TotalSeries; got %d; want %d", status.TotalSeries, totalSeriesExpected)
+		}
+		if !reflect.DeepEqual(status.SeriesCountByMetricName, seriesCountByMetricNameExpected) {
+			t.Fatalf("unexpected SeriesCountByMetricName;\ngot\n%v\nwant\n%v", status.SeriesCountByMetricName, seriesCountByMetricNameExpected)
+		}
+	}
+
+	// New series at the base hour
+	f(nil, baseHour, baseHour, 20, []TopHeapEntry{
+		{
+			Name:  "new_metric",
+			Count: 15,
+		},
+		{
+			Name:  "old_metric",
+			Count: 5,
+		},
+	})
+
+	// New series on multiple hours
+	f(nil, baseHour-1, baseHour, 30, []TopHeapEntry{
+		{
+			Name:  "new_metric",
+			Count: 15,
+		},
+		{
+			Name:  "old_metric",
+			Count: 15,
+		},
+	})
+
+	// New series at the base hour matching the given filter
+	tfs := NewTagFilters(accountID, projectID)
+	if err := tfs.Add(nil, []byte("old_metric"), false, false); err != nil {
+		t.Fatalf("cannot add filter: %s", err)
+	}
+	f([]*TagFilters{tfs}, baseHour, baseHour, 5, []TopHeapEntry{
+		{
+			Name:  "old_metric",
+			Count: 5,
+		},
+	})
+
+	// New series at the previous hour
+	f(nil, baseHour-1, baseHour-1, 10, []TopHeapEntry{
+		{
+			Name:  "old_metric",
+			Count: 10,
+		},
+	})
+
+	// No new series at the next hour
+	f(nil, baseHour+1, baseHour+1, 0, nil)
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
+func TestGetNewSeriesStatusAfterIndexDBRotation(t *testing.T) {
+	const path = "TestGetNewSeriesStatusAfterIndexDBRotation"
+	s := MustOpenStorage(path, OpenOptions{})
+
+	const accountID = 12345
+	const projectID = 85453
+	hour := uint64(time.Now().UnixMilli())/msecPerHour - 1
+
+	addSeries := func(name string, ids []int) {
+		var mrs []MetricRow
+		for _, id := range ids {
+			mn := MetricName{
+				AccountID:   accountID,
+				ProjectID:   projectID,
+				MetricGroup: []byte(name),
+			}
+			mn.AddTag("instance", fmt.Sprintf("instance_%d", id))
+			mrs = append(mrs, MetricRow{
+				MetricNameRaw: mn.marshalRaw(nil),
+				Timestamp:     int64(hour*msecPerHour) + 1000,
+				Value:         1,
+			})
+		}
+		s.AddRows(mrs, defaultPrecisionBits)
+	}
+
+	// Create series in the indexdb, which becomes the previous indexdb after the rotation.
+	addSeries("foo", []int{0, 1, 2})
+	addSeries("bar", []int{0, 1})
+	s.DebugFlush()
+	s.mustRotateIndexDB(time.Now())
+
+	// Re-register the foo series and create new series in the current indexdb.
+	// The re-registered series must be counted only once.
+	addSeries("foo", []int{0, 1, 2})
+	addSeries("bar", []int{2, 3})
+	addSeries("baz", []int{0})
+	s.DebugFlush()
+
+	tr := TimeRange{
+		MinTimestamp: int64(hour * msecPerHour),
+		MaxTimestamp: int64((hour+1)*msecPerHour) - 1,
+	}
+	status, err := s.GetNewSeriesStatus(nil, accountID, projectID, nil, tr, "instance", 1, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if status.TotalSeries != 8 {
+		t.Fatalf("unexpected TotalSeries; got %d; want %d", status.TotalSeries, 8)
+	}
+	if status.TotalLabelValuePairs != 16 {
+		t.Fatalf("unexpected TotalLabelValuePairs; got %d; want %d", status.TotalLabelValuePairs, 16)
+	}
+
+	// bar isn't in top1 for any of indexdbs, while it is in top1 for the merged stats.
+	seriesCountByMetricNameExpected := []TopHeapEntry{
+		{
+			Name:  "bar",
+			Count: 4,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByMetricName, seriesCountByMetricNameExpected) {
+		t.Fatalf("unexpected SeriesCountByMetricName;\ngot\n%v\nwant\n%v", status.SeriesCountByMetricName, seriesCountByMetricNameExpected)
+	}
+	labelValueCountByLabelNameExpected := []TopHeapEntry{
+		{
+			Name:  "instance",
+			Count: 4,
+		},
+	}
+	if !reflect.DeepEqual(status.LabelValueCountByLabelName, labelValueCountByLabelNameExpected) {
+		t.Fatalf("unexpected LabelValueCountByLabelName;\ngot\n%v\nwant\n%v", status.LabelValueCountByLabelName, labelValueCountByLabelNameExpected)
+	}
+
+	s.MustClose()
+	fs.MustRemoveAll(path)
+}
+
 func toTFPointers(tfs []tagFilter) []*tagFilter {
 	tfps := make([]*tagFilter, len(tfs))
 	for i := range tfs {
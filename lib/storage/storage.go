@@ -1812,6 +1812,20 @@ func (s *Storage) GetTSDBStatus(qt *querytracer.Tracer, accountID, projectID uin
 	return res, nil
 }
 
+// GetNewSeriesStatus returns TSDB status data for series, which were created on hours covered by tr.
+//
+// Series are considered created at the hour of the first sample, which has been ingested for them.
+func (s *Storage) GetNewSeriesStatus(qt *querytracer.Tracer, accountID, projectID uint32, tfss []*TagFilters, tr TimeRange, focusLabel string, topN, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
+	idb, putIndexDB := s.getCurrIndexDB()
+	defer putIndexDB()
+	minHour := uint64(tr.MinTimestamp) / msecPerHour
+	maxHour := uint64(tr.MaxTimestamp) / msecPerHour
+	if minHour > maxHour {
+		return &TSDBStatus{}, nil
+	}
+	return idb.GetNewSeriesStatus(qt, accountID, projectID, tfss, minHour, maxHour, focusLabel, topN, maxMetrics, deadline)
+}
+
 // MetricRow is a metric to insert into storage.
 type MetricRow struct {
 	// MetricNameRaw contains raw metric name, which must be decoded
@@ -2115,6 +2129,7 @@ func (s *Storage) RegisterMetricNames(qt *querytracer.Tracer, mrs []MetricRow) {
 		// Schedule creating TSID indexes instead of creating them synchronously.
 		// This should keep stable the ingestion rate when new time series are ingested.
 		createAllIndexesForMetricName(is, mn, &genTSID.TSID, date)
+		is.createNewSeriesHourIndex(uint64(mr.Timestamp)/msecPerHour, &genTSID.TSID)
 		genTSID.generation = generation
 		s.putSeriesToCache(mr.MetricNameRaw, &genTSID, date)
 		newSeriesCount++
@@ -2314,6 +2329,7 @@ func (s *Storage) add(rows []rawRow, dstMrs []*MetricRow, mrs []MetricRow, preci
 		}
 
 		createAllIndexesForMetricName(is, mn, &genTSID.TSID, date)
+		is.createNewSeriesHourIndex(hour, &genTSID.TSID)
 		genTSID.generation = generation
 		s.putSeriesToCache(mr.MetricNameRaw, &genTSID, date)
 		newSeriesCount++
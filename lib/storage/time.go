@@ -14,6 +14,11 @@ func dateToString(date uint64) string {
 	return t.Format("2006-01-02")
 }
 
+func hourToString(hour uint64) string {
+	t := time.Unix(int64(hour*3600), 0).UTC()
+	return t.Format("2006-01-02T15")
+}
+
 // timestampToTime returns time representation of the given timestamp.
 //
 // The returned time is in UTC timezone.
@@ -0,0 +1,238 @@
+package streamaggr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+)
+
+// ConvertCarbonAggregationRules converts carbon-aggregator rules from data into stream aggregation configs.
+//
+// Every non-empty line in data, which doesn't start with `#`, must contain a rule in the following format:
+//
+//	output_template (frequency) = method input_pattern
+//
+// See https://graphite.readthedocs.io/en/latest/config-carbon.html#aggregation-rules-conf
+//
+// Every rule is converted into a config, which aggregates input series matching input_pattern
+// into series with the name generated from output_template per every frequency seconds.
+func ConvertCarbonAggregationRules(data []byte) ([]*Config, error) {
+	var cfgs []*Config
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cfg, err := convertCarbonAggregationRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse carbon aggregation rule at line %d: %w", i+1, err)
+		}
+		cfg.Name = fmt.Sprintf("carbon_aggregation_rule_line_%d", i+1)
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs, nil
+}
+
+var carbonAggregationRuleRegex = regexp.MustCompile(`^(\S+)\s+\((\d+)\)\s*=\s*(\S+)\s+(\S+)$`)
+
+func convertCarbonAggregationRule(line string) (*Config, error) {
+	m := carbonAggregationRuleRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected rule format %q; want `output_template (frequency) = method input_pattern`", line)
+	}
+	outputTemplate, frequency, method, inputPattern := m[1], m[2], m[3], m[4]
+
+	interval, err := strconv.Atoi(frequency)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("frequency must be a positive number of seconds; got %q", frequency)
+	}
+
+	output, isQuantile, err := getCarbonAggregationOutput(method)
+	if err != nil {
+		return nil, err
+	}
+
+	regex, fields, err := carbonInputPatternToRegex(inputPattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse input pattern %q: %w", inputPattern, err)
+	}
+	replacement, err := carbonOutputTemplateToReplacement(outputTemplate, fields)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse output template %q: %w", outputTemplate, err)
+	}
+
+	var match promrelabel.IfExpression
+	if err := match.Parse(fmt.Sprintf("{__name__=~%s}", strconv.Quote(regex))); err != nil {
+		return nil, fmt.Errorf("cannot create match for input pattern %q: %w", inputPattern, err)
+	}
+	intervalStr := fmt.Sprintf("%ds", interval)
+	cfg := &Config{
+		Match:    &match,
+		Interval: intervalStr,
+		Outputs:  []string{output},
+		By:       []string{"__name__"},
+		InputRelabelConfigs: []promrelabel.RelabelConfig{
+			{
+				SourceLabels: []string{"__name__"},
+				Regex: &promrelabel.MultiLineRegex{
+					S: regex,
+				},
+				TargetLabel: "__name__",
+				Replacement: &replacement,
+			},
+		},
+	}
+	if isQuantile {
+		// keep_metric_names cannot be used with quantiles output, so remove the suffix and the quantile label with output relabeling.
+		nameReplacement := "$1"
+		cfg.OutputRelabelConfigs = []promrelabel.RelabelConfig{
+			{
+				SourceLabels: []string{"__name__"},
+				Regex: &promrelabel.MultiLineRegex{
+					S: "(.+):" + intervalStr + "_quantiles",
+				},
+				TargetLabel: "__name__",
+				Replacement: &nameReplacement,
+			},
+			{
+				Action: "labeldrop",
+				Regex: &promrelabel.MultiLineRegex{
+					S: "quantile",
+				},
+			},
+		}
+	} else {
+		keepMetricNames := true
+		cfg.KeepMetricNames = &keepMetricNames
+	}
+	return cfg, nil
+}
+
+var carbonPercentileRegex = regexp.MustCompile(`^p(\d+)$`)
+
+// getCarbonAggregationOutput returns stream aggregation output for the given carbon aggregation method.
+//
+// It returns true if the returned output is quantiles.
+func getCarbonAggregationOutput(method string) (string, bool, error) {
+	switch method {
+	case "sum":
+		return "sum_samples", false, nil
+	case "avg":
+		return "avg", false, nil
+	case "min":
+		return "min", false, nil
+	case "max":
+		return "max", false, nil
+	case "count":
+		return "count_samples", false, nil
+	}
+	if m := carbonPercentileRegex.FindStringSubmatch(method); m != nil {
+		// carbon uses p50 for 0.5, p99 for 0.99 and p999 for 0.999.
+		return fmt.Sprintf("quantiles(0.%s)", m[1]), true, nil
+	}
+	return "", false, fmt.Errorf("unsupported aggregation method %q; supported methods: sum, avg, min, max, count, p50, p75, p80, p90, p95, p99, p999", method)
+}
+
+// carbonInputPatternToRegex converts carbon input pattern to regex in the same way as carbon does.
+//
+// It returns the names of fields captured by the regex.
+func carbonInputPatternToRegex(pattern string) (string, []string, error) {
+	var parts []string
+	var fields []string
+	for _, part := range strings.Split(pattern, ".") {
+		if part == "" {
+			return "", nil, fmt.Errorf("empty path segment")
+		}
+		var regexPart string
+		if n := strings.Index(part, "<<"); n >= 0 {
+			m := strings.Index(part[n:], ">>")
+			if m < 0 {
+				return "", nil, fmt.Errorf("missing `>>` in %q", part)
+			}
+			field := part[n+2 : n+m]
+			if err := checkCarbonFieldName(field); err != nil {
+				return "", nil, err
+			}
+			fields = append(fields, field)
+			regexPart = carbonLiteralToRegex(part[:n]) + "(?P<" + field + ">.+?)" + carbonLiteralToRegex(part[n+m+2:])
+		} else if n := strings.IndexByte(part, '<'); n >= 0 && strings.IndexByte(part[n:], '>') > 0 {
+			m := strings.IndexByte(part[n:], '>')
+			field := part[n+1 : n+m]
+			if err := checkCarbonFieldName(field); err != nil {
+				return "", nil, err
+			}
+			fields = append(fields, field)
+			regexPart = carbonLiteralToRegex(part[:n]) + "(?P<" + field + ">[^.]+?)" + carbonLiteralToRegex(part[n+m+1:])
+		} else if part == "*" {
+			regexPart = "[^.]+"
+		} else {
+			regexPart = carbonLiteralToRegex(part)
+		}
+		parts = append(parts, regexPart)
+	}
+	regex := strings.Join(parts, `\.`)
+	if _, err := regexp.Compile(regex); err != nil {
+		return "", nil, err
+	}
+	return regex, fields, nil
+}
+
+func carbonLiteralToRegex(s string) string {
+	a := strings.Split(s, "*")
+	for i := range a {
+		a[i] = regexp.QuoteMeta(a[i])
+	}
+	return strings.Join(a, "[^.]*")
+}
+
+var carbonFieldNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func checkCarbonFieldName(field string) error {
+	if !carbonFieldNameRegex.MatchString(field) {
+		return fmt.Errorf("invalid field name %q", field)
+	}
+	return nil
+}
+
+// carbonOutputTemplateToReplacement converts carbon output template into relabeling replacement.
+func carbonOutputTemplateToReplacement(template string, fields []string) (string, error) {
+	var sb strings.Builder
+	s := template
+	for len(s) > 0 {
+		n := strings.IndexByte(s, '<')
+		if n < 0 {
+			sb.WriteString(s)
+			break
+		}
+		sb.WriteString(s[:n])
+		s = s[n:]
+		isDouble := strings.HasPrefix(s, "<<")
+		prefixLen, suffix := 1, ">"
+		if isDouble {
+			prefixLen, suffix = 2, ">>"
+		}
+		m := strings.Index(s, suffix)
+		if m < 0 {
+			return "", fmt.Errorf("missing %q in %q", suffix, s)
+		}
+		field := s[prefixLen:m]
+		if !containsString(fields, field) {
+			return "", fmt.Errorf("field %q is missing in the input pattern", field)
+		}
+		sb.WriteString("${" + field + "}")
+		s = s[m+len(suffix):]
+	}
+	return sb.String(), nil
+}
+
+func containsString(a []string, s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package streamaggr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+func TestConvertCarbonAggregationRulesFailure(t *testing.T) {
+	f := func(rules string) {
+		t.Helper()
+
+		cfgs, err := ConvertCarbonAggregationRules([]byte(rules))
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if cfgs != nil {
+			t.Fatalf("expecting nil cfgs")
+		}
+	}
+
+	// invalid format
+	f(`foo.bar = sum foo.*`)
+	f(`foo.bar (60) sum foo.*`)
+
+	// zero frequency
+	f(`foo.bar (0) = sum foo.*`)
+
+	// unsupported method
+	f(`foo.bar (60) = median foo.*`)
+
+	// missing field in the input pattern
+	f(`foo.<env>.bar (60) = sum foo.*.bar`)
+
+	// unclosed field
+	f(`foo.<<env (60) = sum foo.<<env.bar`)
+	f(`foo.<<env>> (60) = sum foo.<<env.bar`)
+
+	// invalid field name
+	f(`foo.<a-b> (60) = sum foo.<a-b>.bar`)
+
+	// empty path segment
+	f(`foo (60) = sum foo..bar`)
+}
+
+func TestConvertCarbonAggregationRulesSuccess(t *testing.T) {
+	f := func(rules, configExpected string) {
+		t.Helper()
+
+		cfgs, err := ConvertCarbonAggregationRules([]byte(rules))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		data, err := yaml.Marshal(cfgs)
+		if err != nil {
+			t.Fatalf("cannot marshal configs: %s", err)
+		}
+		config := string(data)
+		if config != configExpected {
+			t.Fatalf("unexpected config\ngot\n%s\nwant\n%s", config, configExpected)
+		}
+
+		// Verify the generated config can be loaded
+		pushFunc := func(_ []prompbmarshal.TimeSeries) {}
+		a, err := LoadFromData(data, pushFunc, nil, "carbon")
+		if err != nil {
+			t.Fatalf("cannot load the generated config: %s", err)
+		}
+		a.MustStop()
+	}
+
+	// empty rules
+	f(``, "[]\n")
+	f(`
+# comment
+
+`, "[]\n")
+
+	// sum
+	f(`<env>.applications.<app>.all.requests (60) = sum <env>.applications.<app>.*.requests`, `- name: carbon_aggregation_rule_line_1
+  match: '{__name__=~"(?P<env>[^.]+?)\\.applications\\.(?P<app>[^.]+?)\\.[^.]+\\.requests"}'
+  interval: 60s
+  outputs:
+  - sum_samples
+  keep_metric_names: true
+  by:
+  - __name__
+  input_relabel_configs:
+  - source_labels: [__name__]
+    target_label: __name__
+    regex: (?P<env>[^.]+?)\.applications\.(?P<app>[^.]+?)\.[^.]+\.requests
+    replacement: ${env}.applications.${app}.all.requests
+`)
+
+	// multiple rules with double-bracketed field and percentile
+	f(`
+# comment
+foo.<<rest>>.avg (10) = avg foo.bar-*.<<rest>>
+foo.latency.p99 (30) = p99 foo.*.latency
+`, `- name: carbon_aggregation_rule_line_3
+  match: '{__name__=~"foo\\.bar-[^.]*\\.(?P<rest>.+?)"}'
+  interval: 10s
+  outputs:
+  - avg
+  keep_metric_names: true
+  by:
+  - __name__
+  input_relabel_configs:
+  - source_labels: [__name__]
+    target_label: __name__
+    regex: foo\.bar-[^.]*\.(?P<rest>.+?)
+    replacement: foo.${rest}.avg
+- name: carbon_aggregation_rule_line_4
+  match: '{__name__=~"foo\\.[^.]+\\.latency"}'
+  interval: 30s
+  outputs:
+  - quantiles(0.99)
+  by:
+  - __name__
+  input_relabel_configs:
+  - source_labels: [__name__]
+    target_label: __name__
+    regex: foo\.[^.]+\.latency
+    replacement: foo.latency.p99
+  output_relabel_configs:
+  - source_labels: [__name__]
+    target_label: __name__
+    regex: (.+):30s_quantiles
+    replacement: $1
+  - action: labeldrop
+    regex: quantile
+`)
+}
+
+func TestConvertCarbonAggregationRulesAggregate(t *testing.T) {
+	cfgs, err := ConvertCarbonAggregationRules([]byte(`
+<env>.requests.total (60) = sum <env>.requests.*
+<env>.latency.p50 (60) = p50 <env>.latency.*
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, err := yaml.Marshal(cfgs)
+	if err != nil {
+		t.Fatalf("cannot marshal configs: %s", err)
+	}
+
+	var mu sync.Mutex
+	var result []string
+	pushFunc := func(tss []prompbmarshal.TimeSeries) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, ts := range tss {
+			labels := make([]string, 0, len(ts.Labels))
+			for _, label := range ts.Labels {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.Name, label.Value))
+			}
+			result = append(result, fmt.Sprintf("{%s} %v", strings.Join(labels, ","), ts.Samples[0].Value))
+		}
+	}
+	opts := &Options{
+		FlushOnShutdown: true,
+	}
+	a, err := LoadFromData(data, pushFunc, opts, "carbon")
+	if err != nil {
+		t.Fatalf("cannot load the generated config: %s", err)
+	}
+
+	var tss []prompbmarshal.TimeSeries
+	timestamp := time.Now().UnixMilli()
+	for _, line := range strings.Split(`prod.requests.host1 1
+prod.requests.host2 2
+dev.requests.host1 5
+prod.latency.host1 10
+prod.latency.host2 20
+prod.latency.host3 30
+other.metric 100`, "\n") {
+		name, valueStr, _ := strings.Cut(line, " ")
+		value, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil {
+			t.Fatalf("cannot parse value: %s", err)
+		}
+		tss = append(tss, prompbmarshal.TimeSeries{
+			Labels: []prompbmarshal.Label{{
+				Name:  "__name__",
+				Value: name,
+			}},
+			Samples: []prompbmarshal.Sample{{
+				Value:     value,
+				Timestamp: timestamp,
+			}},
+		})
+	}
+	a.Push(tss, nil)
+	a.MustStop()
+
+	sort.Strings(result)
+	resultStr := strings.Join(result, "\n")
+	resultExpected := `{__name__="dev.requests.total"} 5
+{__name__="prod.latency.p50"} 20
+{__name__="prod.requests.total"} 3`
+	if resultStr != resultExpected {
+		t.Fatalf("unexpected result\ngot\n%s\nwant\n%s", resultStr, resultExpected)
+	}
+}
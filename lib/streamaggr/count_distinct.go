@@ -0,0 +1,60 @@
+package streamaggr
+
+import (
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutil"
+)
+
+// countDistinctAggrValue calculates output=count_distinct(label), e.g. the estimated number of distinct values
+// for the given label across input series.
+//
+// The number of distinct values is estimated with HyperLogLog, so the memory usage doesn't depend
+// on the number of distinct values.
+type countDistinctAggrValue struct {
+	hs hll
+}
+
+func (av *countDistinctAggrValue) pushSample(c aggrConfig, _ *pushSample, key string, _ int64) {
+	ac := c.(*countDistinctAggrConfig)
+
+	labels := promutil.GetLabels()
+	labels.Labels = decompressInputLabels(labels.Labels[:0], key, ac.useInputKey)
+	for _, label := range labels.Labels {
+		if label.Name == ac.label {
+			if label.Value != "" {
+				av.hs.add(xxhash.Sum64String(label.Value))
+			}
+			break
+		}
+	}
+	promutil.PutLabels(labels)
+}
+
+func (av *countDistinctAggrValue) flush(_ aggrConfig, ctx *flushCtx, key string, _ bool) {
+	n := av.hs.count()
+	if n > 0 {
+		ctx.appendSeries(key, "count_distinct", n)
+	}
+	av.hs.reset()
+}
+
+func (*countDistinctAggrValue) state() any {
+	return nil
+}
+
+func newCountDistinctAggrConfig(label string, useInputKey bool) aggrConfig {
+	return &countDistinctAggrConfig{
+		label:       label,
+		useInputKey: useInputKey,
+	}
+}
+
+type countDistinctAggrConfig struct {
+	label       string
+	useInputKey bool
+}
+
+func (*countDistinctAggrConfig) getValue(_ any) aggrValue {
+	return &countDistinctAggrValue{}
+}
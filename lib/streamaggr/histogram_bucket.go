@@ -1,6 +1,13 @@
 package streamaggr
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/metrics"
 )
 
@@ -32,6 +39,83 @@ func (av *histogramBucketAggrValue) state() any {
 	return av.shared
 }
 
+func (av *histogramBucketAggrValue) marshalState(dst []byte, _ *aggrOutputs) []byte {
+	var buckets []byte
+	n := uint64(0)
+	av.h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+		buckets = encoding.MarshalBytes(buckets, bytesutil.ToUnsafeBytes(vmrange))
+		buckets = encoding.MarshalVarUint64(buckets, count)
+		n++
+	})
+	dst = encoding.MarshalVarUint64(dst, n)
+	return append(dst, buckets...)
+}
+
+func (av *histogramBucketAggrValue) unmarshalState(src []byte, _ *aggrOutputs) error {
+	n, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return fmt.Errorf("cannot unmarshal the number of buckets")
+	}
+	src = src[nSize:]
+	for i := uint64(0); i < n; i++ {
+		vmrange, nSize := encoding.UnmarshalBytes(src)
+		if nSize <= 0 {
+			return fmt.Errorf("cannot unmarshal vmrange for bucket #%d", i)
+		}
+		src = src[nSize:]
+		count, nSize := encoding.UnmarshalVarUint64(src)
+		if nSize <= 0 {
+			return fmt.Errorf("cannot unmarshal count for bucket %q", vmrange)
+		}
+		src = src[nSize:]
+		v, err := getHistogramBucketValue(bytesutil.ToUnsafeString(vmrange))
+		if err != nil {
+			return err
+		}
+		addHistogramSamples(&av.h, v, count)
+	}
+	if len(src) > 0 {
+		return fmt.Errorf("unexpected non-empty tail left; len(tail)=%d", len(src))
+	}
+	return nil
+}
+
+// getHistogramBucketValue returns a value, which belongs to the bucket with the given vmrange.
+func getHistogramBucketValue(vmrange string) (float64, error) {
+	startStr, endStr, ok := strings.Cut(vmrange, "...")
+	if !ok {
+		return 0, fmt.Errorf("missing `...` in vmrange=%q", vmrange)
+	}
+	start, err := strconv.ParseFloat(startStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse start of vmrange=%q: %w", vmrange, err)
+	}
+	end, err := strconv.ParseFloat(endStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse end of vmrange=%q: %w", vmrange, err)
+	}
+	// The geometric mean is far enough from the bucket bounds, so it falls into the bucket regardless of rounding errors.
+	return math.Sqrt(start * end), nil
+}
+
+// addHistogramSamples adds count samples with the value v to h.
+func addHistogramSamples(h *metrics.Histogram, v float64, count uint64) {
+	// Use exponentiation by squaring in order to avoid calling h.Update count times.
+	var pow, tmp metrics.Histogram
+	pow.Update(v)
+	for count > 0 {
+		if count&1 != 0 {
+			h.Merge(&pow)
+		}
+		count >>= 1
+		if count > 0 {
+			tmp.Reset()
+			tmp.Merge(&pow)
+			pow.Merge(&tmp)
+		}
+	}
+}
+
 func newHistogramBucketAggrConfig(useSharedState bool) aggrConfig {
 	return &histogramBucketAggrConfig{
 		useSharedState: useSharedState,
@@ -0,0 +1,88 @@
+package streamaggr
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// hllPrecision is the number of bits used for selecting HyperLogLog register.
+	//
+	// It results in 4096 registers with ~1.6% standard error.
+	hllPrecision = 12
+
+	hllRegistersCount = 1 << hllPrecision
+
+	// hllMaxExactItems is the maximum number of items, which are counted exactly before switching to HyperLogLog registers.
+	hllMaxExactItems = 1024
+)
+
+// hll estimates the number of distinct items via HyperLogLog algorithm.
+//
+// It counts items exactly until their number exceeds hllMaxExactItems.
+//
+// See https://en.wikipedia.org/wiki/HyperLogLog
+type hll struct {
+	exact     map[uint64]struct{}
+	registers []uint8
+}
+
+// add adds an item with the given hash h to hs.
+func (hs *hll) add(h uint64) {
+	if hs.registers == nil {
+		if hs.exact == nil {
+			hs.exact = make(map[uint64]struct{})
+		}
+		hs.exact[h] = struct{}{}
+		if len(hs.exact) <= hllMaxExactItems {
+			return
+		}
+
+		// Switch to HyperLogLog registers.
+		hs.registers = make([]uint8, hllRegistersCount)
+		for h := range hs.exact {
+			hs.addRegister(h)
+		}
+		hs.exact = nil
+		return
+	}
+	hs.addRegister(h)
+}
+
+func (hs *hll) addRegister(h uint64) {
+	idx := h >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > hs.registers[idx] {
+		hs.registers[idx] = rank
+	}
+}
+
+// count returns an estimated number of distinct items added to hs.
+func (hs *hll) count() float64 {
+	if hs.registers == nil {
+		return float64(len(hs.exact))
+	}
+
+	m := float64(hllRegistersCount)
+	sum := 0.0
+	zeros := 0
+	for _, r := range hs.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return math.Round(estimate)
+}
+
+// reset resets hs to the initial state.
+func (hs *hll) reset() {
+	clear(hs.exact)
+	hs.registers = nil
+}
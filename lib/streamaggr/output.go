@@ -6,6 +6,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/metrics"
 )
 
@@ -32,6 +33,22 @@ func (ao *aggrOutputs) getInputOutputKey(key string) (string, string) {
 	return bytesutil.ToUnsafeString(inputKey), bytesutil.ToUnsafeString(outputKey)
 }
 
+// decompressInputLabels appends input labels for the given inputKey passed to aggrValue.pushSample to dst.
+//
+// inputKey contains both output and input labels if useInputKey is false. Only input labels are appended to dst in this case.
+func decompressInputLabels(dst []prompbmarshal.Label, inputKey string, useInputKey bool) []prompbmarshal.Label {
+	if useInputKey {
+		return decompressLabels(dst, inputKey)
+	}
+	src := bytesutil.ToUnsafeBytes(inputKey)
+	outputKeyLen, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		logger.Panicf("BUG: cannot unmarshal outputKeyLen from uvarint")
+	}
+	src = src[nSize:]
+	return decompressLabels(dst, bytesutil.ToUnsafeString(src[outputKeyLen:]))
+}
+
 func (ao *aggrOutputs) pushSamples(samples []pushSample, deleteDeadline int64, isGreen bool) {
 	var inputKey, outputKey string
 	var sample *pushSample
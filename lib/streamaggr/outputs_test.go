@@ -0,0 +1,208 @@
+package streamaggr
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
+)
+
+func TestAggregatorsSelectiveOutputs(t *testing.T) {
+	f := func(config, input, outputExpected string) {
+		t.Helper()
+
+		var tssOutput []prompbmarshal.TimeSeries
+		var tssOutputLock sync.Mutex
+		pushFunc := func(tss []prompbmarshal.TimeSeries) {
+			tssOutputLock.Lock()
+			tssOutput = appendClonedTimeseries(tssOutput, tss)
+			tssOutputLock.Unlock()
+		}
+		opts := &Options{
+			FlushOnShutdown: true,
+		}
+		a, err := LoadFromData([]byte(config), pushFunc, opts, "outputs")
+		if err != nil {
+			t.Fatalf("cannot initialize aggregators: %s", err)
+		}
+		a.Push(prometheus.MustParsePromMetrics(input, time.Now().UnixMilli()), nil)
+		a.MustStop()
+
+		output := timeSeriessToString(tssOutput)
+		if output != outputExpected {
+			t.Fatalf("unexpected output;\ngot\n%s\nwant\n%s", output, outputExpected)
+		}
+	}
+
+	input := `
+foo{job="a",instance="1"} 10
+foo{job="a",instance="2"} 30
+foo{job="a",instance="3"} 20
+foo{job="a",instance="1"} 40
+foo{job="b",instance="1"} 5
+foo{job="b",instance="2"} -1
+`
+
+	// topk
+	f(`
+- interval: 1m
+  by: [job]
+  outputs: ["topk(2)"]
+`, input, `foo:1m_by_job_topk{instance="1",job="a"} 40
+foo:1m_by_job_topk{instance="1",job="b"} 5
+foo:1m_by_job_topk{instance="2",job="a"} 30
+foo:1m_by_job_topk{instance="2",job="b"} -1
+`)
+
+	// bottomk
+	f(`
+- interval: 1m
+  by: [job]
+  outputs: ["bottomk(1)"]
+`, input, `foo:1m_by_job_bottomk{instance="1",job="a"} 10
+foo:1m_by_job_bottomk{instance="2",job="b"} -1
+`)
+
+	// topk with de-duplication
+	f(`
+- interval: 1m
+  dedup_interval: 30s
+  without: [instance]
+  outputs: ["topk(1)"]
+`, input, `foo:1m_without_instance_topk{instance="1",job="a"} 40
+foo:1m_without_instance_topk{instance="1",job="b"} 5
+`)
+
+	// count_distinct
+	f(`
+- interval: 1m
+  by: [job]
+  outputs: ["count_distinct(instance)"]
+`, input, `foo:1m_by_job_count_distinct{job="a"} 3
+foo:1m_by_job_count_distinct{job="b"} 2
+`)
+
+	// count_distinct with de-duplication
+	f(`
+- interval: 1m
+  dedup_interval: 30s
+  without: [instance]
+  outputs: ["count_distinct(instance)"]
+`, input, `foo:1m_without_instance_count_distinct{job="a"} 3
+foo:1m_without_instance_count_distinct{job="b"} 2
+`)
+
+	// sketch
+	f(`
+- interval: 1m
+  by: [job]
+  outputs: ["sketch(0, 0.5, 1)"]
+`, `
+foo{job="a"} 0
+foo{job="a"} 100
+foo{job="a"} 200
+foo{job="b"} -10
+`, `foo:1m_by_job_sketch{job="a",quantile="0"} 0
+foo:1m_by_job_sketch{job="a",quantile="0.5"} 100.4945677085636
+foo:1m_by_job_sketch{job="a",quantile="1"} 198.36848598124263
+foo:1m_by_job_sketch{job="b",quantile="0"} -10.074696689511264
+foo:1m_by_job_sketch{job="b",quantile="0.5"} -10.074696689511264
+foo:1m_by_job_sketch{job="b",quantile="1"} -10.074696689511264
+`)
+
+	// sketch_buckets
+	f(`
+- interval: 1m
+  outputs: [sketch_buckets]
+`, `
+foo 0
+foo 100
+foo 101
+foo -5
+`, `foo:1m_sketch_buckets{vmrange="0...9.827e-10"} 1
+foo:1m_sketch_buckets{vmrange="9.950e+01...1.015e+02"} 2
+`)
+}
+
+func TestDDSketchQuantiles(t *testing.T) {
+	var sk ddSketch
+	for i := 1; i <= 10000; i++ {
+		sk.update(float64(i))
+	}
+	for _, phi := range []float64{0, 0.1, 0.5, 0.9, 0.99, 1} {
+		expected := 1 + phi*9999
+		q := sk.quantile(phi)
+		if math.Abs(q-expected)/expected > sketchRelativeAccuracy {
+			t.Fatalf("unexpected quantile for phi=%v; got %v; want %v with relative accuracy %v", phi, q, expected, sketchRelativeAccuracy)
+		}
+	}
+
+	sk.reset()
+	if q := sk.quantile(0.5); !math.IsNaN(q) {
+		t.Fatalf("expecting NaN quantile for empty sketch; got %v", q)
+	}
+}
+
+func TestSketchBucketsIgnoreNegativeSamples(t *testing.T) {
+	f := func(phis []float64, values []float64, ignoredExpected, countExpected uint64) {
+		t.Helper()
+
+		ac := newSketchAggrConfig(phis).(*sketchAggrConfig)
+		av := ac.getValue(nil).(*sketchAggrValue)
+		for _, v := range values {
+			av.pushSample(ac, &pushSample{
+				value: v,
+			}, "", 0)
+		}
+		if n := ac.ignoredNegativeSamples.Get(); n != ignoredExpected {
+			t.Fatalf("unexpected number of ignored negative samples; got %d; want %d", n, ignoredExpected)
+		}
+		if av.sk.count != countExpected {
+			t.Fatalf("unexpected number of samples in sketch; got %d; want %d", av.sk.count, countExpected)
+		}
+	}
+
+	// sketch_buckets ignores negative values
+	f(nil, []float64{1, -1, 0, -1e-10, -100}, 2, 3)
+
+	// sketch(phis) keeps negative values
+	f([]float64{0.5}, []float64{1, -1, 0, -1e-10, -100}, 0, 5)
+}
+
+func TestHLLCount(t *testing.T) {
+	f := func(n int) {
+		t.Helper()
+
+		var hs hll
+		for i := 0; i < n; i++ {
+			// add every item twice in order to verify duplicates aren't counted
+			for j := 0; j < 2; j++ {
+				hs.add(xxhash.Sum64String(fmt.Sprintf("item_%d", i)))
+			}
+		}
+		count := hs.count()
+		if n <= hllMaxExactItems {
+			if count != float64(n) {
+				t.Fatalf("unexpected exact count; got %v; want %d", count, n)
+			}
+			return
+		}
+		if math.Abs(count-float64(n))/float64(n) > 0.05 {
+			t.Fatalf("too big estimation error; got %v; want %d", count, n)
+		}
+	}
+
+	f(0)
+	f(1)
+	f(100)
+	f(hllMaxExactItems)
+	f(hllMaxExactItems + 1)
+	f(10_000)
+	f(1_000_000)
+}
@@ -1,9 +1,11 @@
 package streamaggr
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 )
 
 var rateAggrSharedValuePool sync.Pool
@@ -169,6 +171,57 @@ func (av *rateAggrValue) state() any {
 	return av.shared
 }
 
+func (av *rateAggrValue) marshalState(dst []byte, ao *aggrOutputs) []byte {
+	dst = encoding.MarshalVarUint64(dst, uint64(len(av.shared)))
+	for k, sv := range av.shared {
+		state := sv.getState(av.isGreen)
+		dst = ao.marshalInputKey(dst, k)
+		dst = marshalFloat64(dst, sv.value)
+		dst = encoding.MarshalInt64(dst, sv.deleteDeadline)
+		dst = encoding.MarshalInt64(dst, sv.prevTimestamp)
+		dst = marshalFloat64(dst, state.increase)
+		dst = encoding.MarshalInt64(dst, state.timestamp)
+	}
+	return dst
+}
+
+func (av *rateAggrValue) unmarshalState(src []byte, ao *aggrOutputs) error {
+	n, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return fmt.Errorf("cannot unmarshal the number of series")
+	}
+	src = src[nSize:]
+	var err error
+	for i := uint64(0); i < n; i++ {
+		var key string
+		if key, src, err = ao.unmarshalInputKey(src); err != nil {
+			return fmt.Errorf("cannot unmarshal key for series #%d: %w", i, err)
+		}
+		sv := getRateAggrSharedValue(av.isGreen)
+		state := sv.getState(av.isGreen)
+		if sv.value, src, err = unmarshalFloat64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal value for series #%d: %w", i, err)
+		}
+		if sv.deleteDeadline, src, err = unmarshalInt64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal deleteDeadline for series #%d: %w", i, err)
+		}
+		if sv.prevTimestamp, src, err = unmarshalInt64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal prevTimestamp for series #%d: %w", i, err)
+		}
+		if state.increase, src, err = unmarshalFloat64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal increase for series #%d: %w", i, err)
+		}
+		if state.timestamp, src, err = unmarshalInt64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal timestamp for series #%d: %w", i, err)
+		}
+		av.shared[key] = sv
+	}
+	if len(src) > 0 {
+		return fmt.Errorf("unexpected non-empty tail left; len(tail)=%d", len(src))
+	}
+	return nil
+}
+
 func newRateAggrConfig(isAvg bool) aggrConfig {
 	return &rateAggrConfig{
 		isAvg: isAvg,
@@ -0,0 +1,213 @@
+package streamaggr
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// sketchRelativeAccuracy is the relative accuracy for quantiles calculated by sketch outputs.
+	sketchRelativeAccuracy = 0.01
+
+	// sketchMinValue is the minimum absolute value tracked by sketch outputs. Smaller values are counted as zeros.
+	sketchMinValue = 1e-9
+)
+
+var (
+	sketchGamma    = (1 + sketchRelativeAccuracy) / (1 - sketchRelativeAccuracy)
+	sketchLogGamma = math.Log(sketchGamma)
+
+	// sketchZeroBucketRange is vmrange for values smaller than sketchMinValue.
+	// Its upper bound matches the lower bound of the first bucket for positive values.
+	sketchZeroBucketRange = fmt.Sprintf("0...%.3e", math.Pow(sketchGamma, float64(getSketchIndex(sketchMinValue)-1)))
+)
+
+// ddSketch is a mergeable quantile sketch with relative accuracy guarantees.
+//
+// See https://arxiv.org/abs/1908.10693
+type ddSketch struct {
+	positive  map[int]uint64
+	negative  map[int]uint64
+	zeroCount uint64
+	count     uint64
+
+	// indexes is a temporary buffer used for sorting bucket indexes.
+	indexes []int
+}
+
+func getSketchIndex(v float64) int {
+	return int(math.Ceil(math.Log(v) / sketchLogGamma))
+}
+
+// getSketchBucketValue returns the value, which represents the bucket with the given idx with sketchRelativeAccuracy.
+func getSketchBucketValue(idx int) float64 {
+	return 2 * math.Pow(sketchGamma, float64(idx)) / (sketchGamma + 1)
+}
+
+// getSketchBucketRange returns vmrange for the bucket with the given idx.
+func getSketchBucketRange(idx int) string {
+	start := math.Pow(sketchGamma, float64(idx-1))
+	end := math.Pow(sketchGamma, float64(idx))
+	return fmt.Sprintf("%.3e...%.3e", start, end)
+}
+
+func (sk *ddSketch) update(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	sk.count++
+	switch {
+	case v >= sketchMinValue:
+		if sk.positive == nil {
+			sk.positive = make(map[int]uint64)
+		}
+		sk.positive[getSketchIndex(v)]++
+	case v <= -sketchMinValue:
+		if sk.negative == nil {
+			sk.negative = make(map[int]uint64)
+		}
+		sk.negative[getSketchIndex(-v)]++
+	default:
+		sk.zeroCount++
+	}
+}
+
+// quantiles appends quantiles for the given phis to dst and returns the result.
+func (sk *ddSketch) quantiles(dst []float64, phis []float64) []float64 {
+	for _, phi := range phis {
+		dst = append(dst, sk.quantile(phi))
+	}
+	return dst
+}
+
+func (sk *ddSketch) quantile(phi float64) float64 {
+	if sk.count == 0 {
+		return math.NaN()
+	}
+	rank := uint64(phi * float64(sk.count-1))
+
+	// Negative values are ordered by descending bucket index.
+	sk.indexes = sortedSketchIndexes(sk.indexes[:0], sk.negative)
+	for i := len(sk.indexes) - 1; i >= 0; i-- {
+		idx := sk.indexes[i]
+		n := sk.negative[idx]
+		if rank < n {
+			return -getSketchBucketValue(idx)
+		}
+		rank -= n
+	}
+	if rank < sk.zeroCount {
+		return 0
+	}
+	rank -= sk.zeroCount
+	sk.indexes = sortedSketchIndexes(sk.indexes[:0], sk.positive)
+	for _, idx := range sk.indexes {
+		n := sk.positive[idx]
+		if rank < n {
+			return getSketchBucketValue(idx)
+		}
+		rank -= n
+	}
+	return math.NaN()
+}
+
+// visitNonZeroBuckets calls f for non-negative buckets in sk.
+//
+// Negative values aren't visited, since vmrange buckets can represent only non-negative values.
+// sketch_buckets output ignores negative values before they reach sk.
+func (sk *ddSketch) visitNonZeroBuckets(f func(vmrange string, count uint64)) {
+	if sk.zeroCount > 0 {
+		f(sketchZeroBucketRange, sk.zeroCount)
+	}
+	sk.indexes = sortedSketchIndexes(sk.indexes[:0], sk.positive)
+	for _, idx := range sk.indexes {
+		f(getSketchBucketRange(idx), sk.positive[idx])
+	}
+}
+
+func (sk *ddSketch) reset() {
+	clear(sk.positive)
+	clear(sk.negative)
+	sk.zeroCount = 0
+	sk.count = 0
+}
+
+func sortedSketchIndexes(dst []int, m map[int]uint64) []int {
+	for idx := range m {
+		dst = append(dst, idx)
+	}
+	slices.Sort(dst)
+	return dst
+}
+
+// sketchAggrValue calculates output=sketch(phi1, ..., phiN) and output=sketch_buckets.
+//
+// sketch(phi1, ..., phiN) returns quantiles over input samples with sketchRelativeAccuracy.
+//
+// sketch_buckets returns sketch buckets with vmrange labels, which can be merged across multiple aggregators
+// with sum(...) by (vmrange) and then passed to histogram_quantile().
+type sketchAggrValue struct {
+	sk ddSketch
+}
+
+func (av *sketchAggrValue) pushSample(c aggrConfig, sample *pushSample, _ string, _ int64) {
+	ac := c.(*sketchAggrConfig)
+	if ac.phis == nil && sample.value <= -sketchMinValue {
+		// Skip negative values, since they cannot be represented by vmrange buckets.
+		ac.ignoredNegativeSamples.Inc()
+		return
+	}
+	av.sk.update(sample.value)
+}
+
+func (av *sketchAggrValue) flush(c aggrConfig, ctx *flushCtx, key string, _ bool) {
+	ac := c.(*sketchAggrConfig)
+	if av.sk.count == 0 {
+		return
+	}
+	if ac.phis == nil {
+		av.sk.visitNonZeroBuckets(func(vmrange string, count uint64) {
+			ctx.appendSeriesWithExtraLabel(key, "sketch_buckets", float64(count), "vmrange", vmrange)
+		})
+	} else {
+		ac.quantiles = av.sk.quantiles(ac.quantiles[:0], ac.phis)
+		for i, quantile := range ac.quantiles {
+			ac.b = strconv.AppendFloat(ac.b[:0], ac.phis[i], 'g', -1, 64)
+			phiStr := bytesutil.InternBytes(ac.b)
+			ctx.appendSeriesWithExtraLabel(key, "sketch", quantile, "quantile", phiStr)
+		}
+	}
+	av.sk.reset()
+}
+
+func (*sketchAggrValue) state() any {
+	return nil
+}
+
+// newSketchAggrConfig returns config for sketch(phi1, ..., phiN) output.
+//
+// It returns config for sketch_buckets output if phis is nil.
+func newSketchAggrConfig(phis []float64) aggrConfig {
+	return &sketchAggrConfig{
+		phis:                   phis,
+		ignoredNegativeSamples: &metrics.Counter{},
+	}
+}
+
+type sketchAggrConfig struct {
+	phis      []float64
+	quantiles []float64
+	b         []byte
+
+	// ignoredNegativeSamples counts negative samples ignored by sketch_buckets output.
+	ignoredNegativeSamples *metrics.Counter
+}
+
+func (*sketchAggrConfig) getValue(_ any) aggrValue {
+	return &sketchAggrValue{}
+}
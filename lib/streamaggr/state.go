@@ -0,0 +1,358 @@
+package streamaggr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+// stateFormatVersion is the version of the format for the persisted aggregator state.
+//
+// It must be incremented every time the format changes, so the state in the old format is ignored on startup.
+const stateFormatVersion = 1
+
+// stateFileMagic is the prefix for every file with the persisted aggregator state.
+const stateFileMagic = "vmstreamaggr"
+
+// aggrStateMarshaler is implemented by aggrValue types, which preserve the state between aggregation intervals.
+//
+// The state of such outputs is persisted across restarts if Options.StateDir is set.
+type aggrStateMarshaler interface {
+	// marshalState appends the state to dst and returns the result.
+	marshalState(dst []byte, ao *aggrOutputs) []byte
+
+	// unmarshalState restores the state from src, which has been marshaled with marshalState.
+	unmarshalState(src []byte, ao *aggrOutputs) error
+}
+
+// getStateFilePath returns the path to file with the persisted state for the aggregator with the given aggrID.
+func getStateFilePath(stateDir string, aggrID int) string {
+	return filepath.Join(stateDir, fmt.Sprintf("%d.bin", aggrID))
+}
+
+// getStateConfigHash returns the hash for cfg with the given effective settings.
+//
+// The persisted state is ignored on startup if the hash changes.
+func getStateConfigHash(cfg *Config, dedupInterval time.Duration, dropInputLabels []string) uint64 {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal the provided config: %s", err)
+	}
+	data = fmt.Appendf(data, "\ndedupInterval=%s\ndropInputLabels=%s", dedupInterval, strings.Join(dropInputLabels, ","))
+	return xxhash.Sum64(data)
+}
+
+// removeStaleStateFiles removes files with the persisted state for aggregators, which are missing in the current config.
+func removeStaleStateFiles(stateDir string, aggregatorsCount int) {
+	des, err := os.ReadDir(stateDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Errorf("cannot read stream aggregation state dir %q: %s", stateDir, err)
+		}
+		return
+	}
+	for _, de := range des {
+		name := de.Name()
+		var aggrID int
+		if _, err := fmt.Sscanf(name, "%d.bin", &aggrID); err == nil && aggrID >= 1 && aggrID <= aggregatorsCount {
+			continue
+		}
+		path := filepath.Join(stateDir, name)
+		if err := os.RemoveAll(path); err != nil {
+			logger.Errorf("cannot remove stale stream aggregation state at %q: %s", path, err)
+			continue
+		}
+		logger.Infof("removed stale stream aggregation state at %q", path)
+	}
+}
+
+// mustSaveState saves the state of stateful outputs for a to a.stateFilePath.
+func (a *aggregator) mustSaveState() {
+	if a.stateFilePath == "" {
+		return
+	}
+	startTime := time.Now()
+
+	var body []byte
+	var entries uint64
+	ao := a.aggrOutputs
+	ao.m.Range(func(k, v any) bool {
+		av := v.(*aggrValues)
+		av.mu.Lock()
+		if av.deleteDeadline < 0 {
+			// The entry has been deleted by the concurrent flush.
+			av.mu.Unlock()
+			return true
+		}
+		outputKey := k.(string)
+		body = ao.marshalOutputKey(body, outputKey)
+		body = encoding.MarshalInt64(body, av.deleteDeadline)
+		body = encoding.MarshalVarUint64(body, uint64(len(av.blue)))
+		bb := bbPool.Get()
+		for _, o := range av.blue {
+			bb.B = bb.B[:0]
+			if sm, ok := o.(aggrStateMarshaler); ok {
+				bb.B = sm.marshalState(bb.B, ao)
+			}
+			body = encoding.MarshalBytes(body, bb.B)
+		}
+		bbPool.Put(bb)
+		av.mu.Unlock()
+		entries++
+		return true
+	})
+
+	data := append([]byte{}, stateFileMagic...)
+	data = encoding.MarshalUint64(data, stateFormatVersion)
+	data = encoding.MarshalUint64(data, a.stateConfigHash)
+	data = encoding.MarshalVarUint64(data, entries)
+	data = encoding.CompressZSTDLevel(data, body, 1)
+
+	fs.MustMkdirIfNotExist(filepath.Dir(a.stateFilePath))
+	fs.MustWriteAtomic(a.stateFilePath, data, true)
+
+	a.stateSaveDuration.Update(time.Since(startTime).Seconds())
+	a.stateSaves.Inc()
+	a.stateSizeBytes.Store(uint64(len(data)))
+}
+
+// mustLoadState restores the state of stateful outputs for a from a.stateFilePath.
+//
+// The state is ignored if it has been saved with the different format version or with the different config.
+func (a *aggregator) mustLoadState() {
+	path := a.stateFilePath
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Errorf("cannot read stream aggregation state from %q: %s; starting with empty state", path, err)
+		}
+		return
+	}
+	entries, err := a.unmarshalState(data)
+	if err != nil {
+		logger.Warnf("cannot restore stream aggregation state from %q: %s; starting with empty state", path, err)
+		a.aggrOutputs.m.Clear()
+		return
+	}
+	logger.Infof("restored stream aggregation state for %d entries from %q", entries, path)
+}
+
+func (a *aggregator) unmarshalState(src []byte) (uint64, error) {
+	if !strings.HasPrefix(bytesutil.ToUnsafeString(src), stateFileMagic) {
+		return 0, fmt.Errorf("unexpected file format")
+	}
+	src = src[len(stateFileMagic):]
+	if len(src) < 16 {
+		return 0, fmt.Errorf("too short header; got %d bytes; want at least 16 bytes", len(src))
+	}
+	version := encoding.UnmarshalUint64(src)
+	if version != stateFormatVersion {
+		return 0, fmt.Errorf("unsupported state format version %d; want %d", version, stateFormatVersion)
+	}
+	configHash := encoding.UnmarshalUint64(src[8:])
+	if configHash != a.stateConfigHash {
+		return 0, fmt.Errorf("the state has been saved for the different aggregation config")
+	}
+	src = src[16:]
+	entries, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return 0, fmt.Errorf("cannot unmarshal the number of entries")
+	}
+	src = src[nSize:]
+	body, err := encoding.DecompressZSTD(nil, src)
+	if err != nil {
+		return 0, fmt.Errorf("cannot decompress state: %w", err)
+	}
+
+	ao := a.aggrOutputs
+	for i := uint64(0); i < entries; i++ {
+		outputKey, tail, err := ao.unmarshalOutputKey(body)
+		if err != nil {
+			return 0, fmt.Errorf("cannot unmarshal output key for entry #%d: %w", i, err)
+		}
+		body = tail
+		if len(body) < 8 {
+			return 0, fmt.Errorf("cannot unmarshal deleteDeadline for entry #%d", i)
+		}
+		deleteDeadline := encoding.UnmarshalInt64(body)
+		body = body[8:]
+		outputsCount, nSize := encoding.UnmarshalVarUint64(body)
+		if nSize <= 0 {
+			return 0, fmt.Errorf("cannot unmarshal the number of outputs for entry #%d", i)
+		}
+		body = body[nSize:]
+		if outputsCount != uint64(len(ao.configs)) {
+			return 0, fmt.Errorf("unexpected number of outputs for entry #%d; got %d; want %d", i, outputsCount, len(ao.configs))
+		}
+
+		av := &aggrValues{
+			blue:           make([]aggrValue, len(ao.configs)),
+			deleteDeadline: deleteDeadline,
+		}
+		for idx, ac := range ao.configs {
+			av.blue[idx] = ac.getValue(nil)
+			state, nSize := encoding.UnmarshalBytes(body)
+			if nSize <= 0 {
+				return 0, fmt.Errorf("cannot unmarshal state for output #%d at entry #%d", idx, i)
+			}
+			body = body[nSize:]
+			if len(state) == 0 {
+				continue
+			}
+			sm, ok := av.blue[idx].(aggrStateMarshaler)
+			if !ok {
+				return 0, fmt.Errorf("unexpected state for stateless output #%d at entry #%d", idx, i)
+			}
+			if err := sm.unmarshalState(state, ao); err != nil {
+				return 0, fmt.Errorf("cannot unmarshal state for output #%d at entry #%d: %w", idx, i, err)
+			}
+		}
+		ao.m.Store(outputKey, av)
+	}
+	if len(body) > 0 {
+		return 0, fmt.Errorf("unexpected non-empty tail left after unmarshaling %d entries; len(tail)=%d", entries, len(body))
+	}
+	return entries, nil
+}
+
+func (a *aggregator) runStateSaver(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-t.C:
+			a.mustSaveState()
+		}
+	}
+}
+
+// marshalOutputKey appends outputKey from aggrOutputs.m to dst in the format, which doesn't depend on the labels compressor state.
+func (ao *aggrOutputs) marshalOutputKey(dst []byte, outputKey string) []byte {
+	labels := decompressLabels(nil, outputKey)
+	return marshalLabels(dst, labels)
+}
+
+func (ao *aggrOutputs) unmarshalOutputKey(src []byte) (string, []byte, error) {
+	labels, tail, err := unmarshalLabels(src)
+	if err != nil {
+		return "", src, err
+	}
+	key := lc.Compress(nil, labels)
+	return bytesutil.InternBytes(key), tail, nil
+}
+
+// marshalInputKey appends the key for the input series passed to aggrValue.pushSample to dst
+// in the format, which doesn't depend on the labels compressor state.
+func (ao *aggrOutputs) marshalInputKey(dst []byte, inputKey string) []byte {
+	if ao.useInputKey {
+		labels := decompressLabels(nil, inputKey)
+		return marshalLabels(dst, labels)
+	}
+
+	// The inputKey contains both output and input labels. See compressLabels.
+	src := bytesutil.ToUnsafeBytes(inputKey)
+	outputKeyLen, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		logger.Panicf("BUG: cannot unmarshal outputKeyLen from uvarint")
+	}
+	src = src[nSize:]
+	outputLabels := decompressLabels(nil, bytesutil.ToUnsafeString(src[:outputKeyLen]))
+	inputLabels := decompressLabels(nil, bytesutil.ToUnsafeString(src[outputKeyLen:]))
+	dst = marshalLabels(dst, outputLabels)
+	return marshalLabels(dst, inputLabels)
+}
+
+func (ao *aggrOutputs) unmarshalInputKey(src []byte) (string, []byte, error) {
+	if ao.useInputKey {
+		labels, tail, err := unmarshalLabels(src)
+		if err != nil {
+			return "", src, err
+		}
+		key := lc.Compress(nil, labels)
+		return bytesutil.InternBytes(key), tail, nil
+	}
+
+	outputLabels, tail, err := unmarshalLabels(src)
+	if err != nil {
+		return "", src, fmt.Errorf("cannot unmarshal output labels: %w", err)
+	}
+	inputLabels, tail, err := unmarshalLabels(tail)
+	if err != nil {
+		return "", src, fmt.Errorf("cannot unmarshal input labels: %w", err)
+	}
+	key := compressLabels(nil, inputLabels, outputLabels)
+	return bytesutil.InternBytes(key), tail, nil
+}
+
+func marshalLabels(dst []byte, labels []prompbmarshal.Label) []byte {
+	dst = encoding.MarshalVarUint64(dst, uint64(len(labels)))
+	for _, label := range labels {
+		dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(label.Name))
+		dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(label.Value))
+	}
+	return dst
+}
+
+func unmarshalLabels(src []byte) ([]prompbmarshal.Label, []byte, error) {
+	n, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return nil, src, fmt.Errorf("cannot unmarshal labels count")
+	}
+	src = src[nSize:]
+	if n > uint64(len(src)) {
+		return nil, src, fmt.Errorf("too big labels count: %d", n)
+	}
+	labels := make([]prompbmarshal.Label, n)
+	for i := range labels {
+		name, nSize := encoding.UnmarshalBytes(src)
+		if nSize <= 0 {
+			return nil, src, fmt.Errorf("cannot unmarshal label name #%d", i)
+		}
+		src = src[nSize:]
+		value, nSize := encoding.UnmarshalBytes(src)
+		if nSize <= 0 {
+			return nil, src, fmt.Errorf("cannot unmarshal value for label %q", name)
+		}
+		src = src[nSize:]
+		labels[i] = prompbmarshal.Label{
+			Name:  bytesutil.InternBytes(name),
+			Value: bytesutil.InternBytes(value),
+		}
+	}
+	return labels, src, nil
+}
+
+func marshalFloat64(dst []byte, f float64) []byte {
+	return encoding.MarshalUint64(dst, math.Float64bits(f))
+}
+
+func unmarshalFloat64(src []byte) (float64, []byte, error) {
+	if len(src) < 8 {
+		return 0, src, fmt.Errorf("cannot unmarshal float64 from %d bytes; need at least 8 bytes", len(src))
+	}
+	return math.Float64frombits(encoding.UnmarshalUint64(src)), src[8:], nil
+}
+
+func unmarshalInt64(src []byte) (int64, []byte, error) {
+	if len(src) < 8 {
+		return 0, src, fmt.Errorf("cannot unmarshal int64 from %d bytes; need at least 8 bytes", len(src))
+	}
+	return encoding.UnmarshalInt64(src), src[8:], nil
+}
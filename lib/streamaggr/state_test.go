@@ -0,0 +1,245 @@
+package streamaggr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestAggregatorsStatePersistence(t *testing.T) {
+	f := func(configBefore, configAfter string, inputBefore, inputAfter, outputExpected string) {
+		t.Helper()
+
+		stateDir := t.TempDir()
+
+		// Push inputBefore to aggregators, which don't flush incomplete state on shutdown.
+		// The state must be saved to stateDir.
+		pushFunc := func(tss []prompbmarshal.TimeSeries) {
+			panic(fmt.Errorf("unexpected push of %d series before the restart", len(tss)))
+		}
+		opts := &Options{
+			StateDir: stateDir,
+		}
+		a, err := LoadFromData([]byte(configBefore), pushFunc, opts, "state_before")
+		if err != nil {
+			t.Fatalf("cannot initialize aggregators: %s", err)
+		}
+		timestamp := time.Now().UnixMilli()
+		a.Push(prometheus.MustParsePromMetrics(inputBefore, timestamp), nil)
+		a.MustStop()
+
+		// Restore the state from stateDir, push inputAfter and flush the aggregated state on shutdown.
+		var tssOutput []prompbmarshal.TimeSeries
+		var tssOutputLock sync.Mutex
+		pushFunc = func(tss []prompbmarshal.TimeSeries) {
+			tssOutputLock.Lock()
+			tssOutput = appendClonedTimeseries(tssOutput, tss)
+			tssOutputLock.Unlock()
+		}
+		opts = &Options{
+			StateDir:        stateDir,
+			FlushOnShutdown: true,
+		}
+		a, err = LoadFromData([]byte(configAfter), pushFunc, opts, "state_after")
+		if err != nil {
+			t.Fatalf("cannot initialize aggregators: %s", err)
+		}
+		a.Push(prometheus.MustParsePromMetrics(inputAfter, timestamp+10_000), nil)
+		a.MustStop()
+
+		output := timeSeriessToString(tssOutput)
+		if output != outputExpected {
+			t.Fatalf("unexpected output;\ngot\n%s\nwant\n%s", output, outputExpected)
+		}
+	}
+
+	// total continues from the restored state
+	config := `
+- interval: 1m
+  outputs: [total]
+`
+	f(config, config, `
+foo{a="1"} 10
+foo{a="1"} 15
+foo{a="2"} 3
+`, `
+foo{a="1"} 20
+foo{a="2"} 1
+foo{a="3"} 7
+`, `foo:1m_total{a="1"} 10
+foo:1m_total{a="2"} 1
+foo:1m_total{a="3"} 0
+`)
+
+	// increase, which groups series by labels
+	config = `
+- interval: 1m
+  by: [a]
+  outputs: [increase]
+`
+	f(config, config, `
+foo{a="1",b="1"} 10
+foo{a="1",b="2"} 100
+foo{a="1",b="1"} 15
+`, `
+foo{a="1",b="1"} 20
+foo{a="1",b="2"} 110
+`, `foo:1m_by_a_increase{a="1"} 20
+`)
+
+	// increase with de-duplication
+	config = `
+- interval: 1m
+  dedup_interval: 30s
+  outputs: [increase]
+`
+	f(config, config, `
+foo 10
+`, `
+foo 15
+`, `foo:1m_increase 5
+`)
+
+	// rate_sum
+	config = `
+- interval: 1m
+  by: [a]
+  outputs: [rate_sum]
+`
+	f(config, config, `
+foo{a="1",b="1"} 10
+`, `
+foo{a="1",b="1"} 30
+`, `foo:1m_by_a_rate_sum{a="1"} 2
+`)
+
+	// histogram_bucket
+	var h metrics.Histogram
+	for _, v := range []float64{0, 1, 2, 2, 3e20} {
+		h.Update(v)
+	}
+	var buckets []string
+	h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+		buckets = append(buckets, fmt.Sprintf("foo:1m_histogram_bucket{vmrange=%q} %d\n", vmrange, count))
+	})
+	sort.Strings(buckets)
+	outputExpected := strings.Join(buckets, "")
+	config = `
+- interval: 1m
+  outputs: [histogram_bucket]
+`
+	f(config, config, `
+foo 0
+foo 1
+foo 2
+`, `
+foo 2
+foo 3e20
+`, outputExpected)
+
+	// the state is ignored after the config change
+	f(`
+- interval: 1m
+  outputs: [total]
+`, `
+- interval: 1m
+  outputs: [total]
+  without: [b]
+`, `
+foo{a="1"} 10
+foo{a="1"} 15
+`, `
+foo{a="1"} 20
+`, `foo:1m_without_b_total{a="1"} 0
+`)
+
+	// the state isn't persisted for stateless outputs
+	config = `
+- interval: 1m
+  outputs: [sum_samples, total]
+`
+	f(config, config, `
+foo 10
+foo 15
+`, `
+foo 20
+`, `foo:1m_sum_samples 20
+foo:1m_total 10
+`)
+}
+
+func TestAggregatorsStatePersistenceRemoveStale(t *testing.T) {
+	stateDir := t.TempDir()
+	pushFunc := func(_ []prompbmarshal.TimeSeries) {}
+	opts := &Options{
+		StateDir: stateDir,
+	}
+
+	a, err := LoadFromData([]byte(`
+- interval: 1m
+  outputs: [total]
+- interval: 1m
+  outputs: [rate_sum]
+`), pushFunc, opts, "state_stale_before")
+	if err != nil {
+		t.Fatalf("cannot initialize aggregators: %s", err)
+	}
+	a.MustStop()
+	for _, name := range []string{"1.bin", "2.bin"} {
+		if _, err := os.Stat(filepath.Join(stateDir, name)); err != nil {
+			t.Fatalf("missing state file: %s", err)
+		}
+	}
+
+	a, err = LoadFromData([]byte(`
+- interval: 1m
+  outputs: [total]
+`), pushFunc, opts, "state_stale_after")
+	if err != nil {
+		t.Fatalf("cannot initialize aggregators: %s", err)
+	}
+	a.MustStop()
+	if _, err := os.Stat(filepath.Join(stateDir, "2.bin")); !os.IsNotExist(err) {
+		t.Fatalf("expecting the stale state file to be removed; got err=%v", err)
+	}
+}
+
+func TestAggregatorsStatePersistenceInvalidState(t *testing.T) {
+	stateDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(stateDir, "1.bin"), []byte("foobar"), 0644); err != nil {
+		t.Fatalf("cannot write invalid state: %s", err)
+	}
+
+	var tssOutput []prompbmarshal.TimeSeries
+	pushFunc := func(tss []prompbmarshal.TimeSeries) {
+		tssOutput = appendClonedTimeseries(tssOutput, tss)
+	}
+	opts := &Options{
+		StateDir:        stateDir,
+		FlushOnShutdown: true,
+	}
+	a, err := LoadFromData([]byte(`
+- interval: 1m
+  outputs: [total]
+`), pushFunc, opts, "state_invalid")
+	if err != nil {
+		t.Fatalf("cannot initialize aggregators: %s", err)
+	}
+	a.Push(prometheus.MustParsePromMetrics(`foo 10`, time.Now().UnixMilli()), nil)
+	a.MustStop()
+
+	output := timeSeriessToString(tssOutput)
+	outputExpected := "foo:1m_total 0\n"
+	if output != outputExpected {
+		t.Fatalf("unexpected output;\ngot\n%s\nwant\n%s", output, outputExpected)
+	}
+}
@@ -33,6 +33,8 @@ const flushQuantile = 0.95
 
 var supportedOutputs = []string{
 	"avg",
+	"bottomk(N)",
+	"count_distinct(label)",
 	"count_samples",
 	"count_series",
 	"histogram_bucket",
@@ -44,9 +46,12 @@ var supportedOutputs = []string{
 	"quantiles(phi1, ..., phiN)",
 	"rate_avg",
 	"rate_sum",
+	"sketch(phi1, ..., phiN)",
+	"sketch_buckets",
 	"stddev",
 	"stdvar",
 	"sum_samples",
+	"topk(N)",
 	"total",
 	"total_prometheus",
 	"unique_samples",
@@ -148,6 +153,22 @@ type Options struct {
 
 	// EnableWindows enables aggregation in windows
 	EnableWindows bool
+
+	// StateDir is an optional path to directory for persisting the state of total, total_prometheus, increase,
+	// increase_prometheus, rate_avg, rate_sum and histogram_bucket outputs across restarts.
+	//
+	// The state is saved every StateSaveInterval and on MustStop, and it is restored when the Aggregators are created.
+	// The state is ignored on startup if the corresponding aggregation config has been changed.
+	//
+	// The state isn't persisted for aggregations with enable_windows option.
+	//
+	// By default the state isn't persisted.
+	StateDir string
+
+	// StateSaveInterval is the interval for saving the state to StateDir.
+	//
+	// By default the state is saved only on MustStop.
+	StateSaveInterval time.Duration
 }
 
 // Config is a configuration for a single stream aggregation.
@@ -192,6 +213,8 @@ type Config struct {
 	// The following names are allowed:
 	//
 	// - avg - the average value across all the samples
+	// - bottomk(N) - N input series with the smallest values
+	// - count_distinct(label) - estimates the number of distinct values for the given label
 	// - count_samples - counts the input samples
 	// - count_series - counts the number of unique input series
 	// - histogram_bucket - creates VictoriaMetrics histogram for input samples
@@ -203,9 +226,12 @@ type Config struct {
 	// - quantiles(phi1, ..., phiN) - quantiles' estimation for phi in the range [0..1]
 	// - rate_avg - calculates average of rate for input counters
 	// - rate_sum - calculates sum of rate for input counters
+	// - sketch(phi1, ..., phiN) - quantiles' estimation with 1% relative accuracy for phi in the range [0..1]
+	// - sketch_buckets - buckets of the quantile sketch, which can be merged across multiple aggregators
 	// - stddev - standard deviation across all the samples
 	// - stdvar - standard variance across all the samples
 	// - sum_samples - sums the input sample values
+	// - topk(N) - N input series with the biggest values
 	// - total - aggregates input counters
 	// - total_prometheus - aggregates input counters, ignoring the first sample in new time series
 	// - unique_samples - counts the number of unique sample values
@@ -311,6 +337,10 @@ func loadFromData(data []byte, filePath string, pushFunc PushFunc, opts *Options
 		logger.Panicf("BUG: cannot marshal the provided configs: %s", err)
 	}
 
+	if opts != nil && opts.StateDir != "" {
+		removeStaleStateFiles(opts.StateDir, len(as))
+	}
+
 	metrics.RegisterSet(ms)
 	return &Aggregators{
 		as:         as,
@@ -452,6 +482,18 @@ type aggregator struct {
 	ignoredOldSamples *metrics.Counter
 	ignoredNaNSamples *metrics.Counter
 	matchedSamples    *metrics.Counter
+
+	// stateFilePath is the path to file for persisting the state of stateful outputs.
+	//
+	// The state isn't persisted if stateFilePath is empty.
+	stateFilePath string
+
+	// stateConfigHash is the hash of the config, which is used for detecting config changes for the persisted state.
+	stateConfigHash uint64
+
+	stateSaveDuration *metrics.Histogram
+	stateSaves        *metrics.Counter
+	stateSizeBytes    atomic.Uint64
 }
 
 // PushFunc is called by Aggregators when it needs to push its state to metrics storage
@@ -525,16 +567,6 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 		dropInputLabels = *v
 	}
 
-	// initialize input_relabel_configs and output_relabel_configs
-	inputRelabeling, err := promrelabel.ParseRelabelConfigs(cfg.InputRelabelConfigs)
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse input_relabel_configs: %w", err)
-	}
-	outputRelabeling, err := promrelabel.ParseRelabelConfigs(cfg.OutputRelabelConfigs)
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse output_relabel_configs: %w", err)
-	}
-
 	// check by and without lists
 	by := sortAndRemoveDuplicates(cfg.By)
 	without := sortAndRemoveDuplicates(cfg.Without)
@@ -560,7 +592,9 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 			return nil, fmt.Errorf("`outputs` list must contain only a single entry if `keep_metric_names` is set; got %q; "+
 				"see https://docs.victoriametrics.com/victoriametrics/stream-aggregation/#output-metric-names", cfg.Outputs)
 		}
-		if cfg.Outputs[0] == "histogram_bucket" || strings.HasPrefix(cfg.Outputs[0], "quantiles(") && strings.Contains(cfg.Outputs[0], ",") {
+		output := cfg.Outputs[0]
+		if output == "histogram_bucket" || output == "sketch_buckets" ||
+			(strings.HasPrefix(output, "quantiles(") || strings.HasPrefix(output, "sketch(")) && strings.Contains(output, ",") {
 			return nil, fmt.Errorf("`keep_metric_names` cannot be applied to `outputs: %q`, since they can generate multiple time series; "+
 				"see https://docs.victoriametrics.com/victoriametrics/stream-aggregation/#output-metric-names", cfg.Outputs)
 		}
@@ -604,10 +638,15 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 	}
 	outputsSeen := make(map[string]struct{}, len(cfg.Outputs))
 	for i, output := range cfg.Outputs {
-		ac, err := newOutputConfig(output, outputsSeen, useSharedState, ignoreFirstSampleInterval)
+		ac, err := newOutputConfig(output, outputsSeen, useSharedState, useInputKey, ignoreFirstSampleInterval)
 		if err != nil {
 			return nil, err
 		}
+		if cdc, ok := ac.(*countDistinctAggrConfig); ok {
+			if err := checkCountDistinctLabel(cdc.label, by, without, aggregateOnlyByTime); err != nil {
+				return nil, err
+			}
+		}
 		aggrOutputs.configs[i] = ac
 	}
 	outputsLabels := make([]string, 0, len(outputsSeen))
@@ -616,6 +655,11 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 	}
 	metricLabels := fmt.Sprintf(`outputs=%q,name=%q,path=%q,url=%q,position="%d"`, strings.Join(outputsLabels, ","), name, path, alias, aggrID)
 	aggrOutputs.outputSamples = ms.NewCounter(fmt.Sprintf(`vm_streamaggr_output_samples_total{%s}`, metricLabels))
+	for _, ac := range aggrOutputs.configs {
+		if sc, ok := ac.(*sketchAggrConfig); ok && sc.phis == nil {
+			sc.ignoredNegativeSamples = ms.NewCounter(fmt.Sprintf(`vm_streamaggr_ignored_samples_total{reason="negative",%s}`, metricLabels))
+		}
+	}
 
 	// initialize suffix to add to metric names after aggregation
 	suffix := ":" + cfg.Interval
@@ -627,6 +671,19 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 	}
 	suffix += "_"
 
+	// initialize input_relabel_configs and output_relabel_configs
+	//
+	// They are initialized after the rest of the config is validated, since they must be released via MustStop on errors.
+	inputRelabeling, err := promrelabel.ParseRelabelConfigs(cfg.InputRelabelConfigs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse input_relabel_configs: %w", err)
+	}
+	outputRelabeling, err := promrelabel.ParseRelabelConfigs(cfg.OutputRelabelConfigs)
+	if err != nil {
+		inputRelabeling.MustStop()
+		return nil, fmt.Errorf("cannot parse output_relabel_configs: %w", err)
+	}
+
 	// initialize the aggregator
 	a := &aggregator{
 		match: cfg.Match,
@@ -677,6 +734,21 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 		})
 	}
 
+	if opts.StateDir != "" {
+		if enableWindows {
+			logger.Warnf("the state for aggregation %q at %q isn't persisted, since it has enabled aggregation windows", name, path)
+		} else {
+			a.stateFilePath = getStateFilePath(opts.StateDir, aggrID)
+			a.stateConfigHash = getStateConfigHash(cfg, dedupInterval, dropInputLabels)
+			a.stateSaveDuration = ms.NewHistogram(fmt.Sprintf(`vm_streamaggr_state_save_duration_seconds{%s}`, metricLabels))
+			a.stateSaves = ms.NewCounter(fmt.Sprintf(`vm_streamaggr_state_saves_total{%s}`, metricLabels))
+			_ = ms.NewGauge(fmt.Sprintf(`vm_streamaggr_state_size_bytes{%s}`, metricLabels), func() float64 {
+				return float64(a.stateSizeBytes.Load())
+			})
+			a.mustLoadState()
+		}
+	}
+
 	alignFlushToInterval := !opts.NoAlignFlushToInterval
 	if v := cfg.NoAlignFlushToInterval; v != nil {
 		alignFlushToInterval = !*v
@@ -713,10 +785,18 @@ func newAggregator(cfg *Config, path string, pushFunc PushFunc, ms *metrics.Set,
 		a.wg.Done()
 	}()
 
+	if a.stateFilePath != "" && opts.StateSaveInterval > 0 {
+		a.wg.Add(1)
+		go func() {
+			a.runStateSaver(opts.StateSaveInterval)
+			a.wg.Done()
+		}()
+	}
+
 	return a, nil
 }
 
-func newOutputConfig(output string, outputsSeen map[string]struct{}, useSharedState bool, ignoreFirstSampleInterval time.Duration) (aggrConfig, error) {
+func newOutputConfig(output string, outputsSeen map[string]struct{}, useSharedState, useInputKey bool, ignoreFirstSampleInterval time.Duration) (aggrConfig, error) {
 	// check for duplicated output
 	if _, ok := outputsSeen[output]; ok {
 		return nil, fmt.Errorf("`outputs` list contains duplicate aggregation function: %s", output)
@@ -724,25 +804,9 @@ func newOutputConfig(output string, outputsSeen map[string]struct{}, useSharedSt
 	outputsSeen[output] = struct{}{}
 
 	if strings.HasPrefix(output, "quantiles(") {
-		if !strings.HasSuffix(output, ")") {
-			return nil, fmt.Errorf("missing closing brace for `quantiles()` output")
-		}
-		argsStr := output[len("quantiles(") : len(output)-1]
-		if len(argsStr) == 0 {
-			return nil, fmt.Errorf("`quantiles()` must contain at least one phi")
-		}
-		args := strings.Split(argsStr, ",")
-		phis := make([]float64, len(args))
-		for i, arg := range args {
-			arg = strings.TrimSpace(arg)
-			phi, err := strconv.ParseFloat(arg, 64)
-			if err != nil {
-				return nil, fmt.Errorf("cannot parse phi=%q for quantiles(%s): %w", arg, argsStr, err)
-			}
-			if phi < 0 || phi > 1 {
-				return nil, fmt.Errorf("phi inside quantiles(%s) must be in the range [0..1]; got %v", argsStr, phi)
-			}
-			phis[i] = phi
+		phis, err := parseOutputPhis("quantiles", output)
+		if err != nil {
+			return nil, err
 		}
 		if _, ok := outputsSeen["quantiles"]; ok {
 			return nil, fmt.Errorf("`outputs` list contains duplicated `quantiles()` function, please combine multiple phi* like `quantiles(0.5, 0.9)`")
@@ -750,6 +814,49 @@ func newOutputConfig(output string, outputsSeen map[string]struct{}, useSharedSt
 		outputsSeen["quantiles"] = struct{}{}
 		return newQuantilesAggrConfig(phis), nil
 	}
+	if strings.HasPrefix(output, "sketch(") {
+		phis, err := parseOutputPhis("sketch", output)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := outputsSeen["sketch"]; ok {
+			return nil, fmt.Errorf("`outputs` list contains duplicated `sketch()` function, please combine multiple phi* like `sketch(0.5, 0.9)`")
+		}
+		outputsSeen["sketch"] = struct{}{}
+		return newSketchAggrConfig(phis), nil
+	}
+	for _, funcName := range []string{"topk", "bottomk"} {
+		if !strings.HasPrefix(output, funcName+"(") {
+			continue
+		}
+		argStr, err := getOutputArg(funcName, output)
+		if err != nil {
+			return nil, err
+		}
+		k, err := strconv.Atoi(argStr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse N=%q for %s(%s): %w", argStr, funcName, argStr, err)
+		}
+		if k <= 0 {
+			return nil, fmt.Errorf("N inside %s(%s) must be positive", funcName, argStr)
+		}
+		if _, ok := outputsSeen[funcName]; ok {
+			return nil, fmt.Errorf("`outputs` list contains duplicated `%s()` function", funcName)
+		}
+		outputsSeen[funcName] = struct{}{}
+		return newTopkAggrConfig(k, funcName == "bottomk"), nil
+	}
+	if strings.HasPrefix(output, "count_distinct(") {
+		label, err := getOutputArg("count_distinct", output)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := outputsSeen["count_distinct"]; ok {
+			return nil, fmt.Errorf("`outputs` list contains duplicated `count_distinct()` function")
+		}
+		outputsSeen["count_distinct"] = struct{}{}
+		return newCountDistinctAggrConfig(label, useInputKey), nil
+	}
 	ignoreFirstSampleIntervalSecs := uint64(ignoreFirstSampleInterval.Seconds())
 
 	switch output {
@@ -775,6 +882,8 @@ func newOutputConfig(output string, outputsSeen map[string]struct{}, useSharedSt
 		return newRateAggrConfig(true), nil
 	case "rate_sum":
 		return newRateAggrConfig(false), nil
+	case "sketch_buckets":
+		return newSketchAggrConfig(nil), nil
 	case "stddev":
 		return newStddevAggrConfig(), nil
 	case "stdvar":
@@ -792,6 +901,61 @@ func newOutputConfig(output string, outputsSeen map[string]struct{}, useSharedSt
 	}
 }
 
+// checkCountDistinctLabel verifies whether the label passed to count_distinct(label) output can be counted
+// for the given by and without lists.
+func checkCountDistinctLabel(label string, by, without []string, aggregateOnlyByTime bool) error {
+	if aggregateOnlyByTime {
+		return fmt.Errorf("`count_distinct(%s)` output requires either `by` or `without` list", label)
+	}
+	if len(without) > 0 {
+		if !slices.Contains(without, label) {
+			return fmt.Errorf("`without: %s` list must contain %q label used in `count_distinct(%s)` output", without, label, label)
+		}
+		return nil
+	}
+	if slices.Contains(by, label) {
+		return fmt.Errorf("`by: %s` list cannot contain %q label used in `count_distinct(%s)` output", by, label, label)
+	}
+	return nil
+}
+
+// getOutputArg returns a single arg for the output in the form funcName(arg).
+func getOutputArg(funcName, output string) (string, error) {
+	if !strings.HasSuffix(output, ")") {
+		return "", fmt.Errorf("missing closing brace for `%s()` output", funcName)
+	}
+	argStr := strings.TrimSpace(output[len(funcName)+1 : len(output)-1])
+	if len(argStr) == 0 {
+		return "", fmt.Errorf("`%s()` must contain an arg", funcName)
+	}
+	return argStr, nil
+}
+
+// parseOutputPhis parses phis for the output in the form funcName(phi1, ..., phiN).
+func parseOutputPhis(funcName, output string) ([]float64, error) {
+	if !strings.HasSuffix(output, ")") {
+		return nil, fmt.Errorf("missing closing brace for `%s()` output", funcName)
+	}
+	argsStr := output[len(funcName)+1 : len(output)-1]
+	if len(argsStr) == 0 {
+		return nil, fmt.Errorf("`%s()` must contain at least one phi", funcName)
+	}
+	args := strings.Split(argsStr, ",")
+	phis := make([]float64, len(args))
+	for i, arg := range args {
+		arg = strings.TrimSpace(arg)
+		phi, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse phi=%q for %s(%s): %w", arg, funcName, argsStr, err)
+		}
+		if phi < 0 || phi > 1 {
+			return nil, fmt.Errorf("phi inside %s(%s) must be in the range [0..1]; got %v", funcName, argsStr, phi)
+		}
+		phis[i] = phi
+	}
+	return phis, nil
+}
+
 func (a *aggregator) runFlusher(pushFunc PushFunc, alignFlushToInterval, skipIncompleteFlush bool, ignoreFirstIntervals int) {
 	minTime := time.UnixMilli(a.minDeadline.Load())
 	flushTime := minTime.Add(a.interval)
@@ -884,6 +1048,15 @@ func (a *aggregator) runFlusher(pushFunc PushFunc, alignFlushToInterval, skipInc
 	}
 
 	a.dedupFlush(dedupTime, cs)
+	if a.stateFilePath != "" {
+		// Keep the state of stateful outputs, so it can be persisted and restored on the next start.
+		// Incomplete state is flushed before that, so it isn't counted twice after the restore.
+		if !skipIncompleteFlush && ignoreFirstIntervals <= 0 {
+			a.flush(pushFunc, flushTime, cs, false)
+		}
+		a.mustSaveState()
+		return
+	}
 	pf := pushFunc
 	if skipIncompleteFlush || ignoreFirstIntervals > 0 {
 		pf = nil
@@ -951,6 +1124,9 @@ var flushConcurrencyCh = make(chan struct{}, cgroup.AvailableCPUs())
 func (a *aggregator) MustStop() {
 	close(a.stopCh)
 	a.wg.Wait()
+
+	a.inputRelabeling.MustStop()
+	a.outputRelabeling.MustStop()
 }
 
 // Push pushes tss to a.
@@ -1280,6 +1456,32 @@ func (ctx *flushCtx) appendSeriesWithExtraLabel(key, suffix string, value float6
 	}
 }
 
+// appendSeriesWithInputLabels appends the series with output labels for the given key and input labels for the given inputKey.
+//
+// This is needed for outputs, which select individual input series such as topk and bottomk.
+func (ctx *flushCtx) appendSeriesWithInputLabels(key, inputKey, suffix string, value float64) {
+	labelsLen := len(ctx.labels)
+	samplesLen := len(ctx.samples)
+	ctx.labels = decompressLabels(ctx.labels, key)
+	ctx.labels = decompressInputLabels(ctx.labels, inputKey, ctx.ao.useInputKey)
+	if !ctx.a.keepMetricNames {
+		ctx.labels = addMetricSuffix(ctx.labels, labelsLen, ctx.a.suffix, suffix)
+	}
+	ctx.samples = append(ctx.samples, prompbmarshal.Sample{
+		Timestamp: ctx.flushTimestamp,
+		Value:     value,
+	})
+	ctx.tss = append(ctx.tss, prompbmarshal.TimeSeries{
+		Labels:  ctx.labels[labelsLen:],
+		Samples: ctx.samples[samplesLen:],
+	})
+
+	// Limit the maximum length of ctx.tss in order to limit memory usage.
+	if len(ctx.tss) >= 10_000 {
+		ctx.flushSeries()
+	}
+}
+
 func addMetricSuffix(labels []prompbmarshal.Label, offset int, firstSuffix, lastSuffix string) []prompbmarshal.Label {
 	src := labels[offset:]
 	for i := range src {
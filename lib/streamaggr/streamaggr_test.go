@@ -190,6 +190,74 @@ func TestAggregatorsFailure(t *testing.T) {
 - interval: 1m
   outputs: ["quantiles(0.5)", "quantiles(0.9)"]
 `)
+
+	// Invalid sketch()
+	f(`
+- interval: 1m
+  outputs: ["sketch()"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["sketch(1.5)"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["sketch(0.5)", "sketch(0.9)"]
+`)
+	f(`
+- interval: 1m
+  outputs: [sketch_buckets]
+  keep_metric_names: true
+`)
+
+	// Invalid topk() and bottomk()
+	f(`
+- interval: 1m
+  outputs: ["topk("]
+`)
+	f(`
+- interval: 1m
+  outputs: ["topk()"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["topk(foo)"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["bottomk(0)"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["topk(1)", "topk(2)"]
+`)
+
+	// Invalid count_distinct()
+	f(`
+- interval: 1m
+  outputs: ["count_distinct()"]
+  by: [job]
+`)
+	f(`
+- interval: 1m
+  outputs: ["count_distinct(instance)", "count_distinct(pod)"]
+  by: [job]
+`)
+	// count_distinct() label must be dropped from the output labels
+	f(`
+- interval: 1m
+  outputs: ["count_distinct(instance)"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["count_distinct(instance)"]
+  by: [instance]
+`)
+	f(`
+- interval: 1m
+  outputs: ["count_distinct(instance)"]
+  without: [pod]
+`)
 }
 
 func TestAggregatorsEqual(t *testing.T) {
@@ -0,0 +1,136 @@
+package streamaggr
+
+import (
+	"sort"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+)
+
+// topkAggrValue calculates output=topk(N) and output=bottomk(N), e.g. N input series with the biggest or the smallest values
+// seen during the aggregation interval.
+//
+// It keeps only up to N series per each output key, so the memory usage doesn't depend on the number of input series.
+type topkAggrValue struct {
+	// items contains up to N series with the best values seen during the current interval.
+	//
+	// items[0] contains the series with the worst value among the items.
+	items []topkItem
+}
+
+type topkItem struct {
+	key   string
+	value float64
+}
+
+func (av *topkAggrValue) pushSample(c aggrConfig, sample *pushSample, key string, _ int64) {
+	ac := c.(*topkAggrConfig)
+	items := av.items
+	for i := range items {
+		it := &items[i]
+		if it.key != key {
+			continue
+		}
+		// The series is already tracked. Update its value if the new value is better.
+		if ac.isBetter(sample.value, it.value) {
+			it.value = sample.value
+			ac.heapDown(items, i)
+		}
+		return
+	}
+	if len(items) < ac.k {
+		av.items = append(items, topkItem{
+			key:   bytesutil.InternString(key),
+			value: sample.value,
+		})
+		ac.heapUp(av.items, len(av.items)-1)
+		return
+	}
+	if !ac.isBetter(sample.value, items[0].value) {
+		return
+	}
+	// Replace the worst item with the new series.
+	items[0] = topkItem{
+		key:   bytesutil.InternString(key),
+		value: sample.value,
+	}
+	ac.heapDown(items, 0)
+}
+
+func (av *topkAggrValue) flush(c aggrConfig, ctx *flushCtx, key string, _ bool) {
+	ac := c.(*topkAggrConfig)
+	items := av.items
+	sort.Slice(items, func(i, j int) bool {
+		return ac.isBetter(items[i].value, items[j].value)
+	})
+	for _, it := range items {
+		ctx.appendSeriesWithInputLabels(key, it.key, ac.suffix, it.value)
+	}
+	clear(items)
+	av.items = items[:0]
+}
+
+func (*topkAggrValue) state() any {
+	return nil
+}
+
+func newTopkAggrConfig(k int, isBottom bool) aggrConfig {
+	suffix := "topk"
+	if isBottom {
+		suffix = "bottomk"
+	}
+	return &topkAggrConfig{
+		k:        k,
+		isBottom: isBottom,
+		suffix:   suffix,
+	}
+}
+
+type topkAggrConfig struct {
+	k        int
+	isBottom bool
+	suffix   string
+}
+
+func (*topkAggrConfig) getValue(_ any) aggrValue {
+	return &topkAggrValue{}
+}
+
+// isBetter returns true if a must be preferred over b.
+func (ac *topkAggrConfig) isBetter(a, b float64) bool {
+	if ac.isBottom {
+		return a < b
+	}
+	return a > b
+}
+
+// heapUp restores the heap property for items after the item at idx became worse.
+func (ac *topkAggrConfig) heapUp(items []topkItem, idx int) {
+	for idx > 0 {
+		parent := (idx - 1) / 2
+		if !ac.isBetter(items[parent].value, items[idx].value) {
+			return
+		}
+		items[parent], items[idx] = items[idx], items[parent]
+		idx = parent
+	}
+}
+
+// heapDown restores the heap property for items after the item at idx became better.
+func (ac *topkAggrConfig) heapDown(items []topkItem, idx int) {
+	for {
+		worst := idx
+		left := 2*idx + 1
+		right := left + 1
+		if left < len(items) && ac.isBetter(items[worst].value, items[left].value) {
+			worst = left
+		}
+		if right < len(items) && ac.isBetter(items[worst].value, items[right].value) {
+			worst = right
+		}
+		if worst == idx {
+			return
+		}
+		items[worst], items[idx] = items[idx], items[worst]
+		idx = worst
+	}
+}
@@ -1,9 +1,11 @@
 package streamaggr
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 )
 
@@ -74,6 +76,56 @@ func (av *totalAggrValue) state() any {
 	return av.shared
 }
 
+func (av *totalAggrValue) marshalState(dst []byte, ao *aggrOutputs) []byte {
+	dst = marshalFloat64(dst, av.total)
+	dst = marshalFloat64(dst, av.shared.total)
+	lvs := av.shared.lastValues
+	dst = encoding.MarshalVarUint64(dst, uint64(len(lvs)))
+	for k, lv := range lvs {
+		dst = ao.marshalInputKey(dst, k)
+		dst = marshalFloat64(dst, lv.value)
+		dst = encoding.MarshalInt64(dst, lv.timestamp)
+		dst = encoding.MarshalInt64(dst, lv.deleteDeadline)
+	}
+	return dst
+}
+
+func (av *totalAggrValue) unmarshalState(src []byte, ao *aggrOutputs) error {
+	var err error
+	if av.total, src, err = unmarshalFloat64(src); err != nil {
+		return fmt.Errorf("cannot unmarshal total: %w", err)
+	}
+	if av.shared.total, src, err = unmarshalFloat64(src); err != nil {
+		return fmt.Errorf("cannot unmarshal shared total: %w", err)
+	}
+	n, nSize := encoding.UnmarshalVarUint64(src)
+	if nSize <= 0 {
+		return fmt.Errorf("cannot unmarshal the number of last values")
+	}
+	src = src[nSize:]
+	for i := uint64(0); i < n; i++ {
+		var key string
+		var lv totalLastValue
+		if key, src, err = ao.unmarshalInputKey(src); err != nil {
+			return fmt.Errorf("cannot unmarshal key for last value #%d: %w", i, err)
+		}
+		if lv.value, src, err = unmarshalFloat64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal last value #%d: %w", i, err)
+		}
+		if lv.timestamp, src, err = unmarshalInt64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal timestamp for last value #%d: %w", i, err)
+		}
+		if lv.deleteDeadline, src, err = unmarshalInt64(src); err != nil {
+			return fmt.Errorf("cannot unmarshal deleteDeadline for last value #%d: %w", i, err)
+		}
+		av.shared.lastValues[key] = lv
+	}
+	if len(src) > 0 {
+		return fmt.Errorf("unexpected non-empty tail left; len(tail)=%d", len(src))
+	}
+	return nil
+}
+
 func newTotalAggrConfig(ignoreFirstSampleIntervalSecs uint64, resetTotalOnFlush, keepFirstSample bool) aggrConfig {
 	ignoreFirstSampleDeadline := fasttime.UnixTimestamp() + ignoreFirstSampleIntervalSecs
 	return &totalAggrConfig{
@@ -30,6 +30,9 @@ type API interface {
 	// TSDBStatus returns tsdb status for the given sq.
 	TSDBStatus(qt *querytracer.Tracer, sq *storage.SearchQuery, focusLabel string, topN int, deadline uint64) (*storage.TSDBStatus, error)
 
+	// NewSeriesStatus returns tsdb status for series matching the given sq, which were created on the sq time range.
+	NewSeriesStatus(qt *querytracer.Tracer, sq *storage.SearchQuery, focusLabel string, topN int, deadline uint64) (*storage.TSDBStatus, error)
+
 	// DeleteSeries deletes series matching the given sq.
 	DeleteSeries(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline uint64) (int, error)
 
@@ -46,6 +49,26 @@ type API interface {
 	ResetMetricNamesUsageStats(qt *querytracer.Tracer, deadline uint64) error
 }
 
+// RollupResultCacheAPI may be optionally implemented by API in order to serve rollup result cache entries
+// to other vmselect nodes, which share the cache with the API owner.
+type RollupResultCacheAPI interface {
+	// CheckRollupResultCacheAuthKey must return an error if the rollup result cache cannot be accessed with the given authKey.
+	//
+	// It is called before every rollup result cache request.
+	CheckRollupResultCacheAuthKey(authKey []byte) error
+
+	// GetRollupResultCacheEntry appends the value for the given key to dst and returns the result.
+	//
+	// isBig must be set to true for entries stored with isBig=true via SetRollupResultCacheEntry.
+	GetRollupResultCacheEntry(dst, key []byte, isBig bool) []byte
+
+	// SetRollupResultCacheEntry stores the given (key, value) entry in the rollup result cache.
+	SetRollupResultCacheEntry(key, value []byte, isBig bool)
+
+	// UpdateRollupResultCacheMetainfo applies the given update to the metainfo entry stored under the given key.
+	UpdateRollupResultCacheMetainfo(key, update []byte) error
+}
+
 // BlockIterator must iterate through series blocks found by VMSelect.InitSearch.
 //
 // MustClose must be called in order to free up allocated resources when BlockIterator is no longer needed.
@@ -63,10 +63,15 @@ type Server struct {
 	tagValueSuffixesRequests    *metrics.Counter
 	seriesCountRequests         *metrics.Counter
 	tsdbStatusRequests          *metrics.Counter
+	newSeriesStatusRequests     *metrics.Counter
 	searchMetricNamesRequests   *metrics.Counter
 	searchRequests              *metrics.Counter
 	tenantsRequests             *metrics.Counter
 
+	rollupResultCacheGetRequests            *metrics.Counter
+	rollupResultCachePutRequests            *metrics.Counter
+	rollupResultCacheUpdateMetainfoRequests *metrics.Counter
+
 	metricBlocksRead *metrics.Counter
 	metricRowsRead   *metrics.Counter
 }
@@ -133,10 +138,15 @@ func NewServer(addr string, api API, limits Limits, disableResponseCompression b
 		tagValueSuffixesRequests:    metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="tagValueSuffixes",addr=%q}`, addr)),
 		seriesCountRequests:         metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="seriesSount",addr=%q}`, addr)),
 		tsdbStatusRequests:          metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="tsdbStatus",addr=%q}`, addr)),
+		newSeriesStatusRequests:     metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="newSeriesStatus",addr=%q}`, addr)),
 		searchMetricNamesRequests:   metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="searchMetricNames",addr=%q}`, addr)),
 		searchRequests:              metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="search",addr=%q}`, addr)),
 		tenantsRequests:             metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="tenants",addr=%q}`, addr)),
 
+		rollupResultCacheGetRequests:            metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="rollupResultCacheGet",addr=%q}`, addr)),
+		rollupResultCachePutRequests:            metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="rollupResultCachePut",addr=%q}`, addr)),
+		rollupResultCacheUpdateMetainfoRequests: metrics.NewCounter(fmt.Sprintf(`vm_vmselect_rpc_requests_total{action="rollupResultCacheUpdateMetainfo",addr=%q}`, addr)),
+
 		metricBlocksRead: metrics.NewCounter(fmt.Sprintf(`vm_vmselect_metric_blocks_read_total{addr=%q}`, addr)),
 		metricRowsRead:   metrics.NewCounter(fmt.Sprintf(`vm_vmselect_metric_rows_read_total{addr=%q}`, addr)),
 	}
@@ -584,6 +594,8 @@ func (s *Server) processRPC(ctx *vmselectRequestCtx, rpcName string) error {
 		return s.processSeriesCount(ctx)
 	case "tsdbStatus_v6":
 		return s.processTSDBStatus(ctx)
+	case "newSeriesStatus_v1":
+		return s.processNewSeriesStatus(ctx)
 	case "deleteSeries_v5":
 		return s.processDeleteSeries(ctx)
 	case "registerMetricNames_v3":
@@ -594,6 +606,12 @@ func (s *Server) processRPC(ctx *vmselectRequestCtx, rpcName string) error {
 		return s.processMetricNamesUsageStats(ctx)
 	case "resetMetricNamesStats_v1":
 		return s.processResetMetricUsageStats(ctx)
+	case "rollupResultCacheGet_v2":
+		return s.processRollupResultCacheGet(ctx)
+	case "rollupResultCachePut_v2":
+		return s.processRollupResultCachePut(ctx)
+	case "rollupResultCacheUpdateMetainfo_v1":
+		return s.processRollupResultCacheUpdateMetainfo(ctx)
 	default:
 		return fmt.Errorf("unsupported rpcName: %q", rpcName)
 	}
@@ -913,6 +931,42 @@ func (s *Server) processTSDBStatus(ctx *vmselectRequestCtx) error {
 	return writeTSDBStatus(ctx, status)
 }
 
+func (s *Server) processNewSeriesStatus(ctx *vmselectRequestCtx) error {
+	s.newSeriesStatusRequests.Inc()
+
+	// Read request
+	if err := ctx.readSearchQuery(); err != nil {
+		return err
+	}
+	if err := ctx.readDataBufBytes(maxLabelValueSize); err != nil {
+		return fmt.Errorf("cannot read focusLabel: %w", err)
+	}
+	focusLabel := string(ctx.dataBuf)
+	topN, err := ctx.readUint32()
+	if err != nil {
+		return fmt.Errorf("cannot read topN: %w", err)
+	}
+
+	if err := s.beginConcurrentRequest(ctx); err != nil {
+		return ctx.writeErrorMessage(err)
+	}
+	defer s.endConcurrentRequest()
+
+	// Execute the request
+	status, err := s.api.NewSeriesStatus(ctx.qt, &ctx.sq, focusLabel, int(topN), ctx.deadline)
+	if err != nil {
+		return ctx.writeErrorMessage(err)
+	}
+
+	// Send an empty error message to vmselect.
+	if err := ctx.writeString(""); err != nil {
+		return fmt.Errorf("cannot send empty error message: %w", err)
+	}
+
+	// Send status to vmselect.
+	return writeTSDBStatus(ctx, status)
+}
+
 func (s *Server) processTenants(ctx *vmselectRequestCtx) error {
 	s.tenantsRequests.Inc()
 
@@ -954,6 +1008,128 @@ func (s *Server) processTenants(ctx *vmselectRequestCtx) error {
 	return nil
 }
 
+const (
+	maxRollupResultCacheAuthKeySize = 1024
+	maxRollupResultCacheKeySize     = 1024 * 1024
+
+	// MaxRollupResultCacheValueSize is the maximum size of a single rollup result cache entry, which can be transferred between vmselect nodes.
+	MaxRollupResultCacheValueSize = 512 * 1024 * 1024
+
+	maxRollupResultCacheMetainfoUpdateSize = 1024
+)
+
+// readRollupResultCacheRequestAuth reads authKey for the rollup result cache request and verifies it with api.
+//
+// It returns false if the request mustn't be processed. In this case the error message is already sent to vmselect.
+func (s *Server) readRollupResultCacheRequestAuth(ctx *vmselectRequestCtx) (RollupResultCacheAPI, bool, error) {
+	if err := ctx.readDataBufBytes(maxRollupResultCacheAuthKeySize); err != nil {
+		return nil, false, fmt.Errorf("cannot read authKey: %w", err)
+	}
+	api, ok := s.api.(RollupResultCacheAPI)
+	if !ok {
+		return nil, false, ctx.writeErrorMessage(fmt.Errorf("rollup result cache isn't supported by the server"))
+	}
+	if err := api.CheckRollupResultCacheAuthKey(ctx.dataBuf); err != nil {
+		return nil, false, ctx.writeErrorMessage(err)
+	}
+	return api, true, nil
+}
+
+func (s *Server) processRollupResultCacheGet(ctx *vmselectRequestCtx) error {
+	s.rollupResultCacheGetRequests.Inc()
+
+	// Read request
+	api, ok, err := s.readRollupResultCacheRequestAuth(ctx)
+	if !ok {
+		return err
+	}
+	if err := ctx.readDataBufBytes(maxRollupResultCacheKeySize); err != nil {
+		return fmt.Errorf("cannot read key: %w", err)
+	}
+	key := append([]byte{}, ctx.dataBuf...)
+	isBig, err := ctx.readBool()
+	if err != nil {
+		return fmt.Errorf("cannot read isBig: %w", err)
+	}
+
+	// Execute the request.
+	// Do not limit the concurrency for cache requests, since they are cheap
+	// and they shouldn't wait for heavy queries.
+	value := api.GetRollupResultCacheEntry(nil, key, isBig)
+
+	// Send an empty error message to vmselect.
+	if err := ctx.writeString(""); err != nil {
+		return fmt.Errorf("cannot send empty error message: %w", err)
+	}
+
+	// Send the value to vmselect. An empty value means the entry is missing in the cache.
+	ctx.dataBuf = append(ctx.dataBuf[:0], value...)
+	if err := ctx.writeDataBufBytes(); err != nil {
+		return fmt.Errorf("cannot send value: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) processRollupResultCachePut(ctx *vmselectRequestCtx) error {
+	s.rollupResultCachePutRequests.Inc()
+
+	// Read request
+	api, ok, err := s.readRollupResultCacheRequestAuth(ctx)
+	if !ok {
+		return err
+	}
+	if err := ctx.readDataBufBytes(maxRollupResultCacheKeySize); err != nil {
+		return fmt.Errorf("cannot read key: %w", err)
+	}
+	key := append([]byte{}, ctx.dataBuf...)
+	isBig, err := ctx.readBool()
+	if err != nil {
+		return fmt.Errorf("cannot read isBig: %w", err)
+	}
+	if err := ctx.readDataBufBytes(MaxRollupResultCacheValueSize); err != nil {
+		return fmt.Errorf("cannot read value: %w", err)
+	}
+	value := ctx.dataBuf
+
+	// Execute the request.
+	api.SetRollupResultCacheEntry(key, value, isBig)
+
+	// Send an empty error message to vmselect.
+	if err := ctx.writeString(""); err != nil {
+		return fmt.Errorf("cannot send empty error message: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) processRollupResultCacheUpdateMetainfo(ctx *vmselectRequestCtx) error {
+	s.rollupResultCacheUpdateMetainfoRequests.Inc()
+
+	// Read request
+	api, ok, err := s.readRollupResultCacheRequestAuth(ctx)
+	if !ok {
+		return err
+	}
+	if err := ctx.readDataBufBytes(maxRollupResultCacheKeySize); err != nil {
+		return fmt.Errorf("cannot read key: %w", err)
+	}
+	key := append([]byte{}, ctx.dataBuf...)
+	if err := ctx.readDataBufBytes(maxRollupResultCacheMetainfoUpdateSize); err != nil {
+		return fmt.Errorf("cannot read metainfo update: %w", err)
+	}
+	update := ctx.dataBuf
+
+	// Execute the request.
+	if err := api.UpdateRollupResultCacheMetainfo(key, update); err != nil {
+		return ctx.writeErrorMessage(err)
+	}
+
+	// Send an empty error message to vmselect.
+	if err := ctx.writeString(""); err != nil {
+		return fmt.Errorf("cannot send empty error message: %w", err)
+	}
+	return nil
+}
+
 func writeTSDBStatus(ctx *vmselectRequestCtx, status *storage.TSDBStatus) error {
 	if err := ctx.writeUint64(status.TotalSeries); err != nil {
 		return fmt.Errorf("cannot write totalSeries to vmselect: %w", err)
@@ -67,10 +67,7 @@ func (r *Reader) Read(p []byte) (int, error) {
 	if !r.increasedConcurrency {
 		if !incConcurrency() {
 			err = &httpserver.ErrorWithStatusCode{
-				Err: fmt.Errorf("cannot process insert request for %.3f seconds because %d concurrent insert requests are executed. "+
-					"Possible solutions: to reduce workload; to increase compute resources at the server; "+
-					"to increase -insert.maxQueueDuration; to increase -maxConcurrentInserts",
-					maxQueueDuration.Seconds(), *maxConcurrentInserts),
+				Err:        newConcurrencyLimitError(),
 				StatusCode: http.StatusServiceUnavailable,
 			}
 			return 0, err
@@ -95,6 +92,35 @@ func (r *Reader) DecConcurrency() {
 	}
 }
 
+// IncConcurrency increases the concurrency for insert requests, which aren't read via Reader.
+//
+// It waits for up to -insert.maxQueueDuration if -maxConcurrentInserts concurrent insert requests are executed
+// and returns an error if the concurrency cannot be increased during this time.
+// DecConcurrency must be called after the request is processed if IncConcurrency returns nil error.
+func IncConcurrency() error {
+	if !incConcurrency() {
+		return newConcurrencyLimitError()
+	}
+	return nil
+}
+
+// DecConcurrency decreases the concurrency increased by IncConcurrency.
+func DecConcurrency() {
+	decConcurrency()
+}
+
+// MaxConcurrentInserts returns the maximum number of concurrent insert requests set via -maxConcurrentInserts.
+func MaxConcurrentInserts() int {
+	return *maxConcurrentInserts
+}
+
+func newConcurrencyLimitError() error {
+	return fmt.Errorf("cannot process insert request for %.3f seconds because %d concurrent insert requests are executed. "+
+		"Possible solutions: to reduce workload; to increase compute resources at the server; "+
+		"to increase -insert.maxQueueDuration; to increase -maxConcurrentInserts",
+		maxQueueDuration.Seconds(), *maxConcurrentInserts)
+}
+
 func initConcurrencyLimitCh() {
 	concurrencyLimitCh = make(chan struct{}, *maxConcurrentInserts)
 }